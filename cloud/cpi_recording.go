@@ -0,0 +1,255 @@
+package cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const redactedValue = "<redacted>"
+
+// sensitiveKeys are matched against the lower-cased keys of request
+// arguments; any key containing one of them has its value redacted.
+var sensitiveKeys = []string{
+	"password",
+	"secret",
+	"private_key",
+	"token",
+	"credential",
+	"access_key",
+	"api_key",
+	"json_key",
+}
+
+// urlPasswordPattern matches the password in URLs such as the agent's mbus URL.
+var urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+
+// CPIRecording is a single CPI request and the response the CPI gave to it.
+// Error is set when the CPI could not be run at all. The log of the CPI is
+// not kept since CPIs may log their requests with credentials.
+type CPIRecording struct {
+	Request  CmdInput  `json:"request"`
+	Response CmdOutput `json:"response"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type recordingCPICmdRunner struct {
+	cpiCmdRunner CPICmdRunner
+	fs           boshsys.FileSystem
+	dir          string
+	logger       boshlog.Logger
+	logTag       string
+}
+
+// NewRecordingCPICmdRunner runs CPI requests with cpiCmdRunner and writes each
+// request and response to its own numbered file in dir. Values that look like
+// credentials are redacted before they are written.
+func NewRecordingCPICmdRunner(cpiCmdRunner CPICmdRunner, fs boshsys.FileSystem, dir string, logger boshlog.Logger) CPICmdRunner {
+	return &recordingCPICmdRunner{
+		cpiCmdRunner: cpiCmdRunner,
+		fs:           fs,
+		dir:          dir,
+		logger:       logger,
+		logTag:       "recordingCPICmdRunner",
+	}
+}
+
+func (r *recordingCPICmdRunner) Run(context CmdContext, method string, apiVersion int, args ...interface{}) (CmdOutput, error) {
+	cmdOutput, runErr := r.cpiCmdRunner.Run(context, method, apiVersion, args...)
+
+	recording := CPIRecording{
+		Request: CmdInput{
+			Method:     method,
+			Arguments:  redactArguments(args),
+			Context:    context,
+			ApiVersion: apiVersion,
+		},
+		Response: CmdOutput{
+			Result: redactValue(cmdOutput.Result),
+			Error:  cmdOutput.Error,
+		},
+	}
+	if runErr != nil {
+		recording.Error = redactString(runErr.Error())
+	}
+	if recording.Response.Error != nil {
+		cmdError := *recording.Response.Error
+		cmdError.Message = redactString(cmdError.Message)
+		recording.Response.Error = &cmdError
+	}
+
+	err := r.write(method, recording)
+	if err != nil {
+		// Failing to record must not change the outcome of the CPI call
+		r.logger.Error(r.logTag, "Recording CPI method '%s': %s", method, err.Error())
+	}
+
+	return cmdOutput, runErr
+}
+
+func (r *recordingCPICmdRunner) write(method string, recording CPIRecording) error {
+	existing, err := r.fs.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing CPI recordings in '%s'", r.dir)
+	}
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(recording)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling CPI recording")
+	}
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%04d-%s.json", len(existing)+1, method))
+	err = r.fs.WriteFile(path, buffer.Bytes())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing CPI recording '%s'", path)
+	}
+
+	return nil
+}
+
+type replayCPICmdRunner struct {
+	fs     boshsys.FileSystem
+	dir    string
+	logger boshlog.Logger
+	logTag string
+
+	paths    []string
+	position int
+}
+
+// NewReplayCPICmdRunner answers CPI requests with the responses recorded in
+// dir, in the order they were recorded, without running a CPI. A request for
+// a different method than the next recorded one fails the replay. Arguments
+// are only compared to log differences since they include IDs that are
+// generated again on every run.
+func NewReplayCPICmdRunner(fs boshsys.FileSystem, dir string, logger boshlog.Logger) CPICmdRunner {
+	return &replayCPICmdRunner{
+		fs:     fs,
+		dir:    dir,
+		logger: logger,
+		logTag: "replayCPICmdRunner",
+	}
+}
+
+func (r *replayCPICmdRunner) Run(context CmdContext, method string, apiVersion int, args ...interface{}) (CmdOutput, error) {
+	if r.paths == nil {
+		paths, err := r.fs.Glob(filepath.Join(r.dir, "*.json"))
+		if err != nil {
+			return CmdOutput{}, bosherr.WrapErrorf(err, "Listing CPI recordings in '%s'", r.dir)
+		}
+		sort.Strings(paths)
+		r.paths = paths
+	}
+
+	if r.position >= len(r.paths) {
+		return CmdOutput{}, bosherr.Errorf("Replaying CPI method '%s': all %d recorded CPI calls have been replayed", method, len(r.paths))
+	}
+
+	path := r.paths[r.position]
+	r.position++
+
+	bytes, err := r.fs.ReadFile(path)
+	if err != nil {
+		return CmdOutput{}, bosherr.WrapErrorf(err, "Reading CPI recording '%s'", path)
+	}
+
+	var recording CPIRecording
+	err = json.Unmarshal(bytes, &recording)
+	if err != nil {
+		return CmdOutput{}, bosherr.WrapErrorf(err, "Unmarshalling CPI recording '%s'", path)
+	}
+
+	if recording.Request.Method != method {
+		return CmdOutput{}, bosherr.Errorf("Replaying CPI method '%s': recording '%s' is for method '%s'", method, path, recording.Request.Method)
+	}
+
+	r.logger.Debug(r.logTag, "Replaying CPI method '%s' from '%s'", method, path)
+
+	if !reflect.DeepEqual(redactArguments(args), recording.Request.Arguments) {
+		r.logger.Warn(r.logTag, "Replaying CPI method '%s': arguments differ from recording '%s'", method, path)
+	}
+
+	if recording.Error != "" {
+		return CmdOutput{}, bosherr.Error(recording.Error)
+	}
+
+	return recording.Response, nil
+}
+
+func redactArguments(args []interface{}) []interface{} {
+	redacted, ok := redactValue(args).([]interface{})
+	if !ok {
+		return []interface{}{redactedValue}
+	}
+	return redacted
+}
+
+// redactValue returns a redacted copy of value as it would be unmarshalled
+// from JSON.
+func redactValue(value interface{}) interface{} {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return redactedValue
+	}
+
+	var copied interface{}
+	err = json.Unmarshal(bytes, &copied)
+	if err != nil {
+		return redactedValue
+	}
+
+	return redact(copied)
+}
+
+func redact(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, nestedValue := range typedValue {
+			if isSensitiveKey(key) {
+				typedValue[key] = redactedValue
+			} else {
+				typedValue[key] = redact(nestedValue)
+			}
+		}
+	case []interface{}:
+		for i, nestedValue := range typedValue {
+			typedValue[i] = redact(nestedValue)
+		}
+	case string:
+		return redactString(typedValue)
+	}
+
+	return value
+}
+
+func redactString(value string) string {
+	if strings.Contains(value, "PRIVATE KEY-----") {
+		return redactedValue
+	}
+	return urlPasswordPattern.ReplaceAllString(value, "${1}"+redactedValue+"@")
+}
+
+func isSensitiveKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(lowerKey, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,7 @@ type Factory interface {
 type factory struct {
 	fs        boshsys.FileSystem
 	cmdRunner boshsys.CmdRunner
+	recordDir string
 	logger    boshlog.Logger
 }
 
@@ -29,6 +30,22 @@ func NewFactory(
 	}
 }
 
+// NewRecordingFactory returns a Factory whose clouds record every CPI
+// request and response in recordDir.
+func NewRecordingFactory(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	recordDir string,
+	logger boshlog.Logger,
+) Factory {
+	return &factory{
+		fs:        fs,
+		cmdRunner: cmdRunner,
+		recordDir: recordDir,
+		logger:    logger,
+	}
+}
+
 func (f *factory) NewCloud(installation biinstall.Installation, directorID string, stemcellApiVersion int) (Cloud, error) {
 	cpiJob := installation.Job()
 	target := installation.Target()
@@ -44,5 +61,31 @@ func (f *factory) NewCloud(installation biinstall.Installation, directorID strin
 	}
 
 	cpiCmdRunner := NewCPICmdRunner(f.cmdRunner, cpi, f.logger)
+	if f.recordDir != "" {
+		cpiCmdRunner = NewRecordingCPICmdRunner(cpiCmdRunner, f.fs, f.recordDir, f.logger)
+	}
+
 	return NewCloud(cpiCmdRunner, directorID, stemcellApiVersion, f.logger), nil
 }
+
+type replayFactory struct {
+	cpiCmdRunner CPICmdRunner
+	logger       boshlog.Logger
+}
+
+// NewReplayFactory returns a Factory whose clouds answer from the CPI
+// responses recorded in replayDir instead of running the CPI. The clouds share
+// one replay, so responses are used up in order across all of them.
+//
+// Only the CPI is replayed: create-env still installs the CPI release and
+// talks to the agent of the VM, which therefore has to be reachable.
+func NewReplayFactory(fs boshsys.FileSystem, replayDir string, logger boshlog.Logger) Factory {
+	return &replayFactory{
+		cpiCmdRunner: NewReplayCPICmdRunner(fs, replayDir, logger),
+		logger:       logger,
+	}
+}
+
+func (f *replayFactory) NewCloud(installation biinstall.Installation, directorID string, stemcellApiVersion int) (Cloud, error) {
+	return NewCloud(f.cpiCmdRunner, directorID, stemcellApiVersion, f.logger), nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"net"
+	"net/url"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
+	biinstance "github.com/cloudfoundry/bosh-cli/v7/deployment/instance"
+)
+
+// newAgentProvider returns an AgentProvider that reaches the agents of
+// non-bootstrap instances with the credentials and port of the installation
+// mbus URL. When blobstoreFactory is nil no blobstore client is created.
+func newAgentProvider(
+	agentClientFactory bihttpagent.AgentClientFactory,
+	blobstoreFactory biblobstore.Factory,
+	directorID string,
+	installationMbus string,
+	caCert string,
+) biinstance.AgentProvider {
+	return func(address string) (biagentclient.AgentClient, biblobstore.Blobstore, error) {
+		mbusURL, err := mbusURLForAddress(installationMbus, address)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		agentClient, err := agentClientFactory.NewAgentClient(directorID, mbusURL, caCert)
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Creating agent client")
+		}
+
+		if blobstoreFactory == nil {
+			return agentClient, nil, nil
+		}
+
+		blobstore, err := blobstoreFactory.Create(mbusURL, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Creating blobstore client")
+		}
+
+		return agentClient, blobstore, nil
+	}
+}
+
+func mbusURLForAddress(installationMbus, address string) (string, error) {
+	parsedURL, err := url.Parse(installationMbus)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Parsing mbus url")
+	}
+
+	if port := parsedURL.Port(); port != "" {
+		parsedURL.Host = net.JoinHostPort(address, port)
+	} else {
+		parsedURL.Host = address
+	}
+
+	return parsedURL.String(), nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type AuditBlobstoreCmd struct {
+	auditor boshreldir.BlobstoreAuditor
+	ui      boshui.UI
+}
+
+func NewAuditBlobstoreCmd(auditor boshreldir.BlobstoreAuditor, ui boshui.UI) AuditBlobstoreCmd {
+	return AuditBlobstoreCmd{auditor: auditor, ui: ui}
+}
+
+func (c AuditBlobstoreCmd) Run(opts AuditBlobstoreOpts) error {
+	// Blobs only referenced by older commits would otherwise look orphaned
+	if opts.DeleteOrphans && !opts.History {
+		return bosherr.Error("Expected '--history' to be set when deleting orphaned blobs")
+	}
+
+	audit, err := c.auditor.Audit(opts.History)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Auditing blobstore")
+	}
+
+	table := boshtbl.Table{
+		Content: "blobs",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Blobstore ID"),
+			boshtbl.NewHeader("Digest"),
+			boshtbl.NewHeader("Referenced By"),
+			boshtbl.NewHeader("Status"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0, Asc: true},
+		},
+	}
+
+	var problems int
+
+	for _, blob := range audit.Blobs {
+		var status boshtbl.Value = boshtbl.NewValueString("ok")
+
+		if blob.Err != nil {
+			status = boshtbl.NewValueError(blob.Err)
+			problems++
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(blob.BlobstoreID),
+			boshtbl.NewValueString(blob.SHA1),
+			boshtbl.NewValueStrings(blob.ReferencedBy),
+			status,
+		})
+	}
+
+	for _, blobID := range audit.Orphans {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(blobID),
+			boshtbl.NewValueString(""),
+			boshtbl.NewValueStrings(nil),
+			boshtbl.NewValueString("orphaned"),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	c.ui.PrintLinef("Verified %d blob(s), %d missing or not matching their digest", len(audit.Blobs), problems)
+
+	if !audit.Listable {
+		c.ui.PrintLinef("Skipped finding orphaned blobs since blobstore provider cannot list blobs")
+	} else {
+		c.ui.PrintLinef("Found %d orphaned blob(s) in '%s'", len(audit.Orphans), audit.ListScope)
+	}
+
+	if problems > 0 {
+		return bosherr.Errorf("Expected all referenced blobs to be intact but %d are missing or do not match their digest", problems)
+	}
+
+	if !opts.DeleteOrphans {
+		return nil
+	}
+
+	if !audit.Listable {
+		return bosherr.Error("Expected blobstore provider to support listing blobs to delete orphaned blobs")
+	}
+
+	// Unreferenced blobs in a shared bucket may belong to other releases
+	if audit.SharedListScope && !opts.Dedicated {
+		return bosherr.Errorf("Expected blobstore to be scoped to this release to delete orphaned blobs "+
+			"but '%s' may hold blobs of other releases; configure a blobstore folder or set '--dedicated-blobstore'", audit.ListScope)
+	}
+
+	if len(audit.Orphans) == 0 {
+		return nil
+	}
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	err = c.auditor.DeleteOrphans(audit.Orphans)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintLinef("Deleted %d orphaned blob(s)", len(audit.Orphans))
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("AuditBlobstoreCmd", func() {
+	var (
+		auditor *fakereldir.FakeBlobstoreAuditor
+		ui      *fakeui.FakeUI
+		command AuditBlobstoreCmd
+	)
+
+	BeforeEach(func() {
+		auditor = &fakereldir.FakeBlobstoreAuditor{}
+		ui = &fakeui.FakeUI{}
+		command = NewAuditBlobstoreCmd(auditor, ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts AuditBlobstoreOpts
+		)
+
+		BeforeEach(func() {
+			opts = AuditBlobstoreOpts{}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("prints audited blobs and orphans", func() {
+			auditor.AuditReturns(boshreldir.BlobstoreAudit{
+				Blobs: []boshreldir.AuditedBlob{
+					{BlobstoreID: "blob1", SHA1: "sha1", ReferencedBy: []string{"file1.tgz"}},
+				},
+				Listable:  true,
+				ListScope: "s3://bucket/folder/",
+				Orphans:   []string{"orphan1"},
+			}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(auditor.AuditArgsForCall(0)).To(BeFalse())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "blobs",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Blobstore ID"),
+					boshtbl.NewHeader("Digest"),
+					boshtbl.NewHeader("Referenced By"),
+					boshtbl.NewHeader("Status"),
+				},
+
+				SortBy: []boshtbl.ColumnSort{
+					{Column: 0, Asc: true},
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("blob1"),
+						boshtbl.NewValueString("sha1"),
+						boshtbl.NewValueStrings([]string{"file1.tgz"}),
+						boshtbl.NewValueString("ok"),
+					},
+					{
+						boshtbl.NewValueString("orphan1"),
+						boshtbl.NewValueString(""),
+						boshtbl.NewValueStrings(nil),
+						boshtbl.NewValueString("orphaned"),
+					},
+				},
+			}))
+
+			Expect(ui.Said).To(Equal([]string{
+				"Verified 1 blob(s), 0 missing or not matching their digest",
+				"Found 1 orphaned blob(s) in 's3://bucket/folder/'",
+			}))
+
+			Expect(auditor.DeleteOrphansCallCount()).To(Equal(0))
+		})
+
+		It("notes that orphans cannot be found if blobstore cannot list blobs", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement(
+				"Skipped finding orphaned blobs since blobstore provider cannot list blobs"))
+		})
+
+		It("returns error if some blobs are missing or do not match their digest", func() {
+			auditor.AuditReturns(boshreldir.BlobstoreAudit{
+				Blobs: []boshreldir.AuditedBlob{
+					{BlobstoreID: "blob1", Err: errors.New("fake-digest-err")},
+					{BlobstoreID: "blob2"},
+				},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("1 are missing or do not match their digest"))
+
+			Expect(ui.Table.Rows[0][3]).To(Equal(boshtbl.NewValueError(errors.New("fake-digest-err"))))
+		})
+
+		It("returns error if audit fails", func() {
+			auditor.AuditReturns(boshreldir.BlobstoreAudit{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		Context("when deleting orphans", func() {
+			BeforeEach(func() {
+				opts.DeleteOrphans = true
+				opts.History = true
+
+				auditor.AuditReturns(boshreldir.BlobstoreAudit{
+					Listable: true,
+					Orphans:  []string{"orphan1", "orphan2"},
+				}, nil)
+			})
+
+			It("deletes orphans after confirmation", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(auditor.AuditArgsForCall(0)).To(BeTrue())
+
+				Expect(ui.AskedConfirmationCalled).To(BeTrue())
+				Expect(auditor.DeleteOrphansArgsForCall(0)).To(Equal([]string{"orphan1", "orphan2"}))
+
+				Expect(ui.Said).To(ContainElement("Deleted 2 orphaned blob(s)"))
+			})
+
+			It("does not delete orphans if confirmation is rejected", func() {
+				ui.AskedConfirmationErr = errors.New("stop")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Expect(auditor.DeleteOrphansCallCount()).To(Equal(0))
+			})
+
+			It("requires history so that blobs of older releases are not deleted", func() {
+				opts.History = false
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected '--history'"))
+
+				Expect(auditor.AuditCallCount()).To(Equal(0))
+			})
+
+			It("does not delete orphans if some referenced blobs have problems", func() {
+				auditor.AuditReturns(boshreldir.BlobstoreAudit{
+					Blobs:    []boshreldir.AuditedBlob{{BlobstoreID: "blob1", Err: errors.New("fake-err")}},
+					Listable: true,
+					Orphans:  []string{"orphan1"},
+				}, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+
+				Expect(auditor.DeleteOrphansCallCount()).To(Equal(0))
+			})
+
+			It("returns error if blobstore cannot list blobs", func() {
+				auditor.AuditReturns(boshreldir.BlobstoreAudit{}, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("support listing blobs"))
+			})
+
+			It("does not delete orphans if blobstore may hold blobs of other releases", func() {
+				auditor.AuditReturns(boshreldir.BlobstoreAudit{
+					Listable:        true,
+					ListScope:       "s3://bucket/",
+					SharedListScope: true,
+					Orphans:         []string{"orphan1"},
+				}, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("'s3://bucket/' may hold blobs of other releases"))
+				Expect(err.Error()).To(ContainSubstring("--dedicated-blobstore"))
+
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+				Expect(auditor.DeleteOrphansCallCount()).To(Equal(0))
+			})
+
+			It("deletes orphans from blobstore that may be shared if it is confirmed to be dedicated", func() {
+				opts.Dedicated = true
+
+				auditor.AuditReturns(boshreldir.BlobstoreAudit{
+					Listable:        true,
+					ListScope:       "s3://bucket/",
+					SharedListScope: true,
+					Orphans:         []string{"orphan1"},
+				}, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(auditor.DeleteOrphansArgsForCall(0)).To(Equal([]string{"orphan1"}))
+			})
+
+			It("returns error if deleting fails", func() {
+				auditor.DeleteOrphansReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+		})
+	})
+})
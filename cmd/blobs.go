@@ -1,21 +1,28 @@
 package cmd
 
 import (
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
 	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
 	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
 	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type BlobsCmd struct {
-	blobsDir boshreldir.BlobsDir
-	ui       boshui.UI
+	blobsDir  boshreldir.BlobsDir
+	blobCache boshreldir.BlobCache
+	ui        boshui.UI
 }
 
-func NewBlobsCmd(blobsDir boshreldir.BlobsDir, ui boshui.UI) BlobsCmd {
-	return BlobsCmd{blobsDir: blobsDir, ui: ui}
+func NewBlobsCmd(blobsDir boshreldir.BlobsDir, blobCache boshreldir.BlobCache, ui boshui.UI) BlobsCmd {
+	return BlobsCmd{blobsDir: blobsDir, blobCache: blobCache, ui: ui}
 }
 
-func (c BlobsCmd) Run() error {
+func (c BlobsCmd) Run(opts BlobsOpts) error {
+	if opts.CacheStats {
+		return c.printCacheStats()
+	}
+
 	blobs, err := c.blobsDir.Blobs()
 	if err != nil {
 		return err
@@ -55,3 +62,44 @@ func (c BlobsCmd) Run() error {
 
 	return nil
 }
+
+func (c BlobsCmd) printCacheStats() error {
+	if c.blobCache == nil {
+		return bosherr.Error("Release blobs cache is disabled")
+	}
+
+	stats, err := c.blobCache.Stats()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "blobs cache",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Path"),
+			boshtbl.NewHeader("Blobs"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Max Size"),
+			boshtbl.NewHeader("Hits"),
+			boshtbl.NewHeader("Misses"),
+		},
+
+		Rows: [][]boshtbl.Value{
+			{
+				boshtbl.NewValueString(stats.Path),
+				boshtbl.NewValueInt(stats.Blobs),
+				boshtbl.NewValueBytes(uint64(stats.Size)),
+				boshtbl.NewValueBytes(uint64(stats.MaxSize)),
+				boshtbl.NewValueInt(stats.Hits),
+				boshtbl.NewValueInt(stats.Misses),
+			},
+		},
+
+		Transpose: true,
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
 	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
 	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
 	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
@@ -15,19 +16,23 @@ import (
 
 var _ = Describe("BlobsCmd", func() {
 	var (
-		blobsDir *fakereldir.FakeBlobsDir
-		ui       *fakeui.FakeUI
-		command  BlobsCmd
+		blobsDir  *fakereldir.FakeBlobsDir
+		blobCache *fakereldir.FakeBlobCache
+		ui        *fakeui.FakeUI
+		command   BlobsCmd
+		opts      BlobsOpts
 	)
 
 	BeforeEach(func() {
 		blobsDir = &fakereldir.FakeBlobsDir{}
+		blobCache = &fakereldir.FakeBlobCache{}
 		ui = &fakeui.FakeUI{}
-		command = NewBlobsCmd(blobsDir, ui)
+		command = NewBlobsCmd(blobsDir, blobCache, ui)
+		opts = BlobsOpts{}
 	})
 
 	Describe("Run", func() {
-		act := func() error { return command.Run() }
+		act := func() error { return command.Run(opts) }
 
 		It("lists blobs", func() {
 			blobs := []boshreldir.Blob{
@@ -88,5 +93,69 @@ var _ = Describe("BlobsCmd", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
+
+		Context("when showing cache stats", func() {
+			BeforeEach(func() {
+				opts.CacheStats = true
+			})
+
+			It("shows statistics of the blobs cache instead of blobs", func() {
+				blobCache.StatsReturns(boshreldir.BlobCacheStats{
+					Path:    "/cache",
+					Blobs:   2,
+					Size:    100,
+					MaxSize: 1000,
+					Hits:    3,
+					Misses:  4,
+				}, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(blobsDir.BlobsCallCount()).To(Equal(0))
+
+				Expect(ui.Table).To(Equal(boshtbl.Table{
+					Content: "blobs cache",
+
+					Header: []boshtbl.Header{
+						boshtbl.NewHeader("Path"),
+						boshtbl.NewHeader("Blobs"),
+						boshtbl.NewHeader("Size"),
+						boshtbl.NewHeader("Max Size"),
+						boshtbl.NewHeader("Hits"),
+						boshtbl.NewHeader("Misses"),
+					},
+
+					Rows: [][]boshtbl.Value{
+						{
+							boshtbl.NewValueString("/cache"),
+							boshtbl.NewValueInt(2),
+							boshtbl.NewValueBytes(100),
+							boshtbl.NewValueBytes(1000),
+							boshtbl.NewValueInt(3),
+							boshtbl.NewValueInt(4),
+						},
+					},
+
+					Transpose: true,
+				}))
+			})
+
+			It("returns error if stats cannot be retrieved", func() {
+				blobCache.StatsReturns(boshreldir.BlobCacheStats{}, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("returns error if cache is disabled", func() {
+				command = NewBlobsCmd(blobsDir, nil, ui)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Release blobs cache is disabled"))
+			})
+		})
 	})
 })
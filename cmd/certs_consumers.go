@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type CertsConsumersCmd struct {
+	ui        boshui.UI
+	inventory CertsInventory
+}
+
+func NewCertsConsumersCmd(ui boshui.UI, inventory CertsInventory) CertsConsumersCmd {
+	return CertsConsumersCmd{ui: ui, inventory: inventory}
+}
+
+func (c CertsConsumersCmd) Run() error {
+	vars, err := c.inventory.Variables()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "certificates",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Variable"),
+			boshtbl.NewHeader("CA"),
+			boshtbl.NewHeader("Is CA"),
+			boshtbl.NewHeader("Deployments"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, v := range vars {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(v.Name),
+			boshtbl.NewValueString(v.CA),
+			boshtbl.NewValueBool(v.IsCA),
+			boshtbl.NewValueStrings(v.Deployments),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("CertsConsumersCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  CertsConsumersCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewCertsConsumersCmd(ui, NewCertsInventory(director))
+
+		app := &fakedir.FakeDeployment{NameStub: func() string { return "app" }}
+		app.ManifestReturns("variables:\n- {name: /ca, type: certificate, options: {is_ca: true}}\n- {name: tls, type: certificate, options: {ca: /ca}}", nil)
+
+		db := &fakedir.FakeDeployment{NameStub: func() string { return "db" }}
+		db.VariablesReturns([]boshdir.VariableResult{{Name: "/ca"}}, nil)
+
+		director.DeploymentsReturns([]boshdir.Deployment{app, db}, nil)
+		director.InfoReturns(boshdir.Info{Name: "dir"}, nil)
+	})
+
+	Describe("Run", func() {
+		It("lists certificate variables and their deployments", func() {
+			err := command.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "certificates",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Variable"),
+					boshtbl.NewHeader("CA"),
+					boshtbl.NewHeader("Is CA"),
+					boshtbl.NewHeader("Deployments"),
+				},
+				SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("/ca"),
+						boshtbl.NewValueString(""),
+						boshtbl.NewValueBool(true),
+						boshtbl.NewValueStrings([]string{"app", "db"}),
+					},
+					{
+						boshtbl.NewValueString("/dir/app/tls"),
+						boshtbl.NewValueString("/ca"),
+						boshtbl.NewValueBool(false),
+						boshtbl.NewValueStrings([]string{"app"}),
+					},
+				},
+			}))
+		})
+
+		It("returns error if director info cannot be fetched", func() {
+			director.InfoReturns(boshdir.Info{}, errors.New("fake-err"))
+
+			err := command.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type CertsExpiringCmd struct {
+	ui          boshui.UI
+	inventory   CertsInventory
+	timeService clock.Clock
+}
+
+func NewCertsExpiringCmd(ui boshui.UI, inventory CertsInventory, timeService clock.Clock) CertsExpiringCmd {
+	return CertsExpiringCmd{ui: ui, inventory: inventory, timeService: timeService}
+}
+
+func (c CertsExpiringCmd) Run(opts CertsExpiringOpts) error {
+	expiries, err := c.inventory.Expiries(c.timeService.Now())
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "certificates",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Deployment"),
+			boshtbl.NewHeader("Certificate"),
+			boshtbl.NewHeader("Expiry Date (UTC)"),
+			boshtbl.NewHeader("Days Left"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 3, Asc: true}},
+		Notes:  []string{"Only certificates written into deployment manifests are checked, not config server variables such as ((ssl)); list those with 'bosh certs consumers'"},
+	}
+
+	var expiring int
+
+	for _, expiry := range expiries {
+		soon := expiry.DaysLeft <= opts.Threshold
+		if soon {
+			expiring++
+		} else if !opts.All {
+			continue
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(expiry.Deployment),
+			boshtbl.NewValueString(expiry.Certificate),
+			boshtbl.NewValueString(expiry.Expiry),
+			boshtbl.NewValueFmt(boshtbl.NewValueInt(expiry.DaysLeft), soon),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if expiring > 0 {
+		return bosherr.Errorf("%d certificate(s) expire within %d days", expiring, opts.Threshold)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("CertsExpiringCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  CertsExpiringCmd
+		opts     CertsExpiringOpts
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		command = NewCertsExpiringCmd(ui, NewCertsInventory(director), fakeclock.NewFakeClock(now))
+		opts = CertsExpiringOpts{Threshold: 30}
+
+		app := &fakedir.FakeDeployment{NameStub: func() string { return "app" }}
+		app.ManifestReturns("properties:\n  cert: |\n"+indentedCertPEM(now.Add(100*24*time.Hour), "    "), nil)
+
+		director.DeploymentsReturns([]boshdir.Deployment{app}, nil)
+		director.CertificateExpiryReturns([]boshdir.CertificateExpiryInfo{
+			{Path: "director.ssl.cert", Expiry: "2020-01-21T00:00:00Z", DaysLeft: 20},
+			{Path: "nats.cert", Expiry: "2020-03-01T00:00:00Z", DaysLeft: 60},
+		}, nil)
+	})
+
+	Describe("Run", func() {
+		It("lists certificates expiring within threshold and returns error", func() {
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 certificate(s) expire within 30 days"))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "certificates",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Deployment"),
+					boshtbl.NewHeader("Certificate"),
+					boshtbl.NewHeader("Expiry Date (UTC)"),
+					boshtbl.NewHeader("Days Left"),
+				},
+				SortBy: []boshtbl.ColumnSort{{Column: 3, Asc: true}},
+				Rows: [][]boshtbl.Value{{
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueString("director.ssl.cert"),
+					boshtbl.NewValueString("2020-01-21T00:00:00Z"),
+					boshtbl.NewValueFmt(boshtbl.NewValueInt(20), true),
+				}},
+				Notes: []string{"Only certificates written into deployment manifests are checked, not config server variables such as ((ssl)); list those with 'bosh certs consumers'"},
+			}))
+		})
+
+		It("lists all certificates if requested", func() {
+			opts.Threshold = 10
+			opts.All = true
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueString("director.ssl.cert"),
+					boshtbl.NewValueString("2020-01-21T00:00:00Z"),
+					boshtbl.NewValueFmt(boshtbl.NewValueInt(20), false),
+				},
+				{
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueString("nats.cert"),
+					boshtbl.NewValueString("2020-03-01T00:00:00Z"),
+					boshtbl.NewValueFmt(boshtbl.NewValueInt(60), false),
+				},
+				{
+					boshtbl.NewValueString("app"),
+					boshtbl.NewValueString("properties/cert"),
+					boshtbl.NewValueString("2020-04-10T00:00:00Z"),
+					boshtbl.NewValueFmt(boshtbl.NewValueInt(100), false),
+				},
+			}))
+		})
+
+		It("returns error if certificates cannot be found", func() {
+			director.DeploymentsReturns(nil, errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+	})
+})
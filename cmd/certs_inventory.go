@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+)
+
+// CertsInventory collects certificates of the director
+// and certificate variables of all deployments.
+type CertsInventory struct {
+	director boshdir.Director
+}
+
+// CertVariable is a variable of type certificate
+// defined in one or more deployment manifests.
+type CertVariable struct {
+	Name        string // absolute name, e.g. '/director/deployment/ssl'
+	CA          string // absolute name of the signing CA, if any
+	IsCA        bool
+	Deployments []string // deployments that use the variable
+}
+
+// CertExpiry is a certificate of the director or a certificate
+// found in a deployment manifest, e.g. interpolated from a vars store.
+type CertExpiry struct {
+	Deployment  string // empty for director certificates
+	Certificate string
+	Expiry      string
+	DaysLeft    int
+}
+
+type certsManifest struct {
+	Variables []struct {
+		Name    string
+		Type    string
+		Options struct {
+			CA   string `yaml:"ca"`
+			IsCA bool   `yaml:"is_ca"`
+		}
+	}
+}
+
+func NewCertsInventory(director boshdir.Director) CertsInventory {
+	return CertsInventory{director: director}
+}
+
+// Expiries returns director certificates and certificates that are written
+// into deployment manifests as PEM values. Manifests keep placeholders of
+// config server variables, so their certificates are not found.
+func (i CertsInventory) Expiries(now time.Time) ([]CertExpiry, error) {
+	directorCerts, err := i.director.CertificateExpiry()
+	if err != nil {
+		return nil, err
+	}
+
+	var expiries []CertExpiry
+
+	for _, cert := range directorCerts {
+		expiries = append(expiries, CertExpiry{
+			Certificate: cert.Path,
+			Expiry:      cert.Expiry,
+			DaysLeft:    cert.DaysLeft,
+		})
+	}
+
+	deployments, err := i.director.Deployments()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deployment := range deployments {
+		var manifest interface{}
+
+		err := i.unmarshalManifest(deployment, &manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		i.findPEMCerts(manifest, "", func(path string, cert *x509.Certificate) {
+			expiries = append(expiries, CertExpiry{
+				Deployment:  deployment.Name(),
+				Certificate: path,
+				Expiry:      cert.NotAfter.UTC().Format(time.RFC3339),
+				DaysLeft:    int(cert.NotAfter.Sub(now).Hours() / 24),
+			})
+		})
+	}
+
+	return expiries, nil
+}
+
+// Variables returns certificate variables sorted by name. Deployments
+// that define or use a variable are considered its consumers.
+func (i CertsInventory) Variables() ([]CertVariable, error) {
+	info, err := i.director.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	deployments, err := i.director.Deployments()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]*CertVariable{}
+
+	addDeployment := func(v *CertVariable, name string) {
+		for _, dep := range v.Deployments {
+			if dep == name {
+				return
+			}
+		}
+		v.Deployments = append(v.Deployments, name)
+	}
+
+	for _, deployment := range deployments {
+		var manifest certsManifest
+
+		err := i.unmarshalManifest(deployment, &manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mv := range manifest.Variables {
+			if mv.Type != "certificate" {
+				continue
+			}
+
+			name := i.absoluteName(info.Name, deployment.Name(), mv.Name)
+
+			v, found := vars[name]
+			if !found {
+				v = &CertVariable{Name: name}
+				vars[name] = v
+			}
+
+			if len(mv.Options.CA) > 0 && len(v.CA) == 0 {
+				v.CA = i.absoluteName(info.Name, deployment.Name(), mv.Options.CA)
+			}
+
+			v.IsCA = v.IsCA || mv.Options.IsCA
+
+			addDeployment(v, deployment.Name())
+		}
+
+		// Absolute variables may be defined outside of the manifest
+		used, err := deployment.Variables()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Fetching variables of deployment '%s'", deployment.Name())
+		}
+
+		for _, uv := range used {
+			if v, found := vars[uv.Name]; found {
+				addDeployment(v, deployment.Name())
+			}
+		}
+	}
+
+	var result []CertVariable
+
+	for _, v := range vars {
+		sort.Strings(v.Deployments)
+		result = append(result, *v)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+func (i CertsInventory) absoluteName(directorName, deploymentName, name string) string {
+	if strings.HasPrefix(name, "/") {
+		return name
+	}
+
+	return fmt.Sprintf("/%s/%s/%s", directorName, deploymentName, name)
+}
+
+func (i CertsInventory) unmarshalManifest(deployment boshdir.Deployment, manifest interface{}) error {
+	bytes, err := deployment.Manifest()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Fetching manifest of deployment '%s'", deployment.Name())
+	}
+
+	err = yaml.Unmarshal([]byte(bytes), manifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling manifest of deployment '%s'", deployment.Name())
+	}
+
+	return nil
+}
+
+// findPEMCerts calls found with a path like 'instance_groups/web/jobs/nginx/properties/tls/cert'
+// for each value that starts with a PEM encoded certificate. Items of lists are
+// named after their name key, or index if they do not have one.
+func (i CertsInventory) findPEMCerts(val interface{}, path string, found func(string, *x509.Certificate)) {
+	switch typedVal := val.(type) {
+	case map[interface{}]interface{}:
+		var keys []string
+
+		vals := map[string]interface{}{}
+
+		for key, v := range typedVal {
+			keys = append(keys, fmt.Sprintf("%v", key))
+			vals[keys[len(keys)-1]] = v
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			i.findPEMCerts(vals[key], path+"/"+key, found)
+		}
+
+	case []interface{}:
+		for idx, item := range typedVal {
+			name := fmt.Sprintf("%d", idx)
+
+			if m, ok := item.(map[interface{}]interface{}); ok {
+				if n, ok := m["name"].(string); ok {
+					name = n
+				}
+			}
+
+			i.findPEMCerts(item, path+"/"+name, found)
+		}
+
+	case string:
+		block, _ := pem.Decode([]byte(typedVal))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			found(strings.TrimPrefix(path, "/"), cert)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cmd_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+)
+
+func generateCertPEM(notAfter time.Time) string {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func indentedCertPEM(notAfter time.Time, indent string) string {
+	return indent + strings.Replace(strings.TrimSpace(generateCertPEM(notAfter)), "\n", "\n"+indent, -1)
+}
+
+var _ = Describe("CertsInventory", func() {
+	var (
+		director  *fakedir.FakeDirector
+		app       *fakedir.FakeDeployment
+		db        *fakedir.FakeDeployment
+		now       time.Time
+		inventory CertsInventory
+	)
+
+	BeforeEach(func() {
+		director = &fakedir.FakeDirector{}
+		now = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		app = &fakedir.FakeDeployment{NameStub: func() string { return "app" }}
+		app.ManifestReturns(`
+name: app
+instance_groups:
+- name: web
+  jobs:
+  - name: nginx
+    properties:
+      tls:
+        cert: |
+`+indentedCertPEM(now.Add(10*24*time.Hour), "          ")+`
+        key: not-a-cert
+variables:
+- name: app_ca
+  type: certificate
+  options: {is_ca: true}
+- name: app_tls
+  type: certificate
+  options: {ca: app_ca}
+- name: password
+  type: password
+- name: /shared_ca
+  type: certificate
+  options: {is_ca: true}
+`, nil)
+
+		db = &fakedir.FakeDeployment{NameStub: func() string { return "db" }}
+		db.ManifestReturns(`
+name: db
+variables:
+- name: db_tls
+  type: certificate
+  options: {ca: /shared_ca}
+`, nil)
+		db.VariablesReturns([]boshdir.VariableResult{{ID: "1", Name: "/shared_ca"}, {ID: "2", Name: "/other"}}, nil)
+
+		director.DeploymentsReturns([]boshdir.Deployment{app, db}, nil)
+		director.InfoReturns(boshdir.Info{Name: "dir"}, nil)
+		director.CertificateExpiryReturns([]boshdir.CertificateExpiryInfo{
+			{Path: "director.ssl.cert", Expiry: "2020-01-31T00:00:00Z", DaysLeft: 30},
+		}, nil)
+
+		inventory = NewCertsInventory(director)
+	})
+
+	Describe("Expiries", func() {
+		It("returns director certificates and certificates in manifests", func() {
+			expiries, err := inventory.Expiries(now)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(expiries).To(Equal([]CertExpiry{
+				{Certificate: "director.ssl.cert", Expiry: "2020-01-31T00:00:00Z", DaysLeft: 30},
+				{
+					Deployment:  "app",
+					Certificate: "instance_groups/web/jobs/nginx/properties/tls/cert",
+					Expiry:      "2020-01-11T00:00:00Z",
+					DaysLeft:    10,
+				},
+			}))
+		})
+
+		It("returns error if director certificates cannot be fetched", func() {
+			director.CertificateExpiryReturns(nil, errors.New("fake-err"))
+
+			_, err := inventory.Expiries(now)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+
+		It("returns error if manifest cannot be fetched", func() {
+			db.ManifestReturns("", errors.New("fake-err"))
+
+			_, err := inventory.Expiries(now)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Fetching manifest of deployment 'db'"))
+		})
+	})
+
+	Describe("Variables", func() {
+		It("returns certificate variables with deployments that use them", func() {
+			vars, err := inventory.Variables()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vars).To(Equal([]CertVariable{
+				{Name: "/dir/app/app_ca", IsCA: true, Deployments: []string{"app"}},
+				{Name: "/dir/app/app_tls", CA: "/dir/app/app_ca", Deployments: []string{"app"}},
+				{Name: "/dir/db/db_tls", CA: "/shared_ca", Deployments: []string{"db"}},
+				{Name: "/shared_ca", IsCA: true, Deployments: []string{"app", "db"}},
+			}))
+		})
+
+		It("returns error if manifest cannot be unmarshalled", func() {
+			app.ManifestReturns("-", nil)
+
+			_, err := inventory.Variables()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling manifest of deployment 'app'"))
+		})
+
+		It("returns error if variables cannot be fetched", func() {
+			db.VariablesReturns(nil, errors.New("fake-err"))
+
+			_, err := inventory.Variables()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Fetching variables of deployment 'db'"))
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type CertsPlanCmd struct {
+	ui        boshui.UI
+	inventory CertsInventory
+}
+
+type certsPlanStep struct {
+	action      string
+	deployments []string
+}
+
+func NewCertsPlanCmd(ui boshui.UI, inventory CertsInventory) CertsPlanCmd {
+	return CertsPlanCmd{ui: ui, inventory: inventory}
+}
+
+func (c CertsPlanCmd) Run(opts CertsPlanOpts) error {
+	vars, err := c.inventory.Variables()
+	if err != nil {
+		return err
+	}
+
+	variable, err := c.findVariable(vars, opts.Args.Name)
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "rotation_plan",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Step"),
+			boshtbl.NewHeader("Action"),
+			boshtbl.NewHeader("Deployment"),
+		},
+	}
+
+	for i, step := range c.plan(vars, variable) {
+		for _, dep := range step.deployments {
+			table.Rows = append(table.Rows, []boshtbl.Value{
+				boshtbl.NewValueInt(i + 1),
+				boshtbl.NewValueString(step.action),
+				boshtbl.NewValueString(dep),
+			})
+		}
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+// plan follows the usual rotation of a CA: deployments first trust
+// both CAs, then get certificates signed by the new CA and finally
+// stop trusting the old CA. Leaf certificates are simply regenerated.
+func (c CertsPlanCmd) plan(vars []CertVariable, variable CertVariable) []certsPlanStep {
+	if !variable.IsCA {
+		return []certsPlanStep{{
+			action:      fmt.Sprintf("Regenerate certificate '%s' and redeploy", variable.Name),
+			deployments: variable.Deployments,
+		}}
+	}
+
+	trusting := map[string]struct{}{}
+	presenting := map[string]struct{}{}
+
+	for _, dep := range variable.Deployments {
+		trusting[dep] = struct{}{}
+	}
+
+	for _, v := range vars {
+		if v.CA == variable.Name {
+			for _, dep := range v.Deployments {
+				trusting[dep] = struct{}{}
+				presenting[dep] = struct{}{}
+			}
+		}
+	}
+
+	steps := []certsPlanStep{{
+		action:      fmt.Sprintf("Add new CA '%s' next to the old one and redeploy", variable.Name),
+		deployments: c.sortedNames(trusting),
+	}}
+
+	if len(presenting) > 0 {
+		steps = append(steps, certsPlanStep{
+			action:      fmt.Sprintf("Regenerate certificates signed by '%s' and redeploy", variable.Name),
+			deployments: c.sortedNames(presenting),
+		})
+	}
+
+	return append(steps, certsPlanStep{
+		action:      fmt.Sprintf("Remove old CA '%s' and redeploy", variable.Name),
+		deployments: c.sortedNames(trusting),
+	})
+}
+
+// findVariable matches an absolute name or a name relative to a deployment.
+func (c CertsPlanCmd) findVariable(vars []CertVariable, name string) (CertVariable, error) {
+	var found []CertVariable
+
+	for _, v := range vars {
+		if v.Name == name || strings.HasSuffix(v.Name, "/"+name) {
+			found = append(found, v)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return CertVariable{}, bosherr.Errorf("Expected to find certificate variable '%s'", name)
+	case 1:
+		return found[0], nil
+	default:
+		var names []string
+		for _, v := range found {
+			names = append(names, v.Name)
+		}
+		return CertVariable{}, bosherr.Errorf(
+			"Expected to find exactly one certificate variable '%s' but found: %s", name, strings.Join(names, ", "))
+	}
+}
+
+func (c CertsPlanCmd) sortedNames(names map[string]struct{}) []string {
+	var result []string
+
+	for name := range names {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,85 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("CertsPlanCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  CertsPlanCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewCertsPlanCmd(ui, NewCertsInventory(director))
+
+		app := &fakedir.FakeDeployment{NameStub: func() string { return "app" }}
+		app.ManifestReturns("variables:\n- {name: /ca, type: certificate, options: {is_ca: true}}\n- {name: tls, type: certificate, options: {ca: /ca}}", nil)
+
+		db := &fakedir.FakeDeployment{NameStub: func() string { return "db" }}
+		db.ManifestReturns("variables:\n- {name: tls, type: certificate}", nil)
+		db.VariablesReturns([]boshdir.VariableResult{{Name: "/ca"}}, nil)
+
+		cache := &fakedir.FakeDeployment{NameStub: func() string { return "cache" }}
+
+		director.DeploymentsReturns([]boshdir.Deployment{app, db, cache}, nil)
+		director.InfoReturns(boshdir.Info{Name: "dir"}, nil)
+	})
+
+	row := func(step int, action, deployment string) []boshtbl.Value {
+		return []boshtbl.Value{
+			boshtbl.NewValueInt(step),
+			boshtbl.NewValueString(action),
+			boshtbl.NewValueString(deployment),
+		}
+	}
+
+	Describe("Run", func() {
+		It("plans rotation of a CA in three steps", func() {
+			err := command.Run(CertsPlanOpts{Args: CertsPlanArgs{Name: "/ca"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Content).To(Equal("rotation_plan"))
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				row(1, "Add new CA '/ca' next to the old one and redeploy", "app"),
+				row(1, "Add new CA '/ca' next to the old one and redeploy", "db"),
+				row(2, "Regenerate certificates signed by '/ca' and redeploy", "app"),
+				row(3, "Remove old CA '/ca' and redeploy", "app"),
+				row(3, "Remove old CA '/ca' and redeploy", "db"),
+			}))
+		})
+
+		It("plans regeneration of a certificate by its absolute name", func() {
+			err := command.Run(CertsPlanOpts{Args: CertsPlanArgs{Name: "/dir/db/tls"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				row(1, "Regenerate certificate '/dir/db/tls' and redeploy", "db"),
+			}))
+		})
+
+		It("returns error if relative name matches multiple variables", func() {
+			err := command.Run(CertsPlanOpts{Args: CertsPlanArgs{Name: "tls"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected to find exactly one certificate variable 'tls' but found: /dir/app/tls, /dir/db/tls"))
+		})
+
+		It("returns error if variable is not found", func() {
+			err := command.Run(CertsPlanOpts{Args: CertsPlanArgs{Name: "missing"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected to find certificate variable 'missing'"))
+		})
+	})
+})
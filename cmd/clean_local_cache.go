@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+)
+
+type CleanLocalCacheCmd struct {
+	ui        boshui.UI
+	fs        boshsys.FileSystem
+	cachePath string
+}
+
+func NewCleanLocalCacheCmd(ui boshui.UI, fs boshsys.FileSystem, cachePath string) CleanLocalCacheCmd {
+	return CleanLocalCacheCmd{ui: ui, fs: fs, cachePath: cachePath}
+}
+
+func (c CleanLocalCacheCmd) Run(opts CleanLocalCacheOpts) error {
+	paths, err := c.fs.Glob(filepath.Join(c.cachePath, "*"))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing cached compiled packages in '%s'", c.cachePath)
+	}
+
+	if opts.DryRun {
+		c.ui.PrintLinef("Would remove %d cached compiled packages from '%s'", len(paths), c.cachePath)
+		return nil
+	}
+
+	for _, path := range paths {
+		err = c.fs.RemoveAll(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing cached compiled package '%s'", path)
+		}
+	}
+
+	c.ui.PrintLinef("Removed %d cached compiled packages from '%s'", len(paths), c.cachePath)
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+)
+
+var _ = Describe("CleanLocalCacheCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		fs      *fakesys.FakeFileSystem
+		command CleanLocalCacheCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewCleanLocalCacheCmd(ui, fs, "/cache")
+
+		Expect(fs.WriteFileString("/cache/pkg1.tgz", "")).To(Succeed())
+		Expect(fs.WriteFileString("/cache/pkg2.tgz", "")).To(Succeed())
+		fs.SetGlob("/cache/*", []string{"/cache/pkg1.tgz", "/cache/pkg2.tgz"})
+	})
+
+	Describe("Run", func() {
+		It("removes the cached compiled packages", func() {
+			err := command.Run(CleanLocalCacheOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/cache/pkg1.tgz")).To(BeFalse())
+			Expect(fs.FileExists("/cache/pkg2.tgz")).To(BeFalse())
+			Expect(ui.Said).To(Equal([]string{"Removed 2 cached compiled packages from '/cache'"}))
+		})
+
+		It("does not remove anything when doing a dry run", func() {
+			err := command.Run(CleanLocalCacheOpts{DryRun: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/cache/pkg1.tgz")).To(BeTrue())
+			Expect(ui.Said).To(Equal([]string{"Would remove 2 cached compiled packages from '/cache'"}))
+		})
+
+		It("returns an error when a package cannot be removed", func() {
+			fs.RemoveAllStub = func(string) error { return errors.New("fake-err") }
+
+			err := command.Run(CleanLocalCacheOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/cppforlife/go-patch/patch"
@@ -11,15 +12,19 @@ import (
 	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
 	boshrel "github.com/cloudfoundry/bosh-cli/v7/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/v7/release/job"
 	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
 	boshssh "github.com/cloudfoundry/bosh-cli/v7/ssh"
 	bistemcell "github.com/cloudfoundry/bosh-cli/v7/stemcell"
+	bitemplate "github.com/cloudfoundry/bosh-cli/v7/templatescompiler"
+	bitemplateerb "github.com/cloudfoundry/bosh-cli/v7/templatescompiler/erbrenderer"
 	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
 	boshuit "github.com/cloudfoundry/bosh-cli/v7/ui/task"
 
 	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
 	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 )
 
@@ -44,19 +49,29 @@ func (c Cmd) getDeployment() (boshdir.Deployment, error) {
 
 func (c Cmd) Execute() (cmdErr error) {
 	// Catch convenience panics from panicIfErr
-	defer func() {
-		if r := recover(); r != nil {
-			if cp, ok := r.(cmdConveniencePanic); ok {
-				cmdErr = cp.Err
-			} else {
-				panic(r)
-			}
-		}
-	}()
+	defer recoverConveniencePanic(&cmdErr)
 
 	c.configureUI()
 	c.configureFS()
 
+	if envsFlags := c.envsFlags(); envsFlags.IsSet() {
+		return c.executeForEnvs(envsFlags)
+	}
+
+	return c.execute()
+}
+
+func recoverConveniencePanic(cmdErr *error) {
+	if r := recover(); r != nil {
+		if cp, ok := r.(cmdConveniencePanic); ok {
+			*cmdErr = cp.Err
+		} else {
+			panic(r)
+		}
+	}
+}
+
+func (c Cmd) execute() error {
 	deps := c.deps
 
 	switch opts := c.Opts.(type) {
@@ -68,7 +83,7 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *CreateEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.RecreatePersistentDisks).Preparer()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.RecreatePersistentDisks, opts.RecordCPI, opts.ReplayCPI, c.BoshOpts.Parallel).Preparer()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
@@ -76,7 +91,7 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *DeleteEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentDeleter {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).Deleter()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false, opts.RecordCPI, opts.ReplayCPI, c.BoshOpts.Parallel).Deleter()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
@@ -84,7 +99,7 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *StopEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentStateManager {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).StateManager()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false, "", "", c.BoshOpts.Parallel).StateManager()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
@@ -92,7 +107,7 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *StartEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentStateManager {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).StateManager()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false, "", "", c.BoshOpts.Parallel).StateManager()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
@@ -137,7 +152,8 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewTaskCmd(eventsTaskReporter, plainTaskReporter, c.director()).Run(*opts)
 
 	case *TasksOpts:
-		return NewTasksCmd(deps.UI, c.director()).Run(*opts)
+		eventsTaskReporter := boshuit.NewReporter(deps.UI, true)
+		return NewTasksCmd(deps.UI, c.director(), eventsTaskReporter, deps.Time).Run(*opts)
 
 	case *CancelTaskOpts:
 		return NewCancelTaskCmd(c.director()).Run(*opts)
@@ -261,6 +277,12 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *InterpolateOpts:
 		return NewInterpolateCmd(deps.UI).Run(*opts)
 
+	case *VarsStoreCheckOpts:
+		return NewVarsStoreCheckCmd(deps.UI, deps.Time).Run(*opts)
+
+	case *VarsStoreRotateOpts:
+		return NewVarsStoreRotateCmd(deps.UI).Run(*opts)
+
 	case *ConfigOpts:
 		return NewConfigCmd(deps.UI, c.director()).Run(*opts)
 
@@ -276,6 +298,21 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *DeleteConfigOpts:
 		return NewDeleteConfigCmd(deps.UI, c.director()).Run(*opts)
 
+	case *CertsExpiringOpts:
+		return NewCertsExpiringCmd(deps.UI, NewCertsInventory(c.director()), deps.Time).Run(*opts)
+
+	case *CertsConsumersOpts:
+		return NewCertsConsumersCmd(deps.UI, NewCertsInventory(c.director())).Run()
+
+	case *CertsPlanOpts:
+		return NewCertsPlanCmd(deps.UI, NewCertsInventory(c.director())).Run(*opts)
+
+	case *ExportConfigsOpts:
+		return NewExportConfigsCmd(deps.UI, c.director(), deps.FS).Run(*opts)
+
+	case *ImportConfigsOpts:
+		return NewImportConfigsCmd(deps.UI, c.director(), deps.FS).Run(*opts)
+
 	case *CloudConfigOpts:
 		return NewCloudConfigCmd(deps.UI, c.director()).Run(*opts)
 
@@ -312,7 +349,7 @@ func (c Cmd) Execute() (cmdErr error) {
 		relProv, _ := c.releaseProviders()
 
 		return NewInspectLocalReleaseCmd(
-			relProv.NewArchiveReader(),
+			relProv.NewJobExtractingArchiveReader(),
 			deps.UI,
 		).Run(*opts)
 
@@ -337,7 +374,13 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *DeployOpts:
 		director, deployment := c.directorAndDeployment()
 		releaseManager := c.releaseManager(director)
-		return NewDeployCmd(deps.UI, deployment, releaseManager).Run(*opts)
+		return NewDeployCmd(deps.UI, deployment, releaseManager, NewDirectorManifestLinter(director)).Run(*opts)
+
+	case *LintManifestOpts:
+		return NewLintManifestCmd(deps.UI, NewDirectorManifestLinter(c.director())).Run(*opts)
+
+	case *DriftOpts:
+		return NewDriftCmd(deps.UI, c.director(), deps.FS).Run(*opts)
 
 	case *StartOpts:
 		return NewStartCmd(deps.UI, c.deployment()).Run(*opts)
@@ -354,6 +397,13 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *CloudCheckOpts:
 		return NewCloudCheckCmd(c.deployment(), deps.UI).Run(*opts)
 
+	case *WaitHealthyOpts:
+		return NewWaitHealthyCmd(deps.UI, c.deployment(), deps.Time).Run(*opts)
+
+	case *CleanLocalCacheOpts:
+		cachePath := compiledPackageCachePath(filepath.Join(os.Getenv("HOME"), ".bosh"))
+		return NewCleanLocalCacheCmd(deps.UI, deps.FS, cachePath).Run(*opts)
+
 	case *CleanUpOpts:
 		return NewCleanUpCmd(deps.UI, c.director()).Run(*opts)
 
@@ -398,6 +448,18 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *VendorPackageOpts:
 		return NewVendorPackageCmd(c.releaseDir, deps.UI).Run(*opts)
 
+	case *RenderJobOpts:
+		erbRenderer := bitemplateerb.NewNativeERBRenderer(
+			deps.FS,
+			bitemplateerb.NewERBRenderer(deps.FS, deps.CmdRunner, deps.Logger),
+			deps.Logger,
+		)
+		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, deps.FS, deps.UUIDGen, deps.Logger)
+		return NewRenderJobCmd(boshjob.NewSourceReaderImpl(deps.FS), jobRenderer, deps.FS, deps.UI).Run(*opts)
+
+	case *LintReleaseOpts:
+		return NewLintReleaseCmd(c.releaseDir(opts.Directory), deps.UI).Run(*opts)
+
 	case *FinalizeReleaseOpts:
 		_, relDirProv := c.releaseProviders()
 		releaseReader := relDirProv.NewReleaseReader(opts.Directory.Path, c.BoshOpts.Parallel)
@@ -446,7 +508,7 @@ func (c Cmd) Execute() (cmdErr error) {
 		).Run(opts.Args)
 
 	case *BlobsOpts:
-		return NewBlobsCmd(c.blobsDir(opts.Directory), deps.UI).Run()
+		return NewBlobsCmd(c.blobsDir(opts.Directory), c.blobCache(), deps.UI).Run(*opts)
 
 	case *AddBlobOpts:
 		return NewAddBlobCmd(c.blobsDir(opts.Directory), deps.FS, deps.UI).Run(*opts)
@@ -455,11 +517,17 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewRemoveBlobCmd(c.blobsDir(opts.Directory), deps.UI).Run(*opts)
 
 	case *UploadBlobsOpts:
-		return NewUploadBlobsCmd(c.blobsDir(opts.Directory)).Run()
+		return NewUploadBlobsCmd(c.blobsDir(opts.Directory), c.BoshOpts.Parallel).Run()
 
 	case *SyncBlobsOpts:
 		return NewSyncBlobsCmd(c.blobsDir(opts.Directory), c.BoshOpts.Parallel).Run()
 
+	case *MigrateBlobstoreOpts:
+		return NewMigrateBlobstoreCmd(c.blobstoreMigrator(*opts), deps.UI).Run(*opts)
+
+	case *AuditBlobstoreOpts:
+		return NewAuditBlobstoreCmd(c.blobstoreAuditor(opts.Directory), deps.UI).Run(*opts)
+
 	case *CurlOpts:
 		return NewCurlCmd(deps.UI, c.director().(boshdir.DirectorImpl).NewHTTPClientRequest()).Run(*opts)
 
@@ -474,6 +542,61 @@ func (c Cmd) Execute() (cmdErr error) {
 		return fmt.Errorf("Unhandled command: %#v", c.Opts)
 	}
 }
+func (c Cmd) envsFlags() EnvsFlags {
+	switch opts := c.Opts.(type) {
+	case *VMsOpts:
+		return opts.EnvsFlags
+	case *InstancesOpts:
+		return opts.EnvsFlags
+	case *DeploymentsOpts:
+		return opts.EnvsFlags
+	case *ReleasesOpts:
+		return opts.EnvsFlags
+	case *StemcellsOpts:
+		return opts.EnvsFlags
+	case *TasksOpts:
+		return opts.EnvsFlags
+	default:
+		return EnvsFlags{}
+	}
+}
+
+// executeForEnvs runs the command against each of the environments
+// with its own session and prints merged tables of all of them.
+func (c Cmd) executeForEnvs(envsFlags EnvsFlags) error {
+	if opts, ok := c.Opts.(*TasksOpts); ok && opts.Follow {
+		return bosherr.Error("Cannot follow tasks of multiple environments")
+	}
+
+	envs := envsFlags.Envs
+
+	if envsFlags.AllEnvs {
+		envs = nil
+
+		for _, env := range c.config().Environments() {
+			if len(env.Alias) > 0 {
+				envs = append(envs, env.Alias)
+			} else {
+				envs = append(envs, env.URL)
+			}
+		}
+	}
+
+	run := func(env string, ui boshui.UI) (cmdErr error) {
+		defer recoverConveniencePanic(&cmdErr)
+
+		boshOpts := c.BoshOpts
+		boshOpts.EnvironmentOpt = env
+
+		deps := c.deps
+		deps.UI = boshui.NewWrappingConfUI(ui, deps.Logger)
+
+		return NewCmd(boshOpts, c.Opts, deps).execute()
+	}
+
+	return NewMultiEnvRunner(c.deps.UI, run, c.BoshOpts.Parallel).Run(envs)
+}
+
 func (c Cmd) configureUI() {
 	c.deps.UI.EnableTTY(c.BoshOpts.TTYOpt)
 
@@ -485,6 +608,11 @@ func (c Cmd) configureUI() {
 		c.deps.UI.EnableJSON()
 	}
 
+	// Streamed separately so that the document printed with --json stays valid
+	if c.BoshOpts.JSONEventsOpt {
+		c.deps.UI.EnableJSONEvents(os.Stderr)
+	}
+
 	if c.BoshOpts.NonInteractiveOpt {
 		c.deps.UI.EnableNonInteractive()
 	}
@@ -554,11 +682,30 @@ func (c Cmd) releaseProviders() (boshrel.Provider, boshreldir.Provider) {
 
 	releaseDirProvider := boshreldir.NewProvider(
 		indexReporter, releaseIndexReporter, blobsReporter, releaseProvider,
-		c.deps.DigestCalculator, c.deps.CmdRunner, c.deps.UUIDGen, c.deps.Time, c.deps.FS, c.deps.DigestCreationAlgorithms, c.deps.Logger)
+		c.deps.DigestCalculator, c.deps.CmdRunner, c.deps.UUIDGen, c.deps.Time, c.deps.FS, c.blobCache(), c.deps.DigestCreationAlgorithms, c.deps.Logger)
 
 	return releaseProvider, releaseDirProvider
 }
 
+// blobCache is shared by all release directories of the user.
+// Nil is returned when cache is disabled by setting its max size to 0.
+func (c Cmd) blobCache() boshreldir.BlobCache {
+	if c.BoshOpts.BlobCacheMaxSizeOpt < 0 {
+		c.panicIfErr(bosherr.Errorf("Expected release blobs cache max size to be 0 or more, but was '%d'", c.BoshOpts.BlobCacheMaxSizeOpt))
+	}
+
+	if c.BoshOpts.BlobCacheMaxSizeOpt == 0 {
+		return nil
+	}
+
+	cachePath, err := c.deps.FS.ExpandPath(c.BoshOpts.BlobCachePathOpt)
+	c.panicIfErr(err)
+
+	maxSize := c.BoshOpts.BlobCacheMaxSizeOpt * 1024 * 1024
+
+	return boshreldir.NewFSBlobCache(cachePath, maxSize, c.deps.Time, c.deps.FS, c.deps.Logger)
+}
+
 func (c Cmd) releaseManager(director boshdir.Director) ReleaseManager {
 	relProv, relDirProv := c.releaseProviders()
 
@@ -599,6 +746,18 @@ func (c Cmd) blobsDir(dir DirOrCWDArg) boshreldir.BlobsDir {
 	return relDirProv.NewFSBlobsDir(dir.Path)
 }
 
+func (c Cmd) blobstoreMigrator(opts MigrateBlobstoreOpts) boshreldir.BlobstoreMigrator {
+	_, relDirProv := c.releaseProviders()
+	toConfig := boshreldir.NewFSConfig(opts.ToConfig.ExpandedPath, opts.ToPrivateConfig.ExpandedPath, c.deps.FS)
+	reporter := boshui.NewBlobstoreMigrationReporter(c.deps.UI)
+	return relDirProv.NewFSBlobstoreMigrator(opts.Directory.Path, toConfig, reporter)
+}
+
+func (c Cmd) blobstoreAuditor(dir DirOrCWDArg) boshreldir.BlobstoreAuditor {
+	_, relDirProv := c.releaseProviders()
+	return relDirProv.NewFSBlobstoreAuditor(dir.Path, c.BoshOpts.Parallel)
+}
+
 func (c Cmd) releaseDir(dir DirOrCWDArg) boshreldir.ReleaseDir {
 	_, relDirProv := c.releaseProviders()
 	return relDirProv.NewFSReleaseDir(dir.Path, c.BoshOpts.Parallel)
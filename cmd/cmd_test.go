@@ -2,6 +2,7 @@ package cmd_test
 
 import (
 	"errors"
+	"os"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -105,6 +106,69 @@ var _ = Describe("Cmd", func() {
 			Expect(err.Error()).To(Equal("fake-err"))
 		})
 
+		Describe("multiple environments", func() {
+			It("returns error if there are no environments in config", func() {
+				cmd.BoshOpts = BoshOpts{ConfigPathOpt: "/config"}
+				cmd.Opts = &StemcellsOpts{EnvsFlags: EnvsFlags{AllEnvs: true}}
+
+				err := cmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected at least one environment"))
+			})
+
+			It("returns error if following tasks", func() {
+				cmd.Opts = &TasksOpts{Follow: true, EnvsFlags: EnvsFlags{Envs: []string{"prod", "dev"}}}
+
+				err := cmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Cannot follow tasks of multiple environments"))
+			})
+		})
+
+		Describe("release blobs cache", func() {
+			var cachePath string
+
+			BeforeEach(func() {
+				cmd.Opts = &BlobsOpts{CacheStats: true}
+
+				var err error
+
+				// Cache is locked with a file lock that needs a real directory
+				cachePath, err = os.MkdirTemp("", "bosh-blob-cache")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(cachePath) //nolint:errcheck
+			})
+
+			It("uses cache at configured path with configured max size", func() {
+				cmd.BoshOpts = BoshOpts{BlobCachePathOpt: cachePath, BlobCacheMaxSizeOpt: 2}
+
+				err := cmd.Execute()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Table.Rows[0][0]).To(Equal(boshtbl.NewValueString(cachePath)))
+				Expect(ui.Table.Rows[0][3]).To(Equal(boshtbl.NewValueBytes(2 * 1024 * 1024)))
+			})
+
+			It("disables cache when max size is 0", func() {
+				cmd.BoshOpts = BoshOpts{BlobCachePathOpt: cachePath, BlobCacheMaxSizeOpt: 0}
+
+				err := cmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Release blobs cache is disabled"))
+			})
+
+			It("returns error if max size is negative", func() {
+				cmd.BoshOpts = BoshOpts{BlobCachePathOpt: cachePath, BlobCacheMaxSizeOpt: -1}
+
+				err := cmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected release blobs cache max size to be 0 or more"))
+			})
+		})
+
 		It("returns error for unknown commands", func() {
 			err := cmd.Execute()
 			Expect(err).To(HaveOccurred())
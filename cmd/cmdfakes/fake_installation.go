@@ -2,6 +2,7 @@ package cmdfakes
 
 import (
 	biinstallation "github.com/cloudfoundry/bosh-cli/v7/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/v7/installation/manifest"
 )
 
 type FakeInstallation struct {
@@ -14,3 +15,7 @@ func (f *FakeInstallation) Target() biinstallation.Target {
 func (f *FakeInstallation) Job() biinstallation.InstalledJob {
 	return biinstallation.InstalledJob{}
 }
+
+func (f *FakeInstallation) Manifest() biinstallmanifest.Manifest {
+	return biinstallmanifest.Manifest{}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"sync"
 
 	"github.com/cloudfoundry/bosh-cli/v7/uaa"
 	"gopkg.in/yaml.v2"
@@ -187,6 +188,13 @@ func (c FSConfig) Save() error {
 	return nil
 }
 
+// tokenUpdateLock serializes token updates of commands that run against
+// several environments at once, which share the config file.
+var tokenUpdateLock sync.Mutex
+
+// UpdateConfigWithToken saves refreshed credentials into the latest saved
+// config rather than into c so that tokens saved in the meantime for other
+// environments are not overwritten.
 func (c FSConfig) UpdateConfigWithToken(environment string, t uaa.AccessToken) error {
 	creds := Creds{
 		AccessToken:     t.Value(),
@@ -196,8 +204,16 @@ func (c FSConfig) UpdateConfigWithToken(environment string, t uaa.AccessToken) e
 	if refreshToken, ok := t.(uaa.RefreshableAccessToken); ok {
 		creds.RefreshToken = refreshToken.RefreshValue()
 	}
-	config := c.SetCredentials(environment, creds)
-	return config.Save()
+
+	tokenUpdateLock.Lock()
+	defer tokenUpdateLock.Unlock()
+
+	latest, err := NewFSConfigFromPath(c.path, c.fs)
+	if err != nil {
+		return err
+	}
+
+	return latest.SetCredentials(environment, creds).Save()
 }
 
 func (c *FSConfig) findOrCreateEnvironment(urlOrAlias string) (int, fsConfigSchema_Environment) {
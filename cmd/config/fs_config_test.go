@@ -2,7 +2,9 @@ package config_test
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"sync"
 
 	. "github.com/cloudfoundry/bosh-cli/v7/cmd/config"
 	. "github.com/onsi/ginkgo"
@@ -350,6 +352,47 @@ var _ = Describe("FSConfig", func() {
 			}))
 		})
 
+		It("keeps tokens of other environments that are refreshed at the same time", func() {
+			var err error
+
+			config, err = config.AliasEnvironment("url1", "env1", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err = config.AliasEnvironment("url2", "env2", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Save()).To(Succeed())
+
+			// Each environment is run with its own copy of the config
+			snapshots := []FSConfig{readConfig(), readConfig()}
+
+			var wg sync.WaitGroup
+
+			for i, snapshot := range snapshots {
+				wg.Add(1)
+
+				go func(env string, snapshot FSConfig) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					err := snapshot.UpdateConfigWithToken(env, uaa.NewRefreshableAccessToken("bearer", env+"-access", env+"-refresh"))
+					Expect(err).ToNot(HaveOccurred())
+				}(fmt.Sprintf("env%d", i+1), snapshot)
+			}
+
+			wg.Wait()
+
+			reloadedConfig := readConfig()
+
+			for _, env := range []string{"env1", "env2"} {
+				Expect(reloadedConfig.Credentials(env)).To(Equal(Creds{
+					AccessToken:     env + "-access",
+					AccessTokenType: "bearer",
+					RefreshToken:    env + "-refresh",
+				}))
+			}
+		})
+
 		It("returns an error when save fails", func() {
 			fs.WriteFileError = errors.New("write error")
 
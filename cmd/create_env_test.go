@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -141,8 +142,10 @@ var _ = Describe("CreateEnvCmd", func() {
 			defaultCreateEnvOpts CreateEnvOpts
 
 			expectedSkipDrain bool
+			expectedResume    bool
 
 			expectLegacyMigrate        *gomock.Call
+			expectLegacyRead           *gomock.Call
 			expectStemcellUpload       *gomock.Call
 			expectStemcellDeleteUnused *gomock.Call
 			expectInstall              *gomock.Call
@@ -154,6 +157,7 @@ var _ = Describe("CreateEnvCmd", func() {
 		BeforeEach(func() {
 			expectedDeployError = nil
 			expectedSkipDrain = false
+			expectedResume = false
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			stdOut = gbytes.NewBuffer()
 			stdErr = gbytes.NewBuffer()
@@ -358,6 +362,7 @@ var _ = Describe("CreateEnvCmd", func() {
 					mockLegacyDeploymentStateMigrator,
 					releaseManager,
 					deploymentRecord,
+					biconfig.NewDeployProgressRepo(deploymentStateService),
 					mockCloudFactory,
 					fakeStemcellManagerFactory,
 					mockAgentClientFactory,
@@ -380,6 +385,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			command = bicmd.NewCreateEnvCmd(userInterface, doGet)
 
 			expectLegacyMigrate = mockLegacyDeploymentStateMigrator.EXPECT().MigrateIfExists(filepath.Join("/", "path", "to", "bosh-deployments.yml")).AnyTimes()
+			expectLegacyRead = mockLegacyDeploymentStateMigrator.EXPECT().ReadIfExists(filepath.Join("/", "path", "to", "bosh-deployments.yml")).Return(biconfig.DeploymentState{}, false, nil).AnyTimes()
 
 			extractedStemcell = bistemcell.NewExtractedStemcell(
 				bistemcell.Manifest{
@@ -444,9 +450,11 @@ var _ = Describe("CreateEnvCmd", func() {
 				cloudStemcell,
 				fakeVMManager,
 				mockBlobstore,
+				gomock.Any(),
 				expectedSkipDrain,
+				expectedResume,
 				gomock.Any(),
-			).Do(func(_, _, _, _, _, _ interface{}, stage biui.Stage) {
+			).Do(func(_, _, _, _, _, _, _, _ interface{}, stage biui.Stage) {
 				Expect(fakeStage.SubStages).To(ContainElement(stage))
 			}).Return(nil, expectedDeployError).AnyTimes()
 
@@ -516,6 +524,25 @@ var _ = Describe("CreateEnvCmd", func() {
 			Expect(fs.TempRootPath).To(Equal(filepath.Join("fake-install-dir", "fake-installation-id", "tmp")))
 		})
 
+		Context("when the deployment state is locked by someone else", func() {
+			It("returns an error without deploying", func() {
+				expectDeploy.Times(0)
+
+				err := fs.WriteFileString(deploymentStatePath+".lock", "pid 123 on other-host")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Locking deployment state: Deployment state '" + deploymentStatePath + "' is locked by 'pid 123 on other-host'"))
+			})
+		})
+
+		It("unlocks the deployment state when done", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+		})
+
 		Context("when setting the temp root fails", func() {
 			It("returns an error", func() {
 				fs.ChangeTempRootErr = errors.New("fake ChangeTempRootErr")
@@ -670,6 +697,53 @@ var _ = Describe("CreateEnvCmd", func() {
 			})
 		})
 
+		It("clears the deploy progress once deployed", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState, err := setupDeploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Progress).To(BeNil())
+		})
+
+		Context("when Resume is specified", func() {
+			BeforeEach(func() {
+				defaultCreateEnvOpts.Resume = true
+			})
+
+			Context("when an interrupted deploy of the same manifest was journaled", func() {
+				BeforeEach(func() {
+					expectedResume = true
+
+					err := biconfig.NewDeployProgressRepo(setupDeploymentStateService).Start(manifestSHA)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("resumes the deploy", func() {
+					expectDeploy.Times(1)
+
+					err := command.Run(fakeStage, defaultCreateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stdOut).To(gbytes.Say("Resuming interrupted deploy."))
+				})
+			})
+
+			Context("when the interrupted deploy was of another manifest", func() {
+				BeforeEach(func() {
+					err := biconfig.NewDeployProgressRepo(setupDeploymentStateService).Start("fake-other-manifest-sha")
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("deploys from the start", func() {
+					expectDeploy.Times(1)
+
+					err := command.Run(fakeStage, defaultCreateEnvOpts)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stdOut).To(gbytes.Say("No interrupted deploy of this manifest to resume. Deploying from the start."))
+				})
+			})
+		})
+
 		Context("when deployment has not changed", func() {
 			JustBeforeEach(func() {
 				previousDeploymentState := biconfig.DeploymentState{
@@ -701,6 +775,16 @@ var _ = Describe("CreateEnvCmd", func() {
 				Expect(stdOut).To(gbytes.Say("No deployment, stemcell or release changes. Skipping deploy."))
 			})
 
+			It("reports lack of changes once if `diff` flag is specified", func() {
+				expectDeploy.Times(0)
+
+				defaultCreateEnvOpts.Diff = true
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(strings.Count(string(stdOut.Contents()), "No deployment, stemcell or release changes")).To(Equal(1))
+			})
+
 			It("deploys if `recreate` flag is specified", func() {
 				expectDeploy.Times(1)
 
@@ -718,6 +802,96 @@ var _ = Describe("CreateEnvCmd", func() {
 				err := command.Run(fakeStage, defaultCreateEnvOpts)
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			It("reports no changes if `dry-run` flag is specified", func() {
+				expectDeploy.Times(0)
+
+				defaultCreateEnvOpts.DryRun = true
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdOut).To(gbytes.Say("No deployment, stemcell or release changes."))
+				Expect(stdOut).To(gbytes.Say("Dry run: skipping deploy."))
+			})
+		})
+
+		Context("when `dry-run` flag is specified", func() {
+			BeforeEach(func() {
+				defaultCreateEnvOpts.DryRun = true
+			})
+
+			It("reports the changes without installing the cpi or deploying", func() {
+				expectInstall.Times(0)
+				expectNewCloud.Times(0)
+				expectStemcellUpload.Times(0)
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdOut).To(gbytes.Say("Changes:"))
+				Expect(stdOut).To(gbytes.Say("Manifest has changed"))
+				Expect(stdOut).To(gbytes.Say("Stemcell 'fake-stemcell-name/fake-stemcell-version' will be uploaded"))
+				Expect(stdOut).To(gbytes.Say("Releases will be recompiled"))
+				Expect(stdOut).To(gbytes.Say("Dry run: skipping deploy."))
+				Expect(stdOut).ToNot(gbytes.Say("release: fake-cpi-release-name/1.0"))
+			})
+
+			It("does not update the deployment state", func() {
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				deploymentState, err := setupDeploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.CurrentManifestSHA).To(BeEmpty())
+			})
+
+			It("does not create deployment state if it does not exist", func() {
+				err := fs.RemoveAll(deploymentStatePath)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
+			})
+
+			It("does not lock the deployment state", func() {
+				err := fs.WriteFileString(deploymentStatePath+".lock", "pid 123 on other-host")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fs.ReadFileString(deploymentStatePath + ".lock")).To(Equal("pid 123 on other-host"))
+			})
+
+			It("plans from the legacy bosh-deployments.yml without migrating it", func() {
+				err := fs.RemoveAll(deploymentStatePath)
+				Expect(err).ToNot(HaveOccurred())
+
+				expectLegacyMigrate.Times(0)
+				expectLegacyRead.Return(biconfig.DeploymentState{DirectorID: "legacy-director-id"}, true, nil).Times(1)
+
+				err = command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdOut).To(gbytes.Say("Using legacy deployments file without migrating it"))
+				Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
+			})
+		})
+
+		Context("when `diff` flag is specified", func() {
+			BeforeEach(func() {
+				defaultCreateEnvOpts.Diff = true
+			})
+
+			It("reports the previous and new values of the changes and deploys", func() {
+				expectDeploy.Times(1)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdOut).To(gbytes.Say("Stemcell 'fake-stemcell-name/fake-stemcell-version' will be uploaded"))
+				Expect(stdOut).To(gbytes.Say(regexp.QuoteMeta("+ stemcell: fake-stemcell-name/fake-stemcell-version")))
+				Expect(stdOut).To(gbytes.Say("Releases will be recompiled"))
+				Expect(stdOut).To(gbytes.Say(regexp.QuoteMeta("+ release: fake-cpi-release-name/1.0")))
+			})
 		})
 
 		Context("when parsing the cpi deployment manifest fails", func() {
@@ -1064,7 +1238,9 @@ var _ = Describe("CreateEnvCmd", func() {
 					cloudStemcell,
 					fakeVMManager,
 					mockBlobstore,
+					gomock.Any(),
 					expectedSkipDrain,
+					expectedResume,
 					gomock.Any(),
 				).Return(nil, expectedDeployError).AnyTimes()
 
@@ -13,6 +13,7 @@ type DeployCmd struct {
 	ui              boshui.UI
 	deployment      boshdir.Deployment
 	releaseUploader ReleaseUploader
+	manifestLinter  ManifestLinter
 }
 
 type ReleaseUploader interface {
@@ -24,8 +25,9 @@ func NewDeployCmd(
 	ui boshui.UI,
 	deployment boshdir.Deployment,
 	releaseUploader ReleaseUploader,
+	manifestLinter ManifestLinter,
 ) DeployCmd {
-	return DeployCmd{ui, deployment, releaseUploader}
+	return DeployCmd{ui, deployment, releaseUploader, manifestLinter}
 }
 
 func (c DeployCmd) Run(opts DeployOpts) error {
@@ -50,6 +52,14 @@ func (c DeployCmd) Run(opts DeployOpts) error {
 		return err
 	}
 
+	// Releases given with URLs are uploaded by now
+	if opts.Lint {
+		err = lintManifestBytes(c.ui, c.manifestLinter, bytes)
+		if err != nil {
+			return err
+		}
+	}
+
 	deploymentDiff, err := c.deployment.Diff(bytes, opts.NoRedact)
 	if err != nil {
 		return err
@@ -21,6 +21,7 @@ var _ = Describe("DeployCmd", func() {
 		ui              *fakeui.FakeUI
 		deployment      *fakedir.FakeDeployment
 		releaseUploader *fakecmd.FakeReleaseUploader
+		manifestLinter  *fakecmd.FakeManifestLinter
 		command         DeployCmd
 	)
 
@@ -34,7 +35,9 @@ var _ = Describe("DeployCmd", func() {
 			UploadReleasesStub: func(bytes []byte) ([]byte, error) { return bytes, nil },
 		}
 
-		command = NewDeployCmd(ui, deployment, releaseUploader)
+		manifestLinter = &fakecmd.FakeManifestLinter{}
+
+		command = NewDeployCmd(ui, deployment, releaseUploader, manifestLinter)
 	})
 
 	Describe("Run", func() {
@@ -212,6 +215,54 @@ releases:
 			Expect(deployment.UpdateCallCount()).To(Equal(0))
 		})
 
+		It("does not lint manifest by default", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(manifestLinter.LintCallCount()).To(Equal(0))
+		})
+
+		It("lints manifest after uploading releases when requested", func() {
+			opts.Lint = true
+
+			releaseUploader.UploadReleasesReturns([]byte("name: dep\nafter-upload: true\n"), nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(manifestLinter.LintCallCount()).To(Equal(1))
+			Expect(manifestLinter.LintArgsForCall(0)).To(Equal([]byte("name: dep\nafter-upload: true\n")))
+			Expect(deployment.UpdateCallCount()).To(Equal(1))
+		})
+
+		It("prints problems and does not deploy if linting finds problems", func() {
+			opts.Lint = true
+
+			manifestLinter.LintReturns([]ManifestProblem{
+				{Resource: "instance_groups/web", Message: "Network 'private' is not defined in cloud config"},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 problem(s) found"))
+
+			Expect(ui.Table.Rows).To(HaveLen(1))
+			Expect(deployment.DiffCallCount()).To(Equal(0))
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
+		It("returns error and does not deploy if linting fails", func() {
+			opts.Lint = true
+
+			manifestLinter.LintReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(deployment.UpdateCallCount()).To(Equal(0))
+		})
+
 		It("uploads releases but does not deploy if confirmation is rejected", func() {
 			opts.Args.Manifest = FileBytesArg{
 				Bytes: []byte(`
@@ -95,6 +95,17 @@ func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, stage biui.Stage) (
 		return nil
 	}
 
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
 	deploymentState, err := c.deploymentStateService.Load()
 	if err != nil {
 		return bosherr.WrapError(err, "Loading deployment state")
@@ -230,5 +241,7 @@ func (c *deploymentDeleter) deploymentManager(installation biinstall.Installatio
 
 	c.logger.Debug(c.logTag, "Creating deployment manager...")
 
-	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore), nil
+	agentProvider := newAgentProvider(c.agentClientFactory, c.blobstoreFactory, directorID, installationMbus, caCert)
+
+	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore, agentProvider), nil
 }
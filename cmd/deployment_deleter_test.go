@@ -258,7 +258,7 @@ cloud_provider:
 		}
 
 		var expectDeleteAndCleanup = func(skipDrain, defaultUninstallerUsed bool) {
-			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager)
+			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore, gomock.Any()).Return(mockDeploymentManager)
 			mockDeploymentManager.EXPECT().FindCurrent().Return(mockDeployment, true, nil)
 
 			gomock.InOrder(
@@ -273,7 +273,7 @@ cloud_provider:
 		}
 
 		var expectCleanup = func() {
-			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager).AnyTimes()
+			mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore, gomock.Any()).Return(mockDeploymentManager).AnyTimes()
 			mockDeploymentManager.EXPECT().FindCurrent().Return(nil, false, nil).AnyTimes()
 
 			mockDeploymentManager.EXPECT().Cleanup(fakeStage)
@@ -553,7 +553,7 @@ cloud_provider:
 
 			Context("when the call to delete the deployment returns an error", func() {
 				It("returns the error", func() {
-					mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore).Return(mockDeploymentManager)
+					mockDeploymentManagerFactory.EXPECT().NewManager(mockCloud, mockAgentClient, mockBlobstore, gomock.Any()).Return(mockDeploymentManager)
 					mockDeploymentManager.EXPECT().FindCurrent().Return(mockDeployment, true, nil)
 
 					deleteError := bosherr.Error("delete error")
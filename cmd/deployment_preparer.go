@@ -31,6 +31,7 @@ func NewDeploymentPreparer(
 	legacyDeploymentStateMigrator biconfig.LegacyDeploymentStateMigrator,
 	releaseManager boshinst.ReleaseManager,
 	deploymentRecord bidepl.Record,
+	deployProgressRepo biconfig.DeployProgressRepo,
 	cloudFactory bicloud.Factory,
 	stemcellManagerFactory bistemcell.ManagerFactory,
 	agentClientFactory bihttpagent.AgentClientFactory,
@@ -56,6 +57,7 @@ func NewDeploymentPreparer(
 		legacyDeploymentStateMigrator:           legacyDeploymentStateMigrator,
 		releaseManager:                          releaseManager,
 		deploymentRecord:                        deploymentRecord,
+		deployProgressRepo:                      deployProgressRepo,
 		cloudFactory:                            cloudFactory,
 		stemcellManagerFactory:                  stemcellManagerFactory,
 		agentClientFactory:                      agentClientFactory,
@@ -83,6 +85,7 @@ type DeploymentPreparer struct {
 	legacyDeploymentStateMigrator           biconfig.LegacyDeploymentStateMigrator
 	releaseManager                          boshinst.ReleaseManager
 	deploymentRecord                        bidepl.Record
+	deployProgressRepo                      biconfig.DeployProgressRepo
 	cloudFactory                            bicloud.Factory
 	stemcellManagerFactory                  bistemcell.ManagerFactory
 	agentClientFactory                      bihttpagent.AgentClientFactory
@@ -101,25 +104,52 @@ type DeploymentPreparer struct {
 	targetProvider                          biinstall.TargetProvider
 }
 
-func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, skipDrain bool) (err error) {
+func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, skipDrain bool, dryRun bool, diff bool, resume bool) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
-	if !c.deploymentStateService.Exists() {
-		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+	var deploymentState biconfig.DeploymentState
+
+	// Dry run must not write to disk, so state is neither locked, migrated nor initialized
+	if dryRun {
+		deploymentState, err = c.loadReadOnlyDeploymentState()
 		if err != nil {
-			return bosherr.WrapError(err, "Migrating legacy deployment state file")
+			return err
 		}
-		if migrated {
-			c.ui.BeginLinef("Migrated legacy deployments file: '%s'\n", biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+	} else {
+		err = c.deploymentStateService.Lock()
+		if err != nil {
+			return bosherr.WrapError(err, "Locking deployment state")
 		}
-	}
+		defer func() {
+			unlockErr := c.deploymentStateService.Unlock()
+			if unlockErr != nil {
+				c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+			}
+		}()
 
-	deploymentState, err := c.deploymentStateService.Load()
-	if err != nil {
-		return bosherr.WrapError(err, "Loading deployment state")
+		if !c.deploymentStateService.Exists() {
+			migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+			if err != nil {
+				return bosherr.WrapError(err, "Migrating legacy deployment state file")
+			}
+			if migrated {
+				c.ui.BeginLinef("Migrated legacy deployments file: '%s'\n", biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+			}
+		}
+
+		deploymentState, err = c.deploymentStateService.Load()
+		if err != nil {
+			return bosherr.WrapError(err, "Loading deployment state")
+		}
 	}
 
-	target, err := c.targetProvider.NewTarget()
+	var target biinstall.Target
+
+	if dryRun {
+		target, err = c.targetProvider.NewReadOnlyTarget()
+	} else {
+		target, err = c.targetProvider.NewTarget()
+	}
 	if err != nil {
 		return bosherr.WrapError(err, "Determining installation target")
 	}
@@ -179,6 +209,23 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 		}
 	}()
 
+	if dryRun || diff {
+		plan, err := bidepl.NewPlan(deploymentState, manifestSHA, deploymentManifest, c.releaseManager.List(), extractedStemcell, recreate, recreatePersistentDisks)
+		if err != nil {
+			return bosherr.WrapError(err, "Planning deployment changes")
+		}
+
+		// Without dry run lack of changes is reported below when skipping deploy
+		if dryRun || plan.HasChanges() {
+			c.printPlan(plan, diff)
+		}
+
+		if dryRun {
+			c.ui.BeginLinef("Dry run: skipping deploy.\n")
+			return nil
+		}
+	}
+
 	isDeployed, err := c.deploymentRecord.IsDeployed(manifestSHA, c.releaseManager.List(), extractedStemcell)
 	if err != nil {
 		return bosherr.WrapError(err, "Checking if deployment has changed")
@@ -206,6 +253,7 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 				deploymentManifest,
 				manifestSHA,
 				skipDrain,
+				resume,
 				stage,
 				cloud,
 			)
@@ -229,6 +277,47 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 	return err
 }
 
+func (c *DeploymentPreparer) loadReadOnlyDeploymentState() (biconfig.DeploymentState, error) {
+	if !c.deploymentStateService.Exists() {
+		legacyPath := biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath)
+
+		deploymentState, found, err := c.legacyDeploymentStateMigrator.ReadIfExists(legacyPath)
+		if err != nil {
+			return biconfig.DeploymentState{}, bosherr.WrapError(err, "Reading legacy deployment state file")
+		}
+		if found {
+			c.ui.BeginLinef("Using legacy deployments file without migrating it: '%s'\n", legacyPath)
+			return deploymentState, nil
+		}
+	}
+
+	deploymentState, err := c.deploymentStateService.LoadReadOnly()
+	if err != nil {
+		return biconfig.DeploymentState{}, bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	return deploymentState, nil
+}
+
+func (c *DeploymentPreparer) printPlan(plan bidepl.Plan, diff bool) {
+	if !plan.HasChanges() {
+		c.ui.BeginLinef("No deployment, stemcell or release changes.\n")
+		return
+	}
+
+	c.ui.BeginLinef("Changes:\n")
+
+	for _, change := range plan.Changes {
+		c.ui.BeginLinef("  %s\n", change.Description)
+
+		if diff {
+			for _, detail := range change.Details {
+				c.ui.BeginLinef("    %s\n", detail)
+			}
+		}
+	}
+}
+
 func (c *DeploymentPreparer) deploy(
 	installation biinstall.Installation,
 	deploymentState biconfig.DeploymentState,
@@ -237,9 +326,15 @@ func (c *DeploymentPreparer) deploy(
 	deploymentManifest bideplmanifest.Manifest,
 	manifestSHA string,
 	skipDrain bool,
+	resume bool,
 	stage biui.Stage,
 	cloud bicloud.Cloud,
 ) (err error) {
+	resume, err = c.startProgress(manifestSHA, resume)
+	if err != nil {
+		return err
+	}
+
 	stemcellManager := c.stemcellManagerFactory.NewManager(cloud)
 
 	cloudStemcell, err := stemcellManager.Upload(extractedStemcell, stage)
@@ -247,6 +342,11 @@ func (c *DeploymentPreparer) deploy(
 		return err
 	}
 
+	err = c.deployProgressRepo.MarkStemcellUploaded()
+	if err != nil {
+		return bosherr.WrapError(err, "Journaling deploy progress")
+	}
+
 	agentClient, err := c.agentClientFactory.NewAgentClient(deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
 	if err != nil {
 		return err
@@ -270,7 +370,9 @@ func (c *DeploymentPreparer) deploy(
 			cloudStemcell,
 			vmManager,
 			blobstore,
+			newAgentProvider(c.agentClientFactory, c.blobstoreFactory, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA),
 			skipDrain,
+			resume,
 			deployStage,
 		)
 		if err != nil {
@@ -282,6 +384,11 @@ func (c *DeploymentPreparer) deploy(
 			return bosherr.WrapError(err, "Updating deployment record")
 		}
 
+		err = c.deployProgressRepo.Clear()
+		if err != nil {
+			return bosherr.WrapError(err, "Clearing deploy progress")
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -298,6 +405,31 @@ func (c *DeploymentPreparer) deploy(
 	return nil
 }
 
+// startProgress starts journaling a new deploy unless an interrupted deploy
+// of the same manifest is to be resumed. It returns whether to resume.
+func (c *DeploymentPreparer) startProgress(manifestSHA string, resume bool) (bool, error) {
+	if resume {
+		progress, found, err := c.deployProgressRepo.Find()
+		if err != nil {
+			return false, bosherr.WrapError(err, "Finding deploy progress")
+		}
+
+		if found && progress.ManifestSHA == manifestSHA {
+			c.ui.BeginLinef("Resuming interrupted deploy.\n")
+			return true, nil
+		}
+
+		c.ui.BeginLinef("No interrupted deploy of this manifest to resume. Deploying from the start.\n")
+	}
+
+	err := c.deployProgressRepo.Start(manifestSHA)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Journaling deploy progress")
+	}
+
+	return false, nil
+}
+
 func (c *DeploymentPreparer) stemcellApiVersion(stemcell bistemcell.ExtractedStemcell) int {
 	stemcellApiVersion := stemcell.Manifest().ApiVersion
 	if stemcellApiVersion == 0 {
@@ -100,6 +100,17 @@ func (c *deploymentStateManager) executeStateChange(stage biui.Stage, stateChang
 		return nil
 	}
 
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
 	deploymentState, err := c.deploymentStateService.Load()
 	if err != nil {
 		return bosherr.WrapError(err, "Loading deployment state")
@@ -162,5 +173,7 @@ func (c *deploymentStateManager) deploymentManager(directorID, installationMbus,
 
 	c.logger.Debug(c.logTag, "Creating deployment manager...")
 
-	return c.deploymentManagerFactory.NewManager(nil, agentClient, nil), err
+	agentProvider := newAgentProvider(c.agentClientFactory, nil, directorID, installationMbus, caCert)
+
+	return c.deploymentManagerFactory.NewManager(nil, agentClient, nil, agentProvider), err
 }
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type DriftCmd struct {
+	ui       boshui.UI
+	director boshdir.Director
+	fs       boshsys.FileSystem
+}
+
+func NewDriftCmd(ui boshui.UI, director boshdir.Director, fs boshsys.FileSystem) DriftCmd {
+	return DriftCmd{ui: ui, director: director, fs: fs}
+}
+
+// Run compares each deployment with a manifest named after it
+// in the manifests directory, e.g. 'cf.yml' for deployment 'cf'.
+func (c DriftCmd) Run(opts DriftOpts) error {
+	dirPath := opts.Manifests.Path
+
+	deployments, err := c.director.Deployments()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "drift",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Deployment"),
+			boshtbl.NewHeader("Status"),
+			boshtbl.NewHeader("Changes"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	var drifted, failed int
+
+	deployed := map[string]struct{}{}
+
+	for _, deployment := range deployments {
+		name := deployment.Name()
+		deployed[name] = struct{}{}
+
+		path, found := c.manifestPath(dirPath, name)
+		if !found {
+			drifted++
+			table.Rows = append(table.Rows, c.row(name, "no manifest", boshtbl.ValueNone{}))
+			continue
+		}
+
+		changes, err := c.changes(deployment, path, opts)
+		if err != nil {
+			failed++
+			table.Rows = append(table.Rows, []boshtbl.Value{
+				boshtbl.NewValueString(name),
+				boshtbl.NewValueFmt(boshtbl.NewValueString(err.Error()), true),
+				boshtbl.ValueNone{},
+			})
+			continue
+		}
+
+		count := c.changedLines(changes)
+		if count == 0 {
+			table.Rows = append(table.Rows, c.row(name, "in sync", boshtbl.NewValueInt(0)))
+			continue
+		}
+
+		drifted++
+		table.Rows = append(table.Rows, c.row(name, "drifted", boshtbl.NewValueInt(count)))
+
+		if opts.Diff {
+			c.ui.PrintLinef("Deployment '%s':", name)
+			NewDiff(changes).Print(c.ui)
+		}
+	}
+
+	undeployed, err := c.undeployedManifests(dirPath, deployed)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range undeployed {
+		drifted++
+		table.Rows = append(table.Rows, c.row(name, "not deployed", boshtbl.ValueNone{}))
+	}
+
+	c.ui.PrintTable(table)
+
+	if drifted > 0 || failed > 0 {
+		return bosherr.Errorf("%d deployment(s) drifted, %d could not be checked", drifted, failed)
+	}
+
+	return nil
+}
+
+// changes returns the diff of the local manifest against the deployment.
+// Director is only asked for a diff if manifests are not the same.
+func (c DriftCmd) changes(deployment boshdir.Deployment, path string, opts DriftOpts) ([][]interface{}, error) {
+	bytes, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading manifest '%s'", path)
+	}
+
+	tpl := boshtpl.NewTemplate(bytes)
+
+	bytes, err = tpl.Evaluate(opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Evaluating manifest '%s'", path)
+	}
+
+	liveManifest, err := deployment.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var local, live interface{}
+
+	if yaml.Unmarshal(bytes, &local) == nil && yaml.Unmarshal([]byte(liveManifest), &live) == nil {
+		if reflect.DeepEqual(local, live) {
+			return nil, nil
+		}
+	}
+
+	diff, err := deployment.Diff(bytes, opts.NoRedact)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff.Diff, nil
+}
+
+func (c DriftCmd) changedLines(lines [][]interface{}) int {
+	var count int
+
+	for _, line := range lines {
+		if len(line) > 1 && (line[1] == "added" || line[1] == "removed") {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (c DriftCmd) manifestPath(dirPath, name string) (string, bool) {
+	for _, ext := range []string{".yml", ".yaml"} {
+		path := filepath.Join(dirPath, name+ext)
+
+		if c.fs.FileExists(path) {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+func (c DriftCmd) undeployedManifests(dirPath string, deployed map[string]struct{}) ([]string, error) {
+	var names []string
+
+	for _, ext := range []string{".yml", ".yaml"} {
+		paths, err := c.fs.Glob(filepath.Join(dirPath, "*"+ext))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listing manifests in '%s'", dirPath)
+		}
+
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), ext)
+
+			if _, found := deployed[name]; !found {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+func (c DriftCmd) row(name, status string, changes boshtbl.Value) []boshtbl.Value {
+	return []boshtbl.Value{boshtbl.NewValueString(name), boshtbl.NewValueString(status), changes}
+}
@@ -0,0 +1,179 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("DriftCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		fs       *fakesys.FakeFileSystem
+		app      *fakedir.FakeDeployment
+		db       *fakedir.FakeDeployment
+		command  DriftCmd
+		opts     DriftOpts
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewDriftCmd(ui, director, fs)
+
+		opts = DriftOpts{
+			Manifests: DirOrCWDArg{Path: "/manifests"},
+			VarFlags: VarFlags{
+				VarKVs: []boshtpl.VarKV{{Name: "instances", Value: 2}},
+			},
+		}
+
+		app = &fakedir.FakeDeployment{NameStub: func() string { return "app" }}
+		app.ManifestReturns("name: app\ninstances: 2\n", nil)
+
+		db = &fakedir.FakeDeployment{NameStub: func() string { return "db" }}
+		db.ManifestReturns("name: db\ninstances: 1\n", nil)
+		db.DiffReturns(boshdir.DeploymentDiff{Diff: [][]interface{}{
+			{"name: db", ""},
+			{"instances: 1", "removed"},
+			{"instances: 2", "added"},
+		}}, nil)
+
+		director.DeploymentsReturns([]boshdir.Deployment{app, db}, nil)
+
+		Expect(fs.WriteFileString("/manifests/app.yml", "name: app\ninstances: ((instances))\n")).To(Succeed())
+		Expect(fs.WriteFileString("/manifests/db.yaml", "name: db\ninstances: ((instances))\n")).To(Succeed())
+		fs.SetGlob("/manifests/*.yml", []string{"/manifests/app.yml"})
+		fs.SetGlob("/manifests/*.yaml", []string{"/manifests/db.yaml"})
+	})
+
+	Describe("Run", func() {
+		It("reports deployments that differ from interpolated manifests", func() {
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 deployment(s) drifted, 0 could not be checked"))
+
+			Expect(app.DiffCallCount()).To(Equal(0))
+			Expect(db.DiffCallCount()).To(Equal(1))
+
+			bytes, noRedact := db.DiffArgsForCall(0)
+			Expect(bytes).To(Equal([]byte("instances: 2\nname: db\n")))
+			Expect(noRedact).To(BeFalse())
+
+			Expect(ui.Said).To(BeEmpty())
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "drift",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Deployment"),
+					boshtbl.NewHeader("Status"),
+					boshtbl.NewHeader("Changes"),
+				},
+				SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("app"), boshtbl.NewValueString("in sync"), boshtbl.NewValueInt(0)},
+					{boshtbl.NewValueString("db"), boshtbl.NewValueString("drifted"), boshtbl.NewValueInt(2)},
+				},
+			}))
+		})
+
+		It("succeeds when all deployments are in sync", func() {
+			db.DiffReturns(boshdir.DeploymentDiff{Diff: [][]interface{}{{"name: db", ""}}}, nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Rows[1]).To(Equal([]boshtbl.Value{
+				boshtbl.NewValueString("db"), boshtbl.NewValueString("in sync"), boshtbl.NewValueInt(0),
+			}))
+		})
+
+		It("shows differences when requested", func() {
+			opts.Diff = true
+			opts.NoRedact = true
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+
+			_, noRedact := db.DiffArgsForCall(0)
+			Expect(noRedact).To(BeTrue())
+
+			Expect(ui.Said).To(Equal([]string{
+				"Deployment 'db':",
+				"  name: db\n",
+				"- instances: 1\n",
+				"+ instances: 2\n",
+			}))
+		})
+
+		It("reports deployments without manifests and manifests without deployments", func() {
+			Expect(fs.RemoveAll("/manifests/db.yaml")).To(Succeed())
+			Expect(fs.WriteFileString("/manifests/cache.yml", "name: cache")).To(Succeed())
+			fs.SetGlob("/manifests/*.yml", []string{"/manifests/app.yml", "/manifests/cache.yml"})
+			fs.SetGlob("/manifests/*.yaml", []string{})
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("2 deployment(s) drifted, 0 could not be checked"))
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{boshtbl.NewValueString("app"), boshtbl.NewValueString("in sync"), boshtbl.NewValueInt(0)},
+				{boshtbl.NewValueString("db"), boshtbl.NewValueString("no manifest"), boshtbl.ValueNone{}},
+				{boshtbl.NewValueString("cache"), boshtbl.NewValueString("not deployed"), boshtbl.ValueNone{}},
+			}))
+		})
+
+		It("reports deployments that could not be checked and continues", func() {
+			app.ManifestReturns("", errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 deployment(s) drifted, 1 could not be checked"))
+
+			Expect(ui.Table.Rows[0]).To(Equal([]boshtbl.Value{
+				boshtbl.NewValueString("app"),
+				boshtbl.NewValueFmt(boshtbl.NewValueString("fake-err"), true),
+				boshtbl.ValueNone{},
+			}))
+		})
+
+		It("reports manifests that cannot be interpolated", func() {
+			opts.VarFlags = VarFlags{}
+			opts.OpsFlags = OpsFlags{}
+			Expect(fs.WriteFileString("/manifests/app.yml", "name: [")).To(Succeed())
+			Expect(fs.WriteFileString("/manifests/db.yaml", "name: db\ninstances: 1\n")).To(Succeed())
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+
+			Expect(ui.Table.Rows[0][1].String()).To(ContainSubstring("Evaluating manifest '/manifests/app.yml'"))
+		})
+
+		It("returns error if deployments cannot be listed", func() {
+			director.DeploymentsReturns(nil, errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+
+		It("returns error if manifests cannot be listed", func() {
+			fs.GlobErrs = map[string]error{"/manifests/*.yml": errors.New("fake-err")}
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Listing manifests in '/manifests'"))
+		})
+	})
+})
@@ -11,6 +11,7 @@ import (
 	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+	bicpifake "github.com/cloudfoundry/bosh-cli/v7/cpi/fake"
 	bicpirel "github.com/cloudfoundry/bosh-cli/v7/cpi/release"
 	bidepl "github.com/cloudfoundry/bosh-cli/v7/deployment"
 	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
@@ -25,6 +26,7 @@ import (
 	biindex "github.com/cloudfoundry/bosh-cli/v7/index"
 	boshinst "github.com/cloudfoundry/bosh-cli/v7/installation"
 	boshinstmanifest "github.com/cloudfoundry/bosh-cli/v7/installation/manifest"
+	biinstallpkg "github.com/cloudfoundry/bosh-cli/v7/installation/pkg"
 	bitarball "github.com/cloudfoundry/bosh-cli/v7/installation/tarball"
 	boshrel "github.com/cloudfoundry/bosh-cli/v7/release"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/v7/release/set/manifest"
@@ -62,6 +64,7 @@ type envFactory struct {
 	blobstoreFactory   biblobstore.Factory
 	deploymentFactory  bidepl.Factory
 	deploymentRecord   bidepl.Record
+	deployProgressRepo biconfig.DeployProgressRepo
 }
 
 func NewEnvFactory(
@@ -71,6 +74,9 @@ func NewEnvFactory(
 	manifestVars boshtpl.Variables,
 	manifestOp patch.Op,
 	recreatePersistentDisks bool,
+	recordCPIDir string,
+	replayCPIDir string,
+	parallel int,
 ) *envFactory {
 	f := envFactory{
 		deps:         deps,
@@ -110,13 +116,14 @@ func NewEnvFactory(
 		}
 	}
 
-	f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
-		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
+	f.deploymentStateService = biconfig.NewDeploymentStateService(
+		biconfig.NewStateBackend(deps.FS, biconfig.DeploymentStatePath(manifestPath, statePath)), deps.UUIDGen, deps.Logger)
 
 	{
 		installerFactory := boshinst.NewInstallerFactory(
 			deps.UI, deps.CmdRunner, deps.Compressor, releaseJobResolver,
-			deps.UUIDGen, deps.Logger, deps.FS, deps.DigestCreationAlgorithms)
+			deps.UUIDGen, deps.Logger, deps.FS, deps.DigestCreationAlgorithms,
+			biinstallpkg.NewCompiledPackageCache(compiledPackageCachePath(workspaceRootPath), deps.FS, deps.Logger), parallel)
 
 		f.cpiInstaller = bicpirel.CpiInstaller{
 			ReleaseManager:   f.releaseManager,
@@ -138,22 +145,30 @@ func NewEnvFactory(
 
 		f.stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
 		f.vmManagerFactory = bivm.NewManagerFactory(
-			vmRepo, stemcellRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.Logger)
+			f.deploymentStateService, vmRepo, stemcellRepo, diskDeployer, recreatePersistentDisks, deps.UUIDGen, deps.FS, deps.Logger)
 
 		deploymentRepo := biconfig.NewDeploymentRepo(f.deploymentStateService)
 		releaseRepo := biconfig.NewReleaseRepo(f.deploymentStateService, deps.UUIDGen)
 		f.deploymentRecord = bidepl.NewRecord(deploymentRepo, releaseRepo, stemcellRepo)
+		f.deployProgressRepo = biconfig.NewDeployProgressRepo(f.deploymentStateService)
 	}
 
 	{
-		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger)
+		f.blobstoreFactory = bicpifake.NewBlobstoreFactory(
+			biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger), deps.FS, deps.UUIDGen, deps.Logger)
 		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond)
-		f.agentClientFactory = bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger)
-		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
+		f.agentClientFactory = bicpifake.NewAgentClientFactory(
+			bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger), deps.FS, deps.Logger)
+		f.cloudFactory = bicpifake.NewCloudFactory(
+			newCloudFactory(deps, recordCPIDir, replayCPIDir), deps.FS, deps.UUIDGen, deps.Logger)
 	}
 
 	{
-		erbRenderer := bitemplateerb.NewERBRenderer(deps.FS, deps.CmdRunner, deps.Logger)
+		erbRenderer := bitemplateerb.NewNativeERBRenderer(
+			deps.FS,
+			bitemplateerb.NewERBRenderer(deps.FS, deps.CmdRunner, deps.Logger),
+			deps.Logger,
+		)
 		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, deps.FS, deps.UUIDGen, deps.Logger)
 
 		builderFactory := biinstancestate.NewBuilderFactory(
@@ -167,8 +182,10 @@ func NewEnvFactory(
 		sshTunnelFactory := bisshtunnel.NewFactory(deps.Logger)
 		instanceFactory := biinstance.NewFactory(builderFactory)
 
+		instanceRepo := biconfig.NewInstanceRepo(f.deploymentStateService)
+
 		f.instanceManagerFactory = biinstance.NewManagerFactory(
-			sshTunnelFactory, instanceFactory, deps.Logger)
+			f.vmManagerFactory, instanceRepo, f.deployProgressRepo, sshTunnelFactory, instanceFactory, deps.Logger)
 	}
 
 	{
@@ -201,6 +218,7 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 		),
 		f.releaseManager,
 		f.deploymentRecord,
+		f.deployProgressRepo,
 		f.cloudFactory,
 		f.stemcellManagerFactory,
 		f.agentClientFactory,
@@ -210,6 +228,7 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 			f.vmManagerFactory,
 			f.instanceManagerFactory,
 			f.deploymentFactory,
+			f.deployProgressRepo,
 			f.deps.Logger,
 		),
 		f.manifestPath,
@@ -285,3 +304,22 @@ func (f *envFactory) StateManager() DeploymentStateManager {
 		),
 	)
 }
+
+func newCloudFactory(deps BasicDeps, recordCPIDir, replayCPIDir string) bicloud.Factory {
+	if replayCPIDir != "" {
+		return bicloud.NewReplayFactory(deps.FS, replayCPIDir, deps.Logger)
+	}
+
+	if recordCPIDir != "" {
+		return bicloud.NewRecordingFactory(deps.FS, deps.CmdRunner, recordCPIDir, deps.Logger)
+	}
+
+	return bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
+}
+
+// compiledPackageCachePath is shared by all installations. Packages are reused
+// by installations with the same packages dir, e.g. when an environment is
+// created again, since they may refer to the paths they were compiled into.
+func compiledPackageCachePath(workspaceRootPath string) string {
+	return filepath.Join(workspaceRootPath, "compiled-packages")
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+const exportedConfigsIndex = "configs.yml"
+
+// exportedConfigs is the index of an export-configs directory.
+// Contents of each config are kept in a separate file so that
+// they can be reviewed and edited before importing them.
+type exportedConfigs struct {
+	Configs []exportedConfig `yaml:"configs"`
+}
+
+type exportedConfig struct {
+	Type      string `yaml:"type"`
+	Name      string `yaml:"name"`
+	ID        string `yaml:"id"`
+	Team      string `yaml:"team,omitempty"`
+	CreatedAt string `yaml:"created_at"`
+	Path      string `yaml:"path"` // relative to the directory
+}
+
+type ExportConfigsCmd struct {
+	ui       boshui.UI
+	director boshdir.Director
+	fs       boshsys.FileSystem
+}
+
+func NewExportConfigsCmd(ui boshui.UI, director boshdir.Director, fs boshsys.FileSystem) ExportConfigsCmd {
+	return ExportConfigsCmd{ui: ui, director: director, fs: fs}
+}
+
+func (c ExportConfigsCmd) Run(opts ExportConfigsOpts) error {
+	configs, err := c.director.ListConfigs(1, boshdir.ConfigsFilter{})
+	if err != nil {
+		return err
+	}
+
+	dirPath := opts.Args.Directory.Path
+
+	table := boshtbl.Table{
+		Content: "configs",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Path"),
+		},
+	}
+
+	var index exportedConfigs
+
+	usedPaths := map[string]bool{}
+
+	for _, config := range configs {
+		path := c.configPath(config, usedPaths)
+
+		err := c.fs.MkdirAll(filepath.Join(dirPath, config.Type), 0755)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating directory for configs of type '%s'", config.Type)
+		}
+
+		err = c.fs.WriteFileString(filepath.Join(dirPath, path), config.Content)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing config '%s' of type '%s'", config.Name, config.Type)
+		}
+
+		index.Configs = append(index.Configs, exportedConfig{
+			Type:      config.Type,
+			Name:      config.Name,
+			ID:        config.ID,
+			Team:      config.Team,
+			CreatedAt: config.CreatedAt,
+			Path:      path,
+		})
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(config.ID),
+			boshtbl.NewValueString(config.Type),
+			boshtbl.NewValueString(config.Name),
+			boshtbl.NewValueString(path),
+		})
+	}
+
+	bytes, err := yaml.Marshal(index)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling configs index")
+	}
+
+	err = c.fs.WriteFile(filepath.Join(dirPath, exportedConfigsIndex), bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing configs index")
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+// configPath returns a path for the config that no other config uses.
+// Names such as 'a/b' and 'a_b' would otherwise share a file; index
+// keeps the original names so that imports do not depend on paths.
+func (c ExportConfigsCmd) configPath(config boshdir.Config, usedPaths map[string]bool) string {
+	base := filepath.Join(config.Type, strings.Replace(config.Name, "/", "_", -1))
+	path := base + ".yml"
+
+	// Paths differing only in case collide on some file systems
+	for i := 2; usedPaths[strings.ToLower(path)]; i++ {
+		path = fmt.Sprintf("%s-%d.yml", base, i)
+	}
+
+	usedPaths[strings.ToLower(path)] = true
+
+	return path
+}
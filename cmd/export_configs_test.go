@@ -0,0 +1,125 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("ExportConfigsCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		fs       *fakesys.FakeFileSystem
+		command  ExportConfigsCmd
+		opts     ExportConfigsOpts
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewExportConfigsCmd(ui, director, fs)
+		opts = ExportConfigsOpts{Args: ConfigsDirArgs{Directory: DirOrCWDArg{Path: "/export"}}}
+
+		director.ListConfigsReturns([]boshdir.Config{
+			{ID: "1", Type: "cloud", Name: "default", CreatedAt: "2019-01-01", Content: "azs: []", Current: true},
+			{ID: "2", Type: "runtime", Name: "dns/aliases", Team: "ops", CreatedAt: "2019-01-02", Content: "addons: []", Current: true},
+		}, nil)
+	})
+
+	Describe("Run", func() {
+		It("writes latest configs and an index to the directory", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.ListConfigsCallCount()).To(Equal(1))
+			limit, filter := director.ListConfigsArgsForCall(0)
+			Expect(limit).To(Equal(1))
+			Expect(filter).To(Equal(boshdir.ConfigsFilter{}))
+
+			Expect(fs.ReadFileString("/export/cloud/default.yml")).To(Equal("azs: []"))
+			Expect(fs.ReadFileString("/export/runtime/dns_aliases.yml")).To(Equal("addons: []"))
+			Expect(fs.ReadFileString("/export/configs.yml")).To(Equal(`configs:
+- type: cloud
+  name: default
+  id: "1"
+  created_at: "2019-01-01"
+  path: cloud/default.yml
+- type: runtime
+  name: dns/aliases
+  id: "2"
+  team: ops
+  created_at: "2019-01-02"
+  path: runtime/dns_aliases.yml
+`))
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("1"),
+					boshtbl.NewValueString("cloud"),
+					boshtbl.NewValueString("default"),
+					boshtbl.NewValueString("cloud/default.yml"),
+				},
+				{
+					boshtbl.NewValueString("2"),
+					boshtbl.NewValueString("runtime"),
+					boshtbl.NewValueString("dns/aliases"),
+					boshtbl.NewValueString("runtime/dns_aliases.yml"),
+				},
+			}))
+		})
+
+		It("writes configs with names that map to the same file to different files", func() {
+			director.ListConfigsReturns([]boshdir.Config{
+				{ID: "1", Type: "runtime", Name: "a/b", Content: "first"},
+				{ID: "2", Type: "runtime", Name: "a_b", Content: "second"},
+				{ID: "3", Type: "runtime", Name: "A_b", Content: "third"},
+				{ID: "4", Type: "cloud", Name: "a_b", Content: "fourth"},
+			}, nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/export/runtime/a_b.yml")).To(Equal("first"))
+			Expect(fs.ReadFileString("/export/runtime/a_b-2.yml")).To(Equal("second"))
+			Expect(fs.ReadFileString("/export/runtime/A_b-3.yml")).To(Equal("third"))
+			Expect(fs.ReadFileString("/export/cloud/a_b.yml")).To(Equal("fourth"))
+
+			index, err := fs.ReadFileString("/export/configs.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index).To(ContainSubstring("name: a/b\n  id: \"1\"\n  created_at: \"\"\n  path: runtime/a_b.yml\n"))
+			Expect(index).To(ContainSubstring("name: a_b\n  id: \"2\"\n  created_at: \"\"\n  path: runtime/a_b-2.yml\n"))
+		})
+
+		It("returns error if listing configs fails", func() {
+			director.ListConfigsReturns(nil, errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(fs.FileExists("/export/configs.yml")).To(BeFalse())
+		})
+
+		It("returns error if writing config fails", func() {
+			fs.WriteFileErrors["/export/runtime/dns_aliases.yml"] = errors.New("fake-err")
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Writing config 'dns/aliases' of type 'runtime'"))
+
+			Expect(fs.FileExists("/export/configs.yml")).To(BeFalse())
+		})
+	})
+})
@@ -316,6 +316,44 @@ var _ = Describe("Factory", func() {
 		})
 	})
 
+	Describe("certs command", func() {
+		It("is made of subcommands", func() {
+			cmd, err := factory.New([]string{"certs", "expiring", "--threshold", "10"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*CertsExpiringOpts)
+			Expect(opts.Threshold).To(Equal(10))
+
+			cmd, err = factory.New([]string{"certs", "plan", "ca"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmd.Opts.(*CertsPlanOpts).Args.Name).To(Equal("ca"))
+		})
+
+		It("requires a subcommand", func() {
+			_, err := factory.New([]string{"certs"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expiring"))
+		})
+	})
+
+	Describe("vars-store command", func() {
+		It("is made of subcommands", func() {
+			cmd, err := factory.New([]string{"vars-store", "check", "--vars-store", "/creds.yml", "--threshold", "10"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*VarsStoreCheckOpts)
+			Expect(opts.VarsFSStore.IsSet()).To(BeTrue())
+			Expect(opts.Threshold).To(Equal(10))
+		})
+
+		It("requires vars store for check", func() {
+			_, err := factory.New([]string{"vars-store", "check"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("vars-store"))
+		})
+	})
+
 	Describe("help command", func() {
 		It("has a help command", func() {
 			cmd, err := factory.New([]string{"help"})
@@ -384,12 +422,19 @@ var _ = Describe("Factory", func() {
 			boshOpts.RemoveBlob = RemoveBlobOpts{}
 			boshOpts.SyncBlobs = SyncBlobsOpts{}
 			boshOpts.UploadBlobs = UploadBlobsOpts{}
+			boshOpts.MigrateBlobstore = MigrateBlobstoreOpts{}
+			boshOpts.AuditBlobstore = AuditBlobstoreOpts{}
+			boshOpts.RenderJob = RenderJobOpts{}
+			boshOpts.LintRelease = LintReleaseOpts{}
 			boshOpts.SSH = SSHOpts{}
 			boshOpts.SCP = SCPOpts{}
 			boshOpts.Deploy = DeployOpts{}
 			boshOpts.UpdateRuntimeConfig = UpdateRuntimeConfigOpts{}
 			boshOpts.VMs = VMsOpts{}
 			boshOpts.Instances = InstancesOpts{}
+			boshOpts.WaitHealthy = WaitHealthyOpts{}
+			boshOpts.Certs = CertsOpts{}
+			boshOpts.VarsStore = VarsStoreOpts{}
 			boshOpts.Config = ConfigOpts{}
 			boshOpts.Configs = ConfigsOpts{}
 			boshOpts.UpdateConfig = UpdateConfigOpts{}
@@ -404,8 +449,10 @@ var _ = Describe("Factory", func() {
 
 			// Check against entire BoshOpts to avoid future missing assertions
 			Expect(clearNonGlobalOpts(cmd.BoshOpts)).To(Equal(BoshOpts{
-				ConfigPathOpt: "~/.bosh/config",
-				Parallel:      5,
+				ConfigPathOpt:       "~/.bosh/config",
+				Parallel:            5,
+				BlobCachePathOpt:    "~/.bosh/cache/blobs",
+				BlobCacheMaxSizeOpt: 10240,
 			}))
 		})
 
@@ -422,6 +469,8 @@ var _ = Describe("Factory", func() {
 				"--no-color",
 				"--non-interactive",
 				"--parallel", "123",
+				"--blob-cache", "cache",
+				"--blob-cache-max-size", "0",
 				"locks",
 			}
 
@@ -440,6 +489,7 @@ var _ = Describe("Factory", func() {
 				NoColorOpt:        true,
 				NonInteractiveOpt: true,
 				Parallel:          123,
+				BlobCachePathOpt:  "cache",
 			}))
 		})
 
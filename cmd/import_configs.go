@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type ImportConfigsCmd struct {
+	ui       boshui.UI
+	director boshdir.Director
+	fs       boshsys.FileSystem
+}
+
+type importedConfig struct {
+	exportedConfig
+
+	content []byte
+	fromID  string
+}
+
+func NewImportConfigsCmd(ui boshui.UI, director boshdir.Director, fs boshsys.FileSystem) ImportConfigsCmd {
+	return ImportConfigsCmd{ui: ui, director: director, fs: fs}
+}
+
+func (c ImportConfigsCmd) Run(opts ImportConfigsOpts) error {
+	dirPath := opts.Args.Directory.Path
+
+	bytes, err := c.fs.ReadFile(filepath.Join(dirPath, exportedConfigsIndex))
+	if err != nil {
+		return bosherr.WrapError(err, "Reading configs index")
+	}
+
+	var index exportedConfigs
+
+	err = yaml.Unmarshal(bytes, &index)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling configs index")
+	}
+
+	var changed []importedConfig
+
+	for _, config := range index.Configs {
+		content, err := c.fs.ReadFile(filepath.Join(dirPath, config.Path))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading config '%s' of type '%s'", config.Name, config.Type)
+		}
+
+		configDiff, err := c.director.DiffConfig(config.Type, config.Name, content)
+		if err != nil {
+			return err
+		}
+
+		if len(configDiff.Diff) == 0 {
+			continue
+		}
+
+		c.ui.PrintLinef("Config '%s' of type '%s':", config.Name, config.Type)
+		NewDiff(configDiff.Diff).Print(c.ui)
+
+		changed = append(changed, importedConfig{
+			exportedConfig: config,
+			content:        content,
+			fromID:         configDiff.FromId,
+		})
+	}
+
+	if len(changed) == 0 {
+		c.ui.PrintLinef("No configs changed")
+		return nil
+	}
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "configs",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Created At"),
+		},
+	}
+
+	for _, config := range changed {
+		updated, err := c.director.UpdateConfig(config.Type, config.Name, config.fromID, config.content)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Updating config '%s' of type '%s'", config.Name, config.Type)
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(updated.ID),
+			boshtbl.NewValueString(updated.Type),
+			boshtbl.NewValueString(updated.Name),
+			boshtbl.NewValueString(updated.CreatedAt),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("ImportConfigsCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		fs       *fakesys.FakeFileSystem
+		command  ImportConfigsCmd
+		opts     ImportConfigsOpts
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewImportConfigsCmd(ui, director, fs)
+		opts = ImportConfigsOpts{Args: ConfigsDirArgs{Directory: DirOrCWDArg{Path: "/export"}}}
+
+		Expect(fs.WriteFileString("/export/configs.yml", `configs:
+- {type: cloud, name: default, id: "1", path: cloud/default.yml}
+- {type: runtime, name: dns, id: "2", path: runtime/dns.yml}
+`)).To(Succeed())
+		Expect(fs.WriteFileString("/export/cloud/default.yml", "azs: []")).To(Succeed())
+		Expect(fs.WriteFileString("/export/runtime/dns.yml", "addons: []")).To(Succeed())
+
+		director.DiffConfigStub = func(configType, name string, content []byte) (boshdir.ConfigDiff, error) {
+			if configType == "cloud" {
+				return boshdir.ConfigDiff{}, nil
+			}
+			return boshdir.ConfigDiff{
+				Diff:   [][]interface{}{{"addons: []", "added"}},
+				FromId: "5",
+			}, nil
+		}
+
+		director.UpdateConfigReturns(boshdir.Config{ID: "6", Type: "runtime", Name: "dns", CreatedAt: "2019-01-02"}, nil)
+	})
+
+	Describe("Run", func() {
+		It("shows diffs and updates configs that changed", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.DiffConfigCallCount()).To(Equal(2))
+
+			configType, name, content := director.DiffConfigArgsForCall(0)
+			Expect(configType).To(Equal("cloud"))
+			Expect(name).To(Equal("default"))
+			Expect(content).To(Equal([]byte("azs: []")))
+
+			Expect(ui.Said).To(ContainElement("Config 'dns' of type 'runtime':"))
+			Expect(ui.Said).To(ContainElement("+ addons: []\n"))
+			Expect(ui.AskedConfirmationCalled).To(BeTrue())
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(1))
+
+			configType, name, expectedLatestID, content := director.UpdateConfigArgsForCall(0)
+			Expect(configType).To(Equal("runtime"))
+			Expect(name).To(Equal("dns"))
+			Expect(expectedLatestID).To(Equal("5"))
+			Expect(content).To(Equal([]byte("addons: []")))
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{{
+				boshtbl.NewValueString("6"),
+				boshtbl.NewValueString("runtime"),
+				boshtbl.NewValueString("dns"),
+				boshtbl.NewValueString("2019-01-02"),
+			}}))
+		})
+
+		It("does not update configs if confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("stop"))
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(0))
+		})
+
+		It("does not ask for confirmation if no configs changed", func() {
+			director.DiffConfigReturns(boshdir.ConfigDiff{}, nil)
+			director.DiffConfigStub = nil
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{"No configs changed"}))
+			Expect(ui.AskedConfirmationCalled).To(BeFalse())
+			Expect(director.UpdateConfigCallCount()).To(Equal(0))
+		})
+
+		It("returns error if index cannot be read", func() {
+			fs.RemoveAll("/export/configs.yml") //nolint:errcheck
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading configs index"))
+		})
+
+		It("returns error if config file cannot be read", func() {
+			fs.RemoveAll("/export/runtime/dns.yml") //nolint:errcheck
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading config 'dns' of type 'runtime'"))
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(0))
+		})
+
+		It("returns error if diffing fails", func() {
+			director.DiffConfigStub = nil
+			director.DiffConfigReturns(boshdir.ConfigDiff{}, errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+
+		It("returns error if updating fails", func() {
+			director.UpdateConfigReturns(boshdir.Config{}, errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Updating config 'dns' of type 'runtime'"))
+		})
+	})
+})
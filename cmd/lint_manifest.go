@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type LintManifestCmd struct {
+	ui     boshui.UI
+	linter ManifestLinter
+}
+
+func NewLintManifestCmd(ui boshui.UI, linter ManifestLinter) LintManifestCmd {
+	return LintManifestCmd{ui: ui, linter: linter}
+}
+
+func (c LintManifestCmd) Run(opts LintManifestOpts) error {
+	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	return lintManifestBytes(c.ui, c.linter, bytes)
+}
+
+// lintManifestBytes prints all problems found in the manifest
+// and returns an error if there are any.
+func lintManifestBytes(ui boshui.UI, linter ManifestLinter, bytes []byte) error {
+	probs, err := linter.Lint(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Linting manifest")
+	}
+
+	table := boshtbl.Table{
+		Content: "problems",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Resource"),
+			boshtbl.NewHeader("Problem"),
+		},
+	}
+
+	for _, p := range probs {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(p.Resource),
+			boshtbl.NewValueString(p.Message),
+		})
+	}
+
+	ui.PrintTable(table)
+
+	if len(probs) > 0 {
+		return bosherr.Errorf("%d problem(s) found", len(probs))
+	}
+
+	return nil
+}
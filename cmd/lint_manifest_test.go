@@ -0,0 +1,98 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	fakecmd "github.com/cloudfoundry/bosh-cli/v7/cmd/cmdfakes"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("LintManifestCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		linter  *fakecmd.FakeManifestLinter
+		command LintManifestCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		linter = &fakecmd.FakeManifestLinter{}
+		command = NewLintManifestCmd(ui, linter)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts LintManifestOpts
+		)
+
+		BeforeEach(func() {
+			opts = LintManifestOpts{
+				Args: DeployArgs{
+					Manifest: FileBytesArg{Bytes: []byte("name: dep\nnetwork: ((network))")},
+				},
+			}
+			opts.VarKVs = []boshtpl.VarKV{{Name: "network", Value: "default"}}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("lints interpolated manifest and prints empty table when there are no problems", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(linter.LintArgsForCall(0)).To(Equal([]byte("name: dep\nnetwork: default\n")))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "problems",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Resource"),
+					boshtbl.NewHeader("Problem"),
+				},
+			}))
+		})
+
+		It("prints problems and returns error", func() {
+			linter.LintReturns([]ManifestProblem{
+				{Resource: "releases/app", Message: "Release 'app/1' is not uploaded"},
+				{Resource: "instance_groups/web", Message: "AZ 'z3' is not defined in cloud config"},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("2 problem(s) found"))
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{boshtbl.NewValueString("releases/app"), boshtbl.NewValueString("Release 'app/1' is not uploaded")},
+				{boshtbl.NewValueString("instance_groups/web"), boshtbl.NewValueString("AZ 'z3' is not defined in cloud config")},
+			}))
+		})
+
+		It("returns error if manifest cannot be interpolated", func() {
+			opts.OpsFiles = []OpsFileArg{
+				{Ops: patch.Ops([]patch.Op{patch.ReplaceOp{Path: patch.MustNewPointerFromString("/missing/key"), Value: "val"}})},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Evaluating manifest"))
+		})
+
+		It("returns error if linting fails", func() {
+			linter.LintReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(ui.Tables).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type LintReleaseCmd struct {
+	releaseDir boshreldir.ReleaseDir
+	ui         boshui.UI
+}
+
+func NewLintReleaseCmd(releaseDir boshreldir.ReleaseDir, ui boshui.UI) LintReleaseCmd {
+	return LintReleaseCmd{releaseDir: releaseDir, ui: ui}
+}
+
+func (c LintReleaseCmd) Run(opts LintReleaseOpts) error {
+	probs, err := c.releaseDir.Lint()
+	if err != nil {
+		return bosherr.WrapError(err, "Linting release directory")
+	}
+
+	table := boshtbl.Table{
+		Content: "problems",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Check"),
+			boshtbl.NewHeader("Resource"),
+			boshtbl.NewHeader("Problem"),
+		},
+	}
+
+	for _, p := range probs {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(p.Check),
+			boshtbl.NewValueString(p.Resource),
+			boshtbl.NewValueString(p.Message),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if len(probs) > 0 {
+		return bosherr.Errorf("%d problem(s) found", len(probs))
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("LintReleaseCmd", func() {
+	var (
+		releaseDir *fakereldir.FakeReleaseDir
+		ui         *fakeui.FakeUI
+		command    LintReleaseCmd
+	)
+
+	BeforeEach(func() {
+		releaseDir = &fakereldir.FakeReleaseDir{}
+		ui = &fakeui.FakeUI{}
+		command = NewLintReleaseCmd(releaseDir, ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts LintReleaseOpts
+		)
+
+		BeforeEach(func() {
+			opts = LintReleaseOpts{}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("prints empty table when there are no problems", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "problems",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Check"),
+					boshtbl.NewHeader("Resource"),
+					boshtbl.NewHeader("Problem"),
+				},
+			}))
+		})
+
+		It("prints problems and returns error", func() {
+			releaseDir.LintReturns([]boshreldir.LintProblem{
+				{Check: "missing-package", Resource: "jobs/web", Message: "Package 'ruby' is not a package of the release"},
+				{Check: "unused-template", Resource: "jobs/web", Message: "Template file 'templates/old.erb' is not listed in the spec"},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("2 problem(s) found"))
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("missing-package"),
+					boshtbl.NewValueString("jobs/web"),
+					boshtbl.NewValueString("Package 'ruby' is not a package of the release"),
+				},
+				{
+					boshtbl.NewValueString("unused-template"),
+					boshtbl.NewValueString("jobs/web"),
+					boshtbl.NewValueString("Template file 'templates/old.erb' is not listed in the spec"),
+				},
+			}))
+		})
+
+		It("returns error if linting fails", func() {
+			releaseDir.LintReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(ui.Tables).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	semver "github.com/cppforlife/go-semi-semantic/version"
+	"gopkg.in/yaml.v2"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+)
+
+type ManifestLinter interface {
+	Lint(manifest []byte) ([]ManifestProblem, error)
+}
+
+// ManifestProblem is a part of a deployment manifest that
+// refers to something the director does not have.
+type ManifestProblem struct {
+	Resource string // e.g. 'releases/app' or 'instance_groups/web'
+	Message  string
+}
+
+type DirectorManifestLinter struct {
+	director boshdir.Director
+}
+
+type lintManifest struct {
+	Releases []struct {
+		Name     string
+		Version  string
+		Stemcell struct {
+			OS      string
+			Version string
+		}
+	}
+
+	Stemcells []struct {
+		Alias   string
+		OS      string
+		Name    string
+		Version string
+	}
+
+	InstanceGroups []struct {
+		Name     string
+		AZs      []string `yaml:"azs"`
+		VMType   string   `yaml:"vm_type"`
+		Stemcell string
+		Networks []struct {
+			Name string
+		}
+		Jobs []struct {
+			Name    string
+			Release string
+		}
+	} `yaml:"instance_groups"`
+}
+
+type lintCloudConfig struct {
+	AZs []struct {
+		Name string
+	} `yaml:"azs"`
+	Networks []struct {
+		Name string
+	}
+	VMTypes []struct {
+		Name string
+	} `yaml:"vm_types"`
+}
+
+func NewDirectorManifestLinter(director boshdir.Director) DirectorManifestLinter {
+	return DirectorManifestLinter{director: director}
+}
+
+// Lint checks all releases, stemcells and cloud config references
+// of the manifest and returns every problem it finds.
+func (l DirectorManifestLinter) Lint(bytes []byte) ([]ManifestProblem, error) {
+	var manifest lintManifest
+
+	err := yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling manifest")
+	}
+
+	var problems []ManifestProblem
+
+	releaseJobs := map[string]map[string]struct{}{}
+
+	for _, rel := range manifest.Releases {
+		resource := "releases/" + rel.Name
+
+		var stemcell boshdir.OSVersionSlug
+
+		if len(rel.Stemcell.OS) > 0 && len(rel.Stemcell.Version) > 0 {
+			stemcell = boshdir.NewOSVersionSlug(rel.Stemcell.OS, rel.Stemcell.Version)
+		}
+
+		jobs, found, err := l.releaseJobs(rel.Name, rel.Version, stemcell)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			problems = append(problems, ManifestProblem{
+				Resource: resource,
+				Message:  fmt.Sprintf("Release '%s/%s' is not uploaded", rel.Name, rel.Version),
+			})
+			continue
+		}
+
+		releaseJobs[rel.Name] = jobs
+	}
+
+	stemcells, err := l.director.Stemcells()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding stemcells")
+	}
+
+	aliases := map[string]struct{}{}
+
+	for _, stemcell := range manifest.Stemcells {
+		aliases[stemcell.Alias] = struct{}{}
+
+		if !l.hasStemcell(stemcells, stemcell.OS, stemcell.Name, stemcell.Version) {
+			osOrName := stemcell.OS
+			if len(osOrName) == 0 {
+				osOrName = stemcell.Name
+			}
+
+			problems = append(problems, ManifestProblem{
+				Resource: "stemcells/" + stemcell.Alias,
+				Message:  fmt.Sprintf("Stemcell '%s/%s' is not uploaded", osOrName, stemcell.Version),
+			})
+		}
+	}
+
+	cloudConfig, err := l.cloudConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range manifest.InstanceGroups {
+		resource := "instance_groups/" + group.Name
+
+		addProblem := func(msg string, args ...interface{}) {
+			problems = append(problems, ManifestProblem{Resource: resource, Message: fmt.Sprintf(msg, args...)})
+		}
+
+		for _, az := range group.AZs {
+			if _, found := cloudConfig["azs"][az]; !found {
+				addProblem("AZ '%s' is not defined in cloud config", az)
+			}
+		}
+
+		for _, network := range group.Networks {
+			if _, found := cloudConfig["networks"][network.Name]; !found {
+				addProblem("Network '%s' is not defined in cloud config", network.Name)
+			}
+		}
+
+		if len(group.VMType) > 0 {
+			if _, found := cloudConfig["vm_types"][group.VMType]; !found {
+				addProblem("VM type '%s' is not defined in cloud config", group.VMType)
+			}
+		}
+
+		if len(group.Stemcell) > 0 {
+			if _, found := aliases[group.Stemcell]; !found {
+				addProblem("Stemcell '%s' is not defined in manifest", group.Stemcell)
+			}
+		}
+
+		for _, job := range group.Jobs {
+			jobs, found := releaseJobs[job.Release]
+			if !found {
+				if !l.hasRelease(manifest, job.Release) {
+					addProblem("Release '%s' of job '%s' is not defined in manifest", job.Release, job.Name)
+				}
+				continue
+			}
+
+			if _, found := jobs[job.Name]; !found {
+				addProblem("Job '%s' is not in release '%s'", job.Name, job.Release)
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// releaseJobs returns names of jobs in the release version
+// and whether the director has that release version.
+func (l DirectorManifestLinter) releaseJobs(name, version string, stemcell boshdir.OSVersionSlug) (map[string]struct{}, bool, error) {
+	if version == "latest" {
+		latest, err := l.latestReleaseVersion(name)
+		if err != nil || len(latest) == 0 {
+			return nil, false, err
+		}
+
+		version = latest
+	} else {
+		found, err := l.director.HasRelease(name, version, stemcell)
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Checking release '%s/%s'", name, version)
+		}
+
+		if !found {
+			return nil, false, nil
+		}
+	}
+
+	release, err := l.director.FindRelease(boshdir.NewReleaseSlug(name, version))
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Finding release '%s/%s'", name, version)
+	}
+
+	jobs, err := release.Jobs()
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Finding jobs of release '%s/%s'", name, version)
+	}
+
+	names := map[string]struct{}{}
+
+	for _, job := range jobs {
+		names[job.Name] = struct{}{}
+	}
+
+	return names, true, nil
+}
+
+func (l DirectorManifestLinter) latestReleaseVersion(name string) (string, error) {
+	releases, err := l.director.Releases()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Finding releases")
+	}
+
+	var latest semver.Version
+
+	found := false
+
+	for _, release := range releases {
+		if release.Name() == name && (!found || release.Version().IsGt(latest)) {
+			latest = release.Version()
+			found = true
+		}
+	}
+
+	if !found {
+		return "", nil
+	}
+
+	return latest.String(), nil
+}
+
+func (l DirectorManifestLinter) hasStemcell(stemcells []boshdir.Stemcell, os, name, version string) bool {
+	for _, stemcell := range stemcells {
+		if len(os) > 0 && stemcell.OSName() != os {
+			continue
+		}
+
+		if len(name) > 0 && stemcell.Name() != name {
+			continue
+		}
+
+		if version == "latest" || stemcell.Version().String() == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l DirectorManifestLinter) hasRelease(manifest lintManifest, name string) bool {
+	for _, rel := range manifest.Releases {
+		if rel.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cloudConfig returns names of azs, networks and vm_types
+// merged from all cloud configs like the director does.
+func (l DirectorManifestLinter) cloudConfig() (map[string]map[string]struct{}, error) {
+	configs, err := l.director.ListConfigs(1, boshdir.ConfigsFilter{Type: "cloud"})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding cloud configs")
+	}
+
+	names := map[string]map[string]struct{}{
+		"azs":      {},
+		"networks": {},
+		"vm_types": {},
+	}
+
+	for _, config := range configs {
+		var cloudConfig lintCloudConfig
+
+		err := yaml.Unmarshal([]byte(config.Content), &cloudConfig)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling cloud config '%s'", config.Name)
+		}
+
+		for _, az := range cloudConfig.AZs {
+			names["azs"][az.Name] = struct{}{}
+		}
+
+		for _, network := range cloudConfig.Networks {
+			names["networks"][network.Name] = struct{}{}
+		}
+
+		for _, vmType := range cloudConfig.VMTypes {
+			names["vm_types"][vmType.Name] = struct{}{}
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,202 @@
+package cmd_test
+
+import (
+	"errors"
+
+	semver "github.com/cppforlife/go-semi-semantic/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+)
+
+var _ = Describe("DirectorManifestLinter", func() {
+	var (
+		director *fakedir.FakeDirector
+		release  *fakedir.FakeRelease
+		linter   DirectorManifestLinter
+		manifest []byte
+	)
+
+	BeforeEach(func() {
+		director = &fakedir.FakeDirector{}
+		linter = NewDirectorManifestLinter(director)
+
+		release = &fakedir.FakeRelease{}
+		release.JobsReturns([]boshdir.Job{{Name: "web"}, {Name: "worker"}}, nil)
+
+		director.HasReleaseReturns(true, nil)
+		director.FindReleaseReturns(release, nil)
+
+		director.StemcellsReturns([]boshdir.Stemcell{
+			&fakedir.FakeStemcell{
+				NameStub:    func() string { return "bosh-warden-boshlite-ubuntu-jammy-go_agent" },
+				OSNameStub:  func() string { return "ubuntu-jammy" },
+				VersionStub: func() semver.Version { return semver.MustNewVersionFromString("1.18") },
+			},
+		}, nil)
+
+		director.ListConfigsReturns([]boshdir.Config{
+			{Name: "default", Type: "cloud", Content: "azs: [{name: z1}]\nvm_types: [{name: default}]\nnetworks: [{name: default}]"},
+			{Name: "extra", Type: "cloud", Content: "azs: [{name: z2}]\nnetworks: [{name: private}]"},
+		}, nil)
+
+		manifest = []byte(`
+name: dep
+releases:
+- {name: app, version: "1.1"}
+stemcells:
+- {alias: default, os: ubuntu-jammy, version: latest}
+instance_groups:
+- name: web
+  azs: [z1, z2]
+  vm_type: default
+  stemcell: default
+  networks: [{name: default}, {name: private}]
+  jobs:
+  - {name: web, release: app}
+  - {name: worker, release: app}
+`)
+	})
+
+	Describe("Lint", func() {
+		It("returns no problems when director has everything manifest refers to", func() {
+			problems, err := linter.Lint(manifest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+
+			name, version, stemcell := director.HasReleaseArgsForCall(0)
+			Expect(name).To(Equal("app"))
+			Expect(version).To(Equal("1.1"))
+			Expect(stemcell).To(Equal(boshdir.OSVersionSlug{}))
+
+			Expect(director.FindReleaseArgsForCall(0)).To(Equal(boshdir.NewReleaseSlug("app", "1.1")))
+
+			limit, filter := director.ListConfigsArgsForCall(0)
+			Expect(limit).To(Equal(1))
+			Expect(filter).To(Equal(boshdir.ConfigsFilter{Type: "cloud"}))
+		})
+
+		It("returns every problem in one pass", func() {
+			director.HasReleaseStub = func(name, version string, _ boshdir.OSVersionSlug) (bool, error) {
+				return name == "app", nil
+			}
+
+			problems, err := linter.Lint([]byte(`
+name: dep
+releases:
+- {name: app, version: "1.1"}
+- {name: db, version: "2"}
+stemcells:
+- {alias: default, os: ubuntu-jammy, version: "1.19"}
+- {alias: other, name: bosh-warden-boshlite-ubuntu-jammy-go_agent, version: "1.18"}
+instance_groups:
+- name: web
+  azs: [z1, z3]
+  vm_type: large
+  stemcell: missing
+  networks: [{name: public}]
+  jobs:
+  - {name: web, release: app}
+  - {name: api, release: app}
+  - {name: postgres, release: db}
+  - {name: cache, release: redis}
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]ManifestProblem{
+				{Resource: "releases/db", Message: "Release 'db/2' is not uploaded"},
+				{Resource: "stemcells/default", Message: "Stemcell 'ubuntu-jammy/1.19' is not uploaded"},
+				{Resource: "instance_groups/web", Message: "AZ 'z3' is not defined in cloud config"},
+				{Resource: "instance_groups/web", Message: "Network 'public' is not defined in cloud config"},
+				{Resource: "instance_groups/web", Message: "VM type 'large' is not defined in cloud config"},
+				{Resource: "instance_groups/web", Message: "Stemcell 'missing' is not defined in manifest"},
+				{Resource: "instance_groups/web", Message: "Job 'api' is not in release 'app'"},
+				{Resource: "instance_groups/web", Message: "Release 'redis' of job 'cache' is not defined in manifest"},
+			}))
+		})
+
+		It("checks releases for compiled packages when release specifies stemcell", func() {
+			manifest = []byte(`
+releases:
+- {name: app, version: "1.1", stemcell: {os: ubuntu-jammy, version: "1.18"}}
+`)
+
+			_, err := linter.Lint(manifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, stemcell := director.HasReleaseArgsForCall(0)
+			Expect(stemcell).To(Equal(boshdir.NewOSVersionSlug("ubuntu-jammy", "1.18")))
+		})
+
+		It("uses latest uploaded version for releases with latest version", func() {
+			director.ReleasesReturns([]boshdir.Release{
+				&fakedir.FakeRelease{
+					NameStub:    func() string { return "app" },
+					VersionStub: func() semver.Version { return semver.MustNewVersionFromString("1.2") },
+				},
+				&fakedir.FakeRelease{
+					NameStub:    func() string { return "app" },
+					VersionStub: func() semver.Version { return semver.MustNewVersionFromString("1.10") },
+				},
+				&fakedir.FakeRelease{
+					NameStub:    func() string { return "other" },
+					VersionStub: func() semver.Version { return semver.MustNewVersionFromString("2") },
+				},
+			}, nil)
+
+			problems, err := linter.Lint([]byte(`
+releases:
+- {name: app, version: latest}
+- {name: db, version: latest}
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]ManifestProblem{
+				{Resource: "releases/db", Message: "Release 'db/latest' is not uploaded"},
+			}))
+
+			Expect(director.HasReleaseCallCount()).To(Equal(0))
+			Expect(director.FindReleaseCallCount()).To(Equal(1))
+			Expect(director.FindReleaseArgsForCall(0)).To(Equal(boshdir.NewReleaseSlug("app", "1.10")))
+		})
+
+		It("returns error if manifest cannot be parsed", func() {
+			_, err := linter.Lint([]byte("-"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling manifest"))
+		})
+
+		It("returns error if checking release fails", func() {
+			director.HasReleaseReturns(false, errors.New("fake-err"))
+
+			_, err := linter.Lint(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if finding release jobs fails", func() {
+			release.JobsReturns(nil, errors.New("fake-err"))
+
+			_, err := linter.Lint(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if finding stemcells fails", func() {
+			director.StemcellsReturns(nil, errors.New("fake-err"))
+
+			_, err := linter.Lint(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if cloud config cannot be parsed", func() {
+			director.ListConfigsReturns([]boshdir.Config{{Name: "default", Content: "-"}}, nil)
+
+			_, err := linter.Lint(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling cloud config 'default'"))
+		})
+	})
+})
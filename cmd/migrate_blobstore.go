@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+)
+
+type MigrateBlobstoreCmd struct {
+	migrator boshreldir.BlobstoreMigrator
+	ui       boshui.UI
+}
+
+func NewMigrateBlobstoreCmd(migrator boshreldir.BlobstoreMigrator, ui boshui.UI) MigrateBlobstoreCmd {
+	return MigrateBlobstoreCmd{migrator: migrator, ui: ui}
+}
+
+func (c MigrateBlobstoreCmd) Run(opts MigrateBlobstoreOpts) error {
+	migration, err := c.migrator.Migrate()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Migrating blobstore")
+	}
+
+	c.ui.PrintLinef("Copied %d blob(s) (%d copied by previous attempts)", migration.Copied, migration.Skipped)
+	c.ui.PrintLinef("Switched 'blobstore' in 'config/final.yml' to configuration from '%s'", opts.ToConfig.ExpandedPath)
+
+	if len(opts.ToPrivateConfig.ExpandedPath) > 0 {
+		c.ui.PrintLinef("Move private blobstore options from '%s' into 'config/private.yml'", opts.ToPrivateConfig.ExpandedPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+)
+
+var _ = Describe("MigrateBlobstoreCmd", func() {
+	var (
+		migrator *fakereldir.FakeBlobstoreMigrator
+		ui       *fakeui.FakeUI
+		command  MigrateBlobstoreCmd
+	)
+
+	BeforeEach(func() {
+		migrator = &fakereldir.FakeBlobstoreMigrator{}
+		ui = &fakeui.FakeUI{}
+		command = NewMigrateBlobstoreCmd(migrator, ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts MigrateBlobstoreOpts
+		)
+
+		BeforeEach(func() {
+			opts = MigrateBlobstoreOpts{ToConfig: FileArg{ExpandedPath: "/new-final.yml"}}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("migrates blobs and prints summary", func() {
+			migrator.MigrateReturns(boshreldir.BlobstoreMigration{Copied: 2, Skipped: 1}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(migrator.MigrateCallCount()).To(Equal(1))
+
+			Expect(ui.Said).To(Equal([]string{
+				"Copied 2 blob(s) (1 copied by previous attempts)",
+				"Switched 'blobstore' in 'config/final.yml' to configuration from '/new-final.yml'",
+			}))
+		})
+
+		It("reminds to move private options of new blobstore", func() {
+			opts.ToPrivateConfig = FileArg{ExpandedPath: "/new-private.yml"}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement(
+				"Move private blobstore options from '/new-private.yml' into 'config/private.yml'"))
+		})
+
+		It("returns error if migration fails", func() {
+			migrator.MigrateReturns(boshreldir.BlobstoreMigration{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(ui.Said).To(BeEmpty())
+		})
+	})
+})
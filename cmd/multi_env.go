@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"strings"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+// EnvRunFunc runs a command against a single environment
+// printing its tables to the given UI.
+type EnvRunFunc func(environment string, ui boshui.UI) error
+
+// MultiEnvRunner runs a read command against multiple environments
+// and prints tables of all environments merged together.
+type MultiEnvRunner struct {
+	ui          boshui.UI
+	run         EnvRunFunc
+	maxParallel int
+}
+
+type envResult struct {
+	environment string
+	tables      []boshtbl.Table
+	err         error
+}
+
+func NewMultiEnvRunner(ui boshui.UI, run EnvRunFunc, maxParallel int) MultiEnvRunner {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+
+	return MultiEnvRunner{ui: ui, run: run, maxParallel: maxParallel}
+}
+
+func (r MultiEnvRunner) Run(environments []string) error {
+	if len(environments) == 0 {
+		return bosherr.Error("Expected at least one environment")
+	}
+
+	results := make([]envResult, len(environments))
+
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, r.maxParallel)
+
+	for i, env := range environments {
+		wg.Add(1)
+
+		go func(i int, env string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			ui := &envTableUI{}
+			err := r.run(env, ui)
+
+			results[i] = envResult{environment: env, tables: ui.tables, err: err}
+		}(i, env)
+	}
+
+	wg.Wait()
+
+	for _, table := range r.mergeTables(results) {
+		r.ui.PrintTable(table)
+	}
+
+	failed := 0
+
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return bosherr.Errorf("Failed for %d of %d environment(s)", failed, len(environments))
+	}
+
+	return nil
+}
+
+// mergeTables merges tables with the same content, title and headers
+// from all environments into one table with an Environment column.
+// Environments that failed are shown as error rows of the first table.
+func (r MultiEnvRunner) mergeTables(results []envResult) []boshtbl.Table {
+	var merged []boshtbl.Table
+
+	mergedIdx := map[string]int{}
+
+	for _, result := range results {
+		for _, table := range result.tables {
+			key := r.tableKey(table)
+
+			i, found := mergedIdx[key]
+			if !found {
+				i = len(merged)
+				mergedIdx[key] = i
+				merged = append(merged, boshtbl.Table{
+					Title:   table.Title,
+					Content: table.Content,
+					Header:  append([]boshtbl.Header{boshtbl.NewHeader("Environment")}, table.Header...),
+				})
+			}
+
+			// Rows are sorted per environment since values of
+			// error rows cannot be compared with other values
+			for _, row := range table.AsRows() {
+				envRow := append([]boshtbl.Value{boshtbl.NewValueString(result.environment)}, row...)
+				merged[i].Rows = append(merged[i].Rows, envRow)
+			}
+
+			for _, note := range table.Notes {
+				if !r.hasNote(merged[i], note) {
+					merged[i].Notes = append(merged[i].Notes, note)
+				}
+			}
+		}
+	}
+
+	for _, result := range results {
+		if result.err == nil {
+			continue
+		}
+
+		if len(merged) == 0 {
+			merged = append(merged, boshtbl.Table{
+				Content: "environments",
+				Header:  []boshtbl.Header{boshtbl.NewHeader("Environment"), boshtbl.NewHeader("Error")},
+			})
+		}
+
+		row := []boshtbl.Value{
+			boshtbl.NewValueString(result.environment),
+			boshtbl.NewValueFmt(boshtbl.NewValueString(result.err.Error()), true),
+		}
+
+		for len(row) < len(merged[0].Header) {
+			row = append(row, boshtbl.ValueNone{})
+		}
+
+		merged[0].Rows = append(merged[0].Rows, row)
+	}
+
+	return merged
+}
+
+func (r MultiEnvRunner) tableKey(table boshtbl.Table) string {
+	keys := []string{table.Content, table.Title}
+
+	for _, header := range table.Header {
+		keys = append(keys, header.Key)
+	}
+
+	return strings.Join(keys, "\x00")
+}
+
+func (r MultiEnvRunner) hasNote(table boshtbl.Table, note string) bool {
+	for _, n := range table.Notes {
+		if n == note {
+			return true
+		}
+	}
+
+	return false
+}
+
+// envTableUI keeps printed tables and drops all other output
+// so that tables can be merged once all environments finish.
+type envTableUI struct {
+	tables []boshtbl.Table
+}
+
+func (ui *envTableUI) ErrorLinef(pattern string, args ...interface{}) {}
+func (ui *envTableUI) PrintLinef(pattern string, args ...interface{}) {}
+func (ui *envTableUI) BeginLinef(pattern string, args ...interface{}) {}
+func (ui *envTableUI) EndLinef(pattern string, args ...interface{})   {}
+
+func (ui *envTableUI) PrintBlock([]byte)      {}
+func (ui *envTableUI) PrintErrorBlock(string) {}
+
+func (ui *envTableUI) PrintTable(table boshtbl.Table) {
+	ui.tables = append(ui.tables, table)
+}
+
+func (ui *envTableUI) PrintTableFiltered(table boshtbl.Table, _ []boshtbl.Header) {
+	ui.tables = append(ui.tables, table)
+}
+
+func (ui *envTableUI) AskForText(label string) (string, error) {
+	return "", bosherr.Error("Cannot ask for input when running against multiple environments")
+}
+
+func (ui *envTableUI) AskForChoice(label string, options []string) (int, error) {
+	return 0, bosherr.Error("Cannot ask for input when running against multiple environments")
+}
+
+func (ui *envTableUI) AskForPassword(label string) (string, error) {
+	return "", bosherr.Error("Cannot ask for input when running against multiple environments")
+}
+
+func (ui *envTableUI) AskForConfirmation() error {
+	return bosherr.Error("Cannot ask for confirmation when running against multiple environments")
+}
+
+func (ui *envTableUI) IsInteractive() bool { return false }
+
+func (ui *envTableUI) Flush() {}
@@ -0,0 +1,186 @@
+package cmd_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("MultiEnvRunner", func() {
+	var (
+		ui      *fakeui.FakeUI
+		envErrs map[string]error
+		envRuns []string
+		runner  MultiEnvRunner
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		envErrs = map[string]error{}
+		envRuns = nil
+
+		var mutex sync.Mutex
+
+		run := func(env string, envUI boshui.UI) error {
+			mutex.Lock()
+			envRuns = append(envRuns, env)
+			mutex.Unlock()
+
+			envUI.PrintLinef("Using environment '%s'", env)
+
+			if err, found := envErrs[env]; found {
+				return err
+			}
+
+			envUI.PrintTable(boshtbl.Table{
+				Content: "stemcells",
+				Header:  []boshtbl.Header{boshtbl.NewHeader("Name"), boshtbl.NewHeader("Version")},
+				SortBy:  []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("ubuntu"), boshtbl.NewValueString(env + "-2")},
+					{boshtbl.NewValueString("centos"), boshtbl.NewValueString(env + "-1")},
+				},
+				Notes: []string{"(*) Currently deployed"},
+			})
+
+			return nil
+		}
+
+		runner = NewMultiEnvRunner(ui, run, 2)
+	})
+
+	Describe("Run", func() {
+		It("runs against all environments and merges tables with an environment column", func() {
+			err := runner.Run([]string{"prod", "dev", "test"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(envRuns).To(ConsistOf("prod", "dev", "test"))
+			Expect(ui.Said).To(BeEmpty())
+
+			Expect(ui.Tables).To(Equal([]boshtbl.Table{{
+				Content: "stemcells",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Environment"),
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Version"),
+				},
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("prod"), boshtbl.NewValueString("centos"), boshtbl.NewValueString("prod-1")},
+					{boshtbl.NewValueString("prod"), boshtbl.NewValueString("ubuntu"), boshtbl.NewValueString("prod-2")},
+					{boshtbl.NewValueString("dev"), boshtbl.NewValueString("centos"), boshtbl.NewValueString("dev-1")},
+					{boshtbl.NewValueString("dev"), boshtbl.NewValueString("ubuntu"), boshtbl.NewValueString("dev-2")},
+					{boshtbl.NewValueString("test"), boshtbl.NewValueString("centos"), boshtbl.NewValueString("test-1")},
+					{boshtbl.NewValueString("test"), boshtbl.NewValueString("ubuntu"), boshtbl.NewValueString("test-2")},
+				},
+				Notes: []string{"(*) Currently deployed"},
+			}}))
+		})
+
+		It("flattens table sections into rows", func() {
+			run := func(env string, envUI boshui.UI) error {
+				envUI.PrintTable(boshtbl.Table{
+					Content: "instances",
+					Title:   "Deployment 'app'",
+					Header:  []boshtbl.Header{boshtbl.NewHeader("Instance"), boshtbl.NewHeader("Process")},
+					Sections: []boshtbl.Section{{
+						FirstColumn: boshtbl.NewValueString("web/0"),
+						Rows: [][]boshtbl.Value{
+							{boshtbl.ValueString{}, boshtbl.NewValueString("nginx")},
+							{boshtbl.ValueString{}, boshtbl.NewValueString("app")},
+						},
+					}},
+					FillFirstColumn: true,
+				})
+				return nil
+			}
+
+			err := NewMultiEnvRunner(ui, run, 1).Run([]string{"prod"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Title).To(Equal("Deployment 'app'"))
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+				{boshtbl.NewValueString("prod"), boshtbl.NewValueString("web/0"), boshtbl.NewValueString("nginx")},
+				{boshtbl.NewValueString("prod"), boshtbl.NewValueString("web/0"), boshtbl.NewValueString("app")},
+			}))
+		})
+
+		It("keeps tables with different titles apart", func() {
+			run := func(env string, envUI boshui.UI) error {
+				envUI.PrintTable(boshtbl.Table{
+					Content: "vms",
+					Title:   "Deployment '" + env + "-app'",
+					Header:  []boshtbl.Header{boshtbl.NewHeader("Instance")},
+					Rows:    [][]boshtbl.Value{{boshtbl.NewValueString("web/0")}},
+				})
+				return nil
+			}
+
+			err := NewMultiEnvRunner(ui, run, 1).Run([]string{"prod", "dev"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Tables).To(HaveLen(2))
+			Expect(ui.Tables[0].Title).To(Equal("Deployment 'prod-app'"))
+			Expect(ui.Tables[1].Title).To(Equal("Deployment 'dev-app'"))
+		})
+
+		It("adds error rows for environments that failed and returns an error", func() {
+			envErrs["dev"] = errors.New("fake-err")
+
+			err := runner.Run([]string{"prod", "dev"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Failed for 1 of 2 environment(s)"))
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Rows).To(HaveLen(3))
+			Expect(ui.Tables[0].Rows[2]).To(Equal([]boshtbl.Value{
+				boshtbl.NewValueString("dev"),
+				boshtbl.NewValueFmt(boshtbl.NewValueString("fake-err"), true),
+				boshtbl.ValueNone{},
+			}))
+		})
+
+		It("prints errors table if all environments failed", func() {
+			envErrs["prod"] = errors.New("fake-prod-err")
+			envErrs["dev"] = errors.New("fake-dev-err")
+
+			err := runner.Run([]string{"prod", "dev"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Failed for 2 of 2 environment(s)"))
+
+			Expect(ui.Tables).To(Equal([]boshtbl.Table{{
+				Content: "environments",
+				Header:  []boshtbl.Header{boshtbl.NewHeader("Environment"), boshtbl.NewHeader("Error")},
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("prod"), boshtbl.NewValueFmt(boshtbl.NewValueString("fake-prod-err"), true)},
+					{boshtbl.NewValueString("dev"), boshtbl.NewValueFmt(boshtbl.NewValueString("fake-dev-err"), true)},
+				},
+			}}))
+		})
+
+		It("does not allow asking for input", func() {
+			run := func(env string, envUI boshui.UI) error {
+				Expect(envUI.IsInteractive()).To(BeFalse())
+				return envUI.AskForConfirmation()
+			}
+
+			err := NewMultiEnvRunner(ui, run, 1).Run([]string{"prod"})
+			Expect(err).To(HaveOccurred())
+
+			Expect(ui.Tables[0].Rows[0][1].String()).To(ContainSubstring("Cannot ask for confirmation"))
+		})
+
+		It("returns an error if no environments are given", func() {
+			err := runner.Run(nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected at least one environment"))
+		})
+	})
+})
@@ -0,0 +1,11 @@
+package opts
+
+// Shared
+type EnvsFlags struct {
+	AllEnvs bool     `long:"all-envs" description:"Run against all environments in config and merge results"`
+	Envs    []string `long:"envs"     value-name:"ALIAS" description:"Run against given environments and merge results (can be specified multiple times)"`
+}
+
+func (f EnvsFlags) IsSet() bool {
+	return f.AllEnvs || len(f.Envs) > 0
+}
@@ -0,0 +1,24 @@
+package opts_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+)
+
+var _ = Describe("EnvsFlags", func() {
+	Describe("IsSet", func() {
+		It("returns false when no environments are given", func() {
+			Expect(EnvsFlags{}.IsSet()).To(BeFalse())
+		})
+
+		It("returns true when all environments are requested", func() {
+			Expect(EnvsFlags{AllEnvs: true}.IsSet()).To(BeTrue())
+		})
+
+		It("returns true when environments are given", func() {
+			Expect(EnvsFlags{Envs: []string{"prod"}}.IsSet()).To(BeTrue())
+		})
+	})
+})
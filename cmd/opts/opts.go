@@ -1,6 +1,8 @@
 package opts
 
 import (
+	"time"
+
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/go-patch/patch"
 
@@ -20,6 +22,10 @@ type BoshOpts struct {
 	Sha2           bool      `long:"sha2"                  description:"Use SHA256 checksums" env:"BOSH_SHA2"`
 	Parallel       int       `long:"parallel" description:"The max number of parallel operations" default:"5"`
 
+	// Release blobs cache shared by release directories
+	BlobCachePathOpt    string `long:"blob-cache"          description:"Release blobs cache directory path"                   env:"BOSH_BLOB_CACHE"          default:"~/.bosh/cache/blobs"`
+	BlobCacheMaxSizeOpt int64  `long:"blob-cache-max-size" description:"Release blobs cache max size in MiB (0 disables cache)" env:"BOSH_BLOB_CACHE_MAX_SIZE" default:"10240"`
+
 	// Specify client credentials
 	ClientOpt       string `long:"client"        description:"Override username or UAA client"        env:"BOSH_CLIENT"`
 	ClientSecretOpt string `long:"client-secret" description:"Override password or UAA client secret" env:"BOSH_CLIENT_SECRET"`
@@ -29,6 +35,7 @@ type BoshOpts struct {
 	// Output formatting
 	ColumnOpt         []ColumnOpt `long:"column"                    description:"Filter to show only given column(s)"`
 	JSONOpt           bool        `long:"json"                      description:"Output as JSON"`
+	JSONEventsOpt     bool        `long:"json-events"               description:"Stream stage events as lines of JSON to stderr"`
 	TTYOpt            bool        `long:"tty"                       description:"Force TTY-like output"`
 	NoColorOpt        bool        `long:"no-color"                  description:"Toggle colorized output"`
 	NonInteractiveOpt bool        `long:"non-interactive" short:"n" description:"Don't ask for user input" env:"BOSH_NON_INTERACTIVE"`
@@ -47,6 +54,9 @@ type BoshOpts struct {
 	AliasEnv     AliasEnvOpts     `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
 	UnaliasEnv   UnaliasEnvOpts   `command:"unalias-env"               description:"Remove an aliased environment"`
 
+	CleanLocalCache CleanLocalCacheOpts `command:"clean-local-cache" description:"Remove packages compiled by create-env from the local cache"`
+	VarsStore       VarsStoreOpts       `command:"vars-store"        description:"Check and rotate certificates in a variables store used by create-env"`
+
 	// Authentication
 	LogIn  LogInOpts  `command:"log-in"  alias:"l" alias:"login"  description:"Log in"` //nolint:staticcheck
 	LogOut LogOutOpts `command:"log-out"           alias:"logout" description:"Log out"`
@@ -69,6 +79,9 @@ type BoshOpts struct {
 	DeleteConfig DeleteConfigOpts `command:"delete-config" alias:"dc" description:"Delete config"`
 	DiffConfig   DiffConfigOpts   `command:"diff-config" description:"Diff two configs by ID or content"`
 
+	ExportConfigs ExportConfigsOpts `command:"export-configs" description:"Export latest configs of all types and names to a directory"`
+	ImportConfigs ImportConfigsOpts `command:"import-configs" description:"Import configs exported with export-configs"`
+
 	// Cloud config
 	CloudConfig       CloudConfigOpts       `command:"cloud-config"        alias:"cc"  description:"Show current cloud config"`
 	UpdateCloudConfig UpdateCloudConfigOpts `command:"update-cloud-config" alias:"ucc" description:"Update current cloud config"`
@@ -86,11 +99,16 @@ type BoshOpts struct {
 	Deployments      DeploymentsOpts      `command:"deployments"       alias:"ds" alias:"deps" description:"List deployments"` //nolint:staticcheck
 	DeleteDeployment DeleteDeploymentOpts `command:"delete-deployment" alias:"deld"            description:"Delete deployment"`
 
-	Deploy   DeployOpts   `command:"deploy"   alias:"d"   description:"Update deployment"`
-	Manifest ManifestOpts `command:"manifest" alias:"man" description:"Show deployment manifest"`
+	Deploy       DeployOpts       `command:"deploy"        alias:"d"   description:"Update deployment"`
+	Manifest     ManifestOpts     `command:"manifest"      alias:"man" description:"Show deployment manifest"`
+	LintManifest LintManifestOpts `command:"lint-manifest"             description:"Check manifest against releases, stemcells and cloud config on the director"`
+	Drift        DriftOpts        `command:"drift"                     description:"Compare deployments with manifests in a directory"`
 
 	Interpolate InterpolateOpts `command:"interpolate" alias:"int" description:"Interpolates variables into a manifest"`
 
+	// Certificates
+	Certs CertsOpts `command:"certs" description:"Show expiring certificates, their consumers and rotation plans"`
+
 	// Events
 	Events EventsOpts `command:"events" description:"List events"`
 	Event  EventOpts  `command:"event" description:"Show event details"`
@@ -138,6 +156,7 @@ type BoshOpts struct {
 	Unignore           UnignoreOpts           `command:"unignore"                                       description:"Unignore an instance"`
 	CloudCheck         CloudCheckOpts         `command:"cloud-check"     alias:"cck" alias:"cloudcheck" description:"Cloud consistency check and interactive repair"` //nolint:staticcheck
 	OrphanedVMs        OrphanedVMsOpts        `command:"orphaned-vms"                                   description:"List all the orphaned VMs in all deployments"`
+	WaitHealthy        WaitHealthyOpts        `command:"wait-healthy"                                   description:"Wait until all instances in a deployment are running"`
 
 	// Instance management
 	Logs     LogsOpts     `command:"logs"      description:"Fetch logs from instance(s)"`
@@ -160,6 +179,8 @@ type BoshOpts struct {
 	GeneratePackage GeneratePackageOpts `command:"generate-package"            description:"Generate package"`
 	CreateRelease   CreateReleaseOpts   `command:"create-release"   alias:"cr" description:"Create release"`
 	VendorPackage   VendorPackageOpts   `command:"vendor-package"              description:"Vendor package"`
+	RenderJob       RenderJobOpts       `command:"render-job"                  description:"Render job templates with given properties"`
+	LintRelease     LintReleaseOpts     `command:"lint-release"                description:"Check release directory for problems in jobs and packages"`
 
 	Sha1ifyRelease Sha1ifyReleaseOpts `command:"sha1ify-release"  description:"Convert release tarball to use SHA1"`
 	Sha2ifyRelease Sha2ifyReleaseOpts `command:"sha2ify-release"  description:"Convert release tarball to use SHA256"`
@@ -173,6 +194,10 @@ type BoshOpts struct {
 	SyncBlobs   SyncBlobsOpts   `command:"sync-blobs"   description:"Sync blobs"`
 	UploadBlobs UploadBlobsOpts `command:"upload-blobs" description:"Upload blobs"`
 
+	MigrateBlobstore MigrateBlobstoreOpts `command:"migrate-blobstore" description:"Copy release blobs to another blobstore and switch to it"`
+
+	AuditBlobstore AuditBlobstoreOpts `command:"audit-blobstore" description:"Verify release blobs and find orphaned blobs in blobstore"`
+
 	Variables VariablesOpts `command:"variables" alias:"vars" description:"List variables"`
 }
 
@@ -187,9 +212,14 @@ type CreateEnvOpts struct {
 	VarFlags
 	OpsFlags
 	SkipDrain               bool   `long:"skip-drain" description:"Skip running drain and pre-stop scripts"`
-	StatePath               string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath               string `long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`
 	Recreate                bool   `long:"recreate" description:"Recreate VM in deployment"`
 	RecreatePersistentDisks bool   `long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`
+	DryRun                  bool   `long:"dry-run" description:"Show the changes that would be made without deploying"`
+	Diff                    bool   `long:"diff" description:"Show previous and new values of the changes before deploying"`
+	Resume                  bool   `long:"resume" description:"Continue an interrupted deploy of the same manifest from its last completed step"`
+	RecordCPI               string `long:"record-cpi" value-name:"DIR" description:"Record every CPI request and response in a directory. Credentials are redacted and CPI logs are not recorded"`
+	ReplayCPI               string `long:"replay-cpi" value-name:"DIR" description:"Answer CPI requests with the responses recorded in a directory, in order. The CPI is still installed and the agent is still contacted"`
 	cmd
 }
 
@@ -202,7 +232,9 @@ type DeleteEnvOpts struct {
 	VarFlags
 	OpsFlags
 	SkipDrain bool   `long:"skip-drain" description:"Skip running drain and pre-stop scripts"`
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`
+	RecordCPI string `long:"record-cpi" value-name:"DIR" description:"Record every CPI request and response in a directory. Credentials are redacted and CPI logs are not recorded"`
+	ReplayCPI string `long:"replay-cpi" value-name:"DIR" description:"Answer CPI requests with the responses recorded in a directory, in order. The CPI is still installed and the agent is still contacted"`
 	cmd
 }
 
@@ -211,7 +243,7 @@ type StopEnvOpts struct {
 	VarFlags
 	OpsFlags
 	SkipDrain bool   `long:"skip-drain" description:"Skip running drain and pre-stop scripts"`
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`
 	cmd
 }
 
@@ -219,7 +251,7 @@ type StartEnvOpts struct {
 	Args StartStopEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath string `long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`
 	cmd
 }
 
@@ -296,8 +328,11 @@ type TaskArgs struct {
 type TasksOpts struct {
 	Recent     *int `long:"recent" short:"r" description:"Show 30 recent tasks. Use '=' to specify the number of tasks to show" optional:"true" optional-value:"30"`
 	All        bool `long:"all" short:"a" description:"Include all task types (ssh, logs, vms, etc)"`
+	Follow     bool `long:"follow" short:"f" description:"Stream event output of current and new tasks"`
 	Deployment string
 
+	EnvsFlags
+
 	cmd
 }
 
@@ -328,6 +363,39 @@ type CleanUpOpts struct {
 	cmd
 }
 
+type CleanLocalCacheOpts struct {
+	DryRun bool `long:"dry-run" description:"Print out the cached packages that will be deleted but does not delete anything"`
+
+	cmd
+}
+
+type VarsStoreOpts struct {
+	Check  VarsStoreCheckOpts  `command:"check"  description:"List certificates in a variables store and their expiry"`
+	Rotate VarsStoreRotateOpts `command:"rotate" description:"Regenerate certificates and certificates signed by them"`
+}
+
+type VarsStoreCheckOpts struct {
+	VarsFSStore VarsFSStore `long:"vars-store" value-name:"PATH" description:"Load variables from a YAML file" required:"true"`
+	Threshold   int         `long:"threshold"  value-name:"DAYS" description:"Number of days left at which certificates are expiring" default:"30"`
+
+	cmd
+}
+
+type VarsStoreRotateOpts struct {
+	Args VarsStoreRotateArgs `positional-args:"true" required:"true"`
+
+	Names []string `long:"name" value-name:"NAME" description:"Certificate or CA variable to regenerate" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	cmd
+}
+
+type VarsStoreRotateArgs struct {
+	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest that defines the variables"`
+}
+
 type AttachDiskOpts struct {
 	Args AttachDiskArgs `positional-args:"true" required:"true"`
 
@@ -414,6 +482,47 @@ type DeleteConfigArgs struct {
 	ID string `positional-arg-name:"ID" description:"Config ID"`
 }
 
+type ExportConfigsOpts struct {
+	Args ConfigsDirArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type ImportConfigsOpts struct {
+	Args ConfigsDirArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type ConfigsDirArgs struct {
+	Directory DirOrCWDArg `positional-arg-name:"DIR" description:"Directory with exported configs"`
+}
+
+// Certificates
+
+type CertsOpts struct {
+	Expiring  CertsExpiringOpts  `command:"expiring"  description:"List director certificates and certificates written into deployment manifests that expire soon (config server variables are not checked)"`
+	Consumers CertsConsumersOpts `command:"consumers" description:"List certificate variables and deployments that use them"`
+	Plan      CertsPlanOpts      `command:"plan"      description:"Show which deployments to redeploy in what order to rotate a certificate"`
+}
+
+type CertsExpiringOpts struct {
+	Threshold int  `long:"threshold" value-name:"DAYS" description:"Number of days left at which certificates are expiring" default:"30"`
+	All       bool `long:"all"                         description:"Include certificates that are not expiring"`
+	cmd
+}
+
+type CertsConsumersOpts struct {
+	cmd
+}
+
+type CertsPlanOpts struct {
+	Args CertsPlanArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type CertsPlanArgs struct {
+	Name string `positional-arg-name:"NAME" description:"Certificate or CA variable name"`
+}
+
 // Cloud config
 
 type CloudConfigOpts struct {
@@ -483,6 +592,7 @@ type DeploymentOpts struct {
 }
 
 type DeploymentsOpts struct {
+	EnvsFlags
 	cmd
 }
 
@@ -504,6 +614,7 @@ type DeployOpts struct {
 	MaxInFlight string `long:"max-in-flight" description:"Override manifest values for max_in_flight"`
 
 	DryRun bool `long:"dry-run" description:"Renders job templates without altering deployment"`
+	Lint   bool `long:"lint"    description:"Check manifest against releases, stemcells and cloud config before deploying"`
 
 	cmd
 }
@@ -512,6 +623,27 @@ type DeployArgs struct {
 	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type LintManifestOpts struct {
+	Args DeployArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	cmd
+}
+
+type DriftOpts struct {
+	Manifests DirOrCWDArg `long:"manifests" value-name:"DIR" description:"Directory with a manifest for each deployment named after it" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	Diff     bool `long:"diff"      description:"Show differences of drifted deployments"`
+	NoRedact bool `long:"no-redact" description:"Show non-redacted manifest diff"`
+
+	cmd
+}
+
 type ManifestOpts struct {
 	cmd
 }
@@ -551,6 +683,7 @@ type EventArgs struct {
 // Stemcells
 
 type StemcellsOpts struct {
+	EnvsFlags
 	cmd
 }
 
@@ -611,6 +744,7 @@ type InspectStemcellTarballArgs struct {
 // Releases
 
 type ReleasesOpts struct {
+	EnvsFlags
 	cmd
 }
 
@@ -798,6 +932,7 @@ type InstancesOpts struct {
 	Processes  bool `long:"ps"      short:"p" description:"Show processes"`
 	Failing    bool `long:"failing" short:"f" description:"Only show failing instances"`
 	Deployment string
+	EnvsFlags
 	cmd
 }
 
@@ -806,6 +941,7 @@ type VMsOpts struct {
 	Vitals          bool `long:"vitals"            description:"Show vitals"`
 	CloudProperties bool `long:"cloud-properties"  description:"Show cloud properties"`
 	Deployment      string
+	EnvsFlags
 	cmd
 }
 
@@ -820,6 +956,12 @@ type OrphanedVMsOpts struct {
 	cmd
 }
 
+type WaitHealthyOpts struct {
+	Timeout       time.Duration `long:"timeout"        value-name:"DURATION" description:"Maximum time to wait for instances to be running" default:"10m"`
+	InstanceGroup string        `long:"instance-group" value-name:"NAME"     description:"Only wait for instances of the instance group"`
+	cmd
+}
+
 // Instance management
 
 type UpdateResurrectionOpts struct {
@@ -1028,6 +1170,23 @@ type VendorPackageArgs struct {
 	URL         DirOrCWDArg `positional-arg-name:"SRC-DIR" default:"."`
 }
 
+type RenderJobOpts struct {
+	Directory DirOrCWDArg `long:"release-dir" description:"Release directory path if not current working directory" default:"."`
+
+	Job        string       `long:"job"        value-name:"NAME" description:"Name of the job to render"                     required:"true"`
+	Properties FileBytesArg `long:"properties" value-name:"PATH" description:"Path to a YAML file with job properties"        required:"true"`
+	Links      FileBytesArg `long:"links"      value-name:"PATH" description:"Path to a YAML file with links consumed by the job"`
+	OutputDir  DirOrCWDArg  `long:"output-dir" value-name:"DIR"  description:"Directory to write rendered files to"           default:"."`
+
+	cmd
+}
+
+type LintReleaseOpts struct {
+	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+
+	cmd
+}
+
 type Sha1ifyReleaseOpts struct {
 	Args RedigestReleaseArgs `positional-args:"true"`
 
@@ -1085,7 +1244,8 @@ type FinalizeReleaseArgs struct {
 // Blobs
 
 type BlobsOpts struct {
-	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+	Directory  DirOrCWDArg `long:"dir"         description:"Release directory path if not current working directory" default:"."`
+	CacheStats bool        `long:"cache-stats" description:"Show statistics of the local blobs cache shared by release directories"`
 	cmd
 }
 
@@ -1124,6 +1284,25 @@ type UploadBlobsOpts struct {
 	cmd
 }
 
+type MigrateBlobstoreOpts struct {
+	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+
+	ToConfig        FileArg `long:"to-config" description:"Path to config with new blobstore (e.g. new-final.yml)" required:"true"`
+	ToPrivateConfig FileArg `long:"to-private-config" description:"Path to config with private options of new blobstore (e.g. new-private.yml)"`
+
+	cmd
+}
+
+type AuditBlobstoreOpts struct {
+	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+
+	History       bool `long:"history" description:"Include blobs referenced by any commit in git history"`
+	DeleteOrphans bool `long:"delete-orphans" description:"Delete blobs not referenced by any commit (requires --history)"`
+	Dedicated     bool `long:"dedicated-blobstore" description:"Confirm that blobstore bucket or container only holds blobs of this release when deleting orphans"`
+
+	cmd
+}
+
 type CurlOpts struct {
 	Args CurlArgs `positional-args:"true" required:"true"`
 
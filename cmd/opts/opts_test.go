@@ -128,6 +128,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("BlobCachePathOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("BlobCachePathOpt", opts)).To(Equal(
+					`long:"blob-cache" description:"Release blobs cache directory path" env:"BOSH_BLOB_CACHE" default:"~/.bosh/cache/blobs"`,
+				))
+			})
+		})
+
+		Describe("BlobCacheMaxSizeOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("BlobCacheMaxSizeOpt", opts)).To(Equal(
+					`long:"blob-cache-max-size" description:"Release blobs cache max size in MiB (0 disables cache)" env:"BOSH_BLOB_CACHE_MAX_SIZE" default:"10240"`,
+				))
+			})
+		})
+
 		Describe("Parallel", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Parallel", opts)).To(Equal(
@@ -176,6 +192,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("JSONEventsOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("JSONEventsOpt", opts)).To(Equal(
+					`long:"json-events" description:"Stream stage events as lines of JSON to stderr"`,
+				))
+			})
+		})
+
 		Describe("TTYOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("TTYOpt", opts)).To(Equal(
@@ -304,6 +328,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("CleanLocalCache", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CleanLocalCache", opts)).To(Equal(
+					`command:"clean-local-cache" description:"Remove packages compiled by create-env from the local cache"`,
+				))
+			})
+		})
+
+		Describe("VarsStore", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarsStore", opts)).To(Equal(
+					`command:"vars-store" description:"Check and rotate certificates in a variables store used by create-env"`,
+				))
+			})
+		})
+
 		Describe("CleanUp", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("CleanUp", opts)).To(Equal(
@@ -344,6 +384,30 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("Certs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Certs", opts)).To(Equal(
+					`command:"certs" description:"Show expiring certificates, their consumers and rotation plans"`,
+				))
+			})
+		})
+
+		Describe("ExportConfigs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ExportConfigs", opts)).To(Equal(
+					`command:"export-configs" description:"Export latest configs of all types and names to a directory"`,
+				))
+			})
+		})
+
+		Describe("ImportConfigs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ImportConfigs", opts)).To(Equal(
+					`command:"import-configs" description:"Import configs exported with export-configs"`,
+				))
+			})
+		})
+
 		Describe("UpdateConfig", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("UpdateConfig", opts)).To(Equal(
@@ -448,6 +512,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("LintManifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("LintManifest", opts)).To(Equal(
+					`command:"lint-manifest" description:"Check manifest against releases, stemcells and cloud config on the director"`,
+				))
+			})
+		})
+
+		Describe("Drift", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Drift", opts)).To(Equal(
+					`command:"drift" description:"Compare deployments with manifests in a directory"`,
+				))
+			})
+		})
+
 		Describe("Stemcells", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Stemcells", opts)).To(Equal(
@@ -656,6 +736,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("WaitHealthy", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("WaitHealthy", opts)).To(Equal(
+					`command:"wait-healthy" description:"Wait until all instances in a deployment are running"`,
+				))
+			})
+		})
+
 		Describe("Logs", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Logs", opts)).To(Equal(
@@ -736,6 +824,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("RenderJob", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RenderJob", opts)).To(Equal(
+					`command:"render-job" description:"Render job templates with given properties"`,
+				))
+			})
+		})
+
+		Describe("LintRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("LintRelease", opts)).To(Equal(
+					`command:"lint-release" description:"Check release directory for problems in jobs and packages"`,
+				))
+			})
+		})
+
 		Describe("GeneratePackage", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("GeneratePackage", opts)).To(Equal(
@@ -808,6 +912,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("MigrateBlobstore", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("MigrateBlobstore", opts)).To(Equal(
+					`command:"migrate-blobstore" description:"Copy release blobs to another blobstore and switch to it"`,
+				))
+			})
+		})
+
+		Describe("AuditBlobstore", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("AuditBlobstore", opts)).To(Equal(
+					`command:"audit-blobstore" description:"Verify release blobs and find orphaned blobs in blobstore"`,
+				))
+			})
+		})
+
 		Describe("AttachDisk", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("AttachDisk", opts)).To(Equal(
@@ -840,7 +960,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`,
 			))
 		})
 
@@ -856,6 +976,36 @@ var _ = Describe("Opts", func() {
 			))
 		})
 
+		It("has --dry-run", func() {
+			Expect(getStructTagForName("DryRun", opts)).To(Equal(
+				`long:"dry-run" description:"Show the changes that would be made without deploying"`,
+			))
+		})
+
+		It("has --diff", func() {
+			Expect(getStructTagForName("Diff", opts)).To(Equal(
+				`long:"diff" description:"Show previous and new values of the changes before deploying"`,
+			))
+		})
+
+		It("has --resume", func() {
+			Expect(getStructTagForName("Resume", opts)).To(Equal(
+				`long:"resume" description:"Continue an interrupted deploy of the same manifest from its last completed step"`,
+			))
+		})
+
+		It("has --record-cpi", func() {
+			Expect(getStructTagForName("RecordCPI", opts)).To(Equal(
+				`long:"record-cpi" value-name:"DIR" description:"Record every CPI request and response in a directory. Credentials are redacted and CPI logs are not recorded"`,
+			))
+		})
+
+		It("has --replay-cpi", func() {
+			Expect(getStructTagForName("ReplayCPI", opts)).To(Equal(
+				`long:"replay-cpi" value-name:"DIR" description:"Answer CPI requests with the responses recorded in a directory, in order. The CPI is still installed and the agent is still contacted"`,
+			))
+		})
+
 		It("has --skip-drain", func() {
 			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
 				`long:"skip-drain" description:"Skip running drain and pre-stop scripts"`,
@@ -894,7 +1044,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`,
 			))
 		})
 
@@ -903,6 +1053,18 @@ var _ = Describe("Opts", func() {
 				`long:"skip-drain" description:"Skip running drain and pre-stop scripts"`,
 			))
 		})
+
+		It("has --record-cpi", func() {
+			Expect(getStructTagForName("RecordCPI", opts)).To(Equal(
+				`long:"record-cpi" value-name:"DIR" description:"Record every CPI request and response in a directory. Credentials are redacted and CPI logs are not recorded"`,
+			))
+		})
+
+		It("has --replay-cpi", func() {
+			Expect(getStructTagForName("ReplayCPI", opts)).To(Equal(
+				`long:"replay-cpi" value-name:"DIR" description:"Answer CPI requests with the responses recorded in a directory, in order. The CPI is still installed and the agent is still contacted"`,
+			))
+		})
 	})
 
 	Describe("DeleteEnvArgs", func() {
@@ -936,7 +1098,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`,
 			))
 		})
 
@@ -962,7 +1124,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path, or s3:// or gs:// URL of a remote state file"`,
 			))
 		})
 
@@ -1187,6 +1349,136 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("CertsOpts", func() {
+		var opts *CertsOpts
+
+		BeforeEach(func() {
+			opts = &CertsOpts{}
+		})
+
+		Describe("Expiring", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Expiring", opts)).To(Equal(
+					`command:"expiring" description:"List director certificates and certificates written into deployment manifests that expire soon (config server variables are not checked)"`,
+				))
+			})
+		})
+
+		Describe("Consumers", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Consumers", opts)).To(Equal(
+					`command:"consumers" description:"List certificate variables and deployments that use them"`,
+				))
+			})
+		})
+
+		Describe("Plan", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Plan", opts)).To(Equal(
+					`command:"plan" description:"Show which deployments to redeploy in what order to rotate a certificate"`,
+				))
+			})
+		})
+	})
+
+	Describe("CertsExpiringOpts", func() {
+		var opts *CertsExpiringOpts
+
+		BeforeEach(func() {
+			opts = &CertsExpiringOpts{}
+		})
+
+		Describe("Threshold", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Threshold", opts)).To(Equal(
+					`long:"threshold" value-name:"DAYS" description:"Number of days left at which certificates are expiring" default:"30"`,
+				))
+			})
+		})
+
+		Describe("All", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("All", opts)).To(Equal(
+					`long:"all" description:"Include certificates that are not expiring"`,
+				))
+			})
+		})
+	})
+
+	Describe("CertsPlanOpts", func() {
+		var opts *CertsPlanOpts
+
+		BeforeEach(func() {
+			opts = &CertsPlanOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+	})
+
+	Describe("CertsPlanArgs", func() {
+		var opts *CertsPlanArgs
+
+		BeforeEach(func() {
+			opts = &CertsPlanArgs{}
+		})
+
+		Describe("Name", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Name", opts)).To(Equal(
+					`positional-arg-name:"NAME" description:"Certificate or CA variable name"`,
+				))
+			})
+		})
+	})
+
+	Describe("ExportConfigsOpts", func() {
+		var opts *ExportConfigsOpts
+
+		BeforeEach(func() {
+			opts = &ExportConfigsOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+	})
+
+	Describe("ImportConfigsOpts", func() {
+		var opts *ImportConfigsOpts
+
+		BeforeEach(func() {
+			opts = &ImportConfigsOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+	})
+
+	Describe("ConfigsDirArgs", func() {
+		var opts *ConfigsDirArgs
+
+		BeforeEach(func() {
+			opts = &ConfigsDirArgs{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`positional-arg-name:"DIR" description:"Directory with exported configs"`,
+				))
+			})
+		})
+	})
+
 	Describe("UnaliasEnvOpts", func() {
 		var opts *UnaliasEnvOpts
 
@@ -1309,6 +1601,14 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("Follow", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Follow", opts)).To(Equal(
+					`long:"follow" short:"f" description:"Stream event output of current and new tasks"`,
+				))
+			})
+		})
 	})
 
 	Describe("CancelTaskOpts", func() {
@@ -1371,6 +1671,108 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("CleanLocalCacheOpts", func() {
+		var opts *CleanLocalCacheOpts
+
+		BeforeEach(func() {
+			opts = &CleanLocalCacheOpts{}
+		})
+
+		Describe("DryRun", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DryRun", opts)).To(Equal(
+					`long:"dry-run" description:"Print out the cached packages that will be deleted but does not delete anything"`,
+				))
+			})
+		})
+	})
+
+	Describe("VarsStoreOpts", func() {
+		var opts *VarsStoreOpts
+
+		BeforeEach(func() {
+			opts = &VarsStoreOpts{}
+		})
+
+		Describe("Check", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Check", opts)).To(Equal(
+					`command:"check" description:"List certificates in a variables store and their expiry"`,
+				))
+			})
+		})
+
+		Describe("Rotate", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Rotate", opts)).To(Equal(
+					`command:"rotate" description:"Regenerate certificates and certificates signed by them"`,
+				))
+			})
+		})
+	})
+
+	Describe("VarsStoreCheckOpts", func() {
+		var opts *VarsStoreCheckOpts
+
+		BeforeEach(func() {
+			opts = &VarsStoreCheckOpts{}
+		})
+
+		Describe("VarsFSStore", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarsFSStore", opts)).To(Equal(
+					`long:"vars-store" value-name:"PATH" description:"Load variables from a YAML file" required:"true"`,
+				))
+			})
+		})
+
+		Describe("Threshold", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Threshold", opts)).To(Equal(
+					`long:"threshold" value-name:"DAYS" description:"Number of days left at which certificates are expiring" default:"30"`,
+				))
+			})
+		})
+	})
+
+	Describe("VarsStoreRotateOpts", func() {
+		var opts *VarsStoreRotateOpts
+
+		BeforeEach(func() {
+			opts = &VarsStoreRotateOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		Describe("Names", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Names", opts)).To(Equal(
+					`long:"name" value-name:"NAME" description:"Certificate or CA variable to regenerate" required:"true"`,
+				))
+			})
+		})
+	})
+
+	Describe("VarsStoreRotateArgs", func() {
+		var opts *VarsStoreRotateArgs
+
+		BeforeEach(func() {
+			opts = &VarsStoreRotateArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", opts)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest that defines the variables"`,
+				))
+			})
+		})
+	})
+
 	Describe("AttachDiskOpts", func() {
 		var opts *AttachDiskOpts
 
@@ -1636,6 +2038,62 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("Lint", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Lint", opts)).To(Equal(
+					`long:"lint" description:"Check manifest against releases, stemcells and cloud config before deploying"`,
+				))
+			})
+		})
+	})
+
+	Describe("LintManifestOpts", func() {
+		var opts *LintManifestOpts
+
+		BeforeEach(func() {
+			opts = &LintManifestOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(
+					`positional-args:"true" required:"true"`,
+				))
+			})
+		})
+	})
+
+	Describe("DriftOpts", func() {
+		var opts *DriftOpts
+
+		BeforeEach(func() {
+			opts = &DriftOpts{}
+		})
+
+		Describe("Manifests", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifests", opts)).To(Equal(
+					`long:"manifests" value-name:"DIR" description:"Directory with a manifest for each deployment named after it" required:"true"`,
+				))
+			})
+		})
+
+		Describe("Diff", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Diff", opts)).To(Equal(
+					`long:"diff" description:"Show differences of drifted deployments"`,
+				))
+			})
+		})
+
+		Describe("NoRedact", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("NoRedact", opts)).To(Equal(
+					`long:"no-redact" description:"Show non-redacted manifest diff"`,
+				))
+			})
+		})
 	})
 
 	Describe("DeployArgs", func() {
@@ -2422,6 +2880,54 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("EnvsFlags", func() {
+		var opts *EnvsFlags
+
+		BeforeEach(func() {
+			opts = &EnvsFlags{}
+		})
+
+		Describe("AllEnvs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("AllEnvs", opts)).To(Equal(
+					`long:"all-envs" description:"Run against all environments in config and merge results"`,
+				))
+			})
+		})
+
+		Describe("Envs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Envs", opts)).To(Equal(
+					`long:"envs" value-name:"ALIAS" description:"Run against given environments and merge results (can be specified multiple times)"`,
+				))
+			})
+		})
+	})
+
+	Describe("WaitHealthyOpts", func() {
+		var opts *WaitHealthyOpts
+
+		BeforeEach(func() {
+			opts = &WaitHealthyOpts{}
+		})
+
+		Describe("Timeout", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Timeout", opts)).To(Equal(
+					`long:"timeout" value-name:"DURATION" description:"Maximum time to wait for instances to be running" default:"10m"`,
+				))
+			})
+		})
+
+		Describe("InstanceGroup", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("InstanceGroup", opts)).To(Equal(
+					`long:"instance-group" value-name:"NAME" description:"Only wait for instances of the instance group"`,
+				))
+			})
+		})
+	})
+
 	Describe("CloudCheckOpts", func() {
 		var opts *CloudCheckOpts
 
@@ -3008,6 +3514,70 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("RenderJobOpts", func() {
+		var opts *RenderJobOpts
+
+		BeforeEach(func() {
+			opts = &RenderJobOpts{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"release-dir" description:"Release directory path if not current working directory" default:"."`,
+				))
+			})
+		})
+
+		Describe("Job", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Job", opts)).To(Equal(
+					`long:"job" value-name:"NAME" description:"Name of the job to render" required:"true"`,
+				))
+			})
+		})
+
+		Describe("Properties", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Properties", opts)).To(Equal(
+					`long:"properties" value-name:"PATH" description:"Path to a YAML file with job properties" required:"true"`,
+				))
+			})
+		})
+
+		Describe("Links", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Links", opts)).To(Equal(
+					`long:"links" value-name:"PATH" description:"Path to a YAML file with links consumed by the job"`,
+				))
+			})
+		})
+
+		Describe("OutputDir", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("OutputDir", opts)).To(Equal(
+					`long:"output-dir" value-name:"DIR" description:"Directory to write rendered files to" default:"."`,
+				))
+			})
+		})
+	})
+
+	Describe("LintReleaseOpts", func() {
+		var opts *LintReleaseOpts
+
+		BeforeEach(func() {
+			opts = &LintReleaseOpts{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"dir" description:"Release directory path if not current working directory" default:"."`,
+				))
+			})
+		})
+	})
+
 	Describe("VendorPackageArgs", func() {
 		var opts *VendorPackageArgs
 
@@ -3233,6 +3803,14 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("CacheStats", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CacheStats", opts)).To(Equal(
+					`long:"cache-stats" description:"Show statistics of the local blobs cache shared by release directories"`,
+				))
+			})
+		})
 	})
 
 	Describe("AddBlobArgs", func() {
@@ -3327,6 +3905,78 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("MigrateBlobstoreOpts", func() {
+		var opts *MigrateBlobstoreOpts
+
+		BeforeEach(func() {
+			opts = &MigrateBlobstoreOpts{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"dir" description:"Release directory path if not current working directory" default:"."`,
+				))
+			})
+		})
+
+		Describe("ToConfig", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ToConfig", opts)).To(Equal(
+					`long:"to-config" description:"Path to config with new blobstore (e.g. new-final.yml)" required:"true"`,
+				))
+			})
+		})
+
+		Describe("ToPrivateConfig", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ToPrivateConfig", opts)).To(Equal(
+					`long:"to-private-config" description:"Path to config with private options of new blobstore (e.g. new-private.yml)"`,
+				))
+			})
+		})
+	})
+
+	Describe("AuditBlobstoreOpts", func() {
+		var opts *AuditBlobstoreOpts
+
+		BeforeEach(func() {
+			opts = &AuditBlobstoreOpts{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"dir" description:"Release directory path if not current working directory" default:"."`,
+				))
+			})
+		})
+
+		Describe("History", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("History", opts)).To(Equal(
+					`long:"history" description:"Include blobs referenced by any commit in git history"`,
+				))
+			})
+		})
+
+		Describe("DeleteOrphans", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DeleteOrphans", opts)).To(Equal(
+					`long:"delete-orphans" description:"Delete blobs not referenced by any commit (requires --history)"`,
+				))
+			})
+		})
+
+		Describe("Dedicated", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Dedicated", opts)).To(Equal(
+					`long:"dedicated-blobstore" description:"Confirm that blobstore bucket or container only holds blobs of this release when deleting orphans"`,
+				))
+			})
+		})
+	})
+
 	Describe("CurlOpts", func() {
 		var opts *CurlOpts
 
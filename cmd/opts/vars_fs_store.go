@@ -54,6 +54,21 @@ func (s VarsFSStore) List() ([]boshtpl.VariableDefinition, error) {
 	return vars.List()
 }
 
+// Regenerate replaces the stored value of the variable with a newly
+// generated one, e.g. to rotate a certificate before it expires.
+func (s VarsFSStore) Regenerate(varDef boshtpl.VariableDefinition) (interface{}, error) {
+	val, err := s.generateAndSet(varDef)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Generating variable '%s'", varDef.Name)
+	}
+
+	return val, nil
+}
+
+func (s VarsFSStore) Set(name string, val interface{}) error {
+	return s.set(name, val)
+}
+
 func (s VarsFSStore) generateAndSet(varDef boshtpl.VariableDefinition) (interface{}, error) {
 	generator, err := s.ValueGeneratorFactory.GetGenerator(varDef.Type)
 	if err != nil {
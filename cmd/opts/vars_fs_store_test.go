@@ -139,6 +139,44 @@ var _ = Describe("VarsFSStore", func() {
 		})
 	})
 
+	Describe("Regenerate", func() {
+		BeforeEach(func() {
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/file", "key: val\nkey2: val2\n")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("replaces existing value with a generated one", func() {
+			val, err := store.Regenerate(boshtpl.VariableDefinition{Name: "key", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(val).ToNot(Equal("val"))
+
+			Expect(fs.ReadFileString("/file")).To(Equal(fmt.Sprintf("key: %s\nkey2: val2\n", val.(string))))
+		})
+
+		It("returns error if variable type is not known", func() {
+			_, err := store.Regenerate(boshtpl.VariableDefinition{Name: "key", Type: "unknown"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Generating variable 'key': Unsupported value type: unknown"))
+
+			Expect(fs.ReadFileString("/file")).To(Equal("key: val\nkey2: val2\n"))
+		})
+	})
+
+	Describe("Set", func() {
+		It("saves value", func() {
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = store.Set("key", "val")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/file")).To(Equal("key: val\n"))
+		})
+	})
+
 	Describe("List", func() {
 		BeforeEach(func() {
 			err := (&store).UnmarshalFlag("/file")
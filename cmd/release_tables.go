@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	boshrel "github.com/cloudfoundry/bosh-cli/v7/release"
+	boshjobman "github.com/cloudfoundry/bosh-cli/v7/release/job/manifest"
 	boshrelpkg "github.com/cloudfoundry/bosh-cli/v7/release/pkg"
 	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
 	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
@@ -56,6 +57,31 @@ func (t ReleaseTables) Print(ui boshui.UI) {
 		})
 	}
 
+	linksTable := boshtbl.Table{
+		Content: "links",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Job"),
+			boshtbl.NewHeader("Direction"),
+			boshtbl.NewHeader("Link"),
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("Optional"),
+		},
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0, Asc: true},
+			{Column: 1, Asc: true},
+			{Column: 2, Asc: true},
+		},
+	}
+
+	for _, job := range t.Release.Jobs() {
+		for _, link := range job.Consumes {
+			linksTable.Rows = append(linksTable.Rows, t.linkRow(job.Name(), "consumes", link))
+		}
+		for _, link := range job.Provides {
+			linksTable.Rows = append(linksTable.Rows, t.linkRow(job.Name(), "provides", link))
+		}
+	}
+
 	compiledPackages := t.Release.CompiledPackages()
 
 	pkgsTable := boshtbl.Table{
@@ -111,9 +137,25 @@ func (t ReleaseTables) Print(ui boshui.UI) {
 
 	ui.PrintTable(summaryTable)
 	ui.PrintTable(jobsTable)
+
+	// Links are only known for jobs that were read with their specs
+	if len(linksTable.Rows) > 0 {
+		ui.PrintTable(linksTable)
+	}
+
 	ui.PrintTable(pkgsTable)
 }
 
+func (t ReleaseTables) linkRow(jobName, direction string, link boshjobman.LinkDefinition) []boshtbl.Value {
+	return []boshtbl.Value{
+		boshtbl.NewValueString(jobName),
+		boshtbl.NewValueString(direction),
+		boshtbl.NewValueString(link.Name),
+		boshtbl.NewValueString(link.Type),
+		boshtbl.NewValueBool(link.Optional),
+	}
+}
+
 func (t ReleaseTables) sumPkgNames(packages []boshrelpkg.Compilable) []string {
 	var names []string
 	for _, pkg := range packages {
@@ -6,6 +6,7 @@ import (
 
 	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
 	boshjob "github.com/cloudfoundry/bosh-cli/v7/release/job"
+	boshjobman "github.com/cloudfoundry/bosh-cli/v7/release/job/manifest"
 	boshpkg "github.com/cloudfoundry/bosh-cli/v7/release/pkg"
 	fakerel "github.com/cloudfoundry/bosh-cli/v7/release/releasefakes"
 	. "github.com/cloudfoundry/bosh-cli/v7/release/resource"
@@ -214,6 +215,68 @@ var _ = Describe("ReleaseTables", func() {
 			})
 		})
 
+		Context("when jobs consume or provide links", func() {
+			BeforeEach(func() {
+				job := boshjob.NewJob(NewResourceWithBuiltArchive(
+					"job-name", "job-fp", "job-path", "job-sha1"))
+
+				job.Consumes = []boshjobman.LinkDefinition{
+					{Name: "db", Type: "database"},
+					{Name: "cache", Type: "redis", Optional: true},
+				}
+				job.Provides = []boshjobman.LinkDefinition{
+					{Name: "web", Type: "http", Properties: []string{"port"}},
+				}
+
+				release.JobsReturns([]*boshjob.Job{job})
+			})
+
+			It("shows links after jobs", func() {
+				ReleaseTables{Release: release}.Print(ui)
+
+				Expect(ui.Tables).To(HaveLen(4))
+				Expect(ui.Tables[2]).To(Equal(boshtbl.Table{
+					Content: "links",
+					Header: []boshtbl.Header{
+						boshtbl.NewHeader("Job"),
+						boshtbl.NewHeader("Direction"),
+						boshtbl.NewHeader("Link"),
+						boshtbl.NewHeader("Type"),
+						boshtbl.NewHeader("Optional"),
+					},
+					SortBy: []boshtbl.ColumnSort{
+						{Column: 0, Asc: true},
+						{Column: 1, Asc: true},
+						{Column: 2, Asc: true},
+					},
+					Rows: [][]boshtbl.Value{
+						{
+							boshtbl.NewValueString("job-name"),
+							boshtbl.NewValueString("consumes"),
+							boshtbl.NewValueString("db"),
+							boshtbl.NewValueString("database"),
+							boshtbl.NewValueBool(false),
+						},
+						{
+							boshtbl.NewValueString("job-name"),
+							boshtbl.NewValueString("consumes"),
+							boshtbl.NewValueString("cache"),
+							boshtbl.NewValueString("redis"),
+							boshtbl.NewValueBool(true),
+						},
+						{
+							boshtbl.NewValueString("job-name"),
+							boshtbl.NewValueString("provides"),
+							boshtbl.NewValueString("web"),
+							boshtbl.NewValueString("http"),
+							boshtbl.NewValueBool(false),
+						},
+					},
+				}))
+				Expect(ui.Tables[3].Content).To(Equal("packages"))
+			})
+		})
+
 		It("shows info about release without archive path", func() {
 			ReleaseTables{Release: release}.Print(ui)
 
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshjob "github.com/cloudfoundry/bosh-cli/v7/release/job"
+	bitemplate "github.com/cloudfoundry/bosh-cli/v7/templatescompiler"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type RenderJobCmd struct {
+	jobReader   boshjob.DirReader
+	jobRenderer bitemplate.JobRenderer
+	fs          boshsys.FileSystem
+	ui          boshui.UI
+}
+
+// renderJobLink is how links consumed by a job are given in a links file.
+type renderJobLink struct {
+	Address    string                      `yaml:"address"`
+	Properties map[interface{}]interface{} `yaml:"properties"`
+	Instances  []renderJobLinkInstance     `yaml:"instances"`
+}
+
+type renderJobLinkInstance struct {
+	Name      string `yaml:"name"`
+	ID        string `yaml:"id"`
+	Index     int    `yaml:"index"`
+	AZ        string `yaml:"az"`
+	Address   string `yaml:"address"`
+	Bootstrap bool   `yaml:"bootstrap"`
+}
+
+func NewRenderJobCmd(
+	jobReader boshjob.DirReader,
+	jobRenderer bitemplate.JobRenderer,
+	fs boshsys.FileSystem,
+	ui boshui.UI,
+) RenderJobCmd {
+	return RenderJobCmd{jobReader: jobReader, jobRenderer: jobRenderer, fs: fs, ui: ui}
+}
+
+func (c RenderJobCmd) Run(opts RenderJobOpts) error {
+	properties, err := c.properties(opts.Properties.Bytes)
+	if err != nil {
+		return err
+	}
+
+	links, err := c.links(opts.Links.Bytes)
+	if err != nil {
+		return err
+	}
+
+	job, err := c.jobReader.Read(filepath.Join(opts.Directory.Path, "jobs", opts.Job))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading job '%s'", opts.Job)
+	}
+
+	defer job.CleanUp() //nolint:errcheck
+
+	for _, name := range c.missingProperties(*job, properties) {
+		c.ui.ErrorLinef("Property '%s' has no default value and is not set", name)
+	}
+
+	for _, link := range job.Consumes {
+		if _, found := links[link.Name]; !found && !link.Optional {
+			c.ui.ErrorLinef("Link '%s' of type '%s' is consumed but not given", link.Name, link.Type)
+		}
+	}
+
+	renderedJob, err := c.jobRenderer.RenderWithLinks(*job, nil, properties, biproperty.Map{}, links, "", "")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Rendering job '%s'", opts.Job)
+	}
+
+	defer renderedJob.DeleteSilently()
+
+	table := boshtbl.Table{
+		Content: "rendered files",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Template"),
+			boshtbl.NewHeader("Path"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 1, Asc: true},
+		},
+	}
+
+	files := map[string]string{"monit": "monit"}
+
+	for src, dst := range job.Templates {
+		files[src] = dst
+	}
+
+	for src, dst := range files {
+		dstPath := filepath.Join(opts.OutputDir.Path, dst)
+
+		err := c.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating directory '%s'", filepath.Dir(dstPath))
+		}
+
+		err = c.fs.CopyFile(filepath.Join(renderedJob.Path(), dst), dstPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing rendered file '%s'", dstPath)
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(src),
+			boshtbl.NewValueString(dstPath),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+func (c RenderJobCmd) properties(bytes []byte) (biproperty.Map, error) {
+	var rawProperties map[interface{}]interface{}
+
+	err := yaml.Unmarshal(bytes, &rawProperties)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling properties")
+	}
+
+	properties, err := biproperty.BuildMap(rawProperties)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing properties")
+	}
+
+	return properties, nil
+}
+
+func (c RenderJobCmd) links(bytes []byte) (map[string]bitemplate.LinkContext, error) {
+	var rawLinks map[string]renderJobLink
+
+	err := yaml.Unmarshal(bytes, &rawLinks)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling links")
+	}
+
+	links := map[string]bitemplate.LinkContext{}
+
+	for name, rawLink := range rawLinks {
+		properties, err := biproperty.BuildMap(rawLink.Properties)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing link '%s' properties", name)
+		}
+
+		link := bitemplate.LinkContext{
+			Address:    rawLink.Address,
+			Properties: properties,
+			Instances:  []bitemplate.LinkInstanceContext{},
+		}
+
+		for _, instance := range rawLink.Instances {
+			link.Instances = append(link.Instances, bitemplate.LinkInstanceContext(instance))
+		}
+
+		links[name] = link
+	}
+
+	return links, nil
+}
+
+// missingProperties returns properties that the job spec does not have
+// default values for and that are not set. Templates that do not use them
+// with if_p fail to render.
+func (c RenderJobCmd) missingProperties(job boshjob.Job, properties biproperty.Map) []string {
+	var names []string
+
+	for name, definition := range job.Properties {
+		if definition.Default == nil && !c.propertySet(properties, name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (c RenderJobCmd) propertySet(properties biproperty.Map, name string) bool {
+	var value interface{} = properties
+
+	for _, key := range strings.Split(name, ".") {
+		nested, ok := value.(biproperty.Map)
+		if !ok {
+			return false
+		}
+
+		value = nested[key]
+	}
+
+	return value != nil
+}
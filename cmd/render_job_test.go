@@ -0,0 +1,166 @@
+package cmd_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshjob "github.com/cloudfoundry/bosh-cli/v7/release/job"
+	bitemplate "github.com/cloudfoundry/bosh-cli/v7/templatescompiler"
+	bitemplateerb "github.com/cloudfoundry/bosh-cli/v7/templatescompiler/erbrenderer"
+	fakebitemplateerb "github.com/cloudfoundry/bosh-cli/v7/templatescompiler/erbrenderer/fakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("RenderJobCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		fs      *fakesys.FakeFileSystem
+		command RenderJobCmd
+		opts    RenderJobOpts
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		fs.TempDirDirs = []string{"/tmp/job", "/tmp/rendered-job"}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		erbRenderer := bitemplateerb.NewNativeERBRenderer(fs, fakebitemplateerb.NewFakeERBRender(), logger)
+		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, fs, fakeuuid.NewFakeGenerator(), logger)
+		command = NewRenderJobCmd(boshjob.NewSourceReaderImpl(fs), jobRenderer, fs, ui)
+
+		Expect(fs.WriteFileString("/release/jobs/web/spec", `---
+name: web
+templates:
+  config.yml.erb: config/config.yml
+  ctl.erb: bin/ctl
+properties:
+  port:
+    default: 8080
+  users:
+    description: Users allowed to log in
+  tls.enabled:
+    default: false
+consumes:
+- {name: db, type: database, optional: true}
+`)).To(Succeed())
+		Expect(fs.WriteFileString("/release/jobs/web/monit", "check process web")).To(Succeed())
+		Expect(fs.WriteFileString("/release/jobs/web/templates/config.yml.erb",
+			"port: <%= p('port') %>\nusers: <%= p('users').join(',') %>\n"+
+				"<% if_link('db') do |db| %>db: <%= db.address %>:<%= db.p('port') %>\n<% end %>",
+		)).To(Succeed())
+		Expect(fs.WriteFileString("/release/jobs/web/templates/ctl.erb", "exec web --tls=<%= p('tls.enabled') %>")).To(Succeed())
+
+		opts = RenderJobOpts{
+			Directory:  DirOrCWDArg{Path: "/release"},
+			Job:        "web",
+			Properties: FileBytesArg{Bytes: []byte("users: [admin, reader]\ntls: {enabled: true}\n")},
+			OutputDir:  DirOrCWDArg{Path: "/output"},
+		}
+	})
+
+	Describe("Run", func() {
+		It("writes rendered job files to the output directory", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/output/config/config.yml")).To(Equal("port: 8080\nusers: admin,reader\n"))
+			Expect(fs.ReadFileString("/output/bin/ctl")).To(Equal("exec web --tls=true"))
+			Expect(fs.ReadFileString("/output/monit")).To(Equal("check process web"))
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Rows).To(ConsistOf(
+				[]boshtbl.Value{boshtbl.NewValueString("config.yml.erb"), boshtbl.NewValueString("/output/config/config.yml")},
+				[]boshtbl.Value{boshtbl.NewValueString("ctl.erb"), boshtbl.NewValueString("/output/bin/ctl")},
+				[]boshtbl.Value{boshtbl.NewValueString("monit"), boshtbl.NewValueString("/output/monit")},
+			))
+			Expect(ui.Errors).To(BeEmpty())
+		})
+
+		It("renders templates with the given links", func() {
+			opts.Links = FileBytesArg{Bytes: []byte(`
+db:
+  address: db.bosh
+  properties: {port: 5432}
+  instances:
+  - {name: db, index: 0, address: 10.0.0.6}
+`)}
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/output/config/config.yml")).To(Equal("port: 8080\nusers: admin,reader\ndb: db.bosh:5432\n"))
+		})
+
+		It("reports consumed links that are not given", func() {
+			Expect(fs.WriteFileString("/release/jobs/web/spec", `---
+name: web
+templates: {}
+consumes:
+- {name: db, type: database}
+- {name: cache, type: redis, optional: true}
+`)).To(Succeed())
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Errors).To(Equal([]string{"Link 'db' of type 'database' is consumed but not given"}))
+		})
+
+		It("removes temporary files", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/tmp/job")).To(BeFalse())
+			Expect(fs.FileExists("/tmp/rendered-job")).To(BeFalse())
+		})
+
+		Context("when required properties are missing", func() {
+			BeforeEach(func() {
+				opts.Properties = FileBytesArg{Bytes: []byte("tls: {enabled: true}\n")}
+			})
+
+			It("reports them and returns the rendering error", func() {
+				err := command.Run(opts)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Rendering job 'web'"))
+				Expect(err.Error()).To(ContainSubstring("Can't find property 'users'"))
+
+				Expect(ui.Errors).To(Equal([]string{"Property 'users' has no default value and is not set"}))
+				Expect(fs.FileExists("/output/config/config.yml")).To(BeFalse())
+			})
+		})
+
+		It("returns an error if the job cannot be read", func() {
+			opts.Job = "unknown"
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading job 'unknown'"))
+		})
+
+		It("returns an error if properties are not valid YAML", func() {
+			opts.Properties = FileBytesArg{Bytes: []byte("-")}
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling properties"))
+		})
+
+		It("returns an error if writing rendered files fails", func() {
+			fs.RegisterMkdirAllError("/output/config", errors.New("fake-mkdir-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-mkdir-err"))
+		})
+	})
+})
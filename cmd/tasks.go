@@ -1,19 +1,39 @@
 package cmd
 
 import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
 	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
 	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
 	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
 	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	boshuit "github.com/cloudfoundry/bosh-cli/v7/ui/task"
 )
 
+const tasksFollowInterval = 5 * time.Second
+
 type TasksCmd struct {
-	ui       boshui.UI
-	director boshdir.Director
+	ui           boshui.UI
+	director     boshdir.Director
+	taskReporter boshuit.Reporter
+	timeService  clock.Clock
 }
 
-func NewTasksCmd(ui boshui.UI, director boshdir.Director) TasksCmd {
-	return TasksCmd{ui: ui, director: director}
+func NewTasksCmd(
+	ui boshui.UI,
+	director boshdir.Director,
+	taskReporter boshuit.Reporter,
+	timeService clock.Clock,
+) TasksCmd {
+	return TasksCmd{
+		ui:           ui,
+		director:     director,
+		taskReporter: taskReporter,
+		timeService:  timeService,
+	}
 }
 
 func (c TasksCmd) Run(opts TasksOpts) error {
@@ -22,6 +42,10 @@ func (c TasksCmd) Run(opts TasksOpts) error {
 		Deployment: opts.Deployment,
 	}
 
+	if opts.Follow {
+		return c.follow(filter)
+	}
+
 	if opts.Recent != nil {
 		tasks, err := c.director.RecentTasks(*opts.Recent, filter)
 		if err != nil {
@@ -38,6 +62,45 @@ func (c TasksCmd) Run(opts TasksOpts) error {
 	return c.printTable(tasks)
 }
 
+// follow polls current tasks and streams event output of each new task
+// until polling fails. Event lines of concurrent tasks are prefixed
+// with their task IDs by the reporter.
+func (c TasksCmd) follow(filter boshdir.TasksFilter) error {
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+
+	followed := map[int]struct{}{}
+
+	for {
+		tasks, err := c.director.CurrentTasks(filter)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if _, found := followed[task.ID()]; found {
+				continue
+			}
+
+			followed[task.ID()] = struct{}{}
+
+			wg.Add(1)
+
+			go func(task boshdir.Task) {
+				defer wg.Done()
+
+				err := task.EventOutput(c.taskReporter)
+				if err != nil {
+					c.ui.ErrorLinef("Task %d: %s", task.ID(), err)
+				}
+			}(task)
+		}
+
+		<-c.timeService.After(tasksFollowInterval)
+	}
+}
+
 func (c TasksCmd) printTable(tasks []boshdir.Task) error {
 	table := boshtbl.Table{
 		Content: "tasks",
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -13,19 +14,24 @@ import (
 	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
 	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
 	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+	faketask "github.com/cloudfoundry/bosh-cli/v7/ui/task/taskfakes"
 )
 
 var _ = Describe("TasksCmd", func() {
 	var (
-		ui       *fakeui.FakeUI
-		director *fakedir.FakeDirector
-		command  TasksCmd
+		ui           *fakeui.FakeUI
+		director     *fakedir.FakeDirector
+		taskReporter *faketask.FakeReporter
+		timeService  *fakeclock.FakeClock
+		command      TasksCmd
 	)
 
 	BeforeEach(func() {
 		ui = &fakeui.FakeUI{}
 		director = &fakedir.FakeDirector{}
-		command = NewTasksCmd(ui, director)
+		taskReporter = &faketask.FakeReporter{}
+		timeService = fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC))
+		command = NewTasksCmd(ui, director, taskReporter, timeService)
 	})
 
 	Describe("Run", func() {
@@ -154,6 +160,58 @@ var _ = Describe("TasksCmd", func() {
 			})
 		})
 
+		Context("when following tasks", func() {
+			BeforeEach(func() {
+				opts.Follow = true
+				opts.Deployment = "deployment"
+			})
+
+			It("streams event output of current and new tasks until polling fails", func() {
+				task4 := &fakedir.FakeTask{IDStub: func() int { return 4 }}
+				task5 := &fakedir.FakeTask{IDStub: func() int { return 5 }}
+				task5.EventOutputReturns(errors.New("fake-output-err"))
+
+				director.CurrentTasksReturnsOnCall(0, []boshdir.Task{task4}, nil)
+				director.CurrentTasksReturnsOnCall(1, []boshdir.Task{task4, task5}, nil)
+				director.CurrentTasksReturnsOnCall(2, nil, errors.New("fake-err"))
+
+				errCh := make(chan error)
+				go func() { errCh <- act() }()
+
+				timeService.WaitForWatcherAndIncrement(5 * time.Second)
+				timeService.WaitForWatcherAndIncrement(5 * time.Second)
+
+				var err error
+				Eventually(errCh).Should(Receive(&err))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+				Expect(director.CurrentTasksCallCount()).To(Equal(3))
+				Expect(director.CurrentTasksArgsForCall(0)).To(Equal(boshdir.TasksFilter{Deployment: "deployment"}))
+
+				Expect(task4.EventOutputCallCount()).To(Equal(1))
+				Expect(task4.EventOutputArgsForCall(0)).To(Equal(taskReporter))
+				Expect(task5.EventOutputCallCount()).To(Equal(1))
+				Expect(task5.EventOutputArgsForCall(0)).To(Equal(taskReporter))
+
+				Expect(ui.Errors).To(Equal([]string{"Task 5: fake-output-err"}))
+				Expect(ui.Tables).To(BeEmpty())
+			})
+
+			It("includes all task types if requested", func() {
+				opts.All = true
+
+				director.CurrentTasksReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(director.CurrentTasksArgsForCall(0)).To(Equal(boshdir.TasksFilter{
+					All:        true,
+					Deployment: "deployment",
+				}))
+			})
+		})
+
 		Context("when recent tasks are requested", func() {
 			BeforeEach(func() {
 				recent := 30
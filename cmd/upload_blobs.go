@@ -7,17 +7,18 @@ import (
 )
 
 type UploadBlobsCmd struct {
-	blobsDir boshreldir.BlobsDir
+	blobsDir             boshreldir.BlobsDir
+	numOfParallelWorkers int
 }
 
-func NewUploadBlobsCmd(blobsDir boshreldir.BlobsDir) UploadBlobsCmd {
-	return UploadBlobsCmd{blobsDir: blobsDir}
+func NewUploadBlobsCmd(blobsDir boshreldir.BlobsDir, numOfParallelWorkers int) UploadBlobsCmd {
+	return UploadBlobsCmd{blobsDir: blobsDir, numOfParallelWorkers: numOfParallelWorkers}
 }
 
 func (c UploadBlobsCmd) Run() error {
-	err := c.blobsDir.UploadBlobs()
+	err := c.blobsDir.UploadBlobs(c.numOfParallelWorkers)
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Uploading blobs")
+		return bosherr.WrapErrorf(err, "Uploading blobs (run the command again to upload remaining blobs)")
 	}
 
 	return nil
@@ -12,13 +12,15 @@ import (
 
 var _ = Describe("UploadBlobsCmd", func() {
 	var (
-		blobsDir *fakereldir.FakeBlobsDir
-		command  UploadBlobsCmd
+		blobsDir     *fakereldir.FakeBlobsDir
+		command      UploadBlobsCmd
+		numOfWorkers int
 	)
 
 	BeforeEach(func() {
+		numOfWorkers = 5
 		blobsDir = &fakereldir.FakeBlobsDir{}
-		command = NewUploadBlobsCmd(blobsDir)
+		command = NewUploadBlobsCmd(blobsDir, numOfWorkers)
 	})
 
 	Describe("Run", func() {
@@ -29,6 +31,7 @@ var _ = Describe("UploadBlobsCmd", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(blobsDir.UploadBlobsCallCount()).To(Equal(1))
+			Expect(blobsDir.UploadBlobsArgsForCall(0)).To(Equal(5))
 		})
 
 		It("returns error if upload fails", func() {
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type VarsStoreCheckCmd struct {
+	ui          boshui.UI
+	timeService clock.Clock
+}
+
+// varsStoreCert is the value of a certificate variable
+// as saved by the certificate generator.
+type varsStoreCert struct {
+	Certificate string `yaml:"certificate"`
+	PrivateKey  string `yaml:"private_key"`
+	CA          string `yaml:"ca"`
+}
+
+func NewVarsStoreCheckCmd(ui boshui.UI, timeService clock.Clock) VarsStoreCheckCmd {
+	return VarsStoreCheckCmd{ui: ui, timeService: timeService}
+}
+
+func (c VarsStoreCheckCmd) Run(opts VarsStoreCheckOpts) error {
+	defs, err := opts.VarsFSStore.List()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "certificates",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Variable"),
+			boshtbl.NewHeader("Common Name"),
+			boshtbl.NewHeader("Is CA"),
+			boshtbl.NewHeader("Expiry Date (UTC)"),
+			boshtbl.NewHeader("Days Left"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 4, Asc: true}},
+	}
+
+	var expiring int
+
+	now := c.timeService.Now()
+
+	for _, def := range defs {
+		cert, found, err := varsStoreCertificate(opts.VarsFSStore, def.Name)
+		if err != nil {
+			return err
+		} else if !found {
+			continue
+		}
+
+		daysLeft := int(cert.NotAfter.Sub(now).Hours() / 24)
+
+		soon := daysLeft <= opts.Threshold
+		if soon {
+			expiring++
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(def.Name),
+			boshtbl.NewValueString(cert.Subject.CommonName),
+			boshtbl.NewValueBool(cert.IsCA),
+			boshtbl.NewValueString(cert.NotAfter.UTC().Format(time.RFC3339)),
+			boshtbl.NewValueFmt(boshtbl.NewValueInt(daysLeft), soon),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if expiring > 0 {
+		return bosherr.Errorf("%d certificate(s) expire within %d days", expiring, opts.Threshold)
+	}
+
+	return nil
+}
+
+// varsStoreCertificate returns the parsed certificate of the variable
+// and whether the variable holds a certificate.
+func varsStoreCertificate(store VarsFSStore, name string) (*x509.Certificate, bool, error) {
+	val, found, err := varsStoreCertValue(store, name)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	block, _ := pem.Decode([]byte(val.Certificate))
+	if block == nil {
+		return nil, false, bosherr.Errorf("Expected variable '%s' to contain a PEM formatted certificate", name)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Parsing certificate of variable '%s'", name)
+	}
+
+	return cert, true, nil
+}
+
+func varsStoreCertValue(store VarsFSStore, name string) (varsStoreCert, bool, error) {
+	val, found, err := store.Get(boshtpl.VariableDefinition{Name: name})
+	if err != nil || !found {
+		return varsStoreCert{}, false, err
+	}
+
+	if _, ok := val.(map[interface{}]interface{}); !ok {
+		return varsStoreCert{}, false, nil
+	}
+
+	// Convert to YAML for easier struct parsing
+	bytes, err := yaml.Marshal(val)
+	if err != nil {
+		return varsStoreCert{}, false, bosherr.WrapErrorf(err, "Serializing variable '%s'", name)
+	}
+
+	var cert varsStoreCert
+
+	err = yaml.Unmarshal(bytes, &cert)
+	if err != nil {
+		return varsStoreCert{}, false, bosherr.WrapErrorf(err, "Deserializing variable '%s'", name)
+	}
+
+	return cert, len(cert.Certificate) > 0, nil
+}
@@ -0,0 +1,118 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("VarsStoreCheckCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		fs      *fakesys.FakeFileSystem
+		now     time.Time
+		command VarsStoreCheckCmd
+		opts    VarsStoreCheckOpts
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		now = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		command = NewVarsStoreCheckCmd(ui, fakeclock.NewFakeClock(now))
+
+		opts = VarsStoreCheckOpts{VarsFSStore: VarsFSStore{FS: fs}, Threshold: 30}
+
+		err := (&opts.VarsFSStore).UnmarshalFlag("/creds.yml")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	act := func() error { return command.Run(opts) }
+
+	It("lists certificates with their expiry", func() {
+		err := fs.WriteFileString("/creds.yml", `
+admin_password: secret
+default_ca:
+  certificate: |
+`+indentedCertPEM(now.Add(100*24*time.Hour), "    ")+`
+  private_key: key
+director_ssl:
+  certificate: |
+`+indentedCertPEM(now.Add(10*24*time.Hour), "    ")+`
+  private_key: key
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("1 certificate(s) expire within 30 days"))
+
+		Expect(ui.Table.Header).To(Equal([]boshtbl.Header{
+			boshtbl.NewHeader("Variable"),
+			boshtbl.NewHeader("Common Name"),
+			boshtbl.NewHeader("Is CA"),
+			boshtbl.NewHeader("Expiry Date (UTC)"),
+			boshtbl.NewHeader("Days Left"),
+		}))
+
+		Expect(ui.Table.SortBy).To(Equal([]boshtbl.ColumnSort{{Column: 4, Asc: true}}))
+
+		Expect(ui.Table.Rows).To(ConsistOf(
+			[]boshtbl.Value{
+				boshtbl.NewValueString("default_ca"),
+				boshtbl.NewValueString("test"),
+				boshtbl.NewValueBool(false),
+				boshtbl.NewValueString("2020-04-10T00:00:00Z"),
+				boshtbl.NewValueFmt(boshtbl.NewValueInt(100), false),
+			},
+			[]boshtbl.Value{
+				boshtbl.NewValueString("director_ssl"),
+				boshtbl.NewValueString("test"),
+				boshtbl.NewValueBool(false),
+				boshtbl.NewValueString("2020-01-11T00:00:00Z"),
+				boshtbl.NewValueFmt(boshtbl.NewValueInt(10), true),
+			},
+		))
+	})
+
+	It("succeeds if no certificates are expiring", func() {
+		err := fs.WriteFileString("/creds.yml", `
+default_ca:
+  certificate: |
+`+indentedCertPEM(now.Add(100*24*time.Hour), "    ")+`
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(act()).ToNot(HaveOccurred())
+		Expect(ui.Table.Rows).To(HaveLen(1))
+	})
+
+	It("returns error if certificate cannot be parsed", func() {
+		err := fs.WriteFileString("/creds.yml", "default_ca:\n  certificate: not-a-cert\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected variable 'default_ca' to contain a PEM formatted certificate"))
+	})
+
+	It("returns error if vars store cannot be read", func() {
+		err := fs.WriteFileString("/creds.yml", "-")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.ReadFileError = errors.New("fake-err")
+
+		err = act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+	})
+})
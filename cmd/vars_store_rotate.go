@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	cfgtypes "github.com/cloudfoundry/config-server/types"
+	"github.com/cppforlife/go-patch/patch"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+type VarsStoreRotateCmd struct {
+	ui boshui.UI
+}
+
+type varsStoreRotation struct {
+	def boshtpl.VariableDefinition
+	ca  string // name of the signing CA, if any
+}
+
+func NewVarsStoreRotateCmd(ui boshui.UI) VarsStoreRotateCmd {
+	return VarsStoreRotateCmd{ui: ui}
+}
+
+// Run regenerates requested certificates and certificates signed by
+// requested CAs. Old CA certificates are kept next to the new ones in 'ca'
+// values so that components trust both until they are all redeployed.
+func (c VarsStoreRotateCmd) Run(opts VarsStoreRotateOpts) error {
+	store := opts.VarFlags.VarsFSStore
+
+	if !store.IsSet() {
+		return bosherr.Error("Expected '--vars-store' to be specified")
+	}
+
+	vars := opts.VarFlags.AsVariables()
+
+	defs, err := c.variableDefinitions(opts, vars)
+	if err != nil {
+		return err
+	}
+
+	rotations, err := c.rotations(defs, opts.Names)
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "certificates",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Variable"),
+			boshtbl.NewHeader("Signed By"),
+		},
+		Notes: []string{"Old CA certificates are kept in 'ca' values until CAs are rotated again"},
+	}
+
+	for _, rotation := range rotations {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(rotation.def.Name),
+			boshtbl.NewValueString(rotation.ca),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	// Newly generated CAs are saved before certificates they sign are generated
+	store.ValueGeneratorFactory = cfgtypes.NewValueGeneratorConcrete(
+		NewVarsCertLoader(boshtpl.NewMultiVars([]boshtpl.Variables{store, vars})))
+
+	transitionalCAs := map[string]string{}
+
+	for _, rotation := range rotations {
+		name := rotation.def.Name
+
+		oldVal, _, err := varsStoreCertValue(store, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = store.Regenerate(rotation.def)
+		if err != nil {
+			return err
+		}
+
+		newVal, _, err := varsStoreCertValue(store, name)
+		if err != nil {
+			return err
+		}
+
+		if c.isCA(rotation.def) {
+			transitionalCAs[name] = c.concatCerts(newVal.Certificate, oldVal.Certificate)
+		}
+
+		ca, found := transitionalCAs[rotation.ca]
+		if len(rotation.ca) == 0 {
+			ca, found = transitionalCAs[name]
+		}
+
+		if found {
+			err = c.setCA(store, name, ca)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// variableDefinitions returns definitions from the manifest's variables
+// section with options interpolated, e.g. common names of certificates.
+func (c VarsStoreRotateCmd) variableDefinitions(opts VarsStoreRotateOpts, vars boshtpl.Variables) ([]boshtpl.VariableDefinition, error) {
+	var manifest interface{}
+
+	err := yaml.Unmarshal(opts.Args.Manifest.Bytes, &manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling manifest")
+	}
+
+	manifest, err = opts.OpsFlags.AsOp().Apply(manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Applying operations to manifest")
+	}
+
+	section, err := patch.FindOp{Path: patch.MustNewPointerFromString("/variables?")}.Apply(manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding variables in manifest")
+	}
+
+	bytes, err := yaml.Marshal(section)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Serializing variables")
+	}
+
+	// Root of the variables section is a list, hence no variables are generated
+	bytes, err = boshtpl.NewTemplate(bytes).Evaluate(vars, nil, boshtpl.EvaluateOpts{})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Interpolating variables")
+	}
+
+	var defs []boshtpl.VariableDefinition
+
+	err = yaml.Unmarshal(bytes, &defs)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Deserializing variables")
+	}
+
+	return defs, nil
+}
+
+// rotations returns requested certificates followed by certificates
+// signed by rotated CAs. CAs are always rotated before certificates they sign.
+func (c VarsStoreRotateCmd) rotations(defs []boshtpl.VariableDefinition, names []string) ([]varsStoreRotation, error) {
+	certs := map[string]varsStoreRotation{}
+
+	for _, def := range defs {
+		if def.Type == "certificate" {
+			certs[def.Name] = varsStoreRotation{def: def, ca: c.signingCA(def)}
+		}
+	}
+
+	rotated := map[string]struct{}{}
+
+	for _, name := range names {
+		if _, found := certs[name]; !found {
+			return nil, bosherr.Errorf("Expected to find certificate variable '%s' in manifest", name)
+		}
+		rotated[name] = struct{}{}
+	}
+
+	for added := true; added; {
+		added = false
+
+		for name, cert := range certs {
+			if _, found := rotated[name]; found {
+				continue
+			}
+			if _, found := rotated[cert.ca]; found {
+				rotated[name] = struct{}{}
+				added = true
+			}
+		}
+	}
+
+	var result []varsStoreRotation
+
+	visited := map[string]struct{}{}
+
+	var visit func(string)
+
+	visit = func(name string) {
+		if _, found := visited[name]; found {
+			return
+		}
+		visited[name] = struct{}{}
+
+		cert := certs[name]
+
+		if _, found := rotated[cert.ca]; found {
+			visit(cert.ca)
+		}
+
+		result = append(result, cert)
+	}
+
+	// Keep order of the manifest where possible
+	for _, def := range defs {
+		if _, found := rotated[def.Name]; found {
+			visit(def.Name)
+		}
+	}
+
+	return result, nil
+}
+
+func (c VarsStoreRotateCmd) signingCA(def boshtpl.VariableDefinition) string {
+	if options, ok := def.Options.(map[interface{}]interface{}); ok {
+		if ca, ok := options["ca"].(string); ok {
+			return ca
+		}
+	}
+
+	return ""
+}
+
+func (c VarsStoreRotateCmd) isCA(def boshtpl.VariableDefinition) bool {
+	if options, ok := def.Options.(map[interface{}]interface{}); ok {
+		if isCA, ok := options["is_ca"].(bool); ok {
+			return isCA
+		}
+	}
+
+	return false
+}
+
+func (c VarsStoreRotateCmd) setCA(store VarsFSStore, name, ca string) error {
+	val, _, err := store.Get(boshtpl.VariableDefinition{Name: name})
+	if err != nil {
+		return err
+	}
+
+	typedVal, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return bosherr.Errorf("Expected variable '%s' to be a certificate", name)
+	}
+
+	typedVal["ca"] = ca
+
+	return store.Set(name, typedVal)
+}
+
+func (c VarsStoreRotateCmd) concatCerts(certs ...string) string {
+	var result []string
+
+	for _, cert := range certs {
+		if len(cert) > 0 {
+			result = append(result, strings.TrimSuffix(cert, "\n")+"\n")
+		}
+	}
+
+	return strings.Join(result, "")
+}
@@ -0,0 +1,190 @@
+package cmd_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshtpl "github.com/cloudfoundry/bosh-cli/v7/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("VarsStoreRotateCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		fs      *fakesys.FakeFileSystem
+		command VarsStoreRotateCmd
+		opts    VarsStoreRotateOpts
+	)
+
+	const manifest = `
+variables:
+- name: default_ca
+  type: certificate
+  options:
+    is_ca: true
+    common_name: ca
+- name: director_ssl
+  type: certificate
+  options:
+    ca: default_ca
+    common_name: ((internal_ip))
+- name: other_ca
+  type: certificate
+  options:
+    is_ca: true
+    common_name: other
+- name: admin_password
+  type: password
+`
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewVarsStoreRotateCmd(ui)
+
+		opts = VarsStoreRotateOpts{
+			Args:  VarsStoreRotateArgs{Manifest: FileBytesArg{Bytes: []byte(manifest)}},
+			Names: []string{"default_ca"},
+		}
+
+		opts.VarFlags.VarKVs = []boshtpl.VarKV{{Name: "internal_ip", Value: "10.0.0.6"}}
+		opts.VarFlags.VarsFSStore = VarsFSStore{FS: fs}
+
+		err := (&opts.VarFlags.VarsFSStore).UnmarshalFlag("/creds.yml")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	act := func() error { return command.Run(opts) }
+
+	type certVal struct {
+		Certificate string
+		PrivateKey  string `yaml:"private_key"`
+		CA          string
+	}
+
+	readStore := func() map[string]certVal {
+		bytes, err := fs.ReadFile("/creds.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		var vals map[string]certVal
+
+		err = yaml.Unmarshal(bytes, &vals)
+		Expect(err).ToNot(HaveOccurred())
+
+		return vals
+	}
+
+	parseCert := func(data string) *x509.Certificate {
+		block, _ := pem.Decode([]byte(data))
+		Expect(block).ToNot(BeNil())
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+
+		return cert
+	}
+
+	It("regenerates CA and certificates signed by it keeping old CA in ca values", func() {
+		Expect(act()).ToNot(HaveOccurred())
+
+		old := readStore()
+		Expect(old).To(HaveKey("default_ca"))
+		Expect(old).To(HaveKey("director_ssl"))
+		Expect(old).ToNot(HaveKey("other_ca"))
+
+		Expect(act()).ToNot(HaveOccurred())
+
+		rotated := readStore()
+		Expect(rotated["default_ca"].Certificate).ToNot(Equal(old["default_ca"].Certificate))
+		Expect(rotated["director_ssl"].Certificate).ToNot(Equal(old["director_ssl"].Certificate))
+
+		bundle := rotated["default_ca"].Certificate + old["default_ca"].Certificate
+		Expect(rotated["default_ca"].CA).To(Equal(bundle))
+		Expect(rotated["director_ssl"].CA).To(Equal(bundle))
+
+		cert := parseCert(rotated["director_ssl"].Certificate)
+		Expect(cert.Subject.CommonName).To(Equal("10.0.0.6"))
+		Expect(cert.CheckSignatureFrom(parseCert(rotated["default_ca"].Certificate))).ToNot(HaveOccurred())
+	})
+
+	It("regenerates only requested certificates not signed by rotated CAs", func() {
+		opts.Names = []string{"default_ca"}
+		Expect(act()).ToNot(HaveOccurred())
+
+		old := readStore()
+
+		opts.Names = []string{"director_ssl", "other_ca"}
+		Expect(act()).ToNot(HaveOccurred())
+
+		rotated := readStore()
+		Expect(rotated["default_ca"]).To(Equal(old["default_ca"]))
+		Expect(rotated["director_ssl"].Certificate).ToNot(Equal(old["director_ssl"].Certificate))
+		Expect(rotated["director_ssl"].CA).To(Equal(old["default_ca"].Certificate))
+		Expect(rotated).To(HaveKey("other_ca"))
+	})
+
+	It("prints certificates to regenerate with CAs first", func() {
+		opts.Names = []string{"director_ssl", "default_ca"}
+
+		Expect(act()).ToNot(HaveOccurred())
+
+		Expect(ui.Table).To(Equal(boshtbl.Table{
+			Content: "certificates",
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Variable"),
+				boshtbl.NewHeader("Signed By"),
+			},
+			Rows: [][]boshtbl.Value{
+				{boshtbl.NewValueString("default_ca"), boshtbl.NewValueString("")},
+				{boshtbl.NewValueString("director_ssl"), boshtbl.NewValueString("default_ca")},
+			},
+			Notes: []string{"Old CA certificates are kept in 'ca' values until CAs are rotated again"},
+		}))
+	})
+
+	It("does not regenerate certificates if confirmation is rejected", func() {
+		ui.AskedConfirmationErr = errors.New("stop")
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("stop"))
+
+		Expect(fs.FileExists("/creds.yml")).To(BeFalse())
+	})
+
+	It("returns error if variable is not a certificate defined in manifest", func() {
+		opts.Names = []string{"admin_password"}
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected to find certificate variable 'admin_password' in manifest"))
+	})
+
+	It("returns error if vars store is not specified", func() {
+		opts.VarFlags.VarsFSStore = VarsFSStore{}
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected '--vars-store' to be specified"))
+	})
+
+	It("returns error if ops cannot be applied to manifest", func() {
+		opts.OpsFlags.OpsFiles = []OpsFileArg{{
+			Ops: patch.Ops{patch.RemoveOp{Path: patch.MustNewPointerFromString("/unknown")}},
+		}}
+
+		err := act()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Applying operations to manifest"))
+	})
+})
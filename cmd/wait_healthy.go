@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+const waitHealthyInterval = 5 * time.Second
+
+type WaitHealthyCmd struct {
+	ui          boshui.UI
+	deployment  boshdir.Deployment
+	timeService clock.Clock
+}
+
+func NewWaitHealthyCmd(ui boshui.UI, deployment boshdir.Deployment, timeService clock.Clock) WaitHealthyCmd {
+	return WaitHealthyCmd{ui: ui, deployment: deployment, timeService: timeService}
+}
+
+func (c WaitHealthyCmd) Run(opts WaitHealthyOpts) error {
+	deadline := c.timeService.Now().Add(opts.Timeout)
+
+	for {
+		infos, err := c.deployment.InstanceInfos()
+		if err != nil {
+			return err
+		}
+
+		failing, total := c.failingInstances(infos, opts.InstanceGroup)
+
+		// Nothing to wait for usually means a misspelled instance group
+		if total == 0 {
+			if len(opts.InstanceGroup) > 0 {
+				return bosherr.Errorf("Expected instance group '%s' to have instances that are expected to be running", opts.InstanceGroup)
+			}
+			return bosherr.Error("Expected deployment to have instances that are expected to be running")
+		}
+
+		if len(failing) == 0 {
+			break
+		}
+
+		if !c.timeService.Now().Before(deadline) {
+			c.printFailing(failing)
+
+			// Problems such as unresponsive agents explain
+			// why instances do not report their processes
+			err := c.printProblems()
+			if err != nil {
+				return err
+			}
+
+			return bosherr.Errorf("Timed out after %s waiting for %d of %d instance(s) to be running", opts.Timeout, len(failing), total)
+		}
+
+		c.ui.PrintLinef("Waiting for %d of %d instance(s) to be running", len(failing), total)
+
+		<-c.timeService.After(waitHealthyInterval)
+	}
+
+	probs, err := c.deployment.ScanForProblems()
+	if err != nil {
+		return err
+	}
+
+	if len(probs) > 0 {
+		c.printProblemsTable(probs)
+		return bosherr.Errorf("%d problem(s) found", len(probs))
+	}
+
+	c.ui.PrintLinef("All instances are running")
+
+	return nil
+}
+
+// failingInstances returns instances with VMs that are expected to be
+// running but are not, and the number of instances that were checked.
+func (c WaitHealthyCmd) failingInstances(infos []boshdir.VMInfo, group string) ([]boshdir.VMInfo, int) {
+	var failing []boshdir.VMInfo
+
+	total := 0
+
+	for _, info := range infos {
+		if len(group) > 0 && info.JobName != group {
+			continue
+		}
+
+		if len(info.VMID) == 0 || info.State == "stopped" || info.State == "detached" {
+			continue
+		}
+
+		total++
+
+		if !info.IsRunning() {
+			failing = append(failing, info)
+		}
+	}
+
+	return failing, total
+}
+
+func (c WaitHealthyCmd) printFailing(infos []boshdir.VMInfo) {
+	table := boshtbl.Table{
+		Content: "failing processes",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("Process"),
+			boshtbl.NewHeader("Process State"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}, {Column: 1, Asc: true}},
+	}
+
+	for _, info := range infos {
+		instance := boshtbl.NewValueString(info.JobName + "/" + info.ID)
+
+		if len(info.Processes) == 0 {
+			table.Rows = append(table.Rows, []boshtbl.Value{
+				instance,
+				boshtbl.NewValueString(""),
+				boshtbl.ValueFmt{V: boshtbl.NewValueString(info.ProcessState), Error: true},
+			})
+			continue
+		}
+
+		for _, p := range info.Processes {
+			if p.IsRunning() {
+				continue
+			}
+
+			table.Rows = append(table.Rows, []boshtbl.Value{
+				instance,
+				boshtbl.NewValueString(p.Name),
+				boshtbl.ValueFmt{V: boshtbl.NewValueString(p.State), Error: true},
+			})
+		}
+	}
+
+	c.ui.PrintTable(table)
+}
+
+func (c WaitHealthyCmd) printProblems() error {
+	probs, err := c.deployment.ScanForProblems()
+	if err != nil {
+		return err
+	}
+
+	if len(probs) > 0 {
+		c.printProblemsTable(probs)
+	}
+
+	return nil
+}
+
+func (c WaitHealthyCmd) printProblemsTable(probs []boshdir.Problem) {
+	table := boshtbl.Table{
+		Content: "problems",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("#"),
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("Description"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, p := range probs {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueInt(p.ID),
+			boshtbl.NewValueString(p.Type),
+			boshtbl.NewValueString(p.Description),
+		})
+	}
+
+	c.ui.PrintTable(table)
+}
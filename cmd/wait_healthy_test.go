@@ -0,0 +1,224 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	. "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+var _ = Describe("WaitHealthyCmd", func() {
+	var (
+		ui          *fakeui.FakeUI
+		deployment  *fakedir.FakeDeployment
+		timeService *fakeclock.FakeClock
+		command     WaitHealthyCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		deployment = &fakedir.FakeDeployment{}
+		timeService = fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC))
+		command = NewWaitHealthyCmd(ui, deployment, timeService)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts    WaitHealthyOpts
+			running boshdir.VMInfo
+			failing boshdir.VMInfo
+		)
+
+		BeforeEach(func() {
+			opts = WaitHealthyOpts{Timeout: time.Minute}
+
+			running = boshdir.VMInfo{
+				JobName:      "web",
+				ID:           "web-id",
+				VMID:         "vm-1",
+				State:        "started",
+				ProcessState: "running",
+				Processes:    []boshdir.VMInfoProcess{{Name: "nginx", State: "running"}},
+			}
+
+			failing = boshdir.VMInfo{
+				JobName:      "worker",
+				ID:           "worker-id",
+				VMID:         "vm-2",
+				State:        "started",
+				ProcessState: "failing",
+				Processes: []boshdir.VMInfoProcess{
+					{Name: "worker", State: "failing"},
+					{Name: "syslog", State: "running"},
+				},
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("returns once all instances are running and there are no problems", func() {
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{running}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.ScanForProblemsCallCount()).To(Equal(1))
+			Expect(ui.Said).To(Equal([]string{"All instances are running"}))
+			Expect(ui.Tables).To(BeEmpty())
+		})
+
+		It("waits until failing instances are running", func() {
+			deployment.InstanceInfosReturnsOnCall(0, []boshdir.VMInfo{running, failing}, nil)
+			deployment.InstanceInfosReturnsOnCall(1, []boshdir.VMInfo{running, running}, nil)
+
+			errCh := make(chan error)
+			go func() { errCh <- act() }()
+
+			timeService.WaitForWatcherAndIncrement(5 * time.Second)
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.InstanceInfosCallCount()).To(Equal(2))
+			Expect(ui.Said).To(Equal([]string{
+				"Waiting for 1 of 2 instance(s) to be running",
+				"All instances are running",
+			}))
+		})
+
+		It("ignores instances without VMs, stopped instances and other instance groups", func() {
+			noVM := failing
+			noVM.VMID = ""
+
+			stopped := failing
+			stopped.State = "stopped"
+
+			opts.InstanceGroup = "worker"
+
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{failing, noVM, stopped}, nil)
+
+			opts.Timeout = 0
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Timed out after 0s waiting for 1 of 1 instance(s) to be running"))
+
+			opts.InstanceGroup = "web"
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{running, failing}, nil)
+
+			err = act()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if no instances of the instance group are expected to be running", func() {
+			stopped := failing
+			stopped.State = "stopped"
+
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{running, stopped}, nil)
+
+			opts.InstanceGroup = "wokrer"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected instance group 'wokrer' to have instances that are expected to be running"))
+
+			opts.InstanceGroup = "worker"
+
+			err = act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected instance group 'worker' to have instances that are expected to be running"))
+
+			Expect(deployment.ScanForProblemsCallCount()).To(Equal(0))
+		})
+
+		It("returns error if no instances of the deployment are expected to be running", func() {
+			deployment.InstanceInfosReturns(nil, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected deployment to have instances that are expected to be running"))
+		})
+
+		It("prints failing processes and problems when timing out", func() {
+			noProcesses := failing
+			noProcesses.ID = "worker-id2"
+			noProcesses.ProcessState = "unresponsive agent"
+			noProcesses.Processes = nil
+
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{running, failing, noProcesses}, nil)
+			deployment.ScanForProblemsReturns([]boshdir.Problem{
+				{ID: 3, Type: "unresponsive_agent", Description: "worker/worker-id2 is not responding"},
+			}, nil)
+
+			opts.Timeout = 0
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Timed out after 0s waiting for 2 of 3 instance(s) to be running"))
+
+			Expect(ui.Tables).To(HaveLen(2))
+			Expect(ui.Tables[0].Content).To(Equal("failing processes"))
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("worker/worker-id"),
+					boshtbl.NewValueString("worker"),
+					boshtbl.ValueFmt{V: boshtbl.NewValueString("failing"), Error: true},
+				},
+				{
+					boshtbl.NewValueString("worker/worker-id2"),
+					boshtbl.NewValueString(""),
+					boshtbl.ValueFmt{V: boshtbl.NewValueString("unresponsive agent"), Error: true},
+				},
+			}))
+			Expect(ui.Tables[1].Content).To(Equal("problems"))
+			Expect(ui.Tables[1].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueInt(3),
+					boshtbl.NewValueString("unresponsive_agent"),
+					boshtbl.NewValueString("worker/worker-id2 is not responding"),
+				},
+			}))
+		})
+
+		It("returns error if problems are found after instances are running", func() {
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{running}, nil)
+			deployment.ScanForProblemsReturns([]boshdir.Problem{
+				{ID: 3, Type: "mount_info_mismatch", Description: "web/web-id has mismatched disk"},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 problem(s) found"))
+
+			Expect(ui.Tables).To(HaveLen(1))
+			Expect(ui.Tables[0].Content).To(Equal("problems"))
+		})
+
+		It("returns error if instances cannot be retrieved", func() {
+			deployment.InstanceInfosReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if scanning for problems fails", func() {
+			deployment.InstanceInfosReturns([]boshdir.VMInfo{running}, nil)
+			deployment.ScanForProblemsReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
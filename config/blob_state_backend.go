@@ -0,0 +1,405 @@
+package config
+
+import (
+	gobytes "bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gcsconfig "github.com/cloudfoundry/bosh-gcscli/config"
+	s3client "github.com/cloudfoundry/bosh-s3cli/client"
+	s3config "github.com/cloudfoundry/bosh-s3cli/config"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// ErrStateBlobPreconditionFailed is returned by StateBlobClient.Put when the
+// object is not at the expected revision.
+var ErrStateBlobPreconditionFailed = errors.New("Object was modified or already exists")
+
+// StateBlobClient reads and writes the objects of a remote state backend.
+type StateBlobClient interface {
+	// Get returns the contents of the object and its revision,
+	// e.g. the object generation in GCS or the ETag in S3.
+	Get(key string) ([]byte, string, error)
+
+	// Put writes the object only if it is still at the given revision, or if
+	// it does not exist yet when revision is empty. The object store checks
+	// the precondition so that concurrent writers cannot both succeed.
+	Put(key string, contents []byte, revision string) error
+
+	Delete(key string) error
+	Exists(key string) (bool, error)
+}
+
+type blobStateBackend struct {
+	location string
+	key      string
+	client   StateBlobClient
+}
+
+// NewBlobStateBackend stores the state in the object named key. The lock is
+// a second object next to it which is created with a conditional write.
+func NewBlobStateBackend(location string, key string, client StateBlobClient) StateBackend {
+	return blobStateBackend{location: location, key: key, client: client}
+}
+
+func (b blobStateBackend) Location() string {
+	return b.location
+}
+
+func (b blobStateBackend) Exists() (bool, error) {
+	exists, err := b.client.Exists(b.key)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Checking deployment state '%s'", b.location)
+	}
+	return exists, nil
+}
+
+func (b blobStateBackend) Read() ([]byte, string, error) {
+	contents, revision, err := b.client.Get(b.key)
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Reading deployment state '%s'", b.location)
+	}
+	return contents, revision, nil
+}
+
+func (b blobStateBackend) Write(contents []byte, revision string) error {
+	err := b.client.Put(b.key, contents, revision)
+	if err != nil {
+		if err == ErrStateBlobPreconditionFailed {
+			return StateModifiedError{Location: b.location}
+		}
+		return bosherr.WrapErrorf(err, "Writing deployment state '%s'", b.location)
+	}
+	return nil
+}
+
+func (b blobStateBackend) Delete() error {
+	err := b.client.Delete(b.key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting deployment state '%s'", b.location)
+	}
+	return nil
+}
+
+func (b blobStateBackend) Lock(owner string) error {
+	lockKey := b.lockKey()
+
+	err := b.client.Put(lockKey, []byte(owner), "")
+	if err != nil {
+		if err == ErrStateBlobPreconditionFailed {
+			currentOwner, _, err := b.client.Get(lockKey)
+			if err != nil {
+				currentOwner = []byte("unknown")
+			}
+			return bosherr.Errorf("Deployment state '%s' is locked by '%s'. Delete '%s' if the lock is stale", b.location, currentOwner, lockKey)
+		}
+		return bosherr.WrapErrorf(err, "Creating deployment state lock '%s'", lockKey)
+	}
+
+	return nil
+}
+
+func (b blobStateBackend) Unlock() error {
+	err := b.client.Delete(b.lockKey())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting deployment state lock '%s'", b.lockKey())
+	}
+	return nil
+}
+
+func (b blobStateBackend) lockKey() string {
+	return b.key + ".lock"
+}
+
+type s3StateBlobClient struct {
+	options map[string]interface{}
+}
+
+func newS3StateBlobClient(options map[string]interface{}) StateBlobClient {
+	return s3StateBlobClient{options: options}
+}
+
+func (c s3StateBlobClient) Get(key string) ([]byte, string, error) {
+	sdk, conf, err := c.sdk()
+	if err != nil {
+		return nil, "", err
+	}
+
+	output, err := sdk.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(conf.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer output.Body.Close()
+
+	contents, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return contents, aws.StringValue(output.ETag), nil
+}
+
+func (c s3StateBlobClient) Put(key string, contents []byte, revision string) error {
+	sdk, conf, err := c.sdk()
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Body:   gobytes.NewReader(contents),
+		Bucket: aws.String(conf.BucketName),
+		Key:    aws.String(key),
+	}
+	if conf.ServerSideEncryption != "" {
+		input.ServerSideEncryption = aws.String(conf.ServerSideEncryption)
+	}
+	if conf.SSEKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(conf.SSEKMSKeyID)
+	}
+
+	req, _ := sdk.PutObjectRequest(input)
+
+	// Vendored SDK predates conditional writes so headers are set directly
+	if revision == "" {
+		req.HTTPRequest.Header.Set("If-None-Match", "*")
+	} else {
+		req.HTTPRequest.Header.Set("If-Match", revision)
+	}
+
+	err = req.Send()
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		// Conflict is returned when another conditional write is in progress
+		if reqErr.StatusCode() == http.StatusPreconditionFailed || reqErr.StatusCode() == http.StatusConflict {
+			return ErrStateBlobPreconditionFailed
+		}
+	}
+
+	return err
+}
+
+func (c s3StateBlobClient) Delete(key string) error {
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	return client.Delete(key)
+}
+
+func (c s3StateBlobClient) Exists(key string) (bool, error) {
+	client, err := c.client()
+	if err != nil {
+		return false, err
+	}
+
+	return client.Exists(key)
+}
+
+func (c s3StateBlobClient) client() (s3client.S3Blobstore, error) {
+	sdk, conf, err := c.sdk()
+	if err != nil {
+		return s3client.S3Blobstore{}, err
+	}
+
+	client, err := s3client.New(sdk, &conf)
+	if err != nil {
+		return s3client.S3Blobstore{}, bosherr.WrapError(err, "Validating config")
+	}
+
+	return client, nil
+}
+
+func (c s3StateBlobClient) sdk() (*s3.S3, s3config.S3Cli, error) {
+	bytes, err := json.Marshal(c.options)
+	if err != nil {
+		return nil, s3config.S3Cli{}, bosherr.WrapError(err, "Marshaling config")
+	}
+
+	conf, err := s3config.NewFromReader(gobytes.NewBuffer(bytes))
+	if err != nil {
+		return nil, s3config.S3Cli{}, bosherr.WrapError(err, "Reading config")
+	}
+
+	sdk, err := s3client.NewSDK(conf)
+	if err != nil {
+		return nil, s3config.S3Cli{}, bosherr.WrapError(err, "Building client SDK")
+	}
+
+	return sdk, conf, nil
+}
+
+type gcsStateBlobClient struct {
+	fs          boshsys.FileSystem
+	options     map[string]interface{}
+	jsonKeyPath string
+	err         error
+}
+
+func newGCSStateBlobClient(fs boshsys.FileSystem, options map[string]interface{}, jsonKeyPath string, err error) StateBlobClient {
+	return gcsStateBlobClient{fs: fs, options: options, jsonKeyPath: jsonKeyPath, err: err}
+}
+
+func (c gcsStateBlobClient) Get(key string) ([]byte, string, error) {
+	var contents []byte
+	var revision string
+
+	err := c.withObject(key, func(object *storage.ObjectHandle) error {
+		reader, err := object.NewReader(context.Background())
+		if err != nil {
+			return err
+		}
+
+		defer reader.Close()
+
+		contents, err = io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		revision = strconv.FormatInt(reader.Attrs.Generation, 10)
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return contents, revision, nil
+}
+
+func (c gcsStateBlobClient) Put(key string, contents []byte, revision string) error {
+	conditions := storage.Conditions{DoesNotExist: true}
+	if revision != "" {
+		generation, err := strconv.ParseInt(revision, 10, 64)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing object generation '%s'", revision)
+		}
+		conditions = storage.Conditions{GenerationMatch: generation}
+	}
+
+	err := c.withObject(key, func(object *storage.ObjectHandle) error {
+		writer := object.If(conditions).NewWriter(context.Background())
+
+		_, err := writer.Write(contents)
+		if err != nil {
+			writer.Close() //nolint:errcheck
+			return err
+		}
+
+		return writer.Close()
+	})
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return ErrStateBlobPreconditionFailed
+	}
+
+	return err
+}
+
+func (c gcsStateBlobClient) Delete(key string) error {
+	return c.withObject(key, func(object *storage.ObjectHandle) error {
+		return object.Delete(context.Background())
+	})
+}
+
+func (c gcsStateBlobClient) Exists(key string) (bool, error) {
+	var exists bool
+
+	err := c.withObject(key, func(object *storage.ObjectHandle) error {
+		_, err := object.Attrs(context.Background())
+		if err == storage.ErrObjectNotExist {
+			return nil
+		}
+
+		exists = err == nil
+
+		return err
+	})
+
+	return exists, err
+}
+
+// withObject calls fn with a handle of the object and closes the storage client
+// afterwards. The gcscli client is not used since it does not support write
+// preconditions and cannot be closed.
+func (c gcsStateBlobClient) withObject(key string, fn func(*storage.ObjectHandle) error) error {
+	conf, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	var clientOpts []option.ClientOption
+
+	switch conf.CredentialsSource {
+	case gcsconfig.NoneCredentialsSource:
+		clientOpts = append(clientOpts, option.WithoutAuthentication())
+	case gcsconfig.ServiceAccountFileCredentialsSource:
+		clientOpts = append(clientOpts, option.WithCredentialsJSON([]byte(conf.ServiceAccountFile)))
+	}
+
+	client, err := storage.NewClient(context.Background(), clientOpts...)
+	if err != nil {
+		return bosherr.WrapError(err, "Building storage client")
+	}
+
+	defer client.Close() //nolint:errcheck
+
+	object := client.Bucket(conf.BucketName).Object(key)
+	if conf.EncryptionKey != nil {
+		object = object.Key(conf.EncryptionKey)
+	}
+
+	return fn(object)
+}
+
+func (c gcsStateBlobClient) config() (gcsconfig.GCSCli, error) {
+	if c.err != nil {
+		return gcsconfig.GCSCli{}, c.err
+	}
+
+	options := map[string]interface{}{}
+	for name, value := range c.options {
+		options[name] = value
+	}
+
+	if c.jsonKeyPath != "" {
+		jsonKey, err := c.fs.ReadFileString(c.jsonKeyPath)
+		if err != nil {
+			return gcsconfig.GCSCli{}, bosherr.WrapErrorf(err, "Reading service account key '%s'", c.jsonKeyPath)
+		}
+
+		options["json_key"] = jsonKey
+
+		if _, found := options["credentials_source"]; !found {
+			options["credentials_source"] = gcsconfig.ServiceAccountFileCredentialsSource
+		}
+	}
+
+	bytes, err := json.Marshal(options)
+	if err != nil {
+		return gcsconfig.GCSCli{}, bosherr.WrapError(err, "Marshaling config")
+	}
+
+	conf, err := gcsconfig.NewFromReader(gobytes.NewBuffer(bytes))
+	if err != nil {
+		return gcsconfig.GCSCli{}, bosherr.WrapError(err, "Reading config")
+	}
+
+	return conf, nil
+}
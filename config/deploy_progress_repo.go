@@ -0,0 +1,110 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type DeployProgressRepo interface {
+	Find() (DeployProgress, bool, error)
+	Start(manifestSHA string) error
+	MarkStemcellUploaded() error
+	MarkInstancesDeleted() error
+	MarkInstanceStep(name string, index int, step string) error
+	Clear() error
+}
+
+type deployProgressRepo struct {
+	deploymentStateService DeploymentStateService
+}
+
+func NewDeployProgressRepo(deploymentStateService DeploymentStateService) DeployProgressRepo {
+	return deployProgressRepo{
+		deploymentStateService: deploymentStateService,
+	}
+}
+
+func (r deployProgressRepo) Find() (DeployProgress, bool, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return DeployProgress{}, false, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Progress == nil {
+		return DeployProgress{}, false, nil
+	}
+
+	return *deploymentState.Progress, true, nil
+}
+
+// Start replaces the progress of any earlier deploy with an empty journal
+// for a deploy of the given manifest.
+func (r deployProgressRepo) Start(manifestSHA string) error {
+	return r.update(func(progress *DeployProgress) {
+		*progress = DeployProgress{ManifestSHA: manifestSHA}
+	})
+}
+
+func (r deployProgressRepo) MarkStemcellUploaded() error {
+	return r.update(func(progress *DeployProgress) {
+		progress.StemcellUploaded = true
+	})
+}
+
+func (r deployProgressRepo) MarkInstancesDeleted() error {
+	return r.update(func(progress *DeployProgress) {
+		progress.InstancesDeleted = true
+	})
+}
+
+func (r deployProgressRepo) MarkInstanceStep(name string, index int, step string) error {
+	return r.update(func(progress *DeployProgress) {
+		for i, instance := range progress.Instances {
+			if instance.Name == name && instance.Index == index {
+				if !instance.Completed(step) {
+					progress.Instances[i].Steps = append(instance.Steps, step)
+				}
+				return
+			}
+		}
+
+		progress.Instances = append(progress.Instances, InstanceProgress{Name: name, Index: index, Steps: []string{step}})
+	})
+}
+
+func (r deployProgressRepo) Clear() error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Progress == nil {
+		return nil
+	}
+
+	deploymentState.Progress = nil
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+func (r deployProgressRepo) update(updateFunc func(*DeployProgress)) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Progress == nil {
+		deploymentState.Progress = &DeployProgress{}
+	}
+
+	updateFunc(deploymentState.Progress)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
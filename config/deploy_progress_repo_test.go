@@ -0,0 +1,93 @@
+package config_test
+
+import (
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeployProgressRepo", func() {
+	var (
+		repo                   biconfig.DeployProgressRepo
+		deploymentStateService biconfig.DeploymentStateService
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fakesys.NewFakeFileSystem(), fakeuuid.NewFakeGenerator(), logger, "/fake/path")
+		repo = biconfig.NewDeployProgressRepo(deploymentStateService)
+	})
+
+	Describe("Find", func() {
+		It("returns false when no deploy is in progress", func() {
+			_, found, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Start", func() {
+		It("replaces the progress of an earlier deploy", func() {
+			err := repo.Start("fake-old-manifest-sha")
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.MarkStemcellUploaded()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Start("fake-manifest-sha")
+			Expect(err).ToNot(HaveOccurred())
+
+			progress, found, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(progress).To(Equal(biconfig.DeployProgress{ManifestSHA: "fake-manifest-sha"}))
+		})
+	})
+
+	Describe("MarkInstanceStep", func() {
+		It("journals each completed step of an instance once", func() {
+			err := repo.Start("fake-manifest-sha")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(repo.MarkStemcellUploaded()).To(Succeed())
+			Expect(repo.MarkInstancesDeleted()).To(Succeed())
+			Expect(repo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepVMCreated)).To(Succeed())
+			Expect(repo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepDisksAttached)).To(Succeed())
+			Expect(repo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepDisksAttached)).To(Succeed())
+			Expect(repo.MarkInstanceStep("fake-job-name", 1, biconfig.DeployStepVMCreated)).To(Succeed())
+
+			progress, _, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(progress).To(Equal(biconfig.DeployProgress{
+				ManifestSHA:      "fake-manifest-sha",
+				StemcellUploaded: true,
+				InstancesDeleted: true,
+				Instances: []biconfig.InstanceProgress{
+					{Name: "fake-job-name", Index: 0, Steps: []string{"vm_created", "disks_attached"}},
+					{Name: "fake-job-name", Index: 1, Steps: []string{"vm_created"}},
+				},
+			}))
+
+			instanceProgress, found := progress.FindInstance("fake-job-name", 0)
+			Expect(found).To(BeTrue())
+			Expect(instanceProgress.Completed(biconfig.DeployStepDisksAttached)).To(BeTrue())
+			Expect(instanceProgress.Completed(biconfig.DeployStepJobsApplied)).To(BeFalse())
+		})
+	})
+
+	Describe("Clear", func() {
+		It("removes the progress from the deployment state", func() {
+			err := repo.Start("fake-manifest-sha")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Clear()
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Progress).To(BeNil())
+		})
+	})
+})
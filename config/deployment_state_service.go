@@ -15,6 +15,8 @@ type DeploymentState struct {
 	Disks              []DiskRecord     `json:"disks"`
 	Stemcells          []StemcellRecord `json:"stemcells"`
 	Releases           []ReleaseRecord  `json:"releases"`
+	Instances          []InstanceRecord `json:"instances,omitempty"`
+	Progress           *DeployProgress  `json:"progress,omitempty"`
 }
 
 type StemcellRecord struct {
@@ -32,6 +34,55 @@ type DiskRecord struct {
 	CloudProperties biproperty.Map `json:"cloud_properties"`
 }
 
+// InstanceRecord tracks the VM and disk of every instance except the first,
+// whose VM and disk are tracked by CurrentVMCID and CurrentDiskID.
+type InstanceRecord struct {
+	Name   string `json:"name"`
+	Index  int    `json:"index"`
+	IP     string `json:"ip"`
+	VMCID  string `json:"vm_cid"`
+	DiskID string `json:"disk_id"`
+}
+
+// DeployProgress journals the steps that an in-flight deploy has completed, so
+// that an interrupted deploy can be resumed. It is removed once the deploy succeeds.
+type DeployProgress struct {
+	ManifestSHA      string             `json:"manifest_sha"`
+	StemcellUploaded bool               `json:"stemcell_uploaded,omitempty"`
+	InstancesDeleted bool               `json:"instances_deleted,omitempty"`
+	Instances        []InstanceProgress `json:"instances,omitempty"`
+}
+
+type InstanceProgress struct {
+	Name  string   `json:"name"`
+	Index int      `json:"index"`
+	Steps []string `json:"steps"`
+}
+
+const (
+	DeployStepVMCreated     = "vm_created"
+	DeployStepDisksAttached = "disks_attached"
+	DeployStepJobsApplied   = "jobs_applied"
+)
+
+func (p DeployProgress) FindInstance(name string, index int) (InstanceProgress, bool) {
+	for _, instance := range p.Instances {
+		if instance.Name == name && instance.Index == index {
+			return instance, true
+		}
+	}
+	return InstanceProgress{Name: name, Index: index}, false
+}
+
+func (p InstanceProgress) Completed(step string) bool {
+	for _, completedStep := range p.Steps {
+		if completedStep == step {
+			return true
+		}
+	}
+	return false
+}
+
 type ReleaseRecord struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
@@ -42,6 +93,13 @@ type DeploymentStateService interface {
 	Path() string
 	Exists() bool
 	Load() (DeploymentState, error)
+	// LoadReadOnly loads the state without initializing and saving defaults,
+	// e.g. to plan changes without taking the lock.
+	LoadReadOnly() (DeploymentState, error)
 	Save(DeploymentState) error
 	Cleanup() error
+
+	// Lock takes the advisory lock on the state, failing if someone else holds it.
+	Lock() error
+	Unlock() error
 }
@@ -9,6 +9,7 @@ import (
 type DiskRepo interface {
 	UpdateCurrent(diskID string) error
 	FindCurrent() (DiskRecord, bool, error)
+	FindAllCurrent() ([]DiskRecord, error)
 	ClearCurrent() error
 	Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
 	Find(cid string) (DiskRecord, bool, error)
@@ -79,6 +80,33 @@ func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
 	return DiskRecord{}, false, nil
 }
 
+// FindAllCurrent returns the current disk records of all instances
+func (r diskRepo) FindAllCurrent() ([]DiskRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []DiskRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	currentDiskIDs := map[string]struct{}{}
+	if deploymentState.CurrentDiskID != "" {
+		currentDiskIDs[deploymentState.CurrentDiskID] = struct{}{}
+	}
+	for _, instanceRecord := range deploymentState.Instances {
+		if instanceRecord.DiskID != "" {
+			currentDiskIDs[instanceRecord.DiskID] = struct{}{}
+		}
+	}
+
+	records := []DiskRecord{}
+	for _, oldRecord := range deploymentState.Disks {
+		if _, found := currentDiskIDs[oldRecord.ID]; found {
+			records = append(records, oldRecord)
+		}
+	}
+
+	return records, nil
+}
+
 func (r diskRepo) UpdateCurrent(diskID string) error {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
@@ -142,6 +170,12 @@ func (r diskRepo) Delete(diskRecord DiskRecord) error {
 		config.CurrentDiskID = ""
 	}
 
+	for i, instanceRecord := range config.Instances {
+		if instanceRecord.DiskID == diskRecord.ID {
+			config.Instances[i].DiskID = ""
+		}
+	}
+
 	err = r.deploymentStateService.Save(config)
 	if err != nil {
 		return bosherr.WrapError(err, "Saving new config")
@@ -243,6 +243,47 @@ var _ = Describe("DiskRepo", func() {
 				Expect(found).To(BeFalse())
 			})
 		})
+
+		Context("when the disk to be deleted is the current disk of another instance", func() {
+			BeforeEach(func() {
+				instanceRepo := NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1, "10.0.0.2")
+				err := instanceRepo.UpdateCurrent(firstDisk.ID)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("clears the disk of that instance", func() {
+				err := repo.Delete(firstDisk)
+				Expect(err).ToNot(HaveOccurred())
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.Instances).To(Equal([]InstanceRecord{
+					{Name: "fake-job", Index: 1, IP: "10.0.0.2"},
+				}))
+			})
+		})
+	})
+
+	Describe("FindAllCurrent", func() {
+		It("returns the current disks of all instances", func() {
+			firstDisk, err := repo.Save("fake-cid-1", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = repo.Save("fake-cid-2", 2048, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			thirdDisk, err := repo.Save("fake-cid-3", 4096, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(firstDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			instanceRepo := NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1, "10.0.0.2")
+			err = instanceRepo.UpdateCurrent(thirdDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.FindAllCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{firstDisk, thirdDisk}))
+		})
 	})
 
 	Describe("ClearCurrent", func() {
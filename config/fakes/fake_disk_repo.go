@@ -78,6 +78,13 @@ func (r *FakeDiskRepo) FindCurrent() (biconfig.DiskRecord, bool, error) {
 	return r.findCurrentOutput.diskRecord, r.findCurrentOutput.found, r.findCurrentOutput.err
 }
 
+func (r *FakeDiskRepo) FindAllCurrent() ([]biconfig.DiskRecord, error) {
+	if !r.findCurrentOutput.found {
+		return []biconfig.DiskRecord{}, r.findCurrentOutput.err
+	}
+	return []biconfig.DiskRecord{r.findCurrentOutput.diskRecord}, r.findCurrentOutput.err
+}
+
 func (r *FakeDiskRepo) ClearCurrent() error {
 	return nil
 }
@@ -0,0 +1,56 @@
+package fakes
+
+import (
+	"errors"
+	"strconv"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+)
+
+type FakeStateBlobClient struct {
+	Blobs     map[string][]byte
+	Revisions map[string]int
+
+	PutErr error
+}
+
+func NewFakeStateBlobClient() *FakeStateBlobClient {
+	return &FakeStateBlobClient{
+		Blobs:     map[string][]byte{},
+		Revisions: map[string]int{},
+	}
+}
+
+func (c *FakeStateBlobClient) Get(key string) ([]byte, string, error) {
+	contents, found := c.Blobs[key]
+	if !found {
+		return nil, "", errors.New("fake-not-found-error")
+	}
+	return contents, strconv.Itoa(c.Revisions[key]), nil
+}
+
+func (c *FakeStateBlobClient) Put(key string, contents []byte, revision string) error {
+	if c.PutErr != nil {
+		return c.PutErr
+	}
+
+	_, found := c.Blobs[key]
+	if (revision == "" && found) || (revision != "" && revision != strconv.Itoa(c.Revisions[key])) {
+		return biconfig.ErrStateBlobPreconditionFailed
+	}
+
+	c.Blobs[key] = contents
+	c.Revisions[key]++
+	return nil
+}
+
+func (c *FakeStateBlobClient) Delete(key string) error {
+	delete(c.Blobs, key)
+	delete(c.Revisions, key)
+	return nil
+}
+
+func (c *FakeStateBlobClient) Exists(key string) (bool, error) {
+	_, found := c.Blobs[key]
+	return found, nil
+}
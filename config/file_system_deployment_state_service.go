@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -12,24 +13,36 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
-type fileSystemDeploymentStateService struct {
-	configPath    string
-	fs            boshsys.FileSystem
+type deploymentStateService struct {
+	backend       StateBackend
 	uuidGenerator boshuuid.Generator
 	logger        boshlog.Logger
 	logTag        string
+
+	// generation of the state that was last loaded or saved by this service
+	generation int
 }
 
-func NewFileSystemDeploymentStateService(fs boshsys.FileSystem, uuidGenerator boshuuid.Generator, logger boshlog.Logger, deploymentStatePath string) DeploymentStateService {
-	return &fileSystemDeploymentStateService{
-		configPath:    deploymentStatePath,
-		fs:            fs,
+// storedDeploymentState is the document kept by a StateBackend. Generation is
+// bumped on every save so that concurrent writers notice each other.
+type storedDeploymentState struct {
+	DeploymentState
+	Generation int `json:"generation,omitempty"`
+}
+
+func NewDeploymentStateService(backend StateBackend, uuidGenerator boshuuid.Generator, logger boshlog.Logger) DeploymentStateService {
+	return &deploymentStateService{
+		backend:       backend,
 		uuidGenerator: uuidGenerator,
 		logger:        logger,
 		logTag:        "config",
 	}
 }
 
+func NewFileSystemDeploymentStateService(fs boshsys.FileSystem, uuidGenerator boshuuid.Generator, logger boshlog.Logger, deploymentStatePath string) DeploymentStateService {
+	return NewDeploymentStateService(NewFileSystemStateBackend(fs, deploymentStatePath), uuidGenerator, logger)
+}
+
 func DeploymentStatePath(deploymentManifestPath string, deploymentStatePath string) string {
 	if deploymentStatePath != "" {
 		return deploymentStatePath
@@ -39,37 +52,38 @@ func DeploymentStatePath(deploymentManifestPath string, deploymentStatePath stri
 	return filepath.Join(filepath.Dir(deploymentManifestPath), fmt.Sprintf("%s-state.json", baseFileName))
 }
 
-func (s *fileSystemDeploymentStateService) Path() string {
-	return s.configPath
+func (s *deploymentStateService) Path() string {
+	return s.backend.Location()
 }
 
-func (s *fileSystemDeploymentStateService) Exists() bool {
-	return s.fs.FileExists(s.configPath)
+func (s *deploymentStateService) Exists() bool {
+	exists, err := s.backend.Exists()
+	if err != nil {
+		s.logger.Warn(s.logTag, "Checking if deployment state exists: %s", err.Error())
+		return false
+	}
+	return exists
 }
 
-func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
-	if s.configPath == "" {
+func (s *deploymentStateService) Load() (DeploymentState, error) {
+	if s.backend.Location() == "" {
 		panic("configPath not yet set!")
 	}
 
-	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.configPath)
+	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.backend.Location())
 
-	deploymentState := &DeploymentState{}
-
-	if s.fs.FileExists(s.configPath) {
-		deploymentStateFileContents, err := s.fs.ReadFile(s.configPath)
-		if err != nil {
-			return DeploymentState{}, bosherr.WrapErrorf(err, "Reading deployment state file '%s'", s.configPath)
-		}
-		s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
+	storedState, _, found, err := s.read()
+	if err != nil {
+		return DeploymentState{}, err
+	}
 
-		err = json.Unmarshal(deploymentStateFileContents, deploymentState)
-		if err != nil {
-			return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.configPath)
-		}
+	if found {
+		s.generation = storedState.Generation
 	}
 
-	err := s.initDefaults(deploymentState)
+	deploymentState := &storedState.DeploymentState
+
+	err = s.initDefaults(deploymentState)
 	if err != nil {
 		return DeploymentState{}, bosherr.WrapErrorf(err, "Initializing deployment state defaults")
 	}
@@ -77,27 +91,98 @@ func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
 	return *deploymentState, nil
 }
 
-func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState) error {
-	if s.configPath == "" {
+func (s *deploymentStateService) LoadReadOnly() (DeploymentState, error) {
+	if s.backend.Location() == "" {
+		panic("configPath not yet set!")
+	}
+
+	s.logger.Debug(s.logTag, "Loading deployment state read-only: %s", s.backend.Location())
+
+	storedState, _, _, err := s.read()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	return storedState.DeploymentState, nil
+}
+
+func (s *deploymentStateService) Save(deploymentState DeploymentState) error {
+	if s.backend.Location() == "" {
 		panic("configPath not yet set!")
 	}
 
 	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
 
-	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	storedState, revision, found, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if found && storedState.Generation != s.generation {
+		return bosherr.Errorf(
+			"Deployment state '%s' was modified by someone else (expected generation %d, found %d)",
+			s.backend.Location(), s.generation, storedState.Generation)
+	}
+
+	jsonContent, err := json.MarshalIndent(storedDeploymentState{
+		DeploymentState: deploymentState,
+		Generation:      s.generation + 1,
+	}, "", "    ")
 	if err != nil {
 		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
 	}
 
-	err = s.fs.WriteFile(s.configPath, jsonContent)
+	// Backend only writes if nobody saved the state since it was read above
+	err = s.backend.Write(jsonContent, revision)
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Writing deployment state file '%s'", s.configPath)
+		return err
 	}
 
+	s.generation++
+
 	return nil
 }
 
-func (s *fileSystemDeploymentStateService) initDefaults(deploymentState *DeploymentState) error {
+func (s *deploymentStateService) Lock() error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return s.backend.Lock(fmt.Sprintf("pid %d on %s", os.Getpid(), hostname))
+}
+
+func (s *deploymentStateService) Unlock() error {
+	return s.backend.Unlock()
+}
+
+func (s *deploymentStateService) read() (storedDeploymentState, string, bool, error) {
+	storedState := storedDeploymentState{}
+
+	exists, err := s.backend.Exists()
+	if err != nil {
+		return storedState, "", false, err
+	}
+
+	if !exists {
+		return storedState, "", false, nil
+	}
+
+	deploymentStateFileContents, revision, err := s.backend.Read()
+	if err != nil {
+		return storedState, "", false, err
+	}
+	s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
+
+	err = json.Unmarshal(deploymentStateFileContents, &storedState)
+	if err != nil {
+		return storedDeploymentState{}, "", false, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.backend.Location())
+	}
+
+	return storedState, revision, true, nil
+}
+
+func (s *deploymentStateService) initDefaults(deploymentState *DeploymentState) error {
 	if deploymentState.DirectorID == "" {
 		uuid, err := s.uuidGenerator.Generate()
 		if err != nil {
@@ -114,10 +199,10 @@ func (s *fileSystemDeploymentStateService) initDefaults(deploymentState *Deploym
 	return nil
 }
 
-func (s *fileSystemDeploymentStateService) Cleanup() error {
-	err := s.fs.RemoveAll(s.configPath)
+func (s *deploymentStateService) Cleanup() error {
+	err := s.backend.Delete()
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Could not delete deployment state file %s", s.configPath)
+		return bosherr.WrapErrorf(err, "Could not delete deployment state file %s", s.backend.Location())
 	}
 	return nil
 }
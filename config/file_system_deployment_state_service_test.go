@@ -192,11 +192,42 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 					},
 				},
 			}
-			expectedDeploymentStateFileContents, err := json.MarshalIndent(deploymentState, "", "    ")
+			expectedDeploymentStateFileContents, err := json.MarshalIndent(struct {
+				DeploymentState
+				Generation int `json:"generation"`
+			}{deploymentState, 1}, "", "    ")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(deploymentStateFileContents).To(Equal(string(expectedDeploymentStateFileContents)))
 		})
 
+		It("bumps the generation on every save", func() {
+			err := service.Save(DeploymentState{DirectorID: "deadbeef"})
+			Expect(err).NotTo(HaveOccurred())
+			err = service.Save(DeploymentState{DirectorID: "deadbeef"})
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentStateFileContents, err := fakeFs.ReadFileString(deploymentStatePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentStateFileContents).To(ContainSubstring(`"generation": 2`))
+		})
+
+		Context("when the deployment state was saved by someone else since it was loaded", func() {
+			It("returns an error", func() {
+				_, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+
+				otherService := NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, boshlog.NewLogger(boshlog.LevelNone), deploymentStatePath)
+				otherState, err := otherService.Load()
+				Expect(err).NotTo(HaveOccurred())
+				err = otherService.Save(otherState)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = service.Save(DeploymentState{DirectorID: "deadbeef"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Deployment state '/some/deployment.json' was modified by someone else (expected generation 1, found 2)"))
+			})
+		})
+
 		Context("when the deployment file cannot be written", func() {
 			BeforeEach(func() {
 				fakeFs.WriteFileError = errors.New("")
@@ -213,6 +244,35 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 		})
 	})
 
+	Describe("Lock", func() {
+		It("creates a lock file next to the deployment state", func() {
+			err := service.Lock()
+			Expect(err).NotTo(HaveOccurred())
+
+			owner, err := fakeFs.ReadFileString(deploymentStatePath + ".lock")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(HavePrefix("pid "))
+		})
+
+		It("returns an error when the deployment state is already locked", func() {
+			err := fakeFs.WriteFileString(deploymentStatePath+".lock", "pid 123 on other-host")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/some/deployment.json' is locked by 'pid 123 on other-host'. Remove '/some/deployment.json.lock' if the lock is stale"))
+		})
+
+		It("removes the lock file on unlock", func() {
+			err := service.Lock()
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeFs.FileExists(deploymentStatePath + ".lock")).To(BeFalse())
+		})
+	})
+
 	Describe("Cleanup", func() {
 		It("returns true if deployment state file deleted", func() {
 			err := fakeFs.WriteFileString(deploymentStatePath, "")
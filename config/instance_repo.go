@@ -0,0 +1,247 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+type InstanceRepo interface {
+	All() ([]InstanceRecord, error)
+	Delete(name string, index int) error
+}
+
+type instanceRepo struct {
+	deploymentStateService DeploymentStateService
+}
+
+func NewInstanceRepo(deploymentStateService DeploymentStateService) InstanceRepo {
+	return instanceRepo{
+		deploymentStateService: deploymentStateService,
+	}
+}
+
+func (r instanceRepo) All() ([]InstanceRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []InstanceRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Instances == nil {
+		return []InstanceRecord{}, nil
+	}
+
+	return deploymentState.Instances, nil
+}
+
+func (r instanceRepo) Delete(name string, index int) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	deploymentState.Instances = removeInstanceRecord(deploymentState.Instances, name, index)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+// instanceVMRepo is a VMRepo that tracks the current VM of a single instance
+// in the instances section of the deployment state.
+type instanceVMRepo struct {
+	deploymentStateService DeploymentStateService
+	name                   string
+	index                  int
+	ip                     string
+}
+
+func NewInstanceVMRepo(deploymentStateService DeploymentStateService, name string, index int, ip string) VMRepo {
+	return instanceVMRepo{
+		deploymentStateService: deploymentStateService,
+		name:                   name,
+		index:                  index,
+		ip:                     ip,
+	}
+}
+
+func (r instanceVMRepo) FindCurrent() (string, bool, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return "", false, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	record, found := findInstanceRecord(deploymentState.Instances, r.name, r.index)
+	if found && record.VMCID != "" {
+		return record.VMCID, true, nil
+	}
+
+	return "", false, nil
+}
+
+func (r instanceVMRepo) UpdateCurrent(cid string) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	record, _ := findInstanceRecord(deploymentState.Instances, r.name, r.index)
+	record.IP = r.ip
+	record.VMCID = cid
+	deploymentState.Instances = upsertInstanceRecord(deploymentState.Instances, record)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+func (r instanceVMRepo) ClearCurrent() error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	record, found := findInstanceRecord(deploymentState.Instances, r.name, r.index)
+	if !found {
+		return nil
+	}
+
+	// Keep the record around as long as it still owns a disk
+	if record.DiskID == "" {
+		deploymentState.Instances = removeInstanceRecord(deploymentState.Instances, r.name, r.index)
+	} else {
+		record.VMCID = ""
+		deploymentState.Instances = upsertInstanceRecord(deploymentState.Instances, record)
+	}
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+// instanceDiskRepo is a DiskRepo whose current disk is the disk of a single
+// instance. Disk records themselves are shared with all other instances.
+type instanceDiskRepo struct {
+	diskRepo
+	name  string
+	index int
+	ip    string
+}
+
+func NewInstanceDiskRepo(deploymentStateService DeploymentStateService, uuidGenerator boshuuid.Generator, name string, index int, ip string) DiskRepo {
+	return instanceDiskRepo{
+		diskRepo: diskRepo{
+			deploymentStateService: deploymentStateService,
+			uuidGenerator:          uuidGenerator,
+		},
+		name:  name,
+		index: index,
+		ip:    ip,
+	}
+}
+
+func (r instanceDiskRepo) FindCurrent() (DiskRecord, bool, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return DiskRecord{}, false, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	record, found := findInstanceRecord(deploymentState.Instances, r.name, r.index)
+	if !found || record.DiskID == "" {
+		return DiskRecord{}, false, nil
+	}
+
+	for _, oldRecord := range deploymentState.Disks {
+		if oldRecord.ID == record.DiskID {
+			return oldRecord, true, nil
+		}
+	}
+
+	return DiskRecord{}, false, nil
+}
+
+func (r instanceDiskRepo) UpdateCurrent(diskID string) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	found := false
+	for _, oldRecord := range deploymentState.Disks {
+		if oldRecord.ID == diskID {
+			found = true
+		}
+	}
+	if !found {
+		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
+	}
+
+	record, _ := findInstanceRecord(deploymentState.Instances, r.name, r.index)
+	record.IP = r.ip
+	record.DiskID = diskID
+	deploymentState.Instances = upsertInstanceRecord(deploymentState.Instances, record)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+func (r instanceDiskRepo) ClearCurrent() error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	record, found := findInstanceRecord(deploymentState.Instances, r.name, r.index)
+	if !found {
+		return nil
+	}
+
+	record.DiskID = ""
+	deploymentState.Instances = upsertInstanceRecord(deploymentState.Instances, record)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+func findInstanceRecord(records []InstanceRecord, name string, index int) (InstanceRecord, bool) {
+	for _, record := range records {
+		if record.Name == name && record.Index == index {
+			return record, true
+		}
+	}
+	return InstanceRecord{Name: name, Index: index}, false
+}
+
+func upsertInstanceRecord(records []InstanceRecord, newRecord InstanceRecord) []InstanceRecord {
+	for i, record := range records {
+		if record.Name == newRecord.Name && record.Index == newRecord.Index {
+			records[i] = newRecord
+			return records
+		}
+	}
+	return append(records, newRecord)
+}
+
+func removeInstanceRecord(records []InstanceRecord, name string, index int) []InstanceRecord {
+	newRecords := []InstanceRecord{}
+	for _, record := range records {
+		if record.Name != name || record.Index != index {
+			newRecords = append(newRecords, record)
+		}
+	}
+	if len(newRecords) == 0 {
+		return nil
+	}
+	return newRecords
+}
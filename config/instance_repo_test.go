@@ -0,0 +1,166 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/v7/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("InstanceRepo", func() {
+	var (
+		deploymentStateService DeploymentStateService
+		fakeUUIDGenerator      *fakeuuid.FakeGenerator
+		repo                   InstanceRepo
+		vmRepo                 VMRepo
+		diskRepo               DiskRepo
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
+		repo = NewInstanceRepo(deploymentStateService)
+		vmRepo = NewInstanceVMRepo(deploymentStateService, "fake-job", 1, "10.0.0.2")
+		diskRepo = NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1, "10.0.0.2")
+	})
+
+	Describe("All", func() {
+		It("returns no records when no instance has been recorded", func() {
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+
+		It("returns the recorded instances", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = NewInstanceVMRepo(deploymentStateService, "other-job", 0, "10.0.0.3").UpdateCurrent("fake-vm-cid-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]InstanceRecord{
+				{Name: "fake-job", Index: 1, IP: "10.0.0.2", VMCID: "fake-vm-cid-1"},
+				{Name: "other-job", Index: 0, IP: "10.0.0.3", VMCID: "fake-vm-cid-2"},
+			}))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the instance record", func() {
+			err := vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.Delete("fake-job", 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+	})
+
+	Describe("instance VM repo", func() {
+		It("does not touch the current vm of the first instance", func() {
+			err := NewVMRepo(deploymentStateService).UpdateCurrent("fake-bootstrap-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = vmRepo.UpdateCurrent("fake-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CurrentVMCID).To(Equal("fake-bootstrap-vm-cid"))
+
+			cid, found, err := vmRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(cid).To(Equal("fake-vm-cid"))
+		})
+
+		Context("when the instance has no disk", func() {
+			It("removes the instance record when clearing the current vm", func() {
+				err := vmRepo.UpdateCurrent("fake-vm-cid")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = vmRepo.ClearCurrent()
+				Expect(err).ToNot(HaveOccurred())
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+		})
+
+		Context("when the instance has a disk", func() {
+			It("keeps the instance record when clearing the current vm", func() {
+				err := vmRepo.UpdateCurrent("fake-vm-cid")
+				Expect(err).ToNot(HaveOccurred())
+
+				disk, err := diskRepo.Save("fake-disk-cid", 1024, biproperty.Map{})
+				Expect(err).ToNot(HaveOccurred())
+				err = diskRepo.UpdateCurrent(disk.ID)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = vmRepo.ClearCurrent()
+				Expect(err).ToNot(HaveOccurred())
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]InstanceRecord{
+					{Name: "fake-job", Index: 1, IP: "10.0.0.2", DiskID: disk.ID},
+				}))
+
+				_, found, err := vmRepo.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("instance disk repo", func() {
+		It("tracks the current disk of the instance", func() {
+			disk, err := diskRepo.Save("fake-disk-cid", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = diskRepo.UpdateCurrent(disk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			record, found, err := diskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record).To(Equal(disk))
+
+			_, found, err = NewDiskRepo(deploymentStateService, fakeUUIDGenerator).FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error when the disk record does not exist", func() {
+			err := diskRepo.UpdateCurrent("fake-unknown-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying disk record exists with id 'fake-unknown-id'"))
+		})
+
+		It("clears the current disk of the instance", func() {
+			disk, err := diskRepo.Save("fake-disk-cid", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			err = diskRepo.UpdateCurrent(disk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = diskRepo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := diskRepo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
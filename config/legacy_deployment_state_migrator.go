@@ -15,6 +15,8 @@ import (
 
 type LegacyDeploymentStateMigrator interface {
 	MigrateIfExists(configPath string) (migrated bool, err error)
+	// ReadIfExists converts the legacy state without saving it or deleting the legacy file.
+	ReadIfExists(configPath string) (deploymentState DeploymentState, found bool, err error)
 }
 
 type legacyDeploymentStateMigrator struct {
@@ -63,6 +65,19 @@ func (m *legacyDeploymentStateMigrator) MigrateIfExists(configPath string) (migr
 	return true, nil
 }
 
+func (m *legacyDeploymentStateMigrator) ReadIfExists(configPath string) (DeploymentState, bool, error) {
+	if !m.fs.FileExists(configPath) {
+		return DeploymentState{}, false, nil
+	}
+
+	deploymentState, err := m.migrate(configPath)
+	if err != nil {
+		return DeploymentState{}, false, err
+	}
+
+	return deploymentState, true, nil
+}
+
 func (m *legacyDeploymentStateMigrator) migrate(configPath string) (deploymentState DeploymentState, err error) {
 	m.logger.Info(m.logTag, "Migrating legacy bosh-deployments.yml")
 
@@ -124,7 +124,8 @@ registry_instances:
     "current_manifest_sha": "",
     "disks": \[\],
     "stemcells": \[\],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -177,7 +178,8 @@ registry_instances:
     "current_manifest_sha": "",
     "disks": \[\],
     "stemcells": \[\],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -230,7 +232,8 @@ registry_instances:
     "current_manifest_sha": "",
     "disks": \[\],
     "stemcells": \[\],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -297,7 +300,8 @@ registry_instances:
             "cid": "ami-f2503e9a light"
         }
     \],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -350,7 +354,8 @@ registry_instances:
     "current_manifest_sha": "",
     "disks": \[\],
     "stemcells": \[\],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -410,7 +415,8 @@ registry_instances:
         }
     \],
     "stemcells": \[\],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -463,7 +469,8 @@ registry_instances:
     "current_manifest_sha": "",
     "disks": \[\],
     "stemcells": \[\],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -523,7 +530,8 @@ registry_instances:
             "cid": "ami-f2503e9a light"
         }
     \],
-    "releases": \[\]
+    "releases": \[\],
+    "generation": 1
 }`))
 			})
 		})
@@ -7,6 +7,7 @@ package mocks
 import (
 	reflect "reflect"
 
+	config "github.com/cloudfoundry/bosh-cli/v7/config"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -47,3 +48,19 @@ func (mr *MockLegacyDeploymentStateMigratorMockRecorder) MigrateIfExists(arg0 in
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateIfExists", reflect.TypeOf((*MockLegacyDeploymentStateMigrator)(nil).MigrateIfExists), arg0)
 }
+
+// ReadIfExists mocks base method.
+func (m *MockLegacyDeploymentStateMigrator) ReadIfExists(arg0 string) (config.DeploymentState, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadIfExists", arg0)
+	ret0, _ := ret[0].(config.DeploymentState)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReadIfExists indicates an expected call of ReadIfExists.
+func (mr *MockLegacyDeploymentStateMigratorMockRecorder) ReadIfExists(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadIfExists", reflect.TypeOf((*MockLegacyDeploymentStateMigrator)(nil).ReadIfExists), arg0)
+}
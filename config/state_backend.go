@@ -0,0 +1,216 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// StateBackend stores the contents of a deployment state file and an advisory
+// lock that keeps two operators from changing the same environment at once.
+type StateBackend interface {
+	Location() string
+	Exists() (bool, error)
+
+	// Read returns the contents together with an opaque revision of them.
+	Read() (contents []byte, revision string, err error)
+
+	// Write only replaces the state when it is still at the given revision;
+	// an empty revision expects the state to not exist yet. Otherwise it
+	// returns a StateModifiedError.
+	Write(contents []byte, revision string) error
+
+	Delete() error
+
+	// Lock fails when the state is already locked, naming the current owner.
+	Lock(owner string) error
+	Unlock() error
+}
+
+// StateModifiedError is returned when the state was changed by someone else
+// after it was read.
+type StateModifiedError struct {
+	Location string
+}
+
+func (e StateModifiedError) Error() string {
+	return fmt.Sprintf("Deployment state '%s' was modified by someone else", e.Location)
+}
+
+// NewStateBackend selects a backend from the scheme of the state location:
+// s3://bucket/key, gs://bucket/key (or gcs://) or a local file path.
+func NewStateBackend(fs boshsys.FileSystem, location string) StateBackend {
+	parsedURL, err := url.Parse(location)
+	if err != nil || parsedURL.Host == "" {
+		return NewFileSystemStateBackend(fs, location)
+	}
+
+	key := strings.TrimPrefix(parsedURL.Path, "/")
+
+	switch parsedURL.Scheme {
+	case "s3":
+		return NewBlobStateBackend(location, key, newS3StateBlobClient(s3StateOptions(parsedURL)))
+	case "gs", "gcs":
+		options, jsonKeyPath, err := gcsStateOptions(parsedURL)
+		return NewBlobStateBackend(location, key, newGCSStateBlobClient(fs, options, jsonKeyPath, err))
+	default:
+		return NewFileSystemStateBackend(fs, location)
+	}
+}
+
+func s3StateOptions(parsedURL *url.URL) map[string]interface{} {
+	options := map[string]interface{}{
+		"bucket_name":        parsedURL.Host,
+		"credentials_source": "env_or_profile",
+	}
+
+	query := parsedURL.Query()
+	for _, name := range []string{"region", "host", "credentials_source", "server_side_encryption", "sse_kms_key_id", "signature_version"} {
+		if value := query.Get(name); value != "" {
+			options[name] = value
+		}
+	}
+
+	for _, name := range []string{"use_ssl", "ssl_verify_peer", "host_style"} {
+		if value, err := strconv.ParseBool(query.Get(name)); err == nil {
+			options[name] = value
+		}
+	}
+
+	if port, err := strconv.Atoi(query.Get("port")); err == nil {
+		options["port"] = port
+	}
+
+	return options
+}
+
+// gcsStateOptions takes credentials from a key file, or otherwise from
+// application default credentials, e.g. GOOGLE_APPLICATION_CREDENTIALS,
+// rather than from the URL which may be shown in process listings and logs.
+func gcsStateOptions(parsedURL *url.URL) (map[string]interface{}, string, error) {
+	options := map[string]interface{}{
+		"bucket_name": parsedURL.Host,
+	}
+
+	query := parsedURL.Query()
+
+	if query.Has("json_key") {
+		return nil, "", bosherr.Error("Expected GCS service account key to be given with 'json_key_path' instead of 'json_key' in state URL")
+	}
+
+	for _, name := range []string{"credentials_source", "storage_class"} {
+		if value := query.Get(name); value != "" {
+			options[name] = value
+		}
+	}
+
+	return options, query.Get("json_key_path"), nil
+}
+
+type fileSystemStateBackend struct {
+	fs   boshsys.FileSystem
+	path string
+}
+
+func NewFileSystemStateBackend(fs boshsys.FileSystem, path string) StateBackend {
+	return fileSystemStateBackend{fs: fs, path: path}
+}
+
+func (b fileSystemStateBackend) Location() string {
+	return b.path
+}
+
+func (b fileSystemStateBackend) Exists() (bool, error) {
+	return b.fs.FileExists(b.path), nil
+}
+
+func (b fileSystemStateBackend) Read() ([]byte, string, error) {
+	contents, err := b.fs.ReadFile(b.path)
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Reading deployment state file '%s'", b.path)
+	}
+	return contents, b.revision(contents), nil
+}
+
+func (b fileSystemStateBackend) Write(contents []byte, revision string) error {
+	// Writers of a local file are already serialized by the atomic lock file
+	currentRevision := ""
+	if b.fs.FileExists(b.path) {
+		currentContents, err := b.fs.ReadFile(b.path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading deployment state file '%s'", b.path)
+		}
+		currentRevision = b.revision(currentContents)
+	}
+
+	if currentRevision != revision {
+		return StateModifiedError{Location: b.path}
+	}
+
+	err := b.fs.WriteFile(b.path, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state file '%s'", b.path)
+	}
+	return nil
+}
+
+func (b fileSystemStateBackend) Delete() error {
+	return b.fs.RemoveAll(b.path)
+}
+
+func (b fileSystemStateBackend) Lock(owner string) error {
+	lockPath := b.lockPath()
+
+	if b.fs.FileExists(lockPath) {
+		return b.lockedError(lockPath)
+	}
+
+	// O_EXCL makes acquiring the lock atomic on real file systems
+	file, err := b.fs.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return b.lockedError(lockPath)
+		}
+		return bosherr.WrapErrorf(err, "Creating deployment state lock '%s'", lockPath)
+	}
+	defer file.Close()
+
+	_, err = file.Write([]byte(owner))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state lock '%s'", lockPath)
+	}
+
+	return nil
+}
+
+func (b fileSystemStateBackend) Unlock() error {
+	err := b.fs.RemoveAll(b.lockPath())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing deployment state lock '%s'", b.lockPath())
+	}
+	return nil
+}
+
+func (b fileSystemStateBackend) revision(contents []byte) string {
+	digest := sha256.Sum256(contents)
+	return hex.EncodeToString(digest[:])
+}
+
+func (b fileSystemStateBackend) lockPath() string {
+	return b.path + ".lock"
+}
+
+func (b fileSystemStateBackend) lockedError(lockPath string) error {
+	owner, err := b.fs.ReadFileString(lockPath)
+	if err != nil {
+		owner = "unknown"
+	}
+	return bosherr.Errorf("Deployment state '%s' is locked by '%s'. Remove '%s' if the lock is stale", b.path, owner, lockPath)
+}
@@ -0,0 +1,197 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/v7/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/v7/config/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("StateBackend", func() {
+	Describe("NewStateBackend", func() {
+		var fs *fakesys.FakeFileSystem
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+		})
+
+		It("uses the local file system for paths", func() {
+			backend := NewStateBackend(fs, "/path/to/state.json")
+			Expect(backend.Location()).To(Equal("/path/to/state.json"))
+
+			err := backend.Write([]byte("fake-contents"), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.ReadFileString("/path/to/state.json")).To(Equal("fake-contents"))
+		})
+
+		It("only overwrites a local file at the revision it was read at", func() {
+			backend := NewStateBackend(fs, "/path/to/state.json")
+
+			err := backend.Write([]byte("fake-contents"), "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Write([]byte("other-contents"), "")
+			Expect(err).To(Equal(StateModifiedError{Location: "/path/to/state.json"}))
+
+			_, revision, err := backend.Read()
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Write([]byte("new-contents"), revision)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Write([]byte("other-contents"), revision)
+			Expect(err).To(Equal(StateModifiedError{Location: "/path/to/state.json"}))
+			Expect(fs.ReadFileString("/path/to/state.json")).To(Equal("new-contents"))
+		})
+
+		It("uses a remote backend for s3 and gcs urls", func() {
+			Expect(NewStateBackend(fs, "s3://fake-bucket/path/state.json").Location()).To(Equal("s3://fake-bucket/path/state.json"))
+			Expect(NewStateBackend(fs, "gs://fake-bucket/path/state.json").Location()).To(Equal("gs://fake-bucket/path/state.json"))
+		})
+
+		It("does not take gcs credentials from the url", func() {
+			backend := NewStateBackend(fs, "gs://fake-bucket/path/state.json?json_key=fake-key")
+
+			_, err := backend.Exists()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected GCS service account key to be given with 'json_key_path'"))
+		})
+
+		It("reads gcs credentials from the key file", func() {
+			backend := NewStateBackend(fs, "gs://fake-bucket/path/state.json?json_key_path=/missing-key.json")
+
+			_, err := backend.Exists()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading service account key '/missing-key.json'"))
+		})
+	})
+
+	Describe("blob backend", func() {
+		var (
+			client  *fakebiconfig.FakeStateBlobClient
+			backend StateBackend
+		)
+
+		BeforeEach(func() {
+			client = fakebiconfig.NewFakeStateBlobClient()
+			backend = NewBlobStateBackend("s3://fake-bucket/path/state.json", "path/state.json", client)
+		})
+
+		It("stores the state in the object named by the key", func() {
+			exists, err := backend.Exists()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			err = backend.Write([]byte("fake-contents"), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Blobs["path/state.json"]).To(Equal([]byte("fake-contents")))
+
+			contents, revision, err := backend.Read()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(Equal([]byte("fake-contents")))
+			Expect(revision).To(Equal("1"))
+
+			err = backend.Delete()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Blobs).To(BeEmpty())
+		})
+
+		It("wraps write errors with the location", func() {
+			client.PutErr = errors.New("fake-put-error")
+
+			err := backend.Write([]byte("fake-contents"), "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Writing deployment state 's3://fake-bucket/path/state.json': fake-put-error"))
+		})
+
+		It("returns an error when the object changed since it was read", func() {
+			err := backend.Write([]byte("fake-contents"), "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Write([]byte("other-contents"), "")
+			Expect(err).To(Equal(StateModifiedError{Location: "s3://fake-bucket/path/state.json"}))
+
+			err = backend.Write([]byte("new-contents"), "1")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Write([]byte("other-contents"), "1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state 's3://fake-bucket/path/state.json' was modified by someone else"))
+			Expect(client.Blobs["path/state.json"]).To(Equal([]byte("new-contents")))
+		})
+
+		It("locks the state with an object next to it", func() {
+			err := backend.Lock("fake-owner")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Blobs["path/state.json.lock"]).To(Equal([]byte("fake-owner")))
+
+			err = backend.Lock("other-owner")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is locked by 'fake-owner'"))
+
+			err = backend.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Blobs).To(BeEmpty())
+		})
+
+		It("creates the lock with a conditional write so that racing operators cannot both acquire it", func() {
+			client.PutErr = ErrStateBlobPreconditionFailed
+
+			err := backend.Lock("fake-owner")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is locked by 'unknown'"))
+		})
+
+		It("can back a deployment state service", func() {
+			service := NewDeploymentStateService(backend, fakeuuid.NewFakeGenerator(), boshlog.NewLogger(boshlog.LevelNone))
+
+			deploymentState, err := service.Load()
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState.CurrentVMCID = "fake-vm-cid"
+			err = service.Save(deploymentState)
+			Expect(err).NotTo(HaveOccurred())
+
+			reloadedState, err := NewDeploymentStateService(backend, fakeuuid.NewFakeGenerator(), boshlog.NewLogger(boshlog.LevelNone)).Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reloadedState).To(Equal(deploymentState))
+		})
+
+		It("does not overwrite state saved by someone else between reading and writing it", func() {
+			racingBackend := &racingStateBackend{StateBackend: backend}
+			service := NewDeploymentStateService(racingBackend, fakeuuid.NewFakeGenerator(), boshlog.NewLogger(boshlog.LevelNone))
+
+			deploymentState, err := service.Load()
+			Expect(err).NotTo(HaveOccurred())
+
+			racingBackend.race = func() {
+				client.Blobs["path/state.json"] = []byte(`{"director_id":"other-director"}`)
+				client.Revisions["path/state.json"]++
+			}
+
+			err = service.Save(deploymentState)
+			Expect(err).To(Equal(StateModifiedError{Location: "s3://fake-bucket/path/state.json"}))
+			Expect(string(client.Blobs["path/state.json"])).To(ContainSubstring("other-director"))
+		})
+	})
+})
+
+// racingStateBackend lets another writer change the state right after it was read.
+type racingStateBackend struct {
+	StateBackend
+	race func()
+}
+
+func (b *racingStateBackend) Read() ([]byte, string, error) {
+	contents, revision, err := b.StateBackend.Read()
+	if b.race != nil {
+		b.race()
+	}
+	return contents, revision, err
+}
@@ -0,0 +1,192 @@
+package fake
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	"github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type agentState struct {
+	JobState    string               `json:"job_state"`
+	ApplySpec   *applyspec.ApplySpec `json:"apply_spec,omitempty"`
+	Disks       []string             `json:"disks,omitempty"`
+	MountedDisk []string             `json:"mounted_disks,omitempty"`
+}
+
+type agentClient struct {
+	fs     boshsys.FileSystem
+	path   string
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewAgentClient returns an AgentClient that answers every request from a
+// state file at path. It stands in for the agent on a VM of the fake CPI.
+func NewAgentClient(fs boshsys.FileSystem, path string, logger boshlog.Logger) biagentclient.AgentClient {
+	return agentClient{
+		fs:     fs,
+		path:   path,
+		logger: logger,
+		logTag: "fakeAgentClient",
+	}
+}
+
+func (c agentClient) Ping() (string, error) {
+	return "pong", nil
+}
+
+func (c agentClient) Stop() error {
+	return c.update(func(state *agentState) { state.JobState = "stopped" })
+}
+
+func (c agentClient) Drain(string) (int64, error) {
+	return 0, nil
+}
+
+func (c agentClient) Apply(spec applyspec.ApplySpec) error {
+	return c.update(func(state *agentState) { state.ApplySpec = &spec })
+}
+
+func (c agentClient) Start() error {
+	return c.update(func(state *agentState) { state.JobState = "running" })
+}
+
+func (c agentClient) GetState() (biagentclient.AgentState, error) {
+	state, err := c.read()
+	if err != nil {
+		return biagentclient.AgentState{}, err
+	}
+
+	agentState := biagentclient.AgentState{
+		JobState:     state.JobState,
+		NetworkSpecs: map[string]biagentclient.NetworkSpec{},
+	}
+
+	if state.ApplySpec != nil {
+		for name, network := range state.ApplySpec.Networks {
+			var ip string
+			if networkMap, ok := network.(map[string]interface{}); ok {
+				ip, _ = networkMap["ip"].(string)
+			}
+			agentState.NetworkSpecs[name] = biagentclient.NetworkSpec{IP: ip}
+		}
+	}
+
+	return agentState, nil
+}
+
+func (c agentClient) AddPersistentDisk(diskCID string, _ interface{}) error {
+	return c.update(func(state *agentState) {
+		if indexOf(state.Disks, diskCID) == -1 {
+			state.Disks = append(state.Disks, diskCID)
+		}
+	})
+}
+
+func (c agentClient) RemovePersistentDisk(diskCID string) error {
+	return c.update(func(state *agentState) {
+		state.Disks = without(state.Disks, diskCID)
+		state.MountedDisk = without(state.MountedDisk, diskCID)
+	})
+}
+
+func (c agentClient) MountDisk(diskCID string) error {
+	return c.update(func(state *agentState) {
+		if indexOf(state.MountedDisk, diskCID) == -1 {
+			state.MountedDisk = append(state.MountedDisk, diskCID)
+		}
+	})
+}
+
+func (c agentClient) UnmountDisk(diskCID string) error {
+	return c.update(func(state *agentState) { state.MountedDisk = without(state.MountedDisk, diskCID) })
+}
+
+func (c agentClient) ListDisk() ([]string, error) {
+	state, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{}, state.MountedDisk...), nil
+}
+
+func (c agentClient) MigrateDisk() error {
+	return nil
+}
+
+func (c agentClient) CompilePackage(packageSource biagentclient.BlobRef, _ []biagentclient.BlobRef) (biagentclient.BlobRef, error) {
+	return packageSource, nil
+}
+
+func (c agentClient) DeleteARPEntries([]string) error {
+	return nil
+}
+
+func (c agentClient) SyncDNS(string, string, uint64) (string, error) {
+	return "synced", nil
+}
+
+func (c agentClient) RunScript(scriptName string, _ map[string]interface{}) error {
+	c.logger.Debug(c.logTag, "Skipping script '%s'", scriptName)
+	return nil
+}
+
+func (c agentClient) update(change func(*agentState)) error {
+	state, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	change(&state)
+
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling fake agent state")
+	}
+
+	err = c.fs.WriteFile(c.path, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing fake agent state '%s'", c.path)
+	}
+
+	return nil
+}
+
+func (c agentClient) read() (agentState, error) {
+	state := agentState{}
+	if !c.fs.FileExists(c.path) {
+		return state, nil
+	}
+
+	contents, err := c.fs.ReadFile(c.path)
+	if err != nil {
+		return state, bosherr.WrapErrorf(err, "Reading fake agent state '%s'", c.path)
+	}
+
+	err = json.Unmarshal(contents, &state)
+	if err != nil {
+		return state, bosherr.WrapErrorf(err, "Unmarshalling fake agent state '%s'", c.path)
+	}
+
+	return state, nil
+}
+
+func agentStatePath(dir, host string) string {
+	return filepath.Join(dir, "agents", host+".json")
+}
+
+func without(values []string, value string) []string {
+	result := []string{}
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
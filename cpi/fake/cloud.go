@@ -0,0 +1,380 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
+)
+
+type stemcellRecord struct {
+	ImagePath       string         `json:"image_path"`
+	CloudProperties biproperty.Map `json:"cloud_properties"`
+}
+
+type vmRecord struct {
+	AgentID         string                    `json:"agent_id"`
+	StemcellCID     string                    `json:"stemcell_cid"`
+	CloudProperties biproperty.Map            `json:"cloud_properties"`
+	Networks        map[string]biproperty.Map `json:"networks"`
+	Env             biproperty.Map            `json:"env"`
+	Metadata        bicloud.VMMetadata        `json:"metadata,omitempty"`
+	DiskCIDs        []string                  `json:"disk_cids,omitempty"`
+}
+
+type diskRecord struct {
+	Size            int                  `json:"size"`
+	CloudProperties biproperty.Map       `json:"cloud_properties"`
+	VMCID           string               `json:"vm_cid,omitempty"`
+	Metadata        bicloud.DiskMetadata `json:"metadata,omitempty"`
+}
+
+type cloud struct {
+	fs      boshsys.FileSystem
+	uuidGen boshuuid.Generator
+	dir     string
+	logger  boshlog.Logger
+	logTag  string
+}
+
+// NewCloud returns a Cloud that keeps stemcells, VMs and disks as JSON
+// records under dir instead of calling an external CPI. Creating or deleting
+// a VM resets the fake agent listening on each of the VM's IPs.
+func NewCloud(fs boshsys.FileSystem, uuidGen boshuuid.Generator, dir string, logger boshlog.Logger) bicloud.Cloud {
+	return cloud{
+		fs:      fs,
+		uuidGen: uuidGen,
+		dir:     dir,
+		logger:  logger,
+		logTag:  "fakeCloud",
+	}
+}
+
+func (c cloud) Info() (bicloud.CpiInfo, error) {
+	return bicloud.CpiInfo{StemcellFormats: []string{}, ApiVersion: 2}, nil
+}
+
+func (c cloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
+	cid, err := c.newCID("stemcell")
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug(c.logTag, "Creating stemcell '%s' from '%s'", cid, imagePath)
+
+	err = c.write(c.stemcellPath(cid), stemcellRecord{ImagePath: imagePath, CloudProperties: cloudProperties})
+	if err != nil {
+		return "", err
+	}
+
+	return cid, nil
+}
+
+func (c cloud) DeleteStemcell(stemcellCID string) error {
+	if !c.fs.FileExists(c.stemcellPath(stemcellCID)) {
+		return notFoundError("delete_stemcell", bicloud.StemcellNotFoundError, "Stemcell", stemcellCID)
+	}
+
+	return c.remove(c.stemcellPath(stemcellCID))
+}
+
+func (c cloud) HasVM(vmCID string) (bool, error) {
+	return c.fs.FileExists(c.vmPath(vmCID)), nil
+}
+
+func (c cloud) CreateVM(agentID string, stemcellCID string, cloudProperties biproperty.Map, networksInterfaces map[string]biproperty.Map, env biproperty.Map) (string, error) {
+	if !c.fs.FileExists(c.stemcellPath(stemcellCID)) {
+		return "", bosherr.Errorf("Creating vm: Stemcell '%s' not found", stemcellCID)
+	}
+
+	cid, err := c.newCID("vm")
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug(c.logTag, "Creating vm '%s' for agent '%s'", cid, agentID)
+
+	record := vmRecord{
+		AgentID:         agentID,
+		StemcellCID:     stemcellCID,
+		CloudProperties: cloudProperties,
+		Networks:        networksInterfaces,
+		Env:             env,
+	}
+
+	err = c.write(c.vmPath(cid), record)
+	if err != nil {
+		return "", err
+	}
+
+	err = c.resetAgents(record)
+	if err != nil {
+		return "", err
+	}
+
+	return cid, nil
+}
+
+func (c cloud) SetVMMetadata(vmCID string, metadata bicloud.VMMetadata) error {
+	var record vmRecord
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return notFoundError("set_vm_metadata", bicloud.VMNotFoundError, "VM", vmCID)
+	}
+
+	err := c.read(c.vmPath(vmCID), &record)
+	if err != nil {
+		return err
+	}
+
+	record.Metadata = metadata
+
+	return c.write(c.vmPath(vmCID), record)
+}
+
+func (c cloud) SetDiskMetadata(diskCID string, metadata bicloud.DiskMetadata) error {
+	var record diskRecord
+	if !c.fs.FileExists(c.diskPath(diskCID)) {
+		return notFoundError("set_disk_metadata", bicloud.DiskNotFoundError, "Disk", diskCID)
+	}
+
+	err := c.read(c.diskPath(diskCID), &record)
+	if err != nil {
+		return err
+	}
+
+	record.Metadata = metadata
+
+	return c.write(c.diskPath(diskCID), record)
+}
+
+func (c cloud) DeleteVM(vmCID string) error {
+	var record vmRecord
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return notFoundError("delete_vm", bicloud.VMNotFoundError, "VM", vmCID)
+	}
+
+	err := c.read(c.vmPath(vmCID), &record)
+	if err != nil {
+		return err
+	}
+
+	for _, diskCID := range record.DiskCIDs {
+		err = c.updateDisk(diskCID, func(disk *diskRecord) { disk.VMCID = "" })
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.resetAgents(record)
+	if err != nil {
+		return err
+	}
+
+	return c.remove(c.vmPath(vmCID))
+}
+
+func (c cloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (string, error) {
+	cid, err := c.newCID("disk")
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug(c.logTag, "Creating disk '%s' of size %d", cid, size)
+
+	err = c.write(c.diskPath(cid), diskRecord{Size: size, CloudProperties: cloudProperties})
+	if err != nil {
+		return "", err
+	}
+
+	return cid, nil
+}
+
+func (c cloud) AttachDisk(vmCID, diskCID string) (interface{}, error) {
+	var vm vmRecord
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return nil, notFoundError("attach_disk", bicloud.VMNotFoundError, "VM", vmCID)
+	}
+
+	if !c.fs.FileExists(c.diskPath(diskCID)) {
+		return nil, notFoundError("attach_disk", bicloud.DiskNotFoundError, "Disk", diskCID)
+	}
+
+	err := c.read(c.vmPath(vmCID), &vm)
+	if err != nil {
+		return nil, err
+	}
+
+	index := indexOf(vm.DiskCIDs, diskCID)
+	if index == -1 {
+		vm.DiskCIDs = append(vm.DiskCIDs, diskCID)
+		index = len(vm.DiskCIDs) - 1
+
+		err = c.write(c.vmPath(vmCID), vm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.updateDisk(diskCID, func(disk *diskRecord) { disk.VMCID = vmCID })
+	if err != nil {
+		return nil, err
+	}
+
+	return fmt.Sprintf("/dev/sd%c", 'b'+index), nil
+}
+
+func (c cloud) DetachDisk(vmCID, diskCID string) error {
+	var vm vmRecord
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return notFoundError("detach_disk", bicloud.VMNotFoundError, "VM", vmCID)
+	}
+
+	if !c.fs.FileExists(c.diskPath(diskCID)) {
+		return notFoundError("detach_disk", bicloud.DiskNotFoundError, "Disk", diskCID)
+	}
+
+	err := c.read(c.vmPath(vmCID), &vm)
+	if err != nil {
+		return err
+	}
+
+	if index := indexOf(vm.DiskCIDs, diskCID); index != -1 {
+		vm.DiskCIDs = append(vm.DiskCIDs[:index], vm.DiskCIDs[index+1:]...)
+
+		err = c.write(c.vmPath(vmCID), vm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.updateDisk(diskCID, func(disk *diskRecord) { disk.VMCID = "" })
+}
+
+func (c cloud) DeleteDisk(diskCID string) error {
+	var record diskRecord
+	if !c.fs.FileExists(c.diskPath(diskCID)) {
+		return notFoundError("delete_disk", bicloud.DiskNotFoundError, "Disk", diskCID)
+	}
+
+	err := c.read(c.diskPath(diskCID), &record)
+	if err != nil {
+		return err
+	}
+
+	if record.VMCID != "" {
+		return bosherr.Errorf("Deleting disk: Disk '%s' is still attached to VM '%s'", diskCID, record.VMCID)
+	}
+
+	return c.remove(c.diskPath(diskCID))
+}
+
+func (c cloud) String() string {
+	return fmt.Sprintf("FakeCloud{Dir=%s}", c.dir)
+}
+
+func (c cloud) updateDisk(diskCID string, update func(*diskRecord)) error {
+	var record diskRecord
+	err := c.read(c.diskPath(diskCID), &record)
+	if err != nil {
+		return err
+	}
+
+	update(&record)
+
+	return c.write(c.diskPath(diskCID), record)
+}
+
+func (c cloud) resetAgents(record vmRecord) error {
+	for _, network := range record.Networks {
+		ip, ok := network["ip"].(string)
+		if !ok || ip == "" {
+			continue
+		}
+
+		err := c.remove(agentStatePath(c.dir, ip))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c cloud) newCID(kind string) (string, error) {
+	id, err := c.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Generating %s cid", kind)
+	}
+	return fmt.Sprintf("%s-%s", kind, id), nil
+}
+
+func (c cloud) read(path string, record interface{}) error {
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading fake CPI record '%s'", path)
+	}
+
+	err = json.Unmarshal(contents, record)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling fake CPI record '%s'", path)
+	}
+
+	return nil
+}
+
+func (c cloud) write(path string, record interface{}) error {
+	contents, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling fake CPI record '%s'", path)
+	}
+
+	err = c.fs.WriteFile(path, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing fake CPI record '%s'", path)
+	}
+
+	return nil
+}
+
+func (c cloud) remove(path string) error {
+	err := c.fs.RemoveAll(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing fake CPI record '%s'", path)
+	}
+	return nil
+}
+
+func (c cloud) stemcellPath(cid string) string {
+	return filepath.Join(c.dir, "stemcells", cid+".json")
+}
+
+func (c cloud) vmPath(cid string) string {
+	return filepath.Join(c.dir, "vms", cid+".json")
+}
+
+func (c cloud) diskPath(cid string) string {
+	return filepath.Join(c.dir, "disks", cid+".json")
+}
+
+func notFoundError(method, errorType, kind, cid string) error {
+	return bicloud.NewCPIError(method, bicloud.CmdError{
+		Type:    errorType,
+		Message: fmt.Sprintf("%s '%s' not found", kind, cid),
+	})
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,118 @@
+package fake_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/cpi/fake"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
+)
+
+var _ = Describe("Cloud", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		cloud bicloud.Cloud
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		cloud = NewCloud(fs, fakeuuid.NewFakeGenerator(), "/fake-cpi-dir", logger)
+	})
+
+	createVM := func() (string, string) {
+		stemcellCID, err := cloud.CreateStemcell("/fake-image-path", biproperty.Map{})
+		Expect(err).ToNot(HaveOccurred())
+
+		vmCID, err := cloud.CreateVM("fake-agent-id", stemcellCID, biproperty.Map{}, map[string]biproperty.Map{
+			"fake-network-name": {"ip": "10.0.0.6"},
+		}, biproperty.Map{})
+		Expect(err).ToNot(HaveOccurred())
+
+		return stemcellCID, vmCID
+	}
+
+	It("reports CPI api version 2", func() {
+		info, err := cloud.Info()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.ApiVersion).To(Equal(2))
+	})
+
+	It("records stemcells, vms and disks in the fake CPI directory", func() {
+		stemcellCID, vmCID := createVM()
+		Expect(stemcellCID).To(Equal("stemcell-fake-uuid-0"))
+		Expect(vmCID).To(Equal("vm-fake-uuid-1"))
+		Expect(fs.FileExists("/fake-cpi-dir/stemcells/stemcell-fake-uuid-0.json")).To(BeTrue())
+		Expect(fs.FileExists("/fake-cpi-dir/vms/vm-fake-uuid-1.json")).To(BeTrue())
+
+		hasVM, err := cloud.HasVM(vmCID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hasVM).To(BeTrue())
+
+		diskCID, err := cloud.CreateDisk(1024, biproperty.Map{}, vmCID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists("/fake-cpi-dir/disks/" + diskCID + ".json")).To(BeTrue())
+	})
+
+	It("fails to create a vm from an unknown stemcell", func() {
+		_, err := cloud.CreateVM("fake-agent-id", "unknown-stemcell-cid", biproperty.Map{}, map[string]biproperty.Map{}, biproperty.Map{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Stemcell 'unknown-stemcell-cid' not found"))
+	})
+
+	It("resets the fake agents of a vm when the vm is created and deleted", func() {
+		err := fs.WriteFileString("/fake-cpi-dir/agents/10.0.0.6.json", "{}")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, vmCID := createVM()
+		Expect(fs.FileExists("/fake-cpi-dir/agents/10.0.0.6.json")).To(BeFalse())
+
+		err = fs.WriteFileString("/fake-cpi-dir/agents/10.0.0.6.json", "{}")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = cloud.DeleteVM(vmCID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists("/fake-cpi-dir/agents/10.0.0.6.json")).To(BeFalse())
+		Expect(fs.FileExists("/fake-cpi-dir/vms/" + vmCID + ".json")).To(BeFalse())
+	})
+
+	It("attaches and detaches disks", func() {
+		_, vmCID := createVM()
+		diskCID, err := cloud.CreateDisk(1024, biproperty.Map{}, vmCID)
+		Expect(err).ToNot(HaveOccurred())
+
+		hint, err := cloud.AttachDisk(vmCID, diskCID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hint).To(Equal("/dev/sdb"))
+
+		err = cloud.DeleteDisk(diskCID)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("still attached"))
+
+		err = cloud.DetachDisk(vmCID, diskCID)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = cloud.DeleteDisk(diskCID)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns not found CPI errors for unknown cids", func() {
+		err := cloud.DeleteVM("unknown-vm-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bicloud.Error).Type()).To(Equal(bicloud.VMNotFoundError))
+
+		err = cloud.DeleteDisk("unknown-disk-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bicloud.Error).Type()).To(Equal(bicloud.DiskNotFoundError))
+
+		err = cloud.DeleteStemcell("unknown-stemcell-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(bicloud.Error).Type()).To(Equal(bicloud.StemcellNotFoundError))
+	})
+})
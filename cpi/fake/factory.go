@@ -0,0 +1,161 @@
+package fake
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
+	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
+	biinstall "github.com/cloudfoundry/bosh-cli/v7/installation"
+)
+
+// MbusScheme is the scheme of the mbus URL the installation manifest parser
+// generates for the fake CPI. Its path is the fake CPI directory and its host
+// identifies the agent.
+const MbusScheme = "fake"
+
+type cloudFactory struct {
+	delegate bicloud.Factory
+	fs       boshsys.FileSystem
+	uuidGen  boshuuid.Generator
+	logger   boshlog.Logger
+}
+
+// NewCloudFactory returns a cloud Factory that creates a fake cloud for
+// installations whose manifest selects the fake CPI. Other installations get
+// a cloud from delegate.
+func NewCloudFactory(delegate bicloud.Factory, fs boshsys.FileSystem, uuidGen boshuuid.Generator, logger boshlog.Logger) bicloud.Factory {
+	return cloudFactory{
+		delegate: delegate,
+		fs:       fs,
+		uuidGen:  uuidGen,
+		logger:   logger,
+	}
+}
+
+func (f cloudFactory) NewCloud(installation biinstall.Installation, directorID string, stemcellApiVersion int) (bicloud.Cloud, error) {
+	manifest := installation.Manifest()
+	if !manifest.UsesFakeCPI() {
+		return f.delegate.NewCloud(installation, directorID, stemcellApiVersion)
+	}
+
+	return NewCloud(f.fs, f.uuidGen, manifest.FakeCPI.Dir, f.logger), nil
+}
+
+type agentClientFactory struct {
+	delegate bihttpagent.AgentClientFactory
+	fs       boshsys.FileSystem
+	logger   boshlog.Logger
+}
+
+// NewAgentClientFactory returns an AgentClientFactory that creates fake agent
+// clients for fake mbus URLs. Other URLs get a client from delegate.
+func NewAgentClientFactory(delegate bihttpagent.AgentClientFactory, fs boshsys.FileSystem, logger boshlog.Logger) bihttpagent.AgentClientFactory {
+	return agentClientFactory{
+		delegate: delegate,
+		fs:       fs,
+		logger:   logger,
+	}
+}
+
+func (f agentClientFactory) NewAgentClient(directorID, mbusURL, caCert string) (biagentclient.AgentClient, error) {
+	parsedURL, isFake, err := parseFakeMbusURL(mbusURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFake {
+		return f.delegate.NewAgentClient(directorID, mbusURL, caCert)
+	}
+
+	return NewAgentClient(f.fs, agentStatePath(parsedURL.Path, parsedURL.Hostname()), f.logger), nil
+}
+
+type blobstoreFactory struct {
+	delegate biblobstore.Factory
+	fs       boshsys.FileSystem
+	uuidGen  boshuuid.Generator
+	logger   boshlog.Logger
+}
+
+// NewBlobstoreFactory returns a blobstore Factory that stores blobs in the
+// fake CPI directory for fake mbus URLs. Other URLs get a blobstore from
+// delegate.
+func NewBlobstoreFactory(delegate biblobstore.Factory, fs boshsys.FileSystem, uuidGen boshuuid.Generator, logger boshlog.Logger) biblobstore.Factory {
+	return blobstoreFactory{
+		delegate: delegate,
+		fs:       fs,
+		uuidGen:  uuidGen,
+		logger:   logger,
+	}
+}
+
+func (f blobstoreFactory) Create(mbusURL string, httpClient *http.Client) (biblobstore.Blobstore, error) {
+	parsedURL, isFake, err := parseFakeMbusURL(mbusURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFake {
+		return f.delegate.Create(mbusURL, httpClient)
+	}
+
+	davClient := directoryDavClient{fs: f.fs, dir: filepath.Join(parsedURL.Path, "blobstore")}
+	return biblobstore.NewBlobstore(davClient, f.uuidGen, f.fs, f.logger), nil
+}
+
+// directoryDavClient keeps blobs as files in a directory.
+type directoryDavClient struct {
+	fs  boshsys.FileSystem
+	dir string
+}
+
+func (c directoryDavClient) Get(path string) (io.ReadCloser, error) {
+	file, err := c.fs.OpenFile(filepath.Join(c.dir, path), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening blob '%s'", path)
+	}
+	return file, nil
+}
+
+func (c directoryDavClient) Put(path string, content io.ReadCloser, contentLength int64) error {
+	defer content.Close()
+
+	blobPath := filepath.Join(c.dir, path)
+	err := c.fs.MkdirAll(filepath.Dir(blobPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating blobstore directory '%s'", c.dir)
+	}
+
+	file, err := c.fs.OpenFile(blobPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating blob '%s'", path)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing blob '%s'", path)
+	}
+
+	return nil
+}
+
+func parseFakeMbusURL(mbusURL string) (*url.URL, bool, error) {
+	parsedURL, err := url.Parse(mbusURL)
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Parsing mbus url")
+	}
+
+	return parsedURL, parsedURL.Scheme == MbusScheme, nil
+}
@@ -16,6 +16,10 @@ type CpiInstaller struct {
 
 func (i CpiInstaller) ValidateCpiRelease(installationManifest biinstallmanifest.Manifest, stage biui.Stage) error {
 	return stage.Perform("Validating cpi release", func() error {
+		if installationManifest.UsesFakeCPI() {
+			return biui.NewSkipStageError(bosherr.Errorf("Using the fake CPI in '%s'", installationManifest.FakeCPI.Dir), "Using fake CPI")
+		}
+
 		cpiReleaseName := installationManifest.Template.Release
 		cpiRelease, found := i.ReleaseManager.Find(cpiReleaseName)
 		if !found {
@@ -45,6 +49,11 @@ func (i CpiInstaller) installCpiRelease(installer biinstall.Installer, installat
 }
 
 func (i CpiInstaller) WithInstalledCpiRelease(installationManifest biinstallmanifest.Manifest, target biinstall.Target, stage biui.Stage, fn func(biinstall.Installation) error) (errToReturn error) {
+	if installationManifest.UsesFakeCPI() {
+		// The fake CPI runs in-process, so there is nothing to install
+		return fn(biinstall.NewInstallation(target, biinstall.InstalledJob{}, installationManifest))
+	}
+
 	installer := i.InstallerFactory.NewInstaller(target)
 
 	installation, err := i.installCpiRelease(installer, installationManifest, target, stage)
@@ -131,6 +131,29 @@ var _ = Describe("Installer", func() {
 
 		})
 
+		Context("when the installation manifest selects the fake CPI", func() {
+			It("calls the passed in function without installing a CPI release", func() {
+				cpiInstaller := release.CpiInstaller{
+					InstallerFactory: mockInstallerFactory,
+				}
+
+				expectInstall.Times(0)
+				expectCleanup.Times(0)
+				fakeCPIManifest := biinstallationmanifest.Manifest{FakeCPI: biinstallationmanifest.FakeCPI{Dir: "/fake-cpi-dir"}}
+
+				var installationArgumentToFunction biinstallation.Installation
+				err := cpiInstaller.WithInstalledCpiRelease(fakeCPIManifest, target, installStage, func(installation biinstallation.Installation) error {
+					installationArgumentToFunction = installation
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(installationArgumentToFunction.Target()).To(Equal(target))
+				Expect(installationArgumentToFunction.Manifest()).To(Equal(fakeCPIManifest))
+				Expect(installStage.PerformCalls).To(BeEmpty())
+			})
+		})
+
 		Context("when installing the cpi fails", func() {
 			It("returns the error", func() {
 				cpiInstaller := release.CpiInstaller{
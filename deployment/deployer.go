@@ -5,6 +5,7 @@ import (
 
 	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/v7/deployment/instance"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/v7/deployment/manifest"
@@ -22,6 +23,8 @@ type Deployer interface {
 		bistemcell.CloudStemcell,
 		bivm.Manager,
 		biblobstore.Blobstore,
+		biinstance.AgentProvider,
+		bool,
 		bool,
 		biui.Stage,
 	) (Deployment, error)
@@ -31,6 +34,7 @@ type deployer struct {
 	vmManagerFactory       bivm.ManagerFactory
 	instanceManagerFactory biinstance.ManagerFactory
 	deploymentFactory      Factory
+	progressRepo           biconfig.DeployProgressRepo
 	logger                 boshlog.Logger
 	logTag                 string
 }
@@ -39,12 +43,14 @@ func NewDeployer(
 	vmManagerFactory bivm.ManagerFactory,
 	instanceManagerFactory biinstance.ManagerFactory,
 	deploymentFactory Factory,
+	progressRepo biconfig.DeployProgressRepo,
 	logger boshlog.Logger,
 ) Deployer {
 	return &deployer{
 		vmManagerFactory:       vmManagerFactory,
 		instanceManagerFactory: instanceManagerFactory,
 		deploymentFactory:      deploymentFactory,
+		progressRepo:           progressRepo,
 		logger:                 logger,
 		logTag:                 "deployer",
 	}
@@ -56,18 +62,46 @@ func (d *deployer) Deploy(
 	cloudStemcell bistemcell.CloudStemcell,
 	vmManager bivm.Manager,
 	blobstore biblobstore.Blobstore,
+	agentProvider biinstance.AgentProvider,
 	skipDrain bool,
+	resume bool,
 	deployStage biui.Stage,
 ) (Deployment, error) {
-	instanceManager := d.instanceManagerFactory.NewManager(cloud, vmManager, blobstore)
+	if len(deploymentManifest.Jobs) == 0 {
+		return nil, bosherr.Error("There must be at least one job")
+	}
+	if deploymentManifest.Jobs[0].Instances < 1 {
+		return nil, bosherr.Errorf("Job '%s' must have at least one instance, found %d", deploymentManifest.Jobs[0].Name, deploymentManifest.Jobs[0].Instances)
+	}
+
+	instanceManager := d.instanceManagerFactory.NewManager(cloud, vmManager, blobstore, agentProvider)
+
+	progress, _, err := d.progressRepo.Find()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding deploy progress")
+	}
+
+	// Once the old instances are gone, every current VM was created by the
+	// interrupted deploy and may be resumed instead of deleted
+	if !resume || !progress.InstancesDeleted {
+		resume = false
+
+		pingTimeout := 10 * time.Second
+		pingDelay := 500 * time.Millisecond
+		if err := instanceManager.DeleteAll(pingTimeout, pingDelay, skipDrain, deployStage); err != nil {
+			return nil, err
+		}
+
+		if err := d.progressRepo.MarkInstancesDeleted(); err != nil {
+			return nil, bosherr.WrapError(err, "Journaling deploy progress")
+		}
+	}
 
-	pingTimeout := 10 * time.Second
-	pingDelay := 500 * time.Millisecond
-	if err := instanceManager.DeleteAll(pingTimeout, pingDelay, skipDrain, deployStage); err != nil {
+	if err := instanceManager.ForgetObsolete(deploymentManifest); err != nil {
 		return nil, err
 	}
 
-	instances, disks, err := d.createAllInstances(deploymentManifest, instanceManager, cloudStemcell, deployStage)
+	instances, disks, err := d.createAllInstances(deploymentManifest, instanceManager, cloudStemcell, progress, resume, deployStage)
 	if err != nil {
 		return nil, err
 	}
@@ -80,31 +114,51 @@ func (d *deployer) createAllInstances(
 	deploymentManifest bideplmanifest.Manifest,
 	instanceManager biinstance.Manager,
 	cloudStemcell bistemcell.CloudStemcell,
+	progress biconfig.DeployProgress,
+	resume bool,
 	deployStage biui.Stage,
 ) ([]biinstance.Instance, []bidisk.Disk, error) {
 	instances := []biinstance.Instance{}
 	disks := []bidisk.Disk{}
 
-	if len(deploymentManifest.Jobs) != 1 {
-		return instances, disks, bosherr.Errorf("There must only be one job, found %d", len(deploymentManifest.Jobs))
-	}
-
 	for _, jobSpec := range deploymentManifest.Jobs {
-		if jobSpec.Instances != 1 {
-			return instances, disks, bosherr.Errorf("Job '%s' must have only one instance, found %d", jobSpec.Name, jobSpec.Instances)
-		}
 		for instanceID := 0; instanceID < jobSpec.Instances; instanceID++ {
-			instance, instanceDisks, err := instanceManager.Create(jobSpec.Name, instanceID, deploymentManifest, cloudStemcell, deployStage)
-			if err != nil {
-				return instances, disks, bosherr.WrapErrorf(err, "Creating instance '%s/%d'", jobSpec.Name, instanceID)
+			var (
+				instance      biinstance.Instance
+				instanceDisks []bidisk.Disk
+				resumed       bool
+				err           error
+			)
+			if resume {
+				instance, instanceDisks, resumed, err = instanceManager.Resume(jobSpec.Name, instanceID, deploymentManifest, deployStage)
+				if err != nil {
+					return instances, disks, bosherr.WrapErrorf(err, "Resuming instance '%s/%d'", jobSpec.Name, instanceID)
+				}
+			}
+
+			if !resumed {
+				instance, instanceDisks, err = instanceManager.Create(jobSpec.Name, instanceID, deploymentManifest, cloudStemcell, deployStage)
+				if err != nil {
+					return instances, disks, bosherr.WrapErrorf(err, "Creating instance '%s/%d'", jobSpec.Name, instanceID)
+				}
 			}
 			instances = append(instances, instance)
 			disks = append(disks, instanceDisks...)
 
+			instanceProgress, _ := progress.FindInstance(jobSpec.Name, instanceID)
+			if resumed && instanceProgress.Completed(biconfig.DeployStepJobsApplied) {
+				continue
+			}
+
 			err = instance.UpdateJobs(deploymentManifest, deployStage)
 			if err != nil {
 				return instances, disks, err
 			}
+
+			err = d.progressRepo.MarkInstanceStep(jobSpec.Name, instanceID, biconfig.DeployStepJobsApplied)
+			if err != nil {
+				return instances, disks, bosherr.WrapError(err, "Journaling deploy progress")
+			}
 		}
 	}
 
@@ -15,13 +15,17 @@ import (
 	. "github.com/onsi/gomega"
 
 	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
 	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/v7/deployment/instance"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/v7/deployment/manifest"
 	bistemcell "github.com/cloudfoundry/bosh-cli/v7/stemcell"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 
 	"github.com/cloudfoundry/bosh-agent/agentclient"
 	fakebicloud "github.com/cloudfoundry/bosh-cli/v7/cloud/fakes"
@@ -61,6 +65,9 @@ var _ = Describe("Deployer", func() {
 
 		applySpec bias.ApplySpec
 
+		deploymentStateService biconfig.DeploymentStateService
+		agentProvider          biinstance.AgentProvider
+
 		mockStateBuilderFactory *mock_instance_state.MockBuilderFactory
 		mockStateBuilder        *mock_instance_state.MockBuilder
 		mockState               *mock_instance_state.MockState
@@ -135,8 +142,13 @@ var _ = Describe("Deployer", func() {
 		mockStateBuilder = mock_instance_state.NewMockBuilder(mockCtrl)
 		mockState = mock_instance_state.NewMockState(mockCtrl)
 
+		deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fakesys.NewFakeFileSystem(), fakeuuid.NewFakeGenerator(), logger, "/deployment.json")
+		agentProvider = func(address string) (agentclient.AgentClient, biblobstore.Blobstore, error) {
+			return mockAgentClient, mockBlobstore, nil
+		}
+
 		instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-		instanceManagerFactory := biinstance.NewManagerFactory(fakeSSHTunnelFactory, instanceFactory, logger)
+		instanceManagerFactory := biinstance.NewManagerFactory(mockVMManagerFactory, biconfig.NewInstanceRepo(deploymentStateService), biconfig.NewDeployProgressRepo(deploymentStateService), fakeSSHTunnelFactory, instanceFactory, logger)
 
 		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
@@ -148,6 +160,7 @@ var _ = Describe("Deployer", func() {
 			mockVMManagerFactory,
 			instanceManagerFactory,
 			deploymentFactory,
+			biconfig.NewDeployProgressRepo(deploymentStateService),
 			logger,
 		)
 	})
@@ -180,7 +193,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("deletes existing vm", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
@@ -198,7 +211,7 @@ var _ = Describe("Deployer", func() {
 		Context("when skip-drain is specified", func() {
 			It("skips draining", func() {
 				skipDrain = true
-				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
@@ -215,17 +228,188 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("creates a vm", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+			JobName:  "fake-job-name",
+			Index:    0,
 			Stemcell: cloudStemcell,
 			Manifest: deploymentManifest,
 		}))
 	})
 
+	Context("when the job has multiple instances", func() {
+		var otherFakeVMManager *fakebivm.FakeManager
+
+		BeforeEach(func() {
+			deploymentManifest.Jobs[0].Instances = 2
+			deploymentManifest.Jobs[0].Networks = []bideplmanifest.JobNetwork{
+				{Name: "fake-network-name", StaticIPs: []string{"10.0.0.1", "10.0.0.2"}},
+			}
+
+			otherFakeVMManager = fakebivm.NewFakeManager()
+			otherFakeVMManager.CreateVM = fakeVM
+			mockVMManagerFactory.EXPECT().NewInstanceManager(cloud, mockAgentClient, "fake-job-name", 1, "10.0.0.2").Return(otherFakeVMManager).AnyTimes()
+		})
+
+		JustBeforeEach(func() {
+			mockStateBuilder.EXPECT().Build("fake-job-name", 1, deploymentManifest, fakeStage, gomock.Any()).Return(mockState, nil).AnyTimes()
+			mockStateBuilder.EXPECT().BuildInitialState("fake-job-name", 1, deploymentManifest).Return(mockState, nil).AnyTimes()
+		})
+
+		It("creates the later instances through the agent at their static ip", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+				JobName:  "fake-job-name",
+				Index:    0,
+				Stemcell: cloudStemcell,
+				Manifest: deploymentManifest,
+			}))
+			Expect(otherFakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+				JobName:  "fake-job-name",
+				Index:    1,
+				Stemcell: cloudStemcell,
+				Manifest: deploymentManifest,
+			}))
+		})
+
+		It("updates the instances in order", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeStage.PerformCalls).To(ContainElement(&fakebiui.PerformCall{Name: "Updating instance 'fake-job-name/0'"}))
+			Expect(fakeStage.PerformCalls).To(ContainElement(&fakebiui.PerformCall{Name: "Updating instance 'fake-job-name/1'"}))
+		})
+	})
+
+	Context("when a previously deployed instance is no longer in the manifest", func() {
+		BeforeEach(func() {
+			err := deploymentStateService.Save(biconfig.DeploymentState{
+				Instances: []biconfig.InstanceRecord{
+					{Name: "removed-job-name", Index: 0, IP: "10.0.0.5", DiskID: "fake-disk-id"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("forgets the instance", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Instances).To(BeEmpty())
+		})
+	})
+
+	It("journals the completed steps of each instance", func() {
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
+		Expect(err).NotTo(HaveOccurred())
+
+		progress, found, err := biconfig.NewDeployProgressRepo(deploymentStateService).Find()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(progress.InstancesDeleted).To(BeTrue())
+		Expect(progress.Instances).To(Equal([]biconfig.InstanceProgress{
+			{Name: "fake-job-name", Index: 0, Steps: []string{"vm_created", "disks_attached", "jobs_applied"}},
+		}))
+	})
+
+	Context("when resuming an interrupted deploy", func() {
+		var (
+			fakeExistingVM *fakebivm.FakeVM
+			progressRepo   biconfig.DeployProgressRepo
+		)
+
+		BeforeEach(func() {
+			fakeExistingVM = fakebivm.NewFakeVM("existing-vm-cid")
+			fakeExistingVM.AgentClientReturn = mockAgentClient
+			fakeExistingVM.GetStateResult = agentclient.AgentState{}
+			fakeExistingVM.ListDisksDisks = []bidisk.Disk{bidisk.NewDisk(biconfig.DiskRecord{CID: "fake-disk-cid"}, nil, nil)}
+			fakeVMManager.SetFindCurrentBehavior(fakeExistingVM, true, nil)
+
+			progressRepo = biconfig.NewDeployProgressRepo(deploymentStateService)
+			Expect(progressRepo.Start("fake-manifest-sha")).To(Succeed())
+			Expect(progressRepo.MarkInstancesDeleted()).To(Succeed())
+			Expect(progressRepo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepVMCreated)).To(Succeed())
+			Expect(progressRepo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepDisksAttached)).To(Succeed())
+		})
+
+		It("continues with the journaled VM and disks", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, true, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeExistingVM.DeleteCalled).To(Equal(0))
+			Expect(fakeExistingVM.ExistsCalled).To(Equal(1))
+			Expect(fakeExistingVM.UpdateDisksInputs).To(BeEmpty())
+			Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{}))
+			Expect(fakeExistingVM.ApplyInputs).To(HaveLen(2))
+
+			Expect(fakeStage.PerformCalls[0]).To(Equal(&fakebiui.PerformCall{
+				Name: "Verifying VM 'existing-vm-cid' of instance 'fake-job-name/0'",
+			}))
+
+			progress, _, err := progressRepo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(progress.Instances[0].Completed(biconfig.DeployStepJobsApplied)).To(BeTrue())
+		})
+
+		It("does not apply the jobs again once they were applied", func() {
+			Expect(progressRepo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepJobsApplied)).To(Succeed())
+
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, true, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeExistingVM.ApplyInputs).To(BeEmpty())
+		})
+
+		It("attaches the disks again when the agent no longer reports them", func() {
+			fakeExistingVM.ListDisksDisks = []bidisk.Disk{}
+
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, true, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeExistingVM.UpdateDisksInputs).To(HaveLen(1))
+		})
+
+		It("creates the VM again when the journaled VM no longer exists", func() {
+			fakeExistingVM.ExistsFound = false
+
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, true, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeExistingVM.DeleteCalled).To(Equal(0))
+			Expect(fakeVMManager.CreateInput.JobName).To(Equal("fake-job-name"))
+		})
+
+		It("deletes the previous instances first when their deletion was not journaled", func() {
+			Expect(progressRepo.Start("fake-manifest-sha")).To(Succeed())
+
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, true, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
+			Expect(fakeVMManager.CreateInput.JobName).To(Equal("fake-job-name"))
+		})
+	})
+
+	Context("when the first job has no instances", func() {
+		BeforeEach(func() {
+			deploymentManifest.Jobs[0].Instances = 0
+		})
+
+		It("returns an error", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Job 'fake-job-name' must have at least one instance, found 0"))
+		})
+	})
+
 	It("waits for the vm", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(fakeVM.WaitUntilReadyInputs).To(ContainElement(fakebivm.WaitUntilReadyInput{
 			Timeout: 10 * time.Minute,
@@ -234,7 +418,7 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("logs start and stop events to the eventLogger", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeStage.PerformCalls[1]).To(Equal(&fakebiui.PerformCall{
@@ -252,7 +436,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("logs start and stop events to the eventLogger", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-wait-error"))
 
@@ -264,7 +448,7 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("updates the vm", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVM.ApplyInputs).To(Equal([]fakebivm.ApplyInput{
@@ -274,14 +458,14 @@ var _ = Describe("Deployer", func() {
 	})
 
 	It("starts the agent", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVM.StartCalled).To(Equal(1))
 	})
 
 	It("waits until agent reports state as running", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeVM.WaitToBeRunningInputs).To(ContainElement(fakebivm.WaitInput{
@@ -296,13 +480,13 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("returns an error", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 			Expect(err).To(HaveOccurred())
 		})
 	})
 
 	It("logs instance update ui stages", func() {
-		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeStage.PerformCalls[2:4]).To(Equal([]*fakebiui.PerformCall{
@@ -317,7 +501,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("fails with descriptive error", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("Applying the initial agent state: fake-apply-error"))
 		})
@@ -329,7 +513,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("logs start and stop events to the eventLogger", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-start-error"))
 
@@ -349,7 +533,7 @@ var _ = Describe("Deployer", func() {
 		})
 
 		It("logs start and stop events to the eventLogger", func() {
-			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, fakeVMManager, mockBlobstore, agentProvider, skipDrain, false, fakeStage)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-wait-running-error"))
 
@@ -96,15 +96,15 @@ func (d *deployment) Stop(skipDrain bool, stopEnvStage biui.Stage) error {
 }
 
 func (d *deployment) Start(startEnvStage biui.Stage, updateSection bideplmanifest.Update) error {
+	// start the bootstrap instance first, in the order the instances were created
 	for len(d.instances) > 0 {
-		lastIdx := len(d.instances) - 1
-		instance := d.instances[lastIdx]
+		instance := d.instances[0]
 
 		if err := instance.Start(updateSection, d.pingTimeout, d.pingDelay, startEnvStage); err != nil {
 			return err
 		}
 
-		d.instances = d.instances[:lastIdx]
+		d.instances = d.instances[1:]
 	}
 
 	return nil
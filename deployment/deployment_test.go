@@ -122,7 +122,7 @@ var _ = Describe("Deployment", func() {
 		diskManagerFactory := bidisk.NewManagerFactory(diskRepo, logger)
 		diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger, false)
 
-		vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+		vmManagerFactory := bivm.NewManagerFactory(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, false, fakeUUIDGenerator, fs, logger)
 		sshTunnelFactory := bisshtunnel.NewFactory(logger)
 
 		mockStateBuilderFactory = mockinstancestate.NewMockBuilderFactory(mockCtrl)
@@ -130,13 +130,13 @@ var _ = Describe("Deployment", func() {
 		mockState = mockinstancestate.NewMockState(mockCtrl)
 
 		instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-		instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
+		instanceManagerFactory := biinstance.NewManagerFactory(vmManagerFactory, biconfig.NewInstanceRepo(deploymentStateService), biconfig.NewDeployProgressRepo(deploymentStateService), sshTunnelFactory, instanceFactory, logger)
 		stemcellManagerFactory := bistemcell.NewManagerFactory(stemcellRepo)
 
 		mockBlobstore = mockblobstore.NewMockBlobstore(mockCtrl)
 
 		deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, deploymentFactory)
-		deploymentManager := deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore)
+		deploymentManager := deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore, nil)
 
 		allowApplySpecToBeCreated()
 
@@ -52,6 +52,10 @@ func (m *FakeManager) FindCurrent() ([]bidisk.Disk, error) {
 	return m.findCurrentOutput.Disks, m.findCurrentOutput.Err
 }
 
+func (m *FakeManager) FindAllCurrent() ([]bidisk.Disk, error) {
+	return m.findCurrentOutput.Disks, m.findCurrentOutput.Err
+}
+
 func (m *FakeManager) FindUnused() ([]bidisk.Disk, error) {
 	return m.findUnusedOutput.disks, m.findUnusedOutput.err
 }
@@ -13,6 +13,7 @@ import (
 
 type Manager interface {
 	FindCurrent() ([]Disk, error)
+	FindAllCurrent() ([]Disk, error)
 	Create(bideplmanifest.DiskPool, string) (Disk, error)
 	FindUnused() ([]Disk, error)
 	DeleteUnused(biui.Stage) error
@@ -54,6 +55,22 @@ func (m *manager) FindCurrent() ([]Disk, error) {
 	return disks, nil
 }
 
+// FindAllCurrent returns the current disks of all instances
+func (m *manager) FindAllCurrent() ([]Disk, error) {
+	disks := []Disk{}
+
+	diskRecords, err := m.diskRepo.FindAllCurrent()
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Reading disk records")
+	}
+
+	for _, diskRecord := range diskRecords {
+		disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo))
+	}
+
+	return disks, nil
+}
+
 func (m *manager) Create(diskPool bideplmanifest.DiskPool, vmCID string) (Disk, error) {
 	diskCloudProperties := diskPool.CloudProperties
 
@@ -85,13 +102,19 @@ func (m *manager) FindUnused() ([]Disk, error) {
 		return disks, bosherr.WrapError(err, "Getting all disk records")
 	}
 
-	currentDiskRecord, found, err := m.diskRepo.FindCurrent()
+	// Disks of other instances are still in use
+	currentDiskRecords, err := m.diskRepo.FindAllCurrent()
 	if err != nil {
-		return disks, bosherr.WrapError(err, "Finding current disk record")
+		return disks, bosherr.WrapError(err, "Finding current disk records")
+	}
+
+	currentDiskIDs := map[string]struct{}{}
+	for _, currentDiskRecord := range currentDiskRecords {
+		currentDiskIDs[currentDiskRecord.ID] = struct{}{}
 	}
 
 	for _, diskRecord := range diskRecords {
-		if !found || diskRecord.ID != currentDiskRecord.ID {
+		if _, found := currentDiskIDs[diskRecord.ID]; !found {
 			disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo))
 		}
 	}
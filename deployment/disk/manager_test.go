@@ -24,13 +24,15 @@ var _ = Describe("Manager", func() {
 		fakeFs            *fakesys.FakeFileSystem
 		fakeUUIDGenerator *fakeuuid.FakeGenerator
 		diskRepo          biconfig.DiskRepo
+
+		deploymentStateService biconfig.DeploymentStateService
 	)
 
 	BeforeEach(func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeFs = fakesys.NewFakeFileSystem()
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
-		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "/fake/path")
+		deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "/fake/path")
 		diskRepo = biconfig.NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
 		managerFactory := NewManagerFactory(diskRepo, logger)
 		fakeCloud = fakebicloud.NewFakeCloud()
@@ -183,6 +185,47 @@ var _ = Describe("Manager", func() {
 				thirdDisk,
 			}))
 		})
+
+		Context("when a disk is the current disk of another instance", func() {
+			BeforeEach(func() {
+				instanceDiskRepo := biconfig.NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1, "10.0.0.2")
+				err := instanceDiskRepo.UpdateCurrent("fake-guid-3")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("does not return it", func() {
+				disks, err := manager.FindUnused()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(disks).To(Equal([]bidisk.Disk{
+					firstDisk,
+				}))
+			})
+		})
+	})
+
+	Describe("FindAllCurrent", func() {
+		It("returns the current disks of all instances", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
+			firstDiskRecord, err := diskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			err = diskRepo.UpdateCurrent("fake-guid-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeUUIDGenerator.GeneratedUUID = "fake-guid-2"
+			secondDiskRecord, err := diskRepo.Save("fake-disk-cid-2", 1024, biproperty.Map{})
+			Expect(err).ToNot(HaveOccurred())
+			instanceDiskRepo := biconfig.NewInstanceDiskRepo(deploymentStateService, fakeUUIDGenerator, "fake-job", 1, "10.0.0.2")
+			err = instanceDiskRepo.UpdateCurrent("fake-guid-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			disks, err := manager.FindAllCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]bidisk.Disk{
+				NewDisk(firstDiskRecord, fakeCloud, diskRepo),
+				NewDisk(secondDiskRecord, fakeCloud, diskRepo),
+			}))
+		})
 	})
 
 	Describe("DeleteUnused", func() {
@@ -132,6 +132,21 @@ func (mr *MockManagerMockRecorder) DeleteUnused(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnused", reflect.TypeOf((*MockManager)(nil).DeleteUnused), arg0)
 }
 
+// FindAllCurrent mocks base method.
+func (m *MockManager) FindAllCurrent() ([]disk.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllCurrent")
+	ret0, _ := ret[0].([]disk.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllCurrent indicates an expected call of FindAllCurrent.
+func (mr *MockManagerMockRecorder) FindAllCurrent() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllCurrent", reflect.TypeOf((*MockManager)(nil).FindAllCurrent))
+}
+
 // FindCurrent mocks base method.
 func (m *MockManager) FindCurrent() ([]disk.Disk, error) {
 	m.ctrl.T.Helper()
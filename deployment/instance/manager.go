@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/v7/deployment/manifest"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/v7/deployment/sshtunnel"
@@ -16,6 +18,11 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+// AgentProvider returns the agent client and blobstore used to reach the agent
+// listening on the given address. It is used for every instance except the
+// bootstrap instance, which is reached through the installation mbus URL.
+type AgentProvider func(address string) (biagentclient.AgentClient, biblobstore.Blobstore, error)
+
 type Manager interface {
 	FindCurrent() ([]Instance, error)
 	Create(
@@ -25,18 +32,33 @@ type Manager interface {
 		cloudStemcell bistemcell.CloudStemcell,
 		eventLoggerStage biui.Stage,
 	) (Instance, []bidisk.Disk, error)
+
+	// Resume continues an instance that an interrupted deploy journaled as
+	// created. It returns false when the instance has to be created anew,
+	// e.g. because its VM no longer exists.
+	Resume(
+		jobName string,
+		id int,
+		deploymentManifest bideplmanifest.Manifest,
+		eventLoggerStage biui.Stage,
+	) (Instance, []bidisk.Disk, bool, error)
 	DeleteAll(
 		pingTimeout time.Duration,
 		pingDelay time.Duration,
 		skipDrain bool,
 		eventLoggerStage biui.Stage,
 	) error
+	ForgetObsolete(deploymentManifest bideplmanifest.Manifest) error
 }
 
 type manager struct {
 	cloud            bicloud.Cloud
 	vmManager        bivm.Manager
 	blobstore        biblobstore.Blobstore
+	agentProvider    AgentProvider
+	vmManagerFactory bivm.ManagerFactory
+	instanceRepo     biconfig.InstanceRepo
+	progressRepo     biconfig.DeployProgressRepo
 	sshTunnelFactory bisshtunnel.Factory
 	instanceFactory  Factory
 	logger           boshlog.Logger
@@ -47,6 +69,10 @@ func NewManager(
 	cloud bicloud.Cloud,
 	vmManager bivm.Manager,
 	blobstore biblobstore.Blobstore,
+	agentProvider AgentProvider,
+	vmManagerFactory bivm.ManagerFactory,
+	instanceRepo biconfig.InstanceRepo,
+	progressRepo biconfig.DeployProgressRepo,
 	sshTunnelFactory bisshtunnel.Factory,
 	instanceFactory Factory,
 	logger boshlog.Logger,
@@ -55,6 +81,10 @@ func NewManager(
 		cloud:            cloud,
 		vmManager:        vmManager,
 		blobstore:        blobstore,
+		agentProvider:    agentProvider,
+		vmManagerFactory: vmManagerFactory,
+		instanceRepo:     instanceRepo,
+		progressRepo:     progressRepo,
 		sshTunnelFactory: sshTunnelFactory,
 		instanceFactory:  instanceFactory,
 		logger:           logger,
@@ -65,7 +95,7 @@ func NewManager(
 func (m *manager) FindCurrent() ([]Instance, error) {
 	instances := []Instance{}
 
-	// Only one current instance will exist (for now)
+	// The bootstrap instance is tracked separately from the other instances
 	vm, found, err := m.vmManager.FindCurrent()
 	if err != nil {
 		return instances, bosherr.WrapError(err, "Finding currently deployed instances")
@@ -88,6 +118,40 @@ func (m *manager) FindCurrent() ([]Instance, error) {
 		instances = append(instances, instance)
 	}
 
+	records, err := m.instanceRepo.All()
+	if err != nil {
+		return instances, bosherr.WrapError(err, "Finding currently deployed instances")
+	}
+
+	for _, record := range records {
+		if record.VMCID == "" {
+			continue
+		}
+
+		vmManager, blobstore, err := m.instanceClients(record.Name, record.Index, record.IP)
+		if err != nil {
+			return instances, err
+		}
+
+		vm, found, err := vmManager.FindCurrent()
+		if err != nil {
+			return instances, bosherr.WrapErrorf(err, "Finding currently deployed instance '%s/%d'", record.Name, record.Index)
+		}
+
+		if found {
+			instance := m.instanceFactory.NewInstance(
+				record.Name,
+				record.Index,
+				vm,
+				vmManager,
+				m.sshTunnelFactory,
+				blobstore,
+				m.logger,
+			)
+			instances = append(instances, instance)
+		}
+	}
+
 	return instances, nil
 }
 
@@ -98,11 +162,16 @@ func (m *manager) Create(
 	cloudStemcell bistemcell.CloudStemcell,
 	eventLoggerStage biui.Stage,
 ) (Instance, []bidisk.Disk, error) {
+	vmManager, blobstore, err := m.deploymentClients(jobName, id, deploymentManifest)
+	if err != nil {
+		return nil, []bidisk.Disk{}, err
+	}
+
 	var vm bivm.VM
 	stepName := fmt.Sprintf("Creating VM for instance '%s/%d' from stemcell '%s'", jobName, id, cloudStemcell.CID())
-	err := eventLoggerStage.Perform(stepName, func() error {
+	err = eventLoggerStage.Perform(stepName, func() error {
 		var err error
-		vm, err = m.vmManager.Create(cloudStemcell, deploymentManifest)
+		vm, err = vmManager.Create(jobName, id, cloudStemcell, deploymentManifest)
 		if err != nil {
 			return bosherr.WrapError(err, "Creating VM")
 		}
@@ -117,18 +186,92 @@ func (m *manager) Create(
 		return nil, []bidisk.Disk{}, err
 	}
 
-	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, m.logger)
+	err = m.progressRepo.MarkInstanceStep(jobName, id, biconfig.DeployStepVMCreated)
+	if err != nil {
+		return nil, []bidisk.Disk{}, bosherr.WrapError(err, "Journaling deploy progress")
+	}
+
+	instance := m.instanceFactory.NewInstance(jobName, id, vm, vmManager, m.sshTunnelFactory, blobstore, m.logger)
 
 	if err := instance.WaitUntilReady(eventLoggerStage); err != nil {
 		return instance, []bidisk.Disk{}, bosherr.WrapError(err, "Waiting until instance is ready")
 	}
 
-	disks, err := instance.UpdateDisks(deploymentManifest, eventLoggerStage)
+	disks, err := m.updateDisks(instance, deploymentManifest, eventLoggerStage)
+	return instance, disks, err
+}
+
+func (m *manager) Resume(
+	jobName string,
+	id int,
+	deploymentManifest bideplmanifest.Manifest,
+	eventLoggerStage biui.Stage,
+) (Instance, []bidisk.Disk, bool, error) {
+	progress, _, err := m.progressRepo.Find()
 	if err != nil {
-		return instance, disks, bosherr.WrapError(err, "Updating instance disks")
+		return nil, []bidisk.Disk{}, false, bosherr.WrapError(err, "Finding deploy progress")
 	}
+	instanceProgress, _ := progress.FindInstance(jobName, id)
 
-	return instance, disks, err
+	vmManager, blobstore, err := m.deploymentClients(jobName, id, deploymentManifest)
+	if err != nil {
+		return nil, []bidisk.Disk{}, false, err
+	}
+
+	vm, found, err := vmManager.FindCurrent()
+	if err != nil {
+		return nil, []bidisk.Disk{}, false, bosherr.WrapErrorf(err, "Finding current VM of instance '%s/%d'", jobName, id)
+	}
+	if !found {
+		return nil, []bidisk.Disk{}, false, nil
+	}
+
+	exists := false
+	stepName := fmt.Sprintf("Verifying VM '%s' of instance '%s/%d'", vm.CID(), jobName, id)
+	err = eventLoggerStage.Perform(stepName, func() error {
+		exists, err = vm.Exists()
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return biui.NewSkipStageError(bosherr.Errorf("VM '%s' no longer exists", vm.CID()), "VM not found")
+		}
+
+		if !instanceProgress.Completed(biconfig.DeployStepVMCreated) {
+			// The VM was recorded but creating it was never journaled as
+			// complete, so it cannot be trusted to be in a usable state
+			exists = false
+			if err = vm.Delete(); err != nil {
+				return bosherr.WrapErrorf(err, "Deleting incompletely created VM '%s'", vm.CID())
+			}
+			return biui.NewSkipStageError(bosherr.Errorf("VM '%s' was not completely created", vm.CID()), "VM deleted")
+		}
+
+		return nil
+	})
+	if err != nil || !exists {
+		return nil, []bidisk.Disk{}, false, err
+	}
+
+	instance := m.instanceFactory.NewInstance(jobName, id, vm, vmManager, m.sshTunnelFactory, blobstore, m.logger)
+
+	if err := instance.WaitUntilReady(eventLoggerStage); err != nil {
+		return instance, []bidisk.Disk{}, false, bosherr.WrapError(err, "Waiting until instance is ready")
+	}
+
+	if instanceProgress.Completed(biconfig.DeployStepDisksAttached) {
+		disks, attached, err := m.attachedDisks(vm, jobName, id, deploymentManifest)
+		if err != nil {
+			return instance, disks, false, err
+		}
+		if attached {
+			return instance, disks, true, nil
+		}
+	}
+
+	disks, err := m.updateDisks(instance, deploymentManifest, eventLoggerStage)
+	return instance, disks, true, err
 }
 
 func (m *manager) DeleteAll(
@@ -149,3 +292,86 @@ func (m *manager) DeleteAll(
 	}
 	return nil
 }
+
+// ForgetObsolete removes the records of instances that are no longer in the
+// manifest. Their persistent disks become unused and are deleted with the other unused disks.
+func (m *manager) ForgetObsolete(deploymentManifest bideplmanifest.Manifest) error {
+	records, err := m.instanceRepo.All()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding instance records")
+	}
+
+	for _, record := range records {
+		job, found := deploymentManifest.FindJobByName(record.Name)
+		if found && record.Index < job.Instances && !deploymentManifest.IsBootstrapInstance(record.Name, record.Index) {
+			continue
+		}
+
+		m.logger.Debug(m.logTag, "Forgetting obsolete instance '%s/%d'", record.Name, record.Index)
+		err = m.instanceRepo.Delete(record.Name, record.Index)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deleting record of instance '%s/%d'", record.Name, record.Index)
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) updateDisks(instance Instance, deploymentManifest bideplmanifest.Manifest, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
+	disks, err := instance.UpdateDisks(deploymentManifest, eventLoggerStage)
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Updating instance disks")
+	}
+
+	err = m.progressRepo.MarkInstanceStep(instance.JobName(), instance.ID(), biconfig.DeployStepDisksAttached)
+	if err != nil {
+		return disks, bosherr.WrapError(err, "Journaling deploy progress")
+	}
+
+	return disks, nil
+}
+
+// attachedDisks asks the agent which disks are attached to the VM. It returns
+// false when the disk pool of the job asks for a disk that is not attached.
+func (m *manager) attachedDisks(vm bivm.VM, jobName string, id int, deploymentManifest bideplmanifest.Manifest) ([]bidisk.Disk, bool, error) {
+	diskPool, err := deploymentManifest.DiskPool(jobName)
+	if err != nil {
+		return []bidisk.Disk{}, false, bosherr.WrapError(err, "Getting disk pool")
+	}
+
+	disks, err := vm.Disks()
+	if err != nil {
+		return []bidisk.Disk{}, false, bosherr.WrapErrorf(err, "Verifying disks of instance '%s/%d'", jobName, id)
+	}
+
+	if diskPool.DiskSize > 0 && len(disks) == 0 {
+		m.logger.Debug(m.logTag, "Persistent disk of instance '%s/%d' is no longer attached", jobName, id)
+		return disks, false, nil
+	}
+
+	return disks, true, nil
+}
+
+// deploymentClients returns the VM manager and blobstore of an instance in the manifest.
+func (m *manager) deploymentClients(jobName string, id int, deploymentManifest bideplmanifest.Manifest) (bivm.Manager, biblobstore.Blobstore, error) {
+	if deploymentManifest.IsBootstrapInstance(jobName, id) {
+		return m.vmManager, m.blobstore, nil
+	}
+
+	address, found := deploymentManifest.InstanceAddress(jobName, id)
+	if !found {
+		return nil, nil, bosherr.Errorf("Finding static ip of instance '%s/%d'", jobName, id)
+	}
+
+	return m.instanceClients(jobName, id, address)
+}
+
+func (m *manager) instanceClients(jobName string, id int, address string) (bivm.Manager, biblobstore.Blobstore, error) {
+	agentClient, blobstore, err := m.agentProvider(address)
+	if err != nil {
+		return nil, nil, bosherr.WrapErrorf(err, "Creating agent client for instance '%s/%d'", jobName, id)
+	}
+
+	vmManager := m.vmManagerFactory.NewInstanceManager(m.cloud, agentClient, jobName, id, address)
+	return vmManager, blobstore, nil
+}
@@ -3,38 +3,52 @@ package instance
 import (
 	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/v7/deployment/sshtunnel"
 	bivm "github.com/cloudfoundry/bosh-cli/v7/deployment/vm"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 type ManagerFactory interface {
-	NewManager(bicloud.Cloud, bivm.Manager, biblobstore.Blobstore) Manager
+	NewManager(bicloud.Cloud, bivm.Manager, biblobstore.Blobstore, AgentProvider) Manager
 }
 
 type managerFactory struct {
+	vmManagerFactory bivm.ManagerFactory
+	instanceRepo     biconfig.InstanceRepo
+	progressRepo     biconfig.DeployProgressRepo
 	sshTunnelFactory bisshtunnel.Factory
 	instanceFactory  Factory
 	logger           boshlog.Logger
 }
 
 func NewManagerFactory(
+	vmManagerFactory bivm.ManagerFactory,
+	instanceRepo biconfig.InstanceRepo,
+	progressRepo biconfig.DeployProgressRepo,
 	sshTunnelFactory bisshtunnel.Factory,
 	instanceFactory Factory,
 	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
+		vmManagerFactory: vmManagerFactory,
+		instanceRepo:     instanceRepo,
+		progressRepo:     progressRepo,
 		sshTunnelFactory: sshTunnelFactory,
 		instanceFactory:  instanceFactory,
 		logger:           logger,
 	}
 }
 
-func (f *managerFactory) NewManager(cloud bicloud.Cloud, vmManager bivm.Manager, blobstore biblobstore.Blobstore) Manager {
+func (f *managerFactory) NewManager(cloud bicloud.Cloud, vmManager bivm.Manager, blobstore biblobstore.Blobstore, agentProvider AgentProvider) Manager {
 	return NewManager(
 		cloud,
 		vmManager,
 		blobstore,
+		agentProvider,
+		f.vmManagerFactory,
+		f.instanceRepo,
+		f.progressRepo,
 		f.sshTunnelFactory,
 		f.instanceFactory,
 		f.logger,
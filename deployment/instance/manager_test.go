@@ -12,13 +12,19 @@ import (
 	mock_agentclient "github.com/cloudfoundry/bosh-cli/v7/agentclient/mocks"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore/mocks"
 	mock_instance_state "github.com/cloudfoundry/bosh-cli/v7/deployment/instance/state/mocks"
+	mock_vm "github.com/cloudfoundry/bosh-cli/v7/deployment/vm/mocks"
 	"github.com/golang/mock/gomock"
 
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	biblobstore "github.com/cloudfoundry/bosh-cli/v7/blobstore"
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/v7/deployment/manifest"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 
 	"github.com/cloudfoundry/bosh-agent/agentclient"
 	fakebicloud "github.com/cloudfoundry/bosh-cli/v7/cloud/fakes"
@@ -52,12 +58,15 @@ var _ = Describe("Manager", func() {
 
 		mockBlobstore *mock_blobstore.MockBlobstore
 
-		fakeVMManager        *fakebivm.FakeManager
-		fakeSSHTunnelFactory *fakebisshtunnel.FakeFactory
-		fakeSSHTunnel        *fakebisshtunnel.FakeTunnel
-		instanceFactory      Factory
-		logger               boshlog.Logger
-		fakeStage            *fakebiui.FakeStage
+		fakeVMManager          *fakebivm.FakeManager
+		mockVMManagerFactory   *mock_vm.MockManagerFactory
+		deploymentStateService biconfig.DeploymentStateService
+		agentProviderAddresses []string
+		fakeSSHTunnelFactory   *fakebisshtunnel.FakeFactory
+		fakeSSHTunnel          *fakebisshtunnel.FakeTunnel
+		instanceFactory        Factory
+		logger                 boshlog.Logger
+		fakeStage              *fakebiui.FakeStage
 
 		manager Manager
 	)
@@ -84,10 +93,23 @@ var _ = Describe("Manager", func() {
 
 		fakeStage = fakebiui.NewFakeStage()
 
+		mockVMManagerFactory = mock_vm.NewMockManagerFactory(mockCtrl)
+		deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fakesys.NewFakeFileSystem(), fakeuuid.NewFakeGenerator(), logger, "/deployment.json")
+
+		agentProviderAddresses = []string{}
+		agentProvider := func(address string) (biagentclient.AgentClient, biblobstore.Blobstore, error) {
+			agentProviderAddresses = append(agentProviderAddresses, address)
+			return nil, mockBlobstore, nil
+		}
+
 		manager = NewManager(
 			fakeCloud,
 			fakeVMManager,
 			mockBlobstore,
+			agentProvider,
+			mockVMManagerFactory,
+			biconfig.NewInstanceRepo(deploymentStateService),
+			biconfig.NewDeployProgressRepo(deploymentStateService),
 			fakeSSHTunnelFactory,
 			instanceFactory,
 			logger,
@@ -203,9 +225,65 @@ var _ = Describe("Manager", func() {
 			Expect(instance).To(Equal(expectedInstance))
 
 			Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+				JobName:  "fake-job-name",
+				Index:    0,
 				Stemcell: fakeCloudStemcell,
 				Manifest: deploymentManifest,
 			}))
+			Expect(agentProviderAddresses).To(BeEmpty())
+		})
+
+		Context("when creating an instance other than the bootstrap instance", func() {
+			var otherFakeVMManager *fakebivm.FakeManager
+
+			BeforeEach(func() {
+				deploymentManifest.Jobs[0].Instances = 2
+				deploymentManifest.Jobs[0].Networks = []bideplmanifest.JobNetwork{
+					{Name: "fake-network-name", StaticIPs: []string{"10.0.0.1", "10.0.0.2"}},
+				}
+
+				otherFakeVMManager = fakebivm.NewFakeManager()
+				otherFakeVMManager.CreateVM = fakeVM
+				mockVMManagerFactory.EXPECT().NewInstanceManager(fakeCloud, nil, "fake-job-name", 1, "10.0.0.2").Return(otherFakeVMManager)
+			})
+
+			It("creates the VM through the agent at the static ip of the instance", func() {
+				_, _, err := manager.Create(
+					"fake-job-name",
+					1,
+					deploymentManifest,
+					fakeCloudStemcell,
+					fakeStage,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(agentProviderAddresses).To(Equal([]string{"10.0.0.2"}))
+				Expect(otherFakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+					JobName:  "fake-job-name",
+					Index:    1,
+					Stemcell: fakeCloudStemcell,
+					Manifest: deploymentManifest,
+				}))
+				Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{}))
+			})
+		})
+
+		Context("when an instance other than the bootstrap instance has no static ip", func() {
+			BeforeEach(func() {
+				deploymentManifest.Jobs[0].Instances = 2
+			})
+
+			It("returns an error", func() {
+				_, _, err := manager.Create(
+					"fake-job-name",
+					1,
+					deploymentManifest,
+					fakeCloudStemcell,
+					fakeStage,
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Finding static ip of instance 'fake-job-name/1'"))
+			})
 		})
 
 		It("updates the current stemcell", func() {
@@ -311,4 +389,134 @@ var _ = Describe("Manager", func() {
 			})
 		})
 	})
+
+	Describe("FindCurrent", func() {
+		var otherFakeVMManager *fakebivm.FakeManager
+
+		BeforeEach(func() {
+			err := biconfig.NewInstanceVMRepo(deploymentStateService, "fake-job-name", 1, "10.0.0.2").UpdateCurrent("fake-other-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			otherFakeVMManager = fakebivm.NewFakeManager()
+			otherFakeVMManager.SetFindCurrentBehavior(fakebivm.NewFakeVM("fake-other-vm-cid"), true, nil)
+			mockVMManagerFactory.EXPECT().NewInstanceManager(fakeCloud, nil, "fake-job-name", 1, "10.0.0.2").Return(otherFakeVMManager)
+
+			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, gomock.Any()).Return(mockStateBuilder).AnyTimes()
+		})
+
+		It("returns the bootstrap instance followed by the other recorded instances", func() {
+			fakeVMManager.SetFindCurrentBehavior(fakebivm.NewFakeVM("fake-vm-cid"), true, nil)
+
+			instances, err := manager.FindCurrent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances).To(HaveLen(2))
+			Expect(instances[0].JobName()).To(Equal("unknown"))
+			Expect(instances[0].ID()).To(Equal(0))
+			Expect(instances[1].JobName()).To(Equal("fake-job-name"))
+			Expect(instances[1].ID()).To(Equal(1))
+			Expect(agentProviderAddresses).To(Equal([]string{"10.0.0.2"}))
+		})
+	})
+
+	Describe("Resume", func() {
+		var (
+			fakeVM             *fakebivm.FakeVM
+			deploymentManifest bideplmanifest.Manifest
+			progressRepo       biconfig.DeployProgressRepo
+		)
+
+		BeforeEach(func() {
+			deploymentManifest = bideplmanifest.Manifest{
+				Jobs: []bideplmanifest.Job{
+					{Name: "fake-job-name", Instances: 1},
+				},
+			}
+
+			fakeVM = fakebivm.NewFakeVM("fake-vm-cid")
+			fakeVMManager.SetFindCurrentBehavior(fakeVM, true, nil)
+
+			progressRepo = biconfig.NewDeployProgressRepo(deploymentStateService)
+			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, gomock.Any()).Return(mockStateBuilder).AnyTimes()
+		})
+
+		JustBeforeEach(func() {
+			Expect(progressRepo.Start("fake-manifest-sha")).To(Succeed())
+		})
+
+		It("deletes a recorded VM whose creation was not journaled", func() {
+			_, _, resumed, err := manager.Resume("fake-job-name", 0, deploymentManifest, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumed).To(BeFalse())
+			Expect(fakeVM.DeleteCalled).To(Equal(1))
+
+			Expect(fakeStage.PerformCalls[0].Name).To(Equal("Verifying VM 'fake-vm-cid' of instance 'fake-job-name/0'"))
+			Expect(fakeStage.PerformCalls[0].SkipError).To(HaveOccurred())
+		})
+
+		Context("when the VM was journaled as created", func() {
+			JustBeforeEach(func() {
+				Expect(progressRepo.MarkInstanceStep("fake-job-name", 0, biconfig.DeployStepVMCreated)).To(Succeed())
+			})
+
+			It("resumes the instance and attaches its disks", func() {
+				instance, _, resumed, err := manager.Resume("fake-job-name", 0, deploymentManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resumed).To(BeTrue())
+				Expect(instance.JobName()).To(Equal("fake-job-name"))
+				Expect(fakeVM.ExistsCalled).To(Equal(1))
+				Expect(fakeVM.UpdateDisksInputs).To(HaveLen(1))
+
+				progress, _, err := progressRepo.Find()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(progress.Instances[0].Completed(biconfig.DeployStepDisksAttached)).To(BeTrue())
+			})
+
+			It("does not resume when the VM no longer exists", func() {
+				fakeVM.ExistsFound = false
+
+				_, _, resumed, err := manager.Resume("fake-job-name", 0, deploymentManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resumed).To(BeFalse())
+				Expect(fakeVM.DeleteCalled).To(Equal(0))
+			})
+		})
+
+		It("does not resume when no VM is recorded", func() {
+			fakeVMManager.SetFindCurrentBehavior(nil, false, nil)
+
+			_, _, resumed, err := manager.Resume("fake-job-name", 0, deploymentManifest, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumed).To(BeFalse())
+		})
+	})
+
+	Describe("ForgetObsolete", func() {
+		BeforeEach(func() {
+			err := deploymentStateService.Save(biconfig.DeploymentState{
+				Instances: []biconfig.InstanceRecord{
+					{Name: "fake-job-name", Index: 1, IP: "10.0.0.2", DiskID: "fake-disk-id-1"},
+					{Name: "fake-job-name", Index: 2, IP: "10.0.0.3", DiskID: "fake-disk-id-2"},
+					{Name: "removed-job-name", Index: 0, IP: "10.0.0.4", DiskID: "fake-disk-id-3"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("forgets the instances that are no longer in the manifest", func() {
+			deploymentManifest := bideplmanifest.Manifest{
+				Jobs: []bideplmanifest.Job{
+					{Name: "fake-job-name", Instances: 2},
+				},
+			}
+
+			err := manager.ForgetObsolete(deploymentManifest)
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Instances).To(Equal([]biconfig.InstanceRecord{
+				{Name: "fake-job-name", Index: 1, IP: "10.0.0.2", DiskID: "fake-disk-id-1"},
+			}))
+		})
+	})
 })
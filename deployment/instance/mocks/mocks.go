@@ -234,3 +234,34 @@ func (mr *MockManagerMockRecorder) FindCurrent() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCurrent", reflect.TypeOf((*MockManager)(nil).FindCurrent))
 }
+
+// ForgetObsolete mocks base method.
+func (m *MockManager) ForgetObsolete(arg0 manifest.Manifest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgetObsolete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgetObsolete indicates an expected call of ForgetObsolete.
+func (mr *MockManagerMockRecorder) ForgetObsolete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetObsolete", reflect.TypeOf((*MockManager)(nil).ForgetObsolete), arg0)
+}
+
+// Resume mocks base method.
+func (m *MockManager) Resume(arg0 string, arg1 int, arg2 manifest.Manifest, arg3 ui.Stage) (instance.Instance, []disk.Disk, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(instance.Instance)
+	ret1, _ := ret[1].([]disk.Disk)
+	ret2, _ := ret[2].(bool)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockManagerMockRecorder) Resume(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockManager)(nil).Resume), arg0, arg1, arg2, arg3)
+}
@@ -11,7 +11,7 @@ import (
 )
 
 type ManagerFactory interface {
-	NewManager(bicloud.Cloud, biagentclient.AgentClient, biblobstore.Blobstore) Manager
+	NewManager(bicloud.Cloud, biagentclient.AgentClient, biblobstore.Blobstore, biinstance.AgentProvider) Manager
 }
 
 type managerFactory struct {
@@ -38,9 +38,9 @@ func NewManagerFactory(
 	}
 }
 
-func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, blobstore biblobstore.Blobstore) Manager {
+func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, blobstore biblobstore.Blobstore, agentProvider biinstance.AgentProvider) Manager {
 	vmManager := f.vmManagerFactory.NewManager(cloud, agentClient)
-	instanceManager := f.instanceManagerFactory.NewManager(cloud, vmManager, blobstore)
+	instanceManager := f.instanceManagerFactory.NewManager(cloud, vmManager, blobstore, agentProvider)
 	diskManager := f.diskManagerFactory.NewManager(cloud)
 	stemcellManager := f.stemcellManagerFactory.NewManager(cloud)
 
@@ -72,7 +72,7 @@ var _ = Describe("Manager", func() {
 
 		JustBeforeEach(func() {
 			mockInstanceManager.EXPECT().FindCurrent().Return(expectedInstances, nil)
-			mockDiskManager.EXPECT().FindCurrent().Return(expectedDisks, nil)
+			mockDiskManager.EXPECT().FindAllCurrent().Return(expectedDisks, nil)
 			mockStemcellManager.EXPECT().FindCurrent().Return(expectedStemcells, nil)
 
 			expectNewDeployment = mockDeploymentFactory.EXPECT().NewDeployment(expectedInstances, expectedDisks, expectedStemcells).Return(mockDeployment).AnyTimes()
@@ -188,19 +188,19 @@ var _ = Describe("Manager", func() {
 			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, logger)
 			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger, false)
 
-			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bivm.NewManagerFactory(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, false, fakeUUIDGenerator, fs, logger)
 			sshTunnelFactory := bisshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance_state.NewMockBuilderFactory(mockCtrl)
 
 			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
+			instanceManagerFactory := biinstance.NewManagerFactory(vmManagerFactory, biconfig.NewInstanceRepo(deploymentStateService), biconfig.NewDeployProgressRepo(deploymentStateService), sshTunnelFactory, instanceFactory, logger)
 			stemcellManagerFactory := bistemcell.NewManagerFactory(stemcellRepo)
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
 			deploymentManagerFactory := NewManagerFactory(vmManagerFactory, instanceManagerFactory, diskManagerFactory, stemcellManagerFactory, mockDeploymentFactory)
-			deploymentManager = deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore)
+			deploymentManager = deploymentManagerFactory.NewManager(mockCloud, mockAgentClient, mockBlobstore, nil)
 		})
 
 		Context("no orphan disk or stemcell records exist", func() {
@@ -24,6 +24,12 @@ type Update struct {
 // We can't use map[string]NetworkInterface, because it's impossible to down-cast to what the cloud client requires.
 // TODO: refactor to NetworkInterfaces(Job) and use FindJobByName before using (then remove error)
 func (d Manifest) NetworkInterfaces(jobName string) (map[string]biproperty.Map, error) {
+	return d.InstanceNetworkInterfaces(jobName, 0)
+}
+
+// InstanceNetworkInterfaces returns the network interfaces of a single instance of a job.
+// The instance with the given index is assigned the static IP with the same index.
+func (d Manifest) InstanceNetworkInterfaces(jobName string, index int) (map[string]biproperty.Map, error) {
 	job, found := d.FindJobByName(jobName)
 	if !found {
 		return map[string]biproperty.Map{}, bosherr.Errorf("Could not find job with name: %s", jobName)
@@ -35,7 +41,13 @@ func (d Manifest) NetworkInterfaces(jobName string) (map[string]biproperty.Map,
 	var err error
 	for _, jobNetwork := range job.Networks {
 		network := networkMap[jobNetwork.Name]
-		ifaceMap[jobNetwork.Name], err = network.Interface(jobNetwork.StaticIPs, jobNetwork.Defaults)
+
+		var staticIPs []string
+		if len(jobNetwork.StaticIPs) > index {
+			staticIPs = jobNetwork.StaticIPs[index : index+1]
+		}
+
+		ifaceMap[jobNetwork.Name], err = network.Interface(staticIPs, jobNetwork.Defaults)
 		if err != nil {
 			return map[string]biproperty.Map{}, bosherr.WrapError(err, "Building network interface")
 		}
@@ -47,11 +59,37 @@ func (d Manifest) NetworkInterfaces(jobName string) (map[string]biproperty.Map,
 	return ifaceMap, nil
 }
 
+// JobName returns the name of the first job. Its first instance is the
+// bootstrap instance, whose agent is reached through the installation mbus URL.
 func (d Manifest) JobName() string {
-	// Currently we deploy only one job
 	return d.Jobs[0].Name
 }
 
+// IsBootstrapInstance reports whether the instance is the first instance of the first job
+func (d Manifest) IsBootstrapInstance(jobName string, index int) bool {
+	return len(d.Jobs) > 0 && d.JobName() == jobName && index == 0
+}
+
+// InstanceAddress returns the static IP the agent of an instance is reachable at.
+// It is the IP with the same index as the instance on the first job network with static IPs.
+func (d Manifest) InstanceAddress(jobName string, index int) (string, bool) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return "", false
+	}
+
+	for _, jobNetwork := range job.Networks {
+		if len(jobNetwork.StaticIPs) > 0 {
+			if len(jobNetwork.StaticIPs) > index {
+				return jobNetwork.StaticIPs[index], true
+			}
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
 func (d Manifest) Stemcell(jobName string) (StemcellRef, error) {
 	resourcePool, err := d.ResourcePool(jobName)
 	if err != nil {
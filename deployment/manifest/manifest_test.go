@@ -186,6 +186,100 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("InstanceNetworkInterfaces", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Networks: []Network{
+					{
+						Name:            "fake-network-name",
+						Type:            "dynamic",
+						CloudProperties: biproperty.Map{},
+					},
+				},
+				Jobs: []Job{
+					{
+						Name:      "fake-job-name",
+						Instances: 2,
+						Networks: []JobNetwork{
+							{
+								Name:      "fake-network-name",
+								StaticIPs: []string{"5.6.7.8", "5.6.7.9"},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("assigns the static ip with the same index as the instance", func() {
+			Expect(deploymentManifest.InstanceNetworkInterfaces("fake-job-name", 1)).To(Equal(map[string]biproperty.Map{
+				"fake-network-name": biproperty.Map{
+					"type":             "dynamic",
+					"ip":               "5.6.7.9",
+					"cloud_properties": biproperty.Map{},
+					"default":          []NetworkDefault{"dns", "gateway"},
+				},
+			}))
+		})
+
+		It("omits the ip when there is no static ip for the instance", func() {
+			Expect(deploymentManifest.InstanceNetworkInterfaces("fake-job-name", 2)).To(Equal(map[string]biproperty.Map{
+				"fake-network-name": biproperty.Map{
+					"type":             "dynamic",
+					"cloud_properties": biproperty.Map{},
+					"default":          []NetworkDefault{"dns", "gateway"},
+				},
+			}))
+		})
+	})
+
+	Describe("InstanceAddress", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Jobs: []Job{
+					{
+						Name:      "fake-job-name",
+						Instances: 2,
+						Networks: []JobNetwork{
+							{Name: "fake-dynamic-network"},
+							{Name: "fake-manual-network", StaticIPs: []string{"10.0.0.5", "10.0.0.6"}},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns the static ip of the instance on the first network with static ips", func() {
+			address, found := deploymentManifest.InstanceAddress("fake-job-name", 1)
+			Expect(found).To(BeTrue())
+			Expect(address).To(Equal("10.0.0.6"))
+		})
+
+		It("returns false when there are not enough static ips", func() {
+			_, found := deploymentManifest.InstanceAddress("fake-job-name", 2)
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns false when the job does not exist", func() {
+			_, found := deploymentManifest.InstanceAddress("fake-unknown-job", 0)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("IsBootstrapInstance", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Jobs: []Job{{Name: "first-job"}, {Name: "second-job"}},
+			}
+		})
+
+		It("is true only for the first instance of the first job", func() {
+			Expect(deploymentManifest.IsBootstrapInstance("first-job", 0)).To(BeTrue())
+			Expect(deploymentManifest.IsBootstrapInstance("first-job", 1)).To(BeFalse())
+			Expect(deploymentManifest.IsBootstrapInstance("second-job", 0)).To(BeFalse())
+		})
+	})
+
 	Describe("ResourcePool", func() {
 		BeforeEach(func() {
 			deploymentManifest = Manifest{
@@ -71,14 +71,14 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 		}
 	}
 
-	if len(deploymentManifest.Jobs) > 1 {
-		errs = append(errs, bosherr.Error("jobs must be of size 1"))
-	}
-
+	jobNames := map[string]struct{}{}
 	for idx, job := range deploymentManifest.Jobs {
 		if v.isBlank(job.Name) {
 			errs = append(errs, bosherr.Errorf("jobs[%d].name must be provided", idx))
+		} else if _, found := jobNames[job.Name]; found {
+			errs = append(errs, bosherr.Errorf("jobs[%d].name '%s' must be unique", idx, job.Name))
 		}
+		jobNames[job.Name] = struct{}{}
 		if job.PersistentDisk < 0 {
 			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk must be >= 0", idx))
 		}
@@ -102,6 +102,11 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 		}
 
 		errs = append(errs, v.validateJobNetworks(job.Networks, deploymentManifest.Networks, idx)...)
+		errs = append(errs, v.validateInstanceAddresses(job, idx)...)
+
+		if idx > 0 {
+			errs = append(errs, v.validateSameStemcell(deploymentManifest, job, idx)...)
+		}
 
 		if job.Lifecycle != "" && job.Lifecycle != JobLifecycleService {
 			errs = append(errs, bosherr.Errorf("jobs[%d].lifecycle must be 'service' ('%s' not supported)", idx, job.Lifecycle))
@@ -319,6 +324,43 @@ func (v *validator) validateJobNetworks(jobNetworks []JobNetwork, networks []Net
 	return errs
 }
 
+// validateInstanceAddresses checks that the agent of every instance except the
+// bootstrap instance can be reached at a static IP
+func (v *validator) validateInstanceAddresses(job Job, jobIdx int) []error {
+	if job.Instances == 0 || (jobIdx == 0 && job.Instances == 1) {
+		return []error{}
+	}
+
+	for _, jobNetwork := range job.Networks {
+		if len(jobNetwork.StaticIPs) > 0 {
+			if len(jobNetwork.StaticIPs) < job.Instances {
+				return []error{bosherr.Errorf("jobs[%d].networks must provide a static ip for each of the %d instances", jobIdx, job.Instances)}
+			}
+			return []error{}
+		}
+	}
+
+	return []error{bosherr.Errorf("jobs[%d].networks must provide a static ip for each of the %d instances", jobIdx, job.Instances)}
+}
+
+func (v *validator) validateSameStemcell(deploymentManifest Manifest, job Job, jobIdx int) []error {
+	firstResourcePool, err := deploymentManifest.ResourcePool(deploymentManifest.JobName())
+	if err != nil {
+		return []error{}
+	}
+
+	resourcePool, err := deploymentManifest.ResourcePool(job.Name)
+	if err != nil {
+		return []error{}
+	}
+
+	if resourcePool.Stemcell != firstResourcePool.Stemcell {
+		return []error{bosherr.Errorf("jobs[%d].resource_pool must use the same stemcell as jobs[0]", jobIdx)}
+	}
+
+	return []error{}
+}
+
 func (v *validator) validateStaticIP(ip string, jobNetwork JobNetwork, network Network, jobIdx, networkIdx, ipIdx int) []error {
 	if !v.isValidIP(ip) {
 		return []error{bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] must be a valid IP", jobIdx, networkIdx, ipIdx)}
@@ -572,17 +572,86 @@ var _ = Describe("Validator", func() {
 			})
 		})
 
-		It("validates that there is only one job", func() {
+		It("allows multiple jobs", func() {
+			validManifest.Jobs = append(validManifest.Jobs, Job{
+				Name:         "fake-other-job-name",
+				Instances:    1,
+				ResourcePool: "fake-resource-pool-name",
+				Networks: []JobNetwork{
+					{
+						Name:      "fake-network-name",
+						StaticIPs: []string{"10.10.0.42"},
+					},
+				},
+			})
+
+			err := validator.Validate(validManifest, validReleaseSetManifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates job names are unique", func() {
+			deploymentManifest := Manifest{
+				Jobs: []Job{
+					{Name: "fake-job-name"},
+					{Name: "fake-job-name"},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[1].name 'fake-job-name' must be unique"))
+		})
+
+		It("validates that every instance but the first one has a static ip", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{
-					{},
-					{},
+					{
+						Name:      "fake-job-name",
+						Instances: 3,
+						Networks: []JobNetwork{
+							{
+								Name:      "fake-network-name",
+								StaticIPs: []string{"10.10.0.42", "10.10.0.43"},
+							},
+						},
+					},
+					{
+						Name:      "fake-other-job-name",
+						Instances: 1,
+						Networks: []JobNetwork{
+							{Name: "fake-network-name"},
+						},
+					},
 				},
 			}
 
 			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("jobs must be of size 1"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].networks must provide a static ip for each of the 3 instances"))
+			Expect(err.Error()).To(ContainSubstring("jobs[1].networks must provide a static ip for each of the 1 instances"))
+		})
+
+		It("validates that all jobs use the same stemcell", func() {
+			validManifest.ResourcePools = append(validManifest.ResourcePools, ResourcePool{
+				Name:     "fake-other-resource-pool-name",
+				Network:  "fake-network-name",
+				Stemcell: StemcellRef{URL: "file://fake-other-stemcell-url"},
+			})
+			validManifest.Jobs = append(validManifest.Jobs, Job{
+				Name:         "fake-other-job-name",
+				Instances:    1,
+				ResourcePool: "fake-other-resource-pool-name",
+				Networks: []JobNetwork{
+					{
+						Name:      "fake-network-name",
+						StaticIPs: []string{"10.10.0.42"},
+					},
+				},
+			})
+
+			err := validator.Validate(validManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[1].resource_pool must use the same stemcell as jobs[0]"))
 		})
 
 		It("validates job name", func() {
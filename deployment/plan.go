@@ -0,0 +1,250 @@
+package deployment
+
+import (
+	"fmt"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/v7/deployment/manifest"
+	birel "github.com/cloudfoundry/bosh-cli/v7/release"
+	bistemcell "github.com/cloudfoundry/bosh-cli/v7/stemcell"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Change is a single change that deploying would make to the environment.
+// Details hold the previous (-) and new (+) values behind the change.
+type Change struct {
+	Description string
+	Details     []string
+}
+
+// Plan lists the changes that deploying a manifest would make to the
+// environment recorded in a deployment state. Building a plan does not talk to the CPI.
+type Plan struct {
+	Changes []Change
+}
+
+func (p Plan) HasChanges() bool {
+	return len(p.Changes) > 0
+}
+
+func NewPlan(
+	deploymentState biconfig.DeploymentState,
+	manifestSHA string,
+	deploymentManifest bideplmanifest.Manifest,
+	releases []birel.Release,
+	stemcell bistemcell.ExtractedStemcell,
+	recreate bool,
+	recreatePersistentDisks bool,
+) (Plan, error) {
+	plan := Plan{}
+
+	manifestChanged := deploymentState.CurrentManifestSHA != manifestSHA
+	if manifestChanged {
+		plan.add(Change{
+			Description: "Manifest has changed",
+			Details:     diffDetails("manifest sha", deploymentState.CurrentManifestSHA, manifestSHA),
+		})
+	}
+
+	stemcellChanged := stemcellHasChanged(deploymentState, stemcell)
+	if stemcellChanged {
+		plan.add(Change{
+			Description: fmt.Sprintf("Stemcell '%s/%s' will be uploaded", stemcell.Manifest().Name, stemcell.Manifest().Version),
+			Details:     diffDetails("stemcell", currentStemcellName(deploymentState), stemcellName(stemcell.Manifest().Name, stemcell.Manifest().Version)),
+		})
+	}
+
+	releasesChanged := releasesHaveChanged(deploymentState, releases)
+	if releasesChanged {
+		plan.add(Change{
+			Description: "Releases will be recompiled",
+			Details:     releaseDetails(deploymentState, releases),
+		})
+	}
+
+	if !manifestChanged && !stemcellChanged && !releasesChanged && !recreate && !recreatePersistentDisks {
+		return Plan{}, nil
+	}
+
+	if err := plan.addInstanceChanges(deploymentState, deploymentManifest, recreatePersistentDisks); err != nil {
+		return Plan{}, err
+	}
+
+	return plan, nil
+}
+
+func (p *Plan) add(change Change) {
+	p.Changes = append(p.Changes, change)
+}
+
+func (p *Plan) addInstanceChanges(deploymentState biconfig.DeploymentState, deploymentManifest bideplmanifest.Manifest, recreatePersistentDisks bool) error {
+	for _, job := range deploymentManifest.Jobs {
+		diskPool, err := deploymentManifest.DiskPool(job.Name)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Finding disk pool of job '%s'", job.Name)
+		}
+
+		for index := 0; index < job.Instances; index++ {
+			instanceName := fmt.Sprintf("%s/%d", job.Name, index)
+			vmCID, diskID := currentInstanceResources(deploymentState, deploymentManifest, job.Name, index)
+
+			if vmCID != "" {
+				p.add(Change{Description: fmt.Sprintf("VM '%s' of instance '%s' will be recreated", vmCID, instanceName)})
+			} else {
+				p.add(Change{Description: fmt.Sprintf("VM of instance '%s' will be created", instanceName)})
+			}
+
+			if diskPool.DiskSize == 0 {
+				continue
+			}
+
+			diskRecord, found := findDiskRecord(deploymentState, diskID)
+			if !found {
+				p.add(Change{
+					Description: fmt.Sprintf("Disk of instance '%s' will be created", instanceName),
+					Details:     []string{fmt.Sprintf("+ disk size: %d", diskPool.DiskSize)},
+				})
+				continue
+			}
+
+			disk := bidisk.NewDisk(diskRecord, nil, nil)
+			needsMigration, err := disk.NeedsMigration(diskPool.DiskSize, diskPool.CloudProperties)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Comparing disk of instance '%s'", instanceName)
+			}
+
+			if recreatePersistentDisks || needsMigration {
+				details := diffDetails("disk size", fmt.Sprintf("%d", diskRecord.Size), fmt.Sprintf("%d", diskPool.DiskSize))
+				details = append(details, diffDetails("disk cloud_properties", fmt.Sprintf("%v", diskRecord.CloudProperties), fmt.Sprintf("%v", diskPool.CloudProperties))...)
+				p.add(Change{
+					Description: fmt.Sprintf("Disk '%s' of instance '%s' will be migrated", diskRecord.CID, instanceName),
+					Details:     details,
+				})
+			}
+		}
+	}
+
+	for _, record := range deploymentState.Instances {
+		job, found := deploymentManifest.FindJobByName(record.Name)
+		if found && record.Index < job.Instances {
+			continue
+		}
+		p.add(Change{Description: fmt.Sprintf("Instance '%s/%d' will be deleted", record.Name, record.Index)})
+	}
+
+	return nil
+}
+
+func currentInstanceResources(deploymentState biconfig.DeploymentState, deploymentManifest bideplmanifest.Manifest, jobName string, index int) (string, string) {
+	if deploymentManifest.IsBootstrapInstance(jobName, index) {
+		return deploymentState.CurrentVMCID, deploymentState.CurrentDiskID
+	}
+
+	for _, record := range deploymentState.Instances {
+		if record.Name == jobName && record.Index == index {
+			return record.VMCID, record.DiskID
+		}
+	}
+
+	return "", ""
+}
+
+func findDiskRecord(deploymentState biconfig.DeploymentState, diskID string) (biconfig.DiskRecord, bool) {
+	if diskID == "" {
+		return biconfig.DiskRecord{}, false
+	}
+
+	for _, record := range deploymentState.Disks {
+		if record.ID == diskID {
+			return record, true
+		}
+	}
+
+	return biconfig.DiskRecord{}, false
+}
+
+func stemcellHasChanged(deploymentState biconfig.DeploymentState, stemcell bistemcell.ExtractedStemcell) bool {
+	for _, record := range deploymentState.Stemcells {
+		if record.ID == deploymentState.CurrentStemcellID {
+			return record.Name != stemcell.Manifest().Name || record.Version != stemcell.Manifest().Version
+		}
+	}
+	return true
+}
+
+func currentStemcellName(deploymentState biconfig.DeploymentState) string {
+	for _, record := range deploymentState.Stemcells {
+		if record.ID == deploymentState.CurrentStemcellID {
+			return stemcellName(record.Name, record.Version)
+		}
+	}
+	return ""
+}
+
+func stemcellName(name, version string) string {
+	return fmt.Sprintf("%s/%s", name, version)
+}
+
+func releasesHaveChanged(deploymentState biconfig.DeploymentState, releases []birel.Release) bool {
+	if len(deploymentState.Releases) == 0 || len(deploymentState.Releases) != len(releases) {
+		return true
+	}
+
+	for _, release := range releases {
+		if !releaseIsRecorded(deploymentState, release.Name(), release.Version()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func releaseIsRecorded(deploymentState biconfig.DeploymentState, name, version string) bool {
+	for _, record := range deploymentState.Releases {
+		if record.Name == name && record.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func releaseDetails(deploymentState biconfig.DeploymentState, releases []birel.Release) []string {
+	details := []string{}
+
+	for _, record := range deploymentState.Releases {
+		found := false
+		for _, release := range releases {
+			if record.Name == release.Name() && record.Version == release.Version() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			details = append(details, fmt.Sprintf("- release: %s/%s", record.Name, record.Version))
+		}
+	}
+
+	for _, release := range releases {
+		if !releaseIsRecorded(deploymentState, release.Name(), release.Version()) {
+			details = append(details, fmt.Sprintf("+ release: %s/%s", release.Name(), release.Version()))
+		}
+	}
+
+	return details
+}
+
+func diffDetails(name, oldValue, newValue string) []string {
+	if oldValue == newValue {
+		return []string{}
+	}
+
+	details := []string{}
+	if oldValue != "" {
+		details = append(details, fmt.Sprintf("- %s: %s", name, oldValue))
+	}
+	if newValue != "" {
+		details = append(details, fmt.Sprintf("+ %s: %s", name, newValue))
+	}
+	return details
+}
@@ -0,0 +1,150 @@
+package deployment_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/v7/deployment"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/v7/deployment/manifest"
+	birel "github.com/cloudfoundry/bosh-cli/v7/release"
+	fakerel "github.com/cloudfoundry/bosh-cli/v7/release/releasefakes"
+	bistemcell "github.com/cloudfoundry/bosh-cli/v7/stemcell"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("NewPlan", func() {
+	var (
+		deploymentState    biconfig.DeploymentState
+		deploymentManifest bideplmanifest.Manifest
+		releases           []birel.Release
+		stemcell           bistemcell.ExtractedStemcell
+		recreate           bool
+		recreateDisks      bool
+	)
+
+	BeforeEach(func() {
+		release := &fakerel.FakeRelease{}
+		release.NameReturns("fake-release-name")
+		release.VersionReturns("1.0")
+		releases = []birel.Release{release}
+
+		stemcell = bistemcell.NewExtractedStemcell(
+			bistemcell.Manifest{Name: "fake-stemcell-name", Version: "2.0"},
+			"fake-extracted-path",
+			nil,
+			fakesys.NewFakeFileSystem(),
+		)
+
+		deploymentManifest = bideplmanifest.Manifest{
+			DiskPools: []bideplmanifest.DiskPool{
+				{Name: "fake-disk-pool-name", DiskSize: 1024, CloudProperties: biproperty.Map{}},
+			},
+			Jobs: []bideplmanifest.Job{
+				{
+					Name:               "fake-job-name",
+					Instances:          1,
+					PersistentDiskPool: "fake-disk-pool-name",
+				},
+			},
+		}
+
+		deploymentState = biconfig.DeploymentState{
+			CurrentVMCID:       "fake-vm-cid",
+			CurrentDiskID:      "fake-disk-id",
+			CurrentStemcellID:  "fake-stemcell-id",
+			CurrentManifestSHA: "fake-manifest-sha",
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid", Size: 1024, CloudProperties: biproperty.Map{}},
+			},
+			Stemcells: []biconfig.StemcellRecord{
+				{ID: "fake-stemcell-id", Name: "fake-stemcell-name", Version: "2.0"},
+			},
+			Releases: []biconfig.ReleaseRecord{
+				{ID: "fake-release-id", Name: "fake-release-name", Version: "1.0"},
+			},
+		}
+
+		recreate = false
+		recreateDisks = false
+	})
+
+	newPlan := func(manifestSHA string) Plan {
+		plan, err := NewPlan(deploymentState, manifestSHA, deploymentManifest, releases, stemcell, recreate, recreateDisks)
+		Expect(err).ToNot(HaveOccurred())
+		return plan
+	}
+
+	It("has no changes when nothing has changed", func() {
+		plan := newPlan("fake-manifest-sha")
+		Expect(plan.HasChanges()).To(BeFalse())
+	})
+
+	It("recreates the vm when the manifest has changed", func() {
+		plan := newPlan("new-manifest-sha")
+		Expect(plan.Changes).To(Equal([]Change{
+			{
+				Description: "Manifest has changed",
+				Details:     []string{"- manifest sha: fake-manifest-sha", "+ manifest sha: new-manifest-sha"},
+			},
+			{Description: "VM 'fake-vm-cid' of instance 'fake-job-name/0' will be recreated"},
+		}))
+	})
+
+	It("reports a stemcell change", func() {
+		deploymentState.Stemcells[0].Version = "1.0"
+
+		plan := newPlan("fake-manifest-sha")
+		Expect(plan.Changes[0]).To(Equal(Change{
+			Description: "Stemcell 'fake-stemcell-name/2.0' will be uploaded",
+			Details:     []string{"- stemcell: fake-stemcell-name/1.0", "+ stemcell: fake-stemcell-name/2.0"},
+		}))
+	})
+
+	It("reports release changes", func() {
+		deploymentState.Releases[0].Version = "0.9"
+
+		plan := newPlan("fake-manifest-sha")
+		Expect(plan.Changes[0]).To(Equal(Change{
+			Description: "Releases will be recompiled",
+			Details:     []string{"- release: fake-release-name/0.9", "+ release: fake-release-name/1.0"},
+		}))
+	})
+
+	It("reports a disk migration when the disk size has changed", func() {
+		deploymentManifest.DiskPools[0].DiskSize = 2048
+
+		plan := newPlan("new-manifest-sha")
+		Expect(plan.Changes).To(ContainElement(Change{
+			Description: "Disk 'fake-disk-cid' of instance 'fake-job-name/0' will be migrated",
+			Details:     []string{"- disk size: 1024", "+ disk size: 2048"},
+		}))
+	})
+
+	It("reports a disk migration when persistent disks are recreated", func() {
+		recreateDisks = true
+
+		plan := newPlan("fake-manifest-sha")
+		Expect(plan.Changes).To(Equal([]Change{
+			{Description: "VM 'fake-vm-cid' of instance 'fake-job-name/0' will be recreated"},
+			{Description: "Disk 'fake-disk-cid' of instance 'fake-job-name/0' will be migrated", Details: []string{}},
+		}))
+	})
+
+	It("reports new and deleted instances", func() {
+		deploymentState.Instances = []biconfig.InstanceRecord{
+			{Name: "removed-job-name", Index: 0, VMCID: "fake-removed-vm-cid"},
+		}
+		deploymentManifest.Jobs[0].Instances = 2
+		recreate = true
+
+		plan := newPlan("fake-manifest-sha")
+		Expect(plan.Changes).To(Equal([]Change{
+			{Description: "VM 'fake-vm-cid' of instance 'fake-job-name/0' will be recreated"},
+			{Description: "VM of instance 'fake-job-name/1' will be created"},
+			{Description: "Disk of instance 'fake-job-name/1' will be created", Details: []string{"+ disk size: 1024"}},
+			{Description: "Instance 'removed-job-name/0' will be deleted"},
+		}))
+	})
+})
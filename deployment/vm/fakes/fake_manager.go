@@ -7,6 +7,8 @@ import (
 )
 
 type CreateInput struct {
+	JobName  string
+	Index    int
 	Stemcell bistemcell.CloudStemcell
 	Manifest bideplmanifest.Manifest
 }
@@ -33,8 +35,10 @@ func (m *FakeManager) FindCurrent() (bivm.VM, bool, error) {
 	return m.findCurrentBehaviour.vm, m.findCurrentBehaviour.found, m.findCurrentBehaviour.err
 }
 
-func (m *FakeManager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (bivm.VM, error) {
+func (m *FakeManager) Create(jobName string, index int, stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (bivm.VM, error) {
 	input := CreateInput{
+		JobName:  jobName,
+		Index:    index,
 		Stemcell: stemcell,
 		Manifest: deploymentManifest,
 	}
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -19,7 +20,7 @@ import (
 
 type Manager interface {
 	FindCurrent() (VM, bool, error)
-	Create(bistemcell.CloudStemcell, bideplmanifest.Manifest) (VM, error)
+	Create(jobName string, index int, stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (VM, error)
 }
 
 type manager struct {
@@ -85,9 +86,8 @@ func (m *manager) FindCurrent() (VM, bool, error) {
 	return vm, true, err
 }
 
-func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (VM, error) {
-	jobName := deploymentManifest.JobName()
-	networkInterfaces, err := deploymentManifest.NetworkInterfaces(jobName)
+func (m *manager) Create(jobName string, index int, stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (VM, error) {
+	networkInterfaces, err := deploymentManifest.InstanceNetworkInterfaces(jobName, index)
 	m.logger.Debug(m.logTag, "Creating VM with network interfaces: %#v", networkInterfaces)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Getting network spec")
@@ -119,11 +119,11 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 
 	metadata := bicloud.VMMetadata{
 		"deployment":     deploymentManifest.Name,
-		"job":            deploymentManifest.JobName(),
-		"instance_group": deploymentManifest.JobName(),
-		"index":          "0",
+		"job":            jobName,
+		"instance_group": jobName,
+		"index":          strconv.Itoa(index),
 		"director":       "bosh-init",
-		"name":           fmt.Sprintf("%s/%d", deploymentManifest.JobName(), 0),
+		"name":           fmt.Sprintf("%s/%d", jobName, index),
 		"created_at":     m.timeService.Now().Format(time.RFC3339),
 	}
 
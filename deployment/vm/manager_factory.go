@@ -5,6 +5,7 @@ import (
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	bicloud "github.com/cloudfoundry/bosh-cli/v7/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/v7/config"
+	bidisk "github.com/cloudfoundry/bosh-cli/v7/deployment/disk"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -12,32 +13,39 @@ import (
 
 type ManagerFactory interface {
 	NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient) Manager
+	NewInstanceManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, jobName string, index int, address string) Manager
 }
 
 type managerFactory struct {
-	vmRepo        biconfig.VMRepo
-	stemcellRepo  biconfig.StemcellRepo
-	diskDeployer  DiskDeployer
-	uuidGenerator boshuuid.Generator
-	fs            boshsys.FileSystem
-	logger        boshlog.Logger
+	deploymentStateService  biconfig.DeploymentStateService
+	vmRepo                  biconfig.VMRepo
+	stemcellRepo            biconfig.StemcellRepo
+	diskDeployer            DiskDeployer
+	recreatePersistentDisks bool
+	uuidGenerator           boshuuid.Generator
+	fs                      boshsys.FileSystem
+	logger                  boshlog.Logger
 }
 
 func NewManagerFactory(
+	deploymentStateService biconfig.DeploymentStateService,
 	vmRepo biconfig.VMRepo,
 	stemcellRepo biconfig.StemcellRepo,
 	diskDeployer DiskDeployer,
+	recreatePersistentDisks bool,
 	uuidGenerator boshuuid.Generator,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
-		vmRepo:        vmRepo,
-		stemcellRepo:  stemcellRepo,
-		diskDeployer:  diskDeployer,
-		uuidGenerator: uuidGenerator,
-		fs:            fs,
-		logger:        logger,
+		deploymentStateService:  deploymentStateService,
+		vmRepo:                  vmRepo,
+		stemcellRepo:            stemcellRepo,
+		diskDeployer:            diskDeployer,
+		recreatePersistentDisks: recreatePersistentDisks,
+		uuidGenerator:           uuidGenerator,
+		fs:                      fs,
+		logger:                  logger,
 	}
 }
 
@@ -54,3 +62,23 @@ func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclie
 		clock.NewClock(),
 	)
 }
+
+// NewInstanceManager returns a manager for an instance other than the bootstrap
+// instance. Its VM and disk are recorded in the instances section of the deployment state.
+func (f *managerFactory) NewInstanceManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, jobName string, index int, address string) Manager {
+	vmRepo := biconfig.NewInstanceVMRepo(f.deploymentStateService, jobName, index, address)
+	diskRepo := biconfig.NewInstanceDiskRepo(f.deploymentStateService, f.uuidGenerator, jobName, index, address)
+	diskDeployer := NewDiskDeployer(bidisk.NewManagerFactory(diskRepo, f.logger), diskRepo, f.logger, f.recreatePersistentDisks)
+
+	return NewManager(
+		vmRepo,
+		f.stemcellRepo,
+		diskDeployer,
+		agentClient,
+		cloud,
+		f.uuidGenerator,
+		f.fs,
+		f.logger,
+		clock.NewClock(),
+	)
+}
@@ -124,7 +124,7 @@ var _ = Describe("Manager", func() {
 
 	Describe("Create", func() {
 		It("creates a VM", func() {
-			vm, err := manager.Create(stemcell, deploymentManifest)
+			vm, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 			expectedVM := NewVMWithMetadata(
 				"fake-vm-cid",
@@ -160,7 +160,7 @@ var _ = Describe("Manager", func() {
 		})
 
 		It("sets the vm metadata", func() {
-			_, err := manager.Create(stemcell, deploymentManifest)
+			_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(fakeCloud.SetVMMetadataCid).To(Equal("fake-vm-cid"))
 			Expect(fakeCloud.SetVMMetadataMetadata).To(Equal(cloud.VMMetadata{
@@ -174,6 +174,22 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		Context("when creating a later instance of the job", func() {
+			BeforeEach(func() {
+				deploymentManifest.Jobs[0].Instances = 2
+				deploymentManifest.Jobs[0].Networks[0].StaticIPs = []string{"fake-ip", "fake-ip-2"}
+			})
+
+			It("uses the static ip and index of that instance", func() {
+				_, err := manager.Create("fake-job", 1, stemcell, deploymentManifest)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.CreateVMInput.NetworksInterfaces["fake-network-name"]["ip"]).To(Equal("fake-ip-2"))
+				Expect(fakeCloud.SetVMMetadataMetadata["index"]).To(Equal("1"))
+				Expect(fakeCloud.SetVMMetadataMetadata["name"]).To(Equal("fake-job/1"))
+			})
+		})
+
 		Context("deployment-configured tags", func() {
 			It("sets additional tags on vms", func() {
 				deploymentManifest.Tags = map[string]string{
@@ -191,7 +207,7 @@ var _ = Describe("Manager", func() {
 					},
 				}
 
-				_, err := manager.Create(stemcell, deploymentManifest)
+				_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeCloud.CreateVMInput).To(Equal(
@@ -228,7 +244,7 @@ var _ = Describe("Manager", func() {
 						"name":           "awesome-name",
 					}
 
-					_, err := manager.Create(stemcell, deploymentManifest)
+					_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 					Expect(err).ToNot(HaveOccurred())
 
 					Expect(fakeCloud.SetVMMetadataMetadata).To(Equal(cloud.VMMetadata{
@@ -245,7 +261,7 @@ var _ = Describe("Manager", func() {
 		})
 
 		It("updates the current vm record", func() {
-			_, err := manager.Create(stemcell, deploymentManifest)
+			_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(fakeVMRepo.UpdateCurrentCID).To(Equal("fake-vm-cid"))
@@ -257,13 +273,13 @@ var _ = Describe("Manager", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := manager.Create(stemcell, deploymentManifest)
+				_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-set-metadata-error"))
 			})
 
 			It("still updates the current vm record", func() {
-				_, err := manager.Create(stemcell, deploymentManifest)
+				_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 				Expect(err).To(HaveOccurred())
 				Expect(fakeVMRepo.UpdateCurrentCID).To(Equal("fake-vm-cid"))
 			})
@@ -276,7 +292,7 @@ var _ = Describe("Manager", func() {
 				})
 				fakeCloud.SetVMMetadataError = notImplementedCloudError
 
-				_, err := manager.Create(stemcell, deploymentManifest)
+				_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
@@ -287,7 +303,7 @@ var _ = Describe("Manager", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := manager.Create(stemcell, deploymentManifest)
+				_, err := manager.Create("fake-job", 0, stemcell, deploymentManifest)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-error"))
 			})
@@ -36,6 +36,20 @@ func (m *MockManagerFactory) EXPECT() *MockManagerFactoryMockRecorder {
 	return m.recorder
 }
 
+// NewInstanceManager mocks base method.
+func (m *MockManagerFactory) NewInstanceManager(arg0 cloud.Cloud, arg1 agentclient.AgentClient, arg2 string, arg3 int, arg4 string) vm.Manager {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewInstanceManager", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(vm.Manager)
+	return ret0
+}
+
+// NewInstanceManager indicates an expected call of NewInstanceManager.
+func (mr *MockManagerFactoryMockRecorder) NewInstanceManager(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewInstanceManager", reflect.TypeOf((*MockManagerFactory)(nil).NewInstanceManager), arg0, arg1, arg2, arg3, arg4)
+}
+
 // NewManager mocks base method.
 func (m *MockManagerFactory) NewManager(arg0 cloud.Cloud, arg1 agentclient.AgentClient) vm.Manager {
 	m.ctrl.T.Helper()
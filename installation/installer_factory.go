@@ -32,6 +32,8 @@ type installerFactory struct {
 	logTag                 string
 	fs                     boshsys.FileSystem
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	compiledPackageCache   biinstallpkg.CompiledPackageCache
+	parallel               int
 }
 
 func NewInstallerFactory(
@@ -43,6 +45,8 @@ func NewInstallerFactory(
 	logger boshlog.Logger,
 	fs boshsys.FileSystem,
 	digestCreateAlgorithms []boshcrypto.Algorithm,
+	compiledPackageCache biinstallpkg.CompiledPackageCache,
+	parallel int,
 ) InstallerFactory {
 	return &installerFactory{
 		ui:                     ui,
@@ -54,6 +58,8 @@ func NewInstallerFactory(
 		logTag:                 "installer",
 		fs:                     fs,
 		digestCreateAlgorithms: digestCreateAlgorithms,
+		compiledPackageCache:   compiledPackageCache,
+		parallel:               parallel,
 	}
 }
 
@@ -67,6 +73,8 @@ func (f *installerFactory) NewInstaller(target Target) Installer {
 		releaseJobResolver:     f.releaseJobResolver,
 		fs:                     f.fs,
 		digestCreateAlgorithms: f.digestCreateAlgorithms,
+		compiledPackageCache:   f.compiledPackageCache,
+		parallel:               f.parallel,
 	}
 
 	return NewInstaller(
@@ -88,6 +96,9 @@ type installerFactoryContext struct {
 	uuidGenerator      boshuuid.Generator
 	releaseJobResolver bideplrel.JobResolver
 
+	compiledPackageCache biinstallpkg.CompiledPackageCache
+	parallel             int
+
 	jobDependencyCompiler  bistatejob.DependencyCompiler
 	packageCompiler        bistatepkg.Compiler
 	blobstore              boshblob.DigestBlobstore
@@ -98,7 +109,7 @@ type installerFactoryContext struct {
 
 func (c *installerFactoryContext) JobRenderer() JobRenderer {
 
-	erbRenderer := bierbrenderer.NewERBRenderer(c.fs, c.runner, c.logger)
+	erbRenderer := bierbrenderer.NewNativeERBRenderer(c.fs, bierbrenderer.NewERBRenderer(c.fs, c.runner, c.logger), c.logger)
 	jobRenderer := bitemplate.NewJobRenderer(erbRenderer, c.fs, c.uuidGenerator, c.logger)
 	jobListRenderer := bitemplate.NewJobListRenderer(jobRenderer, c.logger)
 
@@ -125,8 +136,9 @@ func (c *installerFactoryContext) JobDependencyCompiler() bistatejob.DependencyC
 		return c.jobDependencyCompiler
 	}
 
-	c.jobDependencyCompiler = bistatejob.NewDependencyCompiler(
+	c.jobDependencyCompiler = bistatejob.NewParallelDependencyCompiler(
 		c.InstallationStatePackageCompiler(),
+		c.parallel,
 		c.logger,
 	)
 
@@ -146,6 +158,7 @@ func (c *installerFactoryContext) InstallationStatePackageCompiler() bistatepkg.
 		c.Blobstore(),
 		c.CompiledPackageRepo(),
 		c.BlobExtractor(),
+		c.compiledPackageCache,
 		c.logger,
 	)
 
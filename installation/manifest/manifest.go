@@ -10,6 +10,18 @@ type Manifest struct {
 	Properties biproperty.Map
 	Mbus       string
 	Cert       Certificate
+	FakeCPI    FakeCPI
+}
+
+// FakeCPI selects the in-process fake CPI instead of a CPI release. The fake
+// CPI keeps its stemcells, VMs and disks, and the state of the fake agents
+// running on its VMs, in Dir.
+type FakeCPI struct {
+	Dir string
+}
+
+func (m Manifest) UsesFakeCPI() bool {
+	return m.FakeCPI.Dir != ""
 }
 
 type Certificate struct {
@@ -57,6 +57,16 @@ var _ = Describe("Validator", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("does not require a CPI release when the fake CPI is selected", func() {
+			manifest := Manifest{
+				Name:    "fake-installation-name",
+				FakeCPI: FakeCPI{Dir: "/fake-cpi-dir"},
+			}
+
+			err := validator.Validate(manifest, birelsetmanifest.Manifest{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
 		It("validates template must be fully specified", func() {
 			manifest := Manifest{}
 
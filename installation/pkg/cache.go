@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	birelpkg "github.com/cloudfoundry/bosh-cli/v7/release/pkg"
+)
+
+// CompiledPackageCache keeps compiled package tarballs so that they can be
+// reused by every installation on this host instead of being compiled again.
+// Tarballs are kept per packages dir since compiled packages may refer to
+// the absolute paths they were compiled into.
+type CompiledPackageCache interface {
+	Get(pkg birelpkg.Compilable, packagesDir string) (tarballPath string, found bool)
+	Put(pkg birelpkg.Compilable, packagesDir string, tarballPath string) error
+}
+
+type compiledPackageCache struct {
+	dir    string
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewCompiledPackageCache stores tarballs in dir, named after a digest of the
+// fingerprints of the package and all of its dependencies, of the packages dir
+// and of the host OS and architecture, since a package compiled elsewhere may not run here.
+func NewCompiledPackageCache(dir string, fs boshsys.FileSystem, logger boshlog.Logger) CompiledPackageCache {
+	return compiledPackageCache{
+		dir:    dir,
+		fs:     fs,
+		logger: logger,
+		logTag: "compiledPackageCache",
+	}
+}
+
+func (c compiledPackageCache) Get(pkg birelpkg.Compilable, packagesDir string) (string, bool) {
+	path := c.path(pkg, packagesDir)
+	if !c.fs.FileExists(path) {
+		return "", false
+	}
+
+	c.logger.Debug(c.logTag, "Found cached compiled package '%s/%s' at '%s'", pkg.Name(), pkg.Fingerprint(), path)
+
+	return path, true
+}
+
+func (c compiledPackageCache) Put(pkg birelpkg.Compilable, packagesDir string, tarballPath string) error {
+	path := c.path(pkg, packagesDir)
+
+	err := c.fs.MkdirAll(c.dir, os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating compiled package cache '%s'", c.dir)
+	}
+
+	// Copy next to the final path first so that readers never see a partial tarball
+	partialPath := path + ".partial"
+
+	err = c.fs.CopyFile(tarballPath, partialPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Caching compiled package '%s'", pkg.Name())
+	}
+
+	err = c.fs.Rename(partialPath, path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Caching compiled package '%s'", pkg.Name())
+	}
+
+	return nil
+}
+
+func (c compiledPackageCache) path(pkg birelpkg.Compilable, packagesDir string) string {
+	return filepath.Join(c.dir, CompiledPackageCacheKey(pkg, packagesDir)+".tgz")
+}
+
+// CompiledPackageCacheKey identifies a compiled package by the fingerprints
+// of the package and its transitive dependencies, the packages dir it was
+// compiled into, and the host platform.
+func CompiledPackageCacheKey(pkg birelpkg.Compilable, packagesDir string) string {
+	fingerprints := []string{}
+	collectDependencyFingerprints(pkg, map[string]bool{}, &fingerprints)
+	sort.Strings(fingerprints)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s/%s\n%s\n%s/%s\n", pkg.Name(), pkg.Fingerprint(), packagesDir, runtime.GOOS, runtime.GOARCH)
+	for _, fingerprint := range fingerprints {
+		fmt.Fprintf(hash, "%s\n", fingerprint)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func collectDependencyFingerprints(pkg birelpkg.Compilable, seen map[string]bool, fingerprints *[]string) {
+	for _, dep := range pkg.Deps() {
+		key := fmt.Sprintf("%s/%s", dep.Name(), dep.Fingerprint())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		*fingerprints = append(*fingerprints, key)
+		collectDependencyFingerprints(dep, seen, fingerprints)
+	}
+}
@@ -0,0 +1,75 @@
+package pkg_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/installation/pkg"
+	birelpkg "github.com/cloudfoundry/bosh-cli/v7/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/v7/release/resource"
+)
+
+var _ = Describe("CompiledPackageCache", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		cache CompiledPackageCache
+		dep   *birelpkg.Package
+		pkg   *birelpkg.Package
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cache = NewCompiledPackageCache("/fake-cache-dir", fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		dep = birelpkg.NewPackage(NewResource("dep-name", "dep-fp", nil), nil)
+		pkg = birelpkg.NewPackage(NewResource("pkg-name", "pkg-fp", nil), []string{"dep-name"})
+		Expect(pkg.AttachDependencies([]*birelpkg.Package{dep})).To(Succeed())
+	})
+
+	It("returns packages that were put into the cache", func() {
+		_, found := cache.Get(pkg, "/fake-packages-dir")
+		Expect(found).To(BeFalse())
+
+		Expect(fs.WriteFileString("/fake-tarball.tgz", "fake-compiled-package")).To(Succeed())
+		Expect(cache.Put(pkg, "/fake-packages-dir", "/fake-tarball.tgz")).To(Succeed())
+
+		path, found := cache.Get(pkg, "/fake-packages-dir")
+		Expect(found).To(BeTrue())
+		Expect(path).To(HavePrefix("/fake-cache-dir/"))
+		Expect(fs.ReadFileString(path)).To(Equal("fake-compiled-package"))
+		Expect(fs.FileExists(path + ".partial")).To(BeFalse())
+	})
+
+	It("returns an error when the tarball cannot be copied", func() {
+		err := cache.Put(pkg, "/fake-packages-dir", "/missing-tarball.tgz")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Caching compiled package 'pkg-name'"))
+	})
+
+	It("does not return packages that were put into the cache for another packages dir", func() {
+		Expect(fs.WriteFileString("/fake-tarball.tgz", "fake-compiled-package")).To(Succeed())
+		Expect(cache.Put(pkg, "/fake-packages-dir", "/fake-tarball.tgz")).To(Succeed())
+
+		_, found := cache.Get(pkg, "/other-packages-dir")
+		Expect(found).To(BeFalse())
+	})
+
+	Describe("CompiledPackageCacheKey", func() {
+		It("changes when a dependency changes", func() {
+			key := CompiledPackageCacheKey(pkg, "/fake-packages-dir")
+
+			otherDep := birelpkg.NewPackage(NewResource("dep-name", "other-dep-fp", nil), nil)
+			otherPkg := birelpkg.NewPackage(NewResource("pkg-name", "pkg-fp", nil), []string{"dep-name"})
+			Expect(otherPkg.AttachDependencies([]*birelpkg.Package{otherDep})).To(Succeed())
+
+			Expect(CompiledPackageCacheKey(otherPkg, "/fake-packages-dir")).ToNot(Equal(key))
+			Expect(CompiledPackageCacheKey(pkg, "/fake-packages-dir")).To(Equal(key))
+		})
+
+		It("changes when the packages dir changes", func() {
+			Expect(CompiledPackageCacheKey(pkg, "/other-packages-dir")).ToNot(Equal(CompiledPackageCacheKey(pkg, "/fake-packages-dir")))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/cloudfoundry/bosh-cli/v7/installation/blobextract"
 	birelpkg "github.com/cloudfoundry/bosh-cli/v7/release/pkg"
@@ -22,8 +23,15 @@ type compiler struct {
 	blobstore           boshblob.DigestBlobstore
 	compiledPackageRepo bistatepkg.CompiledPackageRepo
 	blobExtractor       blobextract.Extractor
+	cache               CompiledPackageCache
 	logger              boshlog.Logger
 	logTag              string
+
+	// lock guards the compiled package repo and the shared packages dir,
+	// which is removed once no package is being compiled in it
+	lock      sync.Mutex
+	compiling int
+	installed map[string]string
 }
 
 func NewPackageCompiler(
@@ -34,6 +42,7 @@ func NewPackageCompiler(
 	blobstore boshblob.DigestBlobstore,
 	compiledPackageRepo bistatepkg.CompiledPackageRepo,
 	blobExtractor blobextract.Extractor,
+	cache CompiledPackageCache,
 	logger boshlog.Logger,
 ) bistatepkg.Compiler {
 	return &compiler{
@@ -44,39 +53,50 @@ func NewPackageCompiler(
 		blobstore:           blobstore,
 		compiledPackageRepo: compiledPackageRepo,
 		blobExtractor:       blobExtractor,
+		cache:               cache,
 		logger:              logger,
 		logTag:              "packageCompiler",
+		installed:           map[string]string{},
 	}
 }
 
+// Compile is safe for concurrent use. Packages are compiled into the shared
+// packages dir, which is where they will be installed, because compiled
+// packages may refer to the absolute paths of their dependencies.
 func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
-	// This is a variable being used now to fulfill the requirement of the compiler_interface compile method
-	// to indicate whether the package is already compiled. Compiled CPI releases are not currently allowed.
-	// No other packages, but CPI ones, are currently being compiled locally.
+	// isCompiledPackage tells the caller that compilation was skipped. Compiled CPI releases are not
+	// currently allowed, so that only happens when the package is found in the compiled package cache.
 	isCompiledPackage := false
 
 	c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
+	c.lock.Lock()
 	record, found, err := c.compiledPackageRepo.Find(pkg)
+	c.lock.Unlock()
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
 	} else if found {
 		return record, isCompiledPackage, nil
 	}
 
+	if cachedTarballPath, found := c.cache.Get(pkg, c.packagesDir); found {
+		record, err = c.saveCompiledPackage(pkg, cachedTarballPath)
+		if err != nil {
+			return record, isCompiledPackage, bosherr.WrapErrorf(err, "Using cached compiled package '%s'", pkg.Name())
+		}
+		return record, true, nil
+	}
+
 	c.logger.Debug(c.logTag, "Installing dependencies of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
+	c.startCompiling()
+	defer c.finishCompiling()
+
 	err = c.installPackages(pkg.Deps())
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Installing dependencies of package '%s'", pkg.Name())
 	}
 
-	defer func() {
-		if err = c.fileSystem.RemoveAll(c.packagesDir); err != nil {
-			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
-		}
-	}()
-
 	c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	installDir := filepath.Join(c.packagesDir, pkg.Name())
@@ -112,6 +132,10 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, bosherr.WrapError(err, "Compiling package")
 	}
 
+	c.lock.Lock()
+	c.installed[pkg.Name()] = pkg.Fingerprint()
+	c.lock.Unlock()
+
 	tarball, err := c.compressor.CompressFilesInDir(installDir)
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapError(err, "Compressing compiled package")
@@ -123,26 +147,72 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		}
 	}()
 
+	err = c.cache.Put(pkg, c.packagesDir, tarball)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to cache compiled package: %s", err.Error())
+	}
+
+	record, err = c.saveCompiledPackage(pkg, tarball)
+	if err != nil {
+		return record, isCompiledPackage, err
+	}
+
+	return record, isCompiledPackage, nil
+}
+
+func (c *compiler) saveCompiledPackage(pkg birelpkg.Compilable, tarball string) (bistatepkg.CompiledPackageRecord, error) {
 	blobID, digest, err := c.blobstore.Create(tarball)
 	if err != nil {
-		return record, isCompiledPackage, bosherr.WrapError(err, "Creating blob")
+		return bistatepkg.CompiledPackageRecord{}, bosherr.WrapError(err, "Creating blob")
 	}
 
-	record = bistatepkg.CompiledPackageRecord{
+	record := bistatepkg.CompiledPackageRecord{
 		BlobID:   blobID,
 		BlobSHA1: digest.String(),
 	}
 
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	err = c.compiledPackageRepo.Save(pkg, record)
 	if err != nil {
-		return record, isCompiledPackage, bosherr.WrapError(err, "Saving compiled package")
+		return record, bosherr.WrapError(err, "Saving compiled package")
 	}
 
-	return record, isCompiledPackage, nil
+	return record, nil
+}
+
+func (c *compiler) startCompiling() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.compiling++
+}
+
+func (c *compiler) finishCompiling() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.compiling--
+	if c.compiling > 0 {
+		return
+	}
+
+	c.installed = map[string]string{}
+	if err := c.fileSystem.RemoveAll(c.packagesDir); err != nil {
+		c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
+	}
 }
 
 func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	for _, pkg := range packages {
+		if fingerprint, found := c.installed[pkg.Name()]; found && fingerprint == pkg.Fingerprint() {
+			continue
+		}
+
 		c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 		record, found, err := c.compiledPackageRepo.Find(pkg)
@@ -158,6 +228,8 @@ func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
 		}
+
+		c.installed[pkg.Name()] = pkg.Fingerprint()
 	}
 
 	return nil
@@ -44,6 +44,7 @@ var _ = Describe("PackageCompiler", func() {
 		packagesDir             string
 		blobstore               *fakeblobstore.FakeDigestBlobstore
 		mockCompiledPackageRepo *mockstatepackage.MockCompiledPackageRepo
+		cache                   CompiledPackageCache
 
 		fakeExtractor *blobextractfakes.FakeExtractor
 
@@ -73,6 +74,8 @@ var _ = Describe("PackageCompiler", func() {
 		err := pkg.AttachDependencies([]*birelpkg.Package{dependency1, dependency2})
 		Expect(err).ToNot(HaveOccurred())
 
+		cache = NewCompiledPackageCache("/fake-cache-dir", fs, logger)
+
 		compiler = NewPackageCompiler(
 			runner,
 			packagesDir,
@@ -81,6 +84,7 @@ var _ = Describe("PackageCompiler", func() {
 			blobstore,
 			mockCompiledPackageRepo,
 			fakeExtractor,
+			cache,
 			logger,
 		)
 	})
@@ -216,6 +220,72 @@ var _ = Describe("PackageCompiler", func() {
 			}))
 		})
 
+		It("adds the compressed package to the compiled package cache", func() {
+			err := fs.WriteFileString(compiledPackageTarballPath, "fake-compiled-package")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = compiler.Compile(pkg)
+			Expect(err).ToNot(HaveOccurred())
+
+			cachedPath, found := cache.Get(pkg, packagesDir)
+			Expect(found).To(BeTrue())
+			Expect(fs.ReadFileString(cachedPath)).To(Equal("fake-compiled-package"))
+		})
+
+		Context("when the compiled package cache has the package", func() {
+			var cachedPath string
+
+			JustBeforeEach(func() {
+				err := fs.WriteFileString("/fake-tarball.tgz", "fake-compiled-package")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = cache.Put(pkg, packagesDir, "/fake-tarball.tgz")
+				Expect(err).ToNot(HaveOccurred())
+
+				cachedPath, _ = cache.Get(pkg, packagesDir)
+			})
+
+			It("skips the compilation and stores the cached package", func() {
+				expectSave.Times(1)
+
+				record, isCompiledPackage, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isCompiledPackage).To(BeTrue())
+				Expect(record.BlobID).To(Equal("fake-blob-id"))
+
+				Expect(runner.RunComplexCommands).To(BeEmpty())
+				Expect(fakeExtractor.ExtractCallCount()).To(Equal(0))
+				Expect(blobstore.CreateArgsForCall(0)).To(Equal(cachedPath))
+			})
+		})
+
+		It("does not use packages cached for another packages dir", func() {
+			err := fs.WriteFileString(compiledPackageTarballPath, "fake-compiled-package")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = compiler.Compile(pkg)
+			Expect(err).ToNot(HaveOccurred())
+
+			otherCompiler := NewPackageCompiler(
+				runner,
+				"other-packages-dir",
+				fs,
+				compressor,
+				blobstore,
+				mockCompiledPackageRepo,
+				fakeExtractor,
+				cache,
+				logger,
+			)
+
+			_, isCompiledPackage, err := otherCompiler.Compile(pkg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isCompiledPackage).To(BeFalse())
+
+			Expect(runner.RunComplexCommands).To(HaveLen(2))
+			Expect(runner.RunComplexCommands[1].Env["BOSH_PACKAGES_DIR"]).To(Equal("other-packages-dir"))
+		})
+
 		It("cleans up the packages dir", func() {
 			_, _, err := compiler.Compile(pkg)
 			Expect(err).ToNot(HaveOccurred())
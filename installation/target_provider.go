@@ -10,6 +10,8 @@ import (
 
 type TargetProvider interface {
 	NewTarget() (Target, error)
+	// NewReadOnlyTarget does not save a newly generated installation ID, e.g. for dry runs.
+	NewReadOnlyTarget() (Target, error)
 }
 
 type targetProvider struct {
@@ -52,3 +54,20 @@ func (p *targetProvider) NewTarget() (Target, error) {
 
 	return NewTarget(filepath.Join(p.installationsRootPath, installationID)), nil
 }
+
+func (p *targetProvider) NewReadOnlyTarget() (Target, error) {
+	deploymentState, err := p.deploymentStateService.LoadReadOnly()
+	if err != nil {
+		return Target{}, bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	installationID := deploymentState.InstallationID
+	if installationID == "" {
+		installationID, err = p.uuidGenerator.Generate()
+		if err != nil {
+			return Target{}, bosherr.WrapError(err, "Generating installation ID")
+		}
+	}
+
+	return NewTarget(filepath.Join(p.installationsRootPath, installationID)), nil
+}
@@ -103,5 +103,26 @@ var _ = Describe("TargetProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(deploymentState.InstallationID).To(Equal("fake-uuid-1"))
 		})
+
+		It("does not create deployment state for read-only target", func() {
+			target, err := targetProvider.NewReadOnlyTarget()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Path()).To(Equal(filepath.Join("/", ".bosh", "installations", "fake-uuid-0")))
+
+			Expect(fakeFS.FileExists(configPath)).To(BeFalse())
+		})
+	})
+
+	Describe("NewReadOnlyTarget", func() {
+		It("uses the existing installation_id without saving deployment state", func() {
+			err := fakeFS.WriteFileString(configPath, `{"installation_id":"12345"}`)
+			Expect(err).ToNot(HaveOccurred())
+
+			target, err := targetProvider.NewReadOnlyTarget()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Path()).To(Equal(filepath.Join("/", ".bosh", "installations", "12345")))
+
+			Expect(fakeFS.ReadFileString(configPath)).To(Equal(`{"installation_id":"12345"}`))
+		})
 	})
 })
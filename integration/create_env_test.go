@@ -391,7 +391,6 @@ cloud_provider:
 			deploymentValidator := bideplmanifest.NewValidator(logger)
 
 			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
 
 			pingTimeout := 1 * time.Second
 			pingDelay := 100 * time.Millisecond
@@ -412,11 +411,14 @@ cloud_provider:
 				stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
 				diskManagerFactory = bidisk.NewManagerFactory(diskRepo, logger)
 				diskDeployer = bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger, false)
-				vmManagerFactory = bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeAgentIDGenerator, fs, logger)
+				vmManagerFactory = bivm.NewManagerFactory(deploymentStateService, vmRepo, stemcellRepo, diskDeployer, false, fakeAgentIDGenerator, fs, logger)
+				deployProgressRepo := biconfig.NewDeployProgressRepo(deploymentStateService)
+				instanceManagerFactory := biinstance.NewManagerFactory(vmManagerFactory, biconfig.NewInstanceRepo(deploymentStateService), deployProgressRepo, sshTunnelFactory, instanceFactory, logger)
 				deployer := bidepl.NewDeployer(
 					vmManagerFactory,
 					instanceManagerFactory,
 					deploymentFactory,
+					deployProgressRepo,
 					logger,
 				)
 				tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
@@ -462,6 +464,7 @@ cloud_provider:
 					legacyDeploymentStateMigrator,
 					releaseManager,
 					deploymentRecord,
+					deployProgressRepo,
 					mockCloudFactory,
 					stemcellManagerFactory,
 					mockAgentClientFactory,
@@ -12,6 +12,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-cli/v7/release/job"
+	boshjobman "github.com/cloudfoundry/bosh-cli/v7/release/job/manifest"
 	boshman "github.com/cloudfoundry/bosh-cli/v7/release/manifest"
 	. "github.com/cloudfoundry/bosh-cli/v7/release/resource"
 )
@@ -50,6 +51,12 @@ properties:
   prop:
     description: prop-desc
     default: prop-default
+    type: string
+    example: prop-example
+consumes:
+- {name: db, type: database, optional: true}
+provides:
+- {name: web, type: http, properties: [prop]}
 `)
 			Expect(err).ToNot(HaveOccurred())
 
@@ -67,8 +74,16 @@ properties:
 				"prop": {
 					Description: "prop-desc",
 					Default:     biproperty.Property("prop-default"),
+					Type:        "string",
+					Example:     biproperty.Property("prop-example"),
 				},
 			}))
+			Expect(job.Consumes).To(Equal([]boshjobman.LinkDefinition{
+				{Name: "db", Type: "database", Optional: true},
+			}))
+			Expect(job.Provides).To(Equal([]boshjobman.LinkDefinition{
+				{Name: "web", Type: "http", Properties: []string{"prop"}},
+			}))
 
 			Expect(job.ExtractedPath()).To(Equal("/extracted/job"))
 
@@ -95,7 +110,7 @@ properties:
 		})
 
 		It("returns a job that can be cleaned up", func() {
-			err := fs.WriteFileString("/extracted/job/job.MF", "")
+			err := fs.WriteFileString("/extracted/job/job.MF", "name: name")
 			Expect(err).ToNot(HaveOccurred())
 			err = fs.MkdirAll("/extracted/job", os.ModeDir)
 			Expect(err).ToNot(HaveOccurred())
@@ -108,7 +123,7 @@ properties:
 		})
 
 		It("returns error when cleaning up fails", func() {
-			err := fs.WriteFileString("/extracted/job/job.MF", "")
+			err := fs.WriteFileString("/extracted/job/job.MF", "name: name")
 			Expect(err).ToNot(HaveOccurred())
 			fs.RemoveAllStub = func(_ string) error { return errors.New("fake-err") }
 
@@ -6,6 +6,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	"github.com/cloudfoundry/bosh-cli/v7/crypto"
+	boshjobman "github.com/cloudfoundry/bosh-cli/v7/release/job/manifest"
 	boshpkg "github.com/cloudfoundry/bosh-cli/v7/release/pkg"
 	. "github.com/cloudfoundry/bosh-cli/v7/release/resource"
 	crypto2 "github.com/cloudfoundry/bosh-utils/crypto"
@@ -24,6 +25,8 @@ type Job struct {
 	PackageNames []string
 	Packages     []boshpkg.Compilable
 	Properties   map[string]PropertyDefinition
+	Consumes     []boshjobman.LinkDefinition
+	Provides     []boshjobman.LinkDefinition
 
 	extractedPath string
 	fs            boshsys.FileSystem
@@ -32,6 +35,8 @@ type Job struct {
 type PropertyDefinition struct {
 	Description string
 	Default     biproperty.Property
+	Type        string
+	Example     biproperty.Property
 }
 
 func NewJob(resource Resource) *Job {
@@ -80,6 +85,8 @@ func (j *Job) RehashWithCalculator(calculator crypto.DigestCalculator, archiveFi
 		PackageNames: j.PackageNames,
 		Packages:     j.Packages,
 		Properties:   j.Properties,
+		Consumes:     j.Consumes,
+		Provides:     j.Provides,
 
 		extractedPath: j.extractedPath,
 		fs:            j.fs,
@@ -115,3 +122,30 @@ func (j Job) CleanUp() error {
 	}
 	return nil
 }
+
+func buildPropertyDefinitions(manifest boshjobman.Manifest) (map[string]PropertyDefinition, error) {
+	properties := make(map[string]PropertyDefinition, len(manifest.Properties))
+
+	for propertyName, rawPropertyDef := range manifest.Properties {
+		defaultValue, err := biproperty.Build(rawPropertyDef.Default)
+		if err != nil {
+			errMsg := "Parsing job '%s' property '%s' default: %#v"
+			return nil, bosherr.WrapErrorf(err, errMsg, manifest.Name, propertyName, rawPropertyDef.Default)
+		}
+
+		exampleValue, err := biproperty.Build(rawPropertyDef.Example)
+		if err != nil {
+			errMsg := "Parsing job '%s' property '%s' example: %#v"
+			return nil, bosherr.WrapErrorf(err, errMsg, manifest.Name, propertyName, rawPropertyDef.Example)
+		}
+
+		properties[propertyName] = PropertyDefinition{
+			Description: rawPropertyDef.Description,
+			Default:     defaultValue,
+			Type:        rawPropertyDef.Type,
+			Example:     exampleValue,
+		}
+	}
+
+	return properties, nil
+}
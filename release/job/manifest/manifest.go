@@ -11,11 +11,24 @@ type Manifest struct {
 	Templates  map[string]string             `yaml:"templates"`
 	Packages   []string                      `yaml:"packages"`
 	Properties map[string]PropertyDefinition `yaml:"properties"`
+	Consumes   []LinkDefinition              `yaml:"consumes"`
+	Provides   []LinkDefinition              `yaml:"provides"`
 }
 
 type PropertyDefinition struct {
 	Description string      `yaml:"description"`
 	Default     interface{} `yaml:"default"`
+	Type        string      `yaml:"type"`
+	Example     interface{} `yaml:"example"`
+}
+
+// LinkDefinition is a link that a job consumes or provides.
+// Only provided links list properties and only consumed links are optional.
+type LinkDefinition struct {
+	Name       string   `yaml:"name"`
+	Type       string   `yaml:"type"`
+	Optional   bool     `yaml:"optional"`
+	Properties []string `yaml:"properties"`
 }
 
 func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
@@ -31,5 +44,70 @@ func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
 		return manifest, bosherr.WrapErrorf(err, "Unmarshalling job spec '%s'", path)
 	}
 
+	err = manifest.validate()
+	if err != nil {
+		return manifest, bosherr.WrapErrorf(err, "Validating job spec '%s'", path)
+	}
+
 	return manifest, nil
 }
+
+func (m Manifest) validate() error {
+	var errs []error
+
+	if len(m.Name) == 0 {
+		errs = append(errs, bosherr.Error("Expected job name to be non-empty"))
+	}
+
+	errs = append(errs, m.validateLinks("consumes", m.Consumes)...)
+	errs = append(errs, m.validateLinks("provides", m.Provides)...)
+
+	for _, link := range m.Consumes {
+		if len(link.Properties) > 0 {
+			errs = append(errs, bosherr.Errorf("Expected consumed link '%s' to not list properties", link.Name))
+		}
+	}
+
+	for _, link := range m.Provides {
+		if link.Optional {
+			errs = append(errs, bosherr.Errorf("Expected provided link '%s' to not be optional", link.Name))
+		}
+
+		for _, property := range link.Properties {
+			if _, found := m.Properties[property]; !found {
+				errs = append(errs, bosherr.Errorf("Expected property '%s' of provided link '%s' to be defined in job properties", property, link.Name))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+func (m Manifest) validateLinks(kind string, links []LinkDefinition) []error {
+	var errs []error
+
+	names := map[string]struct{}{}
+
+	for i, link := range links {
+		if len(link.Name) == 0 {
+			errs = append(errs, bosherr.Errorf("Expected %s[%d] to specify link name", kind, i))
+			continue
+		}
+
+		if len(link.Type) == 0 {
+			errs = append(errs, bosherr.Errorf("Expected %s link '%s' to specify link type", kind, link.Name))
+		}
+
+		if _, found := names[link.Name]; found {
+			errs = append(errs, bosherr.Errorf("Expected %s link name '%s' to be unique", kind, link.Name))
+		}
+
+		names[link.Name] = struct{}{}
+	}
+
+	return errs
+}
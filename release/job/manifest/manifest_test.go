@@ -37,6 +37,20 @@ properties:
   prop1.prop2:
     description: prop2-desc
     default: prop2-default
+    type: string
+    example: prop2-example
+
+consumes:
+- name: db
+  type: database
+- name: cache
+  type: redis
+  optional: true
+
+provides:
+- name: web
+  type: http
+  properties: [prop1]
 `
 
 		err := fs.WriteFileString("/path", contents)
@@ -59,8 +73,19 @@ properties:
 				"prop1.prop2": PropertyDefinition{
 					Description: "prop2-desc",
 					Default:     "prop2-default",
+					Type:        "string",
+					Example:     "prop2-example",
 				},
 			},
+
+			Consumes: []LinkDefinition{
+				{Name: "db", Type: "database"},
+				{Name: "cache", Type: "redis", Optional: true},
+			},
+
+			Provides: []LinkDefinition{
+				{Name: "web", Type: "http", Properties: []string{"prop1"}},
+			},
 		}))
 	})
 
@@ -82,4 +107,57 @@ properties:
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("fake-err"))
 	})
+
+	It("returns error if manifest has values of unexpected types", func() {
+		err := fs.WriteFileString("/path", "name: name\nconsumes: {db: database}")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewManifestFromPath("/path", fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling job spec '/path'"))
+	})
+
+	It("returns error if manifest does not specify job name", func() {
+		err := fs.WriteFileString("/path", "templates: {}")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewManifestFromPath("/path", fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected job name to be non-empty"))
+	})
+
+	It("returns all errors if links are not valid", func() {
+		contents := `---
+name: name
+properties:
+  port: {}
+consumes:
+- type: database
+- name: db
+- name: cache
+  type: redis
+  properties: [port]
+- name: cache
+  type: redis
+provides:
+- name: web
+  type: http
+  optional: true
+  properties: [port, host]
+`
+
+		err := fs.WriteFileString("/path", contents)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewManifestFromPath("/path", fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Validating job spec '/path'"))
+		Expect(err.Error()).To(ContainSubstring("Expected consumes[0] to specify link name"))
+		Expect(err.Error()).To(ContainSubstring("Expected consumes link 'db' to specify link type"))
+		Expect(err.Error()).To(ContainSubstring("Expected consumes link name 'cache' to be unique"))
+		Expect(err.Error()).To(ContainSubstring("Expected consumed link 'cache' to not list properties"))
+		Expect(err.Error()).To(ContainSubstring("Expected provided link 'web' to not be optional"))
+		Expect(err.Error()).To(ContainSubstring("Expected property 'host' of provided link 'web' to be defined in job properties"))
+		Expect(err.Error()).ToNot(ContainSubstring("property 'port'"))
+	})
 })
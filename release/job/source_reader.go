@@ -0,0 +1,88 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshjobman "github.com/cloudfoundry/bosh-cli/v7/release/job/manifest"
+	. "github.com/cloudfoundry/bosh-cli/v7/release/resource"
+)
+
+// SourceReaderImpl reads a job from a release directory without building it.
+// Job files are copied into a temporary directory that is laid out like an
+// extracted job archive so that job templates can be rendered.
+type SourceReaderImpl struct {
+	fs boshsys.FileSystem
+}
+
+func NewSourceReaderImpl(fs boshsys.FileSystem) SourceReaderImpl {
+	return SourceReaderImpl{fs: fs}
+}
+
+func (r SourceReaderImpl) Read(path string) (*Job, error) {
+	specPath := filepath.Join(path, "spec")
+
+	manifest, err := boshjobman.NewManifestFromPath(specPath, r.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Base(path) != manifest.Name {
+		return nil, bosherr.Errorf("Job directory '%s' does not match job name '%s' in spec", filepath.Base(path), manifest.Name)
+	}
+
+	properties, err := buildPropertyDefinitions(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	extractPath, err := r.fs.TempDir("bosh-release-job")
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating temp directory to copy job '%s'", path)
+	}
+
+	job := NewExtractedJob(NewResource(manifest.Name, "", nil), extractPath, r.fs)
+	job.Templates = manifest.Templates
+	job.PackageNames = manifest.Packages
+	job.Properties = properties
+	job.Consumes = manifest.Consumes
+	job.Provides = manifest.Provides
+
+	files := map[string]string{specPath: "job.MF"}
+
+	monitPath := filepath.Join(path, "monit")
+	if r.fs.FileExists(monitPath) {
+		files[monitPath] = "monit"
+	}
+
+	for src := range manifest.Templates {
+		files[filepath.Join(path, "templates", src)] = filepath.Join("templates", src)
+	}
+
+	for srcPath, relativePath := range files {
+		err = r.copyFile(srcPath, filepath.Join(extractPath, relativePath))
+		if err != nil {
+			_ = job.CleanUp()
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+func (r SourceReaderImpl) copyFile(srcPath, dstPath string) error {
+	err := r.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory '%s'", filepath.Dir(dstPath))
+	}
+
+	err = r.fs.CopyFile(srcPath, dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying job file '%s'", srcPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package job_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/release/job"
+)
+
+var _ = Describe("SourceReaderImpl", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader SourceReaderImpl
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fs.TempDirDir = filepath.Join("/", "tmp", "job")
+		reader = NewSourceReaderImpl(fs)
+
+		err := fs.WriteFileString(filepath.Join("/", "my-job", "spec"), `---
+name: my-job
+templates: {src.erb: config/dst}
+packages: [pkg]
+properties:
+  prop:
+    description: prop-desc
+    default: {key: value}
+  required-prop:
+    description: required-desc
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString(filepath.Join("/", "my-job", "monit"), "monit-content")
+		Expect(err).ToNot(HaveOccurred())
+		err = fs.WriteFileString(filepath.Join("/", "my-job", "templates", "src.erb"), "tpl-content")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Read", func() {
+		It("returns a job with all details from the job spec", func() {
+			job, err := reader.Read(filepath.Join("/", "my-job"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(job.Name()).To(Equal("my-job"))
+			Expect(job.Templates).To(Equal(map[string]string{"src.erb": "config/dst"}))
+			Expect(job.PackageNames).To(Equal([]string{"pkg"}))
+			Expect(job.Properties).To(Equal(map[string]PropertyDefinition{
+				"prop": {
+					Description: "prop-desc",
+					Default:     biproperty.Map{"key": "value"},
+				},
+				"required-prop": {
+					Description: "required-desc",
+				},
+			}))
+		})
+
+		It("copies job files into a directory laid out like an extracted job", func() {
+			job, err := reader.Read(filepath.Join("/", "my-job"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.ExtractedPath()).To(Equal(filepath.Join("/", "tmp", "job")))
+
+			Expect(fs.ReadFileString(filepath.Join("/", "tmp", "job", "job.MF"))).To(ContainSubstring("name: my-job"))
+			Expect(fs.ReadFileString(filepath.Join("/", "tmp", "job", "monit"))).To(Equal("monit-content"))
+			Expect(fs.ReadFileString(filepath.Join("/", "tmp", "job", "templates", "src.erb"))).To(Equal("tpl-content"))
+
+			Expect(job.CleanUp()).To(Succeed())
+			Expect(fs.FileExists(filepath.Join("/", "tmp", "job"))).To(BeFalse())
+			Expect(fs.FileExists(filepath.Join("/", "my-job", "monit"))).To(BeTrue())
+		})
+
+		It("returns an error if the job directory does not match the job name", func() {
+			err := fs.WriteFileString(filepath.Join("/", "other-job", "spec"), "---\nname: my-job")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reader.Read(filepath.Join("/", "other-job"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Job directory 'other-job' does not match job name 'my-job' in spec"))
+		})
+
+		It("returns an error and removes copied files if copying fails", func() {
+			fs.CopyFileError = errors.New("fake-err")
+
+			_, err := reader.Read(filepath.Join("/", "my-job"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+			Expect(fs.FileExists(filepath.Join("/", "tmp", "job"))).To(BeFalse())
+		})
+	})
+})
@@ -52,12 +52,15 @@ func (p Provider) NewMultiReader(dirPath string) MultiReader {
 	return NewMultiReader(opts, p.fs)
 }
 
-func (p Provider) NewExtractingArchiveReader() ArchiveReader { return p.archiveReader(true) }
-func (p Provider) NewArchiveReader() ArchiveReader           { return p.archiveReader(false) }
+func (p Provider) NewExtractingArchiveReader() ArchiveReader { return p.archiveReader(true, true) }
+func (p Provider) NewArchiveReader() ArchiveReader           { return p.archiveReader(false, false) }
 
-func (p Provider) archiveReader(extracting bool) ArchiveReader {
-	jobReader := boshjob.NewArchiveReaderImpl(extracting, p.compressor, p.fs)
-	pkgReader := boshpkg.NewArchiveReaderImpl(extracting, p.compressor, p.fs)
+// NewJobExtractingArchiveReader reads job specs but leaves packages archived.
+func (p Provider) NewJobExtractingArchiveReader() ArchiveReader { return p.archiveReader(true, false) }
+
+func (p Provider) archiveReader(extractingJobs, extractingPkgs bool) ArchiveReader {
+	jobReader := boshjob.NewArchiveReaderImpl(extractingJobs, p.compressor, p.fs)
+	pkgReader := boshpkg.NewArchiveReaderImpl(extractingPkgs, p.compressor, p.fs)
 	return NewArchiveReader(jobReader, pkgReader, p.compressor, p.fs, p.logger)
 }
 
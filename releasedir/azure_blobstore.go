@@ -0,0 +1,410 @@
+package releasedir
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"gopkg.in/yaml.v2"
+)
+
+const azureStorageAPIVersion = "2020-04-08"
+
+var azureBlobEndpointSuffixes = map[string]string{
+	"AzureCloud":        "blob.core.windows.net",
+	"AzureChinaCloud":   "blob.core.chinacloudapi.cn",
+	"AzureUSGovernment": "blob.core.usgovcloudapi.net",
+}
+
+/*
+# final.yml
+blobstore:
+  provider: azure-storage
+  options:
+    account_name: cfreleases
+    container_name: cf-release-blobs
+    environment: AzureCloud # optional
+    endpoint: http://127.0.0.1:10000/cfreleases # optional, e.g. for Azurite
+
+# private.yml
+blobstore:
+  options:
+    account_key: ...
+*/
+
+// AzureBlobstore stores blobs as block blobs in a container
+// and authorizes requests with a storage account key.
+type AzureBlobstore struct {
+	fs      boshsys.FileSystem
+	uuidGen boshuuid.Generator
+	options map[string]interface{}
+}
+
+// azureBlobList is a page of List Blobs response.
+type azureBlobList struct {
+	Blobs []struct {
+		Name string `xml:"Name"`
+	} `xml:"Blobs>Blob"`
+
+	NextMarker string `xml:"NextMarker"`
+}
+
+type azureBlobstoreConfig struct {
+	AccountName   string `yaml:"account_name"`
+	AccountKey    string `yaml:"account_key"`
+	ContainerName string `yaml:"container_name"`
+	Environment   string `yaml:"environment"`
+	Endpoint      string `yaml:"endpoint"`
+}
+
+func NewAzureBlobstore(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	options map[string]interface{},
+) AzureBlobstore {
+	return AzureBlobstore{
+		fs:      fs,
+		uuidGen: uuidGen,
+		options: options,
+	}
+}
+
+func (b AzureBlobstore) Get(blobID string) (string, error) {
+	conf, err := b.config()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := b.do(conf, "GET", blobID, nil, nil, 0)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Getting blob '%s'", blobID)
+	}
+
+	defer resp.Body.Close()
+
+	file, err := b.fs.TempFile("bosh-azure-blob")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating destination file")
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, resp.Body)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Downloading blob '%s'", blobID)
+	}
+
+	return file.Name(), nil
+}
+
+func (b AzureBlobstore) Create(path string) (string, error) {
+	conf, err := b.config()
+	if err != nil {
+		return "", err
+	}
+
+	blobID, err := b.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating blobstore ID")
+	}
+
+	file, err := b.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening source file")
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Checking source file size")
+	}
+
+	resp, err := b.do(conf, "PUT", blobID, nil, file, info.Size())
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Uploading blob '%s'", blobID)
+	}
+
+	resp.Body.Close()
+
+	return blobID, nil
+}
+
+func (b AzureBlobstore) CleanUp(path string) error {
+	return b.fs.RemoveAll(path)
+}
+
+func (b AzureBlobstore) Delete(blobID string) error {
+	conf, err := b.config()
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do(conf, "DELETE", blobID, nil, nil, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting blob '%s'", blobID)
+	}
+
+	resp.Body.Close()
+
+	return nil
+}
+
+// List returns IDs of all blobs in the container.
+func (b AzureBlobstore) List() ([]string, error) {
+	conf, err := b.config()
+	if err != nil {
+		return nil, err
+	}
+
+	var blobIDs []string
+	var marker string
+
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}}
+
+		if len(marker) > 0 {
+			query.Set("marker", marker)
+		}
+
+		resp, err := b.do(conf, "GET", "", query, nil, 0)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Listing blobs")
+		}
+
+		var page azureBlobList
+
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Unmarshalling blobs list")
+		}
+
+		for _, blob := range page.Blobs {
+			blobIDs = append(blobIDs, blob.Name)
+		}
+
+		if len(page.NextMarker) == 0 {
+			return blobIDs, nil
+		}
+
+		marker = page.NextMarker
+	}
+}
+
+// ListScope is always shared since blobs are kept in the whole container.
+func (b AzureBlobstore) ListScope() (string, bool, error) {
+	conf, err := b.config()
+	if err != nil {
+		return "", false, err
+	}
+
+	return "azure://" + conf.AccountName + "/" + conf.ContainerName + "/", true, nil
+}
+
+func (b AzureBlobstore) Validate() error {
+	_, err := b.config()
+	return err
+}
+
+func (b AzureBlobstore) config() (azureBlobstoreConfig, error) {
+	var conf azureBlobstoreConfig
+
+	bytes, err := yaml.Marshal(b.options)
+	if err != nil {
+		return conf, bosherr.WrapError(err, "Marshaling config")
+	}
+
+	err = yaml.Unmarshal(bytes, &conf)
+	if err != nil {
+		return conf, bosherr.WrapError(err, "Reading config")
+	}
+
+	if len(conf.AccountName) == 0 {
+		return conf, bosherr.Error("Expected non-empty 'account_name' in Azure blobstore options")
+	}
+
+	if len(conf.AccountKey) == 0 {
+		return conf, bosherr.Error("Expected non-empty 'account_key' in Azure blobstore options")
+	}
+
+	if len(conf.ContainerName) == 0 {
+		return conf, bosherr.Error("Expected non-empty 'container_name' in Azure blobstore options")
+	}
+
+	if len(conf.Endpoint) == 0 {
+		if len(conf.Environment) == 0 {
+			conf.Environment = "AzureCloud"
+		}
+
+		suffix, found := azureBlobEndpointSuffixes[conf.Environment]
+		if !found {
+			return conf, bosherr.Errorf("Expected Azure blobstore 'environment' to be one of AzureCloud, AzureChinaCloud, AzureUSGovernment but was '%s'", conf.Environment)
+		}
+
+		conf.Endpoint = fmt.Sprintf("https://%s.%s", conf.AccountName, suffix)
+	}
+
+	_, err = base64.StdEncoding.DecodeString(conf.AccountKey)
+	if err != nil {
+		return conf, bosherr.WrapError(err, "Decoding 'account_key' in Azure blobstore options")
+	}
+
+	return conf, nil
+}
+
+// do sends request for a blob or, if blobID is empty, for the container itself.
+func (b AzureBlobstore) do(conf azureBlobstoreConfig, method, blobID string, query url.Values, body io.Reader, contentLength int64) (*http.Response, error) {
+	rawURL := strings.TrimSuffix(conf.Endpoint, "/") + "/" + conf.ContainerName
+
+	if len(blobID) > 0 {
+		rawURL += "/" + blobID
+	}
+
+	blobURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building blob URL")
+	}
+
+	blobURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, blobURL.String(), body)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building request")
+	}
+
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureStorageAPIVersion)
+
+	if method == "PUT" {
+		req.ContentLength = contentLength
+		req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+		if contentLength == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	err = b.sign(conf, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpclient.CreateExternalDefaultClient(nil).Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return nil, azureResponseError{
+			status:     resp.Status,
+			statusCode: resp.StatusCode,
+			message:    strings.TrimSpace(string(msg)),
+		}
+	}
+
+	return resp, nil
+}
+
+// azureResponseError keeps status code so that failed uploads can be retried
+// only when service is unavailable
+type azureResponseError struct {
+	status     string
+	statusCode int
+	message    string
+}
+
+func (e azureResponseError) Error() string {
+	return fmt.Sprintf("Unexpected response status '%s': %s", e.status, e.message)
+}
+
+func (e azureResponseError) StatusCode() int {
+	return e.statusCode
+}
+
+// sign adds a Shared Key authorization header as described in
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (b AzureBlobstore) sign(conf azureBlobstoreConfig, req *http.Request) error {
+	key, err := base64.StdEncoding.DecodeString(conf.AccountKey)
+	if err != nil {
+		return bosherr.WrapError(err, "Decoding account key")
+	}
+
+	var contentLength string
+
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+
+	for name, vals := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-ms-") {
+			msHeaders = append(msHeaders, name+":"+strings.Join(vals, ","))
+		}
+	}
+
+	sort.Strings(msHeaders)
+
+	resource := "/" + conf.AccountName + req.URL.EscapedPath()
+
+	query := req.URL.Query()
+
+	var queryNames []string
+
+	for name := range query {
+		queryNames = append(queryNames, name)
+	}
+
+	sort.Strings(queryNames)
+
+	for _, name := range queryNames {
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(query[name], ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date is provided via x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign)) //nolint:errcheck
+
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", conf.AccountName, signature))
+
+	return nil
+}
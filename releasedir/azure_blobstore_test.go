@@ -0,0 +1,290 @@
+package releasedir_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+)
+
+// azureStandIn keeps blobs in memory and only accepts requests
+// signed with the shared key of its storage account.
+type azureStandIn struct {
+	account string
+	key     []byte
+
+	lock  sync.Mutex
+	blobs map[string][]byte
+}
+
+func (s *azureStandIn) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if req.Header.Get("Authorization") != "SharedKey "+s.account+":"+s.signature(req) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("AuthenticationFailed")) //nolint:errcheck
+		return
+	}
+
+	switch req.Method {
+	case "PUT":
+		if req.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(req.Body) //nolint:errcheck
+		s.blobs[req.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case "GET":
+		if req.URL.Query().Get("comp") == "list" {
+			s.list(w, req)
+			return
+		}
+
+		body, found := s.blobs[req.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("BlobNotFound")) //nolint:errcheck
+			return
+		}
+		w.Write(body) //nolint:errcheck
+
+	case "DELETE":
+		if _, found := s.blobs[req.URL.Path]; !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.blobs, req.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// list returns blobs of the container two at a time to exercise paging.
+func (s *azureStandIn) list(w http.ResponseWriter, req *http.Request) {
+	var names []string
+
+	for path := range s.blobs {
+		if strings.HasPrefix(path, req.URL.Path+"/") {
+			names = append(names, strings.TrimPrefix(path, req.URL.Path+"/"))
+		}
+	}
+
+	sort.Strings(names)
+
+	start, _ := strconv.Atoi(req.URL.Query().Get("marker")) //nolint:errcheck
+	end := start + 2
+
+	var nextMarker string
+
+	if end < len(names) {
+		nextMarker = strconv.Itoa(end)
+	} else {
+		end = len(names)
+	}
+
+	resp := `<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Blobs>`
+	for _, name := range names[start:end] {
+		resp += "<Blob><Name>" + name + "</Name></Blob>"
+	}
+	resp += "</Blobs><NextMarker>" + nextMarker + "</NextMarker></EnumerationResults>"
+
+	w.Write([]byte(resp)) //nolint:errcheck
+}
+
+func (s *azureStandIn) signature(req *http.Request) string {
+	var headers []string
+
+	for name := range req.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-ms-") {
+			headers = append(headers, strings.ToLower(name)+":"+req.Header.Get(name))
+		}
+	}
+
+	sort.Strings(headers)
+
+	var contentLength string
+
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	stringToSign := req.Method + "\n\n\n" + contentLength + "\n\n\n\n\n\n\n\n\n" +
+		strings.Join(headers, "\n") + "\n/" + s.account + req.URL.Path
+
+	var params []string
+
+	for name, vals := range req.URL.Query() {
+		params = append(params, name+":"+strings.Join(vals, ","))
+	}
+
+	sort.Strings(params)
+
+	for _, param := range params {
+		stringToSign += "\n" + param
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(stringToSign)) //nolint:errcheck
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var _ = Describe("AzureBlobstore", func() {
+	var (
+		standIn *azureStandIn
+		server  *httptest.Server
+		fs      boshsys.FileSystem
+		uuidGen *fakeuuid.FakeGenerator
+		options map[string]interface{}
+		tmpDir  string
+	)
+
+	BeforeEach(func() {
+		standIn = &azureStandIn{account: "account", key: []byte("secret"), blobs: map[string][]byte{}}
+		server = httptest.NewServer(standIn)
+
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUUID: "blob-id"}
+
+		options = map[string]interface{}{
+			"account_name":   "account",
+			"account_key":    base64.StdEncoding.EncodeToString([]byte("secret")),
+			"container_name": "container",
+			"endpoint":       server.URL,
+		}
+
+		var err error
+
+		tmpDir, err = os.MkdirTemp("", "azure-blobstore")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir) //nolint:errcheck
+	})
+
+	blobstore := func() AzureBlobstore { return NewAzureBlobstore(fs, uuidGen, options) }
+
+	writeFile := func(content string) string {
+		path := filepath.Join(tmpDir, "file")
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	It("uploads, downloads and deletes blobs in the container", func() {
+		blobID, err := blobstore().Create(writeFile("content"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("blob-id"))
+
+		Expect(standIn.blobs).To(HaveKeyWithValue("/container/blob-id", []byte("content")))
+
+		path, err := blobstore().Get("blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		defer os.Remove(path) //nolint:errcheck
+
+		Expect(os.ReadFile(path)).To(Equal([]byte("content")))
+
+		Expect(blobstore().Delete("blob-id")).To(Succeed())
+		Expect(standIn.blobs).To(BeEmpty())
+	})
+
+	It("lists blobs in the container across pages", func() {
+		for _, name := range []string{"blob-1", "blob-2", "blob-3"} {
+			standIn.blobs["/container/"+name] = []byte("content")
+		}
+
+		standIn.blobs["/other-container/other"] = []byte("content")
+
+		blobIDs, err := blobstore().List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobIDs).To(Equal([]string{"blob-1", "blob-2", "blob-3"}))
+	})
+
+	It("describes listed blobs as the whole container which may be shared", func() {
+		scope, shared, err := blobstore().ListScope()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).To(Equal("azure://account/container/"))
+		Expect(shared).To(BeTrue())
+	})
+
+	It("uploads empty blobs", func() {
+		_, err := blobstore().Create(writeFile(""))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(standIn.blobs).To(HaveKeyWithValue("/container/blob-id", []byte{}))
+	})
+
+	It("returns error including response if blob is not found", func() {
+		_, err := blobstore().Get("unknown")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Getting blob 'unknown'"))
+		Expect(err.Error()).To(ContainSubstring("404 Not Found"))
+		Expect(err.Error()).To(ContainSubstring("BlobNotFound"))
+	})
+
+	It("returns error if account key is wrong", func() {
+		options["account_key"] = base64.StdEncoding.EncodeToString([]byte("wrong"))
+
+		_, err := blobstore().Create(writeFile("content"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("AuthenticationFailed"))
+	})
+
+	Describe("Validate", func() {
+		It("succeeds with public Azure endpoint by default", func() {
+			delete(options, "endpoint")
+			Expect(blobstore().Validate()).To(Succeed())
+		})
+
+		It("requires account name, key and container", func() {
+			for _, name := range []string{"account_name", "account_key", "container_name"} {
+				opts := map[string]interface{}{}
+				for k, v := range options {
+					opts[k] = v
+				}
+				delete(opts, name)
+
+				err := NewAzureBlobstore(fs, uuidGen, opts).Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected non-empty '" + name + "'"))
+			}
+		})
+
+		It("returns error for unknown environment", func() {
+			delete(options, "endpoint")
+			options["environment"] = "unknown"
+
+			err := blobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("but was 'unknown'"))
+		})
+
+		It("returns error if account key is not base64 encoded", func() {
+			options["account_key"] = "not base64!"
+
+			err := blobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decoding 'account_key'"))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package releasedir
+
+import (
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// CachingBlobstore consults blob cache before downloading blobs
+// and adds downloaded and uploaded blobs to the cache.
+type CachingBlobstore struct {
+	blobstore boshblob.DigestBlobstore
+	cache     BlobCache
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewCachingBlobstore(blobstore boshblob.DigestBlobstore, cache BlobCache, logger boshlog.Logger) CachingBlobstore {
+	return CachingBlobstore{
+		blobstore: blobstore,
+		cache:     cache,
+
+		logTag: "releasedir.CachingBlobstore",
+		logger: logger,
+	}
+}
+
+func (b CachingBlobstore) Get(blobID string, digest boshcrypto.Digest) (string, error) {
+	if path, found := b.cache.Get(digest); found {
+		return path, nil
+	}
+
+	path, err := b.blobstore.Get(blobID, digest)
+	if err != nil {
+		return "", err
+	}
+
+	// Failing to cache a blob only means it will be downloaded again
+	err = b.cache.Add(path, digest)
+	if err != nil {
+		b.logger.Error(b.logTag, "Caching blob '%s': %s", blobID, err)
+	}
+
+	return path, nil
+}
+
+func (b CachingBlobstore) Create(path string) (string, boshcrypto.MultipleDigest, error) {
+	blobID, digest, err := b.blobstore.Create(path)
+	if err != nil {
+		return "", boshcrypto.MultipleDigest{}, err
+	}
+
+	err = b.cache.Add(path, digest)
+	if err != nil {
+		b.logger.Error(b.logTag, "Caching blob '%s': %s", blobID, err)
+	}
+
+	return blobID, digest, nil
+}
+
+func (b CachingBlobstore) CleanUp(path string) error  { return b.blobstore.CleanUp(path) }
+func (b CachingBlobstore) Delete(blobID string) error { return b.blobstore.Delete(blobID) }
+func (b CachingBlobstore) Validate() error            { return b.blobstore.Validate() }
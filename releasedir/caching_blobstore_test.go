@@ -0,0 +1,110 @@
+package releasedir_test
+
+import (
+	"errors"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+)
+
+var _ = Describe("CachingBlobstore", func() {
+	var (
+		blobstore *fakereldir.FakeDigestBlobstore
+		cache     *fakereldir.FakeBlobCache
+		digest    boshcrypto.MultipleDigest
+		caching   CachingBlobstore
+	)
+
+	BeforeEach(func() {
+		blobstore = &fakereldir.FakeDigestBlobstore{}
+		cache = &fakereldir.FakeBlobCache{}
+		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sha1"))
+		caching = NewCachingBlobstore(blobstore, cache, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Get", func() {
+		It("returns cached blob without downloading it", func() {
+			cache.GetReturns("/cached", true)
+
+			path, err := caching.Get("blob-id", digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/cached"))
+
+			Expect(cache.GetArgsForCall(0)).To(Equal(digest))
+			Expect(blobstore.GetCallCount()).To(Equal(0))
+		})
+
+		It("downloads and caches blob if it is not cached", func() {
+			blobstore.GetReturns("/downloaded", nil)
+
+			path, err := caching.Get("blob-id", digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/downloaded"))
+
+			blobID, blobDigest := blobstore.GetArgsForCall(0)
+			Expect(blobID).To(Equal("blob-id"))
+			Expect(blobDigest).To(Equal(digest))
+
+			cachedPath, cachedDigest := cache.AddArgsForCall(0)
+			Expect(cachedPath).To(Equal("/downloaded"))
+			Expect(cachedDigest).To(Equal(digest))
+		})
+
+		It("returns downloaded blob even if it cannot be cached", func() {
+			blobstore.GetReturns("/downloaded", nil)
+			cache.AddReturns(errors.New("fake-err"))
+
+			path, err := caching.Get("blob-id", digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/downloaded"))
+		})
+
+		It("returns error if blob cannot be downloaded", func() {
+			blobstore.GetReturns("", errors.New("fake-err"))
+
+			_, err := caching.Get("blob-id", digest)
+			Expect(err).To(Equal(errors.New("fake-err")))
+
+			Expect(cache.AddCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Create", func() {
+		It("uploads and caches blob", func() {
+			blobstore.CreateReturns("blob-id", digest, nil)
+
+			blobID, blobDigest, err := caching.Create("/blob")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("blob-id"))
+			Expect(blobDigest).To(Equal(digest))
+
+			cachedPath, cachedDigest := cache.AddArgsForCall(0)
+			Expect(cachedPath).To(Equal("/blob"))
+			Expect(cachedDigest).To(Equal(digest))
+		})
+
+		It("returns error if blob cannot be uploaded", func() {
+			blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-err"))
+
+			_, _, err := caching.Create("/blob")
+			Expect(err).To(Equal(errors.New("fake-err")))
+
+			Expect(cache.AddCallCount()).To(Equal(0))
+		})
+	})
+
+	It("delegates clean up, delete and validate to blobstore", func() {
+		blobstore.CleanUpReturns(errors.New("clean-up-err"))
+		blobstore.DeleteReturns(errors.New("delete-err"))
+		blobstore.ValidateReturns(errors.New("validate-err"))
+
+		Expect(caching.CleanUp("/blob")).To(Equal(errors.New("clean-up-err")))
+		Expect(caching.Delete("blob-id")).To(Equal(errors.New("delete-err")))
+		Expect(caching.Validate()).To(Equal(errors.New("validate-err")))
+	})
+})
@@ -0,0 +1,181 @@
+package releasedir
+
+import (
+	"crypto/x509"
+	"io"
+	"os"
+
+	davclient "github.com/cloudfoundry/bosh-davcli/client"
+	davconfig "github.com/cloudfoundry/bosh-davcli/config"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+# final.yml
+blobstore:
+  provider: dav
+  options:
+    endpoint: https://artifactory.example.com/cf-release-blobs
+    tls: # optional
+      cert:
+        ca: ...
+
+# private.yml
+blobstore:
+  options:
+    user: ...
+    password: ...
+*/
+
+// DavBlobstore stores blobs on a WebDAV server, e.g. one that
+// also serves as a BOSH director blobstore or an Artifactory repository.
+type DavBlobstore struct {
+	fs      boshsys.FileSystem
+	uuidGen boshuuid.Generator
+	options map[string]interface{}
+	logger  boshlog.Logger
+}
+
+type davBlobstoreConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+
+	TLS struct {
+		Cert struct {
+			CA string `yaml:"ca"`
+		} `yaml:"cert"`
+	} `yaml:"tls"`
+}
+
+func NewDavBlobstore(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	options map[string]interface{},
+	logger boshlog.Logger,
+) DavBlobstore {
+	return DavBlobstore{
+		fs:      fs,
+		uuidGen: uuidGen,
+		options: options,
+		logger:  logger,
+	}
+}
+
+func (b DavBlobstore) Get(blobID string) (string, error) {
+	client, err := b.client()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := client.Get(blobID)
+	if err != nil {
+		return "", err
+	}
+
+	defer content.Close()
+
+	file, err := b.fs.TempFile("bosh-dav-blob")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating destination file")
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Downloading blob '%s'", blobID)
+	}
+
+	return file.Name(), nil
+}
+
+func (b DavBlobstore) Create(path string) (string, error) {
+	client, err := b.client()
+	if err != nil {
+		return "", err
+	}
+
+	blobID, err := b.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating blobstore ID")
+	}
+
+	file, err := b.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening source file")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return "", bosherr.WrapError(err, "Checking source file size")
+	}
+
+	// Client closes the file once it is uploaded
+	err = client.Put(blobID, file, info.Size())
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Uploading blob '%s'", blobID)
+	}
+
+	return blobID, nil
+}
+
+func (b DavBlobstore) CleanUp(path string) error {
+	return b.fs.RemoveAll(path)
+}
+
+func (b DavBlobstore) Delete(blobID string) error {
+	client, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	return client.Delete(blobID)
+}
+
+func (b DavBlobstore) Validate() error {
+	_, err := b.client()
+	return err
+}
+
+func (b DavBlobstore) client() (davclient.Client, error) {
+	var conf davBlobstoreConfig
+
+	bytes, err := yaml.Marshal(b.options)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshaling config")
+	}
+
+	err = yaml.Unmarshal(bytes, &conf)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading config")
+	}
+
+	if len(conf.Endpoint) == 0 {
+		return nil, bosherr.Error("Expected non-empty 'endpoint' in DAV blobstore options")
+	}
+
+	var certPool *x509.CertPool
+
+	if len(conf.TLS.Cert.CA) > 0 {
+		certPool, err = boshcrypto.CertPoolFromPEM([]byte(conf.TLS.Cert.CA))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing 'tls.cert.ca' in DAV blobstore options")
+		}
+	}
+
+	davConf := davconfig.Config{
+		Endpoint: conf.Endpoint,
+		User:     conf.User,
+		Password: conf.Password,
+	}
+
+	return davclient.NewClient(davConf, httpclient.CreateExternalDefaultClient(certPool), b.logger), nil
+}
@@ -0,0 +1,155 @@
+package releasedir_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+)
+
+// davStandIn keeps blobs in memory and requires basic auth.
+type davStandIn struct {
+	lock  sync.Mutex
+	blobs map[string][]byte
+}
+
+func (s *davStandIn) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if user, password, ok := req.BasicAuth(); !ok || user != "user" || password != "password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch req.Method {
+	case "PUT":
+		body, _ := io.ReadAll(req.Body) //nolint:errcheck
+		s.blobs[req.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case "GET":
+		body, found := s.blobs[req.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body) //nolint:errcheck
+
+	case "DELETE":
+		delete(s.blobs, req.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+var _ = Describe("DavBlobstore", func() {
+	var (
+		standIn *davStandIn
+		server  *httptest.Server
+		fs      boshsys.FileSystem
+		uuidGen *fakeuuid.FakeGenerator
+		options map[string]interface{}
+		logger  boshlog.Logger
+		tmpDir  string
+	)
+
+	BeforeEach(func() {
+		standIn = &davStandIn{blobs: map[string][]byte{}}
+		server = httptest.NewServer(standIn)
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUUID: "blob-id"}
+
+		options = map[string]interface{}{
+			"endpoint": server.URL + "/blobs",
+			"user":     "user",
+			"password": "password",
+		}
+
+		var err error
+
+		tmpDir, err = os.MkdirTemp("", "dav-blobstore")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir) //nolint:errcheck
+	})
+
+	blobstore := func() DavBlobstore { return NewDavBlobstore(fs, uuidGen, options, logger) }
+
+	It("uploads, downloads and deletes blobs under the endpoint", func() {
+		path := filepath.Join(tmpDir, "file")
+		Expect(os.WriteFile(path, []byte("content"), 0644)).To(Succeed())
+
+		blobID, err := blobstore().Create(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("blob-id"))
+
+		// Blobs are spread over directories named after a digest prefix of their ID
+		Expect(standIn.blobs).To(HaveLen(1))
+		for blobPath, content := range standIn.blobs {
+			Expect(blobPath).To(MatchRegexp(`^/blobs/[0-9a-f]{2}/blob-id$`))
+			Expect(content).To(Equal([]byte("content")))
+		}
+
+		downloadedPath, err := blobstore().Get("blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		defer os.Remove(downloadedPath) //nolint:errcheck
+
+		Expect(os.ReadFile(downloadedPath)).To(Equal([]byte("content")))
+
+		Expect(blobstore().Delete("blob-id")).To(Succeed())
+		Expect(standIn.blobs).To(BeEmpty())
+	})
+
+	It("returns error if blob is not found", func() {
+		_, err := blobstore().Get("unknown")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("404"))
+	})
+
+	It("returns error if credentials are wrong", func() {
+		options["password"] = "wrong"
+
+		path := filepath.Join(tmpDir, "file")
+		Expect(os.WriteFile(path, []byte("content"), 0644)).To(Succeed())
+
+		_, err := blobstore().Create(path)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Uploading blob 'blob-id'"))
+	})
+
+	Describe("Validate", func() {
+		It("requires endpoint", func() {
+			delete(options, "endpoint")
+
+			err := blobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected non-empty 'endpoint'"))
+		})
+
+		It("returns error if CA certificate cannot be parsed", func() {
+			options["tls"] = map[interface{}]interface{}{
+				"cert": map[interface{}]interface{}{"ca": "not-a-cert"},
+			}
+
+			err := blobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing 'tls.cert.ca'"))
+		})
+	})
+})
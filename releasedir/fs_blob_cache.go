@@ -0,0 +1,346 @@
+package releasedir
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/gofrs/flock"
+)
+
+const (
+	fsBlobCacheIndexName = "index.json"
+	fsBlobCacheLockName  = "index.lock"
+)
+
+// FSBlobCache keeps blobs in a directory shared by all release directories.
+// Blobs are keyed by their strongest digest, verified when read and removed
+// in least recently used order once the cache grows over its maximum size.
+type FSBlobCache struct {
+	dirPath string
+	maxSize int64
+
+	timeService clock.Clock
+	fs          boshsys.FileSystem
+
+	// Protects index from concurrent downloads of the same release directory;
+	// other processes are kept out with a lock file
+	lock *sync.Mutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type fsBlobCacheIndex struct {
+	Entries map[string]fsBlobCacheEntry `json:"entries"`
+
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+type fsBlobCacheEntry struct {
+	Size       int64     `json:"size"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func NewFSBlobCache(
+	dirPath string,
+	maxSize int64,
+	timeService clock.Clock,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) FSBlobCache {
+	return FSBlobCache{
+		dirPath: dirPath,
+		maxSize: maxSize,
+
+		timeService: timeService,
+		fs:          fs,
+		lock:        &sync.Mutex{},
+
+		logTag: "releasedir.FSBlobCache",
+		logger: logger,
+	}
+}
+
+func (c FSBlobCache) Get(digest boshcrypto.Digest) (string, bool) {
+	key := c.key(digest)
+	entryPath := filepath.Join(c.dirPath, key)
+
+	// Large blobs are copied and verified without holding the lock so
+	// that other blobs can be read and added in the meantime
+	path, size, copyErr := c.copyVerifiedEntry(entryPath, digest)
+
+	err := c.withLock(func() {
+		index := c.readIndex()
+
+		if copyErr != nil {
+			c.logger.Debug(c.logTag, "Missed blob '%s': %s", key, copyErr)
+
+			if c.fs.FileExists(entryPath) {
+				_ = c.fs.RemoveAll(entryPath)
+			}
+
+			delete(index.Entries, key)
+			index.Misses++
+		} else {
+			index.Entries[key] = fsBlobCacheEntry{Size: size, LastUsedAt: c.timeService.Now().UTC()}
+			index.Hits++
+		}
+
+		c.writeIndex(index)
+	})
+	if err != nil {
+		c.logger.Error(c.logTag, "Updating blobs cache index: %s", err)
+	}
+
+	return path, copyErr == nil
+}
+
+func (c FSBlobCache) Add(path string, digest boshcrypto.Digest) error {
+	stat, err := c.fs.Stat(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Checking blob '%s'", path)
+	}
+
+	// Blob would only evict everything else without being kept itself
+	if stat.Size() > c.maxSize {
+		return nil
+	}
+
+	key := c.key(digest)
+	entryPath := filepath.Join(c.dirPath, key)
+
+	tmpPath, err := c.copyToTempFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding blob '%s' to cache", path)
+	}
+
+	defer c.fs.RemoveAll(tmpPath) //nolint:errcheck
+
+	var moveErr error
+
+	err = c.withLock(func() {
+		moveErr = c.fs.Rename(tmpPath, entryPath)
+		if moveErr != nil {
+			// Temporary files may be kept on a different device
+			moveErr = c.fs.CopyFile(tmpPath, entryPath)
+		}
+
+		if moveErr != nil {
+			return
+		}
+
+		index := c.readIndex()
+		index.Entries[key] = fsBlobCacheEntry{Size: stat.Size(), LastUsedAt: c.timeService.Now().UTC()}
+
+		c.evict(index)
+		c.writeIndex(index)
+	})
+	if err != nil {
+		return err
+	}
+
+	if moveErr != nil {
+		return bosherr.WrapErrorf(moveErr, "Adding blob '%s' to cache", path)
+	}
+
+	return nil
+}
+
+func (c FSBlobCache) Stats() (BlobCacheStats, error) {
+	var stats BlobCacheStats
+
+	err := c.withLock(func() {
+		index := c.readIndex()
+
+		stats = BlobCacheStats{
+			Path:    c.dirPath,
+			Blobs:   len(index.Entries),
+			MaxSize: c.maxSize,
+			Hits:    index.Hits,
+			Misses:  index.Misses,
+		}
+
+		for _, entry := range index.Entries {
+			stats.Size += entry.Size
+		}
+	})
+
+	return stats, err
+}
+
+// withLock runs fn while holding a lock on the cache directory. Lock is
+// held on a file so that other bosh processes, e.g. syncing blobs of
+// another release directory, do not overwrite changes to the index.
+func (c FSBlobCache) withLock(fn func()) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	err := c.fs.MkdirAll(c.dirPath, 0700)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating blobs cache '%s'", c.dirPath)
+	}
+
+	fileLock := flock.New(filepath.Join(c.dirPath, fsBlobCacheLockName))
+
+	err = fileLock.Lock()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Locking blobs cache '%s'", c.dirPath)
+	}
+
+	defer fileLock.Unlock() //nolint:errcheck
+
+	fn()
+
+	return nil
+}
+
+// copyVerifiedEntry returns a copy of the cached blob and its size.
+// Copy is verified instead of the entry itself since the entry may be
+// replaced or evicted by others while it is copied.
+func (c FSBlobCache) copyVerifiedEntry(entryPath string, digest boshcrypto.Digest) (string, int64, error) {
+	if !c.fs.FileExists(entryPath) {
+		return "", 0, bosherr.Error("Not cached")
+	}
+
+	path, err := c.copyToTempFile(entryPath)
+	if err != nil {
+		return "", 0, bosherr.WrapError(err, "Copying cached blob")
+	}
+
+	err = digest.VerifyFilePath(path, c.fs)
+	if err != nil {
+		_ = c.fs.RemoveAll(path)
+		return "", 0, bosherr.WrapError(err, "Verifying cached blob")
+	}
+
+	stat, err := c.fs.Stat(path)
+	if err != nil {
+		_ = c.fs.RemoveAll(path)
+		return "", 0, bosherr.WrapError(err, "Checking cached blob")
+	}
+
+	return path, stat.Size(), nil
+}
+
+func (c FSBlobCache) copyToTempFile(srcPath string) (string, error) {
+	file, err := c.fs.TempFile("bosh-cached-blob")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating destination file")
+	}
+
+	path := file.Name()
+
+	_ = file.Close()
+
+	err = c.fs.CopyFile(srcPath, path)
+	if err != nil {
+		_ = c.fs.RemoveAll(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// evict removes least recently used blobs until cache fits into its maximum size.
+func (c FSBlobCache) evict(index fsBlobCacheIndex) {
+	var keys []string
+	var size int64
+
+	for key, entry := range index.Entries {
+		keys = append(keys, key)
+		size += entry.Size
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return index.Entries[keys[i]].LastUsedAt.Before(index.Entries[keys[j]].LastUsedAt)
+	})
+
+	for _, key := range keys {
+		if size <= c.maxSize {
+			return
+		}
+
+		err := c.fs.RemoveAll(filepath.Join(c.dirPath, key))
+		if err != nil {
+			c.logger.Error(c.logTag, "Evicting blob '%s': %s", key, err)
+			continue
+		}
+
+		size -= index.Entries[key].Size
+		delete(index.Entries, key)
+	}
+}
+
+// key uses strongest digest so that blobs referenced by
+// different sets of digests in different releases are shared.
+func (c FSBlobCache) key(digest boshcrypto.Digest) string {
+	if multiDigest, ok := digest.(boshcrypto.MultipleDigest); ok {
+		strongest, err := multiDigest.DigestFor(multiDigest.Algorithm())
+		if err == nil {
+			digest = strongest
+		}
+	}
+
+	algoName := digest.Algorithm().Name()
+
+	return fmt.Sprintf("%s-%s", algoName, strings.TrimPrefix(digest.String(), algoName+":"))
+}
+
+// readIndex returns an empty index if it cannot be read since
+// cache can always be repopulated from the blobstore.
+func (c FSBlobCache) readIndex() fsBlobCacheIndex {
+	index := fsBlobCacheIndex{Entries: map[string]fsBlobCacheEntry{}}
+
+	indexPath := filepath.Join(c.dirPath, fsBlobCacheIndexName)
+
+	if !c.fs.FileExists(indexPath) {
+		return index
+	}
+
+	bytes, err := c.fs.ReadFile(indexPath)
+	if err != nil {
+		c.logger.Error(c.logTag, "Reading blobs cache index '%s': %s", indexPath, err)
+		return index
+	}
+
+	err = json.Unmarshal(bytes, &index)
+	if err != nil {
+		c.logger.Error(c.logTag, "Unmarshalling blobs cache index '%s': %s", indexPath, err)
+	}
+
+	if index.Entries == nil {
+		index.Entries = map[string]fsBlobCacheEntry{}
+	}
+
+	return index
+}
+
+func (c FSBlobCache) writeIndex(index fsBlobCacheIndex) {
+	indexPath := filepath.Join(c.dirPath, fsBlobCacheIndexName)
+
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		c.logger.Error(c.logTag, "Marshalling blobs cache index: %s", err)
+		return
+	}
+
+	err = c.fs.MkdirAll(c.dirPath, 0700)
+	if err == nil {
+		err = c.fs.WriteFile(indexPath, bytes)
+	}
+
+	if err != nil {
+		c.logger.Error(c.logTag, "Writing blobs cache index '%s': %s", indexPath, err)
+	}
+}
@@ -0,0 +1,246 @@
+package releasedir_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/gofrs/flock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+)
+
+var _ = Describe("FSBlobCache", func() {
+	var (
+		fs          boshsys.FileSystem
+		timeService *fakeclock.FakeClock
+		tmpDir      string
+		cachePath   string
+		cache       FSBlobCache
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		timeService = fakeclock.NewFakeClock(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+		var err error
+
+		tmpDir, err = os.MkdirTemp("", "blob-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		cachePath = filepath.Join(tmpDir, "cache")
+		cache = NewFSBlobCache(cachePath, 10, timeService, fs, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir) //nolint:errcheck
+	})
+
+	writeBlob := func(name, content string) (string, boshcrypto.MultipleDigest) {
+		path := filepath.Join(tmpDir, name)
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+
+		sha1, err := boshcrypto.DigestAlgorithmSHA1.CreateDigest(strings.NewReader(content))
+		Expect(err).ToNot(HaveOccurred())
+
+		sha256, err := boshcrypto.DigestAlgorithmSHA256.CreateDigest(strings.NewReader(content))
+		Expect(err).ToNot(HaveOccurred())
+
+		return path, boshcrypto.MustNewMultipleDigest(sha1, sha256)
+	}
+
+	It("returns copies of added blobs", func() {
+		path, digest := writeBlob("blob", "content")
+
+		Expect(cache.Add(path, digest)).To(Succeed())
+
+		cachedPath, found := cache.Get(digest)
+		Expect(found).To(BeTrue())
+		Expect(cachedPath).ToNot(Equal(path))
+
+		defer os.Remove(cachedPath) //nolint:errcheck
+
+		Expect(os.ReadFile(cachedPath)).To(Equal([]byte("content")))
+
+		// Removing a copy does not remove the blob from cache
+		Expect(os.Remove(cachedPath)).To(Succeed())
+
+		_, found = cache.Get(digest)
+		Expect(found).To(BeTrue())
+	})
+
+	It("shares blobs that are referenced by a different set of digests", func() {
+		path, digest := writeBlob("blob", "content")
+
+		Expect(cache.Add(path, digest)).To(Succeed())
+
+		sha256, err := digest.DigestFor(boshcrypto.DigestAlgorithmSHA256)
+		Expect(err).ToNot(HaveOccurred())
+
+		cachedPath, found := cache.Get(sha256)
+		Expect(found).To(BeTrue())
+
+		os.Remove(cachedPath) //nolint:errcheck
+	})
+
+	It("does not return blobs that were not added", func() {
+		_, digest := writeBlob("blob", "content")
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeFalse())
+	})
+
+	It("removes blobs that do not match their digest when read", func() {
+		path, digest := writeBlob("blob", "content")
+
+		Expect(cache.Add(path, digest)).To(Succeed())
+
+		entries, err := filepath.Glob(filepath.Join(cachePath, "sha256-*"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+
+		Expect(os.WriteFile(entries[0], []byte("corrupt"), 0644)).To(Succeed())
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeFalse())
+
+		Expect(entries[0]).ToNot(BeAnExistingFile())
+
+		stats, err := cache.Stats()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stats.Blobs).To(Equal(0))
+	})
+
+	It("evicts least recently used blobs when growing over its maximum size", func() {
+		path1, digest1 := writeBlob("blob1", "1234")
+		path2, digest2 := writeBlob("blob2", "5678")
+		path3, digest3 := writeBlob("blob3", "9012")
+
+		Expect(cache.Add(path1, digest1)).To(Succeed())
+
+		timeService.Increment(time.Minute)
+		Expect(cache.Add(path2, digest2)).To(Succeed())
+
+		// Using first blob makes second one the least recently used
+		timeService.Increment(time.Minute)
+		cachedPath, found := cache.Get(digest1)
+		Expect(found).To(BeTrue())
+		os.Remove(cachedPath) //nolint:errcheck
+
+		timeService.Increment(time.Minute)
+		Expect(cache.Add(path3, digest3)).To(Succeed())
+
+		_, found = cache.Get(digest2)
+		Expect(found).To(BeFalse())
+
+		for _, digest := range []boshcrypto.MultipleDigest{digest1, digest3} {
+			cachedPath, found := cache.Get(digest)
+			Expect(found).To(BeTrue())
+			os.Remove(cachedPath) //nolint:errcheck
+		}
+	})
+
+	It("does not keep blobs larger than its maximum size", func() {
+		path, digest := writeBlob("blob", "larger than ten bytes")
+
+		Expect(cache.Add(path, digest)).To(Succeed())
+
+		_, found := cache.Get(digest)
+		Expect(found).To(BeFalse())
+	})
+
+	It("is shared by instances with the same directory", func() {
+		path, digest := writeBlob("blob", "content")
+
+		Expect(cache.Add(path, digest)).To(Succeed())
+
+		otherCache := NewFSBlobCache(cachePath, 10, timeService, fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		cachedPath, found := otherCache.Get(digest)
+		Expect(found).To(BeTrue())
+		os.Remove(cachedPath) //nolint:errcheck
+	})
+
+	It("records size of blobs that were cached without one", func() {
+		path, digest := writeBlob("blob", "content")
+
+		Expect(cache.Add(path, digest)).To(Succeed())
+
+		indexPath := filepath.Join(cachePath, "index.json")
+		index, err := os.ReadFile(indexPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(index)).To(ContainSubstring(`"size":7`))
+		Expect(os.WriteFile(indexPath, []byte(strings.Replace(string(index), `"size":7`, `"size":0`, 1)), 0644)).To(Succeed())
+
+		cachedPath, found := cache.Get(digest)
+		Expect(found).To(BeTrue())
+		os.Remove(cachedPath) //nolint:errcheck
+
+		stats, err := cache.Stats()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stats.Size).To(Equal(int64(7)))
+	})
+
+	It("waits for other processes to finish updating the cache", func() {
+		path, digest := writeBlob("blob", "content")
+
+		Expect(os.MkdirAll(cachePath, 0700)).To(Succeed())
+
+		otherProcessLock := flock.New(filepath.Join(cachePath, "index.lock"))
+		Expect(otherProcessLock.Lock()).To(Succeed())
+
+		added := make(chan error)
+		go func() { added <- cache.Add(path, digest) }()
+
+		Consistently(added, 100*time.Millisecond).ShouldNot(Receive())
+
+		Expect(otherProcessLock.Unlock()).To(Succeed())
+
+		var err error
+		Eventually(added).Should(Receive(&err))
+		Expect(err).ToNot(HaveOccurred())
+
+		cachedPath, found := cache.Get(digest)
+		Expect(found).To(BeTrue())
+		os.Remove(cachedPath) //nolint:errcheck
+	})
+
+	Describe("Stats", func() {
+		It("returns number and size of blobs and hits and misses", func() {
+			path, digest := writeBlob("blob", "content")
+			_, otherDigest := writeBlob("other", "other")
+
+			Expect(cache.Add(path, digest)).To(Succeed())
+
+			cachedPath, _ := cache.Get(digest)
+			os.Remove(cachedPath) //nolint:errcheck
+
+			cache.Get(otherDigest)
+
+			stats, err := cache.Stats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal(BlobCacheStats{
+				Path:    cachePath,
+				Blobs:   1,
+				Size:    7,
+				MaxSize: 10,
+				Hits:    1,
+				Misses:  1,
+			}))
+		})
+
+		It("returns empty stats if cache was not used", func() {
+			stats, err := cache.Stats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal(BlobCacheStats{Path: cachePath, MaxSize: 10}))
+		})
+	})
+})
@@ -1,17 +1,26 @@
 package releasedir
 
 import (
+	"errors"
 	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"syscall"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"google.golang.org/api/googleapi"
 	"gopkg.in/yaml.v2"
 
 	"fmt"
@@ -26,13 +35,23 @@ type FSBlobsDir struct {
 
 	reporter         BlobsDirReporter
 	blobstore        boshblob.DigestBlobstore
+	uploadTracker    BlobUploadTracker
 	digestCalculator bicrypto.DigestCalculator
+	timeService      clock.Clock
 	fs               boshsys.FileSystem
 
 	logTag string
 	logger boshlog.Logger
 }
 
+const (
+	// Blobstore given by Provider already makes 3 attempts right away for each
+	// of these attempts; only network and service errors are retried after
+	// a delay since other errors (e.g. denied access) would fail again
+	blobUploadAttempts   = 3
+	blobUploadRetryDelay = 5 * time.Second
+)
+
 /*
 ---
 golang/go1.5.1.linux-amd64.tar.gz:
@@ -54,7 +73,9 @@ func NewFSBlobsDir(
 	dirPath string,
 	reporter BlobsDirReporter,
 	blobstore boshblob.DigestBlobstore,
+	uploadTracker BlobUploadTracker,
 	digestCalculator bicrypto.DigestCalculator,
+	timeService clock.Clock,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) FSBlobsDir {
@@ -64,7 +85,9 @@ func NewFSBlobsDir(
 
 		reporter:         reporter,
 		blobstore:        blobstore,
+		uploadTracker:    uploadTracker,
 		digestCalculator: digestCalculator,
+		timeService:      timeService,
 		fs:               fs,
 
 		logTag: "releasedir.FSBlobsDir",
@@ -268,34 +291,100 @@ func (d FSBlobsDir) UntrackBlob(path string) error {
 	return nil
 }
 
-func (d FSBlobsDir) UploadBlobs() error {
+func (d FSBlobsDir) UploadBlobs(numOfParallelWorkers int) error {
 	blobs, err := d.Blobs()
 	if err != nil {
 		return err
 	}
 
+	var (
+		mutex        sync.Mutex
+		errs         []error
+		saveErr      error
+		uploadedSize int64
+		totalSize    int64
+		tasks        []func() error
+	)
+
 	for i, blob := range blobs {
-		if len(blob.BlobstoreID) == 0 {
+		if len(blob.BlobstoreID) > 0 {
+			continue
+		}
+
+		totalSize += blob.Size
+
+		i, blob := i, blob
+		tasks = append(tasks, func() error {
+			mutex.Lock()
+			stopped := saveErr != nil
+			mutex.Unlock()
+
+			// Blobs uploaded once blobs.yml cannot be saved would be left behind in blobstore
+			if stopped {
+				return nil
+			}
+
 			blobID, err := d.uploadBlob(blob)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
 			if err != nil {
-				return err
+				errs = append(errs, err)
+				return nil
 			}
 
-			blob.BlobstoreID = blobID
-
-			blobs[i] = blob
+			blobs[i].BlobstoreID = blobID
 
+			// Saving after each blob lets subsequent runs skip blobs that were uploaded
 			err = d.save(blobs)
 			if err != nil {
-				return bosherr.WrapErrorf(
+				saveErr = bosherr.WrapErrorf(
 					err, "Saving newly created blob '%s' for path '%s'", blobID, blob.Path)
+				return nil
 			}
-		}
+
+			uploadedSize += blob.Size
+
+			d.reporter.BlobUploadProgress(uploadedSize, totalSize)
+
+			return nil
+		})
+	}
+
+	err = work.Pool{Count: numOfParallelWorkers}.ParallelDo(tasks...)
+	if err != nil {
+		return err
+	}
+
+	if saveErr != nil {
+		return saveErr
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
 	}
 
 	return nil
 }
 
+// SaveBlobstoreID points tracked blob to a different blob with the same contents.
+func (d FSBlobsDir) SaveBlobstoreID(path, blobID string) error {
+	blobs, err := d.Blobs()
+	if err != nil {
+		return err
+	}
+
+	for i, blob := range blobs {
+		if blob.Path == path {
+			blobs[i].BlobstoreID = blobID
+			return d.save(blobs)
+		}
+	}
+
+	return bosherr.Errorf("Expected to find blob for path '%s'", path)
+}
+
 func (d FSBlobsDir) containsSymlinks() (bool, error) {
 	files, err := d.fs.RecursiveGlob(filepath.Join(d.dirPath, "**/*"))
 	if err != nil {
@@ -358,12 +447,41 @@ func (d FSBlobsDir) downloadBlob(blob Blob) error {
 
 func (d FSBlobsDir) uploadBlob(blob Blob) (string, error) {
 	var blobID string
+	var err error
 
 	d.reporter.BlobUploadStarted(blob.Path, blob.Size, blob.SHA1)
 
 	srcPath := filepath.Join(d.dirPath, blob.Path)
 
-	blobID, _, err := d.blobstore.Create(srcPath)
+	lastStep := int64(-1)
+
+	untrack := d.uploadTracker.TrackUpload(srcPath, func(sentSize int64) {
+		percent := int64(100)
+		if blob.Size > 0 {
+			percent = sentSize * 100 / blob.Size
+		}
+
+		// Reporting each tenth keeps output short; retried uploads start from zero
+		if step := percent / 10; step != lastStep {
+			lastStep = step
+			d.reporter.BlobUploadSent(blob.Path, sentSize, blob.Size)
+		}
+	})
+
+	defer untrack()
+
+	for attempt := 1; ; attempt++ {
+		blobID, _, err = d.blobstore.Create(srcPath)
+		if err == nil || attempt == blobUploadAttempts || !isTransientUploadError(err) {
+			break
+		}
+
+		d.logger.Warn(d.logTag, "Failed to create blob for path '%s' (attempt %d out of %d): %s",
+			blob.Path, attempt, blobUploadAttempts, err)
+
+		d.timeService.Sleep(blobUploadRetryDelay)
+	}
+
 	if err != nil {
 		d.reporter.BlobUploadFinished(blob.Path, "", err)
 		return "", bosherr.WrapErrorf(err, "Creating blob for path '%s'", blob.Path)
@@ -374,6 +492,43 @@ func (d FSBlobsDir) uploadBlob(blob Blob) (string, error) {
 	return blobID, nil
 }
 
+// isTransientUploadError looks through wrapped errors for network errors
+// and server side (5xx) or throttling (429) response statuses
+func isTransientUploadError(err error) bool {
+	for err != nil {
+		switch typedErr := err.(type) {
+		case bosherr.ComplexError:
+			err = typedErr.Cause
+			continue
+		case *url.Error:
+			// Check underlying error since URL errors are also returned for e.g. invalid certificates
+			err = typedErr.Err
+			continue
+		case net.Error:
+			return true
+		case interface{ StatusCode() int }:
+			return isTransientUploadStatus(typedErr.StatusCode())
+		case *googleapi.Error:
+			return isTransientUploadStatus(typedErr.Code)
+		case interface{ OrigErr() error }:
+			err = typedErr.OrigErr()
+			continue
+		}
+
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+			return true
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
+
+func isTransientUploadStatus(statusCode int) bool {
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
+
 func (d FSBlobsDir) moveBlobLocally(srcPath, dstPath string) error {
 	err := d.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
 	if err != nil {
@@ -4,12 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
@@ -20,12 +25,39 @@ import (
 	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
 )
 
+type sleepRecordingClock struct {
+	clock.Clock
+
+	mutex *sync.Mutex
+	slept []time.Duration
+}
+
+func (c *sleepRecordingClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.slept = append(c.slept, d)
+}
+
+type fakeStatusError struct {
+	statusCode int
+}
+
+func (e fakeStatusError) Error() string {
+	return fmt.Sprintf("fake-status-err: %d", e.statusCode)
+}
+
+func (e fakeStatusError) StatusCode() int {
+	return e.statusCode
+}
+
 var _ = Describe("FSBlobsDir", func() {
 	var (
 		fs               *fakesys.FakeFileSystem
 		reporter         *fakereldir.FakeBlobsDirReporter
 		blobstore        *fakereldir.FakeDigestBlobstore
+		uploadTracker    *fakereldir.FakeBlobUploadTracker
 		digestCalculator *fakecrypto.FakeDigestCalculator
+		timeService      *sleepRecordingClock
 		blobsDir         FSBlobsDir
 		logger           *fakelogger.FakeLogger
 	)
@@ -34,9 +66,12 @@ var _ = Describe("FSBlobsDir", func() {
 		fs = fakesys.NewFakeFileSystem()
 		reporter = &fakereldir.FakeBlobsDirReporter{}
 		blobstore = &fakereldir.FakeDigestBlobstore{}
+		uploadTracker = &fakereldir.FakeBlobUploadTracker{}
+		uploadTracker.TrackUploadReturns(func() {})
 		digestCalculator = fakecrypto.NewFakeDigestCalculator()
+		timeService = &sleepRecordingClock{Clock: clock.NewClock(), mutex: &sync.Mutex{}}
 		logger = &fakelogger.FakeLogger{}
-		blobsDir = NewFSBlobsDir(filepath.Join("/", "dir"), reporter, blobstore, digestCalculator, fs, logger)
+		blobsDir = NewFSBlobsDir(filepath.Join("/", "dir"), reporter, blobstore, uploadTracker, digestCalculator, timeService, fs, logger)
 	})
 
 	Describe("Blobs", func() {
@@ -158,7 +193,7 @@ already-downloaded.tgz:
 					}
 				}
 
-				blobsDir = NewFSBlobsDir(filepath.Join("/", "dir"), reporter, blobstore, digestCalculator, fs, logger)
+				blobsDir = NewFSBlobsDir(filepath.Join("/", "dir"), reporter, blobstore, uploadTracker, digestCalculator, timeService, fs, logger)
 
 				err := act(4)
 				Expect(err).ToNot(HaveOccurred())
@@ -718,7 +753,7 @@ bosh-116.tgz:
 
 	Describe("UploadBlobs", func() {
 		act := func() error {
-			return blobsDir.UploadBlobs()
+			return blobsDir.UploadBlobs(1)
 		}
 
 		BeforeEach(func() {
@@ -825,8 +860,9 @@ non-uploaded2.tgz:
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 
-			Expect(reporter.BlobUploadStartedCallCount()).To(Equal(1))
-			Expect(reporter.BlobUploadFinishedCallCount()).To(Equal(1))
+			Expect(reporter.BlobUploadStartedCallCount()).To(Equal(2))
+			Expect(reporter.BlobUploadFinishedCallCount()).To(Equal(2))
+			Expect(reporter.BlobUploadProgressCallCount()).To(Equal(0))
 
 			path, size, sha1 := reporter.BlobUploadStartedArgsForCall(0)
 			Expect(path).To(Equal("non-uploaded.tgz"))
@@ -853,12 +889,11 @@ non-uploaded2.tgz:
 		})
 
 		It("returns error if uploading fails and saves blob id for successfully uploaded blobs", func() {
-			times := 0
 			blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
-				defer func() { times += 1 }()
-				blobID := []string{"blob2", "blob5"}[times]
-				err := []error{nil, errors.New("fake-err")}[times]
-				return blobID, boshcrypto.MultipleDigest{}, err
+				if filepath.Base(fileName) == "non-uploaded2.tgz" {
+					return "", boshcrypto.MultipleDigest{}, errors.New("fake-err")
+				}
+				return "blob2", boshcrypto.MultipleDigest{}, nil
 			}
 
 			err := act()
@@ -873,5 +908,215 @@ non-uploaded2.tgz:
 				{Path: "non-uploaded2.tgz", Size: 245, SHA1: "blob5sha"},
 			}))
 		})
+
+		It("continues uploading other blobs if uploading a blob fails", func() {
+			blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
+				if filepath.Base(fileName) == "non-uploaded.tgz" {
+					return "", boshcrypto.MultipleDigest{}, errors.New("fake-err")
+				}
+				return "blob5", boshcrypto.MultipleDigest{}, nil
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Creating blob for path 'non-uploaded.tgz'"))
+
+			Expect(blobsDir.Blobs()).To(ContainElement(
+				Blob{Path: "non-uploaded2.tgz", Size: 245, BlobstoreID: "blob5", SHA1: "blob5sha"}))
+		})
+
+		It("retries uploading blob after a delay", func() {
+			attempts := 0
+			blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
+				if filepath.Base(fileName) == "non-uploaded.tgz" {
+					attempts++
+					if attempts < 3 {
+						return "", boshcrypto.MultipleDigest{}, bosherr.WrapError(
+							&net.OpError{Op: "write", Net: "tcp", Err: syscall.ECONNRESET}, "Creating blob in inner blobstore")
+					}
+					return "blob2", boshcrypto.MultipleDigest{}, nil
+				}
+				return "blob5", boshcrypto.MultipleDigest{}, nil
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobstore.CreateCallCount()).To(Equal(4))
+			Expect(timeService.slept).To(Equal([]time.Duration{5 * time.Second, 5 * time.Second}))
+
+			Expect(blobsDir.Blobs()).To(ContainElement(
+				Blob{Path: "non-uploaded.tgz", Size: 243, BlobstoreID: "blob2", SHA1: "blob2sha"}))
+
+			Expect(reporter.BlobUploadStartedCallCount()).To(Equal(2))
+			Expect(reporter.BlobUploadFinishedCallCount()).To(Equal(2))
+		})
+
+		It("gives up uploading blob after a few attempts", func() {
+			blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, fakeStatusError{statusCode: 503})
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(blobstore.CreateCallCount()).To(Equal(6))
+			Expect(timeService.slept).To(HaveLen(4))
+		})
+
+		It("retries uploading blob when blobstore is throttling requests", func() {
+			attempts := 0
+			blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
+				if filepath.Base(fileName) == "non-uploaded.tgz" {
+					attempts++
+					if attempts == 1 {
+						return "", boshcrypto.MultipleDigest{}, fakeStatusError{statusCode: 429}
+					}
+					return "blob2", boshcrypto.MultipleDigest{}, nil
+				}
+				return "blob5", boshcrypto.MultipleDigest{}, nil
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobstore.CreateCallCount()).To(Equal(3))
+			Expect(timeService.slept).To(HaveLen(1))
+		})
+
+		It("does not retry uploading blob when error is not transient", func() {
+			blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, bosherr.WrapError(errors.New("fake-err"), "fake-wrap"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(blobstore.CreateCallCount()).To(Equal(2))
+			Expect(timeService.slept).To(BeEmpty())
+		})
+
+		It("does not retry uploading blob when blobstore denies access", func() {
+			blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, fakeStatusError{statusCode: 403})
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(blobstore.CreateCallCount()).To(Equal(2))
+			Expect(timeService.slept).To(BeEmpty())
+		})
+
+		It("reports combined size of uploaded blobs after each upload", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reporter.BlobUploadProgressCallCount()).To(Equal(2))
+
+			uploadedSize, totalSize := reporter.BlobUploadProgressArgsForCall(0)
+			Expect(uploadedSize).To(Equal(int64(243)))
+			Expect(totalSize).To(Equal(int64(488)))
+
+			uploadedSize, totalSize = reporter.BlobUploadProgressArgsForCall(1)
+			Expect(uploadedSize).To(Equal(int64(488)))
+			Expect(totalSize).To(Equal(int64(488)))
+		})
+
+		It("reports bytes sent of each blob as it is being uploaded", func() {
+			blobPath := filepath.Join("/", "dir", "blobs", "non-uploaded.tgz")
+
+			untracked := 0
+			uploadTracker.TrackUploadReturns(func() { untracked++ })
+
+			blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
+				if fileName == blobPath {
+					path, progress := uploadTracker.TrackUploadArgsForCall(0)
+					Expect(path).To(Equal(blobPath))
+
+					for _, sentSize := range []int64{10, 20, 30, 130, 243} {
+						progress(sentSize)
+					}
+				}
+				return "blob2", boshcrypto.MultipleDigest{}, nil
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			// Only reported once for each tenth of blob
+			Expect(reporter.BlobUploadSentCallCount()).To(Equal(4))
+
+			for i, sentSize := range []int64{10, 30, 130, 243} {
+				path, sent, size := reporter.BlobUploadSentArgsForCall(i)
+				Expect(path).To(Equal("non-uploaded.tgz"))
+				Expect(sent).To(Equal(sentSize))
+				Expect(size).To(Equal(int64(243)))
+			}
+
+			Expect(uploadTracker.TrackUploadCallCount()).To(Equal(2))
+			Expect(untracked).To(Equal(2))
+		})
+
+		It("uploads blobs in parallel", func() {
+			var mutex sync.Mutex
+			uploading, maxUploading := 0, 0
+
+			blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
+				mutex.Lock()
+				uploading++
+				if uploading > maxUploading {
+					maxUploading = uploading
+				}
+				mutex.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mutex.Lock()
+				uploading--
+				mutex.Unlock()
+
+				return "id-" + filepath.Base(fileName), boshcrypto.MultipleDigest{}, nil
+			}
+
+			err := blobsDir.UploadBlobs(2)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(maxUploading).To(Equal(2))
+
+			Expect(blobsDir.Blobs()).To(Equal([]Blob{
+				{Path: "already-downloaded.tgz", Size: 245, BlobstoreID: "blob4", SHA1: "blob4sha"},
+				{Path: filepath.Join("dir", "file-in-directory.tgz"), Size: 133, BlobstoreID: "blob1", SHA1: "blob1sha"},
+				{Path: "file-in-root.tgz", Size: 245, BlobstoreID: "blob3", SHA1: "blob3sha"},
+				{Path: "non-uploaded.tgz", Size: 243, BlobstoreID: "id-non-uploaded.tgz", SHA1: "blob2sha"},
+				{Path: "non-uploaded2.tgz", Size: 245, BlobstoreID: "id-non-uploaded2.tgz", SHA1: "blob5sha"},
+			}))
+		})
+	})
+
+	Describe("SaveBlobstoreID", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(filepath.Join("/", "dir", "config", "blobs.yml"), `
+file1.tgz:
+  object_id: blob1
+  size: 133
+  sha: blob1sha
+file2.tgz:
+  object_id: blob2
+  size: 245
+  sha: blob2sha
+`)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("replaces blobstore ID of the blob", func() {
+			err := blobsDir.SaveBlobstoreID("file2.tgz", "new-blob2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobsDir.Blobs()).To(Equal([]Blob{
+				{Path: "file1.tgz", Size: 133, BlobstoreID: "blob1", SHA1: "blob1sha"},
+				{Path: "file2.tgz", Size: 245, BlobstoreID: "new-blob2", SHA1: "blob2sha"},
+			}))
+		})
+
+		It("returns error if blob is not tracked", func() {
+			err := blobsDir.SaveBlobstoreID("unknown.tgz", "new-blob")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find blob for path 'unknown.tgz'"))
+		})
 	})
 })
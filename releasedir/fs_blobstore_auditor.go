@@ -0,0 +1,256 @@
+package releasedir
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cloudfoundry/bosh-utils/work"
+	"gopkg.in/yaml.v2"
+
+	boshidx "github.com/cloudfoundry/bosh-cli/v7/releasedir/index"
+)
+
+// FSBlobstoreAuditor checks that blobs referenced by a release directory
+// are intact and finds blobs in the blobstore that are no longer referenced.
+type FSBlobstoreAuditor struct {
+	blobsDir FSBlobsDir
+	indicies []boshidx.FSIndex
+	gitRepo  FSGitRepo
+
+	blobstore boshblob.DigestBlobstore
+	lister    BlobLister // nil if blobstore cannot list its blobs
+	parallel  int
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewFSBlobstoreAuditor(
+	blobsDir FSBlobsDir,
+	indicies []boshidx.FSIndex,
+	gitRepo FSGitRepo,
+	blobstore boshblob.DigestBlobstore,
+	lister BlobLister,
+	parallel int,
+	logger boshlog.Logger,
+) FSBlobstoreAuditor {
+	return FSBlobstoreAuditor{
+		blobsDir: blobsDir,
+		indicies: indicies,
+		gitRepo:  gitRepo,
+
+		blobstore: blobstore,
+		lister:    lister,
+		parallel:  parallel,
+
+		logTag: "releasedir.FSBlobstoreAuditor",
+		logger: logger,
+	}
+}
+
+func (a FSBlobstoreAuditor) Audit(history bool) (BlobstoreAudit, error) {
+	var audit BlobstoreAudit
+
+	err := a.blobstore.Validate()
+	if err != nil {
+		return audit, bosherr.WrapError(err, "Validating blobstore")
+	}
+
+	refs := auditedBlobRefs{}
+
+	err = a.collectWorkingTree(refs)
+	if err != nil {
+		return audit, err
+	}
+
+	if history {
+		err = a.collectHistory(refs)
+		if err != nil {
+			return audit, err
+		}
+	}
+
+	audit.Blobs = refs.Blobs()
+
+	var tasks []func() error
+
+	for i := range audit.Blobs {
+		blob := &audit.Blobs[i]
+		tasks = append(tasks, func() error {
+			blob.Err = a.verify(*blob)
+			return nil
+		})
+	}
+
+	err = work.Pool{Count: a.parallel}.ParallelDo(tasks...)
+	if err != nil {
+		return audit, err
+	}
+
+	if a.lister == nil {
+		return audit, nil
+	}
+
+	audit.ListScope, audit.SharedListScope, err = a.lister.ListScope()
+	if err != nil {
+		return audit, bosherr.WrapError(err, "Describing listed blobs")
+	}
+
+	blobIDs, err := a.lister.List()
+	if err != nil {
+		return audit, bosherr.WrapError(err, "Listing blobs")
+	}
+
+	audit.Listable = true
+
+	for _, blobID := range blobIDs {
+		if _, found := refs[blobID]; !found {
+			audit.Orphans = append(audit.Orphans, blobID)
+		}
+	}
+
+	sort.Strings(audit.Orphans)
+
+	return audit, nil
+}
+
+func (a FSBlobstoreAuditor) DeleteOrphans(blobIDs []string) error {
+	var errs []error
+
+	for _, blobID := range blobIDs {
+		err := a.blobstore.Delete(blobID)
+		if err != nil {
+			errs = append(errs, bosherr.WrapErrorf(err, "Deleting blob '%s'", blobID))
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bosherr.NewMultiError(errs...), "Deleting orphaned blobs")
+	}
+
+	return nil
+}
+
+func (a FSBlobstoreAuditor) collectWorkingTree(refs auditedBlobRefs) error {
+	blobs, err := a.blobsDir.Blobs()
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		refs.Add(blob.BlobstoreID, blob.SHA1, blob.Path)
+	}
+
+	for _, index := range a.indicies {
+		entries, err := index.Entries()
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			refs.Add(entry.BlobstoreID, entry.SHA1, fmt.Sprintf("%s/%s", entry.Name, entry.Version))
+		}
+	}
+
+	return nil
+}
+
+func (a FSBlobstoreAuditor) collectHistory(refs auditedBlobRefs) error {
+	files, err := a.gitRepo.HistoricalFiles(filepath.Join("config", "blobs.yml"), ".final_builds")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		switch {
+		case strings.HasSuffix(file.Path, "config/blobs.yml"):
+			var schema fsBlobsDirSchema
+
+			err := yaml.Unmarshal(file.Contents, &schema)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Unmarshalling blobs index '%s' from history", file.Path)
+			}
+
+			for blobPath, blob := range schema {
+				refs.Add(blob.BlobstoreID, blob.SHA1, blobPath)
+			}
+
+		case filepath.Base(file.Path) == "index.yml":
+			// Each job and package keeps its index in a directory named after it
+			name := filepath.Base(filepath.Dir(file.Path))
+
+			entries, err := boshidx.ParseEntries(name, file.Contents)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Parsing index '%s' from history", file.Path)
+			}
+
+			for _, entry := range entries {
+				refs.Add(entry.BlobstoreID, entry.SHA1, fmt.Sprintf("%s/%s", entry.Name, entry.Version))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a FSBlobstoreAuditor) verify(blob AuditedBlob) error {
+	digest, err := boshcrypto.ParseMultipleDigest(blob.SHA1)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing digest '%s'", blob.SHA1)
+	}
+
+	path, err := a.blobstore.Get(blob.BlobstoreID, digest)
+	if err != nil {
+		return err
+	}
+
+	err = a.blobstore.CleanUp(path)
+	if err != nil {
+		a.logger.Error(a.logTag, "Cleaning up blob '%s': %s", path, err)
+	}
+
+	return nil
+}
+
+// auditedBlobRefs collects references to each blob by its blobstore ID.
+type auditedBlobRefs map[string]*AuditedBlob
+
+func (r auditedBlobRefs) Add(blobID, sha1, desc string) {
+	// Blobs that were never uploaded only exist locally
+	if len(blobID) == 0 {
+		return
+	}
+
+	blob, found := r[blobID]
+	if !found {
+		blob = &AuditedBlob{BlobstoreID: blobID, SHA1: sha1}
+		r[blobID] = blob
+	}
+
+	for _, existing := range blob.ReferencedBy {
+		if existing == desc {
+			return
+		}
+	}
+
+	blob.ReferencedBy = append(blob.ReferencedBy, desc)
+}
+
+func (r auditedBlobRefs) Blobs() []AuditedBlob {
+	var blobs []AuditedBlob
+
+	for _, blob := range r {
+		sort.Strings(blob.ReferencedBy)
+		blobs = append(blobs, *blob)
+	}
+
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].BlobstoreID < blobs[j].BlobstoreID })
+
+	return blobs
+}
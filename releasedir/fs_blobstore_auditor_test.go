@@ -0,0 +1,225 @@
+package releasedir_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakecrypto "github.com/cloudfoundry/bosh-cli/v7/crypto/fakes"
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	boshidx "github.com/cloudfoundry/bosh-cli/v7/releasedir/index"
+	fakeidx "github.com/cloudfoundry/bosh-cli/v7/releasedir/index/indexfakes"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+)
+
+type fakeBlobLister struct {
+	blobIDs []string
+	err     error
+
+	scope    string
+	shared   bool
+	scopeErr error
+}
+
+func (l fakeBlobLister) List() ([]string, error) { return l.blobIDs, l.err }
+
+func (l fakeBlobLister) ListScope() (string, bool, error) { return l.scope, l.shared, l.scopeErr }
+
+var _ = Describe("FSBlobstoreAuditor", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		blobstore *fakereldir.FakeDigestBlobstore
+		lister    BlobLister
+	)
+
+	blobsPath := filepath.Join("/", "dir", "config", "blobs.yml")
+	jobIndexPath := filepath.Join("/", "dir", ".final_builds", "jobs", "job", "index.yml")
+	licIndexPath := filepath.Join("/", "dir", ".final_builds", "license", "index.yml")
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		blobstore = &fakereldir.FakeDigestBlobstore{}
+		lister = nil
+
+		err := fs.WriteFileString(blobsPath, `
+file1.tgz: {object_id: blob1, size: 1, sha: blob1sha}
+local.tgz: {size: 2, sha: localsha}
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString(jobIndexPath, `---
+builds:
+  fp: {version: fp, blobstore_id: job-blob, sha1: jobsha}
+format-version: "2"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.SetGlob(filepath.Join("/", "dir", ".final_builds", "jobs", "*", "index.yml"), []string{jobIndexPath})
+
+		err = fs.WriteFileString(licIndexPath, `---
+builds:
+  lic-fp: {version: lic-fp, blobstore_id: job-blob, sha1: jobsha}
+format-version: "2"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+			return "/tmp/" + blobID, nil
+		}
+	})
+
+	buildAuditor := func() FSBlobstoreAuditor {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		blobsDir := NewFSBlobsDir(filepath.Join("/", "dir"), &fakereldir.FakeBlobsDirReporter{},
+			blobstore, NewUploadTrackingFileSystem(fs), fakecrypto.NewFakeDigestCalculator(), clock.NewClock(), fs, logger)
+
+		indexReporter := &fakeidx.FakeReporter{}
+		indexBlobs := &fakeidx.FakeIndexBlobs{}
+		indicies := []boshidx.FSIndex{
+			boshidx.NewFSIndex("job", filepath.Join("/", "dir", ".final_builds", "jobs"), true, true, indexReporter, indexBlobs, fs),
+			boshidx.NewFSIndex("license", filepath.Join("/", "dir", ".final_builds", "license"), false, true, indexReporter, indexBlobs, fs),
+		}
+
+		gitRepo := NewFSGitRepo(filepath.Join("/", "dir"), cmdRunner, fs)
+
+		return NewFSBlobstoreAuditor(blobsDir, indicies, gitRepo, blobstore, lister, 2, logger)
+	}
+
+	Describe("Audit", func() {
+		It("verifies blobs referenced by blobs.yml and final indices of the working tree", func() {
+			audit, err := buildAuditor().Audit(false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(audit).To(Equal(BlobstoreAudit{
+				Blobs: []AuditedBlob{
+					{BlobstoreID: "blob1", SHA1: "blob1sha", ReferencedBy: []string{"file1.tgz"}},
+					{BlobstoreID: "job-blob", SHA1: "jobsha", ReferencedBy: []string{"job/fp", "license/lic-fp"}},
+				},
+			}))
+
+			Expect(blobstore.GetCallCount()).To(Equal(2))
+			Expect(blobstore.CleanUpCallCount()).To(Equal(2))
+
+			Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+		})
+
+		It("records blobs that cannot be downloaded or do not match their digest", func() {
+			blobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+				if blobID == "blob1" {
+					return "", errors.New("fake-digest-err")
+				}
+				return "/tmp/" + blobID, nil
+			}
+
+			audit, err := buildAuditor().Audit(false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(audit.Blobs[0].Err).To(Equal(errors.New("fake-digest-err")))
+			Expect(audit.Blobs[1].Err).ToNot(HaveOccurred())
+		})
+
+		It("includes blobs referenced by any version of blobs.yml and final indices in git history", func() {
+			cmdRunner.AddCmdResult("git rev-list --all --objects -- config/blobs.yml .final_builds", fakesys.FakeCmdResult{
+				Stdout: "commit1\nobj1 config/blobs.yml\nobj2 .final_builds/packages/pkg/index.yml\nobj3 .final_builds/packages/pkg/other\n",
+			})
+
+			blobsYML := "old.tgz: {object_id: old-blob, size: 1, sha: oldsha}\n"
+			indexYML := "builds: {pfp: {version: pfp, blobstore_id: pkg-blob, sha1: pkgsha}}\n"
+
+			cmdRunner.AddCmdResult("git cat-file --batch", fakesys.FakeCmdResult{
+				Stdout: fmt.Sprintf("obj1 blob %d\n%s\nobj2 blob %d\n%s\nobj3 blob 1\n-\n",
+					len(blobsYML), blobsYML, len(indexYML), indexYML),
+			})
+
+			audit, err := buildAuditor().Audit(true)
+			Expect(err).ToNot(HaveOccurred())
+
+			var blobIDs, referencedBy []string
+
+			for _, blob := range audit.Blobs {
+				blobIDs = append(blobIDs, blob.BlobstoreID)
+				referencedBy = append(referencedBy, blob.ReferencedBy...)
+			}
+
+			Expect(blobIDs).To(Equal([]string{"blob1", "job-blob", "old-blob", "pkg-blob"}))
+			Expect(referencedBy).To(ContainElement("old.tgz"))
+			Expect(referencedBy).To(ContainElement("pkg/pfp"))
+
+			Expect(blobstore.GetCallCount()).To(Equal(4))
+		})
+
+		It("returns error if history cannot be read", func() {
+			cmdRunner.AddCmdResult("git rev-list --all --objects -- config/blobs.yml .final_builds", fakesys.FakeCmdResult{
+				Error: errors.New("fake-err"),
+			})
+
+			_, err := buildAuditor().Audit(true)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Listing files in history"))
+		})
+
+		It("returns error if blobstore is not valid", func() {
+			blobstore.ValidateReturns(errors.New("fake-err"))
+
+			_, err := buildAuditor().Audit(false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating blobstore"))
+
+			Expect(blobstore.GetCallCount()).To(Equal(0))
+		})
+
+		Context("when blobstore can list its blobs", func() {
+			It("returns blobs that are not referenced as orphans", func() {
+				lister = fakeBlobLister{blobIDs: []string{"orphan2", "blob1", "orphan1", "job-blob"}, scope: "s3://bucket/", shared: true}
+
+				audit, err := buildAuditor().Audit(false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(audit.Listable).To(BeTrue())
+				Expect(audit.Orphans).To(Equal([]string{"orphan1", "orphan2"}))
+				Expect(audit.ListScope).To(Equal("s3://bucket/"))
+				Expect(audit.SharedListScope).To(BeTrue())
+			})
+
+			It("returns error if listed blobs cannot be described", func() {
+				lister = fakeBlobLister{scopeErr: errors.New("fake-err")}
+
+				_, err := buildAuditor().Audit(false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("returns error if listing fails", func() {
+				lister = fakeBlobLister{err: errors.New("fake-err")}
+
+				_, err := buildAuditor().Audit(false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Listing blobs"))
+			})
+		})
+	})
+
+	Describe("DeleteOrphans", func() {
+		It("deletes each blob and returns error for blobs that could not be deleted", func() {
+			blobstore.DeleteStub = func(blobID string) error {
+				if blobID == "orphan1" {
+					return errors.New("fake-err")
+				}
+				return nil
+			}
+
+			err := buildAuditor().DeleteOrphans([]string{"orphan1", "orphan2"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deleting blob 'orphan1'"))
+			Expect(err.Error()).ToNot(ContainSubstring("orphan2"))
+
+			Expect(blobstore.DeleteCallCount()).To(Equal(2))
+		})
+	})
+})
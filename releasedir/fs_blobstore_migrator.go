@@ -0,0 +1,298 @@
+package releasedir
+
+import (
+	"fmt"
+	"path/filepath"
+
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	boshidx "github.com/cloudfoundry/bosh-cli/v7/releasedir/index"
+)
+
+// FSBlobstoreMigrator copies blobs of a release directory to another blobstore.
+// Copied blobs are recorded in a state file so that an interrupted migration
+// can be resumed. Blobstore IDs are only rewritten once all blobs are copied,
+// together with switching the release blobstore.
+type FSBlobstoreMigrator struct {
+	statePath string
+
+	config   FSConfig
+	toConfig FSConfig
+
+	blobsDir FSBlobsDir
+	indicies []boshidx.FSIndex
+
+	blobstore   boshblob.DigestBlobstore
+	toBlobstore boshblob.DigestBlobstore
+
+	reporter BlobstoreMigrationReporter
+	fs       boshsys.FileSystem
+
+	logTag string
+	logger boshlog.Logger
+}
+
+/*
+# .blobstore-migration.yml
+---
+blobstore_ids:
+  36764f38-6274-4a5d-8faa-26c31a745cb2: 0fa3c3fc-6124-46ec-8aa9-7376bf23db9d
+*/
+
+type fsBlobstoreMigrationSchema struct {
+	BlobstoreIDs map[string]string `yaml:"blobstore_ids"`
+}
+
+func NewFSBlobstoreMigrator(
+	dirPath string,
+	config FSConfig,
+	toConfig FSConfig,
+	blobsDir FSBlobsDir,
+	indicies []boshidx.FSIndex,
+	blobstore boshblob.DigestBlobstore,
+	toBlobstore boshblob.DigestBlobstore,
+	reporter BlobstoreMigrationReporter,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) FSBlobstoreMigrator {
+	return FSBlobstoreMigrator{
+		statePath: filepath.Join(dirPath, ".blobstore-migration.yml"),
+
+		config:   config,
+		toConfig: toConfig,
+
+		blobsDir: blobsDir,
+		indicies: indicies,
+
+		blobstore:   blobstore,
+		toBlobstore: toBlobstore,
+
+		reporter: reporter,
+		fs:       fs,
+
+		logTag: "releasedir.FSBlobstoreMigrator",
+		logger: logger,
+	}
+}
+
+func (m FSBlobstoreMigrator) Migrate() (BlobstoreMigration, error) {
+	var migration BlobstoreMigration
+
+	err := m.toBlobstore.Validate()
+	if err != nil {
+		return migration, bosherr.WrapError(err, "Validating new blobstore")
+	}
+
+	state, err := m.readState()
+	if err != nil {
+		return migration, err
+	}
+
+	var errs []error
+
+	blobs, err := m.blobsDir.Blobs()
+	if err != nil {
+		return migration, err
+	}
+
+	for _, blob := range blobs {
+		// Blobs that were never uploaded only exist locally
+		if len(blob.BlobstoreID) == 0 {
+			continue
+		}
+
+		err := m.migrateBlob(blob.Path, blob.BlobstoreID, blob.SHA1, state, &migration)
+		if err != nil {
+			errs = append(errs, bosherr.WrapErrorf(err, "Migrating blob for path '%s'", blob.Path))
+		}
+	}
+
+	for _, index := range m.indicies {
+		entries, err := index.Entries()
+		if err != nil {
+			return migration, err
+		}
+
+		for _, entry := range entries {
+			desc := fmt.Sprintf("%s/%s", entry.Name, entry.Version)
+
+			err := m.migrateBlob(desc, entry.BlobstoreID, entry.SHA1, state, &migration)
+			if err != nil {
+				errs = append(errs, bosherr.WrapErrorf(err, "Migrating blob for '%s'", desc))
+			}
+		}
+	}
+
+	// References keep pointing to the release blobstore until all blobs are copied
+	if len(errs) > 0 {
+		return migration, bosherr.WrapError(bosherr.NewMultiError(errs...),
+			"Migrating blobs (run the command again to resume)")
+	}
+
+	err = m.rewriteBlobstoreIDs(blobs, state)
+	if err != nil {
+		return migration, bosherr.WrapError(err, "Rewriting blobstore IDs (run the command again to resume)")
+	}
+
+	err = m.config.SaveBlobstore(m.toConfig)
+	if err != nil {
+		return migration, bosherr.WrapError(err, "Switching release blobstore")
+	}
+
+	err = m.fs.RemoveAll(m.statePath)
+	if err != nil {
+		return migration, bosherr.WrapErrorf(err, "Removing migration state '%s'", m.statePath)
+	}
+
+	return migration, nil
+}
+
+// rewriteBlobstoreIDs points blobs.yml and final indices to the copied blobs
+// right before the release blobstore is switched. References that were
+// rewritten by an interrupted attempt are no longer in the migration state.
+func (m FSBlobstoreMigrator) rewriteBlobstoreIDs(blobs []Blob, state fsBlobstoreMigrationSchema) error {
+	for _, blob := range blobs {
+		newBlobID, found := state.BlobstoreIDs[blob.BlobstoreID]
+		if !found {
+			continue
+		}
+
+		err := m.blobsDir.SaveBlobstoreID(blob.Path, newBlobID)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Saving blobstore ID for path '%s'", blob.Path)
+		}
+	}
+
+	for _, index := range m.indicies {
+		entries, err := index.Entries()
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			newBlobID, found := state.BlobstoreIDs[entry.BlobstoreID]
+			if !found {
+				continue
+			}
+
+			err := index.SaveBlobstoreID(entry, newBlobID)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Saving blobstore ID for '%s/%s'", entry.Name, entry.Version)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m FSBlobstoreMigrator) migrateBlob(desc, blobID, sha1 string, state fsBlobstoreMigrationSchema, migration *BlobstoreMigration) error {
+	// Blob was copied by a previous attempt and possibly already referenced
+	for oldBlobID, newBlobID := range state.BlobstoreIDs {
+		if blobID == oldBlobID || blobID == newBlobID {
+			migration.Skipped++
+			return nil
+		}
+	}
+
+	m.reporter.BlobMigrationStarted(desc, blobID)
+
+	newBlobID, err := m.copyBlob(blobID, sha1)
+	if err != nil {
+		m.reporter.BlobMigrationFinished(desc, blobID, "", err)
+		return err
+	}
+
+	state.BlobstoreIDs[blobID] = newBlobID
+
+	err = m.writeState(state)
+	if err != nil {
+		m.reporter.BlobMigrationFinished(desc, blobID, newBlobID, err)
+		return err
+	}
+
+	m.reporter.BlobMigrationFinished(desc, blobID, newBlobID, nil)
+
+	migration.Copied++
+
+	return nil
+}
+
+func (m FSBlobstoreMigrator) copyBlob(blobID, sha1 string) (string, error) {
+	digest, err := boshcrypto.ParseMultipleDigest(sha1)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing digest '%s'", sha1)
+	}
+
+	path, err := m.blobstore.Get(blobID, digest)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Getting blob '%s'", blobID)
+	}
+
+	defer func() {
+		err := m.blobstore.CleanUp(path)
+		if err != nil {
+			m.logger.Error(m.logTag, "Cleaning up blob '%s': %s", path, err)
+		}
+	}()
+
+	newBlobID, _, err := m.toBlobstore.Create(path)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating blob in new blobstore")
+	}
+
+	// Downloading the copy verifies that it was stored intact
+	copyPath, err := m.toBlobstore.Get(newBlobID, digest)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Verifying blob '%s' in new blobstore", newBlobID)
+	}
+
+	err = m.toBlobstore.CleanUp(copyPath)
+	if err != nil {
+		m.logger.Error(m.logTag, "Cleaning up blob '%s': %s", copyPath, err)
+	}
+
+	return newBlobID, nil
+}
+
+func (m FSBlobstoreMigrator) readState() (fsBlobstoreMigrationSchema, error) {
+	state := fsBlobstoreMigrationSchema{BlobstoreIDs: map[string]string{}}
+
+	if !m.fs.FileExists(m.statePath) {
+		return state, nil
+	}
+
+	bytes, err := m.fs.ReadFile(m.statePath)
+	if err != nil {
+		return state, bosherr.WrapErrorf(err, "Reading migration state '%s'", m.statePath)
+	}
+
+	err = yaml.Unmarshal(bytes, &state)
+	if err != nil {
+		return state, bosherr.WrapErrorf(err, "Unmarshalling migration state '%s'", m.statePath)
+	}
+
+	if state.BlobstoreIDs == nil {
+		state.BlobstoreIDs = map[string]string{}
+	}
+
+	return state, nil
+}
+
+func (m FSBlobstoreMigrator) writeState(state fsBlobstoreMigrationSchema) error {
+	bytes, err := yaml.Marshal(state)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling migration state")
+	}
+
+	err = m.fs.WriteFile(m.statePath, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing migration state '%s'", m.statePath)
+	}
+
+	return nil
+}
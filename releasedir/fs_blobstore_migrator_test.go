@@ -0,0 +1,283 @@
+package releasedir_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakecrypto "github.com/cloudfoundry/bosh-cli/v7/crypto/fakes"
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	boshidx "github.com/cloudfoundry/bosh-cli/v7/releasedir/index"
+	fakeidx "github.com/cloudfoundry/bosh-cli/v7/releasedir/index/indexfakes"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+)
+
+var _ = Describe("FSBlobstoreMigrator", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		blobstore   *fakereldir.FakeDigestBlobstore
+		toBlobstore *fakereldir.FakeDigestBlobstore
+		reporter    *fakereldir.FakeBlobstoreMigrationReporter
+		migrator    FSBlobstoreMigrator
+	)
+
+	blobsPath := filepath.Join("/", "dir", "config", "blobs.yml")
+	jobIndexPath := filepath.Join("/", "dir", ".final_builds", "jobs", "job", "index.yml")
+	licIndexPath := filepath.Join("/", "dir", ".final_builds", "license", "index.yml")
+	finalPath := filepath.Join("/", "dir", "config", "final.yml")
+	statePath := filepath.Join("/", "dir", ".blobstore-migration.yml")
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		blobstore = &fakereldir.FakeDigestBlobstore{}
+		toBlobstore = &fakereldir.FakeDigestBlobstore{}
+		reporter = &fakereldir.FakeBlobstoreMigrationReporter{}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		config := NewFSConfig(finalPath, filepath.Join("/", "dir", "config", "private.yml"), fs)
+		toConfig := NewFSConfig(filepath.Join("/", "new-final.yml"), filepath.Join("/", "new-private.yml"), fs)
+
+		blobsDir := NewFSBlobsDir(filepath.Join("/", "dir"), &fakereldir.FakeBlobsDirReporter{},
+			blobstore, NewUploadTrackingFileSystem(fs), fakecrypto.NewFakeDigestCalculator(), clock.NewClock(), fs, logger)
+
+		indexReporter := &fakeidx.FakeReporter{}
+		indexBlobs := &fakeidx.FakeIndexBlobs{}
+		indicies := []boshidx.FSIndex{
+			boshidx.NewFSIndex("job", filepath.Join("/", "dir", ".final_builds", "jobs"), true, true, indexReporter, indexBlobs, fs),
+			boshidx.NewFSIndex("license", filepath.Join("/", "dir", ".final_builds", "license"), false, true, indexReporter, indexBlobs, fs),
+		}
+
+		migrator = NewFSBlobstoreMigrator(filepath.Join("/", "dir"), config, toConfig,
+			blobsDir, indicies, blobstore, toBlobstore, reporter, fs, logger)
+
+		err := fs.WriteFileString(finalPath, "name: release\nblobstore: {provider: s3, options: {bucket_name: old}}")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString(filepath.Join("/", "new-final.yml"), "blobstore: {provider: gcs, options: {bucket_name: new}}")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString(blobsPath, `
+file1.tgz: {object_id: blob1, size: 1, sha: blob1sha}
+local.tgz: {size: 2, sha: localsha}
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString(jobIndexPath, `---
+builds:
+  fp: {version: fp, blobstore_id: job-blob, sha1: jobsha}
+format-version: "2"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.SetGlob(filepath.Join("/", "dir", ".final_builds", "jobs", "*", "index.yml"), []string{jobIndexPath})
+
+		err = fs.WriteFileString(licIndexPath, `---
+builds:
+  lic-fp: {version: lic-fp, blobstore_id: lic-blob, sha1: licsha}
+format-version: "2"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+			return "/tmp/" + blobID, nil
+		}
+
+		toBlobstore.CreateStub = func(path string) (string, boshcrypto.MultipleDigest, error) {
+			return "new-" + filepath.Base(path), boshcrypto.MultipleDigest{}, nil
+		}
+
+		toBlobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+			return "/tmp/copy-" + blobID, nil
+		}
+	})
+
+	blobIDs := func() []string {
+		blobs, err := NewFSBlobsDir(filepath.Join("/", "dir"), nil, nil, nil, nil, nil, fs, nil).Blobs()
+		Expect(err).ToNot(HaveOccurred())
+
+		var ids []string
+
+		for _, blob := range blobs {
+			ids = append(ids, blob.BlobstoreID)
+		}
+
+		for _, index := range []boshidx.FSIndex{
+			boshidx.NewFSIndex("job", filepath.Join("/", "dir", ".final_builds", "jobs"), true, true, nil, nil, fs),
+			boshidx.NewFSIndex("license", filepath.Join("/", "dir", ".final_builds", "license"), false, true, nil, nil, fs),
+		} {
+			entries, err := index.Entries()
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, entry := range entries {
+				ids = append(ids, entry.BlobstoreID)
+			}
+		}
+
+		return ids
+	}
+
+	It("copies blobs of blobs.yml and final indices and rewrites their blobstore IDs", func() {
+		migration, err := migrator.Migrate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migration).To(Equal(BlobstoreMigration{Copied: 3}))
+
+		Expect(blobIDs()).To(Equal([]string{"new-blob1", "", "new-job-blob", "new-lic-blob"}))
+
+		Expect(blobstore.GetCallCount()).To(Equal(3))
+
+		blobID, digest := blobstore.GetArgsForCall(0)
+		Expect(blobID).To(Equal("blob1"))
+		Expect(digest).To(Equal(boshcrypto.MustParseMultipleDigest("blob1sha")))
+
+		Expect(toBlobstore.CreateArgsForCall(0)).To(Equal("/tmp/blob1"))
+		Expect(blobstore.CleanUpArgsForCall(0)).To(Equal("/tmp/blob1"))
+	})
+
+	It("verifies copies by downloading them from the new blobstore", func() {
+		_, err := migrator.Migrate()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(toBlobstore.GetCallCount()).To(Equal(3))
+
+		blobID, digest := toBlobstore.GetArgsForCall(1)
+		Expect(blobID).To(Equal("new-job-blob"))
+		Expect(digest).To(Equal(boshcrypto.MustParseMultipleDigest("jobsha")))
+
+		Expect(toBlobstore.CleanUpArgsForCall(1)).To(Equal("/tmp/copy-new-job-blob"))
+	})
+
+	It("reports migrated blobs", func() {
+		_, err := migrator.Migrate()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(reporter.BlobMigrationStartedCallCount()).To(Equal(3))
+
+		desc, blobID := reporter.BlobMigrationStartedArgsForCall(1)
+		Expect(desc).To(Equal("job/fp"))
+		Expect(blobID).To(Equal("job-blob"))
+
+		desc, blobID, newBlobID, err := reporter.BlobMigrationFinishedArgsForCall(2)
+		Expect(desc).To(Equal("license/lic-fp"))
+		Expect(blobID).To(Equal("lic-blob"))
+		Expect(newBlobID).To(Equal("new-lic-blob"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("switches release blobstore to the public part of new config and removes migration state", func() {
+		_, err := migrator.Migrate()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fs.ReadFileString(finalPath)).To(Equal(
+			"name: release\nblobstore:\n  provider: gcs\n  options:\n    bucket_name: new\n"))
+
+		Expect(fs.FileExists(statePath)).To(BeFalse())
+	})
+
+	It("returns error if new blobstore is not valid", func() {
+		toBlobstore.ValidateReturns(errors.New("fake-err"))
+
+		_, err := migrator.Migrate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Validating new blobstore"))
+
+		Expect(blobstore.GetCallCount()).To(Equal(0))
+	})
+
+	Context("when some blobs fail to be copied", func() {
+		BeforeEach(func() {
+			toBlobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+				if blobID == "new-job-blob" {
+					return "", errors.New("fake-digest-err")
+				}
+				return "/tmp/copy-" + blobID, nil
+			}
+		})
+
+		It("copies other blobs, keeps release blobstore and blobstore IDs and returns error", func() {
+			migration, err := migrator.Migrate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Migrating blob for 'job/fp'"))
+			Expect(err.Error()).To(ContainSubstring("fake-digest-err"))
+			Expect(migration).To(Equal(BlobstoreMigration{Copied: 2}))
+
+			Expect(blobIDs()).To(Equal([]string{"blob1", "", "job-blob", "lic-blob"}))
+
+			Expect(fs.ReadFileString(finalPath)).To(ContainSubstring("provider: s3"))
+			Expect(fs.ReadFileString(statePath)).To(Equal("blobstore_ids:\n  blob1: new-blob1\n  lic-blob: new-lic-blob\n"))
+
+			_, _, _, reportedErr := reporter.BlobMigrationFinishedArgsForCall(1)
+			Expect(reportedErr).To(HaveOccurred())
+		})
+
+		It("resumes by copying only blobs that were not copied before", func() {
+			_, err := migrator.Migrate()
+			Expect(err).To(HaveOccurred())
+
+			toBlobstore.GetStub = nil
+			toBlobstore.GetReturns("/tmp/copy", nil)
+
+			migration, err := migrator.Migrate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(migration).To(Equal(BlobstoreMigration{Copied: 1, Skipped: 2}))
+
+			Expect(toBlobstore.CreateCallCount()).To(Equal(4))
+			Expect(toBlobstore.CreateArgsForCall(3)).To(Equal("/tmp/job-blob"))
+
+			Expect(blobIDs()).To(Equal([]string{"new-blob1", "", "new-job-blob", "new-lic-blob"}))
+
+			Expect(fs.ReadFileString(finalPath)).To(ContainSubstring("provider: gcs"))
+		})
+	})
+
+	It("rewrites blobstore IDs of blobs that were copied before rewriting failed", func() {
+		err := fs.WriteFileString(statePath, "blobstore_ids: {blob1: copied-blob1}")
+		Expect(err).ToNot(HaveOccurred())
+
+		migration, err := migrator.Migrate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migration).To(Equal(BlobstoreMigration{Copied: 2, Skipped: 1}))
+
+		Expect(blobIDs()).To(Equal([]string{"copied-blob1", "", "new-job-blob", "new-lic-blob"}))
+	})
+
+	It("resumes blobs that were referenced by new blobstore IDs when an earlier attempt was interrupted", func() {
+		err := fs.WriteFileString(statePath, "blobstore_ids: {blob1: copied-blob1, job-blob: copied-job-blob}")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fs.WriteFileString(blobsPath, "file1.tgz: {object_id: copied-blob1, size: 1, sha: blob1sha}\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		migration, err := migrator.Migrate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migration).To(Equal(BlobstoreMigration{Copied: 1, Skipped: 2}))
+
+		Expect(blobIDs()).To(Equal([]string{"copied-blob1", "copied-job-blob", "new-lic-blob"}))
+		Expect(fs.ReadFileString(finalPath)).To(ContainSubstring("provider: gcs"))
+	})
+
+	It("keeps release blobstore and migration state if blobstore IDs cannot be rewritten", func() {
+		fs.WriteFileErrors[jobIndexPath] = errors.New("fake-err")
+
+		_, err := migrator.Migrate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Rewriting blobstore IDs"))
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+		Expect(fs.ReadFileString(finalPath)).To(ContainSubstring("provider: s3"))
+		Expect(fs.FileExists(statePath)).To(BeTrue())
+	})
+
+	It("returns error if migration state cannot be read", func() {
+		err := fs.WriteFileString(statePath, "-")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = migrator.Migrate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling migration state"))
+	})
+})
@@ -76,17 +76,30 @@ func (c FSConfig) SaveName(name string) error {
 	publicSchema.FinalName = ""
 	publicSchema.Name = name
 
-	bytes, err := yaml.Marshal(publicSchema)
+	return c.write(publicSchema)
+}
+
+// SaveBlobstore replaces blobstore with the one configured in other config.
+// Only public options are saved so that credentials stay out of public config.
+func (c FSConfig) SaveBlobstore(other FSConfig) error {
+	publicSchema, _, err := c.read()
 	if err != nil {
-		return bosherr.WrapError(err, "Marshalling config")
+		return err
 	}
 
-	err = c.fs.WriteFile(c.publicPath, bytes)
+	otherPublicSchema, _, err := other.read()
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Writing config '%s'", c.publicPath)
+		return err
 	}
 
-	return nil
+	if len(otherPublicSchema.Blobstore.Provider) == 0 {
+		return bosherr.Errorf(
+			"Expected non-empty 'blobstore.provider' in config '%s'", other.publicPath)
+	}
+
+	publicSchema.Blobstore = otherPublicSchema.Blobstore
+
+	return c.write(publicSchema)
 }
 
 func (c FSConfig) Blobstore() (string, map[string]interface{}, error) {
@@ -113,6 +126,20 @@ func (c FSConfig) Blobstore() (string, map[string]interface{}, error) {
 	return publicSchema.Blobstore.Provider, opts, nil
 }
 
+func (c FSConfig) write(publicSchema fsConfigPublicSchema) error {
+	bytes, err := yaml.Marshal(publicSchema)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling config")
+	}
+
+	err = c.fs.WriteFile(c.publicPath, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing config '%s'", c.publicPath)
+	}
+
+	return nil
+}
+
 func (c FSConfig) read() (fsConfigPublicSchema, fsConfigPrivateSchema, error) {
 	var publicSchema fsConfigPublicSchema
 	var privateSchema fsConfigPrivateSchema
@@ -267,4 +267,60 @@ var _ = Describe("FSConfig", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
 	})
+
+	Describe("SaveBlobstore", func() {
+		var (
+			otherConfig FSConfig
+		)
+
+		BeforeEach(func() {
+			otherConfig = NewFSConfig("/other/public.yml", "/other/private.yml", fs)
+
+			err := fs.WriteFileString("/dir/public.yml", "name: name\nblobstore: {provider: s3, options: {bucket_name: bucket}}")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("replaces blobstore with public blobstore of other config keeping other entries", func() {
+			err := fs.WriteFileString("/other/public.yml", "blobstore: {provider: gcs, options: {bucket_name: other-bucket}}")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/other/private.yml", "blobstore: {options: {json_key: secret}}")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = config.SaveBlobstore(otherConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/dir/public.yml")).To(Equal(
+				"name: name\nblobstore:\n  provider: gcs\n  options:\n    bucket_name: other-bucket\n"))
+		})
+
+		It("returns error if other blobstore provider is empty", func() {
+			err := fs.WriteFileString("/other/public.yml", "blobstore: {options: {bucket_name: other-bucket}}")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = config.SaveBlobstore(otherConfig)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected non-empty 'blobstore.provider' in config '/other/public.yml'"))
+		})
+
+		It("returns error if cannot unmarshal other public config", func() {
+			err := fs.WriteFileString("/other/public.yml", "-")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = config.SaveBlobstore(otherConfig)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("line 1"))
+		})
+
+		It("returns error if cannot write public config", func() {
+			err := fs.WriteFileString("/other/public.yml", "blobstore: {provider: gcs}")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileError = errors.New("fake-err")
+
+			err = config.SaveBlobstore(otherConfig)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
 })
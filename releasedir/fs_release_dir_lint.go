@@ -0,0 +1,431 @@
+package releasedir
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshjobman "github.com/cloudfoundry/bosh-cli/v7/release/job/manifest"
+	boshpkgman "github.com/cloudfoundry/bosh-cli/v7/release/pkg/manifest"
+)
+
+const (
+	LintMissingPackage     = "missing-package"
+	LintPackageCycle       = "package-cycle"
+	LintMissingTemplate    = "missing-template"
+	LintUnusedTemplate     = "unused-template"
+	LintUndeclaredProperty = "undeclared-property"
+	LintUnmatchedFiles     = "unmatched-files"
+	LintInvalidSpec        = "invalid-spec"
+	LintBuild              = "build"
+)
+
+// LintProblem is a problem found in a job or package of a release directory.
+type LintProblem struct {
+	Check    string
+	Resource string // e.g. 'jobs/web' or 'packages/ruby'
+	Message  string
+}
+
+type lintPackage struct {
+	name         string
+	dependencies []string
+	files        []string
+}
+
+var (
+	// Matches p(...) and if_p(...) but not link(...).p(...)
+	lintPropertyCallRegexp = regexp.MustCompile(`(?:^|[^.\w])(?:if_p|p)\s*\(\s*(\[[^\]]*\]|"[^"]*"|'[^']*')`)
+	lintPropertyNameRegexp = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+)
+
+// Lint checks jobs and packages of the release directory for problems
+// that would make the release fail to build or deploy. Problems are
+// sorted by resource.
+func (d FSReleaseDir) Lint() ([]LintProblem, error) {
+	var problems []LintProblem
+
+	pkgs, pkgProblems, err := d.lintPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	problems = append(problems, pkgProblems...)
+
+	jobProblems, err := d.lintJobs(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	problems = append(problems, jobProblems...)
+
+	// Building the release catches the rest, e.g. missing packaging
+	// scripts, but would only repeat problems that were already found
+	if len(problems) == 0 {
+		release, err := d.releaseReader.Read(d.dirPath)
+		if err != nil {
+			problems = append(problems, LintProblem{Check: LintBuild, Resource: ".", Message: err.Error()})
+		} else {
+			err = release.CleanUp()
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Cleaning up release")
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Resource < problems[j].Resource
+	})
+
+	return problems, nil
+}
+
+func (d FSReleaseDir) lintPackages() (map[string]lintPackage, []LintProblem, error) {
+	var problems []LintProblem
+
+	pkgs := map[string]lintPackage{}
+
+	pkgDirs, err := d.lintDirs("packages")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Blobs that were not synced yet are only listed in blobs.yml
+	blobs, err := d.blobsDir.Blobs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pkgDir := range pkgDirs {
+		resource := filepath.Join("packages", filepath.Base(pkgDir))
+
+		pkg, err := d.readLintPackage(pkgDir)
+		if err != nil {
+			problems = append(problems, LintProblem{Check: LintInvalidSpec, Resource: resource, Message: err.Error()})
+			continue
+		}
+
+		pkgs[pkg.name] = pkg
+
+		for _, glob := range pkg.files {
+			found, err := d.lintFilesMatch(glob, blobs)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if !found {
+				problems = append(problems, LintProblem{
+					Check:    LintUnmatchedFiles,
+					Resource: resource,
+					Message:  "Files pattern '" + glob + "' does not match any files in src or blobs",
+				})
+			}
+		}
+	}
+
+	for _, pkg := range pkgs {
+		for _, dep := range pkg.dependencies {
+			if _, found := pkgs[dep]; !found {
+				problems = append(problems, LintProblem{
+					Check:    LintMissingPackage,
+					Resource: filepath.Join("packages", pkg.name),
+					Message:  "Dependency '" + dep + "' is not a package of the release",
+				})
+			}
+		}
+	}
+
+	for _, cycle := range d.lintPackageCycles(pkgs) {
+		problems = append(problems, LintProblem{
+			Check:    LintPackageCycle,
+			Resource: filepath.Join("packages", cycle[0]),
+			Message:  "Dependencies form a cycle: " + strings.Join(cycle, " -> "),
+		})
+	}
+
+	return pkgs, problems, nil
+}
+
+func (d FSReleaseDir) readLintPackage(pkgDir string) (lintPackage, error) {
+	lockPath := filepath.Join(pkgDir, "spec.lock")
+
+	// Vendored packages only keep a lock with their dependencies
+	if d.fs.FileExists(lockPath) {
+		lock, err := boshpkgman.NewManifestLockFromPath(lockPath, d.fs)
+		if err != nil {
+			return lintPackage{}, err
+		}
+
+		return lintPackage{name: lock.Name, dependencies: lock.Dependencies}, nil
+	}
+
+	manifest, err := boshpkgman.NewManifestFromPath(filepath.Join(pkgDir, "spec"), d.fs)
+	if err != nil {
+		return lintPackage{}, err
+	}
+
+	return lintPackage{name: manifest.Name, dependencies: manifest.Dependencies, files: manifest.Files}, nil
+}
+
+func (d FSReleaseDir) lintFilesMatch(glob string, blobs []Blob) (bool, error) {
+	for _, blob := range blobs {
+		matched, err := doublestar.Match(glob, blob.Path)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Matching files pattern '%s'", glob)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	for _, dir := range []string{"src", "blobs"} {
+		matches, err := d.fs.RecursiveGlob(filepath.Join(d.dirPath, dir, glob))
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Listing package files in %s", dir)
+		}
+
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// lintPackageCycles returns each dependency cycle once,
+// starting and ending with the same package.
+func (d FSReleaseDir) lintPackageCycles(pkgs map[string]lintPackage) [][]string {
+	var names []string
+
+	for name := range pkgs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var cycles [][]string
+
+	visited := map[string]bool{}
+	onPath := map[string]int{}
+
+	var visit func(name string, path []string)
+
+	visit = func(name string, path []string) {
+		if i, found := onPath[name]; found {
+			cycle := append([]string{}, path[i:]...)
+			cycles = append(cycles, append(cycle, name))
+			return
+		}
+
+		if visited[name] {
+			return
+		}
+
+		visited[name] = true
+		onPath[name] = len(path)
+		path = append(path, name)
+
+		deps := append([]string{}, pkgs[name].dependencies...)
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			if _, found := pkgs[dep]; found {
+				visit(dep, path)
+			}
+		}
+
+		delete(onPath, name)
+	}
+
+	for _, name := range names {
+		visit(name, nil)
+	}
+
+	return cycles
+}
+
+func (d FSReleaseDir) lintJobs(pkgs map[string]lintPackage) ([]LintProblem, error) {
+	var problems []LintProblem
+
+	jobDirs, err := d.lintDirs("jobs")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, jobDir := range jobDirs {
+		resource := filepath.Join("jobs", filepath.Base(jobDir))
+
+		manifest, err := boshjobman.NewManifestFromPath(filepath.Join(jobDir, "spec"), d.fs)
+		if err != nil {
+			problems = append(problems, LintProblem{Check: LintInvalidSpec, Resource: resource, Message: err.Error()})
+			continue
+		}
+
+		for _, pkgName := range manifest.Packages {
+			if _, found := pkgs[pkgName]; !found {
+				problems = append(problems, LintProblem{
+					Check:    LintMissingPackage,
+					Resource: resource,
+					Message:  "Package '" + pkgName + "' is not a package of the release",
+				})
+			}
+		}
+
+		templateProblems, err := d.lintTemplates(jobDir, resource, manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		problems = append(problems, templateProblems...)
+	}
+
+	return problems, nil
+}
+
+func (d FSReleaseDir) lintTemplates(jobDir, resource string, manifest boshjobman.Manifest) ([]LintProblem, error) {
+	var problems []LintProblem
+
+	templatesDir := filepath.Join(jobDir, "templates")
+	templatePaths := []string{}
+
+	for src := range manifest.Templates {
+		templatePaths = append(templatePaths, filepath.Join(templatesDir, src))
+	}
+
+	sort.Strings(templatePaths)
+
+	monitPath := filepath.Join(jobDir, "monit")
+	if d.fs.FileExists(monitPath) {
+		templatePaths = append(templatePaths, monitPath)
+	}
+
+	listed := map[string]bool{}
+
+	for _, path := range templatePaths {
+		listed[path] = true
+
+		rel, _ := filepath.Rel(jobDir, path)
+
+		if !d.fs.FileExists(path) {
+			problems = append(problems, LintProblem{
+				Check:    LintMissingTemplate,
+				Resource: resource,
+				Message:  "Template '" + rel + "' is listed in the spec but does not exist",
+			})
+			continue
+		}
+
+		contents, err := d.fs.ReadFileString(path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading template '%s'", path)
+		}
+
+		for _, name := range d.lintPropertyNames(contents) {
+			if !d.lintPropertyDeclared(manifest, name) {
+				problems = append(problems, LintProblem{
+					Check:    LintUndeclaredProperty,
+					Resource: resource,
+					Message:  "Property '" + name + "' is used in '" + rel + "' but is not declared in the spec",
+				})
+			}
+		}
+	}
+
+	if !d.fs.FileExists(templatesDir) {
+		return problems, nil
+	}
+
+	var unused []string
+
+	err := d.fs.Walk(templatesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && !listed[path] {
+			unused = append(unused, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing templates in '%s'", templatesDir)
+	}
+
+	sort.Strings(unused)
+
+	for _, path := range unused {
+		rel, _ := filepath.Rel(jobDir, path)
+
+		problems = append(problems, LintProblem{
+			Check:    LintUnusedTemplate,
+			Resource: resource,
+			Message:  "Template file '" + rel + "' is not listed in the spec",
+		})
+	}
+
+	return problems, nil
+}
+
+// lintPropertyNames returns names of properties that a template looks up
+// with p or if_p, in the order they are first used.
+func (d FSReleaseDir) lintPropertyNames(template string) []string {
+	var names []string
+
+	seen := map[string]bool{}
+
+	for _, call := range lintPropertyCallRegexp.FindAllStringSubmatch(template, -1) {
+		for _, match := range lintPropertyNameRegexp.FindAllStringSubmatch(call[1], -1) {
+			name := match[1] + match[2]
+
+			if len(name) > 0 && !seen[name] && !strings.Contains(name, "#{") {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// lintPropertyDeclared allows looking up a parent of declared properties
+// as a hash and a child of a property that is declared as a hash.
+func (d FSReleaseDir) lintPropertyDeclared(manifest boshjobman.Manifest, name string) bool {
+	for declared := range manifest.Properties {
+		if declared == name || strings.HasPrefix(declared, name+".") || strings.HasPrefix(name, declared+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d FSReleaseDir) lintDirs(name string) ([]string, error) {
+	matches, err := d.fs.Glob(filepath.Join(d.dirPath, name, "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing %s in directory", name)
+	}
+
+	var dirs []string
+
+	for _, match := range matches {
+		info, err := d.fs.Stat(match)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking '%s'", match)
+		}
+
+		if info.IsDir() {
+			dirs = append(dirs, match)
+		}
+	}
+
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
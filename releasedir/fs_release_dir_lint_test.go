@@ -0,0 +1,209 @@
+package releasedir_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshrel "github.com/cloudfoundry/bosh-cli/v7/release"
+	fakerel "github.com/cloudfoundry/bosh-cli/v7/release/releasefakes"
+	fakeres "github.com/cloudfoundry/bosh-cli/v7/release/resource/resourcefakes"
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/v7/releasedir/releasedirfakes"
+)
+
+var _ = Describe("FSReleaseDir", func() {
+	var (
+		reader     *fakerel.FakeReader
+		release    *fakerel.FakeRelease
+		blobsDir   *fakereldir.FakeBlobsDir
+		fs         *fakesys.FakeFileSystem
+		releaseDir FSReleaseDir
+	)
+
+	BeforeEach(func() {
+		release = &fakerel.FakeRelease{}
+		reader = &fakerel.FakeReader{}
+		reader.ReadReturns(release, nil)
+		blobsDir = &fakereldir.FakeBlobsDir{}
+		fs = fakesys.NewFakeFileSystem()
+		releaseDir = NewFSReleaseDir(
+			"/dir",
+			&fakereldir.FakeConfig{},
+			&fakereldir.FakeGitRepo{},
+			blobsDir,
+			&fakereldir.FakeGenerator{},
+			&fakereldir.FakeReleaseIndex{},
+			&fakereldir.FakeReleaseIndex{},
+			boshrel.ArchiveIndicies{
+				Jobs:     &fakeres.FakeArchiveIndex{},
+				Packages: &fakeres.FakeArchiveIndex{},
+			},
+			reader,
+			fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 1, 2, 333, time.UTC)),
+			fs,
+			2,
+		)
+
+		fs.SetGlob("/dir/jobs/*", []string{"/dir/jobs/web"})
+		fs.SetGlob("/dir/packages/*", []string{"/dir/packages/nginx", "/dir/packages/pcre"})
+
+		Expect(fs.WriteFileString("/dir/jobs/web/spec", `---
+name: web
+templates:
+  ctl.erb: bin/ctl
+  config.erb: config/web.conf
+packages: [nginx]
+properties:
+  port: {default: 80}
+  tls: {description: TLS settings}
+`)).To(Succeed())
+		Expect(fs.WriteFileString("/dir/jobs/web/monit", "check process web")).To(Succeed())
+		Expect(fs.WriteFileString("/dir/jobs/web/templates/ctl.erb", "<% if_p('tls.cert') do |cert| %><%= cert %><% end %>")).To(Succeed())
+		Expect(fs.WriteFileString("/dir/jobs/web/templates/config.erb", `listen <%= p("port") %>;`)).To(Succeed())
+
+		Expect(fs.WriteFileString("/dir/packages/nginx/spec", "name: nginx\ndependencies: [pcre]\nfiles: [nginx/*.tar.gz]")).To(Succeed())
+		Expect(fs.WriteFileString("/dir/packages/pcre/spec.lock", "name: pcre\nfingerprint: fp")).To(Succeed())
+		fs.SetGlob("/dir/src/nginx/*.tar.gz", []string{})
+		fs.SetGlob("/dir/blobs/nginx/*.tar.gz", []string{"/dir/blobs/nginx/nginx-1.0.tar.gz"})
+	})
+
+	Describe("Lint", func() {
+		It("returns no problems and builds release when jobs and packages are consistent", func() {
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+
+			Expect(reader.ReadCallCount()).To(Equal(1))
+			Expect(reader.ReadArgsForCall(0)).To(Equal("/dir"))
+			Expect(release.CleanUpCallCount()).To(Equal(1))
+		})
+
+		It("returns error if built release cannot be cleaned up", func() {
+			release.CleanUpReturns(errors.New("fake-err"))
+
+			_, err := releaseDir.Lint()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns problem if release cannot be built", func() {
+			reader.ReadReturns(nil, errors.New("fake-err"))
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]LintProblem{
+				{Check: LintBuild, Resource: ".", Message: "fake-err"},
+			}))
+		})
+
+		It("returns problems for packages that are missing", func() {
+			Expect(fs.WriteFileString("/dir/jobs/web/spec", "name: web\npackages: [nginx, ruby]")).To(Succeed())
+			Expect(fs.WriteFileString("/dir/packages/nginx/spec", "name: nginx\ndependencies: [pcre, zlib]")).To(Succeed())
+			fs.RemoveAll("/dir/jobs/web/templates") //nolint:errcheck
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]LintProblem{
+				{Check: LintMissingPackage, Resource: "jobs/web", Message: "Package 'ruby' is not a package of the release"},
+				{Check: LintMissingPackage, Resource: "packages/nginx", Message: "Dependency 'zlib' is not a package of the release"},
+			}))
+
+			Expect(reader.ReadCallCount()).To(Equal(0))
+		})
+
+		It("returns problems for package dependency cycles", func() {
+			Expect(fs.WriteFileString("/dir/packages/pcre/spec.lock", "name: pcre\ndependencies: [nginx]")).To(Succeed())
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]LintProblem{
+				{Check: LintPackageCycle, Resource: "packages/nginx", Message: "Dependencies form a cycle: nginx -> pcre -> nginx"},
+			}))
+		})
+
+		It("returns problems for templates that are missing or not listed in spec", func() {
+			Expect(fs.RemoveAll("/dir/jobs/web/templates/config.erb")).To(Succeed())
+			Expect(fs.WriteFileString("/dir/jobs/web/templates/old.erb", "")).To(Succeed())
+			Expect(fs.WriteFileString("/dir/jobs/web/templates/config/extra.erb", "")).To(Succeed())
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]LintProblem{
+				{Check: LintMissingTemplate, Resource: "jobs/web", Message: "Template 'templates/config.erb' is listed in the spec but does not exist"},
+				{Check: LintUnusedTemplate, Resource: "jobs/web", Message: "Template file 'templates/config/extra.erb' is not listed in the spec"},
+				{Check: LintUnusedTemplate, Resource: "jobs/web", Message: "Template file 'templates/old.erb' is not listed in the spec"},
+			}))
+		})
+
+		It("returns problems for properties used in templates but not declared in spec", func() {
+			Expect(fs.WriteFileString("/dir/jobs/web/templates/config.erb", `
+listen <%= p("port") %>;
+workers <%= p('workers', 1) %>;
+<%= p(['log.level', "log_level"]) %>
+<%= link('db').p('db.port') %> <%= spec.p %>
+<% if_p('tls', 'worker_connections') do |tls, conns| %><% end %>
+`)).To(Succeed())
+			Expect(fs.WriteFileString("/dir/jobs/web/monit", `check process <%= p("process_name") %>`)).To(Succeed())
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]LintProblem{
+				{Check: LintUndeclaredProperty, Resource: "jobs/web", Message: "Property 'workers' is used in 'templates/config.erb' but is not declared in the spec"},
+				{Check: LintUndeclaredProperty, Resource: "jobs/web", Message: "Property 'log.level' is used in 'templates/config.erb' but is not declared in the spec"},
+				{Check: LintUndeclaredProperty, Resource: "jobs/web", Message: "Property 'log_level' is used in 'templates/config.erb' but is not declared in the spec"},
+				{Check: LintUndeclaredProperty, Resource: "jobs/web", Message: "Property 'process_name' is used in 'monit' but is not declared in the spec"},
+			}))
+		})
+
+		It("returns problems for package files patterns that do not match any files", func() {
+			fs.SetGlob("/dir/blobs/nginx/*.tar.gz", []string{})
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]LintProblem{
+				{Check: LintUnmatchedFiles, Resource: "packages/nginx", Message: "Files pattern 'nginx/*.tar.gz' does not match any files in src or blobs"},
+			}))
+		})
+
+		It("matches package files patterns against blobs that are not synced yet", func() {
+			fs.SetGlob("/dir/blobs/nginx/*.tar.gz", []string{})
+			blobsDir.BlobsReturns([]Blob{{Path: "other/file.tgz"}, {Path: "nginx/nginx-1.0.tar.gz"}}, nil)
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("returns error if listing blobs fails", func() {
+			blobsDir.BlobsReturns(nil, errors.New("fake-err"))
+
+			_, err := releaseDir.Lint()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns problems for specs that cannot be read", func() {
+			Expect(fs.WriteFileString("/dir/jobs/web/spec", "name: ''")).To(Succeed())
+
+			problems, err := releaseDir.Lint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0].Check).To(Equal(LintInvalidSpec))
+			Expect(problems[0].Resource).To(Equal("jobs/web"))
+			Expect(problems[0].Message).To(ContainSubstring("Expected job name to be non-empty"))
+		})
+
+		It("returns error if listing package files fails", func() {
+			fs.GlobErrs = map[string]error{"/dir/src/nginx/*.tar.gz": errors.New("fake-err")}
+
+			_, err := releaseDir.Lint()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
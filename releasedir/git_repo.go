@@ -2,6 +2,7 @@ package releasedir
 
 import (
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -99,6 +100,87 @@ func (r FSGitRepo) MustNotBeDirty(force bool) (bool, error) {
 	return false, nil
 }
 
+// GitFile is a version of a file committed at some point in git history.
+type GitFile struct {
+	Path     string
+	Contents []byte
+}
+
+// HistoricalFiles returns all distinct versions of files under given paths
+// that were committed to any branch or tag.
+func (r FSGitRepo) HistoricalFiles(paths ...string) ([]GitFile, error) {
+	cmd := boshsys.Command{
+		Name:       "git",
+		Args:       append([]string{"rev-list", "--all", "--objects", "--"}, paths...),
+		WorkingDir: r.dirPath,
+	}
+	stdout, _, _, err := r.runner.RunComplexCommand(cmd)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing files in history")
+	}
+
+	var hashes []string
+	objPaths := map[string]string{}
+
+	// Commits are listed without paths; trees and blobs with paths
+	for _, line := range strings.Split(stdout, "\n") {
+		pieces := strings.SplitN(line, " ", 2)
+		if len(pieces) != 2 || len(pieces[1]) == 0 {
+			continue
+		}
+
+		if _, found := objPaths[pieces[0]]; !found {
+			hashes = append(hashes, pieces[0])
+			objPaths[pieces[0]] = pieces[1]
+		}
+	}
+
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	cmd = boshsys.Command{
+		Name:       "git",
+		Args:       []string{"cat-file", "--batch"},
+		WorkingDir: r.dirPath,
+		Stdin:      strings.NewReader(strings.Join(hashes, "\n") + "\n"),
+	}
+	stdout, _, _, err = r.runner.RunComplexCommand(cmd)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading files in history")
+	}
+
+	var files []GitFile
+
+	// Each object is printed as '<hash> <type> <size>\n<contents>\n'
+	for len(stdout) > 0 {
+		headerEnd := strings.Index(stdout, "\n")
+		if headerEnd == -1 {
+			return nil, bosherr.Errorf("Expected object header in '%s'", stdout)
+		}
+
+		header := strings.Fields(stdout[:headerEnd])
+		stdout = stdout[headerEnd+1:]
+
+		if len(header) != 3 {
+			continue // missing objects only have a header
+		}
+
+		size, err := strconv.Atoi(header[2])
+		if err != nil || size+1 > len(stdout) {
+			return nil, bosherr.Errorf("Expected object '%s' to have valid size", header[0])
+		}
+
+		if header[1] == "blob" {
+			files = append(files, GitFile{Path: objPaths[header[0]], Contents: []byte(stdout[:size])})
+		}
+
+		stdout = stdout[size+1:]
+	}
+
+	return files, nil
+}
+
 func (r FSGitRepo) isNotGitRepo(stderr string) bool {
 	if r.fs.FileExists(filepath.Join(r.dirPath, ".git")) {
 		return false
@@ -2,6 +2,7 @@ package releasedir_test
 
 import (
 	"errors"
+	"io"
 
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -178,4 +179,76 @@ releases/**/*.tgz
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
 	})
+
+	Describe("HistoricalFiles", func() {
+		var (
+			fsGitRepo FSGitRepo
+		)
+
+		BeforeEach(func() {
+			fsGitRepo = NewFSGitRepo("/dir", cmdRunner, fs)
+		})
+
+		It("returns contents of distinct blobs under given paths", func() {
+			cmdRunner.AddCmdResult("git rev-list --all --objects -- config/blobs.yml .final_builds", fakesys.FakeCmdResult{
+				Stdout: "commit1\ncommit2\ntree1 \ntree2 .final_builds\nblob1 .final_builds/jobs/job/index.yml\nblob2 config/blobs.yml\nblob1 .final_builds/jobs/job/index.yml\n",
+			})
+
+			cmdRunner.AddCmdResult("git cat-file --batch", fakesys.FakeCmdResult{
+				Stdout: "tree2 tree 5\nbytes\nblob1 blob 8\nindex\nv1\nblob2 blob 0\n\n",
+			})
+
+			files, err := fsGitRepo.HistoricalFiles("config/blobs.yml", ".final_builds")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(Equal([]GitFile{
+				{Path: ".final_builds/jobs/job/index.yml", Contents: []byte("index\nv1")},
+				{Path: "config/blobs.yml", Contents: []byte{}},
+			}))
+
+			Expect(cmdRunner.RunComplexCommands).To(HaveLen(2))
+
+			catCmd := cmdRunner.RunComplexCommands[1]
+			Expect(catCmd.WorkingDir).To(Equal("/dir"))
+
+			stdin, err := io.ReadAll(catCmd.Stdin)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stdin)).To(Equal("tree2\nblob1\nblob2\n"))
+		})
+
+		It("returns no files if nothing was committed under given paths", func() {
+			cmdRunner.AddCmdResult("git rev-list --all --objects -- config/blobs.yml", fakesys.FakeCmdResult{
+				Stdout: "commit1\n",
+			})
+
+			files, err := fsGitRepo.HistoricalFiles("config/blobs.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(BeEmpty())
+
+			Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+		})
+
+		It("returns error if listing history fails", func() {
+			cmdRunner.AddCmdResult("git rev-list --all --objects -- config/blobs.yml", fakesys.FakeCmdResult{
+				Error: errors.New("fake-err"),
+			})
+
+			_, err := fsGitRepo.HistoricalFiles("config/blobs.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Listing files in history"))
+		})
+
+		It("returns error if object contents are truncated", func() {
+			cmdRunner.AddCmdResult("git rev-list --all --objects -- config/blobs.yml", fakesys.FakeCmdResult{
+				Stdout: "blob1 config/blobs.yml\n",
+			})
+
+			cmdRunner.AddCmdResult("git cat-file --batch", fakesys.FakeCmdResult{
+				Stdout: "blob1 blob 100\nshort\n",
+			})
+
+			_, err := fsGitRepo.HistoricalFiles("config/blobs.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected object 'blob1' to have valid size"))
+		})
+	})
 })
@@ -32,6 +32,15 @@ type indexEntry struct {
 	SHA1        string
 }
 
+type Entry struct {
+	Name    string
+	Key     string
+	Version string
+
+	BlobstoreID string
+	SHA1        string
+}
+
 /*
 ---
 builds:
@@ -204,6 +213,56 @@ func (i FSIndex) Add(name, fingerprint, path, sha1 string) (string, string, erro
 	return blobPath, sha1, nil
 }
 
+// Entries returns entries of all names kept in the index, e.g. to move their blobs.
+func (i FSIndex) Entries() ([]Entry, error) {
+	names := []string{i.name}
+
+	if i.useSubdir {
+		indexPaths, err := i.fs.Glob(filepath.Join(i.dirPath, "*", "index.yml"))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listing indices")
+		}
+
+		names = nil
+
+		for _, indexPath := range indexPaths {
+			names = append(names, filepath.Base(filepath.Dir(indexPath)))
+		}
+
+		sort.Strings(names)
+	}
+
+	var result []Entry
+
+	for _, name := range names {
+		entries, err := i.entries(name)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, sortedEntries(name, entries)...)
+	}
+
+	return result, nil
+}
+
+// SaveBlobstoreID points existing entry to a different blob with the same contents.
+func (i FSIndex) SaveBlobstoreID(entry Entry, blobID string) error {
+	entries, err := i.entries(entry.Name)
+	if err != nil {
+		return err
+	}
+
+	for idx, existing := range entries {
+		if existing.Key == entry.Key {
+			entries[idx].BlobstoreID = blobID
+			return i.save(entry.Name, entries)
+		}
+	}
+
+	return bosherr.Errorf("Expected to find index entry '%s/%s'", entry.Name, entry.Key)
+}
+
 var (
 	// Ruby CLI for some reason produces invalid annotations
 	invalidBinaryAnnotationReplacer = strings.NewReplacer(" !binary ", " !!binary ")
@@ -221,11 +280,45 @@ func (i FSIndex) entries(name string) ([]indexEntry, error) {
 		return nil, bosherr.WrapErrorf(err, "Reading index")
 	}
 
+	return unmarshalEntries(bytes)
+}
+
+// ParseEntries returns entries of index contents that do not come
+// from the file system, e.g. of index versions kept in git history.
+func ParseEntries(name string, bytes []byte) ([]Entry, error) {
+	entries, err := unmarshalEntries(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedEntries(name, entries), nil
+}
+
+func sortedEntries(name string, entries []indexEntry) []Entry {
+	sort.Slice(entries, func(a, b int) bool { return entries[a].Key < entries[b].Key })
+
+	var result []Entry
+
+	for _, entry := range entries {
+		result = append(result, Entry{
+			Name:    name,
+			Key:     entry.Key,
+			Version: entry.Version,
+
+			BlobstoreID: entry.BlobstoreID,
+			SHA1:        entry.SHA1,
+		})
+	}
+
+	return result
+}
+
+func unmarshalEntries(bytes []byte) ([]indexEntry, error) {
 	var schema fsIndexSchema
 
 	str := invalidBinaryAnnotationReplacer.Replace(string(bytes))
 
-	err = yaml.Unmarshal([]byte(str), &schema)
+	err := yaml.Unmarshal([]byte(str), &schema)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Unmarshalling index")
 	}
@@ -492,6 +492,115 @@ format-version: "2"`)
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
 	})
+
+	Describe("Entries", func() {
+		It("returns entries of all names sorted by name and key", func() {
+			err := fs.WriteFileString(filepath.Join("/", "dir", "name2", "index.yml"), `---
+builds:
+  fp2: {version: fp2, blobstore_id: fp2-blob-id, sha1: fp2-sha1}
+format-version: "2"`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString(filepath.Join("/", "dir", "name1", "index.yml"), `---
+builds:
+  fp3: {version: fp3, blobstore_id: fp3-blob-id, sha1: fp3-sha1}
+  fp1: {version: "1", blobstore_id: fp1-blob-id, sha1: fp1-sha1}
+format-version: "2"`)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.SetGlob(filepath.Join("/", "dir", "*", "index.yml"), []string{
+				filepath.Join("/", "dir", "name2", "index.yml"),
+				filepath.Join("/", "dir", "name1", "index.yml"),
+			})
+
+			entries, err := index.Entries()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]boshidx.Entry{
+				{Name: "name1", Key: "fp1", Version: "1", BlobstoreID: "fp1-blob-id", SHA1: "fp1-sha1"},
+				{Name: "name1", Key: "fp3", Version: "fp3", BlobstoreID: "fp3-blob-id", SHA1: "fp3-sha1"},
+				{Name: "name2", Key: "fp2", Version: "fp2", BlobstoreID: "fp2-blob-id", SHA1: "fp2-sha1"},
+			}))
+		})
+
+		It("returns entries from non-prefixed index file under index name", func() {
+			index = boshidx.NewFSIndex("index-name", filepath.Join("/", "dir"), false, true, reporter, blobs, fs)
+
+			err := fs.WriteFileString(filepath.Join("/", "dir", "index.yml"), `---
+builds:
+  fp: {version: fp, blobstore_id: fp-blob-id, sha1: fp-sha1}
+format-version: "2"`)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := index.Entries()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]boshidx.Entry{
+				{Name: "index-name", Key: "fp", Version: "fp", BlobstoreID: "fp-blob-id", SHA1: "fp-sha1"},
+			}))
+		})
+
+		It("returns error if listing indices fails", func() {
+			fs.GlobErr = errors.New("fake-err")
+
+			_, err := index.Entries()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("ParseEntries", func() {
+		It("returns entries of index contents sorted by key", func() {
+			entries, err := boshidx.ParseEntries("name", []byte(`---
+builds:
+  fp2: {version: fp2, blobstore_id: fp2-blob-id, sha1: fp2-sha1}
+  fp1: {version: fp1, blobstore_id: fp1-blob-id, sha1: fp1-sha1}
+format-version: "2"`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]boshidx.Entry{
+				{Name: "name", Key: "fp1", Version: "fp1", BlobstoreID: "fp1-blob-id", SHA1: "fp1-sha1"},
+				{Name: "name", Key: "fp2", Version: "fp2", BlobstoreID: "fp2-blob-id", SHA1: "fp2-sha1"},
+			}))
+		})
+
+		It("returns error if contents cannot be unmarshalled", func() {
+			_, err := boshidx.ParseEntries("name", []byte("-"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling index"))
+		})
+	})
+
+	Describe("SaveBlobstoreID", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(filepath.Join("/", "dir", "name", "index.yml"), `---
+builds:
+  fp2: {version: fp2, blobstore_id: fp2-blob-id, sha1: fp2-sha1}
+  fp: {version: fp, blobstore_id: fp-blob-id, sha1: fp-sha1}
+format-version: "2"`)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("replaces blobstore ID of the entry", func() {
+			err := index.SaveBlobstoreID(boshidx.Entry{Name: "name", Key: "fp"}, "new-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString(filepath.Join("/", "dir", "name", "index.yml"))).To(Equal(`builds:
+  fp:
+    version: fp
+    blobstore_id: new-blob-id
+    sha1: fp-sha1
+  fp2:
+    version: fp2
+    blobstore_id: fp2-blob-id
+    sha1: fp2-sha1
+format-version: "2"
+`))
+		})
+
+		It("returns error if entry is not found", func() {
+			err := index.SaveBlobstoreID(boshidx.Entry{Name: "name", Key: "unknown"}, "new-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected to find index entry 'name/unknown'"))
+		})
+	})
 })
 
 // Fixture needs to be long because natural sort may succeed for smaller sizes
@@ -55,3 +55,15 @@ func (p Provider) DevAndFinalIndicies(dirPath string) (boshrel.ArchiveIndicies,
 
 	return devIndicies, finalIndicies
 }
+
+// FinalFSIndicies returns final indices of jobs, packages and license
+// to work with all of their entries, e.g. to move their blobs.
+func (p Provider) FinalFSIndicies(dirPath string) []FSIndex {
+	finalBlobsCache := NewFSIndexBlobs(filepath.Join("~", ".bosh", "cache"), p.reporter, p.blobstore, p.fs)
+
+	return []FSIndex{
+		NewFSIndex("job", filepath.Join(dirPath, ".final_builds", "jobs"), true, true, p.reporter, finalBlobsCache, p.fs),
+		NewFSIndex("package", filepath.Join(dirPath, ".final_builds", "packages"), true, true, p.reporter, finalBlobsCache, p.fs),
+		NewFSIndex("license", filepath.Join(dirPath, ".final_builds", "license"), false, true, p.reporter, finalBlobsCache, p.fs),
+	}
+}
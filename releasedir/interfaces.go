@@ -42,6 +42,9 @@ type ReleaseDir interface {
 
 	// FinalizeRelease adds the Release to the final list so that it's consumable by others.
 	FinalizeRelease(release boshrel.Release, force bool) error
+
+	// Lint returns problems in jobs and packages of the release directory.
+	Lint() ([]LintProblem, error)
 }
 
 //counterfeiter:generate . Config
@@ -75,7 +78,7 @@ type BlobsDir interface {
 	Blobs() ([]Blob, error)
 
 	SyncBlobs(numOfParallelWorkers int) error
-	UploadBlobs() error
+	UploadBlobs(numOfParallelWorkers int) error
 
 	TrackBlob(string, io.ReadCloser) (Blob, error)
 	UntrackBlob(string) error
@@ -89,6 +92,22 @@ type BlobsDirReporter interface {
 
 	BlobUploadStarted(path string, size int64, sha1 string)
 	BlobUploadFinished(path, blobID string, err error)
+
+	// BlobUploadSent is reported as a blob is being uploaded
+	// with the number of bytes sent so far and the size of the blob.
+	BlobUploadSent(path string, sentSize, size int64)
+
+	// BlobUploadProgress is reported each time a blob is uploaded
+	// with the combined size of uploaded blobs and of all blobs to upload.
+	BlobUploadProgress(uploadedSize, totalSize int64)
+}
+
+//counterfeiter:generate . BlobUploadTracker
+
+type BlobUploadTracker interface {
+	// TrackUpload calls progress with the number of bytes sent
+	// while the file at path is uploaded, until untrack is called.
+	TrackUpload(path string, progress func(sentSize int64)) (untrack func())
 }
 
 type Blob struct {
@@ -99,6 +118,87 @@ type Blob struct {
 	SHA1        string
 }
 
+//counterfeiter:generate . BlobCache
+
+type BlobCache interface {
+	// Get returns a copy of the cached blob that matches the digest.
+	// Caller is responsible for removing the copy.
+	Get(digest boshcrypto.Digest) (path string, found bool)
+	Add(path string, digest boshcrypto.Digest) error
+
+	Stats() (BlobCacheStats, error)
+}
+
+type BlobCacheStats struct {
+	Path string
+
+	Blobs   int
+	Size    int64
+	MaxSize int64
+
+	Hits   int
+	Misses int
+}
+
+//counterfeiter:generate . BlobstoreMigrator
+
+type BlobstoreMigrator interface {
+	// Migrate copies blobs referenced by blobs.yml and final indices
+	// to another blobstore and points release directory to it.
+	// It can be called again to resume after a failure.
+	Migrate() (BlobstoreMigration, error)
+}
+
+type BlobstoreMigration struct {
+	Copied  int
+	Skipped int // already copied by a previous attempt
+}
+
+//counterfeiter:generate . BlobstoreMigrationReporter
+
+type BlobstoreMigrationReporter interface {
+	BlobMigrationStarted(desc, blobID string)
+	BlobMigrationFinished(desc, blobID, newBlobID string, err error)
+}
+
+// BlobLister is implemented by release blobstores that can enumerate
+// their blobs, which is required to find blobs not referenced by a release.
+type BlobLister interface {
+	List() ([]string, error)
+
+	// ListScope describes what List covers, e.g. a bucket folder.
+	// Scope is shared if it may hold blobs of other releases as well.
+	ListScope() (scope string, shared bool, err error)
+}
+
+//counterfeiter:generate . BlobstoreAuditor
+
+type BlobstoreAuditor interface {
+	// Audit verifies blobs referenced by blobs.yml and final indices
+	// of the working tree or, if history is set, of all git history.
+	Audit(history bool) (BlobstoreAudit, error)
+	DeleteOrphans(blobIDs []string) error
+}
+
+type BlobstoreAudit struct {
+	Blobs []AuditedBlob
+
+	// Orphans are only known if blobstore is a BlobLister
+	Listable bool
+	Orphans  []string
+
+	ListScope       string
+	SharedListScope bool // orphans may be blobs of other releases
+}
+
+type AuditedBlob struct {
+	BlobstoreID  string
+	SHA1         string
+	ReferencedBy []string
+
+	Err error // blob is missing or does not match its digest
+}
+
 //counterfeiter:generate . ReleaseIndex
 
 type ReleaseIndex interface {
@@ -0,0 +1,72 @@
+package releasedir
+
+import (
+	"path/filepath"
+
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// LocalBlobstore keeps blobs as files of a local directory.
+type LocalBlobstore struct {
+	boshblob.Blobstore
+
+	fs      boshsys.FileSystem
+	options map[string]interface{}
+}
+
+func NewLocalBlobstore(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	options map[string]interface{},
+) LocalBlobstore {
+	return LocalBlobstore{
+		Blobstore: boshblob.NewLocalBlobstore(fs, uuidGen, options),
+
+		fs:      fs,
+		options: options,
+	}
+}
+
+// ListScope is the blobstore directory, which is not shared
+// since it is specific to the release directory that uses it.
+func (b LocalBlobstore) ListScope() (string, bool, error) {
+	dirPath, _ := b.options["blobstore_path"].(string)
+
+	if len(dirPath) == 0 {
+		return "", false, bosherr.Error("Expected non-empty 'blobstore_path' in local blobstore options")
+	}
+
+	return dirPath, false, nil
+}
+
+// List returns IDs of all blobs in the blobstore directory.
+func (b LocalBlobstore) List() ([]string, error) {
+	dirPath, _ := b.options["blobstore_path"].(string)
+
+	if len(dirPath) == 0 {
+		return nil, bosherr.Error("Expected non-empty 'blobstore_path' in local blobstore options")
+	}
+
+	paths, err := b.fs.Glob(filepath.Join(dirPath, "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing blobs in '%s'", dirPath)
+	}
+
+	var blobIDs []string
+
+	for _, path := range paths {
+		stat, err := b.fs.Stat(path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking blob '%s'", path)
+		}
+
+		if !stat.IsDir() {
+			blobIDs = append(blobIDs, filepath.Base(path))
+		}
+	}
+
+	return blobIDs, nil
+}
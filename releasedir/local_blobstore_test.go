@@ -0,0 +1,83 @@
+package releasedir_test
+
+import (
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+)
+
+var _ = Describe("LocalBlobstore", func() {
+	var (
+		fs        boshsys.FileSystem
+		uuidGen   *fakeuuid.FakeGenerator
+		tmpDir    string
+		blobstore LocalBlobstore
+	)
+
+	BeforeEach(func() {
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUUID: "blob-id"}
+
+		var err error
+
+		tmpDir, err = os.MkdirTemp("", "local-blobstore")
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore = NewLocalBlobstore(fs, uuidGen, map[string]interface{}{"blobstore_path": filepath.Join(tmpDir, "blobs")})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir) //nolint:errcheck
+	})
+
+	It("uploads blobs into blobstore directory", func() {
+		path := filepath.Join(tmpDir, "file")
+		Expect(os.WriteFile(path, []byte("content"), 0644)).To(Succeed())
+
+		blobID, err := blobstore.Create(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("blob-id"))
+
+		Expect(os.ReadFile(filepath.Join(tmpDir, "blobs", "blob-id"))).To(Equal([]byte("content")))
+	})
+
+	Describe("List", func() {
+		It("returns IDs of blobs skipping directories", func() {
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "blobs", "dir"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpDir, "blobs", "blob-1"), []byte("1"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpDir, "blobs", "blob-2"), []byte("2"), 0644)).To(Succeed())
+
+			blobIDs, err := blobstore.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobIDs).To(ConsistOf("blob-1", "blob-2"))
+		})
+
+		It("returns error if blobstore path is not configured", func() {
+			_, err := NewLocalBlobstore(fs, uuidGen, map[string]interface{}{}).List()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected non-empty 'blobstore_path'"))
+		})
+	})
+
+	Describe("ListScope", func() {
+		It("returns blobstore directory which is not shared", func() {
+			scope, shared, err := blobstore.ListScope()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scope).To(Equal(filepath.Join(tmpDir, "blobs")))
+			Expect(shared).To(BeFalse())
+		})
+
+		It("returns error if blobstore path is not configured", func() {
+			_, _, err := NewLocalBlobstore(fs, uuidGen, map[string]interface{}{}).ListScope()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected non-empty 'blobstore_path'"))
+		})
+	})
+})
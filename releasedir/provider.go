@@ -27,6 +27,8 @@ type Provider struct {
 	uuidGen                boshuuid.Generator
 	timeService            clock.Clock
 	fs                     boshsys.FileSystem
+	uploadFS               UploadTrackingFileSystem
+	blobCache              BlobCache
 	logger                 boshlog.Logger
 	digestCreateAlgorithms []boshcrypto.Algorithm
 }
@@ -41,6 +43,7 @@ func NewProvider(
 	uuidGen boshuuid.Generator,
 	timeService clock.Clock,
 	fs boshsys.FileSystem,
+	blobCache BlobCache,
 	digestCreateAlgorithms []boshcrypto.Algorithm,
 	logger boshlog.Logger,
 ) Provider {
@@ -54,6 +57,8 @@ func NewProvider(
 		uuidGen:                uuidGen,
 		timeService:            timeService,
 		fs:                     fs,
+		uploadFS:               NewUploadTrackingFileSystem(fs),
+		blobCache:              blobCache,
 		digestCreateAlgorithms: digestCreateAlgorithms,
 		logger:                 logger,
 	}
@@ -92,7 +97,7 @@ func (p Provider) NewFSReleaseDir(dirPath string, parallel int) FSReleaseDir {
 }
 
 func (p Provider) NewFSBlobsDir(dirPath string) FSBlobsDir {
-	return NewFSBlobsDir(dirPath, p.blobsReporter, p.newBlobstore(dirPath), p.digestCalculator, p.fs, p.logger)
+	return NewFSBlobsDir(dirPath, p.blobsReporter, p.newBlobstore(dirPath), p.uploadFS, p.digestCalculator, p.timeService, p.fs, p.logger)
 }
 
 func (p Provider) NewReleaseReader(dirPath string, parallel int) boshrel.BuiltReader {
@@ -102,36 +107,123 @@ func (p Provider) NewReleaseReader(dirPath string, parallel int) boshrel.BuiltRe
 	return boshrel.NewBuiltReader(multiReader, devIndex, finalIndex, parallel)
 }
 
+// NewFSBlobstoreMigrator returns migrator that moves blobs of the release directory
+// to the blobstore configured by toConfig.
+func (p Provider) NewFSBlobstoreMigrator(dirPath string, toConfig FSConfig, reporter BlobstoreMigrationReporter) FSBlobstoreMigrator {
+	blobstore := p.newBlobstore(dirPath)
+
+	// Cache is not consulted so that copies are verified against the new blobstore
+	toBlobstore, err := p.newConfigBlobstore(toConfig)
+	if err != nil {
+		toBlobstore = NewErrBlobstore(err)
+	}
+
+	indiciesProvider := boshidx.NewProvider(p.indexReporter, blobstore, p.fs)
+
+	return NewFSBlobstoreMigrator(
+		dirPath,
+		p.newConfig(dirPath),
+		toConfig,
+		p.NewFSBlobsDir(dirPath),
+		indiciesProvider.FinalFSIndicies(dirPath),
+		blobstore,
+		toBlobstore,
+		reporter,
+		p.fs,
+		p.logger,
+	)
+}
+
+// NewFSBlobstoreAuditor returns auditor of blobs referenced by the release directory.
+func (p Provider) NewFSBlobstoreAuditor(dirPath string, parallel int) FSBlobstoreAuditor {
+	var blobstore boshblob.DigestBlobstore
+	var lister BlobLister
+
+	// Cache is not consulted so that blobs are verified against the blobstore itself
+	providerBlobstore, err := p.newProviderBlobstore(p.newConfig(dirPath))
+	if err == nil {
+		lister, _ = providerBlobstore.(BlobLister)
+		blobstore, err = p.newDigestBlobstore(providerBlobstore)
+	}
+
+	if err != nil {
+		blobstore = NewErrBlobstore(err)
+	}
+
+	indiciesProvider := boshidx.NewProvider(p.indexReporter, blobstore, p.fs)
+
+	return NewFSBlobstoreAuditor(
+		p.NewFSBlobsDir(dirPath),
+		indiciesProvider.FinalFSIndicies(dirPath),
+		NewFSGitRepo(dirPath, p.cmdRunner, p.fs),
+		blobstore,
+		lister,
+		parallel,
+		p.logger,
+	)
+}
+
 func (p Provider) newBlobstore(dirPath string) boshblob.DigestBlobstore {
-	provider, options, err := p.newConfig(dirPath).Blobstore()
+	blobstore, err := p.newConfigBlobstore(p.newConfig(dirPath))
 	if err != nil {
 		return NewErrBlobstore(err)
 	}
 
+	if p.blobCache != nil {
+		return NewCachingBlobstore(blobstore, p.blobCache, p.logger)
+	}
+
+	return blobstore
+}
+
+func (p Provider) newConfigBlobstore(config Config) (boshblob.DigestBlobstore, error) {
+	blobstore, err := p.newProviderBlobstore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.newDigestBlobstore(blobstore)
+}
+
+func (p Provider) newProviderBlobstore(config Config) (boshblob.Blobstore, error) {
+	provider, options, err := config.Blobstore()
+	if err != nil {
+		return nil, err
+	}
+
 	var blobstore boshblob.Blobstore
 
 	switch provider {
 	case "local":
-		blobstore = boshblob.NewLocalBlobstore(p.fs, p.uuidGen, options)
+		blobstore = NewLocalBlobstore(p.uploadFS, p.uuidGen, options)
 	case "s3":
-		blobstore = NewS3Blobstore(p.fs, p.uuidGen, options)
+		blobstore = NewS3Blobstore(p.uploadFS, p.uuidGen, options)
 	case "gcs":
-		blobstore = NewGCSBlobstore(p.fs, p.uuidGen, options)
+		blobstore = NewGCSBlobstore(p.uploadFS, p.uuidGen, options)
 	case "ghrel":
-		blobstore = NewGHRelBlobstore(p.fs, p.uuidGen, options)
+		blobstore = NewGHRelBlobstore(p.uploadFS, p.uuidGen, options)
+	case "azure-storage":
+		blobstore = NewAzureBlobstore(p.uploadFS, p.uuidGen, options)
+	case "dav":
+		blobstore = NewDavBlobstore(p.uploadFS, p.uuidGen, options, p.logger)
 	default:
-		return NewErrBlobstore(bosherr.Error("Expected release blobstore to be configured"))
+		return nil, bosherr.Error("Expected release blobstore to be configured")
 	}
 
+	return blobstore, nil
+}
+
+func (p Provider) newDigestBlobstore(blobstore boshblob.Blobstore) (boshblob.DigestBlobstore, error) {
 	digestBlobstore := boshblob.NewDigestVerifiableBlobstore(blobstore, p.fs, p.digestCreateAlgorithms)
+	// FSBlobsDir retries uploads failing with network or service errors on top of these attempts after a delay
 	digestBlobstore = boshblob.NewRetryableBlobstore(digestBlobstore, 3, p.logger)
 
-	err = digestBlobstore.Validate()
+	err := digestBlobstore.Validate()
 	if err != nil {
-		return NewErrBlobstore(err)
+		return nil, err
 	}
 
-	return digestBlobstore
+	return digestBlobstore, nil
 }
 
 func (p Provider) newConfig(dirPath string) FSConfig {
@@ -67,9 +67,10 @@ type FakeBlobsDir struct {
 	untrackBlobReturnsOnCall map[int]struct {
 		result1 error
 	}
-	UploadBlobsStub        func() error
+	UploadBlobsStub        func(int) error
 	uploadBlobsMutex       sync.RWMutex
 	uploadBlobsArgsForCall []struct {
+		arg1 int
 	}
 	uploadBlobsReturns struct {
 		result1 error
@@ -377,17 +378,18 @@ func (fake *FakeBlobsDir) UntrackBlobReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeBlobsDir) UploadBlobs() error {
+func (fake *FakeBlobsDir) UploadBlobs(arg1 int) error {
 	fake.uploadBlobsMutex.Lock()
 	ret, specificReturn := fake.uploadBlobsReturnsOnCall[len(fake.uploadBlobsArgsForCall)]
 	fake.uploadBlobsArgsForCall = append(fake.uploadBlobsArgsForCall, struct {
-	}{})
+		arg1 int
+	}{arg1})
 	stub := fake.UploadBlobsStub
 	fakeReturns := fake.uploadBlobsReturns
-	fake.recordInvocation("UploadBlobs", []interface{}{})
+	fake.recordInvocation("UploadBlobs", []interface{}{arg1})
 	fake.uploadBlobsMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
@@ -401,12 +403,19 @@ func (fake *FakeBlobsDir) UploadBlobsCallCount() int {
 	return len(fake.uploadBlobsArgsForCall)
 }
 
-func (fake *FakeBlobsDir) UploadBlobsCalls(stub func() error) {
+func (fake *FakeBlobsDir) UploadBlobsCalls(stub func(int) error) {
 	fake.uploadBlobsMutex.Lock()
 	defer fake.uploadBlobsMutex.Unlock()
 	fake.UploadBlobsStub = stub
 }
 
+func (fake *FakeBlobsDir) UploadBlobsArgsForCall(i int) int {
+	fake.uploadBlobsMutex.RLock()
+	defer fake.uploadBlobsMutex.RUnlock()
+	argsForCall := fake.uploadBlobsArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeBlobsDir) UploadBlobsReturns(result1 error) {
 	fake.uploadBlobsMutex.Lock()
 	defer fake.uploadBlobsMutex.Unlock()
@@ -30,6 +30,19 @@ type FakeBlobsDirReporter struct {
 		arg2 string
 		arg3 error
 	}
+	BlobUploadProgressStub        func(int64, int64)
+	blobUploadProgressMutex       sync.RWMutex
+	blobUploadProgressArgsForCall []struct {
+		arg1 int64
+		arg2 int64
+	}
+	BlobUploadSentStub        func(string, int64, int64)
+	blobUploadSentMutex       sync.RWMutex
+	blobUploadSentArgsForCall []struct {
+		arg1 string
+		arg2 int64
+		arg3 int64
+	}
 	BlobUploadStartedStub        func(string, int64, string)
 	blobUploadStartedMutex       sync.RWMutex
 	blobUploadStartedArgsForCall []struct {
@@ -144,6 +157,73 @@ func (fake *FakeBlobsDirReporter) BlobUploadFinishedArgsForCall(i int) (string,
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
+func (fake *FakeBlobsDirReporter) BlobUploadProgress(arg1 int64, arg2 int64) {
+	fake.blobUploadProgressMutex.Lock()
+	fake.blobUploadProgressArgsForCall = append(fake.blobUploadProgressArgsForCall, struct {
+		arg1 int64
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.BlobUploadProgressStub
+	fake.recordInvocation("BlobUploadProgress", []interface{}{arg1, arg2})
+	fake.blobUploadProgressMutex.Unlock()
+	if stub != nil {
+		fake.BlobUploadProgressStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadProgressCallCount() int {
+	fake.blobUploadProgressMutex.RLock()
+	defer fake.blobUploadProgressMutex.RUnlock()
+	return len(fake.blobUploadProgressArgsForCall)
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadProgressCalls(stub func(int64, int64)) {
+	fake.blobUploadProgressMutex.Lock()
+	defer fake.blobUploadProgressMutex.Unlock()
+	fake.BlobUploadProgressStub = stub
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadProgressArgsForCall(i int) (int64, int64) {
+	fake.blobUploadProgressMutex.RLock()
+	defer fake.blobUploadProgressMutex.RUnlock()
+	argsForCall := fake.blobUploadProgressArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadSent(arg1 string, arg2 int64, arg3 int64) {
+	fake.blobUploadSentMutex.Lock()
+	fake.blobUploadSentArgsForCall = append(fake.blobUploadSentArgsForCall, struct {
+		arg1 string
+		arg2 int64
+		arg3 int64
+	}{arg1, arg2, arg3})
+	stub := fake.BlobUploadSentStub
+	fake.recordInvocation("BlobUploadSent", []interface{}{arg1, arg2, arg3})
+	fake.blobUploadSentMutex.Unlock()
+	if stub != nil {
+		fake.BlobUploadSentStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadSentCallCount() int {
+	fake.blobUploadSentMutex.RLock()
+	defer fake.blobUploadSentMutex.RUnlock()
+	return len(fake.blobUploadSentArgsForCall)
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadSentCalls(stub func(string, int64, int64)) {
+	fake.blobUploadSentMutex.Lock()
+	defer fake.blobUploadSentMutex.Unlock()
+	fake.BlobUploadSentStub = stub
+}
+
+func (fake *FakeBlobsDirReporter) BlobUploadSentArgsForCall(i int) (string, int64, int64) {
+	fake.blobUploadSentMutex.RLock()
+	defer fake.blobUploadSentMutex.RUnlock()
+	argsForCall := fake.blobUploadSentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeBlobsDirReporter) BlobUploadStarted(arg1 string, arg2 int64, arg3 string) {
 	fake.blobUploadStartedMutex.Lock()
 	fake.blobUploadStartedArgsForCall = append(fake.blobUploadStartedArgsForCall, struct {
@@ -187,6 +267,10 @@ func (fake *FakeBlobsDirReporter) Invocations() map[string][][]interface{} {
 	defer fake.blobDownloadStartedMutex.RUnlock()
 	fake.blobUploadFinishedMutex.RLock()
 	defer fake.blobUploadFinishedMutex.RUnlock()
+	fake.blobUploadProgressMutex.RLock()
+	defer fake.blobUploadProgressMutex.RUnlock()
+	fake.blobUploadSentMutex.RLock()
+	defer fake.blobUploadSentMutex.RUnlock()
 	fake.blobUploadStartedMutex.RLock()
 	defer fake.blobUploadStartedMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -97,6 +97,18 @@ type FakeReleaseDir struct {
 	initReturnsOnCall map[int]struct {
 		result1 error
 	}
+	LintStub        func() ([]releasedir.LintProblem, error)
+	lintMutex       sync.RWMutex
+	lintArgsForCall []struct {
+	}
+	lintReturns struct {
+		result1 []releasedir.LintProblem
+		result2 error
+	}
+	lintReturnsOnCall map[int]struct {
+		result1 []releasedir.LintProblem
+		result2 error
+	}
 	NextDevVersionStub        func(string, bool) (version.Version, error)
 	nextDevVersionMutex       sync.RWMutex
 	nextDevVersionArgsForCall []struct {
@@ -581,6 +593,62 @@ func (fake *FakeReleaseDir) InitReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeReleaseDir) Lint() ([]releasedir.LintProblem, error) {
+	fake.lintMutex.Lock()
+	ret, specificReturn := fake.lintReturnsOnCall[len(fake.lintArgsForCall)]
+	fake.lintArgsForCall = append(fake.lintArgsForCall, struct {
+	}{})
+	stub := fake.LintStub
+	fakeReturns := fake.lintReturns
+	fake.recordInvocation("Lint", []interface{}{})
+	fake.lintMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReleaseDir) LintCallCount() int {
+	fake.lintMutex.RLock()
+	defer fake.lintMutex.RUnlock()
+	return len(fake.lintArgsForCall)
+}
+
+func (fake *FakeReleaseDir) LintCalls(stub func() ([]releasedir.LintProblem, error)) {
+	fake.lintMutex.Lock()
+	defer fake.lintMutex.Unlock()
+	fake.LintStub = stub
+}
+
+func (fake *FakeReleaseDir) LintReturns(result1 []releasedir.LintProblem, result2 error) {
+	fake.lintMutex.Lock()
+	defer fake.lintMutex.Unlock()
+	fake.LintStub = nil
+	fake.lintReturns = struct {
+		result1 []releasedir.LintProblem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseDir) LintReturnsOnCall(i int, result1 []releasedir.LintProblem, result2 error) {
+	fake.lintMutex.Lock()
+	defer fake.lintMutex.Unlock()
+	fake.LintStub = nil
+	if fake.lintReturnsOnCall == nil {
+		fake.lintReturnsOnCall = make(map[int]struct {
+			result1 []releasedir.LintProblem
+			result2 error
+		})
+	}
+	fake.lintReturnsOnCall[i] = struct {
+		result1 []releasedir.LintProblem
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeReleaseDir) NextDevVersion(arg1 string, arg2 bool) (version.Version, error) {
 	fake.nextDevVersionMutex.Lock()
 	ret, specificReturn := fake.nextDevVersionReturnsOnCall[len(fake.nextDevVersionArgsForCall)]
@@ -841,6 +909,8 @@ func (fake *FakeReleaseDir) Invocations() map[string][][]interface{} {
 	defer fake.generatePackageMutex.RUnlock()
 	fake.initMutex.RLock()
 	defer fake.initMutex.RUnlock()
+	fake.lintMutex.RLock()
+	defer fake.lintMutex.RUnlock()
 	fake.nextDevVersionMutex.RLock()
 	defer fake.nextDevVersionMutex.RUnlock()
 	fake.nextFinalVersionMutex.RLock()
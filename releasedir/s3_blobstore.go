@@ -4,7 +4,10 @@ import (
 	gobytes "bytes"
 	"encoding/json"
 	"os"
+	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	s3client "github.com/cloudfoundry/bosh-s3cli/client"
 	s3config "github.com/cloudfoundry/bosh-s3cli/config"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -82,7 +85,69 @@ func (b S3Blobstore) CleanUp(path string) error {
 }
 
 func (b S3Blobstore) Delete(blobID string) error {
-	panic("Not implemented")
+	client, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	err = client.Delete(blobID)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting blob '%s'", blobID)
+	}
+
+	return nil
+}
+
+// List returns IDs of all blobs in the bucket folder.
+func (b S3Blobstore) List() ([]string, error) {
+	conf, err := b.config()
+	if err != nil {
+		return nil, err
+	}
+
+	s3ClientSDK, err := s3client.NewSDK(conf)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Building client SDK")
+	}
+
+	var prefix string
+
+	if len(conf.FolderName) > 0 {
+		prefix = conf.FolderName + "/"
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(conf.BucketName),
+		Prefix: aws.String(prefix),
+	}
+
+	var blobIDs []string
+
+	err = s3ClientSDK.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			blobIDs = append(blobIDs, strings.TrimPrefix(aws.StringValue(object.Key), prefix))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing blobs")
+	}
+
+	return blobIDs, nil
+}
+
+// ListScope is shared unless blobs are kept in a bucket folder.
+func (b S3Blobstore) ListScope() (string, bool, error) {
+	conf, err := b.config()
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(conf.FolderName) == 0 {
+		return "s3://" + conf.BucketName + "/", true, nil
+	}
+
+	return "s3://" + conf.BucketName + "/" + conf.FolderName + "/", false, nil
 }
 
 func (b S3Blobstore) Validate() error {
@@ -91,14 +156,9 @@ func (b S3Blobstore) Validate() error {
 }
 
 func (b S3Blobstore) client() (s3client.S3Blobstore, error) {
-	bytes, err := json.Marshal(b.options)
+	conf, err := b.config()
 	if err != nil {
-		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Marshaling config")
-	}
-
-	conf, err := s3config.NewFromReader(gobytes.NewBuffer(bytes))
-	if err != nil {
-		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Reading config")
+		return s3client.S3Blobstore{}, err
 	}
 
 	s3ClientSDK, err := s3client.NewSDK(conf)
@@ -113,3 +173,17 @@ func (b S3Blobstore) client() (s3client.S3Blobstore, error) {
 
 	return client, nil
 }
+
+func (b S3Blobstore) config() (s3config.S3Cli, error) {
+	bytes, err := json.Marshal(b.options)
+	if err != nil {
+		return s3config.S3Cli{}, bosherr.WrapErrorf(err, "Marshaling config")
+	}
+
+	conf, err := s3config.NewFromReader(gobytes.NewBuffer(bytes))
+	if err != nil {
+		return s3config.S3Cli{}, bosherr.WrapErrorf(err, "Reading config")
+	}
+
+	return conf, nil
+}
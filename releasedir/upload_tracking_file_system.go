@@ -0,0 +1,110 @@
+package releasedir
+
+import (
+	"os"
+	"sync"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// UploadTrackingFileSystem is given to blobstores so that progress of uploads
+// can be followed by reads of uploaded files since blobstores only take paths.
+type UploadTrackingFileSystem struct {
+	boshsys.FileSystem
+
+	mutex   *sync.Mutex
+	tracked map[string]func(int64)
+}
+
+func NewUploadTrackingFileSystem(fs boshsys.FileSystem) UploadTrackingFileSystem {
+	return UploadTrackingFileSystem{
+		FileSystem: fs,
+
+		mutex:   &sync.Mutex{},
+		tracked: map[string]func(int64){},
+	}
+}
+
+// TrackUpload calls progress with the number of bytes read from the file at path
+// each time it is read, until untrack is called. Count starts over when the file
+// is opened again, e.g. when upload is retried.
+func (fs UploadTrackingFileSystem) TrackUpload(path string, progress func(sentSize int64)) (untrack func()) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.tracked[path] = progress
+
+	return func() {
+		fs.mutex.Lock()
+		defer fs.mutex.Unlock()
+
+		delete(fs.tracked, path)
+	}
+}
+
+func (fs UploadTrackingFileSystem) OpenFile(path string, flag int, perm os.FileMode) (boshsys.File, error) {
+	file, err := fs.FileSystem.OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.mutex.Lock()
+	progress, found := fs.tracked[path]
+	fs.mutex.Unlock()
+
+	if !found {
+		return file, nil
+	}
+
+	return &uploadTrackingFile{File: file, progress: progress}, nil
+}
+
+// uploadTrackingFile reports the furthest offset read so far
+// since clients may read parts of the file out of order.
+type uploadTrackingFile struct {
+	boshsys.File
+
+	mutex    sync.Mutex
+	offset   int64
+	sentSize int64
+	progress func(int64)
+}
+
+func (f *uploadTrackingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+
+	f.mutex.Lock()
+	f.offset += int64(n)
+	f.reportLocked(f.offset)
+	f.mutex.Unlock()
+
+	return n, err
+}
+
+func (f *uploadTrackingFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+
+	f.mutex.Lock()
+	f.reportLocked(off + int64(n))
+	f.mutex.Unlock()
+
+	return n, err
+}
+
+func (f *uploadTrackingFile) Seek(offset int64, whence int) (int64, error) {
+	pos, err := f.File.Seek(offset, whence)
+	if err == nil {
+		f.mutex.Lock()
+		f.offset = pos
+		f.mutex.Unlock()
+	}
+
+	return pos, err
+}
+
+func (f *uploadTrackingFile) reportLocked(readSize int64) {
+	if readSize > f.sentSize {
+		f.sentSize = readSize
+		f.progress(f.sentSize)
+	}
+}
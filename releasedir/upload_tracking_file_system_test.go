@@ -0,0 +1,129 @@
+package releasedir_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+)
+
+var _ = Describe("UploadTrackingFileSystem", func() {
+	var (
+		tmpDir   string
+		path     string
+		uploadFS UploadTrackingFileSystem
+		reported []int64
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tmpDir, err = os.MkdirTemp("", "upload-tracking-fs")
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(tmpDir, "blob")
+		Expect(os.WriteFile(path, []byte(strings.Repeat("a", 250)), 0644)).To(Succeed())
+
+		uploadFS = NewUploadTrackingFileSystem(boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)))
+		reported = nil
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir) //nolint:errcheck
+	})
+
+	track := func() func() {
+		return uploadFS.TrackUpload(path, func(sentSize int64) { reported = append(reported, sentSize) })
+	}
+
+	open := func() boshsys.File {
+		file, err := uploadFS.OpenFile(path, os.O_RDONLY, 0)
+		Expect(err).ToNot(HaveOccurred())
+		return file
+	}
+
+	It("reports bytes read from tracked file", func() {
+		defer track()()
+
+		file := open()
+		defer file.Close() //nolint:errcheck
+
+		buf := make([]byte, 100)
+
+		for {
+			_, err := file.Read(buf)
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(reported).To(Equal([]int64{100, 200, 250}))
+	})
+
+	It("reports furthest offset when file is read out of order", func() {
+		defer track()()
+
+		file := open()
+		defer file.Close() //nolint:errcheck
+
+		buf := make([]byte, 100)
+
+		_, err := file.ReadAt(buf, 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.ReadAt(buf, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.Seek(200, io.SeekStart)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.Read(buf)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(reported).To(Equal([]int64{200, 250}))
+	})
+
+	It("starts over when tracked file is opened again", func() {
+		defer track()()
+
+		for i := 0; i < 2; i++ {
+			file := open()
+
+			_, err := io.ReadAll(file)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(file.Close()).To(Succeed())
+		}
+
+		Expect(reported).To(Equal([]int64{250, 250}))
+	})
+
+	It("does not report reads of files that are not tracked", func() {
+		track()()
+
+		file := open()
+		defer file.Close() //nolint:errcheck
+
+		_, err := io.ReadAll(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(reported).To(BeEmpty())
+	})
+
+	It("returns error if file cannot be opened", func() {
+		defer track()()
+
+		Expect(os.Remove(path)).To(Succeed())
+
+		_, err := uploadFS.OpenFile(path, os.O_RDONLY, 0)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,6 +3,7 @@ package job
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -26,14 +27,23 @@ type DependencyCompiler interface {
 
 type dependencyCompiler struct {
 	packageCompiler bistatepkg.Compiler
+	parallel        int
 
 	logTag string
 	logger boshlog.Logger
 }
 
 func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, logger boshlog.Logger) DependencyCompiler {
+	return NewParallelDependencyCompiler(packageCompiler, 1, logger)
+}
+
+// NewParallelDependencyCompiler compiles up to parallel packages at a time. A
+// package is only compiled once all of its dependencies have been compiled.
+// packageCompiler must be safe for concurrent use when parallel is above 1.
+func NewParallelDependencyCompiler(packageCompiler bistatepkg.Compiler, parallel int, logger boshlog.Logger) DependencyCompiler {
 	return &dependencyCompiler{
 		packageCompiler: packageCompiler,
+		parallel:        parallel,
 
 		logTag: "dependencyCompiler",
 		logger: logger,
@@ -106,6 +116,10 @@ func (c *dependencyCompiler) resolvePackageDependencies(releasePackage birelpkg.
 
 // compilePackages compiles the specified packages, in the order specified, uploads them to the Blobstore, and returns the blob references
 func (c *dependencyCompiler) compilePackages(requiredPackages []birelpkg.Compilable, stage biui.Stage) ([]CompiledPackageRef, error) {
+	if c.parallel > 1 && len(requiredPackages) > 1 {
+		return c.compilePackagesInParallel(requiredPackages, stage)
+	}
+
 	packageRefs := make([]CompiledPackageRef, 0, len(requiredPackages))
 
 	for _, pkg := range requiredPackages {
@@ -139,4 +153,120 @@ func (c *dependencyCompiler) compilePackages(requiredPackages []birelpkg.Compila
 	return packageRefs, nil
 }
 
+type compileResult struct {
+	index             int
+	record            bistatepkg.CompiledPackageRecord
+	isAlreadyCompiled bool
+	err               error
+}
+
+// compilePackagesInParallel compiles every package whose dependencies are
+// compiled, up to c.parallel at a time. Packages are reported to the stage as
+// they finish since stages cannot be performed concurrently. The returned
+// blob references are in compilation order.
+func (c *dependencyCompiler) compilePackagesInParallel(requiredPackages []birelpkg.Compilable, stage biui.Stage) ([]CompiledPackageRef, error) {
+	indexes := map[string]int{}
+	for i, pkg := range requiredPackages {
+		indexes[c.pkgKey(pkg)] = i
+	}
+
+	pendingDeps := make([]int, len(requiredPackages))
+	dependents := make([][]int, len(requiredPackages))
+	ready := []int{}
+
+	for i, pkg := range requiredPackages {
+		for _, dep := range pkg.Deps() {
+			depIndex, found := indexes[c.pkgKey(dep)]
+			if !found {
+				continue
+			}
+			pendingDeps[i]++
+			dependents[depIndex] = append(dependents[depIndex], i)
+		}
+
+		if pendingDeps[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	results := make(chan compileResult)
+	var wg sync.WaitGroup
+
+	compile := func(index int) {
+		defer wg.Done()
+		record, isAlreadyCompiled, err := c.packageCompiler.Compile(requiredPackages[index])
+		results <- compileResult{index: index, record: record, isAlreadyCompiled: isAlreadyCompiled, err: err}
+	}
+
+	packageRefs := make([]CompiledPackageRef, len(requiredPackages))
+	running := 0
+	compiled := 0
+	var compileErr error
+
+	for compiled < len(requiredPackages) {
+		for compileErr == nil && len(ready) > 0 && running < c.parallel {
+			wg.Add(1)
+			go compile(ready[0])
+			ready = ready[1:]
+			running++
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+
+		pkg := requiredPackages[result.index]
+		stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+		err := stage.Perform(stepName, func() error {
+			if result.err != nil {
+				return result.err
+			}
+
+			if result.isAlreadyCompiled {
+				return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
+			}
+
+			return nil
+		})
+		if err != nil {
+			// Let packages that are already compiling finish, but start no more
+			if compileErr == nil {
+				compileErr = err
+			}
+			continue
+		}
+
+		packageRefs[result.index] = CompiledPackageRef{
+			Name:        pkg.Name(),
+			Version:     pkg.Fingerprint(),
+			BlobstoreID: result.record.BlobID,
+			SHA1:        result.record.BlobSHA1,
+		}
+		compiled++
+
+		for _, dependent := range dependents[result.index] {
+			pendingDeps[dependent]--
+			if pendingDeps[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	wg.Wait()
+
+	if compileErr != nil {
+		return nil, compileErr
+	}
+
+	if compiled < len(requiredPackages) {
+		return nil, bosherr.Errorf("Compiled %d of %d packages, the remaining packages have unresolvable dependencies", compiled, len(requiredPackages))
+	}
+
+	return packageRefs, nil
+}
+
 func (c *dependencyCompiler) pkgKey(pkg birelpkg.Compilable) string { return pkg.Name() }
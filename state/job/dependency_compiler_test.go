@@ -1,6 +1,8 @@
 package job_test
 
 import (
+	"errors"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -224,4 +226,61 @@ var _ = Describe("DependencyCompiler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("when compiling in parallel", func() {
+		var (
+			pkg3              *boshrelpkg.Package
+			expectCompilePkg3 *gomock.Call
+		)
+
+		BeforeEach(func() {
+			dependencyCompiler = NewParallelDependencyCompiler(mockPackageCompiler, 3, logger)
+
+			pkg3 = newPkg("pkg3-name", "pkg3-fp", nil)
+
+			job.PackageNames = append(job.PackageNames, pkg3.Name())
+			err := job.AttachPackages([]*boshrelpkg.Package{pkg2, pkg3})
+			Expect(err).ToNot(HaveOccurred())
+			jobs = []boshreljob.Job{*job}
+		})
+
+		JustBeforeEach(func() {
+			compiledPackageRecord3 := bistatepkg.CompiledPackageRecord{
+				BlobID:   "fake-compiled-package-blobstore-id-3",
+				BlobSHA1: "fake-compiled-package-sha1-3",
+			}
+			expectCompilePkg3 = mockPackageCompiler.EXPECT().Compile(pkg3).Return(compiledPackageRecord3, false, nil).AnyTimes()
+		})
+
+		It("compiles each package once, after its dependencies", func() {
+			expectCompilePkg1.Times(1)
+			expectCompilePkg2.Times(1).After(expectCompilePkg1)
+			expectCompilePkg3.Times(1)
+
+			_, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stage.PerformCalls).To(HaveLen(3))
+		})
+
+		It("returns references to the compiled packages in compilation order", func() {
+			compiledPackageRefs, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+
+			positions := map[string]int{}
+			for i, ref := range compiledPackageRefs {
+				positions[ref.Name] = i
+			}
+			Expect(positions).To(HaveLen(3))
+			Expect(positions["pkg1-name"]).To(BeNumerically("<", positions["pkg2-name"]))
+		})
+
+		It("returns the error of a failed package", func() {
+			expectCompilePkg1.Return(bistatepkg.CompiledPackageRecord{}, false, errors.New("fake-compile-error"))
+			expectCompilePkg2.Times(0)
+
+			_, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+		})
+	})
 })
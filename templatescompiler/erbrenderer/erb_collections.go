@@ -0,0 +1,1610 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxRangeSize limits how many items a range is expanded into.
+const maxRangeSize = 1000000
+
+func newEnumerator(items []interface{}, receiver interface{}, method string, args []interface{}) *rbEnumerator {
+	return &rbEnumerator{items: items, receiver: receiver, method: method, args: args}
+}
+
+// toA converts a value the way Kernel#Array does.
+func toA(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case *rbArray:
+		return v.items, nil
+	case *rbHash:
+		return v.pairs(), nil
+	case *rbRange:
+		return rangeItems(v)
+	case *rbEnumerator:
+		return v.items, nil
+	}
+	return []interface{}{value}, nil
+}
+
+func toArrayArg(value interface{}) ([]interface{}, error) {
+	if array, ok := value.(*rbArray); ok {
+		return array.items, nil
+	}
+	return nil, typeConversionError(value, "Array")
+}
+
+func rangeItems(r *rbRange) ([]interface{}, error) {
+	from, ok := r.from.(int64)
+	if !ok {
+		if r.from == nil {
+			return nil, newRubyError("TypeError", "can't iterate from NilClass")
+		}
+		return nil, &unsupportedError{reason: fmt.Sprintf("iterating ranges of %s is not supported", className(r.from))}
+	}
+
+	var to int64
+	switch v := r.to.(type) {
+	case int64:
+		to = v
+		if r.exclusive {
+			if to == math.MinInt64 {
+				return []interface{}{}, nil
+			}
+			to--
+		}
+	case float64:
+		to = int64(v)
+		if r.exclusive && float64(to) == v {
+			to--
+		}
+	default:
+		return nil, &unsupportedError{reason: "iterating endless ranges is not supported"}
+	}
+
+	if to < from {
+		return []interface{}{}, nil
+	}
+
+	if size, err := subtractIntegers(to, from); err != nil || size >= maxRangeSize {
+		return nil, &unsupportedError{reason: "iterating large ranges is not supported"}
+	}
+
+	items := make([]interface{}, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		items = append(items, n)
+	}
+	return items, nil
+}
+
+func rangeCovers(r *rbRange, value interface{}) bool {
+	if r.from != nil {
+		result, err := rbCompare(r.from, value)
+		if err != nil || result > 0 {
+			return false
+		}
+	}
+	if r.to != nil {
+		result, err := rbCompare(value, r.to)
+		if err != nil || result > 0 || (r.exclusive && result == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *erbInterpreter) dig(value interface{}, keys []interface{}) (interface{}, error) {
+	for _, key := range keys {
+		switch value.(type) {
+		case nil:
+			return nil, nil
+		case *rbHash, *rbArray, *rbOpenStruct:
+			var err error
+			value, err = i.callMethod(value, "[]", []interface{}{key}, nil)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, newRubyError("TypeError", "%s does not have #dig method", className(value))
+		}
+	}
+	return value, nil
+}
+
+// compareWith compares items with the block when given, like Array#sort.
+func compareWith(block *rbBlock) func(a, b interface{}) (int, error) {
+	if block == nil {
+		return rbCompare
+	}
+	return func(a, b interface{}) (int, error) {
+		result, err := block.call(a, b)
+		if err != nil {
+			return 0, err
+		}
+		n, ok := result.(int64)
+		if !ok {
+			return 0, newRubyError("ArgumentError", "comparison of %s with %s failed", className(a), className(b))
+		}
+		return int(n), nil
+	}
+}
+
+// enumerableMethod implements the Enumerable methods over the items that a
+// collection yields. It reports whether it knows the method.
+func (i *erbInterpreter) enumerableMethod(receiver interface{}, items []interface{}, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	value, err := i.enumerable(receiver, items, name, args, block)
+	if unknown, ok := err.(unknownMethod); ok && string(unknown) == name {
+		return nil, false, nil
+	}
+	return value, true, err
+}
+
+type unknownMethod string
+
+func (u unknownMethod) Error() string { return string(u) }
+
+var enumeratorMethods = map[string]bool{
+	"each": true, "each_entry": true, "each_with_index": true, "reverse_each": true, "map": true,
+	"collect": true, "flat_map": true, "collect_concat": true, "filter_map": true, "select": true,
+	"filter": true, "find_all": true, "reject": true, "each_slice": true, "each_cons": true,
+	"group_by": true, "partition": true, "sort_by": true, "min_by": true, "max_by": true,
+	"find_index": true, "each_with_object": true, "find": true, "detect": true,
+}
+
+func (i *erbInterpreter) enumerable(receiver interface{}, items []interface{}, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	if block == nil && enumeratorMethods[name] && !(name == "find_index" && len(args) > 0) {
+		yielded := items
+		switch name {
+		case "each_with_index":
+			yielded = make([]interface{}, len(items))
+			for index, item := range items {
+				yielded[index] = newArray(item, int64(index))
+			}
+		case "each_slice", "each_cons":
+			value, err := i.enumerable(nil, items, name, args, nativeBlock(func(args []interface{}) (interface{}, error) { return nil, nil }))
+			if err != nil {
+				return nil, err
+			}
+			yielded = value.(*rbArray).items
+		case "each_with_object":
+			return nil, &unsupportedError{reason: "'each_with_object' without a block"}
+		}
+		return newEnumerator(yielded, receiver, name, args), nil
+	}
+
+	switch name {
+	case "each", "each_entry":
+		for _, item := range items {
+			if _, err := block.call(item); err != nil {
+				return nil, err
+			}
+		}
+		return receiver, nil
+
+	case "reverse_each":
+		for index := len(items) - 1; index >= 0; index-- {
+			if _, err := block.call(items[index]); err != nil {
+				return nil, err
+			}
+		}
+		return receiver, nil
+
+	case "each_with_index":
+		for index, item := range items {
+			if _, err := block.call(item, int64(index)); err != nil {
+				return nil, err
+			}
+		}
+		return receiver, nil
+
+	case "each_with_object":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if _, err := block.call(item, args[0]); err != nil {
+				return nil, err
+			}
+		}
+		return args[0], nil
+
+	case "map", "collect", "flat_map", "collect_concat", "filter_map":
+		results := []interface{}{}
+		for _, item := range items {
+			value, err := block.call(item)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case name == "filter_map":
+				if truthy(value) {
+					results = append(results, value)
+				}
+			case name == "flat_map" || name == "collect_concat":
+				if array, ok := value.(*rbArray); ok {
+					results = append(results, array.items...)
+				} else {
+					results = append(results, value)
+				}
+			default:
+				results = append(results, value)
+			}
+		}
+		return newArray(results...), nil
+
+	case "select", "filter", "find_all", "reject":
+		results := []interface{}{}
+		for _, item := range items {
+			value, err := block.call(item)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(value) == (name != "reject") {
+				results = append(results, item)
+			}
+		}
+		return newArray(results...), nil
+
+	case "partition":
+		selected, rejected := []interface{}{}, []interface{}{}
+		for _, item := range items {
+			value, err := block.call(item)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(value) {
+				selected = append(selected, item)
+			} else {
+				rejected = append(rejected, item)
+			}
+		}
+		return newArray(newArray(selected...), newArray(rejected...)), nil
+
+	case "group_by":
+		groups := newHash()
+		for _, item := range items {
+			key, err := block.call(item)
+			if err != nil {
+				return nil, err
+			}
+			group, ok := groups.fetch(key).(*rbArray)
+			if !ok {
+				group = newArray()
+				groups.set(key, group)
+			}
+			group.items = append(group.items, item)
+		}
+		return groups, nil
+
+	case "find", "detect":
+		for _, item := range items {
+			value, err := block.call(item)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(value) {
+				return item, nil
+			}
+		}
+		return nil, nil
+
+	case "find_index":
+		for index, item := range items {
+			var matched bool
+			if len(args) > 0 {
+				matched = rbEqual(item, args[0])
+			} else {
+				value, err := block.call(item)
+				if err != nil {
+					return nil, err
+				}
+				matched = truthy(value)
+			}
+			if matched {
+				return int64(index), nil
+			}
+		}
+		return nil, nil
+
+	case "inject", "reduce":
+		return i.inject(items, args, block)
+
+	case "sum":
+		if err := checkArgs(args, 0, 1); err != nil {
+			return nil, err
+		}
+		var total interface{} = int64(0)
+		if len(args) == 1 {
+			total = args[0]
+		}
+		for _, item := range items {
+			value := item
+			if block != nil {
+				var err error
+				if value, err = block.call(item); err != nil {
+					return nil, err
+				}
+			}
+			var err error
+			if total, err = i.callMethod(total, "+", []interface{}{value}, nil); err != nil {
+				return nil, err
+			}
+		}
+		return total, nil
+
+	case "count":
+		if len(args) == 0 && block == nil {
+			return int64(len(items)), nil
+		}
+		count := int64(0)
+		for _, item := range items {
+			matched := false
+			if len(args) > 0 {
+				matched = rbEqual(item, args[0])
+			} else {
+				value, err := block.call(item)
+				if err != nil {
+					return nil, err
+				}
+				matched = truthy(value)
+			}
+			if matched {
+				count++
+			}
+		}
+		return count, nil
+
+	case "min", "max", "minmax":
+		if len(args) > 0 {
+			return nil, &unsupportedError{reason: fmt.Sprintf("'%s' with a count", name)}
+		}
+		sorted := append([]interface{}{}, items...)
+		if err := sortValues(sorted, compareWith(block)); err != nil {
+			return nil, err
+		}
+		if len(sorted) == 0 {
+			if name == "minmax" {
+				return newArray(nil, nil), nil
+			}
+			return nil, nil
+		}
+		switch name {
+		case "min":
+			return sorted[0], nil
+		case "max":
+			return sorted[len(sorted)-1], nil
+		}
+		return newArray(sorted[0], sorted[len(sorted)-1]), nil
+
+	case "sort":
+		sorted := append([]interface{}{}, items...)
+		if err := sortValues(sorted, compareWith(block)); err != nil {
+			return nil, err
+		}
+		return newArray(sorted...), nil
+
+	case "sort_by", "min_by", "max_by":
+		type keyed struct{ key, item interface{} }
+		pairs := make([]interface{}, len(items))
+		for index, item := range items {
+			key, err := block.call(item)
+			if err != nil {
+				return nil, err
+			}
+			pairs[index] = keyed{key, item}
+		}
+		err := sortValues(pairs, func(a, b interface{}) (int, error) {
+			return rbCompare(a.(keyed).key, b.(keyed).key)
+		})
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "min_by", "max_by":
+			if len(pairs) == 0 {
+				return nil, nil
+			}
+			if name == "min_by" {
+				return pairs[0].(keyed).item, nil
+			}
+			return pairs[len(pairs)-1].(keyed).item, nil
+		}
+		sorted := make([]interface{}, len(pairs))
+		for index, pair := range pairs {
+			sorted[index] = pair.(keyed).item
+		}
+		return newArray(sorted...), nil
+
+	case "each_slice", "each_cons":
+		size, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if size <= 0 {
+			return nil, newRubyError("ArgumentError", "invalid size")
+		}
+		var groups []interface{}
+		if name == "each_slice" {
+			for start := 0; start < len(items); start += size {
+				end := start + size
+				if end > len(items) {
+					end = len(items)
+				}
+				groups = append(groups, newArray(append([]interface{}{}, items[start:end]...)...))
+			}
+		} else {
+			for start := 0; start+size <= len(items); start++ {
+				groups = append(groups, newArray(append([]interface{}{}, items[start:start+size]...)...))
+			}
+		}
+		for _, group := range groups {
+			if _, err := block.call(group); err != nil {
+				return nil, err
+			}
+		}
+		if receiver == nil {
+			return newArray(groups...), nil
+		}
+		return receiver, nil
+
+	case "zip":
+		if block != nil {
+			return nil, &unsupportedError{reason: "'zip' with a block"}
+		}
+		others := make([][]interface{}, len(args))
+		for index, arg := range args {
+			other, err := toA(arg)
+			if err != nil {
+				return nil, err
+			}
+			others[index] = other
+		}
+		zipped := make([]interface{}, len(items))
+		for index, item := range items {
+			tuple := []interface{}{item}
+			for _, other := range others {
+				if index < len(other) {
+					tuple = append(tuple, other[index])
+				} else {
+					tuple = append(tuple, nil)
+				}
+			}
+			zipped[index] = newArray(tuple...)
+		}
+		return newArray(zipped...), nil
+
+	case "first":
+		if len(args) == 0 {
+			if len(items) == 0 {
+				return nil, nil
+			}
+			return items[0], nil
+		}
+		fallthrough
+	case "take":
+		count, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, newRubyError("ArgumentError", "attempt to take negative size")
+		}
+		if count > len(items) {
+			count = len(items)
+		}
+		return newArray(append([]interface{}{}, items[:count]...)...), nil
+
+	case "drop":
+		count, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, newRubyError("ArgumentError", "attempt to drop negative size")
+		}
+		if count > len(items) {
+			count = len(items)
+		}
+		return newArray(append([]interface{}{}, items[count:]...)...), nil
+
+	case "take_while", "drop_while":
+		if err := requireBlock(block, name); err != nil {
+			return nil, err
+		}
+		index := 0
+		for ; index < len(items); index++ {
+			value, err := block.call(items[index])
+			if err != nil {
+				return nil, err
+			}
+			if !truthy(value) {
+				break
+			}
+		}
+		if name == "take_while" {
+			return newArray(append([]interface{}{}, items[:index]...)...), nil
+		}
+		return newArray(append([]interface{}{}, items[index:]...)...), nil
+
+	case "include?", "member?":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if rbEqual(item, args[0]) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "any?", "all?", "none?", "one?":
+		matches := 0
+		for _, item := range items {
+			var matched bool
+			switch {
+			case len(args) > 0:
+				var err error
+				if matched, err = caseEqual(args[0], item); err != nil {
+					return nil, err
+				}
+			case block != nil:
+				value, err := block.call(item)
+				if err != nil {
+					return nil, err
+				}
+				matched = truthy(value)
+			default:
+				matched = truthy(item)
+			}
+			if matched {
+				matches++
+			}
+		}
+		switch name {
+		case "any?":
+			return matches > 0, nil
+		case "all?":
+			return matches == len(items), nil
+		case "none?":
+			return matches == 0, nil
+		}
+		return matches == 1, nil
+
+	case "uniq":
+		seen := map[interface{}]bool{}
+		results := []interface{}{}
+		for _, item := range items {
+			key := item
+			if block != nil {
+				var err error
+				if key, err = block.call(item); err != nil {
+					return nil, err
+				}
+			}
+			if seen[hashKey(key)] {
+				continue
+			}
+			seen[hashKey(key)] = true
+			results = append(results, item)
+		}
+		return newArray(results...), nil
+
+	case "tally":
+		counts := newHash()
+		for _, item := range items {
+			count, _ := counts.fetch(item).(int64)
+			counts.set(item, count+1)
+		}
+		return counts, nil
+
+	case "to_a", "entries":
+		return newArray(append([]interface{}{}, items...)...), nil
+
+	case "to_h":
+		hash := newHash()
+		for index, item := range items {
+			if block != nil {
+				var err error
+				if item, err = block.call(item); err != nil {
+					return nil, err
+				}
+			}
+			pair, ok := item.(*rbArray)
+			if !ok {
+				return nil, newRubyError("TypeError", "wrong element type %s at %d (expected array)", className(item), index)
+			}
+			if len(pair.items) != 2 {
+				return nil, newRubyError("ArgumentError", "wrong array length at %d (expected 2, was %d)", index, len(pair.items))
+			}
+			hash.set(pair.items[0], pair.items[1])
+		}
+		return hash, nil
+	}
+
+	return nil, unknownMethod(name)
+}
+
+func (i *erbInterpreter) inject(items []interface{}, args []interface{}, block *rbBlock) (interface{}, error) {
+	if err := checkArgs(args, 0, 2); err != nil {
+		return nil, err
+	}
+
+	var memo interface{}
+	hasMemo := false
+	var op string
+
+	switch len(args) {
+	case 1:
+		if symbol, ok := args[0].(rbSymbol); ok && block == nil {
+			op = string(symbol)
+		} else {
+			memo, hasMemo = args[0], true
+		}
+	case 2:
+		memo, hasMemo = args[0], true
+		op = toS(args[1])
+	}
+
+	if op == "" && block == nil {
+		return nil, newRubyError("LocalJumpError", "no block given")
+	}
+
+	if !hasMemo {
+		if len(items) == 0 {
+			return nil, nil
+		}
+		memo, items = items[0], items[1:]
+	}
+
+	for _, item := range items {
+		var err error
+		if op != "" {
+			memo, err = i.callMethod(memo, op, []interface{}{item}, nil)
+		} else {
+			memo, err = block.call(memo, item)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return memo, nil
+}
+
+func (i *erbInterpreter) enumeratorMethod(enumerator *rbEnumerator, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "with_index", "each_with_index", "with_object", "each_with_object":
+		if err := checkArgs(args, 0, 1); err != nil {
+			return nil, err
+		}
+
+		withObject := strings.HasSuffix(name, "object")
+		if withObject && len(args) == 0 {
+			return nil, newRubyError("ArgumentError", "wrong number of arguments (given 0, expected 1)")
+		}
+
+		offset := 0
+		if name == "with_index" && len(args) == 1 && args[0] != nil {
+			var err error
+			if offset, err = intArg(args, 0); err != nil {
+				return nil, err
+			}
+		}
+
+		if block == nil {
+			if withObject {
+				return nil, &unsupportedError{reason: fmt.Sprintf("'%s' without a block", name)}
+			}
+			yielded := make([]interface{}, len(enumerator.items))
+			for index, item := range enumerator.items {
+				yielded[index] = newArray(item, int64(index+offset))
+			}
+			return newEnumerator(yielded, enumerator, name, args), nil
+		}
+
+		index := offset
+		value, err := i.callMethod(enumerator.receiver, enumerator.method, enumerator.args, nativeBlock(func(yielded []interface{}) (interface{}, error) {
+			var item interface{}
+			switch len(yielded) {
+			case 0:
+			case 1:
+				item = yielded[0]
+			default:
+				item = newArray(yielded...)
+			}
+			if withObject {
+				return block.call(item, args[0])
+			}
+			index++
+			return block.call(item, int64(index-1))
+		}))
+		if err != nil {
+			return nil, err
+		}
+		if withObject {
+			return args[0], nil
+		}
+		return value, nil
+
+	case "size":
+		return int64(len(enumerator.items)), nil
+
+	case "next", "peek", "rewind":
+		return nil, &unsupportedError{reason: fmt.Sprintf("external enumeration with '%s' is not supported", name)}
+	}
+
+	if block != nil && enumeratorMethods[name] && enumerator.method == name {
+		return i.callMethod(enumerator.receiver, enumerator.method, enumerator.args, block)
+	}
+
+	if value, handled, err := i.enumerableMethod(enumerator, enumerator.items, name, args, block); handled {
+		return value, err
+	}
+
+	return i.objectMethod(enumerator, name, args, block)
+}
+
+func (i *erbInterpreter) arrayMethod(array *rbArray, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	items := array.items
+
+	switch name {
+	case "length", "size":
+		return int64(len(items)), nil
+	case "empty?":
+		return len(items) == 0, nil
+	case "to_a", "to_ary", "entries":
+		return array, nil
+
+	case "[]", "slice":
+		return arraySlice(items, args)
+
+	case "[]=":
+		if err := checkArgs(args, 2, 2); err != nil {
+			return nil, &unsupportedError{reason: "assigning to array slices is not supported"}
+		}
+		index, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 {
+			index += len(items)
+			if index < 0 {
+				return nil, newRubyError("IndexError", "index %d too small for array; minimum: -%d", index-len(items), len(items))
+			}
+		}
+		for len(array.items) <= index {
+			array.items = append(array.items, nil)
+		}
+		array.items[index] = args[1]
+		return args[1], nil
+
+	case "first", "last":
+		if len(args) == 0 {
+			if len(items) == 0 {
+				return nil, nil
+			}
+			if name == "first" {
+				return items[0], nil
+			}
+			return items[len(items)-1], nil
+		}
+		count, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, newRubyError("ArgumentError", "negative array size")
+		}
+		if count > len(items) {
+			count = len(items)
+		}
+		if name == "first" {
+			return newArray(append([]interface{}{}, items[:count]...)...), nil
+		}
+		return newArray(append([]interface{}{}, items[len(items)-count:]...)...), nil
+
+	case "fetch":
+		if err := checkArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		index, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		position := index
+		if position < 0 {
+			position += len(items)
+		}
+		if position >= 0 && position < len(items) {
+			return items[position], nil
+		}
+		if block != nil {
+			return block.call(args[0])
+		}
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return nil, newRubyError("IndexError", "index %d outside of array bounds: %d...%d", index, -len(items), len(items))
+
+	case "dig":
+		if err := checkArgs(args, 1, -1); err != nil {
+			return nil, err
+		}
+		return i.dig(array, args)
+
+	case "values_at":
+		results := []interface{}{}
+		for index := range args {
+			value, err := arraySlice(items, args[index:index+1])
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, value)
+		}
+		return newArray(results...), nil
+
+	case "index", "find_index", "rindex":
+		if name == "rindex" {
+			for index := len(items) - 1; index >= 0; index-- {
+				matched := false
+				if len(args) > 0 {
+					matched = rbEqual(items[index], args[0])
+				} else if block != nil {
+					value, err := block.call(items[index])
+					if err != nil {
+						return nil, err
+					}
+					matched = truthy(value)
+				}
+				if matched {
+					return int64(index), nil
+				}
+			}
+			return nil, nil
+		}
+		value, _, err := i.enumerableMethod(array, items, "find_index", args, block)
+		return value, err
+
+	case "push", "append", "<<":
+		if name == "<<" {
+			if err := checkArgs(args, 1, 1); err != nil {
+				return nil, err
+			}
+		}
+		array.items = append(array.items, args...)
+		return array, nil
+
+	case "unshift", "prepend":
+		array.items = append(append([]interface{}{}, args...), items...)
+		return array, nil
+
+	case "insert":
+		if err := checkArgs(args, 1, -1); err != nil {
+			return nil, err
+		}
+		index, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 {
+			index += len(items) + 1
+		}
+		if index < 0 {
+			return nil, newRubyError("IndexError", "index %d too small for array", index)
+		}
+		for len(items) < index {
+			items = append(items, nil)
+		}
+		array.items = append(append(append([]interface{}{}, items[:index]...), args[1:]...), items[index:]...)
+		return array, nil
+
+	case "concat":
+		for _, arg := range args {
+			other, err := toArrayArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, other...)
+		}
+		return array, nil
+
+	case "pop", "shift":
+		if len(args) > 0 {
+			return nil, &unsupportedError{reason: fmt.Sprintf("'%s' with a count", name)}
+		}
+		if len(items) == 0 {
+			return nil, nil
+		}
+		if name == "pop" {
+			array.items = items[:len(items)-1]
+			return items[len(items)-1], nil
+		}
+		array.items = items[1:]
+		return items[0], nil
+
+	case "delete":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		var deleted interface{}
+		found := false
+		kept := []interface{}{}
+		for _, item := range items {
+			if rbEqual(item, args[0]) {
+				deleted, found = item, true
+				continue
+			}
+			kept = append(kept, item)
+		}
+		array.items = kept
+		if !found && block != nil {
+			return block.call(args[0])
+		}
+		return deleted, nil
+
+	case "delete_at":
+		index, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 {
+			index += len(items)
+		}
+		if index < 0 || index >= len(items) {
+			return nil, nil
+		}
+		deleted := items[index]
+		array.items = append(append([]interface{}{}, items[:index]...), items[index+1:]...)
+		return deleted, nil
+
+	case "clear":
+		array.items = []interface{}{}
+		return array, nil
+
+	case "+":
+		other, err := toArrayArg(argOrNil(args, 0))
+		if err != nil {
+			return nil, err
+		}
+		return newArray(append(append([]interface{}{}, items...), other...)...), nil
+
+	case "-", "difference":
+		results := append([]interface{}{}, items...)
+		for _, arg := range args {
+			other, err := toArrayArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			excluded := map[interface{}]bool{}
+			for _, item := range other {
+				excluded[hashKey(item)] = true
+			}
+			kept := []interface{}{}
+			for _, item := range results {
+				if !excluded[hashKey(item)] {
+					kept = append(kept, item)
+				}
+			}
+			results = kept
+		}
+		return newArray(results...), nil
+
+	case "&", "|", "intersection", "union":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		other, err := toArrayArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var combined []interface{}
+		if name == "&" || name == "intersection" {
+			inOther := map[interface{}]bool{}
+			for _, item := range other {
+				inOther[hashKey(item)] = true
+			}
+			for _, item := range items {
+				if inOther[hashKey(item)] {
+					combined = append(combined, item)
+				}
+			}
+		} else {
+			combined = append(append([]interface{}{}, items...), other...)
+		}
+		value, _, err := i.enumerableMethod(nil, combined, "uniq", nil, nil)
+		return value, err
+
+	case "*":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		if separator, ok := args[0].(string); ok {
+			return joinArray(items, separator, map[*rbArray]bool{array: true})
+		}
+		count, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, newRubyError("ArgumentError", "negative argument")
+		}
+		repeated := []interface{}{}
+		for n := 0; n < count; n++ {
+			repeated = append(repeated, items...)
+		}
+		return newArray(repeated...), nil
+
+	case "<=>":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		result, err := rbCompare(array, args[0])
+		if err != nil {
+			return nil, nil
+		}
+		return int64(result), nil
+
+	case "join":
+		if err := checkArgs(args, 0, 1); err != nil {
+			return nil, err
+		}
+		separator := ""
+		if len(args) == 1 && args[0] != nil {
+			var err error
+			if separator, err = stringArg(args, 0); err != nil {
+				return nil, err
+			}
+		}
+		return joinArray(items, separator, map[*rbArray]bool{array: true})
+
+	case "compact":
+		results := []interface{}{}
+		for _, item := range items {
+			if item != nil {
+				results = append(results, item)
+			}
+		}
+		return newArray(results...), nil
+
+	case "flatten":
+		depth := -1
+		if len(args) > 0 && args[0] != nil {
+			var err error
+			if depth, err = intArg(args, 0); err != nil {
+				return nil, err
+			}
+		}
+		return newArray(flatten(items, depth)...), nil
+
+	case "reverse":
+		reversed := make([]interface{}, len(items))
+		for index, item := range items {
+			reversed[len(items)-1-index] = item
+		}
+		return newArray(reversed...), nil
+
+	case "rotate":
+		count := 1
+		if len(args) > 0 {
+			var err error
+			if count, err = intArg(args, 0); err != nil {
+				return nil, err
+			}
+		}
+		if len(items) == 0 {
+			return newArray(), nil
+		}
+		count = ((count % len(items)) + len(items)) % len(items)
+		return newArray(append(append([]interface{}{}, items[count:]...), items[:count]...)...), nil
+
+	case "transpose":
+		if len(items) == 0 {
+			return newArray(), nil
+		}
+		rows := make([][]interface{}, len(items))
+		for index, item := range items {
+			row, err := toArrayArg(item)
+			if err != nil {
+				return nil, err
+			}
+			if len(row) != len(rows[0]) && index > 0 {
+				return nil, newRubyError("IndexError", "element size differs (%d should be %d)", len(row), len(rows[0]))
+			}
+			rows[index] = row
+		}
+		columns := make([]interface{}, len(rows[0]))
+		for column := range columns {
+			values := make([]interface{}, len(rows))
+			for row := range rows {
+				values[row] = rows[row][column]
+			}
+			columns[column] = newArray(values...)
+		}
+		return newArray(columns...), nil
+
+	case "assoc":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if pair, ok := item.(*rbArray); ok && len(pair.items) > 0 && rbEqual(pair.items[0], args[0]) {
+				return pair, nil
+			}
+		}
+		return nil, nil
+
+	case "map!", "collect!", "select!", "filter!", "keep_if", "reject!", "delete_if", "sort!",
+		"sort_by!", "uniq!", "compact!", "flatten!", "reverse!", "shuffle!":
+		value, err := i.arrayMethod(array, strings.TrimSuffix(strings.NewReplacer("keep_if", "select", "delete_if", "reject").Replace(name), "!"), args, block)
+		if err != nil {
+			return nil, err
+		}
+		changed, ok := value.(*rbArray)
+		if !ok {
+			return value, nil
+		}
+		unchanged := len(changed.items) == len(items)
+		array.items = changed.items
+		if unchanged && (name == "uniq!" || name == "compact!" || name == "flatten!" || name == "select!" || name == "reject!") {
+			return nil, nil
+		}
+		return array, nil
+	}
+
+	if value, handled, err := i.enumerableMethod(array, items, name, args, block); handled {
+		return value, err
+	}
+
+	return i.objectMethod(array, name, args, block)
+}
+
+func argOrNil(args []interface{}, index int) interface{} {
+	if index < len(args) {
+		return args[index]
+	}
+	return nil
+}
+
+func arraySlice(items []interface{}, args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	switch index := args[0].(type) {
+	case int64, float64:
+		start, _ := intArg(args, 0)
+		if start < 0 {
+			start += len(items)
+		}
+		if len(args) == 2 {
+			length, err := intArg(args, 1)
+			if err != nil {
+				return nil, err
+			}
+			if start < 0 || start > len(items) || length < 0 {
+				return nil, nil
+			}
+			end := start + length
+			if end > len(items) {
+				end = len(items)
+			}
+			return newArray(append([]interface{}{}, items[start:end]...)...), nil
+		}
+		if start < 0 || start >= len(items) {
+			return nil, nil
+		}
+		return items[start], nil
+
+	case *rbRange:
+		start, count, ok, err := rangeBounds(index, len(items))
+		if err != nil || !ok {
+			return nil, err
+		}
+		return newArray(append([]interface{}{}, items[start:start+count]...)...), nil
+	}
+
+	return nil, typeConversionError(args[0], "Integer")
+}
+
+func joinArray(items []interface{}, separator string, seen map[*rbArray]bool) (interface{}, error) {
+	parts := make([]string, len(items))
+
+	for index, item := range items {
+		if nested, ok := item.(*rbArray); ok {
+			if seen[nested] {
+				return nil, newRubyError("ArgumentError", "recursive array join")
+			}
+			seen[nested] = true
+			joined, err := joinArray(nested.items, separator, seen)
+			if err != nil {
+				return nil, err
+			}
+			delete(seen, nested)
+			parts[index] = joined.(string)
+			continue
+		}
+		parts[index] = toS(item)
+	}
+
+	return strings.Join(parts, separator), nil
+}
+
+func flatten(items []interface{}, depth int) []interface{} {
+	flattened := []interface{}{}
+
+	for _, item := range items {
+		if nested, ok := item.(*rbArray); ok && depth != 0 {
+			flattened = append(flattened, flatten(nested.items, depth-1)...)
+			continue
+		}
+		flattened = append(flattened, item)
+	}
+
+	return flattened
+}
+
+func (i *erbInterpreter) hashMethod(hash *rbHash, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "length", "size":
+		return int64(hash.len()), nil
+	case "empty?":
+		return hash.len() == 0, nil
+
+	case "[]":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return hash.fetch(args[0]), nil
+
+	case "[]=", "store":
+		if err := checkArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		hash.set(args[0], args[1])
+		return args[1], nil
+
+	case "fetch":
+		if err := checkArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		if value, found := hash.get(args[0]); found {
+			return value, nil
+		}
+		if block != nil {
+			return block.call(args[0])
+		}
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return nil, newRubyError("KeyError", "key not found: %s", inspect(args[0]))
+
+	case "dig":
+		if err := checkArgs(args, 1, -1); err != nil {
+			return nil, err
+		}
+		return i.dig(hash, args)
+
+	case "key?", "has_key?", "include?", "member?":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		_, found := hash.get(args[0])
+		return found, nil
+
+	case "value?", "has_value?", "key":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		var key interface{}
+		found := false
+		_ = hash.each(func(k, v interface{}) error {
+			if !found && rbEqual(v, args[0]) {
+				key, found = k, true
+			}
+			return nil
+		})
+		if name == "key" {
+			return key, nil
+		}
+		return found, nil
+
+	case "keys":
+		return newArray(hash.keys()...), nil
+
+	case "values", "values_at", "fetch_values":
+		keys := hash.keys()
+		if name != "values" {
+			keys = args
+		}
+		values := []interface{}{}
+		for _, key := range keys {
+			if name == "fetch_values" {
+				if _, found := hash.get(key); !found {
+					return nil, newRubyError("KeyError", "key not found: %s", inspect(key))
+				}
+			}
+			values = append(values, hash.fetch(key))
+		}
+		return newArray(values...), nil
+
+	case "each", "each_pair":
+		if block == nil {
+			return newEnumerator(hash.pairs(), hash, name, args), nil
+		}
+		err := hash.each(func(key, value interface{}) error {
+			_, err := block.call(newArray(key, value))
+			return err
+		})
+		return hash, err
+
+	case "each_key", "each_value":
+		if err := requireBlock(block, name); err != nil {
+			return nil, err
+		}
+		err := hash.each(func(key, value interface{}) error {
+			if name == "each_key" {
+				_, err := block.call(key)
+				return err
+			}
+			_, err := block.call(value)
+			return err
+		})
+		return hash, err
+
+	case "select", "filter", "reject", "select!", "filter!", "keep_if", "reject!", "delete_if":
+		if err := requireBlock(block, name); err != nil {
+			return nil, err
+		}
+		keep := name == "select" || name == "filter" || name == "select!" || name == "filter!" || name == "keep_if"
+		result := newHash()
+		err := hash.each(func(key, value interface{}) error {
+			selected, err := block.call(key, value)
+			if err != nil {
+				return err
+			}
+			if truthy(selected) == keep {
+				result.set(key, value)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(name, "!") && name != "keep_if" && name != "delete_if" {
+			result.defaultValue = hash.defaultValue
+			return result, nil
+		}
+		unchanged := result.len() == hash.len()
+		hash.order, hash.entries = result.order, result.entries
+		if unchanged && strings.HasSuffix(name, "!") {
+			return nil, nil
+		}
+		return hash, nil
+
+	case "to_h":
+		if block == nil {
+			return hash, nil
+		}
+		result := newHash()
+		err := hash.each(func(key, value interface{}) error {
+			pair, err := block.call(key, value)
+			if err != nil {
+				return err
+			}
+			array, ok := pair.(*rbArray)
+			if !ok || len(array.items) != 2 {
+				return newRubyError("TypeError", "wrong element type %s (expected array)", className(pair))
+			}
+			result.set(array.items[0], array.items[1])
+			return nil
+		})
+		return result, err
+
+	case "to_a":
+		return newArray(hash.pairs()...), nil
+
+	case "merge", "merge!", "update":
+		result := hash
+		if name == "merge" {
+			result = hash.copy()
+		}
+		for _, arg := range args {
+			other, ok := arg.(*rbHash)
+			if !ok {
+				return nil, typeConversionError(arg, "Hash")
+			}
+			err := other.each(func(key, value interface{}) error {
+				if existing, found := result.get(key); found && block != nil {
+					merged, err := block.call(key, existing, value)
+					if err != nil {
+						return err
+					}
+					value = merged
+				}
+				result.set(key, value)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+
+	case "delete":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		value, found := hash.delete(args[0])
+		if !found && block != nil {
+			return block.call(args[0])
+		}
+		return value, nil
+
+	case "clear":
+		hash.order, hash.entries = nil, map[interface{}]*rbHashEntry{}
+		return hash, nil
+
+	case "invert":
+		result := newHash()
+		_ = hash.each(func(key, value interface{}) error {
+			result.set(value, key)
+			return nil
+		})
+		return result, nil
+
+	case "transform_values", "transform_keys", "transform_values!", "transform_keys!":
+		if err := requireBlock(block, name); err != nil {
+			return nil, err
+		}
+		result := newHash()
+		err := hash.each(func(key, value interface{}) error {
+			if strings.HasPrefix(name, "transform_values") {
+				transformed, err := block.call(value)
+				result.set(key, transformed)
+				return err
+			}
+			transformed, err := block.call(key)
+			result.set(transformed, value)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(name, "!") {
+			hash.order, hash.entries = result.order, result.entries
+			return hash, nil
+		}
+		return result, nil
+
+	case "compact":
+		result := newHash()
+		_ = hash.each(func(key, value interface{}) error {
+			if value != nil {
+				result.set(key, value)
+			}
+			return nil
+		})
+		return result, nil
+
+	case "slice", "except":
+		result := newHash()
+		if name == "slice" {
+			for _, key := range args {
+				if value, found := hash.get(key); found {
+					result.set(key, value)
+				}
+			}
+			return result, nil
+		}
+		result = hash.copy()
+		for _, key := range args {
+			result.delete(key)
+		}
+		return result, nil
+
+	case "default":
+		return hash.defaultValue, nil
+
+	case "sort":
+		value, _, err := i.enumerableMethod(hash, hash.pairs(), name, args, block)
+		return value, err
+	}
+
+	if value, handled, err := i.enumerableMethod(hash, hash.pairs(), name, args, block); handled {
+		return value, err
+	}
+
+	return i.objectMethod(hash, name, args, block)
+}
+
+func (i *erbInterpreter) rangeMethod(r *rbRange, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "begin":
+		return r.from, nil
+	case "end":
+		return r.to, nil
+	case "exclude_end?":
+		return r.exclusive, nil
+	case "include?", "member?", "cover?", "===":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return rangeCovers(r, args[0]), nil
+	case "first", "min":
+		if len(args) == 0 && block == nil {
+			if name == "min" {
+				if result, err := rbCompare(r.from, r.to); err != nil || result > 0 || (result == 0 && r.exclusive) {
+					return nil, err
+				}
+			}
+			return r.from, nil
+		}
+	case "last", "max":
+		// Only max takes exclusive ends into account
+		if len(args) == 0 && block == nil && (!r.exclusive || name == "last") {
+			if name == "max" {
+				if result, err := rbCompare(r.from, r.to); err != nil || result > 0 {
+					return nil, err
+				}
+			}
+			return r.to, nil
+		}
+	case "size", "count", "length":
+		if len(args) == 0 && block == nil && name != "length" {
+			items, err := rangeItems(r)
+			if err != nil {
+				return nil, err
+			}
+			return int64(len(items)), nil
+		}
+	case "step":
+		step, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if step <= 0 {
+			return nil, newRubyError("ArgumentError", "step can't be negative")
+		}
+		items, err := rangeItems(r)
+		if err != nil {
+			return nil, err
+		}
+		var stepped []interface{}
+		for index := 0; index < len(items); index += step {
+			stepped = append(stepped, items[index])
+		}
+		if block == nil {
+			return newEnumerator(stepped, r, name, args), nil
+		}
+		for _, item := range stepped {
+			if _, err := block.call(item); err != nil {
+				return nil, err
+			}
+		}
+		return r, nil
+	case "to_s", "inspect", "==", "eql?":
+		return i.objectMethod(r, name, args, block)
+	}
+
+	items, err := rangeItems(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "last" {
+		value, err := i.arrayMethod(newArray(items...), name, args, block)
+		return value, err
+	}
+	if name == "to_a" || name == "to_ary" || name == "entries" {
+		return newArray(items...), nil
+	}
+
+	if value, handled, err := i.enumerableMethod(r, items, name, args, block); handled {
+		return value, err
+	}
+
+	return i.objectMethod(r, name, args, block)
+}
@@ -0,0 +1,156 @@
+package erbrenderer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+)
+
+var _ = Describe("ERB collections", func() {
+	Describe("arrays", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("literals", "<%= [1, 'a', :b, nil, true, 1.5, [2]].inspect %>", `[1, "a", :b, nil, true, 1.5, [2]]`),
+			Entry("to_s", "<%= [1, 'a'] %> <%= [].to_s %>", `[1, "a"] []`),
+			Entry("length", "<%= [1, 2].length %> <%= [].size %> <%= [].empty? %> <%= [nil].empty? %>", "2 0 true false"),
+			Entry("indexing", "<%= [1, 2, 3][0] %> <%= [1, 2, 3][-1] %> <%= [1, 2, 3][5].inspect %>", "1 3 nil"),
+			Entry("slicing", "<%= [1, 2, 3, 4][1, 2].inspect %> <%= [1, 2, 3, 4][1..2].inspect %> <%= [1, 2, 3, 4][1...-1].inspect %> <%= [1, 2][2, 1].inspect %> <%= [1, 2][3, 1].inspect %>", "[2, 3] [2, 3] [2, 3] [] nil"),
+			Entry("assigning", "<% a = [1] %><% a[3] = 4 %><%= a.inspect %>", "[1, nil, nil, 4]"),
+			Entry("first and last", "<%= [1, 2, 3].first %> <%= [1, 2, 3].last(2).inspect %> <%= [].first.inspect %> <%= [1, 2].first(5).inspect %>", "1 [2, 3] nil [1, 2]"),
+			Entry("fetch", "<%= [1, 2].fetch(1) %> <%= [1, 2].fetch(5, 'x') %> <%= [1, 2].fetch(-1) %>", "2 x 2"),
+			Entry("dig", "<%= [[1, [2, 3]]].dig(0, 1, 0) %> <%= [[1]].dig(1, 0).inspect %>", "2 nil"),
+			Entry("values_at", "<%= [1, 2, 3].values_at(0, 2, 4).inspect %>", "[1, 3, nil]"),
+			Entry("index", "<%= [1, 2, 1].index(1) %> <%= [1, 2, 1].rindex(1) %> <%= [1, 2].index { |x| x > 1 } %> <%= [1].index(5).inspect %>", "0 2 1 nil"),
+			Entry("push and pop", "<% a = [1] %><% a.push(2, 3) %><% a << 4 %><%= a.pop %> <%= a.shift %> <%= a.inspect %>", "4 1 [2, 3]"),
+			Entry("unshift and insert", "<% a = [2] %><% a.unshift(1) %><% a.insert(1, 'x') %><%= a.inspect %>", `[1, "x", 2]`),
+			Entry("concat", "<% a = [1] %><% a.concat([2], [3]) %><%= a.inspect %>", "[1, 2, 3]"),
+			Entry("delete", "<% a = [1, 2, 1, 3] %><%= a.delete(1) %> <%= a.inspect %> <%= a.delete(5).inspect %>", "1 [2, 3] nil"),
+			Entry("delete_at", "<% a = [1, 2, 3] %><%= a.delete_at(1) %> <%= a.inspect %>", "2 [1, 3]"),
+			Entry("clear", "<% a = [1, 2] %><% a.clear %><%= a.inspect %>", "[]"),
+			Entry("arithmetic", "<%= ([1, 2] + [3]).inspect %> <%= ([1, 2, 2, 3] - [2]).inspect %> <%= ([1, 2] * 2).inspect %> <%= [1, 2] * ',' %>", "[1, 2, 3] [1, 3] [1, 2, 1, 2] 1,2"),
+			Entry("set operations", "<%= ([1, 2, 2] & [2, 3]).inspect %> <%= ([1, 2] | [2, 3]).inspect %> <%= [1, 2].union([3]).inspect %> <%= [1, 2].intersection([2]).inspect %> <%= [1, 2].difference([1]).inspect %>", "[2] [1, 2, 3] [1, 2, 3] [2] [2]"),
+			Entry("comparison", "<%= [1, 2] <=> [1, 3] %> <%= [1, 2] == [1, 2] %> <%= [1] != [2] %> <%= [1, 2] <=> [1] %>", "-1 true true 1"),
+			Entry("join", "<%= [1, [2, [3]]].join(',') %> <%= [1, nil, 'a'].join('-') %> <%= [].join(',').empty? %> <%= %w(a b).join %>", "1,2,3 1--a true ab"),
+			Entry("compact", "<%= [1, nil, 2, nil].compact.inspect %>", "[1, 2]"),
+			Entry("flatten", "<%= [1, [2, [3, [4]]]].flatten.inspect %> <%= [1, [2, [3]]].flatten(1).inspect %>", "[1, 2, 3, 4] [1, 2, [3]]"),
+			Entry("reverse and rotate", "<%= [1, 2, 3].reverse.inspect %> <%= [1, 2, 3].rotate.inspect %> <%= [1, 2, 3].rotate(-1).inspect %>", "[3, 2, 1] [2, 3, 1] [3, 1, 2]"),
+			Entry("transpose", "<%= [[1, 2], [3, 4]].transpose.inspect %>", "[[1, 3], [2, 4]]"),
+			Entry("assoc", "<%= [[:a, 1], [:b, 2]].assoc(:b).inspect %> <%= [[:a, 1]].assoc(:c).inspect %>", "[:b, 2] nil"),
+			Entry("in-place changes", "<% a = [3, 1, 2] %><% a.sort! %><% a.map! { |x| x * 2 } %><% a.select! { |x| x > 2 } %><%= a.inspect %>", "[4, 6]"),
+			Entry("word arrays", "<%= %w(a b c).inspect %> <%= %w[x y].length %>", `["a", "b", "c"] 2`),
+			Entry("splats in literals", "<% a = [2, 3] %><%= [1, *a, 4].inspect %>", "[1, 2, 3, 4]"),
+			Entry("nested inspect", `<%= [{'a' => [1, nil]}, "q\"uote"].inspect %>`, `[{"a"=>[1, nil]}, "q\"uote"]`),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("fetch out of range", "<%= [1, 2].fetch(5) %>", "index 5 outside of array bounds: -2...2"),
+			Entry("adding non-arrays", "<%= [1] + 1 %>", "no implicit conversion of Integer into Array"),
+			Entry("index below start", "<% a = [1] %><% a[-3] = 1 %>", "index -3 too small for array; minimum: -1"),
+		)
+	})
+
+	Describe("enumerables", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("each", "<% [1, 2].each do |x| %><%= x %>,<% end %>", "1,2,"),
+			Entry("each returning its receiver", "<%= [1, 2].each { |x| x }.inspect %>", "[1, 2]"),
+			Entry("reverse_each", "<% [1, 2].reverse_each do |x| %><%= x %><% end %>", "21"),
+			Entry("each_with_index", "<% %w(a b).each_with_index do |x, i| %><%= i %>=<%= x %> <% end %>", "0=a 1=b "),
+			Entry("each_with_object", "<%= [1, 2].each_with_object([]) { |x, acc| acc << x * 2 }.inspect %>", "[2, 4]"),
+			Entry("map", "<%= [1, 2].map { |x| x * 2 }.inspect %> <%= [1, 2].collect(&:to_s).inspect %>", `[2, 4] ["1", "2"]`),
+			Entry("map with index", "<%= %w(a b).map.with_index { |x, i| x * (i + 1) }.inspect %> <%= %w(a b).each_with_index.map { |x, i| i }.inspect %>", `["a", "bb"] [0, 1]`),
+			Entry("map with index offset", "<%= %w(a b).map.with_index(1) { |x, i| i }.inspect %>", "[1, 2]"),
+			Entry("flat_map", "<%= [[1, 2], [3]].flat_map { |x| x }.inspect %> <%= [1, 2].flat_map { |x| [x, x] }.inspect %>", "[1, 2, 3] [1, 1, 2, 2]"),
+			Entry("filter_map", "<%= [1, 2, 3].filter_map { |x| x * 2 if x.odd? }.inspect %>", "[2, 6]"),
+			Entry("select and reject", "<%= [1, 2, 3].select(&:even?).inspect %> <%= [1, 2, 3].reject(&:even?).inspect %> <%= [1, 2].filter { |x| x > 5 }.inspect %>", "[2] [1, 3] []"),
+			Entry("partition", "<%= [1, 2, 3].partition(&:odd?).inspect %>", "[[1, 3], [2]]"),
+			Entry("group_by", "<%= %w(a bb c).group_by(&:length).inspect %>", `{1=>["a", "c"], 2=>["bb"]}`),
+			Entry("find", "<%= [1, 2, 3].find { |x| x > 1 } %> <%= [1].detect { |x| x > 1 }.inspect %>", "2 nil"),
+			Entry("find_index", "<%= [1, 2, 3].find_index(3) %> <%= [1, 2, 3].find_index { |x| x > 1 } %>", "2 1"),
+			Entry("inject", "<%= [1, 2, 3].inject { |s, x| s + x } %> <%= [1, 2, 3].inject(10) { |s, x| s + x } %> <%= [1, 2, 3].reduce(1) { |p, x| p * x } %> <%= [].inject { |s, x| s + x }.inspect %>", "6 16 6 nil"),
+			Entry("sum", "<%= [1, 2, 3].sum %> <%= [1.5, 2].sum %> <%= [1, 2].sum { |x| x * 10 } %> <%= [].sum %> <%= [[1], [2]].sum([]).inspect %>", "6 3.5 30 0 [1, 2]"),
+			Entry("count", "<%= [1, 2, 1].count %> <%= [1, 2, 1].count(1) %> <%= [1, 2, 3].count(&:odd?) %>", "3 2 2"),
+			Entry("min and max", "<%= [3, 1, 2].min %> <%= [3, 1, 2].max %> <%= [3, 1, 2].minmax.inspect %> <%= [].max.inspect %> <%= %w(bb a).min { |a, b| a.length <=> b.length } %>", "1 3 [1, 3] nil a"),
+			Entry("sort", "<%= [3, 1, 2].sort.inspect %> <%= [3, 1, 2].sort { |a, b| b <=> a }.inspect %> <%= %w(b A a).sort.inspect %>", `[1, 2, 3] [3, 2, 1] ["A", "a", "b"]`),
+			Entry("sort_by", "<%= %w(ccc a bb).sort_by(&:length).inspect %> <%= %w(ccc a bb).min_by(&:length) %> <%= %w(ccc a bb).max_by(&:length) %>", `["a", "bb", "ccc"] a ccc`),
+			Entry("each_slice", "<%= [1, 2, 3].each_slice(2).to_a.inspect %> <%= [1, 2, 3].each_cons(2).to_a.inspect %>", "[[1, 2], [3]] [[1, 2], [2, 3]]"),
+			Entry("each_slice with a block", "<% [1, 2, 3].each_slice(2) do |s| %><%= s.inspect %><% end %>", "[1, 2][3]"),
+			Entry("zip", "<%= [1, 2].zip([3, 4], [5]).inspect %>", "[[1, 3, 5], [2, 4, nil]]"),
+			Entry("take and drop", "<%= [1, 2, 3].take(2).inspect %> <%= [1, 2, 3].drop(2).inspect %> <%= [1, 2, 3, 1].take_while { |x| x < 3 }.inspect %> <%= [1, 2, 3, 1].drop_while { |x| x < 3 }.inspect %>", "[1, 2] [3] [1, 2] [3, 1]"),
+			Entry("include?", "<%= [1, 2].include?(2) %> <%= [1, 2].member?(3) %> <%= [[1]].include?([1]) %>", "true false true"),
+			Entry("predicates", "<%= [1, nil].any? %> <%= [1, nil].all? %> <%= [nil, false].none? %> <%= [1, nil].one? %> <%= [1, 2].any? { |x| x > 1 } %> <%= [].all? %> <%= [1, 2].all?(Integer) %>", "true false true true true true true"),
+			Entry("uniq", "<%= [1, 2, 1, 3].uniq.inspect %> <%= %w(a b aa).uniq(&:length).inspect %>", `[1, 2, 3] ["a", "aa"]`),
+			Entry("tally", "<%= %w(a b a).tally.inspect %>", `{"a"=>2, "b"=>1}`),
+			Entry("to_h", "<%= [[:a, 1], [:b, 2]].to_h.inspect %> <%= %w(a b).to_h { |x| [x, x.upcase] }.inspect %>", `{:a=>1, :b=>2} {"a"=>"A", "b"=>"B"}`),
+			Entry("chains", "<%= (1..10).select(&:even?).map { |x| x * x }.reject { |x| x > 50 }.sum %>", "56"),
+			Entry("enumerators", "<%= [1, 2].each.size %> <%= [1, 2].map.to_a.inspect %>", "2 [1, 2]"),
+			Entry("with_object", "<%= [1, 2].each.with_object([]) { |x, acc| acc.unshift(x) }.inspect %>", "[2, 1]"),
+			Entry("destructuring block arguments", "<%= [[1, 2], [3, 4]].map { |a, b| a + b }.inspect %> <%= {a: 1}.map { |k, v| [k, v] }.inspect %>", "[3, 7] [[:a, 1]]"),
+			Entry("next in blocks", "<%= [1, 2, 3].map { |x| next 0 if x == 2; x }.inspect %>", "[1, 0, 3]"),
+			Entry("break in blocks", "<%= [1, 2, 3].each { |x| break x * 10 if x == 2 } %>", "20"),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("comparing mixed values", "<%= [1, 'a'].sort %>", "comparison of String with 1 failed"),
+		)
+	})
+
+	Describe("hashes", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("literals", `<%= {'a' => 1, b: [2], 3 => nil}.inspect %>`, `{"a"=>1, :b=>[2], 3=>nil}`),
+			Entry("string-like symbol keys", `<%= {"a-b": 1}.inspect %>`, `{:"a-b"=>1}`),
+			Entry("length", "<%= {a: 1}.length %> <%= {}.size %> <%= {}.empty? %>", "1 0 true"),
+			Entry("indexing", "<%= {a: 1}[:a] %> <%= {a: 1}['a'].inspect %> <%= {1 => 'x'}[1] %>", "1 nil x"),
+			Entry("assigning", "<% h = {} %><% h[:a] = 1 %><% h.store(:b, 2) %><% h[:a] = 3 %><%= h.inspect %>", "{:a=>3, :b=>2}"),
+			Entry("fetch", "<%= {a: 1}.fetch(:a) %> <%= {a: 1}.fetch(:b, 2) %> <%= {a: 1}.fetch(:b) { |k| k.to_s * 2 } %>", "1 2 bb"),
+			Entry("dig", "<%= {a: {b: [1, 2]}}.dig(:a, :b, 1) %> <%= {a: {}}.dig(:a, :b, :c).inspect %>", "2 nil"),
+			Entry("keys", "<%= {a: 1, b: 2}.key?(:a) %> <%= {a: 1}.include?(:b) %> <%= {a: 1}.value?(1) %> <%= {a: 1}.key(1).inspect %> <%= {a: 1, b: 2}.keys.inspect %>", "true false true :a [:a, :b]"),
+			Entry("values", "<%= {a: 1, b: 2}.values.inspect %> <%= {a: 1, b: 2}.values_at(:b, :c).inspect %> <%= {a: 1}.fetch_values(:a).inspect %>", "[1, 2] [2, nil] [1]"),
+			Entry("each", "<% {a: 1, b: 2}.each do |k, v| %><%= k %>=<%= v %>;<% end %>", "a=1;b=2;"),
+			Entry("each_pair with one argument", "<% {a: 1}.each_pair do |pair| %><%= pair.inspect %><% end %>", "[:a, 1]"),
+			Entry("each_key and each_value", "<% {a: 1, b: 2}.each_key do |k| %><%= k %><% end %> <% {a: 1, b: 2}.each_value do |v| %><%= v %><% end %>", "ab 12"),
+			Entry("select and reject", "<%= {a: 1, b: 2}.select { |k, v| v > 1 }.inspect %> <%= {a: 1, b: 2}.reject { |k, v| k == :a }.inspect %>", "{:b=>2} {:b=>2}"),
+			Entry("map", "<%= {a: 1, b: 2}.map { |k, v| \"#{k}=#{v}\" }.join('&') %>", "a=1&b=2"),
+			Entry("sort_by", "<%= {b: 1, a: 2}.sort_by { |k, v| k }.inspect %> <%= {b: 1, a: 2}.sort.to_h.inspect %>", "[[:a, 2], [:b, 1]] {:a=>2, :b=>1}"),
+			Entry("min_by", "<%= {a: 2, b: 1}.min_by { |k, v| v }.inspect %>", "[:b, 1]"),
+			Entry("to_h and to_a", "<%= {a: 1}.to_a.inspect %> <%= {a: 1}.to_h { |k, v| [v, k] }.inspect %>", "[[:a, 1]] {1=>:a}"),
+			Entry("merge", "<%= {a: 1, b: 2}.merge({b: 3, c: 4}).inspect %> <%= {a: 1}.merge({a: 2}) { |k, old, new| old + new }.inspect %>", "{:a=>1, :b=>3, :c=>4} {:a=>3}"),
+			Entry("merge!", "<% h = {a: 1} %><% h.merge!(b: 2) %><% h.update({c: 3}) %><%= h.inspect %>", "{:a=>1, :b=>2, :c=>3}"),
+			Entry("delete", "<% h = {a: 1, b: 2} %><%= h.delete(:a) %> <%= h.delete(:z).inspect %> <%= h.inspect %>", "1 nil {:b=>2}"),
+			Entry("clear", "<% h = {a: 1} %><% h.clear %><%= h.inspect %>", "{}"),
+			Entry("invert", "<%= {a: 1, b: 2}.invert.inspect %>", "{1=>:a, 2=>:b}"),
+			Entry("transforming", "<%= {a: 1}.transform_values { |v| v * 2 }.inspect %> <%= {a: 1}.transform_keys(&:to_s).inspect %>", `{:a=>2} {"a"=>1}`),
+			Entry("compact", "<%= {a: nil, b: 1}.compact.inspect %>", "{:b=>1}"),
+			Entry("slice and except", "<%= {a: 1, b: 2, c: 3}.slice(:a, :c).inspect %> <%= {a: 1, b: 2}.except(:a).inspect %>", "{:a=>1, :c=>3} {:b=>2}"),
+			Entry("enumerable methods", "<%= {a: 1, b: 2}.count { |k, v| v > 1 } %> <%= {a: 1, b: 2}.sum { |k, v| v } %> <%= {a: 1}.any? { |k, v| v == 1 } %> <%= {a: 1}.find { |k, v| v == 1 }.inspect %>", "1 3 true [:a, 1]"),
+			Entry("group_by", "<%= {a: 1, b: 2, c: 1}.group_by { |k, v| v }.keys.inspect %>", "[1, 2]"),
+			Entry("equality", "<%= {a: 1, b: 2} == {b: 2, a: 1} %> <%= {a: 1} == {a: 2} %>", "true false"),
+			Entry("default", "<%= {}.default.inspect %>", "nil"),
+			Entry("keeping insertion order", "<% h = {c: 1} %><% h[:a] = 2 %><% h[:b] = 3 %><%= h.keys.inspect %>", "[:c, :a, :b]"),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("fetching missing keys", "<%= {a: 1}.fetch(:b) %>", "key not found: :b"),
+			Entry("fetching missing string keys", "<%= {'a' => 1}.fetch('b') %>", `key not found: "b"`),
+		)
+	})
+
+	Describe("ranges", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("to_a", "<%= (1..3).to_a.inspect %> <%= (1...3).to_a.inspect %> <%= (3..1).to_a.inspect %>", "[1, 2, 3] [1, 2] []"),
+			Entry("inspect", "<%= (1..3).inspect %> <%= (1...3) %>", "1..3 1...3"),
+			Entry("bounds", "<%= (1..3).begin %> <%= (1...3).end %> <%= (1...3).exclude_end? %> <%= (1...3).last %> <%= (1..3).max %>", "1 3 true 3 3"),
+			Entry("first and last", "<%= (1..10).first(3).inspect %> <%= (1..10).last(2).inspect %> <%= (1..3).first %>", "[1, 2, 3] [9, 10] 1"),
+			Entry("include?", "<%= (1..3).include?(3) %> <%= (1...3).include?(3) %> <%= (1..3).cover?(2.5) %> <%= (1..3) === 0 %>", "true false true false"),
+			Entry("size", "<%= (1..3).size %> <%= (1...3).count %> <%= (3..1).size %>", "3 2 0"),
+			Entry("step", "<%= (1..10).step(3).to_a.inspect %> <% (0..4).step(2) do |x| %><%= x %><% end %>", "[1, 4, 7, 10] 024"),
+			Entry("enumerable methods", "<%= (1..4).map { |x| x * 2 }.inspect %> <%= (1..4).sum %> <%= (1..4).select(&:even?).inspect %> <%= (1..3).each_slice(2).to_a.inspect %>", "[2, 4, 6, 8] 10 [2, 4] [[1, 2], [3]]"),
+			Entry("each", "<% (1..3).each do |i| %><%= i %><% end %>", "123"),
+			Entry("equality", "<%= (1..2) == (1..2) %> <%= (1..2) == (1...2) %>", "true false"),
+			Entry("in case statements", "<% case 5 when 1..3 %>low<% when 4..6 %>mid<% else %>high<% end %>", "mid"),
+		)
+
+		DescribeTable("passing to Ruby for what is not supported", expectRubyFallback,
+			Entry("large ranges", "<%= (1..100000000).to_a.size %>", "iterating large ranges is not supported"),
+			Entry("ranges of strings", "<%= ('a'..'c').to_a.inspect %>", "iterating ranges of String is not supported"),
+			Entry("external enumeration", "<% e = [1, 2].each %><%= e.next %>", "external enumeration with 'next' is not supported"),
+		)
+	})
+})
@@ -0,0 +1,450 @@
+package erbrenderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// erbEvaluationContext is the Go counterpart of TemplateEvaluationContext in
+// the Ruby renderer script. It provides the methods that templates call
+// without a receiver, such as 'p' and 'spec'.
+type erbEvaluationContext struct {
+	name          interface{}
+	index         interface{}
+	spec          interface{}
+	properties    interface{}
+	rawProperties *rbHash
+	links         *rbHash
+}
+
+// rbLink is a link consumed by the job, as returned by 'link'.
+type rbLink struct {
+	name       string
+	properties *rbHash
+	instances  interface{}
+	address    interface{}
+}
+
+func newERBEvaluationContext(contextJSON []byte) (*erbEvaluationContext, error) {
+	decoded, err := decodeJSON(contextJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, ok := decoded.(*rbHash)
+	if !ok {
+		spec = newHash()
+	}
+
+	context := &erbEvaluationContext{
+		index: spec.fetch("index"),
+		spec:  openStruct(spec),
+		links: newHash(),
+	}
+
+	if job, ok := spec.fetch("job").(*rbHash); ok {
+		context.name = job.fetch("name")
+	}
+
+	var sourceProperties *rbHash
+	if jobProperties, ok := spec.fetch("job_properties").(*rbHash); ok {
+		sourceProperties = jobProperties
+	} else {
+		sourceProperties = hashOrEmpty(spec.fetch("global_properties")).copy()
+		recursiveMerge(sourceProperties, hashOrEmpty(spec.fetch("cluster_properties")))
+	}
+
+	properties := newHash()
+	_ = hashOrEmpty(spec.fetch("default_properties")).each(func(name, value interface{}) error {
+		copyProperty(properties, sourceProperties, toS(name), value)
+		return nil
+	})
+
+	context.rawProperties = properties
+	context.properties = openStruct(properties)
+
+	_ = hashOrEmpty(spec.fetch("links")).each(func(name, value interface{}) error {
+		link := hashOrEmpty(value)
+		context.links.set(toS(name), &rbLink{
+			name:       toS(name),
+			properties: hashOrEmpty(link.fetch("properties")),
+			instances:  openStruct(arrayOrEmpty(link.fetch("instances"))),
+			address:    link.fetch("address"),
+		})
+		return nil
+	})
+
+	return context, nil
+}
+
+func hashOrEmpty(value interface{}) *rbHash {
+	if hash, ok := value.(*rbHash); ok {
+		return hash
+	}
+	return newHash()
+}
+
+func arrayOrEmpty(value interface{}) *rbArray {
+	if array, ok := value.(*rbArray); ok {
+		return array
+	}
+	return newArray()
+}
+
+func recursiveMerge(dst, src *rbHash) {
+	_ = src.each(func(key, value interface{}) error {
+		existing, existingIsHash := dst.fetch(key).(*rbHash)
+		incoming, incomingIsHash := value.(*rbHash)
+		if existingIsHash && incomingIsHash {
+			merged := existing.copy()
+			recursiveMerge(merged, incoming)
+			dst.set(key, merged)
+		} else {
+			dst.set(key, value)
+		}
+		return nil
+	})
+}
+
+// copyProperty sets a dotted property name in dst to its value in src, or to
+// defaultValue when src does not have it.
+func copyProperty(dst, src *rbHash, name string, defaultValue interface{}) {
+	keys := strings.Split(name, ".")
+
+	var srcRef interface{} = src
+	for _, key := range keys {
+		hash, ok := srcRef.(*rbHash)
+		if !ok {
+			srcRef = nil
+			break
+		}
+		srcRef = hash.fetch(key)
+		if srcRef == nil {
+			break
+		}
+	}
+
+	dstRef := dst
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := dstRef.fetch(key).(*rbHash)
+		if !ok {
+			next = newHash()
+			dstRef.set(key, next)
+		}
+		dstRef = next
+	}
+
+	if srcRef == nil {
+		srcRef = defaultValue
+	}
+	dstRef.set(keys[len(keys)-1], srcRef)
+}
+
+// lookupProperty finds a dotted property name in collection.
+func (i *erbInterpreter) lookupProperty(collection *rbHash, name interface{}) (interface{}, error) {
+	s, ok := name.(string)
+	if !ok {
+		return nil, newRubyError("NoMethodError", "undefined method `split' for %s", describeForError(name))
+	}
+
+	var ref interface{} = collection
+	for _, key := range strings.Split(s, ".") {
+		var err error
+		ref, err = i.callMethod(ref, "[]", []interface{}{key}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if ref == nil {
+			return nil, nil
+		}
+	}
+
+	return ref, nil
+}
+
+func (i *erbInterpreter) callFunction(name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	context := i.context
+
+	switch name {
+	case "p":
+		return i.propertyValue(context.rawProperties, args)
+
+	case "if_p":
+		return i.ifP(context.rawProperties, args, block)
+
+	case "if_link":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		link, found := context.links.get(toS(args[0]))
+		if !found {
+			return &rbElseBlock{active: true}, nil
+		}
+		if block == nil {
+			return nil, newRubyError("LocalJumpError", "no block given (yield)")
+		}
+		if _, err := block.call(link); err != nil {
+			return nil, err
+		}
+		return &rbElseBlock{}, nil
+
+	case "link":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		link, found := context.links.get(toS(args[0]))
+		if !found {
+			return nil, newRubyError("TemplateEvaluationContext::UnknownLink", "Can't find link '%s'", toS(args[0]))
+		}
+		return link, nil
+
+	case "spec", "name", "index", "properties", "raw_properties":
+		if err := checkArgs(args, 0, 0); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "spec":
+			return context.spec, nil
+		case "name":
+			return context.name, nil
+		case "index":
+			return context.index, nil
+		case "properties":
+			return context.properties, nil
+		}
+		return context.rawProperties, nil
+
+	case "raise", "fail":
+		return nil, raiseError(args)
+
+	case "format", "sprintf":
+		if len(args) == 0 {
+			return nil, newRubyError("ArgumentError", "too few arguments")
+		}
+		format, ok := args[0].(string)
+		if !ok {
+			return nil, typeConversionError(args[0], "String")
+		}
+		return rubyFormat(format, args[1:])
+
+	case "Integer", "Float", "String", "Array":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "Integer":
+			return toInteger(args[0])
+		case "Float":
+			return toFloat(args[0])
+		case "String":
+			return toS(args[0]), nil
+		}
+		items, err := toA(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return newArray(items...), nil
+
+	case "require":
+		return true, nil
+
+	case "puts", "print":
+		return nil, nil
+	}
+
+	return nil, &unsupportedError{reason: fmt.Sprintf("method '%s' is not supported", name)}
+}
+
+// propertyValue implements 'p', which returns the first of the given
+// properties that is set, or the default when one is given.
+func (i *erbInterpreter) propertyValue(properties *rbHash, args []interface{}) (interface{}, error) {
+	var first interface{}
+	if len(args) > 0 {
+		first = args[0]
+	}
+
+	names, err := toA(first)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		value, err := i.lookupProperty(properties, name)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			return value, nil
+		}
+	}
+
+	if len(args) == 2 {
+		return args[1], nil
+	}
+
+	quoted := make([]string, len(names))
+	for index, name := range names {
+		quoted[index] = toS(name)
+	}
+
+	return nil, newRubyError("TemplateEvaluationContext::UnknownProperty",
+		"Can't find property '%s'", strings.Join(quoted, "', or '"))
+}
+
+// ifP implements 'if_p', which yields the given properties only when all of
+// them are set.
+func (i *erbInterpreter) ifP(properties *rbHash, names []interface{}, block *rbBlock) (interface{}, error) {
+	values := make([]interface{}, len(names))
+
+	for index, name := range names {
+		value, err := i.lookupProperty(properties, name)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return &rbElseBlock{active: true}, nil
+		}
+		values[index] = value
+	}
+
+	if block == nil {
+		return nil, newRubyError("LocalJumpError", "no block given (yield)")
+	}
+	if _, err := block.call(values...); err != nil {
+		return nil, err
+	}
+
+	return &rbElseBlock{}, nil
+}
+
+func (i *erbInterpreter) elseBlockMethod(elseBlock *rbElseBlock, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "else":
+		if !elseBlock.active {
+			return nil, nil
+		}
+		if block == nil {
+			return nil, newRubyError("LocalJumpError", "no block given (yield)")
+		}
+		return block.call()
+
+	case "else_if_p":
+		if !elseBlock.active {
+			return &rbElseBlock{}, nil
+		}
+		return i.ifP(i.context.rawProperties, args, block)
+	}
+
+	return i.objectMethod(elseBlock, name, args, block)
+}
+
+func (i *erbInterpreter) linkMethod(link *rbLink, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "p":
+		return i.propertyValue(link.properties, args)
+	case "if_p":
+		return i.ifP(link.properties, args, block)
+	case "instances":
+		return link.instances, nil
+	case "address":
+		return link.address, nil
+	case "name":
+		return link.name, nil
+	}
+
+	return i.objectMethod(link, name, args, block)
+}
+
+func raiseError(args []interface{}) error {
+	switch len(args) {
+	case 0:
+		return newRubyError("RuntimeError", "unhandled exception")
+	case 1:
+		if class, ok := args[0].(rbClass); ok {
+			return newRubyError(string(class), "%s", string(class))
+		}
+		return newRubyError("RuntimeError", "%s", toS(args[0]))
+	}
+
+	class, ok := args[0].(rbClass)
+	if !ok {
+		return newRubyError("TypeError", "exception class/object expected")
+	}
+	return newRubyError(string(class), "%s", toS(args[1]))
+}
+
+// decodeJSON decodes JSON into template values, keeping the order of object
+// keys and telling integers and floats apart like Ruby's JSON parser.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeJSONValue(decoder)
+	if _, ok := err.(*unsupportedError); ok {
+		return nil, err
+	} else if err != nil {
+		return nil, newRubyError("JSON::ParserError", "%s", err.Error())
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, newRubyError("JSON::ParserError", "unexpected token after JSON value")
+	}
+
+	return value, nil
+}
+
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			hash := newHash()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				hash.set(key.(string), value)
+			}
+			_, err := decoder.Token()
+			return hash, err
+
+		case '[':
+			array := newArray()
+			for decoder.More() {
+				value, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array.items = append(array.items, value)
+			}
+			_, err := decoder.Token()
+			return array, err
+		}
+		return nil, fmt.Errorf("unexpected '%s'", t)
+
+	case json.Number:
+		if integer, err := t.Int64(); err == nil {
+			return integer, nil
+		}
+		// Ruby parses integers of any size exactly rather than as floats
+		if !strings.ContainsAny(t.String(), ".eE") {
+			return nil, integerOverflowError()
+		}
+		return t.Float64()
+	}
+
+	return token, nil
+}
@@ -0,0 +1,640 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unsupportedError means that a template uses Ruby that the native renderer
+// does not implement, so it has to be rendered by Ruby instead.
+type unsupportedError struct {
+	line   int
+	reason string
+}
+
+func (e *unsupportedError) Error() string {
+	if e.line > 0 {
+		return fmt.Sprintf("line %d: %s", e.line, e.reason)
+	}
+	return e.reason
+}
+
+// rubyError is an exception raised by a template, such as an unknown property.
+type rubyError struct {
+	class   string
+	message string
+	line    int
+}
+
+func newRubyError(class, format string, args ...interface{}) *rubyError {
+	return &rubyError{class: class, message: fmt.Sprintf(format, args...)}
+}
+
+// Error formats the exception like Ruby's Exception#inspect.
+func (e *rubyError) Error() string {
+	return fmt.Sprintf("#<%s: %s>", e.class, e.message)
+}
+
+// jumpSignal unwinds the evaluation of a block for 'next' and 'break'.
+type jumpSignal struct {
+	kind  string
+	value interface{}
+	block *rbBlock
+}
+
+func (j *jumpSignal) Error() string {
+	return fmt.Sprintf("'%s' outside of a block", j.kind)
+}
+
+func setErrorLine(err error, line int) {
+	switch e := err.(type) {
+	case *rubyError:
+		if e.line == 0 {
+			e.line = line
+		}
+	case *unsupportedError:
+		if e.line == 0 {
+			e.line = line
+		}
+	}
+}
+
+type erbScope struct {
+	vars   map[string]interface{}
+	parent *erbScope
+}
+
+func newERBScope(parent *erbScope) *erbScope {
+	return &erbScope{vars: map[string]interface{}{}, parent: parent}
+}
+
+func (s *erbScope) lookup(name string) (interface{}, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if value, found := scope.vars[name]; found {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// assign sets a variable in the scope that already defines it, so that
+// blocks can update the variables of the code around them.
+func (s *erbScope) assign(name string, value interface{}) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if _, found := scope.vars[name]; found {
+			scope.vars[name] = value
+			return
+		}
+	}
+	s.vars[name] = value
+}
+
+// rbBlock is a block given to a method, either as code, as '&:symbol' or as
+// a Go function for blocks that methods pass on to other methods.
+type rbBlock struct {
+	params []blockParam
+	body   []erbNode
+	scope  *erbScope
+	interp *erbInterpreter
+
+	symbol rbSymbol
+	native func(args []interface{}) (interface{}, error)
+}
+
+func nativeBlock(f func(args []interface{}) (interface{}, error)) *rbBlock {
+	return &rbBlock{native: f}
+}
+
+func (b *rbBlock) call(args ...interface{}) (interface{}, error) {
+	if b.native != nil {
+		return b.native(args)
+	}
+
+	if b.symbol != "" {
+		if len(args) == 0 {
+			return nil, newRubyError("ArgumentError", "no receiver given")
+		}
+		return b.interp.callMethod(args[0], string(b.symbol), args[1:], nil)
+	}
+
+	scope := newERBScope(b.scope)
+	bindBlockParams(scope, b.params, args, true)
+
+	value, err := b.interp.evalBody(b.body, scope)
+	if jump, ok := err.(*jumpSignal); ok {
+		if jump.kind == "next" {
+			return jump.value, nil
+		}
+		if jump.block == nil {
+			jump.block = b
+		}
+	}
+
+	return value, err
+}
+
+// bindBlockParams assigns block arguments to parameters. Like Ruby, a single
+// array argument is spread over several parameters.
+func bindBlockParams(scope *erbScope, params []blockParam, args []interface{}, spread bool) {
+	if spread && len(params) > 1 && len(args) == 1 {
+		if array, ok := args[0].(*rbArray); ok {
+			args = array.items
+		}
+	}
+
+	for i, param := range params {
+		var value interface{}
+		if i < len(args) {
+			value = args[i]
+		}
+
+		if param.nested != nil {
+			items := []interface{}{value}
+			if array, ok := value.(*rbArray); ok {
+				items = array.items
+			}
+			bindBlockParams(scope, param.nested, items, false)
+			continue
+		}
+
+		scope.vars[param.name] = value
+	}
+}
+
+// erbInterpreter evaluates a parsed template against an evaluation context.
+type erbInterpreter struct {
+	context *erbEvaluationContext
+	out     strings.Builder
+}
+
+func newERBInterpreter(context *erbEvaluationContext) *erbInterpreter {
+	return &erbInterpreter{context: context}
+}
+
+func (i *erbInterpreter) render(body []erbNode) (string, error) {
+	_, err := i.evalBody(body, newERBScope(nil))
+	if jump, ok := err.(*jumpSignal); ok {
+		return "", &unsupportedError{reason: jump.Error()}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return i.out.String(), nil
+}
+
+func (i *erbInterpreter) evalBody(body []erbNode, scope *erbScope) (interface{}, error) {
+	var value interface{}
+
+	for _, node := range body {
+		var err error
+		value, err = i.eval(node, scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+func (i *erbInterpreter) eval(node erbNode, scope *erbScope) (interface{}, error) {
+	value, err := i.evalNode(node, scope)
+	if err != nil {
+		setErrorLine(err, node.nodeLine())
+		return nil, err
+	}
+	return value, nil
+}
+
+func (i *erbInterpreter) evalNode(node erbNode, scope *erbScope) (interface{}, error) {
+	switch n := node.(type) {
+	case *textNode:
+		i.out.WriteString(n.text)
+		return nil, nil
+
+	case *outputNode:
+		value, err := i.eval(n.expr, scope)
+		if err != nil {
+			return nil, err
+		}
+		i.out.WriteString(toS(value))
+		return nil, nil
+
+	case *literalNode:
+		return n.value, nil
+
+	case *stringNode:
+		s, err := i.evalParts(n.parts, scope)
+		if err != nil {
+			return nil, err
+		}
+		if n.symbol {
+			return rbSymbol(s), nil
+		}
+		return s, nil
+
+	case *regexpNode:
+		source, err := i.evalParts(n.parts, scope)
+		if err != nil {
+			return nil, err
+		}
+		return newRegexp(source, n.flags)
+
+	case *arrayNode:
+		items, err := i.evalArgs(n.elements, scope)
+		if err != nil {
+			return nil, err
+		}
+		return newArray(items...), nil
+
+	case *hashNode:
+		hash := newHash()
+		for index := range n.keys {
+			key, err := i.eval(n.keys[index], scope)
+			if err != nil {
+				return nil, err
+			}
+			value, err := i.eval(n.values[index], scope)
+			if err != nil {
+				return nil, err
+			}
+			hash.set(key, value)
+		}
+		return hash, nil
+
+	case *rangeNode:
+		from, err := i.eval(n.from, scope)
+		if err != nil {
+			return nil, err
+		}
+		to, err := i.eval(n.to, scope)
+		if err != nil {
+			return nil, err
+		}
+		return &rbRange{from: from, to: to, exclusive: n.exclusive}, nil
+
+	case *localNode:
+		value, _ := scope.lookup(n.name)
+		return value, nil
+
+	case *constNode:
+		return lookupConstant(n.name)
+
+	case *callNode:
+		return i.evalCall(n, scope)
+
+	case *assignNode:
+		return i.evalAssign(n, scope)
+
+	case *andNode:
+		left, err := i.eval(n.left, scope)
+		if err != nil || !truthy(left) {
+			return left, err
+		}
+		return i.eval(n.right, scope)
+
+	case *orNode:
+		left, err := i.eval(n.left, scope)
+		if err != nil || truthy(left) {
+			return left, err
+		}
+		return i.eval(n.right, scope)
+
+	case *notNode:
+		value, err := i.eval(n.expr, scope)
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(value), nil
+
+	case *ifNode:
+		cond, err := i.eval(n.cond, scope)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) != n.negate {
+			return i.evalBody(n.thenBody, scope)
+		}
+		return i.evalBody(n.elseBody, scope)
+
+	case *caseNode:
+		return i.evalCase(n, scope)
+
+	case *seqNode:
+		return i.evalBody(n.body, scope)
+
+	case *jumpNode:
+		var value interface{}
+		if n.value != nil {
+			var err error
+			value, err = i.eval(n.value, scope)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, &jumpSignal{kind: n.kind, value: value}
+
+	case *splatNode:
+		return nil, &unsupportedError{reason: "splat outside of arguments"}
+	}
+
+	return nil, &unsupportedError{reason: fmt.Sprintf("unknown node %T", node)}
+}
+
+func (i *erbInterpreter) evalParts(parts []erbNode, scope *erbScope) (string, error) {
+	var b strings.Builder
+
+	for _, part := range parts {
+		value, err := i.eval(part, scope)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(toS(value))
+	}
+
+	return b.String(), nil
+}
+
+// evalArgs evaluates arguments and expands splats.
+func (i *erbInterpreter) evalArgs(nodes []erbNode, scope *erbScope) ([]interface{}, error) {
+	values := []interface{}{}
+
+	for _, node := range nodes {
+		if splat, ok := node.(*splatNode); ok {
+			value, err := i.eval(splat.value, scope)
+			if err != nil {
+				return nil, err
+			}
+			items, err := toA(value)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, items...)
+			continue
+		}
+
+		value, err := i.eval(node, scope)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func (i *erbInterpreter) makeBlock(node *callNode, scope *erbScope) (*rbBlock, error) {
+	if node.block != nil {
+		return &rbBlock{params: node.block.params, body: node.block.body, scope: scope, interp: i}, nil
+	}
+
+	if node.blockArg != nil {
+		value, err := i.eval(node.blockArg, scope)
+		if err != nil {
+			return nil, err
+		}
+		symbol, ok := value.(rbSymbol)
+		if !ok {
+			return nil, &unsupportedError{reason: "only symbols are supported as block arguments"}
+		}
+		return &rbBlock{symbol: symbol, interp: i}, nil
+	}
+
+	return nil, nil
+}
+
+func (i *erbInterpreter) evalCall(node *callNode, scope *erbScope) (interface{}, error) {
+	var receiver interface{}
+
+	if node.receiver != nil {
+		var err error
+		receiver, err = i.eval(node.receiver, scope)
+		if err != nil {
+			return nil, err
+		}
+		if node.safeNav && receiver == nil {
+			return nil, nil
+		}
+	}
+
+	args, err := i.evalArgs(node.args, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := i.makeBlock(node, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+
+	switch {
+	case node.receiver == nil:
+		value, err = i.callFunction(node.name, args, block)
+	case isStringMutation(receiver, node.name):
+		value, err = i.mutateString(node, scope, receiver.(string), args, block)
+	default:
+		value, err = i.callMethod(receiver, node.name, args, block)
+	}
+
+	if jump, ok := err.(*jumpSignal); ok && block != nil && jump.block == block {
+		return jump.value, nil
+	}
+
+	return value, err
+}
+
+var stringMutations = map[string]bool{
+	"<<": true, "concat": true, "prepend": true, "replace": true, "insert": true,
+}
+
+func isStringMutation(receiver interface{}, name string) bool {
+	if _, ok := receiver.(string); !ok {
+		return false
+	}
+	return stringMutations[name] || (strings.HasSuffix(name, "!") && name != "!")
+}
+
+// mutateString emulates methods that change a string in place by assigning
+// the changed string back to the variable or element that holds it.
+func (i *erbInterpreter) mutateString(node *callNode, scope *erbScope, s string, args []interface{}, block *rbBlock) (interface{}, error) {
+	var assign func(value interface{}) error
+
+	switch target := node.receiver.(type) {
+	case *localNode:
+		assign = func(value interface{}) error {
+			scope.assign(target.name, value)
+			return nil
+		}
+	case *callNode:
+		if target.name != "[]" || target.receiver == nil {
+			return nil, &unsupportedError{reason: fmt.Sprintf("'%s' on a string that is not held in a variable", node.name)}
+		}
+		container, err := i.eval(target.receiver, scope)
+		if err != nil {
+			return nil, err
+		}
+		indexArgs, err := i.evalArgs(target.args, scope)
+		if err != nil {
+			return nil, err
+		}
+		assign = func(value interface{}) error {
+			_, err := i.callMethod(container, "[]=", append(indexArgs, value), nil)
+			return err
+		}
+	default:
+		return nil, &unsupportedError{reason: fmt.Sprintf("'%s' on a string that is not held in a variable", node.name)}
+	}
+
+	if stringMutations[node.name] {
+		value, err := i.callMethod(s, node.name, args, block)
+		if err != nil {
+			return nil, err
+		}
+		return value, assign(value)
+	}
+
+	value, err := i.callMethod(s, strings.TrimSuffix(node.name, "!"), args, block)
+	if err != nil {
+		return nil, err
+	}
+	if changed, ok := value.(string); !ok || changed == s {
+		// Bang methods return nil when they do not change anything
+		return nil, nil
+	}
+
+	return value, assign(value)
+}
+
+func (i *erbInterpreter) evalAssign(node *assignNode, scope *erbScope) (interface{}, error) {
+	switch target := node.target.(type) {
+	case *localNode:
+		current, _ := scope.lookup(target.name)
+		value, err := i.combineAssign(node, current, scope)
+		if err != nil {
+			return nil, err
+		}
+		scope.assign(target.name, value)
+		return value, nil
+
+	case *callNode:
+		receiver, err := i.eval(target.receiver, scope)
+		if err != nil {
+			return nil, err
+		}
+		args, err := i.evalArgs(target.args, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		var current interface{}
+		if node.op != "" {
+			current, err = i.callMethod(receiver, "[]", args, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := i.combineAssign(node, current, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = i.callMethod(receiver, "[]=", append(args, value), nil)
+		return value, err
+	}
+
+	return nil, &unsupportedError{reason: "unsupported assignment"}
+}
+
+func (i *erbInterpreter) combineAssign(node *assignNode, current interface{}, scope *erbScope) (interface{}, error) {
+	switch node.op {
+	case "":
+		return i.eval(node.value, scope)
+	case "||":
+		if truthy(current) {
+			return current, nil
+		}
+		return i.eval(node.value, scope)
+	case "&&":
+		if !truthy(current) {
+			return current, nil
+		}
+		return i.eval(node.value, scope)
+	}
+
+	value, err := i.eval(node.value, scope)
+	if err != nil {
+		return nil, err
+	}
+	return i.callMethod(current, node.op, []interface{}{value}, nil)
+}
+
+func (i *erbInterpreter) evalCase(node *caseNode, scope *erbScope) (interface{}, error) {
+	var subject interface{}
+	if node.subject != nil {
+		var err error
+		subject, err = i.eval(node.subject, scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, clause := range node.whens {
+		values, err := i.evalArgs(clause.values, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range values {
+			matched := truthy(value)
+			if node.subject != nil {
+				matched, err = caseEqual(value, subject)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if matched {
+				return i.evalBody(clause.body, scope)
+			}
+		}
+	}
+
+	return i.evalBody(node.elseBody, scope)
+}
+
+// caseEqual implements '===' as used by 'case' statements.
+func caseEqual(pattern, value interface{}) (bool, error) {
+	switch p := pattern.(type) {
+	case rbClass:
+		return isA(value, string(p)), nil
+	case *rbRange:
+		return rangeCovers(p, value), nil
+	case *rbRegexp:
+		switch v := value.(type) {
+		case string:
+			return p.re.MatchString(v), nil
+		case rbSymbol:
+			return p.re.MatchString(string(v)), nil
+		}
+		return false, nil
+	}
+	return rbEqual(pattern, value), nil
+}
+
+var knownConstants = map[string]bool{
+	"JSON": true, "YAML": true, "Psych": true, "Hash": true, "Array": true, "String": true,
+	"Symbol": true, "Integer": true, "Float": true, "Numeric": true, "NilClass": true,
+	"TrueClass": true, "FalseClass": true, "OpenStruct": true, "Regexp": true, "Range": true,
+	"Comparable": true, "Enumerable": true, "Object": true, "Kernel": true,
+	"StandardError": true, "RuntimeError": true, "ArgumentError": true, "TypeError": true,
+	"KeyError": true, "IndexError": true,
+}
+
+func lookupConstant(name string) (interface{}, error) {
+	if !knownConstants[name] {
+		return nil, &unsupportedError{reason: fmt.Sprintf("constant '%s' is not supported", name)}
+	}
+	return rbClass(name), nil
+}
@@ -0,0 +1,661 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type erbTokenKind int
+
+const (
+	tokenEOF erbTokenKind = iota
+	tokenNewline
+	tokenText
+	tokenOutputBegin
+	tokenOutputEnd
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenSymbol
+	tokenRegexp
+	tokenWords
+	tokenIdent
+	tokenConst
+	tokenLabel
+	tokenOp
+)
+
+type erbToken struct {
+	kind erbTokenKind
+
+	// text is the name of identifiers, constants, labels and operators, the
+	// source of numbers and the content of template text
+	text string
+
+	// parts make up strings, symbols and regexps, which may be interpolated
+	parts []stringPart
+	flags string
+	words []string
+
+	line        int
+	spaceBefore bool
+}
+
+type stringPart struct {
+	text   string
+	code   string
+	isCode bool
+	line   int
+}
+
+var rubyKeywords = map[string]bool{
+	"alias": true, "and": true, "begin": true, "BEGIN": true, "break": true, "case": true,
+	"class": true, "def": true, "defined?": true, "do": true, "else": true, "elsif": true,
+	"end": true, "END": true, "ensure": true, "false": true, "for": true, "if": true,
+	"in": true, "module": true, "next": true, "nil": true, "not": true, "or": true,
+	"redo": true, "rescue": true, "retry": true, "return": true, "self": true, "super": true,
+	"then": true, "true": true, "undef": true, "unless": true, "until": true, "when": true,
+	"while": true, "yield": true, "__FILE__": true, "__LINE__": true, "__method__": true,
+}
+
+// rubyOperators are ordered so that longer operators are matched first.
+var rubyOperators = []string{
+	"**=", "<=>", "===", "...", "||=", "&&=", "<<=", ">>=",
+	"**", "==", "!=", "=~", "!~", ">=", "<=", "&&", "||", "<<", ">>", "+=", "-=", "*=", "/=", "%=",
+	"=>", "->", "..", "::", "&.",
+	"+", "-", "*", "/", "%", "=", "<", ">", "!", "&", "|", "^", "~", "?", ":", ",", ".",
+	"(", ")", "[", "]", "{", "}", ";",
+}
+
+type erbLexer struct {
+	src    string
+	pos    int
+	line   int
+	tokens []erbToken
+	space  bool
+
+	inRegexp bool
+}
+
+// lexRuby appends the tokens of a snippet of Ruby code that starts on line.
+func lexRuby(src string, line int, tokens []erbToken) ([]erbToken, error) {
+	l := &erbLexer{src: src, line: line, tokens: tokens}
+
+	for {
+		done, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return l.tokens, nil
+		}
+	}
+}
+
+func (l *erbLexer) unsupported(format string, args ...interface{}) error {
+	return &unsupportedError{line: l.line, reason: fmt.Sprintf(format, args...)}
+}
+
+func (l *erbLexer) emit(token erbToken) {
+	token.line = l.line
+	token.spaceBefore = l.space
+	l.tokens = append(l.tokens, token)
+	l.space = false
+}
+
+func (l *erbLexer) last() *erbToken {
+	if len(l.tokens) == 0 {
+		return nil
+	}
+	return &l.tokens[len(l.tokens)-1]
+}
+
+// expectsOperand reports whether the previous token leaves the lexer in a
+// position where an expression starts, which decides whether '/' starts a
+// regexp and '<<' a heredoc.
+func (l *erbLexer) expectsOperand() bool {
+	last := l.last()
+	if last == nil {
+		return true
+	}
+
+	switch last.kind {
+	case tokenNewline, tokenText, tokenOutputBegin:
+		return true
+	case tokenOp:
+		return last.text != ")" && last.text != "]" && last.text != "}"
+	case tokenIdent:
+		return rubyKeywords[last.text] && last.text != "end" && last.text != "self" &&
+			last.text != "nil" && last.text != "true" && last.text != "false"
+	case tokenLabel:
+		return true
+	}
+
+	return false
+}
+
+// commandArgumentAhead reports whether the previous token is an identifier
+// that is followed by a space but not by whitespace after the current
+// character, as in 'split /,/'.
+func (l *erbLexer) commandArgumentAhead() bool {
+	last := l.last()
+	if last == nil || last.kind != tokenIdent || rubyKeywords[last.text] || !l.space {
+		return false
+	}
+	return l.pos+1 < len(l.src) && l.src[l.pos+1] != ' ' && l.src[l.pos+1] != '='
+}
+
+func (l *erbLexer) peekChar(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *erbLexer) next() (bool, error) {
+	if l.pos >= len(l.src) {
+		return true, nil
+	}
+
+	c := l.src[l.pos]
+
+	switch {
+	case c == ' ' || c == '\t' || c == '\r':
+		l.pos++
+		l.space = true
+		return false, nil
+
+	case c == '\\' && l.peekChar(1) == '\n':
+		l.pos += 2
+		l.line++
+		l.space = true
+		return false, nil
+
+	case c == '#':
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.pos++
+		}
+		return false, nil
+
+	case c == '\n':
+		l.pos++
+		l.lexNewline()
+		l.line++
+		return false, nil
+
+	case c == ';':
+		l.pos++
+		l.emit(erbToken{kind: tokenNewline})
+		return false, nil
+
+	case isDigit(c):
+		return false, l.lexNumber()
+
+	case isIdentStart(c):
+		l.lexIdentifier()
+		return false, nil
+
+	case c == '@' || c == '$' || c == '`':
+		return false, l.unsupported("instance variables, globals and commands are not supported")
+
+	case c == '"' || c == '\'':
+		l.pos++
+		parts, err := l.lexString(c, c, c == '"')
+		if err != nil {
+			return false, err
+		}
+		l.emit(erbToken{kind: tokenString, parts: parts})
+		return false, nil
+
+	case c == ':' && l.peekChar(1) == '"':
+		l.pos += 2
+		parts, err := l.lexString('"', '"', true)
+		if err != nil {
+			return false, err
+		}
+		l.emit(erbToken{kind: tokenSymbol, parts: parts})
+		return false, nil
+
+	case c == ':' && isIdentStart(l.peekChar(1)) && (l.space || l.expectsOperand()):
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.src) && isIdentChar(l.src[l.pos]) {
+			l.pos++
+		}
+		if l.pos < len(l.src) && (l.src[l.pos] == '?' || l.src[l.pos] == '!' || l.src[l.pos] == '=') {
+			l.pos++
+		}
+		l.emit(erbToken{kind: tokenSymbol, parts: []stringPart{{text: l.src[start:l.pos]}}})
+		return false, nil
+
+	case c == '/' && (l.expectsOperand() || l.commandArgumentAhead()):
+		l.pos++
+		parts, err := l.lexString('/', '/', true)
+		if err != nil {
+			return false, err
+		}
+		l.emit(erbToken{kind: tokenRegexp, parts: parts, flags: l.lexRegexpFlags()})
+		return false, nil
+
+	case c == '%' && (l.expectsOperand() || l.commandArgumentAhead()):
+		return false, l.lexPercentLiteral()
+
+	case c == '<' && l.peekChar(1) == '<' && (l.peekChar(2) == '~' || l.peekChar(2) == '-' || isUpper(l.peekChar(2))) && l.expectsOperand():
+		return false, l.unsupported("heredocs are not supported")
+
+	case c == '=' && l.pos == 0 && strings.HasPrefix(l.src, "=begin"):
+		return false, l.unsupported("block comments are not supported")
+	}
+
+	for _, op := range rubyOperators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			l.emit(erbToken{kind: tokenOp, text: op})
+			return false, nil
+		}
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return false, l.unsupported("unexpected character %q", r)
+}
+
+// lexNewline ends a statement unless the expression continues on the next
+// line, either because the line ends in an operator or because the next line
+// starts with a method call.
+func (l *erbLexer) lexNewline() {
+	l.space = true
+
+	last := l.last()
+	if last == nil || last.kind == tokenNewline || last.kind == tokenOutputBegin {
+		return
+	}
+	if last.kind == tokenOp && last.text != ")" && last.text != "]" && last.text != "}" && last.text != "|" {
+		return
+	}
+
+	rest := strings.TrimLeft(l.src[l.pos:], " \t\r\n")
+	if strings.HasPrefix(rest, "&.") || (strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "..")) {
+		return
+	}
+
+	l.emit(erbToken{kind: tokenNewline})
+}
+
+func (l *erbLexer) lexNumber() error {
+	start := l.pos
+	isFloat := false
+
+	if l.src[l.pos] == '0' && l.pos+1 < len(l.src) && strings.ContainsRune("xXbBoO", rune(l.src[l.pos+1])) {
+		l.pos += 2
+		for l.pos < len(l.src) && (isHexDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+	} else {
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+		if l.peekChar(0) == '.' && isDigit(l.peekChar(1)) {
+			isFloat = true
+			l.pos++
+			for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+				l.pos++
+			}
+		}
+		if c := l.peekChar(0); c == 'e' || c == 'E' {
+			next := l.peekChar(1)
+			if isDigit(next) || ((next == '+' || next == '-') && isDigit(l.peekChar(2))) {
+				isFloat = true
+				l.pos += 2
+				for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+					l.pos++
+				}
+			}
+		}
+	}
+
+	if l.pos < len(l.src) && isIdentChar(l.src[l.pos]) {
+		return l.unsupported("unsupported number literal")
+	}
+
+	source := strings.ReplaceAll(l.src[start:l.pos], "_", "")
+	if isFloat {
+		l.emit(erbToken{kind: tokenFloat, text: source})
+	} else {
+		l.emit(erbToken{kind: tokenInt, text: source})
+	}
+
+	return nil
+}
+
+func (l *erbLexer) lexIdentifier() {
+	start := l.pos
+	for l.pos < len(l.src) && isIdentChar(l.src[l.pos]) {
+		l.pos++
+	}
+
+	// Method names may end in '?' or '!' as long as it is not part of '!=' or '?:'
+	if c := l.peekChar(0); (c == '?' || c == '!') && l.peekChar(1) != '=' && l.peekChar(1) != ':' {
+		l.pos++
+	}
+
+	name := l.src[start:l.pos]
+
+	if name == "defined" && l.peekChar(0) == '?' {
+		l.pos++
+		name = "defined?"
+	}
+
+	// 'name: value' in hashes and arguments
+	if l.peekChar(0) == ':' && l.peekChar(1) != ':' && !rubyKeywords[name] {
+		l.pos++
+		l.emit(erbToken{kind: tokenLabel, text: name})
+		return
+	}
+
+	if isUpper(name[0]) {
+		l.emit(erbToken{kind: tokenConst, text: name})
+	} else {
+		l.emit(erbToken{kind: tokenIdent, text: name})
+	}
+}
+
+// lexString reads up to the closing delimiter. Interpolated strings are split
+// into literal and code parts; nested delimiters are balanced when open and
+// close differ, as in '%w(a (b) c)'.
+func (l *erbLexer) lexString(open, close byte, interpolate bool) ([]stringPart, error) {
+	var parts []stringPart
+	var text strings.Builder
+	depth := 0
+	regexp := open == '/' || l.inRegexp
+
+	for {
+		if l.pos >= len(l.src) {
+			return nil, l.unsupported("unterminated string")
+		}
+
+		c := l.src[l.pos]
+
+		switch {
+		case c == close && depth == 0:
+			l.pos++
+			if text.Len() > 0 || len(parts) == 0 {
+				parts = append(parts, stringPart{text: text.String()})
+			}
+			return parts, nil
+
+		case c == close:
+			depth--
+			text.WriteByte(c)
+			l.pos++
+
+		case c == open && open != close:
+			depth++
+			text.WriteByte(c)
+			l.pos++
+
+		case c == '\n':
+			l.line++
+			text.WriteByte(c)
+			l.pos++
+
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return nil, l.unsupported("unterminated string")
+			}
+			if !interpolate {
+				next := l.src[l.pos+1]
+				if next == close || next == '\\' || (next == open && open != close) {
+					text.WriteByte(next)
+				} else {
+					text.WriteByte('\\')
+					text.WriteByte(next)
+				}
+				l.pos += 2
+				continue
+			}
+			if regexp {
+				// Regexps keep their escapes for the regexp engine
+				next := l.src[l.pos+1]
+				if next == '/' {
+					text.WriteByte('/')
+				} else {
+					text.WriteByte('\\')
+					text.WriteByte(next)
+				}
+				l.pos += 2
+				continue
+			}
+			err := l.lexEscape(&text)
+			if err != nil {
+				return nil, err
+			}
+
+		case c == '#' && interpolate && l.peekChar(1) == '{':
+			if text.Len() > 0 {
+				parts = append(parts, stringPart{text: text.String()})
+				text.Reset()
+			}
+			line := l.line
+			code, err := l.lexInterpolation()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, stringPart{code: code, isCode: true, line: line})
+
+		case c == '#' && interpolate && (l.peekChar(1) == '@' || l.peekChar(1) == '$'):
+			return nil, l.unsupported("interpolating variables is not supported")
+
+		default:
+			text.WriteByte(c)
+			l.pos++
+		}
+	}
+}
+
+func (l *erbLexer) lexEscape(text *strings.Builder) error {
+	next := l.src[l.pos+1]
+	l.pos += 2
+
+	switch next {
+	case 'n':
+		text.WriteByte('\n')
+	case 't':
+		text.WriteByte('\t')
+	case 'r':
+		text.WriteByte('\r')
+	case 's':
+		text.WriteByte(' ')
+	case '0':
+		text.WriteByte(0)
+	case 'e':
+		text.WriteByte(27)
+	case 'a':
+		text.WriteByte(7)
+	case 'b':
+		text.WriteByte(8)
+	case 'f':
+		text.WriteByte(12)
+	case 'v':
+		text.WriteByte(11)
+	case '\n':
+		l.line++
+	case 'u':
+		if l.peekChar(0) == '{' {
+			end := strings.IndexByte(l.src[l.pos:], '}')
+			if end == -1 {
+				return l.unsupported("unterminated unicode escape")
+			}
+			for _, hex := range strings.Fields(l.src[l.pos+1 : l.pos+end]) {
+				code, err := strconv.ParseUint(hex, 16, 32)
+				if err != nil {
+					return l.unsupported("invalid unicode escape")
+				}
+				text.WriteRune(rune(code))
+			}
+			l.pos += end + 1
+			return nil
+		}
+		if l.pos+4 > len(l.src) {
+			return l.unsupported("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+		if err != nil {
+			return l.unsupported("invalid unicode escape")
+		}
+		text.WriteRune(rune(code))
+		l.pos += 4
+	case 'x':
+		end := l.pos
+		for end < len(l.src) && end < l.pos+2 && isHexDigit(l.src[end]) {
+			end++
+		}
+		code, err := strconv.ParseUint(l.src[l.pos:end], 16, 8)
+		if err != nil {
+			return l.unsupported("invalid hex escape")
+		}
+		text.WriteByte(byte(code))
+		l.pos = end
+	default:
+		text.WriteByte(next)
+	}
+
+	return nil
+}
+
+// lexInterpolation returns the code between '#{' and its closing brace.
+func (l *erbLexer) lexInterpolation() (string, error) {
+	l.pos += 2
+	start := l.pos
+	depth := 0
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				code := l.src[start:l.pos]
+				l.pos++
+				return code, nil
+			}
+			depth--
+		case '\n':
+			l.line++
+		case '"', '\'':
+			// Skip nested strings so that braces in them are not counted
+			l.pos++
+			for l.pos < len(l.src) && l.src[l.pos] != c {
+				if l.src[l.pos] == '\\' {
+					l.pos++
+				}
+				l.pos++
+			}
+		}
+		l.pos++
+	}
+
+	return "", l.unsupported("unterminated interpolation")
+}
+
+func (l *erbLexer) lexRegexpFlags() string {
+	start := l.pos
+	for l.pos < len(l.src) && strings.IndexByte("imxounse", l.src[l.pos]) != -1 {
+		l.pos++
+	}
+	return l.src[start:l.pos]
+}
+
+func (l *erbLexer) lexPercentLiteral() error {
+	kind := byte('Q')
+	pos := l.pos + 1
+
+	if pos < len(l.src) && isAlpha(l.src[pos]) {
+		kind = l.src[pos]
+		pos++
+	}
+	if pos >= len(l.src) {
+		return l.unsupported("unterminated percent literal")
+	}
+
+	open := l.src[pos]
+	close := open
+	switch open {
+	case '(':
+		close = ')'
+	case '[':
+		close = ']'
+	case '{':
+		close = '}'
+	case '<':
+		close = '>'
+	default:
+		if isAlpha(open) || isDigit(open) || open == ' ' {
+			return l.unsupported("unsupported percent literal")
+		}
+	}
+
+	l.pos = pos + 1
+
+	switch kind {
+	case 'w', 'W', 'i', 'I':
+		parts, err := l.lexString(open, close, false)
+		if err != nil {
+			return err
+		}
+		var words []string
+		for _, part := range parts {
+			words = append(words, strings.Fields(part.text)...)
+		}
+		token := erbToken{kind: tokenWords, words: words}
+		if kind == 'i' || kind == 'I' {
+			token.flags = "symbols"
+		}
+		l.emit(token)
+
+	case 'q':
+		parts, err := l.lexString(open, close, false)
+		if err != nil {
+			return err
+		}
+		l.emit(erbToken{kind: tokenString, parts: parts})
+
+	case 'Q':
+		parts, err := l.lexString(open, close, true)
+		if err != nil {
+			return err
+		}
+		l.emit(erbToken{kind: tokenString, parts: parts})
+
+	case 'r':
+		l.inRegexp = true
+		parts, err := l.lexString(open, close, true)
+		l.inRegexp = false
+		if err != nil {
+			return err
+		}
+		l.emit(erbToken{kind: tokenRegexp, parts: parts, flags: l.lexRegexpFlags()})
+
+	default:
+		return l.unsupported("unsupported percent literal '%%%c'", kind)
+	}
+
+	return nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isAlpha(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
+
+func isIdentStart(c byte) bool { return isAlpha(c) || c == '_' || c >= 0x80 }
+
+func isIdentChar(c byte) bool { return isIdentStart(c) || isDigit(c) }
@@ -0,0 +1,1865 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// callMethod calls a method on a template value. Methods that the native
+// renderer does not implement are reported as unsupported so that Ruby can
+// render the template instead, except on nil where Ruby would fail as well.
+func (i *erbInterpreter) callMethod(receiver interface{}, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch r := receiver.(type) {
+	case nil:
+		return i.nilMethod(name, args, block)
+	case bool:
+		return i.boolMethod(r, name, args, block)
+	case int64, float64:
+		return i.numericMethod(receiver, name, args, block)
+	case string:
+		return i.stringMethod(r, name, args, block)
+	case rbSymbol:
+		return i.symbolMethod(r, name, args, block)
+	case *rbArray:
+		return i.arrayMethod(r, name, args, block)
+	case *rbHash:
+		return i.hashMethod(r, name, args, block)
+	case *rbRange:
+		return i.rangeMethod(r, name, args, block)
+	case *rbOpenStruct:
+		return i.openStructMethod(r, name, args, block)
+	case *rbRegexp:
+		return i.regexpMethod(r, name, args, block)
+	case *rbMatchData:
+		return i.matchDataMethod(r, name, args, block)
+	case rbClass:
+		return i.classMethod(r, name, args, block)
+	case *rbElseBlock:
+		return i.elseBlockMethod(r, name, args, block)
+	case *rbLink:
+		return i.linkMethod(r, name, args, block)
+	case *rbEnumerator:
+		return i.enumeratorMethod(r, name, args, block)
+	}
+
+	return i.objectMethod(receiver, name, args, block)
+}
+
+// objectMethod implements the methods that all values respond to.
+func (i *erbInterpreter) objectMethod(receiver interface{}, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "nil?":
+		return receiver == nil, nil
+	case "to_s":
+		return toS(receiver), nil
+	case "inspect":
+		return inspect(receiver), nil
+	case "==", "eql?", "equal?":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		// Unlike ==, eql? does not consider 1 and 1.0 equal
+		if name == "eql?" && className(receiver) != className(args[0]) {
+			return false, nil
+		}
+		return rbEqual(receiver, args[0]), nil
+	case "!":
+		return !truthy(receiver), nil
+	case "===":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return caseEqual(receiver, args[0])
+	case "=~":
+		return nil, nil
+	case "class":
+		return rbClass(className(receiver)), nil
+	case "is_a?", "kind_of?", "instance_of?":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		class, ok := args[0].(rbClass)
+		if !ok {
+			return nil, newRubyError("TypeError", "class or module required")
+		}
+		if name == "instance_of?" {
+			return className(receiver) == string(class), nil
+		}
+		return isA(receiver, string(class)), nil
+	case "freeze", "itself", "taint", "untaint", "+@", "-@":
+		return receiver, nil
+	case "frozen?":
+		return false, nil
+	case "dup", "clone":
+		return dupValue(receiver), nil
+	case "tap":
+		if block == nil {
+			return nil, newRubyError("LocalJumpError", "no block given (yield)")
+		}
+		if _, err := block.call(receiver); err != nil {
+			return nil, err
+		}
+		return receiver, nil
+	case "then", "yield_self":
+		if block == nil {
+			return nil, &unsupportedError{reason: fmt.Sprintf("'%s' without a block", name)}
+		}
+		return block.call(receiver)
+	case "send", "public_send", "__send__":
+		if len(args) == 0 {
+			return nil, newRubyError("ArgumentError", "no method name given")
+		}
+		return i.callMethod(receiver, toS(args[0]), args[1:], block)
+	case "to_json":
+		return jsonGenerate(receiver, false)
+	case "to_yaml":
+		return yamlDump(receiver)
+	}
+
+	if receiver == nil {
+		return nil, newRubyError("NoMethodError", "undefined method `%s' for nil:NilClass", name)
+	}
+
+	return nil, &unsupportedError{reason: fmt.Sprintf("method '%s' of %s is not supported", name, className(receiver))}
+}
+
+func dupValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *rbArray:
+		return newArray(append([]interface{}{}, v.items...)...)
+	case *rbHash:
+		return v.copy()
+	case *rbOpenStruct:
+		return &rbOpenStruct{fields: v.fields.copy()}
+	}
+	return value
+}
+
+func checkArgs(args []interface{}, min, max int) error {
+	if len(args) >= min && (max < 0 || len(args) <= max) {
+		return nil
+	}
+
+	switch {
+	case min == max:
+		return newRubyError("ArgumentError", "wrong number of arguments (given %d, expected %d)", len(args), min)
+	case max < 0:
+		return newRubyError("ArgumentError", "wrong number of arguments (given %d, expected %d+)", len(args), min)
+	}
+	return newRubyError("ArgumentError", "wrong number of arguments (given %d, expected %d..%d)", len(args), min, max)
+}
+
+func typeConversionError(value interface{}, target string) error {
+	source := className(value)
+	switch value.(type) {
+	case nil:
+		source = "nil"
+	case bool:
+		source = toS(value)
+	}
+	return newRubyError("TypeError", "no implicit conversion of %s into %s", source, target)
+}
+
+// describeForError describes a value the way Ruby does in NoMethodError.
+func describeForError(value interface{}) string {
+	if value == nil {
+		return "nil:NilClass"
+	}
+	return inspect(value) + ":" + className(value)
+}
+
+func requireBlock(block *rbBlock, name string) error {
+	if block == nil {
+		return &unsupportedError{reason: fmt.Sprintf("'%s' without a block", name)}
+	}
+	return nil
+}
+
+func intArg(args []interface{}, index int) (int, error) {
+	if index >= len(args) {
+		return 0, newRubyError("ArgumentError", "wrong number of arguments")
+	}
+	switch v := args[index].(type) {
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	}
+	return 0, typeConversionError(args[index], "Integer")
+}
+
+func stringArg(args []interface{}, index int) (string, error) {
+	if index >= len(args) {
+		return "", newRubyError("ArgumentError", "wrong number of arguments")
+	}
+	if s, ok := args[index].(string); ok {
+		return s, nil
+	}
+	return "", typeConversionError(args[index], "String")
+}
+
+func (i *erbInterpreter) nilMethod(name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "to_a":
+		return newArray(), nil
+	case "to_h":
+		return newHash(), nil
+	case "to_i":
+		return int64(0), nil
+	case "to_f":
+		return 0.0, nil
+	case "&":
+		return false, nil
+	case "|", "^":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return truthy(args[0]), nil
+	}
+
+	return i.objectMethod(nil, name, args, block)
+}
+
+func (i *erbInterpreter) boolMethod(receiver bool, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "&", "|", "^":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		other := truthy(args[0])
+		switch name {
+		case "&":
+			return receiver && other, nil
+		case "|":
+			return receiver || other, nil
+		}
+		return receiver != other, nil
+	}
+
+	return i.objectMethod(receiver, name, args, block)
+}
+
+func (i *erbInterpreter) numericMethod(receiver interface{}, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	integer, isInteger := receiver.(int64)
+	float, _ := receiver.(float64)
+	if isInteger {
+		float = float64(integer)
+	}
+
+	switch name {
+	case "+", "-", "*", "/", "%", "modulo", "**", "div", "fdiv", "divmod":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return arithmetic(receiver, name, args[0])
+
+	case "<", "<=", ">", ">=":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		result, err := rbCompare(receiver, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return compareResult(name, result), nil
+
+	case "<=>":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		result, err := rbCompare(receiver, args[0])
+		if err != nil {
+			return nil, nil
+		}
+		return int64(result), nil
+
+	case "between?":
+		if err := checkArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		low, err := rbCompare(receiver, args[0])
+		if err != nil {
+			return nil, err
+		}
+		high, err := rbCompare(receiver, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return low >= 0 && high <= 0, nil
+
+	case "clamp":
+		if err := checkArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		if low, err := rbCompare(receiver, args[0]); err != nil || low < 0 {
+			return args[0], err
+		}
+		if high, err := rbCompare(receiver, args[1]); err != nil || high > 0 {
+			return args[1], err
+		}
+		return receiver, nil
+
+	case "-@":
+		if isInteger {
+			return negateInteger(integer)
+		}
+		return -float, nil
+
+	case "abs", "magnitude":
+		if isInteger {
+			if integer < 0 {
+				return negateInteger(integer)
+			}
+			return integer, nil
+		}
+		return math.Abs(float), nil
+
+	case "to_i", "to_int", "truncate":
+		if isInteger {
+			return integer, nil
+		}
+		return toInteger(float)
+
+	case "to_f":
+		return float, nil
+
+	case "to_s", "inspect":
+		if isInteger && len(args) == 1 {
+			base, err := intArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			if base < 2 || base > 36 {
+				return nil, newRubyError("ArgumentError", "invalid radix %d", base)
+			}
+			return strconv.FormatInt(integer, base), nil
+		}
+		return toS(receiver), nil
+
+	case "floor", "ceil", "round":
+		return roundNumber(receiver, name, args)
+
+	case "zero?":
+		return float == 0, nil
+	case "nonzero?":
+		if float == 0 {
+			return nil, nil
+		}
+		return receiver, nil
+	case "positive?":
+		return float > 0, nil
+	case "negative?":
+		return float < 0, nil
+	case "integer?":
+		return isInteger, nil
+	case "finite?":
+		return !math.IsInf(float, 0) && !math.IsNaN(float), nil
+	case "infinite?":
+		if math.IsInf(float, 1) {
+			return int64(1), nil
+		} else if math.IsInf(float, -1) {
+			return int64(-1), nil
+		}
+		return nil, nil
+	case "nan?":
+		if !isInteger {
+			return math.IsNaN(float), nil
+		}
+	}
+
+	if !isInteger {
+		return i.objectMethod(receiver, name, args, block)
+	}
+
+	switch name {
+	case "even?":
+		return integer%2 == 0, nil
+	case "odd?":
+		return integer%2 != 0, nil
+	case "succ", "next":
+		return addIntegers(integer, 1)
+	case "pred":
+		return subtractIntegers(integer, 1)
+	case "chr":
+		if integer < 0 || integer > 255 {
+			return nil, newRubyError("RangeError", "%d out of char range", integer)
+		}
+		return string([]byte{byte(integer)}), nil
+	case "ord":
+		return integer, nil
+
+	case "&", "|", "^", "<<", ">>":
+		other, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "&":
+			return integer & int64(other), nil
+		case "|":
+			return integer | int64(other), nil
+		case "^":
+			return integer ^ int64(other), nil
+		case "<<":
+			return shiftInteger(integer, int64(other))
+		}
+		if other == math.MinInt {
+			return shiftInteger(integer, math.MaxInt64)
+		}
+		return shiftInteger(integer, -int64(other))
+
+	case "times":
+		items, err := rangeItems(&rbRange{from: int64(0), to: integer, exclusive: true})
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return newEnumerator(items, receiver, name, args), nil
+		}
+		for _, item := range items {
+			if _, err := block.call(item); err != nil {
+				return nil, err
+			}
+		}
+		return receiver, nil
+
+	case "upto", "downto":
+		limit, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		var items []interface{}
+		if name == "upto" {
+			if items, err = rangeItems(&rbRange{from: integer, to: int64(limit)}); err != nil {
+				return nil, err
+			}
+		} else {
+			if items, err = rangeItems(&rbRange{from: int64(limit), to: integer}); err != nil {
+				return nil, err
+			}
+			for left, right := 0, len(items)-1; left < right; left, right = left+1, right-1 {
+				items[left], items[right] = items[right], items[left]
+			}
+		}
+		if block == nil {
+			return newEnumerator(items, receiver, name, args), nil
+		}
+		for _, item := range items {
+			if _, err := block.call(item); err != nil {
+				return nil, err
+			}
+		}
+		return receiver, nil
+	}
+
+	return i.objectMethod(receiver, name, args, block)
+}
+
+func compareResult(op string, result int) bool {
+	switch op {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	}
+	return result >= 0
+}
+
+// integerOverflowError makes templates that need integers beyond 64 bits fall
+// back to the Ruby renderer, whose integers have arbitrary precision.
+func integerOverflowError() error {
+	return &unsupportedError{reason: "integers that do not fit into 64 bits are not supported"}
+}
+
+func addIntegers(left, right int64) (int64, error) {
+	sum := left + right
+	if (sum > left) != (right > 0) {
+		return 0, integerOverflowError()
+	}
+	return sum, nil
+}
+
+func subtractIntegers(left, right int64) (int64, error) {
+	difference := left - right
+	if (difference < left) != (right > 0) {
+		return 0, integerOverflowError()
+	}
+	return difference, nil
+}
+
+func multiplyIntegers(left, right int64) (int64, error) {
+	if left == 0 || right == 0 {
+		return 0, nil
+	}
+	product := left * right
+	if product/right != left || (left == math.MinInt64 && right == -1) || (right == math.MinInt64 && left == -1) {
+		return 0, integerOverflowError()
+	}
+	return product, nil
+}
+
+func negateInteger(value int64) (int64, error) {
+	if value == math.MinInt64 {
+		return 0, integerOverflowError()
+	}
+	return -value, nil
+}
+
+// powerOfInteger squares the base for each bit of the exponent so that huge
+// exponents overflow after a few steps instead of looping for a long time.
+func powerOfInteger(base, exponent int64) (int64, error) {
+	result := int64(1)
+
+	for exponent > 0 {
+		var err error
+
+		if exponent&1 == 1 {
+			if result, err = multiplyIntegers(result, base); err != nil {
+				return 0, err
+			}
+		}
+
+		exponent >>= 1
+
+		if exponent > 0 {
+			if base, err = multiplyIntegers(base, base); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func shiftInteger(value int64, shift int64) (int64, error) {
+	if shift < 0 {
+		if shift == math.MinInt64 || -shift >= 64 {
+			if value < 0 {
+				return -1, nil
+			}
+			return 0, nil
+		}
+		return value >> uint(-shift), nil
+	}
+
+	if value == 0 {
+		return 0, nil
+	}
+
+	if shift >= 63 {
+		return 0, integerOverflowError()
+	}
+
+	shifted := value << uint(shift)
+	if shifted>>uint(shift) != value {
+		return 0, integerOverflowError()
+	}
+
+	return shifted, nil
+}
+
+func arithmetic(left interface{}, op string, right interface{}) (interface{}, error) {
+	var rightFloat float64
+	rightInteger, rightIsInteger := right.(int64)
+
+	switch r := right.(type) {
+	case int64:
+		rightFloat = float64(r)
+	case float64:
+		rightFloat = r
+	default:
+		source := className(right)
+		if right == nil {
+			source = "nil"
+		}
+		return nil, newRubyError("TypeError", "%s can't be coerced into %s", source, className(left))
+	}
+
+	leftInteger, leftIsInteger := left.(int64)
+	leftFloat, _ := left.(float64)
+	if leftIsInteger {
+		leftFloat = float64(leftInteger)
+	}
+
+	if op == "fdiv" {
+		return leftFloat / rightFloat, nil
+	}
+
+	if op == "divmod" {
+		quotient, err := arithmetic(left, "div", right)
+		if err != nil {
+			return nil, err
+		}
+		remainder, err := arithmetic(left, "%", right)
+		if err != nil {
+			return nil, err
+		}
+		return newArray(quotient, remainder), nil
+	}
+
+	if leftIsInteger && rightIsInteger {
+		switch op {
+		case "+":
+			return addIntegers(leftInteger, rightInteger)
+		case "-":
+			return subtractIntegers(leftInteger, rightInteger)
+		case "*":
+			return multiplyIntegers(leftInteger, rightInteger)
+		case "/", "div", "%", "modulo":
+			if rightInteger == 0 {
+				return nil, newRubyError("ZeroDivisionError", "divided by 0")
+			}
+			if leftInteger == math.MinInt64 && rightInteger == -1 && op != "%" && op != "modulo" {
+				return nil, integerOverflowError()
+			}
+			quotient := leftInteger / rightInteger
+			remainder := leftInteger % rightInteger
+			if remainder != 0 && (remainder < 0) != (rightInteger < 0) {
+				quotient--
+				remainder += rightInteger
+			}
+			if op == "/" || op == "div" {
+				return quotient, nil
+			}
+			return remainder, nil
+		case "**":
+			if rightInteger < 0 {
+				return nil, &unsupportedError{reason: "rational numbers are not supported"}
+			}
+			return powerOfInteger(leftInteger, rightInteger)
+		}
+	}
+
+	switch op {
+	case "+":
+		return leftFloat + rightFloat, nil
+	case "-":
+		return leftFloat - rightFloat, nil
+	case "*":
+		return leftFloat * rightFloat, nil
+	case "/":
+		return leftFloat / rightFloat, nil
+	case "div":
+		if rightFloat == 0 {
+			return nil, newRubyError("ZeroDivisionError", "divided by 0")
+		}
+		return toInteger(math.Floor(leftFloat / rightFloat))
+	case "%", "modulo":
+		remainder := math.Mod(leftFloat, rightFloat)
+		if remainder != 0 && (remainder < 0) != (rightFloat < 0) {
+			remainder += rightFloat
+		}
+		return remainder, nil
+	case "**":
+		return math.Pow(leftFloat, rightFloat), nil
+	}
+
+	return nil, &unsupportedError{reason: fmt.Sprintf("operator '%s' is not supported", op)}
+}
+
+func roundNumber(receiver interface{}, name string, args []interface{}) (interface{}, error) {
+	digits := 0
+	if len(args) > 0 {
+		var err error
+		digits, err = intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if integer, ok := receiver.(int64); ok {
+		if digits >= 0 {
+			return integer, nil
+		}
+		return nil, &unsupportedError{reason: fmt.Sprintf("'%s' with negative digits", name)}
+	}
+
+	float := receiver.(float64)
+	round := map[string]func(float64) float64{"floor": math.Floor, "ceil": math.Ceil, "round": math.Round}[name]
+
+	if digits > 0 {
+		scale := math.Pow(10, float64(digits))
+		return round(float*scale) / scale, nil
+	}
+
+	scale := math.Pow(10, float64(-digits))
+	return toInteger(round(float/scale) * scale)
+}
+
+func (i *erbInterpreter) stringMethod(s string, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "length", "size":
+		return int64(utf8.RuneCountInString(s)), nil
+	case "bytesize":
+		return int64(len(s)), nil
+	case "empty?":
+		return s == "", nil
+	case "to_s", "to_str":
+		return s, nil
+	case "to_sym", "intern":
+		return rbSymbol(s), nil
+	case "to_i":
+		base := 10
+		if len(args) > 0 {
+			var err error
+			if base, err = intArg(args, 0); err != nil {
+				return nil, err
+			}
+		}
+		return stringToI(s, base)
+	case "hex":
+		return stringToI(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"), 16)
+	case "oct":
+		return stringToI(s, 8)
+	case "to_f":
+		return stringToF(s), nil
+	case "ord":
+		if s == "" {
+			return nil, newRubyError("ArgumentError", "empty string")
+		}
+		r, _ := utf8.DecodeRuneInString(s)
+		return int64(r), nil
+
+	case "upcase":
+		return strings.ToUpper(s), nil
+	case "downcase":
+		return strings.ToLower(s), nil
+	case "capitalize":
+		if s == "" {
+			return s, nil
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		return string(unicode.ToUpper(r)) + strings.ToLower(s[size:]), nil
+	case "swapcase":
+		return strings.Map(func(r rune) rune {
+			if unicode.IsUpper(r) {
+				return unicode.ToLower(r)
+			}
+			return unicode.ToUpper(r)
+		}, s), nil
+	case "strip":
+		return strings.Trim(s, rubyWhitespace), nil
+	case "lstrip":
+		return strings.TrimLeft(s, rubyWhitespace), nil
+	case "rstrip":
+		return strings.TrimRight(s, rubyWhitespace), nil
+	case "chomp":
+		if len(args) > 0 {
+			suffix, err := stringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return strings.TrimSuffix(s, suffix), nil
+		}
+		for _, suffix := range []string{"\r\n", "\n", "\r"} {
+			if strings.HasSuffix(s, suffix) {
+				return strings.TrimSuffix(s, suffix), nil
+			}
+		}
+		return s, nil
+	case "chop":
+		if strings.HasSuffix(s, "\r\n") {
+			return s[:len(s)-2], nil
+		}
+		runes := []rune(s)
+		if len(runes) == 0 {
+			return s, nil
+		}
+		return string(runes[:len(runes)-1]), nil
+	case "reverse":
+		runes := []rune(s)
+		for a, b := 0, len(runes)-1; a < b; a, b = a+1, b-1 {
+			runes[a], runes[b] = runes[b], runes[a]
+		}
+		return string(runes), nil
+	case "squeeze":
+		return squeeze(s, args)
+	case "delete_prefix", "delete_suffix":
+		affix, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if name == "delete_prefix" {
+			return strings.TrimPrefix(s, affix), nil
+		}
+		return strings.TrimSuffix(s, affix), nil
+
+	case "+":
+		other, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return s + other, nil
+	case "*":
+		count, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, newRubyError("ArgumentError", "negative argument")
+		}
+		return strings.Repeat(s, count), nil
+	case "%":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		if array, ok := args[0].(*rbArray); ok {
+			return rubyFormat(s, array.items)
+		}
+		return rubyFormat(s, args)
+	case "<<", "concat":
+		result := s
+		for _, arg := range args {
+			switch v := arg.(type) {
+			case string:
+				result += v
+			case int64:
+				result += string(rune(v))
+			default:
+				return nil, typeConversionError(arg, "String")
+			}
+		}
+		return result, nil
+	case "prepend":
+		prefix := ""
+		for _, arg := range args {
+			other, ok := arg.(string)
+			if !ok {
+				return nil, typeConversionError(arg, "String")
+			}
+			prefix += other
+		}
+		return prefix + s, nil
+	case "replace":
+		return stringArg(args, 0)
+
+	case "==", "eql?", "===":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return rbEqual(s, args[0]), nil
+	case "<", "<=", ">", ">=":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		result, err := rbCompare(s, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return compareResult(name, result), nil
+	case "<=>":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		result, err := rbCompare(s, args[0])
+		if err != nil {
+			return nil, nil
+		}
+		return int64(result), nil
+	case "casecmp":
+		other, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return int64(strings.Compare(strings.ToLower(s), strings.ToLower(other))), nil
+	case "casecmp?":
+		other, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return strings.EqualFold(s, other), nil
+
+	case "include?":
+		other, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Contains(s, other), nil
+	case "start_with?", "end_with?":
+		for _, arg := range args {
+			if re, ok := arg.(*rbRegexp); ok && name == "start_with?" {
+				if loc := re.re.FindStringIndex(s); loc != nil && loc[0] == 0 {
+					return true, nil
+				}
+				continue
+			}
+			affix, ok := arg.(string)
+			if !ok {
+				return nil, typeConversionError(arg, "String")
+			}
+			if (name == "start_with?" && strings.HasPrefix(s, affix)) || (name == "end_with?" && strings.HasSuffix(s, affix)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "index", "rindex":
+		return stringIndex(s, name, args)
+
+	case "=~":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		re, ok := args[0].(*rbRegexp)
+		if !ok {
+			return nil, newRubyError("TypeError", "wrong argument type %s (expected Regexp)", className(args[0]))
+		}
+		return regexpIndex(re, s), nil
+	case "match", "match?":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		re, err := toRegexp(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if name == "match?" {
+			return re.re.MatchString(s), nil
+		}
+		return newMatchData(re, s), nil
+	case "scan":
+		return i.scan(s, args, block)
+	case "sub", "gsub":
+		return i.substitute(s, args, block, name == "gsub")
+	case "tr", "delete", "count":
+		return translate(s, name, args)
+
+	case "split":
+		return split(s, args)
+	case "lines":
+		return newArray(stringsToValues(splitLines(s))...), nil
+	case "chars":
+		return newArray(stringsToValues(strings.Split(s, ""))...), nil
+	case "bytes":
+		items := make([]interface{}, len(s))
+		for index := 0; index < len(s); index++ {
+			items[index] = int64(s[index])
+		}
+		return newArray(items...), nil
+	case "each_line", "each_char":
+		parts := splitLines(s)
+		if name == "each_char" {
+			parts = strings.Split(s, "")
+		}
+		if block == nil {
+			return newEnumerator(stringsToValues(parts), s, name, args), nil
+		}
+		for _, part := range parts {
+			if _, err := block.call(part); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	case "partition", "rpartition":
+		separator, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		index := strings.Index(s, separator)
+		if name == "rpartition" {
+			index = strings.LastIndex(s, separator)
+		}
+		if index == -1 {
+			if name == "rpartition" {
+				return newArray("", "", s), nil
+			}
+			return newArray(s, "", ""), nil
+		}
+		return newArray(s[:index], separator, s[index+len(separator):]), nil
+
+	case "[]", "slice":
+		return stringSlice(s, args)
+
+	case "center", "ljust", "rjust":
+		return justify(s, name, args)
+
+	case "force_encoding", "encode", "unicode_normalize", "scrub", "b":
+		if name == "encode" && len(args) > 0 && !strings.EqualFold(toS(args[0]), "UTF-8") {
+			return nil, &unsupportedError{reason: "encoding strings is not supported"}
+		}
+		return s, nil
+	case "valid_encoding?", "ascii_only?":
+		if name == "ascii_only?" {
+			for index := 0; index < len(s); index++ {
+				if s[index] >= 0x80 {
+					return false, nil
+				}
+			}
+			return true, nil
+		}
+		return utf8.ValidString(s), nil
+	}
+
+	return i.objectMethod(s, name, args, block)
+}
+
+// rubyWhitespace is what String#strip removes.
+const rubyWhitespace = " \t\n\v\f\r\x00"
+
+func stringsToValues(strs []string) []interface{} {
+	values := make([]interface{}, 0, len(strs))
+	for _, s := range strs {
+		values = append(values, s)
+	}
+	return values
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		end := strings.IndexByte(s, '\n')
+		if end == -1 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:end+1])
+		s = s[end+1:]
+	}
+	return lines
+}
+
+func split(s string, args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 0, 2); err != nil {
+		return nil, err
+	}
+
+	limit := 0
+	if len(args) == 2 {
+		var err error
+		if limit, err = intArg(args, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	var parts []string
+	var separator interface{} = " "
+	if len(args) > 0 && args[0] != nil {
+		separator = args[0]
+	}
+
+	switch sep := separator.(type) {
+	case string:
+		switch {
+		case sep == " ":
+			if limit > 0 {
+				return nil, &unsupportedError{reason: "'split' on whitespace with a limit"}
+			}
+			parts = strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(" \t\n\v\f\r\x00", r) })
+		case sep == "":
+			parts = strings.Split(s, "")
+			if limit > 0 && len(parts) > limit {
+				parts = append(parts[:limit-1], strings.Join(parts[limit-1:], ""))
+			}
+		case limit > 0:
+			parts = strings.SplitN(s, sep, limit)
+		default:
+			parts = strings.Split(s, sep)
+		}
+	case *rbRegexp:
+		if sep.re.NumSubexp() > 0 {
+			return nil, &unsupportedError{reason: "'split' with capture groups"}
+		}
+		n := -1
+		if limit > 0 {
+			n = limit
+		}
+		parts = sep.re.Split(s, n)
+	default:
+		return nil, typeConversionError(separator, "String")
+	}
+
+	if limit == 0 {
+		for len(parts) > 0 && parts[len(parts)-1] == "" {
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	return newArray(stringsToValues(parts)...), nil
+}
+
+// stringSlice implements String#[] with character rather than byte indexes.
+func stringSlice(s string, args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	runes := []rune(s)
+
+	switch index := args[0].(type) {
+	case int64:
+		start := int(index)
+		if start < 0 {
+			start += len(runes)
+		}
+		if len(args) == 2 {
+			length, err := intArg(args, 1)
+			if err != nil {
+				return nil, err
+			}
+			if start < 0 || start > len(runes) || length < 0 {
+				return nil, nil
+			}
+			end := start + length
+			if end > len(runes) {
+				end = len(runes)
+			}
+			return string(runes[start:end]), nil
+		}
+		if start < 0 || start >= len(runes) {
+			return nil, nil
+		}
+		return string(runes[start]), nil
+
+	case *rbRange:
+		start, count, ok, err := rangeBounds(index, len(runes))
+		if err != nil || !ok {
+			return nil, err
+		}
+		return string(runes[start : start+count]), nil
+
+	case string:
+		if strings.Contains(s, index) {
+			return index, nil
+		}
+		return nil, nil
+
+	case *rbRegexp:
+		match := index.re.FindStringSubmatch(s)
+		if match == nil {
+			return nil, nil
+		}
+		group := 0
+		if len(args) == 2 {
+			var err error
+			if group, err = intArg(args, 1); err != nil {
+				return nil, err
+			}
+		}
+		if group >= len(match) {
+			return nil, nil
+		}
+		return match[group], nil
+	}
+
+	return nil, typeConversionError(args[0], "Integer")
+}
+
+// rangeBounds resolves a range of indexes into a collection of length items
+// to a start index and a count, like Ruby does for slicing.
+func rangeBounds(r *rbRange, length int) (int, int, bool, error) {
+	start, end := 0, length-1
+
+	if r.from != nil {
+		from, ok := r.from.(int64)
+		if !ok {
+			return 0, 0, false, typeConversionError(r.from, "Integer")
+		}
+		start = int(from)
+	}
+	if r.to != nil {
+		to, ok := r.to.(int64)
+		if !ok {
+			return 0, 0, false, typeConversionError(r.to, "Integer")
+		}
+		end = int(to)
+		if end < 0 {
+			end += length
+		}
+		if r.exclusive {
+			end--
+		}
+	}
+
+	if start < 0 {
+		start += length
+	}
+	if start < 0 || start > length {
+		return 0, 0, false, nil
+	}
+
+	if end >= length {
+		end = length - 1
+	}
+	count := end - start + 1
+	if count < 0 {
+		count = 0
+	}
+
+	return start, count, true, nil
+}
+
+func stringIndex(s string, name string, args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	runes := []rune(s)
+	start := 0
+	if name == "rindex" {
+		start = len(runes)
+	}
+	if len(args) == 2 {
+		var err error
+		if start, err = intArg(args, 1); err != nil {
+			return nil, err
+		}
+		if start < 0 {
+			start += len(runes)
+		}
+		if start < 0 || (name == "index" && start > len(runes)) {
+			return nil, nil
+		}
+		if start > len(runes) {
+			start = len(runes)
+		}
+	}
+
+	var byteIndex int
+	switch needle := args[0].(type) {
+	case string:
+		if name == "index" {
+			offset := len(string(runes[:start]))
+			byteIndex = strings.Index(s[offset:], needle)
+			if byteIndex != -1 {
+				byteIndex += offset
+			}
+		} else {
+			end := len(string(runes[:start])) + len(needle)
+			if end > len(s) {
+				end = len(s)
+			}
+			byteIndex = strings.LastIndex(s[:end], needle)
+		}
+	case *rbRegexp:
+		if name == "rindex" || start != 0 {
+			return nil, &unsupportedError{reason: fmt.Sprintf("'%s' with a regexp", name)}
+		}
+		loc := needle.re.FindStringIndex(s)
+		if loc == nil {
+			return nil, nil
+		}
+		byteIndex = loc[0]
+	default:
+		return nil, typeConversionError(args[0], "String")
+	}
+
+	if byteIndex == -1 {
+		return nil, nil
+	}
+	return int64(utf8.RuneCountInString(s[:byteIndex])), nil
+}
+
+func justify(s string, name string, args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+	width, err := intArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	pad := " "
+	if len(args) == 2 {
+		if pad, err = stringArg(args, 1); err != nil {
+			return nil, err
+		}
+		if pad == "" {
+			return nil, newRubyError("ArgumentError", "zero width padding")
+		}
+	}
+
+	length := utf8.RuneCountInString(s)
+	if width <= length {
+		return s, nil
+	}
+
+	padding := func(n int) string {
+		padRunes := []rune(strings.Repeat(pad, n/utf8.RuneCountInString(pad)+1))
+		return string(padRunes[:n])
+	}
+
+	total := width - length
+	switch name {
+	case "ljust":
+		return s + padding(total), nil
+	case "rjust":
+		return padding(total) + s, nil
+	}
+	left := total / 2
+	return padding(left) + s + padding(total-left), nil
+}
+
+func squeeze(s string, args []interface{}) (interface{}, error) {
+	var set func(r rune) bool
+	if len(args) > 0 {
+		chars, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		set, err = characterSet(chars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var b strings.Builder
+	var last rune = -1
+	for _, r := range s {
+		if r == last && (set == nil || set(r)) {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String(), nil
+}
+
+// expandCharacters expands 'a-z' style ranges as used by String#tr.
+func expandCharacters(chars string) []rune {
+	runes := []rune(chars)
+	var expanded []rune
+
+	for index := 0; index < len(runes); index++ {
+		if index+2 < len(runes) && runes[index+1] == '-' {
+			for r := runes[index]; r <= runes[index+2]; r++ {
+				expanded = append(expanded, r)
+			}
+			index += 2
+			continue
+		}
+		expanded = append(expanded, runes[index])
+	}
+
+	return expanded
+}
+
+func characterSet(chars string) (func(r rune) bool, error) {
+	negate := false
+	if len(chars) > 1 && chars[0] == '^' {
+		negate = true
+		chars = chars[1:]
+	}
+	if strings.Contains(chars, "\\") {
+		return nil, &unsupportedError{reason: "escapes in character sets are not supported"}
+	}
+
+	members := map[rune]bool{}
+	for _, r := range expandCharacters(chars) {
+		members[r] = true
+	}
+
+	return func(r rune) bool { return members[r] != negate }, nil
+}
+
+func translate(s string, name string, args []interface{}) (interface{}, error) {
+	if name == "tr" {
+		if err := checkArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+	} else if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	from, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	set, err := characterSet(from)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "delete":
+		return strings.Map(func(r rune) rune {
+			if set(r) {
+				return -1
+			}
+			return r
+		}, s), nil
+	case "count":
+		count := int64(0)
+		for _, r := range s {
+			if set(r) {
+				count++
+			}
+		}
+		return count, nil
+	}
+
+	to, err := stringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(from, "^") && len(from) > 1 {
+		return nil, &unsupportedError{reason: "negated 'tr' is not supported"}
+	}
+
+	fromRunes := expandCharacters(from)
+	toRunes := expandCharacters(to)
+	mapping := map[rune]rune{}
+	for index, r := range fromRunes {
+		switch {
+		case len(toRunes) == 0:
+			mapping[r] = -1
+		case index < len(toRunes):
+			mapping[r] = toRunes[index]
+		default:
+			mapping[r] = toRunes[len(toRunes)-1]
+		}
+	}
+
+	return strings.Map(func(r rune) rune {
+		if mapped, found := mapping[r]; found {
+			return mapped
+		}
+		return r
+	}, s), nil
+}
+
+func toRegexp(pattern interface{}) (*rbRegexp, error) {
+	switch p := pattern.(type) {
+	case *rbRegexp:
+		return p, nil
+	case string:
+		return &rbRegexp{re: regexp.MustCompile(regexp.QuoteMeta(p)), source: regexp.QuoteMeta(p)}, nil
+	}
+	return nil, newRubyError("TypeError", "wrong argument type %s (expected Regexp)", className(pattern))
+}
+
+func regexpIndex(re *rbRegexp, s string) interface{} {
+	loc := re.re.FindStringIndex(s)
+	if loc == nil {
+		return nil
+	}
+	return int64(utf8.RuneCountInString(s[:loc[0]]))
+}
+
+func (i *erbInterpreter) substitute(s string, args []interface{}, block *rbBlock, all bool) (interface{}, error) {
+	if block != nil {
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+	} else if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	re, err := toRegexp(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	n := 1
+	if all {
+		n = -1
+	}
+
+	var b strings.Builder
+	last := 0
+
+	for _, match := range re.re.FindAllStringSubmatchIndex(s, n) {
+		b.WriteString(s[last:match[0]])
+		matched := s[match[0]:match[1]]
+
+		switch {
+		case block != nil:
+			value, err := block.call(matched)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(toS(value))
+		default:
+			switch replacement := args[1].(type) {
+			case string:
+				b.Write(re.re.ExpandString(nil, rubyReplacement(replacement), s, match))
+			case *rbHash:
+				b.WriteString(toS(replacement.fetch(matched)))
+			default:
+				return nil, typeConversionError(args[1], "String")
+			}
+		}
+
+		last = match[1]
+	}
+
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+func (i *erbInterpreter) scan(s string, args []interface{}, block *rbBlock) (interface{}, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	re, err := toRegexp(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var results []interface{}
+	for _, match := range re.re.FindAllStringSubmatch(s, -1) {
+		var result interface{} = match[0]
+		if len(match) > 1 {
+			result = newArray(stringsToValues(match[1:])...)
+		}
+		results = append(results, result)
+	}
+
+	if block == nil {
+		return newArray(results...), nil
+	}
+	for _, result := range results {
+		if _, err := block.call(result); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (i *erbInterpreter) symbolMethod(symbol rbSymbol, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "to_s", "id2name", "name":
+		return string(symbol), nil
+	case "to_sym":
+		return symbol, nil
+	case "length", "size", "empty?", "start_with?", "end_with?", "[]", "=~", "match?":
+		return i.stringMethod(string(symbol), name, args, block)
+	case "upcase", "downcase", "capitalize", "swapcase", "succ":
+		value, err := i.stringMethod(string(symbol), name, args, block)
+		if err != nil {
+			return nil, err
+		}
+		return rbSymbol(value.(string)), nil
+	case "<=>":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		result, err := rbCompare(symbol, args[0])
+		if err != nil {
+			return nil, nil
+		}
+		return int64(result), nil
+	}
+
+	return i.objectMethod(symbol, name, args, block)
+}
+
+// rbMatchData is the result of String#match and Regexp#match.
+type rbMatchData struct {
+	re     *rbRegexp
+	groups []interface{}
+	pre    string
+	post   string
+}
+
+func newMatchData(re *rbRegexp, s string) interface{} {
+	match := re.re.FindStringSubmatchIndex(s)
+	if match == nil {
+		return nil
+	}
+
+	data := &rbMatchData{re: re, pre: s[:match[0]], post: s[match[1]:]}
+	for group := 0; group < len(match); group += 2 {
+		if match[group] < 0 {
+			data.groups = append(data.groups, nil)
+			continue
+		}
+		data.groups = append(data.groups, s[match[group]:match[group+1]])
+	}
+
+	return data
+}
+
+func (i *erbInterpreter) matchDataMethod(data *rbMatchData, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "[]":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		switch key := args[0].(type) {
+		case int64:
+			if key < 0 {
+				key += int64(len(data.groups))
+			}
+			if key < 0 || key >= int64(len(data.groups)) {
+				return nil, nil
+			}
+			return data.groups[key], nil
+		case string, rbSymbol:
+			index := data.re.re.SubexpIndex(toS(key))
+			if index == -1 {
+				return nil, newRubyError("IndexError", "undefined group name reference: %s", toS(key))
+			}
+			return data.groups[index], nil
+		}
+		return nil, typeConversionError(args[0], "Integer")
+	case "captures":
+		return newArray(data.groups[1:]...), nil
+	case "to_a":
+		return newArray(data.groups...), nil
+	case "to_s":
+		return data.groups[0], nil
+	case "pre_match":
+		return data.pre, nil
+	case "post_match":
+		return data.post, nil
+	case "named_captures":
+		captures := newHash()
+		for index, groupName := range data.re.re.SubexpNames() {
+			if groupName != "" {
+				captures.set(groupName, data.groups[index])
+			}
+		}
+		return captures, nil
+	}
+
+	return i.objectMethod(data, name, args, block)
+}
+
+func (i *erbInterpreter) regexpMethod(re *rbRegexp, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "source":
+		return re.source, nil
+	case "=~", "match", "match?", "===":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		var s string
+		switch v := args[0].(type) {
+		case nil:
+			if name == "===" || name == "match?" {
+				return false, nil
+			}
+			return nil, nil
+		case string:
+			s = v
+		case rbSymbol:
+			s = string(v)
+		default:
+			if name == "===" {
+				return false, nil
+			}
+			return nil, typeConversionError(args[0], "String")
+		}
+		switch name {
+		case "=~":
+			return regexpIndex(re, s), nil
+		case "match":
+			return newMatchData(re, s), nil
+		}
+		return re.re.MatchString(s), nil
+	}
+
+	return i.objectMethod(re, name, args, block)
+}
+
+func (i *erbInterpreter) openStructMethod(object *rbOpenStruct, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "nil?", "to_s", "inspect", "==", "eql?", "!", "===", "=~", "class", "is_a?", "kind_of?",
+		"instance_of?", "freeze", "frozen?", "itself", "dup", "clone", "tap", "then", "send",
+		"public_send", "to_json", "to_yaml", "hash", "method", "methods", "object_id":
+		return i.objectMethod(object, name, args, block)
+
+	case "[]":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return object.fields.fetch(toS(args[0])), nil
+
+	case "dig":
+		if err := checkArgs(args, 1, -1); err != nil {
+			return nil, err
+		}
+		return i.dig(object.fields.fetch(toS(args[0])), args[1:])
+
+	case "to_h":
+		hash := newHash()
+		_ = object.fields.each(func(key, value interface{}) error {
+			hash.set(rbSymbol(toS(key)), value)
+			return nil
+		})
+		return hash, nil
+
+	case "each_pair":
+		if err := requireBlock(block, name); err != nil {
+			return nil, err
+		}
+		err := object.fields.each(func(key, value interface{}) error {
+			_, err := block.call(newArray(rbSymbol(toS(key)), value))
+			return err
+		})
+		return object, err
+
+	case "respond_to?":
+		if err := checkArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		_, found := object.fields.get(toS(args[0]))
+		return found, nil
+	}
+
+	if value, found := object.fields.get(name); found && len(args) == 0 {
+		return value, nil
+	}
+
+	if len(args) == 0 && block == nil && !strings.HasSuffix(name, "=") {
+		return nil, nil
+	}
+
+	return nil, &unsupportedError{reason: fmt.Sprintf("method '%s' of OpenStruct is not supported", name)}
+}
+
+func (i *erbInterpreter) classMethod(class rbClass, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch class + "." + rbClass(name) {
+	case "JSON.dump":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		switch args[0].(type) {
+		case string, int64, float64:
+			return inspect(args[0]), nil
+		}
+		return jsonGenerate(args[0], false)
+
+	case "JSON.generate":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return jsonGenerate(args[0], false)
+
+	case "JSON.pretty_generate":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return jsonGenerate(args[0], true)
+
+	case "JSON.parse", "JSON.load":
+		if err := checkArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeJSON([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 2 {
+			options, ok := args[1].(*rbHash)
+			if !ok || options.len() != 1 || !truthy(options.fetch(rbSymbol("symbolize_names"))) {
+				return nil, &unsupportedError{reason: fmt.Sprintf("options of 'JSON.%s' are not supported", name)}
+			}
+			value = symbolizeNames(value)
+		}
+		return value, nil
+
+	case "YAML.dump", "Psych.dump":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return yamlDump(args[0])
+
+	case "YAML.load", "YAML.safe_load", "Psych.load", "Psych.safe_load":
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return yamlLoad(s)
+
+	case "Hash.new":
+		if block != nil {
+			return nil, &unsupportedError{reason: "'Hash.new' with a block"}
+		}
+		if err := checkArgs(args, 0, 1); err != nil {
+			return nil, err
+		}
+		hash := newHash()
+		if len(args) == 1 {
+			hash.defaultValue = args[0]
+		}
+		return hash, nil
+
+	case "Array.new":
+		if err := checkArgs(args, 0, 2); err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return newArray(), nil
+		}
+		size, err := intArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]interface{}, size)
+		for index := range items {
+			if block != nil {
+				if items[index], err = block.call(int64(index)); err != nil {
+					return nil, err
+				}
+			} else if len(args) == 2 {
+				items[index] = args[1]
+			}
+		}
+		return newArray(items...), nil
+
+	case "String.new":
+		if err := checkArgs(args, 0, 1); err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return "", nil
+		}
+		return stringArg(args, 0)
+
+	case "OpenStruct.new":
+		if err := checkArgs(args, 0, 1); err != nil {
+			return nil, err
+		}
+		fields := newHash()
+		if len(args) == 1 {
+			hash, ok := args[0].(*rbHash)
+			if !ok {
+				return nil, &unsupportedError{reason: "'OpenStruct.new' without a hash"}
+			}
+			_ = hash.each(func(key, value interface{}) error {
+				fields.set(toS(key), value)
+				return nil
+			})
+		}
+		return &rbOpenStruct{fields: fields}, nil
+	}
+
+	switch name {
+	case "name", "to_s", "inspect":
+		return string(class), nil
+	}
+
+	return i.objectMethod(class, name, args, block)
+}
+
+func symbolizeNames(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *rbHash:
+		symbolized := newHash()
+		_ = v.each(func(key, value interface{}) error {
+			symbolized.set(rbSymbol(toS(key)), symbolizeNames(value))
+			return nil
+		})
+		return symbolized
+	case *rbArray:
+		items := make([]interface{}, len(v.items))
+		for index, item := range v.items {
+			items[index] = symbolizeNames(item)
+		}
+		return newArray(items...)
+	}
+	return value
+}
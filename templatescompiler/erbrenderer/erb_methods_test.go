@@ -0,0 +1,189 @@
+package erbrenderer_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ERB methods", func() {
+	Describe("integers", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("largest integer", "<%= 9223372036854775807 %>", "9223372036854775807"),
+			Entry("smallest integer", "<%= -9223372036854775807 - 1 %>", "-9223372036854775808"),
+			Entry("addition up to the largest integer", "<%= 9223372036854775806 + 1 %>", "9223372036854775807"),
+			Entry("subtraction down to the smallest integer", "<%= -9223372036854775807 - 1 %>", "-9223372036854775808"),
+			Entry("multiplication that fits", "<%= 3037000499 * 3037000499 %>", "9223372030926249001"),
+			Entry("multiplication with negative numbers", "<%= -4294967296 * 2147483648 %>", "-9223372036854775808"),
+			Entry("powers", "<%= 2 ** 10 %> <%= 2 ** 62 %> <%= 10 ** 0 %>", "1024 4611686018427387904 1"),
+			Entry("powers of negative numbers", "<%= (-2) ** 63 %> <%= (-3) ** 3 %>", "-9223372036854775808 -27"),
+			Entry("huge powers that do not grow", "<%= 1 ** 10000000000000 %> <%= 0 ** 10000000000000 %> <%= (-1) ** 10000000000001 %>", "1 0 -1"),
+			Entry("integer division rounding down", "<%= 7 / 2 %> <%= -7 / 2 %> <%= 7 / -2 %>", "3 -4 -4"),
+			Entry("modulo with the sign of the divisor", "<%= 7 % 3 %> <%= -7 % 3 %> <%= 7 % -3 %>", "1 2 -2"),
+			Entry("divmod", "<%= 7.divmod(2).inspect %> <%= (-7).divmod(2).inspect %>", "[3, 1] [-4, 1]"),
+			Entry("dividing the smallest integer", "<%= (-9223372036854775807 - 1) % -1 %> <%= (-9223372036854775807 - 1) / 1 %>", "0 -9223372036854775808"),
+			Entry("fdiv", "<%= 10.fdiv(4) %>", "2.5"),
+			Entry("bit operations", "<%= 6 & 3 %> <%= 6 | 3 %> <%= 6 ^ 3 %>", "2 7 5"),
+			Entry("shifts", "<%= 1 << 62 %> <%= 5 << -1 %> <%= 1024 >> 3 %> <%= -1 >> 70 %> <%= 1 >> 70 %>", "4611686018427387904 2 128 -1 0"),
+			Entry("negation and abs", "<%= -(-9223372036854775807) %> <%= (-5).abs %> <%= 5.magnitude %>", "9223372036854775807 5 5"),
+			Entry("succ and pred", "<%= 9223372036854775806.succ %> <%= 1.next %> <%= (-9223372036854775807).pred %>", "9223372036854775807 2 -9223372036854775808"),
+			Entry("predicates", "<%= 4.even? %> <%= 3.odd? %> <%= 0.zero? %> <%= 2.positive? %> <%= -2.negative? %> <%= 1.integer? %>", "true true true true true true"),
+			Entry("nonzero?", "<%= 0.nonzero?.inspect %> <%= 3.nonzero? %>", "nil 3"),
+			Entry("comparisons", "<%= 1 <=> 2 %> <%= 2 <=> 2 %> <%= 3 <=> 2 %> <%= (1 <=> 'a').inspect %>", "-1 0 1 nil"),
+			Entry("between? and clamp", "<%= 5.between?(1, 10) %> <%= 10.clamp(1, 5) %> <%= -1.clamp(1, 5) %> <%= 3.clamp(1, 5) %>", "true 5 1 3"),
+			Entry("radix", "<%= 255.to_s(16) %> <%= 255.to_s(2) %> <%= -255.to_s(36) %>", "ff 11111111 -73"),
+			Entry("chr and ord", "<%= 65.chr %> <%= 97.ord %>", "A 97"),
+			Entry("conversions", "<%= 2.to_f %> <%= 2.to_i %> <%= 2.to_s %> <%= 2.inspect %>", "2.0 2 2 2"),
+			Entry("times", "<%= 3.times.map { |i| i * i }.inspect %> <%= 0.times.to_a.inspect %> <%= -1.times.to_a.inspect %>", "[0, 1, 4] [] []"),
+			Entry("times with a block", "<% s = '' %><% 3.times { |i| s << i.to_s } %><%= s %>", "012"),
+			Entry("upto and downto", "<%= 1.upto(3).to_a.inspect %> <%= 3.downto(1).to_a.inspect %> <%= 3.upto(1).to_a.inspect %>", "[1, 2, 3] [3, 2, 1] []"),
+			Entry("downto with a block", "<% s = [] %><% 3.downto(1) { |n| s << n } %><%= s.join %>", "321"),
+			Entry("sum of integers", "<%= [9223372036854775806, 1].sum %>", "9223372036854775807"),
+		)
+
+		DescribeTable("passing to Ruby when results do not fit into 64 bits", func(template string) {
+			done := make(chan struct{})
+
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				expectRubyFallback(template, "integers that do not fit into 64 bits are not supported")
+			}()
+
+			// Computing the result must not take as long as the result is big
+			Eventually(done, 5*time.Second).Should(BeClosed())
+		},
+			Entry("addition", "<%= 9223372036854775807 + 1 %>"),
+			Entry("addition of negative numbers", "<%= -9223372036854775807 + -2 %>"),
+			Entry("subtraction", "<%= -9223372036854775807 - 2 %>"),
+			Entry("subtraction of negative numbers", "<%= 9223372036854775807 - -1 %>"),
+			Entry("multiplication", "<%= 4294967296 * 4294967296 %>"),
+			Entry("multiplication of properties", "<%= p('port') * 9223372036854775807 %>"),
+			Entry("multiplication of the smallest integer", "<%= (-9223372036854775807 - 1) * -1 %>"),
+			Entry("powers", "<%= 2 ** 64 %>"),
+			Entry("powers with huge exponents", "<%= 2 ** 10000000000000 %>"),
+			Entry("powers of negative numbers", "<%= (-2) ** 64 %>"),
+			Entry("division of the smallest integer", "<%= (-9223372036854775807 - 1) / -1 %>"),
+			Entry("div of the smallest integer", "<%= (-9223372036854775807 - 1).div(-1) %>"),
+			Entry("negation of the smallest integer", "<%= -(-9223372036854775807 - 1) %>"),
+			Entry("abs of the smallest integer", "<%= (-9223372036854775807 - 1).abs %>"),
+			Entry("succ", "<%= 9223372036854775807.succ %>"),
+			Entry("pred", "<%= (-9223372036854775807 - 1).pred %>"),
+			Entry("shifts", "<%= 1 << 64 %>"),
+			Entry("shifts losing bits", "<%= 3 << 62 %>"),
+			Entry("right shifts by negative amounts", "<%= 1 >> -64 %>"),
+			Entry("sums", "<%= [9223372036854775807, 1].sum %>"),
+			Entry("String#to_i", "<%= '99999999999999999999'.to_i %>"),
+			Entry("String#hex", "<%= 'ffffffffffffffffff'.hex %>"),
+			Entry("Integer()", "<%= Integer('99999999999999999999') %>"),
+			Entry("Float#to_i", "<%= 1e19.to_i %>"),
+			Entry("Float#round", "<%= 1e19.round %>"),
+			Entry("JSON.parse", "<% require 'json' %><%= JSON.parse('[12345678901234567890]') %>"),
+		)
+
+		DescribeTable("passing to Ruby for what is not supported", expectRubyFallback,
+			Entry("negative exponents", "<%= 2 ** -1 %>", "rational numbers are not supported"),
+			Entry("huge literals", "<%= 99999999999999999999 %>", "integer '99999999999999999999' does not fit into 64 bits"),
+			Entry("large times", "<%= 10000000000000.times.count %>", "iterating large ranges is not supported"),
+			Entry("large upto", "<%= 1.upto(9223372036854775807).first %>", "iterating large ranges is not supported"),
+			Entry("large downto", "<%= 0.downto(-9223372036854775807 - 1).first %>", "iterating large ranges is not supported"),
+			Entry("rounding to tens", "<%= 1234.round(-2) %>", "'round' with negative digits"),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("division by zero", "<%= 1 / 0 %>", "#<ZeroDivisionError: divided by 0>"),
+			Entry("modulo by zero", "<%= 1 % 0 %>", "#<ZeroDivisionError: divided by 0>"),
+			Entry("adding nil", "<%= 1 + nil %>", "#<TypeError: nil can't be coerced into Integer>"),
+			Entry("adding strings", "<%= 1 + '1' %>", "#<TypeError: String can't be coerced into Integer>"),
+			Entry("chr out of range", "<%= 256.chr %>", "#<RangeError: 256 out of char range>"),
+			Entry("invalid radix", "<%= 255.to_s(1) %>", "#<ArgumentError: invalid radix 1>"),
+			Entry("invalid Integer()", "<%= Integer('abc') %>", `#<ArgumentError: invalid value for Integer(): "abc">`),
+		)
+	})
+
+	Describe("floats", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("formatting", "<%= 1.5 %> <%= 100.0 %> <%= 1e20 %> <%= 1.0e-5 %> <%= 0.1 + 0.2 %>", "1.5 100.0 1.0e+20 1.0e-05 0.30000000000000004"),
+			Entry("infinity", "<%= 1.0 / 0 %> <%= -1.0 / 0 %> <%= (1.0 / 0).infinite? %>", "Infinity -Infinity 1"),
+			Entry("mixed arithmetic", "<%= 1 + 0.5 %> <%= 3 * 1.5 %> <%= 7 / 2.0 %> <%= 2 ** 0.5 %>", "1.5 4.5 3.5 1.4142135623730951"),
+			Entry("modulo", "<%= 10.0 % 3 %> <%= -7.5 % 2 %>", "1.0 0.5"),
+			Entry("div", "<%= 7.5.div(2) %>", "3"),
+			Entry("rounding", "<%= 3.7.floor %> <%= 3.2.ceil %> <%= 3.5.round %> <%= 2.5.round %> <%= (-3.5).round %>", "3 4 4 3 -4"),
+			Entry("rounding to digits", "<%= 3.14159.round(2) %> <%= 3.14159.floor(3) %> <%= 3.14159.ceil(1) %>", "3.14 3.141 3.2"),
+			Entry("conversions", "<%= 1.9.to_i %> <%= -1.9.to_i %> <%= 1.5.to_f %> <%= 1.5.to_s %>", "1 -1 1.5 1.5"),
+			Entry("predicates", "<%= 1.5.finite? %> <%= 0.0.zero? %> <%= 1.5.integer? %> <%= 1.5.nan? %>", "true true false false"),
+			Entry("comparison with integers", "<%= 2.0 == 2 %> <%= 1.5 < 2 %> <%= 2.5 <=> 2 %>", "true true 1"),
+			Entry("abs", "<%= -1.5.abs %>", "1.5"),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("converting infinity", "<%= (1.0 / 0).to_i %>", "#<FloatDomainError: Infinity>"),
+			Entry("div by zero", "<%= 1.5.div(0) %>", "#<ZeroDivisionError: divided by 0>"),
+		)
+	})
+
+	Describe("strings", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("case", "<%= 'hello'.capitalize %> <%= 'Hello'.swapcase %> <%= 'MiXed'.upcase %> <%= 'MiXed'.downcase %>", "Hello hELLO MIXED mixed"),
+			Entry("reverse", "<%= 'abc'.reverse %>", "cba"),
+			Entry("length", "<%= 'abc'.length %> <%= '日本'.size %> <%= '日本'.bytesize %>", "3 2 6"),
+			Entry("empty?", "<%= 'x'.empty? %> <%= ''.empty? %>", "false true"),
+			Entry("concatenation and repetition", "<%= 'a' + 'b' %> <%= 'ab' * 3 %>", "ab ababab"),
+			Entry("stripping", "[<%= '  x  '.strip %>|<%= '  x  '.lstrip %>|<%= '  x  '.rstrip %>]", "[x|x  |  x]"),
+			Entry("chomp and chop", `<%= "line\n".chomp %>|<%= "file.yml".chomp(".yml") %>|<%= "abc".chop %>`, "line|file|ab"),
+			Entry("justifying", "<%= 'abc'.center(7, '*') %>|<%= 'abc'.ljust(5) %>|<%= 'abc'.rjust(5, '0') %>", "**abc**|abc  |00abc"),
+			Entry("tr, squeeze, delete and count", "<%= 'a-b-c'.tr('-', '_') %> <%= 'aaabbb'.squeeze %> <%= 'hello'.delete('l') %> <%= 'tic-tac-toe'.count('t') %>", "a_b_c ab heo 3"),
+			Entry("tr with ranges", "<%= 'hello'.tr('a-y', 'b-z') %>", "ifmmp"),
+			Entry("predicates", "<%= 'hello'.start_with?('hell') %> <%= 'x.yml'.end_with?('.json', '.yml') %> <%= 'abc'.include?('d') %>", "true true false"),
+			Entry("split on whitespace", "<%= ' a b  c '.split.inspect %>", `["a", "b", "c"]`),
+			Entry("split with a limit", "<%= 'a:b:c'.split(':', 2).inspect %>", `["a", "b:c"]`),
+			Entry("split with a regexp", "<%= 'a1b22c'.split(/\\d+/).inspect %>", `["a", "b", "c"]`),
+			Entry("split dropping trailing empty strings", "<%= 'a,b,,'.split(',').inspect %>", `["a", "b"]`),
+			Entry("lines", `<%= "l1\nl2".lines.inspect %>`, `["l1\n", "l2"]`),
+			Entry("chars and bytes", "<%= 'abc'.chars.inspect %> <%= 'ab'.bytes.inspect %>", `["a", "b", "c"] [97, 98]`),
+			Entry("indexing", "<%= 'abcdef'[1] %> <%= 'abcdef'[1, 3] %> <%= 'abcdef'[2..-1] %> <%= 'abcdef'[-2..-1] %> <%= 'abc'[5].inspect %>", "b bcd cdef ef nil"),
+			Entry("slice", "<%= 'hello world'.slice(0, 5) %>", "hello"),
+			Entry("index and rindex", "<%= 'hello'.index('l') %> <%= 'hello'.rindex('l') %> <%= 'hello'.index('z').inspect %>", "2 3 nil"),
+			Entry("sub and gsub", "<%= 'a-b-c'.sub('-', '+') %> <%= 'a-b-c'.gsub('-', '+') %>", "a+b-c a+b+c"),
+			Entry("gsub with a block", "<%= 'CamelCase'.gsub(/([A-Z])/) { |m| '_' + m.downcase } %>", "_camel_case"),
+			Entry("sub with back references", `<%= "key=value".sub(/(\w+)=(\w+)/, '\2=\1') %>`, "value=key"),
+			Entry("gsub with a hash", "<%= 'cat'.gsub(/[aeiou]/, 'a' => '4') %>", "c4t"),
+			Entry("scan", "<%= 'a1b2c3'.scan(/\\d/).join(',') %> <%= 'k1=v1,k2=v2'.scan(/(\\w+)=(\\w+)/).inspect %>", `1,2,3 [["k1", "v1"], ["k2", "v2"]]`),
+			Entry("matching", `<%= "abc" =~ /z/ ? 'yes' : 'no' %> <%= "hello"[/l+/] %> <%= "abc".match?(/b/) %>`, "no ll true"),
+			Entry("match data", `<% m = "v1.2.3".match(/(\d+)\.(\d+)/) %><%= m[0] %> <%= m[1] %> <%= m.captures.inspect %>`, `1.2 1 ["1", "2"]`),
+			Entry("named captures", `<% m = "user@host".match(/(?<user>\w+)@(?<host>\w+)/) %><%= m[:user] %> <%= m['host'] %>`, "user host"),
+			Entry("format operator", `<%= "%.2f" % 3.14159 %> <%= "%s-%s" % ['a', 1] %> <%= "%05d" % 42 %> <%= "%x" % 255 %>`, "3.14 a-1 00042 ff"),
+			Entry("conversions to numbers", "<%= '0x1A'.hex %> <%= '777'.oct %> <%= '12abc'.to_i %> <%= '3.5kg'.to_f %> <%= 'ff'.to_i(16) %> <%= 'abc'.to_i %>", "26 511 12 3.5 255 0"),
+			Entry("conversions to symbols", "<%= 'a,b'.split(',').map(&:to_sym).inspect %> <%= 'abc'.to_sym.inspect %>", "[:a, :b] :abc"),
+			Entry("comparison", "<%= 'hello' <=> 'help' %> <%= 'b' > 'a' %> <%= 'abc' == 'abc' %> <%= 'abc'.eql?('abc') %>", "-1 true true true"),
+			Entry("casecmp", "<%= 'abc'.casecmp('ABC') %> <%= 'abc'.casecmp?('ABC') %>", "0 true"),
+			Entry("inspect with escapes", `<%= "a\tb\"c".inspect %>`, `"a\tb\"c"`),
+			Entry("each_char", "<%= 'hello'.each_char.to_a.length %>", "5"),
+			Entry("bang methods on variables", "<% s = 'abc' %><% s.upcase! %><% t = ' a ' %><% t.strip! %><%= s %><%= t %>", "ABCa"),
+			Entry("appending to variables", "<% s = 'a' %><% s << 'b' %><% s.concat('c') %><% s += 'd' %><%= s %>", "abcd"),
+			Entry("joining words", "<%= 'snake_case_word'.split('_').map(&:capitalize).join %>", "SnakeCaseWord"),
+			Entry("interpolation of non-strings", `<%= "#{nil}|#{1.5}|#{[1, 'a']}|#{:sym}" %>`, `|1.5|[1, "a"]|sym`),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("adding integers", "<%= 'a' + 1 %>", "#<TypeError: no implicit conversion of Integer into String>"),
+			Entry("ord of empty strings", "<%= ''.ord %>", "#<ArgumentError: empty string>"),
+		)
+	})
+
+	Describe("nil, booleans and symbols", func() {
+		DescribeTable("computing like Ruby", expectNativeResult,
+			Entry("nil", "[<%= nil %>] <%= nil.to_a.inspect %> <%= nil.to_s.empty? %> <%= nil.to_i %> <%= nil.inspect %> <%= nil.nil? %>", "[] [] true 0 nil true"),
+			Entry("booleans", "<%= true & false %> <%= true | false %> <%= true ^ true %> <%= !true %> <%= false.to_s %>", "false true false false false"),
+			Entry("symbols", "<%= :abc %> <%= :abc.inspect %> <%= :b <=> :a %> <%= :abc.length %>", "abc :abc 1 3"),
+			Entry("object methods", "<%= 1.is_a?(Integer) %> <%= 'a'.kind_of?(String) %> <%= [].instance_of?(Array) %> <%= 1.class %> <%= nil.class %>", "true true true Integer NilClass"),
+			Entry("frozen values", "<%= 'a'.dup %> <%= [1].clone.inspect %> <%= 1.itself %> <%= 'a'.then { |s| s * 2 } %>", "a [1] 1 aa"),
+			Entry("tap", "<%= [1].tap { |a| a << 2 }.inspect %>", "[1, 2]"),
+			Entry("nil?", "<%= 1.nil? %> <%= [nil].first.nil? %>", "false true"),
+			Entry("equality", "<%= 1 == 1.0 %> <%= 1.eql?(1.0) %> <%= 'a' != 'b' %> <%= nil == false %>", "true false true false"),
+		)
+	})
+})
@@ -0,0 +1,1380 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type erbNode interface {
+	nodeLine() int
+}
+
+type nodeBase struct{ line int }
+
+func (n nodeBase) nodeLine() int { return n.line }
+
+type textNode struct {
+	nodeBase
+	text string
+}
+
+type outputNode struct {
+	nodeBase
+	expr erbNode
+}
+
+// literalNode holds nil, booleans, numbers, symbols and plain strings.
+type literalNode struct {
+	nodeBase
+	value interface{}
+}
+
+// stringNode is an interpolated string or symbol.
+type stringNode struct {
+	nodeBase
+	parts  []erbNode
+	symbol bool
+}
+
+type regexpNode struct {
+	nodeBase
+	parts []erbNode
+	flags string
+}
+
+type arrayNode struct {
+	nodeBase
+	elements []erbNode
+}
+
+type hashNode struct {
+	nodeBase
+	keys   []erbNode
+	values []erbNode
+}
+
+type rangeNode struct {
+	nodeBase
+	from, to  erbNode
+	exclusive bool
+}
+
+type splatNode struct {
+	nodeBase
+	value erbNode
+}
+
+type localNode struct {
+	nodeBase
+	name string
+}
+
+type constNode struct {
+	nodeBase
+	name string
+}
+
+// callNode calls a method on receiver, or on the template context when
+// receiver is nil. Operators and indexing are method calls as well.
+type callNode struct {
+	nodeBase
+	receiver erbNode
+	name     string
+	args     []erbNode
+	block    *blockNode
+	blockArg erbNode
+	safeNav  bool
+}
+
+type blockNode struct {
+	nodeBase
+	params []blockParam
+	body   []erbNode
+}
+
+type blockParam struct {
+	name   string
+	nested []blockParam
+}
+
+// assignNode assigns to a local or to an index; op is set for '+=' and the like.
+type assignNode struct {
+	nodeBase
+	target erbNode
+	op     string
+	value  erbNode
+}
+
+type andNode struct {
+	nodeBase
+	left, right erbNode
+}
+
+type orNode struct {
+	nodeBase
+	left, right erbNode
+}
+
+type notNode struct {
+	nodeBase
+	expr erbNode
+}
+
+type ifNode struct {
+	nodeBase
+	cond     erbNode
+	thenBody []erbNode
+	elseBody []erbNode
+	negate   bool
+}
+
+type caseNode struct {
+	nodeBase
+	subject  erbNode
+	whens    []whenClause
+	elseBody []erbNode
+}
+
+type whenClause struct {
+	values []erbNode
+	body   []erbNode
+}
+
+type seqNode struct {
+	nodeBase
+	body []erbNode
+}
+
+// jumpNode is 'next' or 'break' inside a block.
+type jumpNode struct {
+	nodeBase
+	kind  string
+	value erbNode
+}
+
+type parserScope struct {
+	locals map[string]bool
+	parent *parserScope
+}
+
+func newParserScope(parent *parserScope) *parserScope {
+	return &parserScope{locals: map[string]bool{}, parent: parent}
+}
+
+func (s *parserScope) isLocal(name string) bool {
+	for scope := s; scope != nil; scope = scope.parent {
+		if scope.locals[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *parserScope) declare(name string) {
+	if !s.isLocal(name) {
+		s.locals[name] = true
+	}
+}
+
+// erbParser parses the Ruby subset used by job templates. Anything outside
+// of it is reported as an unsupportedError so that Ruby can render the
+// template instead.
+type erbParser struct {
+	tokens []erbToken
+	pos    int
+	scope  *parserScope
+
+	// noDo is set while parsing the arguments of a command call so that a
+	// 'do' block binds to the command rather than to its last argument
+	noDo bool
+}
+
+func newERBParser(tokens []erbToken, scope *parserScope) *erbParser {
+	return &erbParser{tokens: tokens, scope: scope}
+}
+
+var binaryPrecedence = map[string]int{
+	"||": 1, "&&": 2,
+	"<=>": 4, "==": 4, "===": 4, "!=": 4, "=~": 4, "!~": 4,
+	"<": 5, "<=": 5, ">": 5, ">=": 5,
+	"|": 6, "^": 6, "&": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+var assignOperators = map[string]string{
+	"=": "", "+=": "+", "-=": "-", "*=": "*", "/=": "/", "%=": "%", "**=": "**",
+	"||=": "||", "&&=": "&&", "<<=": "<<", ">>=": ">>",
+}
+
+func (p *erbParser) peek() erbToken { return p.tokens[p.pos] }
+
+func (p *erbParser) peekAt(offset int) erbToken {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *erbParser) next() erbToken {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *erbParser) isOp(token erbToken, ops ...string) bool {
+	if token.kind != tokenOp {
+		return false
+	}
+	for _, op := range ops {
+		if token.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *erbParser) isKeyword(token erbToken, keywords ...string) bool {
+	if token.kind != tokenIdent {
+		return false
+	}
+	for _, keyword := range keywords {
+		if token.text == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *erbParser) skipNewlines() {
+	for p.peek().kind == tokenNewline {
+		p.next()
+	}
+}
+
+func (p *erbParser) errorf(token erbToken, format string, args ...interface{}) error {
+	line := token.line
+	if line == 0 && p.pos > 0 {
+		line = p.tokens[p.pos-1].line
+	}
+	return &unsupportedError{line: line, reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *erbParser) unexpected(token erbToken) error {
+	switch token.kind {
+	case tokenEOF:
+		return p.errorf(token, "unexpected end of template")
+	case tokenText:
+		return p.errorf(token, "unexpected template text")
+	case tokenNewline:
+		return p.errorf(token, "unexpected end of line")
+	case tokenOutputBegin, tokenOutputEnd:
+		return p.errorf(token, "unexpected output tag")
+	case tokenString, tokenSymbol, tokenRegexp, tokenWords:
+		return p.errorf(token, "unexpected literal")
+	}
+	return p.errorf(token, "unexpected '%s'", token.text)
+}
+
+func (p *erbParser) expectOp(op string) error {
+	token := p.next()
+	if !p.isOp(token, op) {
+		return p.unexpected(token)
+	}
+	return nil
+}
+
+func (p *erbParser) expectKeyword(keyword string) error {
+	token := p.next()
+	if !p.isKeyword(token, keyword) {
+		return p.unexpected(token)
+	}
+	return nil
+}
+
+func (p *erbParser) parseProgram() ([]erbNode, error) {
+	body, err := p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.peek(); token.kind != tokenEOF {
+		return nil, p.unexpected(token)
+	}
+
+	return body, nil
+}
+
+// parseStatements parses statements up to the end of input, an output tag or
+// one of the terminating keywords or closing brackets.
+func (p *erbParser) parseStatements(terminators ...string) ([]erbNode, error) {
+	var body []erbNode
+
+	for {
+		p.skipNewlines()
+
+		if p.atTerminator(terminators) {
+			return body, nil
+		}
+
+		statement, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, statement)
+
+		// Text and output segments end statements of their own.
+		switch statement.(type) {
+		case *textNode, *outputNode:
+			continue
+		}
+
+		token := p.peek()
+		switch token.kind {
+		case tokenNewline, tokenText, tokenOutputBegin:
+		default:
+			if !p.atTerminator(terminators) {
+				return nil, p.unexpected(token)
+			}
+		}
+	}
+}
+
+func (p *erbParser) atTerminator(terminators []string) bool {
+	token := p.peek()
+
+	switch token.kind {
+	case tokenEOF, tokenOutputEnd:
+		return true
+	case tokenIdent, tokenOp:
+		for _, terminator := range terminators {
+			if token.text == terminator {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p *erbParser) parseStatement() (erbNode, error) {
+	token := p.peek()
+
+	switch token.kind {
+	case tokenText:
+		p.next()
+		return &textNode{nodeBase{token.line}, token.text}, nil
+
+	case tokenOutputBegin:
+		p.next()
+		body, err := p.parseStatements()
+		if err != nil {
+			return nil, err
+		}
+		if end := p.next(); end.kind != tokenOutputEnd {
+			return nil, p.unexpected(end)
+		}
+		return &outputNode{nodeBase{token.line}, &seqNode{nodeBase{token.line}, body}}, nil
+	}
+
+	statement, err := p.parseExprStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token = p.peek()
+
+		switch {
+		case p.isKeyword(token, "if", "unless"):
+			p.next()
+			cond, err := p.parseExprStatement()
+			if err != nil {
+				return nil, err
+			}
+			statement = &ifNode{
+				nodeBase: nodeBase{token.line},
+				cond:     cond,
+				thenBody: []erbNode{statement},
+				negate:   token.text == "unless",
+			}
+
+		case p.isKeyword(token, "while", "until", "rescue"):
+			return nil, p.errorf(token, "'%s' modifiers are not supported", token.text)
+
+		default:
+			return statement, nil
+		}
+	}
+}
+
+// parseExprStatement parses expressions joined by 'and', 'or' and 'not'.
+func (p *erbParser) parseExprStatement() (erbNode, error) {
+	left, err := p.parseNotExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+		if !p.isKeyword(token, "and", "or") {
+			return left, nil
+		}
+		p.next()
+		p.skipNewlines()
+
+		right, err := p.parseNotExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if token.text == "and" {
+			left = &andNode{nodeBase{token.line}, left, right}
+		} else {
+			left = &orNode{nodeBase{token.line}, left, right}
+		}
+	}
+}
+
+func (p *erbParser) parseNotExpr() (erbNode, error) {
+	token := p.peek()
+	if p.isKeyword(token, "not") {
+		p.next()
+		expr, err := p.parseNotExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{nodeBase{token.line}, expr}, nil
+	}
+
+	return p.parseExpr()
+}
+
+func (p *erbParser) parseExpr() (erbNode, error) {
+	left, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	if token.kind != tokenOp {
+		return left, nil
+	}
+	op, isAssign := assignOperators[token.text]
+	if !isAssign {
+		return left, nil
+	}
+
+	var target erbNode
+	switch node := left.(type) {
+	case *localNode:
+		target = node
+	case *callNode:
+		switch {
+		case node.receiver == nil && len(node.args) == 0 && node.block == nil && node.blockArg == nil:
+			p.scope.declare(node.name)
+			target = &localNode{node.nodeBase, node.name}
+		case node.name == "[]" && node.receiver != nil:
+			target = node
+		default:
+			return nil, p.errorf(token, "assigning to attributes is not supported")
+		}
+	default:
+		return nil, p.errorf(token, "unsupported assignment")
+	}
+
+	p.next()
+	p.skipNewlines()
+
+	value, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isOp(p.peek(), ",") {
+		return nil, p.errorf(p.peek(), "multiple assignment is not supported")
+	}
+
+	return &assignNode{nodeBase{token.line}, target, op, value}, nil
+}
+
+func (p *erbParser) parseTernary() (erbNode, error) {
+	cond, err := p.parseRange()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	if !p.isOp(token, "?") {
+		return cond, nil
+	}
+	p.next()
+	p.skipNewlines()
+
+	thenExpr, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipNewlines()
+	if err := p.expectOp(":"); err != nil {
+		return nil, err
+	}
+	p.skipNewlines()
+
+	elseExpr, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ifNode{
+		nodeBase: nodeBase{token.line},
+		cond:     cond,
+		thenBody: []erbNode{thenExpr},
+		elseBody: []erbNode{elseExpr},
+	}, nil
+}
+
+func (p *erbParser) parseRange() (erbNode, error) {
+	from, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	if !p.isOp(token, "..", "...") {
+		return from, nil
+	}
+	p.next()
+
+	to, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rangeNode{nodeBase{token.line}, from, to, token.text == "..."}, nil
+}
+
+func (p *erbParser) parseBinary(minPrecedence int) (erbNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+		if token.kind != tokenOp {
+			return left, nil
+		}
+		precedence, ok := binaryPrecedence[token.text]
+		if !ok || precedence < minPrecedence {
+			return left, nil
+		}
+		p.next()
+		p.skipNewlines()
+
+		right, err := p.parseBinary(precedence + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch token.text {
+		case "&&":
+			left = &andNode{nodeBase{token.line}, left, right}
+		case "||":
+			left = &orNode{nodeBase{token.line}, left, right}
+		case "!=":
+			left = &notNode{nodeBase{token.line}, &callNode{nodeBase: nodeBase{token.line}, receiver: left, name: "==", args: []erbNode{right}}}
+		case "!~":
+			left = &notNode{nodeBase{token.line}, &callNode{nodeBase: nodeBase{token.line}, receiver: left, name: "=~", args: []erbNode{right}}}
+		default:
+			left = &callNode{nodeBase: nodeBase{token.line}, receiver: left, name: token.text, args: []erbNode{right}}
+		}
+	}
+}
+
+func (p *erbParser) parseUnary() (erbNode, error) {
+	token := p.peek()
+
+	switch {
+	case p.isOp(token, "!"):
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{nodeBase{token.line}, expr}, nil
+
+	case p.isOp(token, "-"):
+		p.next()
+		if next := p.peek(); (next.kind == tokenInt || next.kind == tokenFloat) && !next.spaceBefore && !p.isOp(p.peekAt(1), "**") {
+			p.next()
+			literal, err := p.parseNumber(next, "-")
+			if err != nil {
+				return nil, err
+			}
+			return p.parsePostfixOf(literal)
+		}
+		expr, err := p.parsePow()
+		if err != nil {
+			return nil, err
+		}
+		return &callNode{nodeBase: nodeBase{token.line}, receiver: expr, name: "-@"}, nil
+
+	case p.isOp(token, "+"):
+		p.next()
+		return p.parsePow()
+
+	case p.isKeyword(token, "not"):
+		return p.parseNotExpr()
+	}
+
+	return p.parsePow()
+}
+
+func (p *erbParser) parsePow() (erbNode, error) {
+	base, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	if !p.isOp(token, "**") {
+		return base, nil
+	}
+	p.next()
+	p.skipNewlines()
+
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &callNode{nodeBase: nodeBase{token.line}, receiver: base, name: "**", args: []erbNode{exponent}}, nil
+}
+
+func (p *erbParser) parsePostfix() (erbNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parsePostfixOf(node)
+}
+
+func (p *erbParser) parsePostfixOf(node erbNode) (erbNode, error) {
+	for {
+		token := p.peek()
+
+		switch {
+		case p.isOp(token, ".", "&."):
+			p.next()
+			p.skipNewlines()
+
+			nameToken := p.next()
+			switch nameToken.kind {
+			case tokenIdent, tokenConst:
+			case tokenLabel:
+				return nil, p.errorf(nameToken, "unexpected label")
+			default:
+				return nil, p.unexpected(nameToken)
+			}
+
+			call := &callNode{
+				nodeBase: nodeBase{nameToken.line},
+				receiver: node,
+				name:     nameToken.text,
+				safeNav:  token.text == "&.",
+			}
+
+			if p.isOp(p.peek(), "=") && !p.isOp(p.peekAt(1), "=") {
+				return nil, p.errorf(p.peek(), "assigning to attributes is not supported")
+			}
+
+			if err := p.parseCallArgs(call); err != nil {
+				return nil, err
+			}
+			if err := p.parseBlock(call); err != nil {
+				return nil, err
+			}
+
+			node = call
+
+		case p.isOp(token, "[") && !token.spaceBefore:
+			p.next()
+			args, blockArg, err := p.parseArgList("]")
+			if err != nil {
+				return nil, err
+			}
+			if blockArg != nil {
+				return nil, p.errorf(token, "unexpected block argument")
+			}
+			node = &callNode{nodeBase: nodeBase{token.line}, receiver: node, name: "[]", args: args}
+
+		case p.isOp(token, "::"):
+			p.next()
+			nameToken := p.next()
+			constant, ok := node.(*constNode)
+			if !ok || nameToken.kind != tokenConst {
+				return nil, p.errorf(token, "'::' is only supported between constants")
+			}
+			node = &constNode{constant.nodeBase, constant.name + "::" + nameToken.text}
+
+		default:
+			return node, nil
+		}
+	}
+}
+
+// canStartCommandArg reports whether token starts the first argument of a
+// method called without parentheses, as in 'p "name"'.
+func (p *erbParser) canStartCommandArg(token erbToken) bool {
+	if !token.spaceBefore {
+		return false
+	}
+
+	switch token.kind {
+	case tokenInt, tokenFloat, tokenString, tokenSymbol, tokenRegexp, tokenWords, tokenConst, tokenLabel:
+		return true
+	case tokenIdent:
+		return !rubyKeywords[token.text] || p.isKeyword(token, "nil", "true", "false", "not")
+	case tokenOp:
+		next := p.peekAt(1)
+		switch token.text {
+		case "[", "(", "->":
+			return true
+		case "!", "-", "*", "&", "::":
+			return !next.spaceBefore && next.kind != tokenNewline
+		}
+	}
+
+	return false
+}
+
+func (p *erbParser) parseCallArgs(call *callNode) error {
+	token := p.peek()
+
+	switch {
+	case p.isOp(token, "(") && !token.spaceBefore:
+		p.next()
+		args, blockArg, err := p.parseArgList(")")
+		if err != nil {
+			return err
+		}
+		call.args, call.blockArg = args, blockArg
+
+	case p.canStartCommandArg(token):
+		noDo := p.noDo
+		p.noDo = true
+		args, blockArg, err := p.parseArgs(false)
+		p.noDo = noDo
+		if err != nil {
+			return err
+		}
+		call.args, call.blockArg = args, blockArg
+	}
+
+	return nil
+}
+
+func (p *erbParser) parseArgList(closer string) ([]erbNode, erbNode, error) {
+	noDo := p.noDo
+	p.noDo = false
+	defer func() { p.noDo = noDo }()
+
+	p.skipNewlines()
+	if p.isOp(p.peek(), closer) {
+		p.next()
+		return nil, nil, nil
+	}
+
+	args, blockArg, err := p.parseArgs(true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.skipNewlines()
+	if err := p.expectOp(closer); err != nil {
+		return nil, nil, err
+	}
+
+	return args, blockArg, nil
+}
+
+// parseArgs parses comma separated arguments. Trailing 'key => value' and
+// 'key: value' pairs are collected into a single hash argument.
+func (p *erbParser) parseArgs(multiline bool) ([]erbNode, erbNode, error) {
+	var args []erbNode
+	var blockArg erbNode
+	var hash *hashNode
+
+	for {
+		if multiline {
+			p.skipNewlines()
+		}
+
+		token := p.peek()
+
+		switch {
+		case token.kind == tokenLabel:
+			p.next()
+			p.skipNewlines()
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, nil, err
+			}
+			if hash == nil {
+				hash = &hashNode{nodeBase: nodeBase{token.line}}
+				args = append(args, hash)
+			}
+			hash.keys = append(hash.keys, &literalNode{nodeBase{token.line}, rbSymbol(token.text)})
+			hash.values = append(hash.values, value)
+
+		case p.isOp(token, "&"):
+			p.next()
+			symbol := p.next()
+			if symbol.kind != tokenSymbol || len(symbol.parts) != 1 || symbol.parts[0].isCode {
+				return nil, nil, p.errorf(token, "only symbols are supported as block arguments")
+			}
+			blockArg = &literalNode{nodeBase{token.line}, rbSymbol(symbol.parts[0].text)}
+
+		case p.isOp(token, "*"):
+			p.next()
+			value, err := p.parseTernary()
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, &splatNode{nodeBase{token.line}, value})
+
+		case p.isOp(token, "**"):
+			return nil, nil, p.errorf(token, "double splat arguments are not supported")
+
+		default:
+			value, err := p.parseNotExpr()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if arrow := p.peek(); p.isOp(arrow, "=>") {
+				p.next()
+				p.skipNewlines()
+				hashValue, err := p.parseExpr()
+				if err != nil {
+					return nil, nil, err
+				}
+				if hash == nil {
+					hash = &hashNode{nodeBase: nodeBase{token.line}}
+					args = append(args, hash)
+				}
+				hash.keys = append(hash.keys, value)
+				hash.values = append(hash.values, hashValue)
+			} else {
+				if hash != nil {
+					return nil, nil, p.errorf(token, "arguments after a hash argument are not supported")
+				}
+				args = append(args, value)
+			}
+		}
+
+		if !p.isOp(p.peek(), ",") {
+			return args, blockArg, nil
+		}
+		p.next()
+		p.skipNewlines()
+	}
+}
+
+func (p *erbParser) parseBlock(call *callNode) error {
+	token := p.peek()
+
+	var closer string
+	switch {
+	case p.isOp(token, "{"):
+		closer = "}"
+	case p.isKeyword(token, "do") && !p.noDo:
+		closer = "end"
+	default:
+		return nil
+	}
+
+	if call.blockArg != nil {
+		return p.errorf(token, "both block argument and block given")
+	}
+	p.next()
+
+	noDo := p.noDo
+	p.noDo = false
+	p.scope = newParserScope(p.scope)
+	defer func() {
+		p.scope = p.scope.parent
+		p.noDo = noDo
+	}()
+
+	params, err := p.parseBlockParams()
+	if err != nil {
+		return err
+	}
+
+	body, err := p.parseStatements(closer)
+	if err != nil {
+		return err
+	}
+
+	end := p.next()
+	if end.text != closer || (end.kind != tokenOp && end.kind != tokenIdent) {
+		return p.unexpected(end)
+	}
+
+	call.block = &blockNode{nodeBase{token.line}, params, body}
+
+	return nil
+}
+
+func (p *erbParser) parseBlockParams() ([]blockParam, error) {
+	p.skipNewlines()
+
+	token := p.peek()
+	if p.isOp(token, "||") {
+		p.next()
+		return nil, nil
+	}
+	if !p.isOp(token, "|") {
+		return nil, nil
+	}
+	p.next()
+
+	params, err := p.parseParamList("|")
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+func (p *erbParser) parseParamList(closer string) ([]blockParam, error) {
+	var params []blockParam
+
+	for {
+		token := p.next()
+
+		switch {
+		case token.kind == tokenIdent && !rubyKeywords[token.text]:
+			p.scope.locals[token.text] = true
+			params = append(params, blockParam{name: token.text})
+
+		case p.isOp(token, "("):
+			nested, err := p.parseParamList(")")
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, blockParam{nested: nested})
+
+		default:
+			return nil, p.errorf(token, "unsupported block parameter")
+		}
+
+		separator := p.next()
+		switch {
+		case p.isOp(separator, closer):
+			return params, nil
+		case p.isOp(separator, ","):
+			if p.isOp(p.peek(), closer) {
+				// '|a, |' takes the first element only
+				p.next()
+				params = append(params, blockParam{name: "_"})
+				return params, nil
+			}
+		default:
+			return nil, p.errorf(separator, "unsupported block parameter")
+		}
+	}
+}
+
+func (p *erbParser) parseNumber(token erbToken, sign string) (erbNode, error) {
+	if token.kind == tokenFloat {
+		value, err := strconv.ParseFloat(sign+token.text, 64)
+		if err != nil {
+			return nil, p.errorf(token, "invalid float '%s'", token.text)
+		}
+		return &literalNode{nodeBase{token.line}, value}, nil
+	}
+
+	value, err := strconv.ParseInt(sign+token.text, 0, 64)
+	if isIntegerRangeError(err) {
+		return nil, p.errorf(token, "integer '%s' does not fit into 64 bits", token.text)
+	} else if err != nil {
+		return nil, p.errorf(token, "unsupported integer '%s'", token.text)
+	}
+	return &literalNode{nodeBase{token.line}, value}, nil
+}
+
+func (p *erbParser) parsePrimary() (erbNode, error) {
+	token := p.next()
+	base := nodeBase{token.line}
+
+	switch token.kind {
+	case tokenInt, tokenFloat:
+		return p.parseNumber(token, "")
+
+	case tokenString:
+		node, err := p.parseStringParts(token, false)
+		if err != nil {
+			return nil, err
+		}
+		// Adjacent string literals are concatenated
+		for next := p.peek(); next.kind == tokenString; next = p.peek() {
+			p.next()
+			more, err := p.parseStringParts(next, false)
+			if err != nil {
+				return nil, err
+			}
+			node = &stringNode{nodeBase: base, parts: []erbNode{node, more}}
+		}
+		return node, nil
+
+	case tokenSymbol:
+		return p.parseStringParts(token, true)
+
+	case tokenRegexp:
+		node, err := p.parseStringParts(token, false)
+		if err != nil {
+			return nil, err
+		}
+		return &regexpNode{base, []erbNode{node}, token.flags}, nil
+
+	case tokenWords:
+		array := &arrayNode{nodeBase: base}
+		for _, word := range token.words {
+			if token.flags == "symbols" {
+				array.elements = append(array.elements, &literalNode{base, rbSymbol(word)})
+			} else {
+				array.elements = append(array.elements, &literalNode{base, word})
+			}
+		}
+		return array, nil
+
+	case tokenConst:
+		if p.isOp(p.peek(), "(") && !p.peek().spaceBefore {
+			// Conversion functions such as Integer("1")
+			call := &callNode{nodeBase: base, name: token.text}
+			if err := p.parseCallArgs(call); err != nil {
+				return nil, err
+			}
+			return call, nil
+		}
+		return &constNode{base, token.text}, nil
+
+	case tokenIdent:
+		if rubyKeywords[token.text] {
+			return p.parseKeyword(token)
+		}
+
+		if p.scope.isLocal(token.text) && !(p.isOp(p.peek(), "(") && !p.peek().spaceBefore) {
+			return &localNode{base, token.text}, nil
+		}
+
+		call := &callNode{nodeBase: base, name: token.text}
+		if err := p.parseCallArgs(call); err != nil {
+			return nil, err
+		}
+		if err := p.parseBlock(call); err != nil {
+			return nil, err
+		}
+		return call, nil
+
+	case tokenOp:
+		switch token.text {
+		case "(":
+			noDo := p.noDo
+			p.noDo = false
+			body, err := p.parseStatements(")")
+			p.noDo = noDo
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			if len(body) == 1 {
+				return body[0], nil
+			}
+			return &seqNode{base, body}, nil
+
+		case "[":
+			args, blockArg, err := p.parseArgList("]")
+			if err != nil {
+				return nil, err
+			}
+			if blockArg != nil {
+				return nil, p.errorf(token, "unexpected block argument")
+			}
+			return &arrayNode{base, args}, nil
+
+		case "{":
+			return p.parseHash(token)
+		}
+	}
+
+	return nil, p.unexpected(token)
+}
+
+func (p *erbParser) parseKeyword(token erbToken) (erbNode, error) {
+	base := nodeBase{token.line}
+
+	switch token.text {
+	case "nil":
+		return &literalNode{base, nil}, nil
+	case "true":
+		return &literalNode{base, true}, nil
+	case "false":
+		return &literalNode{base, false}, nil
+
+	case "if", "unless":
+		return p.parseIf(token)
+
+	case "case":
+		return p.parseCase(token)
+
+	case "not":
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{base, expr}, nil
+
+	case "next", "break":
+		node := &jumpNode{nodeBase: base, kind: token.text}
+		if !p.atStatementEnd() {
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			node.value = value
+		}
+		return node, nil
+	}
+
+	return nil, p.errorf(token, "'%s' is not supported", token.text)
+}
+
+func (p *erbParser) atStatementEnd() bool {
+	token := p.peek()
+
+	switch token.kind {
+	case tokenNewline, tokenEOF, tokenText, tokenOutputBegin, tokenOutputEnd:
+		return true
+	case tokenIdent:
+		return p.isKeyword(token, "if", "unless", "end", "else", "elsif", "when", "and", "or", "then", "do")
+	case tokenOp:
+		return p.isOp(token, "}", ")", "]", ";")
+	}
+
+	return false
+}
+
+func (p *erbParser) parseIf(token erbToken) (erbNode, error) {
+	cond, err := p.parseExprStatement()
+	if err != nil {
+		return nil, err
+	}
+	if p.isKeyword(p.peek(), "then") {
+		p.next()
+	}
+
+	node := &ifNode{nodeBase: nodeBase{token.line}, cond: cond, negate: token.text == "unless"}
+
+	node.thenBody, err = p.parseStatements("elsif", "else", "end")
+	if err != nil {
+		return nil, err
+	}
+
+	next := p.next()
+	switch {
+	case p.isKeyword(next, "elsif") && token.text == "if":
+		elsif, err := p.parseIf(next)
+		if err != nil {
+			return nil, err
+		}
+		node.elseBody = []erbNode{elsif}
+		return node, nil
+
+	case p.isKeyword(next, "else"):
+		node.elseBody, err = p.parseStatements("end")
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("end"); err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case p.isKeyword(next, "end"):
+		return node, nil
+	}
+
+	return nil, p.unexpected(next)
+}
+
+func (p *erbParser) parseCase(token erbToken) (erbNode, error) {
+	node := &caseNode{nodeBase: nodeBase{token.line}}
+
+	if p.peek().kind != tokenNewline {
+		subject, err := p.parseExprStatement()
+		if err != nil {
+			return nil, err
+		}
+		node.subject = subject
+	}
+	p.skipNewlines()
+
+	for {
+		next := p.next()
+
+		switch {
+		case p.isKeyword(next, "when"):
+			var clause whenClause
+			for {
+				value, err := p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+				clause.values = append(clause.values, value)
+				if !p.isOp(p.peek(), ",") {
+					break
+				}
+				p.next()
+				p.skipNewlines()
+			}
+			if p.isKeyword(p.peek(), "then") {
+				p.next()
+			}
+
+			body, err := p.parseStatements("when", "else", "end")
+			if err != nil {
+				return nil, err
+			}
+			clause.body = body
+			node.whens = append(node.whens, clause)
+
+		case p.isKeyword(next, "else") && len(node.whens) > 0:
+			body, err := p.parseStatements("end")
+			if err != nil {
+				return nil, err
+			}
+			node.elseBody = body
+			if err := p.expectKeyword("end"); err != nil {
+				return nil, err
+			}
+			return node, nil
+
+		case p.isKeyword(next, "end") && len(node.whens) > 0:
+			return node, nil
+
+		default:
+			return nil, p.unexpected(next)
+		}
+	}
+}
+
+func (p *erbParser) parseHash(token erbToken) (erbNode, error) {
+	noDo := p.noDo
+	p.noDo = false
+	defer func() { p.noDo = noDo }()
+
+	node := &hashNode{nodeBase: nodeBase{token.line}}
+
+	for {
+		p.skipNewlines()
+		if p.isOp(p.peek(), "}") {
+			p.next()
+			return node, nil
+		}
+
+		keyToken := p.peek()
+
+		var key erbNode
+		switch {
+		case keyToken.kind == tokenLabel:
+			p.next()
+			key = &literalNode{nodeBase{keyToken.line}, rbSymbol(keyToken.text)}
+
+		case keyToken.kind == tokenString && p.isOp(p.peekAt(1), ":") && !p.peekAt(1).spaceBefore:
+			p.next()
+			p.next()
+			parts, err := p.parseStringParts(keyToken, true)
+			if err != nil {
+				return nil, err
+			}
+			key = parts
+
+		default:
+			var err error
+			key, err = p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			p.skipNewlines()
+			if err := p.expectOp("=>"); err != nil {
+				return nil, err
+			}
+		}
+
+		p.skipNewlines()
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, value)
+
+		p.skipNewlines()
+		if p.isOp(p.peek(), ",") {
+			p.next()
+			continue
+		}
+		if err := p.expectOp("}"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
+
+// parseStringParts parses the code interpolated into a string, which shares
+// the local variables of the enclosing code.
+func (p *erbParser) parseStringParts(token erbToken, symbol bool) (erbNode, error) {
+	base := nodeBase{token.line}
+
+	if len(token.parts) == 1 && !token.parts[0].isCode {
+		if symbol {
+			return &literalNode{base, rbSymbol(token.parts[0].text)}, nil
+		}
+		return &literalNode{base, token.parts[0].text}, nil
+	}
+
+	node := &stringNode{nodeBase: base, symbol: symbol}
+
+	for _, part := range token.parts {
+		if !part.isCode {
+			node.parts = append(node.parts, &literalNode{base, part.text})
+			continue
+		}
+
+		tokens, err := lexRuby(part.code, part.line, nil)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, erbToken{kind: tokenEOF, line: part.line})
+
+		body, err := newERBParser(tokens, p.scope).parseProgram()
+		if err != nil {
+			return nil, err
+		}
+		node.parts = append(node.parts, &seqNode{nodeBase{part.line}, body})
+	}
+
+	return node, nil
+}
@@ -0,0 +1,89 @@
+package erbrenderer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+)
+
+var _ = Describe("ERB parser", func() {
+	Describe("templates", func() {
+		DescribeTable("rendering like Ruby", expectNativeResult,
+			Entry("empty templates", "", ""),
+			Entry("trailing newlines", "a\n\n", "a\n\n"),
+			Entry("trimming after tags", "<% x = 1 -%>\n<%= x -%>\nb", "1b"),
+			Entry("trimming before tags", "a\n  <%- x = 1 %>b", "a\nb"),
+			Entry("keeping newlines of expressions", "<%= 1 %>\n<%= 2 %>\n", "1\n2\n"),
+			Entry("newlines inside tags", "<%=\n  1 +\n  2\n%>", "3"),
+			Entry("percent signs in text", "100% <%= '%' %> %>", "100% % %>"),
+			Entry("semicolons", "<% a = 1; b = 2 %><%= a + b %>", "3"),
+			Entry("comments in code", "<% a = 1 # the answer\n%><%= a %>", "1"),
+			Entry("nil expressions", "[<%= nil %>][<%= p('missing', nil) %>]", "[][]"),
+			Entry("values of statements", "<%= if true then 'a' end %> <%= (1 if false).inspect %>", "a nil"),
+		)
+
+		DescribeTable("passing to Ruby for what is not supported", expectRubyFallback,
+			Entry("unknown constants", "<%= File.read('x') %>", "constant 'File' is not supported"),
+			Entry("method definitions", "<% def f; end %>", "'def' is not supported"),
+			Entry("rescue modifiers", "<%= p('port') rescue 1 %>", "'rescue' modifiers are not supported"),
+			Entry("loops", "<% i = 0 %><% while i < 3 %><% i += 1 %><% end %>", "'while' is not supported"),
+			Entry("for loops", "<% for x in [1, 2] %><% end %>", "'for' is not supported"),
+			Entry("defined?", "<%= defined?(a) %>", "'defined?' is not supported"),
+			Entry("case without a subject", "<%= case when true then 1 end %>", "'when' is not supported"),
+			Entry("unknown methods", "<%= missing_var %>", "method 'missing_var' is not supported"),
+			Entry("unterminated tags", "<%= 1", "unterminated ERB tag"),
+		)
+	})
+
+	Describe("control flow", func() {
+		DescribeTable("rendering like Ruby", expectNativeResult,
+			Entry("if", "<% if 1 > 2 %>a<% elsif 2 > 1 %>b<% else %>c<% end %>", "b"),
+			Entry("if modifiers", "<%= 'a' if true %><%= 'b' if false %>", "a"),
+			Entry("unless", "<% unless false %>a<% else %>b<% end %>", "a"),
+			Entry("ternaries", "<%= 1 > 2 ? 'a' : 'b' %> <%= nil ? 1 : false ? 2 : 3 %>", "b 3"),
+			Entry("truthiness", "<%= 0 ? 't' : 'f' %><%= '' ? 't' : 'f' %><%= [] ? 't' : 'f' %><%= nil ? 't' : 'f' %>", "tttf"),
+			Entry("boolean operators", "<%= nil || 'a' %> <%= 1 && 2 %> <%= nil && 1 %> <%= (false or true) %> <%= (true and nil).inspect %> <%= (not true) %>", "a 2  true nil false"),
+			Entry("conditional assignment", "<% a = nil %><% a ||= 1 %><% a ||= 2 %><% b = 1 %><% b &&= 3 %><%= a %><%= b %>", "13"),
+			Entry("operator assignment", "<% a = 10 %><% a -= 1 %><% a *= 2 %><% a /= 3 %><% a %= 4 %><%= a %>", "2"),
+			Entry("case with values", "<% case 'b' when 'a', 'b' %>ab<% else %>other<% end %>", "ab"),
+			Entry("case with classes", "<%= case 1.5 when Integer then 'int' when Float then 'float' end %>", "float"),
+			Entry("case with regexps", "<%= case 'abc' when /^a/ then 'a' else 'x' end %>", "a"),
+			Entry("case without a match", "<%= (case 1 when 2 then 'x' end).inspect %>", "nil"),
+			Entry("times", "<% 3.times do |i| %><%= i %><% end %>", "012"),
+			Entry("blocks seeing outer variables", "<% prefix = '-' %><%= [1, 2].map { |x| prefix + x.to_s }.join %>", "-1-2"),
+			Entry("parentheses", "<%= (1 + 2) * 3 %> <%= 1 + 2 * 3 %> <%= -2 ** 2 %> <%= 2 ** 3 ** 2 %>", "9 7 -4 512"),
+			Entry("method calls without parentheses", "<%= [1, 2].include? 2 %>", "true"),
+			Entry("safe navigation", "<%= nil&.length.inspect %> <%= 'ab'&.length %>", "nil 2"),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("raise with a message", "<% raise 'bad' %>", "#<RuntimeError: bad>"),
+			Entry("fail", "<% fail 'bad' %>", "#<RuntimeError: bad>"),
+			Entry("raise with a class", "<% raise ArgumentError, 'bad arg' %>", "#<ArgumentError: bad arg>"),
+			Entry("errors on later lines", "a\nb\n<%= nil.upcase %>", "line 3"),
+		)
+	})
+
+	Describe("evaluation context", func() {
+		DescribeTable("rendering like Ruby", expectNativeResult,
+			Entry("p with nested defaults", "<%= p('limits') %>", `{"cpu"=>2, "memory"=>1.5}`),
+			Entry("p with nil defaults", "<%= p('missing', nil).inspect %>", "nil"),
+			Entry("p with hash values", "<%= p('limits')['cpu'] %> <%= p('limits.cpu') %>", "2 2"),
+			Entry("if_p with several properties", "<% if_p('port', 'with_default') do |port, d| %><%= port %> <%= d %><% end %>", "8080 default-value"),
+			Entry("if_p with one unset property", "<% if_p('port', 'missing') do %>set<% end %>", ""),
+			Entry("if_p returning an else", "<% if_p('missing') do %>a<% end.else do %>b<% end %><% if_p('port') do %>c<% end.else do %>d<% end %>", "bc"),
+			Entry("spec fields", "<%= spec.index %> <%= spec.id %> <%= spec.az %> <%= spec.address %> <%= spec.networks.default.gateway %>", "0 fake-uuid z1 10.0.0.5 10.0.0.1"),
+			Entry("spec as a hash", "<%= spec.job.name %> <%= spec.deployment.upcase %>", "fake-job FAKE-DEPLOYMENT"),
+			Entry("link properties", "<%= link('db').p('db.port') %> <%= link('db').p('db.missing', 'x') %>", "5432 x"),
+			Entry("link instances", "<% link('db').instances.each do |i| %><%= i.name %>/<%= i.index %>@<%= i.address %><% end %>", "db/0@10.0.0.6"),
+			Entry("if_link with an else", "<% if_link('missing') do %>a<% end.else do %>b<% end %>", "b"),
+			Entry("format", "<%= format('%s=%d', 'a', 1) %> <%= sprintf('%.2f', 1.0 / 3) %> <%= '%-3s|' % 'a' %> <%= '%03d' % 7 %>", "a=1 0.33 a  | 007"),
+			Entry("conversion functions", "<%= Integer('42') + 1 %> <%= Float('1.5') %> <%= String(1) %> <%= Array(nil).inspect %> <%= Array([1]).inspect %>", "43 1.5 1 [] [1]"),
+			Entry("require", "<% require 'json' %><%= JSON.generate([1]) %>", "[1]"),
+		)
+
+		DescribeTable("raising like Ruby", expectNativeError,
+			Entry("unknown link properties", "<%= link('db').p('db.missing') %>", "Can't find property 'db.missing'"),
+			Entry("bad integers", "<%= Integer('abc') %>", `invalid value for Integer(): "abc"`),
+		)
+	})
+})
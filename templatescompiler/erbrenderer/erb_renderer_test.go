@@ -47,6 +47,29 @@ var _ = Describe("ErbRenderer", func() {
 		}))
 	})
 
+	It("gives templates rendered by ruby access to links in the context", func() {
+		var script, contextJSON string
+
+		runner.SetCmdCallback(
+			"ruby fake-temp-dir/erb-render.rb fake-temp-dir/erb-context.json fake-src-path fake-dst-path",
+			func() {
+				var err error
+				script, err = fs.ReadFileString(filepath.Join("fake-temp-dir", "erb-render.rb"))
+				Expect(err).ToNot(HaveOccurred())
+				contextJSON, err = fs.ReadFileString(filepath.Join("fake-temp-dir", "erb-context.json"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+		err := erbRenderer.Render("fake-src-path", "fake-dst-path", nativeRendererContext)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(contextJSON).To(ContainSubstring(`"links":{"db":{"address":"db.bosh"`))
+		Expect(script).To(ContainSubstring("@links = spec['links'] || {}"))
+		Expect(script).To(ContainSubstring("def link(name)"))
+		Expect(script).To(ContainSubstring("def if_link(name)"))
+		Expect(script).To(ContainSubstring("class EvaluationLink"))
+	})
+
 	It("cleans up temporary directory", func() {
 		err := erbRenderer.Render("fake-src-path", "fake-dst-path", context)
 		Expect(err).ToNot(HaveOccurred())
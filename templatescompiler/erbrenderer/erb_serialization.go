@@ -0,0 +1,584 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonGenerate formats values like Ruby's JSON.generate and
+// JSON.pretty_generate.
+func jsonGenerate(value interface{}, pretty bool) (string, error) {
+	var b strings.Builder
+
+	if err := writeJSON(&b, value, pretty, 0); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func writeJSON(b *strings.Builder, value interface{}, pretty bool, depth int) error {
+	newline := func(depth int) {
+		if pretty {
+			b.WriteString("\n")
+			b.WriteString(strings.Repeat("  ", depth))
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return newRubyError("JSON::GeneratorError", "%s not allowed in JSON", formatFloat(v))
+		}
+		b.WriteString(formatFloat(v))
+	case string:
+		return writeJSONString(b, v)
+	case rbSymbol:
+		return writeJSONString(b, string(v))
+
+	case *rbArray:
+		if len(v.items) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[")
+		for index, item := range v.items {
+			if index > 0 {
+				b.WriteString(",")
+			}
+			newline(depth + 1)
+			if err := writeJSON(b, item, pretty, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteString("]")
+
+	case *rbHash:
+		if v.len() == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{")
+		first := true
+		err := v.each(func(key, value interface{}) error {
+			if !first {
+				b.WriteString(",")
+			}
+			first = false
+			newline(depth + 1)
+			if err := writeJSONString(b, toS(key)); err != nil {
+				return err
+			}
+			b.WriteString(":")
+			if pretty {
+				b.WriteString(" ")
+			}
+			return writeJSON(b, value, pretty, depth+1)
+		})
+		if err != nil {
+			return err
+		}
+		newline(depth)
+		b.WriteString("}")
+
+	default:
+		return writeJSONString(b, toS(value))
+	}
+
+	return nil
+}
+
+func writeJSONString(b *strings.Builder, s string) error {
+	if !utf8.ValidString(s) {
+		return newRubyError("JSON::GeneratorError", "source sequence is illegal/malformed utf-8")
+	}
+
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+
+	return nil
+}
+
+// yamlLineWidth is the width at which libyaml, and so Ruby's YAML.dump,
+// folds long scalars.
+const yamlLineWidth = 80
+
+type yamlWriter struct {
+	b      strings.Builder
+	column int
+}
+
+func (w *yamlWriter) write(s string) {
+	w.b.WriteString(s)
+	if index := strings.LastIndexByte(s, '\n'); index != -1 {
+		w.column = utf8.RuneCountInString(s[index+1:])
+	} else {
+		w.column += utf8.RuneCountInString(s)
+	}
+}
+
+func (w *yamlWriter) newline(indent int) {
+	w.write("\n" + strings.Repeat(" ", indent))
+}
+
+// yamlDump formats values like Ruby's YAML.dump. Sequences are not indented
+// under mapping keys and long scalars are folded like libyaml does.
+func yamlDump(value interface{}) (string, error) {
+	w := &yamlWriter{}
+	w.write("---")
+
+	var err error
+	switch v := value.(type) {
+	case *rbHash:
+		if v.len() == 0 {
+			w.write(" {}")
+			break
+		}
+		w.newline(0)
+		err = w.writeMapping(v, 0)
+	case *rbArray:
+		if len(v.items) == 0 {
+			w.write(" []")
+			break
+		}
+		w.newline(0)
+		err = w.writeSequence(v, 0)
+	case nil:
+		w.write(" ")
+	default:
+		w.write(" ")
+		err = w.writeScalar(value, 2)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	w.write("\n")
+	return w.b.String(), nil
+}
+
+func (w *yamlWriter) writeMapping(hash *rbHash, indent int) error {
+	first := true
+
+	return hash.each(func(key, value interface{}) error {
+		if !first {
+			w.newline(indent)
+		}
+		first = false
+
+		switch key.(type) {
+		case *rbArray, *rbHash:
+			return &unsupportedError{reason: "YAML with complex keys is not supported"}
+		}
+		if err := w.writeScalar(key, indent+2); err != nil {
+			return err
+		}
+		w.write(":")
+
+		return w.writeValue(value, indent, true)
+	})
+}
+
+func (w *yamlWriter) writeSequence(array *rbArray, indent int) error {
+	for index, item := range array.items {
+		if index > 0 {
+			w.newline(indent)
+		}
+		w.write("-")
+		if err := w.writeValue(item, indent, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeValue writes the value of a mapping entry or sequence item whose
+// key or dash starts at indent.
+func (w *yamlWriter) writeValue(value interface{}, indent int, inMapping bool) error {
+	switch v := value.(type) {
+	case *rbHash:
+		if v.len() == 0 {
+			w.write(" {}")
+			return nil
+		}
+		if inMapping {
+			w.newline(indent + 2)
+		} else {
+			w.write(" ")
+		}
+		return w.writeMapping(v, indent+2)
+
+	case *rbArray:
+		if len(v.items) == 0 {
+			w.write(" []")
+			return nil
+		}
+		if inMapping {
+			w.newline(indent)
+			return w.writeSequence(v, indent)
+		}
+		w.write(" ")
+		return w.writeSequence(v, indent+2)
+
+	case nil:
+		return nil
+	}
+
+	w.write(" ")
+	return w.writeScalar(value, indent+2)
+}
+
+func (w *yamlWriter) writeScalar(value interface{}, indent int) error {
+	switch v := value.(type) {
+	case nil:
+		w.write("")
+	case bool:
+		w.write(strconv.FormatBool(v))
+	case int64:
+		w.write(strconv.FormatInt(v, 10))
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			w.write(".nan")
+		case math.IsInf(v, 1):
+			w.write(".inf")
+		case math.IsInf(v, -1):
+			w.write("-.inf")
+		default:
+			w.write(formatFloat(v))
+		}
+	case rbSymbol:
+		return w.writeString(":"+string(v), indent, true)
+	case string:
+		return w.writeString(v, indent, false)
+	default:
+		return &unsupportedError{reason: fmt.Sprintf("YAML for %s is not supported", className(value))}
+	}
+	return nil
+}
+
+var (
+	yamlBoolean        = regexp.MustCompile(`^(?i:yes|true|on|no|false|off)$`)
+	yamlNull           = regexp.MustCompile(`^(?:~|null|Null|NULL)$`)
+	yamlInteger        = regexp.MustCompile(`^(?:[-+]?0b[0-1_,]+|[-+]?0[0-7_,]+|[-+]?(?:0|[1-9](?:[0-9]|,[0-9]|_[0-9])*)|[-+]?0x[0-9a-fA-F_,]+)$`)
+	yamlFloat          = regexp.MustCompile(`^(?:[-+]?(?:[0-9][0-9_,]*)?\.[0-9]*(?:[eE][-+][0-9]+)?|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN))$`)
+	yamlSexagesimal    = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+(?:\.[0-9_]*)?$`)
+	yamlDate           = regexp.MustCompile(`^\d{4}-(?:1[012]|0\d|\d)-(?:[12]\d|3[01]|0\d|\d)$`)
+	yamlTime           = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}(?:[Tt]|\s+)\d{1,2}:\d\d:\d\d(?:\.\d*)?(?:\s*(?:Z|[-+]\d{1,2}:?(?:\d\d)?))?$`)
+	yamlOctalLookalike = regexp.MustCompile(`^0[0-7]*[89]`)
+)
+
+// yamlResolvesToNonString reports whether Ruby's YAML parser would read a
+// plain scalar as something other than the string itself.
+func yamlResolvesToNonString(s string) bool {
+	return s == "" || yamlBoolean.MatchString(s) || yamlNull.MatchString(s) ||
+		(strings.HasPrefix(s, ":") && len(s) > 1) || yamlInteger.MatchString(s) ||
+		yamlFloat.MatchString(s) || yamlSexagesimal.MatchString(s) ||
+		yamlDate.MatchString(s) || yamlTime.MatchString(s) || yamlOctalLookalike.MatchString(s)
+}
+
+func isYAMLPrintable(r rune) bool {
+	return r == '\n' || (r >= 0x20 && r != 0x7f && r != utf8.RuneError && r != 0xfeff && (r < 0x80 || unicode.IsPrint(r)))
+}
+
+// writeString picks the scalar style like Psych and libyaml do: literal
+// blocks for multiline text, double quotes for text that starts with a
+// symbol, single quotes for text that would not be read back as a string,
+// and plain scalars otherwise.
+func (w *yamlWriter) writeString(s string, indent int, symbol bool) error {
+	special := false
+	for _, r := range s {
+		if !isYAMLPrintable(r) {
+			special = true
+		}
+	}
+
+	innerNewline := strings.Contains(strings.TrimSuffix(s, "\n"), "\n")
+
+	switch {
+	case symbol:
+	case s == "y" || s == "Y" || s == "n" || s == "N":
+		return w.writeDoubleQuoted(s, indent)
+	case innerNewline && !special:
+		if w.literalAllowed(s) {
+			return w.writeLiteral(s, indent)
+		}
+		return &unsupportedError{reason: "YAML for multiline strings with trailing spaces is not supported"}
+	case special || innerNewline:
+		return w.writeDoubleQuoted(s, indent)
+	}
+
+	first, _ := utf8.DecodeRuneInString(s)
+	if !symbol && s != "" && !(unicode.IsLetter(first) || unicode.IsDigit(first) || first == '_') && !strings.Contains(s[1:], `"`) {
+		return w.writeDoubleQuoted(s, indent)
+	}
+
+	if !symbol && (yamlResolvesToNonString(s) || s == "<<") {
+		return w.writeSingleQuoted(s, indent)
+	}
+
+	if !plainAllowed(s) {
+		if strings.Contains(s, "\n") {
+			return w.writeDoubleQuoted(s, indent)
+		}
+		return w.writeSingleQuoted(s, indent)
+	}
+
+	w.writeFolded(s, indent, func(runes []rune, index int) bool {
+		return index+1 >= len(runes) || runes[index+1] != ' '
+	})
+	return nil
+}
+
+// plainAllowed reports whether libyaml can write s as a plain scalar in a
+// block context.
+func plainAllowed(s string) bool {
+	if s == "" || strings.HasPrefix(s, " ") || strings.HasSuffix(s, " ") || strings.Contains(s, "\n") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	if strings.HasPrefix(s, "---") || strings.HasPrefix(s, "...") {
+		return false
+	}
+	first := s[0]
+	if strings.IndexByte("-?:,[]{}#&*!|>'\"%@`", first) != -1 {
+		return len(s) > 1 && (first == '-' || first == '?' || first == ':') && s[1] != ' '
+	}
+	return true
+}
+
+func (w *yamlWriter) literalAllowed(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFolded writes a scalar, breaking lines at spaces past the line width.
+func (w *yamlWriter) writeFolded(s string, indent int, canBreak func(runes []rune, index int) bool) {
+	runes := []rune(s)
+	var b strings.Builder
+	spaces := false
+
+	flush := func() {
+		w.write(b.String())
+		b.Reset()
+	}
+
+	for index, r := range runes {
+		if r == ' ' {
+			if !spaces && w.column+utf8.RuneCountInString(b.String()) > yamlLineWidth && canBreak(runes, index) {
+				flush()
+				w.newline(indent)
+			} else {
+				b.WriteRune(r)
+			}
+			spaces = true
+			continue
+		}
+		b.WriteRune(r)
+		spaces = false
+	}
+
+	flush()
+}
+
+func (w *yamlWriter) writeSingleQuoted(s string, indent int) error {
+	w.write("'")
+	w.writeFolded(strings.ReplaceAll(s, "'", "''"), indent, func(runes []rune, index int) bool {
+		return index != 0 && index != len(runes)-1 && runes[index+1] != ' '
+	})
+	w.write("'")
+	return nil
+}
+
+var yamlEscapes = map[rune]string{
+	0: `\0`, 7: `\a`, 8: `\b`, 9: `\t`, 10: `\n`, 11: `\v`, 12: `\f`, 13: `\r`, 27: `\e`,
+	'"': `\"`, '\\': `\\`, 0x85: `\N`, 0xa0: `\_`, 0x2028: `\L`, 0x2029: `\P`,
+}
+
+func (w *yamlWriter) writeDoubleQuoted(s string, indent int) error {
+	w.write(`"`)
+
+	runes := []rune(s)
+	var b strings.Builder
+	spaces := false
+
+	for index, r := range runes {
+		if escape, found := yamlEscapes[r]; found {
+			b.WriteString(escape)
+			spaces = false
+			continue
+		}
+		if !isYAMLPrintable(r) {
+			switch {
+			case r <= 0xff:
+				fmt.Fprintf(&b, `\x%02X`, r)
+			case r <= 0xffff:
+				fmt.Fprintf(&b, `\u%04X`, r)
+			default:
+				fmt.Fprintf(&b, `\U%08X`, r)
+			}
+			spaces = false
+			continue
+		}
+		if r == ' ' {
+			if !spaces && w.column+utf8.RuneCountInString(b.String()) > yamlLineWidth && index != 0 && index != len(runes)-1 {
+				w.write(b.String())
+				b.Reset()
+				w.newline(indent)
+				if index+1 < len(runes) && runes[index+1] == ' ' {
+					b.WriteString(`\`)
+				}
+			} else {
+				b.WriteRune(r)
+			}
+			spaces = true
+			continue
+		}
+		b.WriteRune(r)
+		spaces = false
+	}
+
+	w.write(b.String())
+	w.write(`"`)
+	return nil
+}
+
+// writeLiteral writes a multiline string as a '|' block scalar.
+func (w *yamlWriter) writeLiteral(s string, indent int) error {
+	chomp := "-"
+	body := s
+	switch {
+	case strings.HasSuffix(s, "\n\n"):
+		chomp = "+"
+		body = s[:len(s)-1]
+	case strings.HasSuffix(s, "\n"):
+		chomp = ""
+		body = s[:len(s)-1]
+	}
+
+	hint := ""
+	if strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\n") {
+		hint = "2"
+	}
+
+	w.write("|" + hint + chomp)
+	for _, line := range strings.Split(body, "\n") {
+		if line == "" {
+			w.write("\n")
+			continue
+		}
+		w.newline(indent)
+		w.write(line)
+	}
+
+	return nil
+}
+
+// yamlLoad parses YAML into template values, keeping the order of keys.
+func yamlLoad(s string) (interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &document); err != nil {
+		return nil, newRubyError("Psych::SyntaxError", "%s", err.Error())
+	}
+
+	if len(document.Content) == 0 {
+		return false, nil
+	}
+
+	return fromYAML(document.Content[0])
+}
+
+func fromYAML(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return fromYAML(node.Alias)
+
+	case yaml.MappingNode:
+		hash := newHash()
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			if node.Content[index].Value == "<<" && node.Content[index].Tag == "!!merge" {
+				return nil, &unsupportedError{reason: "YAML merge keys are not supported"}
+			}
+			key, err := fromYAML(node.Content[index])
+			if err != nil {
+				return nil, err
+			}
+			value, err := fromYAML(node.Content[index+1])
+			if err != nil {
+				return nil, err
+			}
+			hash.set(key, value)
+		}
+		return hash, nil
+
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+		for index, item := range node.Content {
+			converted, err := fromYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			items[index] = converted
+		}
+		return newArray(items...), nil
+
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, newRubyError("Psych::SyntaxError", "%s", err.Error())
+		}
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case float64, string, bool, nil:
+			return v, nil
+		}
+		return nil, &unsupportedError{reason: fmt.Sprintf("YAML value '%s' is not supported", node.Value)}
+	}
+
+	return nil, &unsupportedError{reason: "unsupported YAML document"}
+}
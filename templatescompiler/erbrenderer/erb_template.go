@@ -0,0 +1,177 @@
+package erbrenderer
+
+import (
+	"strings"
+)
+
+type erbSegmentKind int
+
+const (
+	erbSegmentText erbSegmentKind = iota
+	erbSegmentCode
+	erbSegmentOutput
+)
+
+type erbSegment struct {
+	kind    erbSegmentKind
+	content string
+	line    int
+}
+
+// scanERBTemplate splits a template into text, code and output segments the
+// way Ruby's ERB does with trim mode "-": '<%-' removes the indentation
+// before a tag that starts a line and '-%>' removes the newline after it.
+func scanERBTemplate(template string) ([]erbSegment, error) {
+	var segments []erbSegment
+	var text strings.Builder
+
+	line := 1
+	textLine := 1
+	pos := 0
+
+	flushText := func() {
+		if text.Len() > 0 {
+			segments = append(segments, erbSegment{kind: erbSegmentText, content: text.String(), line: textLine})
+			text.Reset()
+		}
+	}
+
+	for pos < len(template) {
+		start := strings.Index(template[pos:], "<%")
+		if start == -1 {
+			text.WriteString(template[pos:])
+			break
+		}
+		start += pos
+
+		text.WriteString(template[pos:start])
+		line += strings.Count(template[pos:start], "\n")
+
+		if strings.HasPrefix(template[start:], "<%%") {
+			text.WriteString("<%")
+			pos = start + 3
+			continue
+		}
+
+		tagStart := start + 2
+		kind := erbSegmentCode
+		comment := false
+
+		switch {
+		case strings.HasPrefix(template[tagStart:], "="):
+			kind = erbSegmentOutput
+			tagStart++
+		case strings.HasPrefix(template[tagStart:], "#"):
+			comment = true
+			tagStart++
+		case strings.HasPrefix(template[tagStart:], "-"):
+			tagStart++
+			trimIndentation(&text)
+		}
+
+		content, end, err := scanERBTag(template, tagStart)
+		if err != nil {
+			return nil, &unsupportedError{line: line, reason: err.Error()}
+		}
+
+		pos = end
+		if strings.HasSuffix(content, "-") {
+			content = content[:len(content)-1]
+			if strings.HasPrefix(template[pos:], "\n") {
+				pos++
+			} else if strings.HasPrefix(template[pos:], "\r\n") {
+				pos += 2
+			}
+		}
+
+		if !comment {
+			flushText()
+			segments = append(segments, erbSegment{kind: kind, content: content, line: line})
+		}
+
+		line += strings.Count(template[start:pos], "\n")
+		if text.Len() == 0 {
+			textLine = line
+		}
+	}
+
+	flushText()
+
+	return segments, nil
+}
+
+func scanERBTag(template string, pos int) (string, int, error) {
+	var content strings.Builder
+
+	for {
+		end := strings.Index(template[pos:], "%>")
+		if end == -1 {
+			return "", 0, erbError("unterminated ERB tag")
+		}
+		end += pos
+
+		if end > pos && template[end-1] == '%' {
+			// '%%>' stands for a literal '%>' inside a tag
+			content.WriteString(template[pos : end-1])
+			content.WriteString("%>")
+			pos = end + 2
+			continue
+		}
+
+		content.WriteString(template[pos:end])
+		return content.String(), end + 2, nil
+	}
+}
+
+// trimIndentation removes the spaces and tabs that precede a '<%-' tag when
+// nothing else precedes it on its line.
+func trimIndentation(text *strings.Builder) {
+	current := text.String()
+	trimmed := strings.TrimRight(current, " \t")
+
+	if trimmed == "" || strings.HasSuffix(trimmed, "\n") {
+		text.Reset()
+		text.WriteString(trimmed)
+	}
+}
+
+type erbError string
+
+func (e erbError) Error() string { return string(e) }
+
+// parseERBTemplate turns a template into the statements of a Ruby program in
+// which text and output segments are statements of their own.
+func parseERBTemplate(template string) ([]erbNode, error) {
+	segments, err := scanERBTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []erbToken
+
+	for _, segment := range segments {
+		switch segment.kind {
+		case erbSegmentText:
+			tokens = append(tokens, erbToken{kind: tokenText, text: segment.content, line: segment.line})
+
+		case erbSegmentOutput:
+			tokens = append(tokens, erbToken{kind: tokenOutputBegin, line: segment.line})
+			tokens, err = lexRuby(segment.content, segment.line, tokens)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, erbToken{kind: tokenOutputEnd, line: segment.line})
+
+		case erbSegmentCode:
+			tokens, err = lexRuby(segment.content, segment.line, tokens)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, erbToken{kind: tokenNewline, line: segment.line})
+		}
+	}
+
+	tokens = append(tokens, erbToken{kind: tokenEOF})
+
+	return newERBParser(tokens, newParserScope(nil)).parseProgram()
+}
@@ -0,0 +1,851 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Template values are nil, bool, int64, float64, string and the types below,
+// which mirror the Ruby classes of the same name.
+
+type rbSymbol string
+
+type rbArray struct {
+	items []interface{}
+}
+
+func newArray(items ...interface{}) *rbArray {
+	if items == nil {
+		items = []interface{}{}
+	}
+	return &rbArray{items: items}
+}
+
+type rbHashEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// rbHash keeps its keys in insertion order like a Ruby Hash.
+type rbHash struct {
+	order        []interface{}
+	entries      map[interface{}]*rbHashEntry
+	defaultValue interface{}
+}
+
+// compositeKey stands in for arrays and hashes used as hash keys.
+type compositeKey struct{ inspected string }
+
+func newHash() *rbHash {
+	return &rbHash{entries: map[interface{}]*rbHashEntry{}}
+}
+
+func hashKey(key interface{}) interface{} {
+	switch key.(type) {
+	case *rbArray, *rbHash, *rbOpenStruct, *rbRange:
+		return compositeKey{inspect(key)}
+	}
+	return key
+}
+
+func (h *rbHash) get(key interface{}) (interface{}, bool) {
+	entry, found := h.entries[hashKey(key)]
+	if !found {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// fetch returns the value for key or the default value of the hash.
+func (h *rbHash) fetch(key interface{}) interface{} {
+	if value, found := h.get(key); found {
+		return value
+	}
+	return h.defaultValue
+}
+
+func (h *rbHash) set(key, value interface{}) {
+	k := hashKey(key)
+	if entry, found := h.entries[k]; found {
+		entry.value = value
+		return
+	}
+	h.entries[k] = &rbHashEntry{key: key, value: value}
+	h.order = append(h.order, k)
+}
+
+func (h *rbHash) delete(key interface{}) (interface{}, bool) {
+	k := hashKey(key)
+	entry, found := h.entries[k]
+	if !found {
+		return nil, false
+	}
+	delete(h.entries, k)
+	for i, orderedKey := range h.order {
+		if orderedKey == k {
+			h.order = append(h.order[:i:i], h.order[i+1:]...)
+			break
+		}
+	}
+	return entry.value, true
+}
+
+func (h *rbHash) len() int { return len(h.order) }
+
+func (h *rbHash) each(f func(key, value interface{}) error) error {
+	for _, k := range append([]interface{}{}, h.order...) {
+		entry, found := h.entries[k]
+		if !found {
+			continue
+		}
+		if err := f(entry.key, entry.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *rbHash) keys() []interface{} {
+	var keys []interface{}
+	_ = h.each(func(key, _ interface{}) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+func (h *rbHash) pairs() []interface{} {
+	var pairs []interface{}
+	_ = h.each(func(key, value interface{}) error {
+		pairs = append(pairs, newArray(key, value))
+		return nil
+	})
+	return pairs
+}
+
+func (h *rbHash) copy() *rbHash {
+	copied := newHash()
+	_ = h.each(func(key, value interface{}) error {
+		copied.set(key, value)
+		return nil
+	})
+	copied.defaultValue = h.defaultValue
+	return copied
+}
+
+// rbOpenStruct answers methods named after its fields, as 'spec' does.
+type rbOpenStruct struct {
+	fields *rbHash
+}
+
+type rbRange struct {
+	from, to  interface{}
+	exclusive bool
+}
+
+type rbRegexp struct {
+	re     *regexp.Regexp
+	source string
+}
+
+type rbClass string
+
+// rbElseBlock is returned by if_p so that templates can chain '.else'.
+type rbElseBlock struct {
+	active bool
+}
+
+// rbEnumerator is what iterating methods return when called without a block.
+type rbEnumerator struct {
+	items    []interface{}
+	receiver interface{}
+	method   string
+	args     []interface{}
+}
+
+func truthy(value interface{}) bool {
+	return value != nil && value != false
+}
+
+func className(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "NilClass"
+	case bool:
+		if value.(bool) {
+			return "TrueClass"
+		}
+		return "FalseClass"
+	case int64:
+		return "Integer"
+	case float64:
+		return "Float"
+	case string:
+		return "String"
+	case rbSymbol:
+		return "Symbol"
+	case *rbArray:
+		return "Array"
+	case *rbHash:
+		return "Hash"
+	case *rbOpenStruct:
+		return "OpenStruct"
+	case *rbRange:
+		return "Range"
+	case *rbRegexp:
+		return "Regexp"
+	case rbClass:
+		return "Class"
+	case *rbEnumerator:
+		return "Enumerator"
+	}
+	return "Object"
+}
+
+var classAncestors = map[string][]string{
+	"Integer":    {"Numeric", "Comparable"},
+	"Float":      {"Numeric", "Comparable"},
+	"String":     {"Comparable"},
+	"Array":      {"Enumerable"},
+	"Hash":       {"Enumerable"},
+	"Range":      {"Enumerable"},
+	"Enumerator": {"Enumerable"},
+}
+
+func isA(value interface{}, class string) bool {
+	name := className(value)
+	if name == class || class == "Object" || class == "BasicObject" || class == "Kernel" {
+		return true
+	}
+	for _, ancestor := range classAncestors[name] {
+		if ancestor == class {
+			return true
+		}
+	}
+	return false
+}
+
+func rbEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return x == y
+		case float64:
+			return float64(x) == y
+		}
+		return false
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return x == float64(y)
+		case float64:
+			return x == y
+		}
+		return false
+	case *rbArray:
+		y, ok := b.(*rbArray)
+		if !ok || len(x.items) != len(y.items) {
+			return false
+		}
+		for i := range x.items {
+			if !rbEqual(x.items[i], y.items[i]) {
+				return false
+			}
+		}
+		return true
+	case *rbHash:
+		y, ok := b.(*rbHash)
+		if !ok || x.len() != y.len() {
+			return false
+		}
+		for _, k := range x.order {
+			other, found := y.entries[k]
+			if !found || !rbEqual(x.entries[k].value, other.value) {
+				return false
+			}
+		}
+		return true
+	case *rbOpenStruct:
+		y, ok := b.(*rbOpenStruct)
+		return ok && rbEqual(x.fields, y.fields)
+	case *rbRange:
+		y, ok := b.(*rbRange)
+		return ok && rbEqual(x.from, y.from) && rbEqual(x.to, y.to) && x.exclusive == y.exclusive
+	case *rbRegexp:
+		y, ok := b.(*rbRegexp)
+		return ok && x.source == y.source
+	}
+
+	return a == b
+}
+
+func rbCompare(a, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compareOrdered(x, y), nil
+		case float64:
+			return compareOrdered(float64(x), y), nil
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return compareOrdered(x, float64(y)), nil
+		case float64:
+			return compareOrdered(x, y), nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case rbSymbol:
+		if y, ok := b.(rbSymbol); ok {
+			return strings.Compare(string(x), string(y)), nil
+		}
+	case *rbArray:
+		if y, ok := b.(*rbArray); ok {
+			for i := 0; i < len(x.items) && i < len(y.items); i++ {
+				result, err := rbCompare(x.items[i], y.items[i])
+				if err != nil || result != 0 {
+					return result, err
+				}
+			}
+			return compareOrdered(len(x.items), len(y.items)), nil
+		}
+	}
+
+	return 0, newRubyError("ArgumentError", "comparison of %s with %s failed", className(a), describeOperand(b))
+}
+
+// describeOperand names the right hand side of a failed comparison the way
+// Ruby does, which shows simple values and the class of everything else.
+func describeOperand(value interface{}) string {
+	switch value.(type) {
+	case nil, bool, int64, float64, rbSymbol:
+		return inspect(value)
+	}
+	return className(value)
+}
+
+func compareOrdered[T int | int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sortValues(items []interface{}, compare func(a, b interface{}) (int, error)) error {
+	var err error
+	sort.SliceStable(items, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var result int
+		result, err = compare(items[i], items[j])
+		return result < 0
+	})
+	return err
+}
+
+// toS converts a value the way Ruby's to_s does.
+func toS(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case rbSymbol:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return formatFloat(v)
+	case *rbRange:
+		return toS(v.from) + rangeOperator(v) + toS(v.to)
+	case *rbRegexp:
+		return "(?-mix:" + v.source + ")"
+	case rbClass:
+		return string(v)
+	}
+	return inspect(value)
+}
+
+func rangeOperator(r *rbRange) string {
+	if r.exclusive {
+		return "..."
+	}
+	return ".."
+}
+
+// inspect converts a value the way Ruby's inspect does.
+func inspect(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return inspectString(v)
+	case rbSymbol:
+		if isPlainSymbol(string(v)) {
+			return ":" + string(v)
+		}
+		return ":" + inspectString(string(v))
+	case *rbArray:
+		parts := make([]string, len(v.items))
+		for i, item := range v.items {
+			parts[i] = inspect(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *rbHash:
+		var parts []string
+		_ = v.each(func(key, value interface{}) error {
+			parts = append(parts, inspect(key)+"=>"+inspect(value))
+			return nil
+		})
+		return "{" + strings.Join(parts, ", ") + "}"
+	case *rbOpenStruct:
+		var parts []string
+		_ = v.fields.each(func(key, value interface{}) error {
+			parts = append(parts, toS(key)+"="+inspect(value))
+			return nil
+		})
+		if len(parts) == 0 {
+			return "#<OpenStruct>"
+		}
+		return "#<OpenStruct " + strings.Join(parts, ", ") + ">"
+	case *rbRange:
+		return inspect(v.from) + rangeOperator(v) + inspect(v.to)
+	case *rbRegexp:
+		return "/" + v.source + "/"
+	case *rbElseBlock:
+		return "#<TemplateEvaluationContext::ElseBlock>"
+	case *rbEnumerator:
+		return "#<Enumerator: ...>"
+	}
+	return toS(value)
+}
+
+func isPlainSymbol(name string) bool {
+	if name == "" || !isIdentStart(name[0]) || isDigit(name[0]) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isIdentChar(c) && !((c == '?' || c == '!' || c == '=') && i == len(name)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func inspectString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, "\\x%02X", s[i])
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '#' && i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '$' || s[i+1] == '@'):
+			b.WriteString("\\#")
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == 27:
+			b.WriteString("\\e")
+		case r == 7:
+			b.WriteString("\\a")
+		case r == 8:
+			b.WriteString("\\b")
+		case r == 11:
+			b.WriteString("\\v")
+		case r == 12:
+			b.WriteString("\\f")
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\x%02X", r)
+		case !unicode.IsPrint(r) && r > 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+
+		i += size
+	}
+
+	b.WriteByte('"')
+	return b.String()
+}
+
+// formatFloat formats like Ruby's Float#to_s, which always shows a fraction
+// and switches to exponent notation for very large and small numbers.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		if math.Signbit(f) {
+			return "-0.0"
+		}
+		return "0.0"
+	}
+
+	abs := math.Abs(f)
+	if abs >= 1e16 || abs < 1e-4 {
+		formatted := strconv.FormatFloat(f, 'e', -1, 64)
+		mantissa, exponent, _ := strings.Cut(formatted, "e")
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		return mantissa + "e" + exponent
+	}
+
+	formatted := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
+
+// openStruct converts hashes, including those nested in arrays, the way
+// OpenStruct.new does for the template spec.
+func openStruct(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *rbHash:
+		fields := newHash()
+		_ = v.each(func(key, value interface{}) error {
+			fields.set(toS(key), openStruct(value))
+			return nil
+		})
+		return &rbOpenStruct{fields: fields}
+	case *rbArray:
+		items := make([]interface{}, len(v.items))
+		for i, item := range v.items {
+			items[i] = openStruct(item)
+		}
+		return newArray(items...)
+	}
+	return value
+}
+
+// toInteger converts a value the way Kernel#Integer does.
+func toInteger(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, newRubyError("FloatDomainError", "%s", formatFloat(v))
+		}
+		// 2**63 is the smallest float that does not fit, and -2**63 still does
+		if v >= math.Exp2(63) || v < -math.Exp2(63) {
+			return nil, integerOverflowError()
+		}
+		return int64(v), nil
+	case string:
+		cleaned := strings.ReplaceAll(strings.TrimSpace(v), "_", "")
+		parsed, err := strconv.ParseInt(cleaned, 0, 64)
+		if isIntegerRangeError(err) {
+			return nil, integerOverflowError()
+		} else if err != nil {
+			return nil, newRubyError("ArgumentError", "invalid value for Integer(): %s", inspectString(v))
+		}
+		return parsed, nil
+	case nil:
+		return nil, newRubyError("TypeError", "can't convert nil into Integer")
+	}
+	return nil, newRubyError("TypeError", "can't convert %s into Integer", className(value))
+}
+
+// toFloat converts a value the way Kernel#Float does.
+func toFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		parsed, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), "_", ""), 64)
+		if err != nil {
+			return nil, newRubyError("ArgumentError", "invalid value for Float(): %s", inspectString(v))
+		}
+		return parsed, nil
+	case nil:
+		return nil, newRubyError("TypeError", "can't convert nil into Float")
+	}
+	return nil, newRubyError("TypeError", "can't convert %s into Float", className(value))
+}
+
+var leadingInteger = regexp.MustCompile(`^\s*[-+]?\d[\d_]*`)
+var leadingFloat = regexp.MustCompile(`^\s*[-+]?\d[\d_]*(\.\d+)?([eE][-+]?\d+)?`)
+
+// stringToI converts the leading digits of a string like String#to_i.
+func stringToI(s string, base int) (int64, error) {
+	if base != 10 {
+		s = strings.TrimSpace(s)
+		end := 0
+		for end < len(s) {
+			if _, err := strconv.ParseInt(s[end:end+1], base, 64); err != nil && !(end == 0 && (s[0] == '-' || s[0] == '+')) {
+				break
+			}
+			end++
+		}
+		value, err := strconv.ParseInt(s[:end], base, 64)
+		if isIntegerRangeError(err) {
+			return 0, integerOverflowError()
+		}
+		return value, nil
+	}
+
+	match := strings.ReplaceAll(strings.TrimSpace(leadingInteger.FindString(s)), "_", "")
+	value, err := strconv.ParseInt(match, 10, 64)
+	if isIntegerRangeError(err) {
+		return 0, integerOverflowError()
+	}
+	return value, nil
+}
+
+func isIntegerRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+func stringToF(s string) float64 {
+	match := strings.ReplaceAll(strings.TrimSpace(leadingFloat.FindString(s)), "_", "")
+	value, _ := strconv.ParseFloat(match, 64)
+	return value
+}
+
+// newRegexp translates a Ruby regexp into Go syntax. In Ruby '^' and '$'
+// always match at line boundaries and the 'm' flag makes '.' match newlines.
+func newRegexp(source, flags string) (*rbRegexp, error) {
+	goFlags := "m"
+	for _, flag := range flags {
+		switch flag {
+		case 'i':
+			goFlags += "i"
+		case 'm':
+			goFlags += "s"
+		case 'o', 'u', 'n', 'e', 's':
+		default:
+			return nil, &unsupportedError{reason: fmt.Sprintf("regexp flag '%c' is not supported", flag)}
+		}
+	}
+
+	translated := source
+	translated = strings.ReplaceAll(translated, `\h`, `[0-9a-fA-F]`)
+	translated = strings.ReplaceAll(translated, `\Z`, `\n?\z`)
+	translated = strings.ReplaceAll(translated, `(?<`, `(?P<`)
+	translated = strings.ReplaceAll(translated, `(?P<=`, `(?<=`)
+	translated = strings.ReplaceAll(translated, `(?P<!`, `(?<!`)
+
+	re, err := regexp.Compile("(?" + goFlags + ")" + translated)
+	if err != nil {
+		return nil, &unsupportedError{reason: fmt.Sprintf("regexp /%s/ is not supported: %s", source, err.Error())}
+	}
+
+	return &rbRegexp{re: re, source: source}, nil
+}
+
+// rubyReplacement translates '\1' and '\k<name>' style back references in a
+// sub or gsub replacement into the '${1}' style of Go.
+func rubyReplacement(replacement string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		switch {
+		case c == '$':
+			b.WriteString("$$")
+		case c == '\\' && i+1 < len(replacement):
+			next := replacement[i+1]
+			switch {
+			case isDigit(next):
+				fmt.Fprintf(&b, "${%c}", next)
+				i++
+			case next == '&':
+				b.WriteString("${0}")
+				i++
+			case next == 'k' && i+2 < len(replacement) && replacement[i+2] == '<':
+				end := strings.IndexByte(replacement[i:], '>')
+				if end == -1 {
+					b.WriteByte(c)
+					continue
+				}
+				fmt.Fprintf(&b, "${%s}", replacement[i+3:i+end])
+				i += end
+			case next == '\\':
+				b.WriteByte('\\')
+				i++
+			default:
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// rubyFormat implements the subset of Kernel#format used in templates.
+func rubyFormat(format string, args []interface{}) (string, error) {
+	var b strings.Builder
+	argIndex := 0
+
+	nextArg := func() (interface{}, error) {
+		if argIndex >= len(args) {
+			return nil, newRubyError("ArgumentError", "too few arguments")
+		}
+		arg := args[argIndex]
+		argIndex++
+		return arg, nil
+	}
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+ 0#", format[i]) != -1 {
+			i++
+		}
+
+		var name string
+		if i < len(format) && (format[i] == '<' || format[i] == '{') {
+			closer := byte('>')
+			if format[i] == '{' {
+				closer = '}'
+			}
+			end := strings.IndexByte(format[i:], closer)
+			if end == -1 {
+				return "", newRubyError("ArgumentError", "malformed name - unmatched parenthesis")
+			}
+			name = format[i+1 : i+end]
+			if format[i] == '{' {
+				value, err := formatNamedArg(args, name)
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(toS(value))
+				i += end
+				continue
+			}
+			i += end + 1
+		}
+
+		for i < len(format) && (isDigit(format[i]) || format[i] == '.') {
+			i++
+		}
+		if i >= len(format) {
+			return "", newRubyError("ArgumentError", "incomplete format specifier; use %%%% (double %%) instead")
+		}
+
+		verb := format[i]
+		spec := strings.Replace(format[start:i], "<"+name+">", "", 1)
+
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+
+		var arg interface{}
+		var err error
+		if name != "" {
+			arg, err = formatNamedArg(args, name)
+		} else {
+			arg, err = nextArg()
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch verb {
+		case 's':
+			fmt.Fprintf(&b, spec+"s", toS(arg))
+		case 'p':
+			fmt.Fprintf(&b, spec+"s", inspect(arg))
+		case 'd', 'i', 'u':
+			if f, ok := arg.(float64); ok {
+				arg = math.Floor(f)
+			}
+			integer, err := toInteger(arg)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, spec+"d", integer)
+		case 'x', 'X', 'o', 'b', 'B':
+			integer, err := toInteger(arg)
+			if err != nil {
+				return "", err
+			}
+			if verb == 'B' {
+				verb = 'b'
+			}
+			fmt.Fprintf(&b, spec+string(verb), integer)
+		case 'f', 'e', 'E', 'g', 'G':
+			float, err := toFloat(arg)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, spec+string(verb), float)
+		case 'c':
+			switch v := arg.(type) {
+			case int64:
+				b.WriteRune(rune(v))
+			default:
+				s := toS(v)
+				if s != "" {
+					r, _ := utf8.DecodeRuneInString(s)
+					b.WriteRune(r)
+				}
+			}
+		default:
+			return "", newRubyError("ArgumentError", "malformed format string - %%%c", verb)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func formatNamedArg(args []interface{}, name string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, newRubyError("ArgumentError", "one hash required")
+	}
+	hash, ok := args[0].(*rbHash)
+	if !ok {
+		return nil, newRubyError("ArgumentError", "one hash required")
+	}
+	value, found := hash.get(rbSymbol(name))
+	if !found {
+		return nil, newRubyError("KeyError", "key<%s> not found", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,98 @@
+package erbrenderer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type nativeERBRenderer struct {
+	fs       boshsys.FileSystem
+	fallback ERBRenderer
+	logger   boshlog.Logger
+	logTag   string
+}
+
+// NewNativeERBRenderer renders templates in Go, without Ruby. Templates
+// that use Ruby beyond what job templates commonly need are passed on to
+// fallback.
+func NewNativeERBRenderer(
+	fs boshsys.FileSystem,
+	fallback ERBRenderer,
+	logger boshlog.Logger,
+) ERBRenderer {
+	return nativeERBRenderer{
+		fs:       fs,
+		fallback: fallback,
+		logger:   logger,
+		logTag:   "nativeERBRenderer",
+	}
+}
+
+func (r nativeERBRenderer) Render(srcPath, dstPath string, context TemplateEvaluationContext) error {
+	r.logger.Debug(r.logTag, "Rendering template %s", dstPath)
+
+	template, err := r.fs.ReadFileString(srcPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading template '%s'", srcPath)
+	}
+
+	contextBytes, err := json.Marshal(context)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling context")
+	}
+
+	var result string
+
+	evaluationContext, err := newERBEvaluationContext(contextBytes)
+	if err == nil {
+		result, err = r.render(template, evaluationContext)
+	} else if _, ok := err.(*unsupportedError); !ok {
+		return bosherr.WrapError(err, "Reading context")
+	}
+
+	if unsupported, ok := err.(*unsupportedError); ok {
+		r.logger.Debug(r.logTag, "Rendering template '%s' with Ruby: %s", srcPath, unsupported.Error())
+
+		err = r.fallback.Render(srcPath, dstPath, context)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Rendering template '%s' that is not supported natively (%s)", srcPath, unsupported.Error())
+		}
+
+		return nil
+	}
+	if err != nil {
+		return bosherr.Errorf("Error filling in template '%s' for %s (%s)", srcPath, r.instanceName(evaluationContext), r.location(err))
+	}
+
+	err = r.fs.WriteFileString(dstPath, result)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing rendered template '%s'", dstPath)
+	}
+
+	return nil
+}
+
+func (r nativeERBRenderer) render(template string, context *erbEvaluationContext) (string, error) {
+	body, err := parseERBTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	return newERBInterpreter(context).render(body)
+}
+
+func (r nativeERBRenderer) instanceName(context *erbEvaluationContext) string {
+	return fmt.Sprintf("%s/%s", toS(context.name), toS(context.index))
+}
+
+// location describes an error like the Ruby renderer script does.
+func (r nativeERBRenderer) location(err error) string {
+	if rubyErr, ok := err.(*rubyError); ok && rubyErr.line > 0 {
+		return fmt.Sprintf("line %d: %s", rubyErr.line, rubyErr.Error())
+	}
+	return fmt.Sprintf("line unknown: %s", err.Error())
+}
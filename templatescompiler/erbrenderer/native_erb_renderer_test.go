@@ -0,0 +1,310 @@
+package erbrenderer_test
+
+import (
+	"errors"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/templatescompiler/erbrenderer"
+	fakebierbrenderer "github.com/cloudfoundry/bosh-cli/v7/templatescompiler/erbrenderer/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+type jsonContext string
+
+func (c jsonContext) MarshalJSON() ([]byte, error) {
+	return []byte(c), nil
+}
+
+const nativeRendererContext = jsonContext(`{
+	"index": 0,
+	"id": "fake-uuid",
+	"az": "z1",
+	"bootstrap": true,
+	"job": {"name": "fake-job"},
+	"deployment": "fake-deployment",
+	"address": "10.0.0.5",
+	"networks": {"default": {"ip": "10.0.0.5", "netmask": "255.255.255.0", "gateway": "10.0.0.1"}},
+	"global_properties": {"global": {"only": "from-global"}, "shared": {"value": "from-global"}},
+	"cluster_properties": {"shared": {"value": "from-cluster"}, "port": 8080},
+	"job_properties": null,
+	"default_properties": {
+		"global.only": null,
+		"shared.value": null,
+		"port": 80,
+		"missing": null,
+		"with_default": "default-value",
+		"users": [{"name": "admin", "groups": ["a", "b"]}, {"name": "reader", "groups": []}],
+		"limits": {"cpu": 2, "memory": 1.5},
+		"motd": "line one\nline two",
+		"enabled": false
+	},
+	"links": {
+		"db": {
+			"address": "db.bosh",
+			"properties": {"db": {"port": 5432}},
+			"instances": [{"name": "db", "index": 0, "address": "10.0.0.6"}]
+		}
+	}
+}`)
+
+// renderTemplate renders template with the native renderer. When the template
+// is passed on to the Ruby renderer instead, the returned error explains why.
+func renderTemplate(template string) (string, error) {
+	fs := fakesys.NewFakeFileSystem()
+
+	err := fs.WriteFileString("/fake-src-path", template)
+	Expect(err).ToNot(HaveOccurred())
+
+	fallback := fakebierbrenderer.NewFakeERBRender()
+
+	err = fallback.SetRenderBehavior("/fake-src-path", "/fake-dst-path", nativeRendererContext, errors.New("fake-ruby-error"))
+	Expect(err).ToNot(HaveOccurred())
+
+	erbRenderer := NewNativeERBRenderer(fs, fallback, boshlog.NewLogger(boshlog.LevelNone))
+
+	err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+	if err != nil {
+		return "", err
+	}
+
+	return fs.ReadFileString("/fake-dst-path")
+}
+
+// expectNativeResult is used by tables of templates that render natively
+// just like they render with Ruby.
+func expectNativeResult(template, expected string) {
+	result, err := renderTemplate(template)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(result).To(Equal(expected))
+}
+
+// expectNativeError is used by tables of templates that raise natively just
+// like they raise with Ruby.
+func expectNativeError(template, expected string) {
+	_, err := renderTemplate(template)
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(HavePrefix("Error filling in template"))
+	Expect(err.Error()).To(ContainSubstring(expected))
+}
+
+// expectRubyFallback is used by tables of templates that need Ruby.
+func expectRubyFallback(template, reason string) {
+	_, err := renderTemplate(template)
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("not supported natively"))
+	Expect(err.Error()).To(ContainSubstring(reason))
+}
+
+var _ = Describe("NativeERBRenderer", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		fallback    *fakebierbrenderer.FakeERBRenderer
+		erbRenderer ERBRenderer
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		fallback = fakebierbrenderer.NewFakeERBRender()
+
+		erbRenderer = NewNativeERBRenderer(fs, fallback, logger)
+	})
+
+	render := func(template string) (string, error) {
+		err := fs.WriteFileString("/fake-src-path", template)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+		if err != nil {
+			return "", err
+		}
+
+		return fs.ReadFileString("/fake-dst-path")
+	}
+
+	DescribeTable("rendering templates",
+		func(template, expected string) {
+			result, err := render(template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(expected))
+			Expect(fallback.RenderInputs).To(BeEmpty())
+		},
+		Entry("plain text", "just text\n", "just text\n"),
+		Entry("escaped tags", "<%% not code %>", "<% not code %>"),
+		Entry("comments", "a<%# comment %>b", "ab"),
+		Entry("cluster properties over global ones", "<%= p('shared.value') %>", "from-cluster"),
+		Entry("global properties", "<%= p('global.only') %>", "from-global"),
+		Entry("spec defaults", "<%= p('port') %>/<%= p('with_default') %>", "8080/default-value"),
+		Entry("p with a default", "<%= p('missing', 'fallback') %>", "fallback"),
+		Entry("p with alternative names", "<%= p(['missing', 'port']) %>", "8080"),
+		Entry("false properties", "<%= p('enabled') %>", "false"),
+		Entry("floats", "<%= p('limits.memory') * 2 %> <%= 10 / 4 %> <%= 10.0 / 4 %>", "3.0 2 2.5"),
+		Entry("spec", "<%= spec.deployment %> <%= spec.networks.default.ip %> <%= spec.job.name %> <%= spec.bootstrap %>",
+			"fake-deployment 10.0.0.5 fake-job true"),
+		Entry("name and index", "<%= name %>/<%= index %>", "fake-job/0"),
+		Entry("if_p with a set property", "<% if_p('port') do |port| %>port=<%= port %><% end %>", "port=8080"),
+		Entry("if_p with an unset property and else",
+			"<% if_p('missing') do |m| %>set<% end.else do %>unset<% end %>", "unset"),
+		Entry("else_if_p",
+			"<% if_p('missing') do %>a<% end.else_if_p('port', 'with_default') do |port, d| %><%= port %>-<%= d %><% end %>",
+			"8080-default-value"),
+		Entry("trim mode",
+			"<%- p('users').each do |user| -%>\n- <%= user['name'] %>\n<%- end -%>\n",
+			"- admin\n- reader\n"),
+		Entry("loops with indexes and joins",
+			"<% p('users').each_with_index do |user, i| %><%= i %>:<%= user['name'] %>(<%= user['groups'].join(',') %>) <% end %>",
+			"0:admin(a,b) 1:reader() "),
+		Entry("map with symbol blocks", "<%= p('users').map { |u| u['name'] }.map(&:upcase).sort.reverse.join(' ') %>", "READER ADMIN"),
+		Entry("hashes", `<% h = {"b" => 1, a: 2} %><%= h %> <%= h.keys.inspect %> <%= h.to_a.flatten.length %>`,
+			`{"b"=>1, :a=>2} ["b", :a] 4`),
+		Entry("hash iteration", "<% p('limits').sort.each do |k, v| %><%= k %>=<%= v %>;<% end %>", "cpu=2;memory=1.5;"),
+		Entry("string helpers", `<%= "  Mixed Case  ".strip.downcase.gsub(/\s+/, '-').sub('case', 'CASE') %>`, "mixed-CASE"),
+		Entry("string interpolation", `<%= "port #{p('port') + 1} on #{spec.address}" %>`, "port 8081 on 10.0.0.5"),
+		Entry("split and regexps", `<%= "a,b,,c".split(",").inspect %> <%= "x1y22" =~ /\d+/ %> <%= "v1.2"[/\d+\.\d+/] %>`,
+			`["a", "b", "", "c"] 1 1.2`),
+		Entry("conditionals", "<% if p('port') > 1024 && !p('enabled') %>high<% elsif p('port') == 80 %>80<% else %>low<% end %>", "high"),
+		Entry("unless and ternaries", "<%= 'on' unless p('enabled') %> <%= p('enabled') ? 'yes' : 'no' %>", "on no"),
+		Entry("case statements", "<% case p('port') when 1..1024 then %>low<% when Integer %>int<% end %>", "int"),
+		Entry("local variable updates in blocks",
+			"<% total = 0 %><% [1, 2, 3].each { |n| total += n } %><%= total %>", "6"),
+		Entry("next and break",
+			"<% [1, 2, 3, 4].each do |n| next if n.even?; break if n > 2 %><%= n %><% end %>", "1"),
+		Entry("string appends", `<% s = "a" %><% s << "b" %><%= s %>`, "ab"),
+		Entry("JSON", `<%= JSON.dump(p('limits')) %> <%= p('users').first.to_json %> <%= JSON.dump("a\"b") %>`,
+			`{"cpu":2,"memory":1.5} {"name":"admin","groups":["a","b"]} "a\"b"`),
+		Entry("pretty JSON", "<%= JSON.pretty_generate({'a' => [1], 'b' => {}}) %>",
+			"{\n  \"a\": [\n    1\n  ],\n  \"b\": {}\n}"),
+		Entry("YAML", "<%= p('users').to_yaml %>",
+			"---\n- name: admin\n  groups:\n  - a\n  - b\n- name: reader\n  groups: []\n"),
+		Entry("YAML quoting", "<%= {'a' => 'true', 'b' => '/path', 'c' => '', 'd' => 'plain text', 'e' => p('motd')}.to_yaml %>",
+			"---\na: 'true'\nb: \"/path\"\nc: ''\nd: plain text\ne: |-\n  line one\n  line two\n"),
+		Entry("format", "<%= format('%05.1f|%-4s|%d', 3.14159, 'ab', 7) %> <%= '%s-%s' % ['a', 1] %>", "003.1|ab  |7 a-1"),
+		Entry("links", "<%= link('db').p('db.port') %> <%= link('db').address %> <%= link('db').instances.map(&:address).join %>",
+			"5432 db.bosh 10.0.0.6"),
+		Entry("if_link", "<% if_link('db') do |db| %><%= db.address %><% end %><% if_link('cache') do %>x<% end.else do %>no cache<% end %>",
+			"db.boshno cache"),
+		Entry("ranges", "<%= (1..3).map { |n| n * 2 }.inspect %> <%= (1...3).to_a.sum %> <%= [4, 5, 6][1..-1].inspect %>",
+			"[2, 4, 6] 3 [5, 6]"),
+		Entry("multiline code", "<%\n  ports = [80, 443]\n  names = ports.map do |port|\n    \"port-#{port}\"\n  end\n%><%= names.join(', ') %>",
+			"port-80, port-443"),
+	)
+
+	DescribeTable("raising errors like the Ruby renderer",
+		func(template, expected string) {
+			_, err := render(template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(expected))
+			Expect(fallback.RenderInputs).To(BeEmpty())
+		},
+		Entry("unknown properties", "line\n<%= p('missing') %>",
+			"Error filling in template '/fake-src-path' for fake-job/0 (line 2: #<TemplateEvaluationContext::UnknownProperty: Can't find property 'missing'>)"),
+		Entry("unknown alternative properties", "<%= p(['missing', 'other']) %>",
+			"Error filling in template '/fake-src-path' for fake-job/0 (line 1: #<TemplateEvaluationContext::UnknownProperty: Can't find property 'missing', or 'other'>)"),
+		Entry("raise", "\n\n<% raise 'invalid configuration' %>",
+			"Error filling in template '/fake-src-path' for fake-job/0 (line 3: #<RuntimeError: invalid configuration>)"),
+		Entry("methods called on nil", "<%= p('missing', nil).upcase %>",
+			"Error filling in template '/fake-src-path' for fake-job/0 (line 1: #<NoMethodError: undefined method `upcase' for nil:NilClass>)"),
+		Entry("unknown links", "<%= link('cache').address %>",
+			"Error filling in template '/fake-src-path' for fake-job/0 (line 1: #<TemplateEvaluationContext::UnknownLink: Can't find link 'cache'>)"),
+	)
+
+	Context("when the template uses Ruby that is not supported natively", func() {
+		template := "<%= File.read('/etc/hostname') %>"
+
+		BeforeEach(func() {
+			err := fs.WriteFileString("/fake-src-path", template)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("renders the template with the fallback renderer", func() {
+			err := fallback.SetRenderBehavior("/fake-src-path", "/fake-dst-path", nativeRendererContext, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fallback.RenderInputs).To(HaveLen(1))
+			Expect(fs.FileExists("/fake-dst-path")).To(BeFalse())
+		})
+
+		It("passes links on to the fallback renderer", func() {
+			err := fs.WriteFileString("/fake-src-path", "<%= link('db').address %> <%= File.read('/etc/hostname') %>")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fallback.SetRenderBehavior("/fake-src-path", "/fake-dst-path", nativeRendererContext, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fallback.RenderInputs).To(HaveLen(1))
+
+			contextJSON, err := fallback.RenderInputs[0].Context.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contextJSON)).To(ContainSubstring(`"address": "db.bosh"`))
+		})
+
+		It("returns an error that explains why the fallback was used when it fails", func() {
+			err := fallback.SetRenderBehavior("/fake-src-path", "/fake-dst-path", nativeRendererContext, errors.New("fake-ruby-error"))
+			Expect(err).ToNot(HaveOccurred())
+
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-ruby-error"))
+			Expect(err.Error()).To(ContainSubstring("constant 'File' is not supported"))
+		})
+	})
+
+	Context("when properties have integers that do not fit into 64 bits", func() {
+		bigIntegerContext := jsonContext(`{"index": 0, "default_properties": {"big": 12345678901234567890, "float": 1.5e20}}`)
+
+		BeforeEach(func() {
+			err := fs.WriteFileString("/fake-src-path", "<%= p('big') %>")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("renders the template with the fallback renderer instead of rendering them as floats", func() {
+			err := fallback.SetRenderBehavior("/fake-src-path", "/fake-dst-path", bigIntegerContext, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", bigIntegerContext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fallback.RenderInputs).To(HaveLen(1))
+			Expect(fs.FileExists("/fake-dst-path")).To(BeFalse())
+		})
+
+		It("returns an error that explains why the fallback was used when it fails", func() {
+			err := fallback.SetRenderBehavior("/fake-src-path", "/fake-dst-path", bigIntegerContext, errors.New("fake-ruby-error"))
+			Expect(err).ToNot(HaveOccurred())
+
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", bigIntegerContext)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("integers that do not fit into 64 bits are not supported"))
+		})
+	})
+
+	Context("when reading the template fails", func() {
+		It("returns an error", func() {
+			err := fs.WriteFileString("/fake-src-path", "text")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.ReadFileError = errors.New("fake-read-error")
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+		})
+	})
+
+	Context("when writing the result fails", func() {
+		It("returns an error", func() {
+			err := fs.WriteFileString("/fake-src-path", "text")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileError = errors.New("fake-write-error")
+			err = erbRenderer.Render("/fake-src-path", "/fake-dst-path", nativeRendererContext)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+})
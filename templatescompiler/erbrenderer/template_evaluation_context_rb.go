@@ -21,7 +21,25 @@ class Hash
   end
 end
 
+module PropertyHelper
+  private
+
+  def lookup_property(collection, name)
+    keys = name.split(".")
+    ref = collection
+
+    keys.each do |key|
+      ref = ref[key]
+      return nil if ref.nil?
+    end
+
+    ref
+  end
+end
+
 class TemplateEvaluationContext
+  include PropertyHelper
+
   attr_reader :name, :index
   attr_reader :properties, :raw_properties
   attr_reader :spec
@@ -44,6 +62,7 @@ class TemplateEvaluationContext
     @properties = openstruct(properties)
     @raw_properties = properties
     @spec = openstruct(spec)
+    @links = spec['links'] || {}
   end
 
   def get_binding
@@ -73,8 +92,19 @@ class TemplateEvaluationContext
     InactiveElseBlock.new
   end
 
+  def link(name)
+    link_spec = @links[name]
+    raise UnknownLink.new(name) if link_spec.nil?
+
+    EvaluationLink.new(name, link_spec)
+  end
+
   def if_link(name)
-    false
+    link_spec = @links[name]
+    return ActiveElseBlock.new(self) if link_spec.nil?
+
+    yield EvaluationLink.new(name, link_spec)
+    InactiveElseBlock.new
   end
 
   private
@@ -110,18 +140,6 @@ class TemplateEvaluationContext
     end
   end
 
-  def lookup_property(collection, name)
-    keys = name.split(".")
-    ref = collection
-
-    keys.each do |key|
-      ref = ref[key]
-      return nil if ref.nil?
-    end
-
-    ref
-  end
-
   class UnknownProperty < StandardError
     attr_reader :name
 
@@ -131,6 +149,51 @@ class TemplateEvaluationContext
     end
   end
 
+  class UnknownLink < StandardError
+    attr_reader :name
+
+    def initialize(name)
+      @name = name
+      super("Can't find link '#{name}'")
+    end
+  end
+
+  class EvaluationLink
+    include PropertyHelper
+
+    attr_reader :name, :address, :instances, :properties
+
+    def initialize(name, link_spec)
+      @name = name
+      @address = link_spec['address']
+      @properties = link_spec['properties'] || {}
+      @instances = (link_spec['instances'] || []).map { |instance| OpenStruct.new(instance) }
+    end
+
+    def p(*args)
+      names = Array(args[0])
+
+      names.each do |name|
+        result = lookup_property(@properties, name)
+        return result unless result.nil?
+      end
+
+      return args[1] if args.length == 2
+      raise UnknownProperty.new(names)
+    end
+
+    def if_p(*names)
+      values = names.map do |name|
+        value = lookup_property(@properties, name)
+        return ActiveElseBlock.new(self) if value.nil?
+        value
+      end
+
+      yield *values
+      InactiveElseBlock.new
+    end
+  end
+
   class ActiveElseBlock
     def initialize(template)
       @context = template
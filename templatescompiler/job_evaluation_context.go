@@ -16,6 +16,7 @@ type jobEvaluationContext struct {
 	releaseJobProperties *biproperty.Map
 	jobProperties        biproperty.Map
 	globalProperties     biproperty.Map
+	links                map[string]LinkContext
 	deploymentName       string
 	address              string
 	uuidGen              boshuuid.Generator
@@ -42,6 +43,24 @@ type RootContext struct {
 	ClusterProperties biproperty.Map  `json:"cluster_properties"` // values from instance group (deployment job) properties
 	JobProperties     *biproperty.Map `json:"job_properties"`     // values from release job (aka template) properties
 	DefaultProperties biproperty.Map  `json:"default_properties"` // values from release's job's spec
+
+	Links map[string]LinkContext `json:"links,omitempty"` // links consumed by the job, by name
+}
+
+// LinkContext is a link that templates access with link() and if_link().
+type LinkContext struct {
+	Address    string                `json:"address,omitempty"`
+	Properties biproperty.Map        `json:"properties"`
+	Instances  []LinkInstanceContext `json:"instances"`
+}
+
+type LinkInstanceContext struct {
+	Name      string `json:"name"`
+	ID        string `json:"id"`
+	Index     int    `json:"index"`
+	AZ        string `json:"az"`
+	Address   string `json:"address"`
+	Bootstrap bool   `json:"bootstrap"`
 }
 
 type jobContext struct {
@@ -59,6 +78,7 @@ func NewJobEvaluationContext(
 	releaseJobProperties *biproperty.Map,
 	jobProperties biproperty.Map,
 	globalProperties biproperty.Map,
+	links map[string]LinkContext,
 	deploymentName string,
 	address string,
 	uuidGen boshuuid.Generator,
@@ -69,6 +89,7 @@ func NewJobEvaluationContext(
 		releaseJobProperties: releaseJobProperties,
 		jobProperties:        jobProperties,
 		globalProperties:     globalProperties,
+		links:                links,
 		deploymentName:       deploymentName,
 		address:              address,
 		uuidGen:              uuidGen,
@@ -92,6 +113,7 @@ func (ec jobEvaluationContext) MarshalJSON() ([]byte, error) {
 		ClusterProperties: ec.jobProperties,
 		JobProperties:     ec.releaseJobProperties,
 		DefaultProperties: defaultProperties,
+		Links:             ec.links,
 	}
 
 	if len(ec.address) > 0 {
@@ -26,6 +26,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		jobProperties           *biproperty.Map
 		instanceGroupProperties biproperty.Map
 		deploymentProperties    biproperty.Map
+		links                   map[string]LinkContext
 		erbRenderer             erbrenderer.ERBRenderer
 		jobEvaluationContext    bierbrenderer.TemplateEvaluationContext
 		uuidGen                 *fakeuuid.FakeGenerator
@@ -48,6 +49,7 @@ var _ = Describe("JobEvaluationContext", func() {
 
 		uuidGen = fakeuuid.NewFakeGenerator()
 		jobProperties = nil
+		links = nil
 	})
 
 	JustBeforeEach(func() {
@@ -58,6 +60,7 @@ var _ = Describe("JobEvaluationContext", func() {
 			jobProperties,
 			instanceGroupProperties,
 			deploymentProperties,
+			links,
 			"fake-deployment-name",
 			"1.2.3.4",
 			uuidGen,
@@ -102,6 +105,30 @@ var _ = Describe("JobEvaluationContext", func() {
 		generatedContext := act()
 		Expect(generatedContext.Bootstrap).To(Equal(true))
 	})
+
+	It("it has no links by default", func() {
+		generatedContext := act()
+		Expect(generatedContext.Links).To(BeEmpty())
+	})
+
+	Context("when links are given", func() {
+		BeforeEach(func() {
+			links = map[string]LinkContext{
+				"db": {
+					Address:    "db.bosh",
+					Properties: biproperty.Map{"port": 5432},
+					Instances:  []LinkInstanceContext{{Name: "db", Index: 0, Address: "10.0.0.6"}},
+				},
+			}
+		})
+
+		It("it has links available in the spec", func() {
+			generatedContext := act()
+			Expect(generatedContext.Links["db"].Address).To(Equal("db.bosh"))
+			Expect(generatedContext.Links["db"].Properties).To(Equal(biproperty.Map{"port": float64(5432)}))
+			Expect(generatedContext.Links["db"].Instances).To(Equal([]LinkInstanceContext{{Name: "db", Index: 0, Address: "10.0.0.6"}}))
+		})
+	})
 	Context("when the UUID generator raise an error", func() {
 		It("it raises an error", func() {
 			uuidGen.GenerateError = errors.Error("boom")
@@ -115,7 +142,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fs := boshsys.NewOsFileSystem(logger)
 		commandRunner := boshsys.NewExecCmdRunner(logger)
-		erbRenderer = erbrenderer.NewERBRenderer(fs, commandRunner, logger)
+		erbRenderer = erbrenderer.NewNativeERBRenderer(fs, erbrenderer.NewERBRenderer(fs, commandRunner, logger), logger)
 
 		srcFile, err := os.CreateTemp("", "source.txt.erb")
 		Expect(err).ToNot(HaveOccurred())
@@ -136,6 +163,7 @@ var _ = Describe("JobEvaluationContext", func() {
 			jobProperties,
 			instanceGroupProperties,
 			deploymentProperties,
+			links,
 			"fake-deployment-name",
 			"1.2.3.4",
 			uuidGen,
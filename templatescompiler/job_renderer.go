@@ -15,6 +15,7 @@ import (
 
 type JobRenderer interface {
 	Render(releaseJob bireljob.Job, releaseJobProperties *biproperty.Map, jobProperties biproperty.Map, globalProperties biproperty.Map, deploymentName string, address string) (RenderedJob, error)
+	RenderWithLinks(releaseJob bireljob.Job, releaseJobProperties *biproperty.Map, jobProperties biproperty.Map, globalProperties biproperty.Map, links map[string]LinkContext, deploymentName string, address string) (RenderedJob, error)
 }
 
 type jobRenderer struct {
@@ -41,7 +42,11 @@ func NewJobRenderer(
 }
 
 func (r *jobRenderer) Render(releaseJob bireljob.Job, releaseJobProperties *biproperty.Map, jobProperties biproperty.Map, globalProperties biproperty.Map, deploymentName string, address string) (RenderedJob, error) {
-	context := NewJobEvaluationContext(releaseJob, releaseJobProperties, jobProperties, globalProperties, deploymentName, address, r.uuidGen, r.logger)
+	return r.RenderWithLinks(releaseJob, releaseJobProperties, jobProperties, globalProperties, nil, deploymentName, address)
+}
+
+func (r *jobRenderer) RenderWithLinks(releaseJob bireljob.Job, releaseJobProperties *biproperty.Map, jobProperties biproperty.Map, globalProperties biproperty.Map, links map[string]LinkContext, deploymentName string, address string) (RenderedJob, error) {
+	context := NewJobEvaluationContext(releaseJob, releaseJobProperties, jobProperties, globalProperties, links, deploymentName, address, r.uuidGen, r.logger)
 
 	sourcePath := releaseJob.ExtractedPath()
 
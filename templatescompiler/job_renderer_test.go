@@ -29,6 +29,7 @@ var _ = Describe("JobRenderer", func() {
 		globalProperties     biproperty.Map
 		srcPath              string
 		dstPath              string
+		logger               boshlog.Logger
 	)
 
 	BeforeEach(func() {
@@ -54,9 +55,9 @@ var _ = Describe("JobRenderer", func() {
 			"director.yml.erb": "config/director.yml",
 		}
 
-		logger := boshlog.NewLogger(boshlog.LevelNone)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		context = NewJobEvaluationContext(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4", nil, logger)
+		context = NewJobEvaluationContext(*job, &releaseJobProperties, jobProperties, globalProperties, nil, "fake-deployment-name", "1.2.3.4", nil, logger)
 
 		fakeERBRenderer = fakebirender.NewFakeERBRender()
 
@@ -121,4 +122,30 @@ var _ = Describe("JobRenderer", func() {
 			})
 		})
 	})
+
+	Describe("RenderWithLinks", func() {
+		It("renders job templates with links in the context", func() {
+			links := map[string]LinkContext{"db": {Address: "db.bosh"}}
+			linksContext := NewJobEvaluationContext(*job, &releaseJobProperties, jobProperties, globalProperties, links, "fake-deployment-name", "1.2.3.4", nil, logger)
+
+			_ = fakeERBRenderer.SetRenderBehavior(filepath.Join(srcPath, "templates/director.yml.erb"), filepath.Join(dstPath, "config/director.yml"), linksContext, nil)
+			_ = fakeERBRenderer.SetRenderBehavior(filepath.Join(srcPath, "monit"), filepath.Join(dstPath, "monit"), linksContext, nil)
+
+			renderedjob, err := jobRenderer.RenderWithLinks(*job, &releaseJobProperties, jobProperties, globalProperties, links, "fake-deployment-name", "1.2.3.4")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeERBRenderer.RenderInputs).To(Equal([]fakebirender.RenderInput{
+				{
+					SrcPath: filepath.Join(srcPath, "templates/director.yml.erb"),
+					DstPath: filepath.Join(renderedjob.Path(), "config/director.yml"),
+					Context: linksContext,
+				},
+				{
+					SrcPath: filepath.Join(srcPath, "monit"),
+					DstPath: filepath.Join(renderedjob.Path(), "monit"),
+					Context: linksContext,
+				},
+			}))
+		})
+	})
 })
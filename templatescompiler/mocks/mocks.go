@@ -51,6 +51,21 @@ func (mr *MockJobRendererMockRecorder) Render(arg0, arg1, arg2, arg3, arg4, arg5
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockJobRenderer)(nil).Render), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// RenderWithLinks mocks base method.
+func (m *MockJobRenderer) RenderWithLinks(arg0 job.Job, arg1 *property.Map, arg2, arg3 property.Map, arg4 map[string]templatescompiler.LinkContext, arg5, arg6 string) (templatescompiler.RenderedJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderWithLinks", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(templatescompiler.RenderedJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderWithLinks indicates an expected call of RenderWithLinks.
+func (mr *MockJobRendererMockRecorder) RenderWithLinks(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderWithLinks", reflect.TypeOf((*MockJobRenderer)(nil).RenderWithLinks), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
 // MockJobListRenderer is a mock of JobListRenderer interface.
 type MockJobListRenderer struct {
 	ctrl     *gomock.Controller
@@ -37,3 +37,25 @@ func (r BlobsReporter) BlobUploadFinished(path, blobID string, err error) {
 		r.ui.BeginLinef("Blob upload '%s' (id: %s) finished\n", path, blobID)
 	}
 }
+
+func (r BlobsReporter) BlobUploadSent(path string, sentSize, size int64) {
+	var percent int64
+
+	if size > 0 {
+		percent = sentSize * 100 / size
+	}
+
+	r.ui.BeginLinef("Blob upload '%s': %s of %s (%d%%)\n",
+		path, humanize.Bytes(uint64(sentSize)), humanize.Bytes(uint64(size)), percent)
+}
+
+func (r BlobsReporter) BlobUploadProgress(uploadedSize, totalSize int64) {
+	var percent int64
+
+	if totalSize > 0 {
+		percent = uploadedSize * 100 / totalSize
+	}
+
+	r.ui.BeginLinef("Blobs uploaded: %s of %s (%d%%)\n",
+		humanize.Bytes(uint64(uploadedSize)), humanize.Bytes(uint64(totalSize)), percent)
+}
@@ -59,4 +59,23 @@ var _ = Describe("BlobsReporter", func() {
 			Expect(ui.Said).To(Equal([]string{"Blob upload 'path' (id: blob-id) finished\n"}))
 		})
 	})
+
+	Describe("BlobUploadSent", func() {
+		It("prints bytes sent of a blob", func() {
+			reporter.BlobUploadSent("path", 250, 1000)
+			Expect(ui.Said).To(Equal([]string{"Blob upload 'path': 250 B of 1.0 kB (25%)\n"}))
+		})
+	})
+
+	Describe("BlobUploadProgress", func() {
+		It("prints combined size of uploaded blobs", func() {
+			reporter.BlobUploadProgress(250, 1000)
+			Expect(ui.Said).To(Equal([]string{"Blobs uploaded: 250 B of 1.0 kB (25%)\n"}))
+		})
+
+		It("prints no percentage of empty blobs", func() {
+			reporter.BlobUploadProgress(0, 0)
+			Expect(ui.Said).To(Equal([]string{"Blobs uploaded: 0 B of 0 B (0%)\n"}))
+		})
+	})
 })
@@ -0,0 +1,21 @@
+package ui
+
+type BlobstoreMigrationReporter struct {
+	ui UI
+}
+
+func NewBlobstoreMigrationReporter(ui UI) BlobstoreMigrationReporter {
+	return BlobstoreMigrationReporter{ui: ui}
+}
+
+func (r BlobstoreMigrationReporter) BlobMigrationStarted(desc, blobID string) {
+	r.ui.BeginLinef("Blob migration '%s' (id: %s) started\n", desc, blobID)
+}
+
+func (r BlobstoreMigrationReporter) BlobMigrationFinished(desc, blobID, newBlobID string, err error) {
+	if err != nil {
+		r.ui.ErrorLinef("Blob migration '%s' (id: %s) failed", desc, blobID)
+	} else {
+		r.ui.BeginLinef("Blob migration '%s' (id: %s -> %s) finished\n", desc, blobID, newBlobID)
+	}
+}
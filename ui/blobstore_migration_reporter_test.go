@@ -0,0 +1,42 @@
+package ui_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+)
+
+var _ = Describe("BlobstoreMigrationReporter", func() {
+	var (
+		ui       *fakeui.FakeUI
+		reporter BlobstoreMigrationReporter
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		reporter = NewBlobstoreMigrationReporter(ui)
+	})
+
+	Describe("BlobMigrationStarted", func() {
+		It("prints migration msg", func() {
+			reporter.BlobMigrationStarted("desc", "blob-id")
+			Expect(ui.Said).To(Equal([]string{"Blob migration 'desc' (id: blob-id) started\n"}))
+		})
+	})
+
+	Describe("BlobMigrationFinished", func() {
+		It("prints failed migration msg", func() {
+			reporter.BlobMigrationFinished("desc", "blob-id", "", errors.New("err"))
+			Expect(ui.Errors).To(Equal([]string{"Blob migration 'desc' (id: blob-id) failed"}))
+		})
+
+		It("prints finished migration msg", func() {
+			reporter.BlobMigrationFinished("desc", "blob-id", "new-blob-id", nil)
+			Expect(ui.Said).To(Equal([]string{"Blob migration 'desc' (id: blob-id -> new-blob-id) finished\n"}))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"io"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	. "github.com/cloudfoundry/bosh-cli/v7/ui/table"
@@ -48,6 +50,11 @@ func (ui *ConfUI) EnableJSON() {
 	ui.parent = NewJSONUI(ui.parent, ui.logger)
 }
 
+// EnableJSONEvents writes stage events to writer as they happen.
+func (ui *ConfUI) EnableJSONEvents(writer io.Writer) {
+	ui.parent = NewJSONEventsUI(ui.parent, writer, ui.logger)
+}
+
 func (ui *ConfUI) ShowColumns(columns []Header) {
 	ui.showColumns = columns
 }
@@ -80,6 +87,10 @@ func (ui *ConfUI) PrintErrorBlock(block string) {
 	ui.parent.PrintErrorBlock(block)
 }
 
+func (ui *ConfUI) PrintEvent(event StageEvent) {
+	printEvent(ui.parent, event)
+}
+
 func (ui *ConfUI) PrintTable(table Table) {
 	if len(ui.showColumns) > 0 {
 		err := table.SetColumnVisibility(ui.showColumns)
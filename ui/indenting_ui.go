@@ -38,6 +38,10 @@ func (ui *indentingUI) PrintErrorBlock(block string) {
 	ui.parent.PrintErrorBlock(block)
 }
 
+func (ui *indentingUI) PrintEvent(event StageEvent) {
+	printEvent(ui.parent, event)
+}
+
 func (ui *indentingUI) PrintTable(table Table) {
 	ui.parent.PrintTable(table)
 }
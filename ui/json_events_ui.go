@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/ui/table"
+)
+
+// jsonEventsUI writes each stage event to writer as one JSON object per line
+// as soon as it happens, so that progress can be followed while a command runs.
+type jsonEventsUI struct {
+	parent UI
+	writer io.Writer
+	lock   sync.Mutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewJSONEventsUI(parent UI, writer io.Writer, logger boshlog.Logger) UI {
+	return &jsonEventsUI{parent: parent, writer: writer, logTag: "JSONEventsUI", logger: logger}
+}
+
+func (ui *jsonEventsUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.parent.ErrorLinef(pattern, args...)
+}
+
+func (ui *jsonEventsUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.parent.PrintLinef(pattern, args...)
+}
+
+func (ui *jsonEventsUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.parent.BeginLinef(pattern, args...)
+}
+
+func (ui *jsonEventsUI) EndLinef(pattern string, args ...interface{}) {
+	ui.parent.EndLinef(pattern, args...)
+}
+
+func (ui *jsonEventsUI) PrintBlock(block []byte) {
+	ui.parent.PrintBlock(block)
+}
+
+func (ui *jsonEventsUI) PrintErrorBlock(block string) {
+	ui.parent.PrintErrorBlock(block)
+}
+
+func (ui *jsonEventsUI) PrintEvent(event StageEvent) {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		ui.logger.Error(ui.logTag, "Failed to marshal stage event: %s", err)
+	} else {
+		ui.lock.Lock()
+		_, err = ui.writer.Write(append(bytes, '\n'))
+		ui.lock.Unlock()
+
+		if err != nil {
+			ui.logger.Error(ui.logTag, "Failed to write stage event: %s", err)
+		}
+	}
+
+	printEvent(ui.parent, event)
+}
+
+func (ui *jsonEventsUI) PrintTable(table Table) {
+	ui.parent.PrintTable(table)
+}
+
+func (ui *jsonEventsUI) PrintTableFiltered(table Table, filterHeader []Header) {
+	ui.parent.PrintTableFiltered(table, filterHeader)
+}
+
+func (ui *jsonEventsUI) AskForText(label string) (string, error) {
+	return ui.parent.AskForText(label)
+}
+
+func (ui *jsonEventsUI) AskForChoice(label string, options []string) (int, error) {
+	return ui.parent.AskForChoice(label, options)
+}
+
+func (ui *jsonEventsUI) AskForPassword(label string) (string, error) {
+	return ui.parent.AskForPassword(label)
+}
+
+func (ui *jsonEventsUI) AskForConfirmation() error {
+	return ui.parent.AskForConfirmation()
+}
+
+func (ui *jsonEventsUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+func (ui *jsonEventsUI) Flush() {
+	ui.parent.Flush()
+}
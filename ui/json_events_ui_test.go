@@ -0,0 +1,79 @@
+package ui_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/v7/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/v7/ui/fakes"
+)
+
+var _ = Describe("JSONEventsUI", func() {
+	var (
+		parentUI *fakeui.FakeUI
+		events   *bytes.Buffer
+		ui       UI
+	)
+
+	BeforeEach(func() {
+		parentUI = &fakeui.FakeUI{}
+		events = &bytes.Buffer{}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		ui = NewJSONEventsUI(NewJSONUI(parentUI, logger), events, logger)
+	})
+
+	Describe("PrintEvent", func() {
+		It("writes each event as a line of JSON before output is flushed", func() {
+			startTime := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			ui.(EventUI).PrintEvent(StageEvent{Time: startTime, Stage: "fake-stage", State: StageEventStarted})
+
+			Expect(events.String()).To(Equal(`{"time":"2020-01-01T00:00:00Z","stage":"fake-stage","state":"started"}` + "\n"))
+			Expect(parentUI.Blocks).To(BeEmpty())
+
+			ui.(EventUI).PrintEvent(StageEvent{Time: startTime, Stage: "fake-stage", State: StageEventFinished, DurationSeconds: 1.5})
+
+			lines := strings.Split(strings.TrimSuffix(events.String(), "\n"), "\n")
+			Expect(lines).To(HaveLen(2))
+
+			var event StageEvent
+			Expect(json.Unmarshal([]byte(lines[1]), &event)).To(Succeed())
+			Expect(event.State).To(Equal(StageEventFinished))
+			Expect(event.DurationSeconds).To(Equal(1.5))
+		})
+
+		It("keeps events in the single document printed by the JSON UI", func() {
+			ui.(EventUI).PrintEvent(StageEvent{Stage: "fake-stage", State: StageEventStarted})
+			ui.PrintLinef("fake-line")
+			ui.Flush()
+
+			Expect(parentUI.Blocks).To(HaveLen(1))
+
+			var doc struct {
+				Lines  []string
+				Events []StageEvent
+			}
+			Expect(json.Unmarshal([]byte(parentUI.Blocks[0]), &doc)).To(Succeed())
+			Expect(doc.Lines).To(Equal([]string{"fake-line"}))
+			Expect(doc.Events).To(Equal([]StageEvent{{Stage: "fake-stage", State: StageEventStarted}}))
+		})
+	})
+
+	It("passes output through to the parent UI", func() {
+		parent := &fakeui.FakeUI{}
+		ui = NewJSONEventsUI(parent, events, boshlog.NewLogger(boshlog.LevelNone))
+
+		ui.PrintLinef("fake-line")
+		ui.ErrorLinef("fake-error")
+
+		Expect(parent.Said).To(Equal([]string{"fake-line"}))
+		Expect(parent.Errors).To(Equal([]string{"fake-error"}))
+		Expect(events.String()).To(BeEmpty())
+	})
+})
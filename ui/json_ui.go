@@ -23,6 +23,7 @@ type uiResp struct {
 	Tables []tableResp
 	Blocks []string
 	Lines  []string
+	Events []StageEvent `json:",omitempty"`
 }
 
 type tableResp struct {
@@ -60,6 +61,12 @@ func (ui *jsonUI) PrintErrorBlock(block string) {
 	ui.uiResp.Blocks = append(ui.uiResp.Blocks, block)
 }
 
+// PrintEvent collects the event so that it is part of the single document
+// printed on Flush.
+func (ui *jsonUI) PrintEvent(event StageEvent) {
+	ui.uiResp.Events = append(ui.uiResp.Events, event)
+}
+
 func (ui *jsonUI) PrintTable(table Table) {
 	resp := ui.printTableHeader(&table)
 	ui.uiResp.Tables = append(ui.uiResp.Tables, resp)
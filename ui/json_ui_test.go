@@ -35,6 +35,7 @@ var _ = Describe("JSONUI", func() {
 		Tables []tableResp
 		Blocks []string
 		Lines  []string
+		Events []StageEvent
 	}
 
 	finalOutput := func() uiResp {
@@ -100,6 +101,29 @@ var _ = Describe("JSONUI", func() {
 		})
 	})
 
+	Describe("PrintEvent", func() {
+		It("includes in Events of the single printed document", func() {
+			ui.PrintLinef("fake-line1")
+			ui.(EventUI).PrintEvent(StageEvent{Stage: "fake-stage", State: StageEventStarted})
+			ui.(EventUI).PrintEvent(StageEvent{Stage: "fake-stage", State: StageEventFinished})
+
+			Expect(finalOutput()).To(Equal(uiResp{
+				Lines: []string{"fake-line1"},
+				Events: []StageEvent{
+					{Stage: "fake-stage", State: StageEventStarted},
+					{Stage: "fake-stage", State: StageEventFinished},
+				},
+			}))
+			Expect(parentUI.Blocks).To(HaveLen(1))
+		})
+
+		It("omits Events when no stages were performed", func() {
+			ui.PrintLinef("fake-line1")
+			ui.Flush()
+			Expect(parentUI.Blocks[0]).ToNot(ContainSubstring("Events"))
+		})
+	})
+
 	Describe("PrintErrorBlock", func() {
 		It("includes in Blocks", func() {
 			ui.PrintErrorBlock("fake-block1")
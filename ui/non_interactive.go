@@ -37,6 +37,10 @@ func (ui *nonInteractiveUI) PrintErrorBlock(block string) {
 	ui.parent.PrintErrorBlock(block)
 }
 
+func (ui *nonInteractiveUI) PrintEvent(event StageEvent) {
+	printEvent(ui.parent, event)
+}
+
 func (ui *nonInteractiveUI) PrintTable(table Table) {
 	ui.parent.PrintTable(table)
 }
@@ -21,8 +21,12 @@ type stage struct {
 	logger boshlog.Logger
 
 	simpleMode bool
+	parent     string
 }
 
+// NewStage returns a Stage that prints each stage to ui. When ui emits stage
+// events (as the JSON UI does) every stage also emits a started event and a
+// finished, failed or skipped event.
 func NewStage(ui UI, timeService clock.Clock, logger boshlog.Logger) Stage {
 	return &stage{
 		ui:          ui,
@@ -44,17 +48,21 @@ func (s *stage) Perform(name string, closure func() error) error {
 
 	s.ui.BeginLinef("%s...", name)
 	startTime := s.timeService.Now()
+	s.printStarted(name, startTime)
 	err := closure()
 	if err != nil {
 		if skipErr, ok := err.(SkipStageError); ok {
 			s.ui.EndLinef(" Skipped [%s] (%s)", skipErr.SkipMessage(), s.elapsedSince(startTime))
 			s.logger.Info(s.logTag, "Skipped stage '%s': %s", name, skipErr.Error())
+			s.printEnded(name, startTime, StageEvent{State: StageEventSkipped, SkipMessage: skipErr.SkipMessage()})
 			return nil
 		}
 		s.ui.EndLinef(" Failed (%s)", s.elapsedSince(startTime))
+		s.printFailed(name, startTime, err)
 		return err
 	}
 	s.ui.EndLinef(" Finished (%s)", s.elapsedSince(startTime))
+	s.printEnded(name, startTime, StageEvent{State: StageEventFinished})
 	return nil
 }
 
@@ -65,21 +73,46 @@ func (s *stage) PerformComplex(name string, closure func(Stage) error) error {
 
 	s.ui.BeginLinef("Started %s\n", name)
 	startTime := s.timeService.Now()
-	err := closure(s.newSubStage())
+	s.printStarted(name, startTime)
+	err := closure(s.newSubStage(name))
 	if err != nil {
 		s.ui.BeginLinef("Failed %s (%s)\n", name, s.elapsedSince(startTime))
+		s.printFailed(name, startTime, err)
 		return err
 	}
 	s.ui.BeginLinef("Finished %s (%s)\n", name, s.elapsedSince(startTime))
+	s.printEnded(name, startTime, StageEvent{State: StageEventFinished})
 	return nil
 }
 
+func (s *stage) printStarted(name string, startTime time.Time) {
+	printEvent(s.ui, StageEvent{Time: startTime, Stage: name, Parent: s.parent, State: StageEventStarted})
+}
+
+func (s *stage) printFailed(name string, startTime time.Time, err error) {
+	s.printEnded(name, startTime, StageEvent{
+		State:     StageEventFailed,
+		CPIMethod: failedCPIMethod(err),
+		Error:     err.Error(),
+	})
+}
+
+func (s *stage) printEnded(name string, startTime time.Time, event StageEvent) {
+	event.Time = s.timeService.Now()
+	event.Stage = name
+	event.Parent = s.parent
+	event.DurationSeconds = event.Time.Sub(startTime).Seconds()
+	printEvent(s.ui, event)
+}
+
 func (s *stage) elapsedSince(startTime time.Time) string {
 	stopTime := s.timeService.Now()
 	duration := stopTime.Sub(startTime)
 	return biuifmt.Duration(duration)
 }
 
-func (s *stage) newSubStage() Stage {
-	return NewStage(NewIndentingUI(s.ui), s.timeService, s.logger)
+func (s *stage) newSubStage(name string) Stage {
+	subStage := NewStage(NewIndentingUI(s.ui), s.timeService, s.logger).(*stage)
+	subStage.parent = name
+	return subStage
 }
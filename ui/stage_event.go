@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	StageEventStarted  = "started"
+	StageEventFinished = "finished"
+	StageEventFailed   = "failed"
+	StageEventSkipped  = "skipped"
+)
+
+// StageEvent describes a stage starting or ending. Stages nested in a
+// complex stage name it as their Parent.
+type StageEvent struct {
+	Time   time.Time `json:"time"`
+	Stage  string    `json:"stage"`
+	Parent string    `json:"parent,omitempty"`
+	State  string    `json:"state"`
+
+	// DurationSeconds is only set once the stage has ended
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+
+	// CPIMethod is set when a stage failed because a CPI method failed
+	CPIMethod   string `json:"cpi_method,omitempty"`
+	Error       string `json:"error,omitempty"`
+	SkipMessage string `json:"skip_message,omitempty"`
+}
+
+// EventUI is implemented by UIs that record stage events.
+type EventUI interface {
+	PrintEvent(StageEvent)
+}
+
+// printEvent hands the event to ui when it emits stage events; UIs that wrap
+// another UI use it to pass events through.
+func printEvent(ui UI, event StageEvent) {
+	if eventUI, ok := ui.(EventUI); ok {
+		eventUI.PrintEvent(event)
+	}
+}
+
+type cpiMethodError interface {
+	Method() string
+}
+
+func failedCPIMethod(err error) string {
+	for err != nil {
+		if methodErr, ok := err.(cpiMethodError); ok {
+			return methodErr.Method()
+		}
+
+		complexErr, ok := err.(bosherr.ComplexError)
+		if !ok {
+			return ""
+		}
+		err = complexErr.Cause
+	}
+
+	return ""
+}
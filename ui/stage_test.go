@@ -2,6 +2,7 @@ package ui_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -203,4 +204,82 @@ Failed Complex stage 1 (00:01:00)
 			Expect(actionsPerformed).To(Equal([]string{"1"}))
 		})
 	})
+
+	Describe("stage events", func() {
+		var jsonUI UI
+
+		events := func() []StageEvent {
+			jsonUI.Flush()
+
+			var resp struct {
+				Lines  []string
+				Events []StageEvent
+			}
+			Expect(json.Unmarshal(uiOut.Bytes(), &resp)).To(Succeed())
+			return resp.Events
+		}
+
+		BeforeEach(func() {
+			jsonUI = NewJSONUI(ui, logger)
+			stage = NewStage(jsonUI, fakeTimeService, logger)
+		})
+
+		It("emits started and finished events with the duration of each stage", func() {
+			err := stage.PerformComplex("Complex stage 1", func(stage Stage) error {
+				return stage.Perform("Simple stage A", func() error {
+					fakeTimeService.Increment(time.Minute)
+					return nil
+				})
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			emitted := events()
+			Expect(emitted).To(HaveLen(4))
+
+			Expect(emitted[0].Stage).To(Equal("Complex stage 1"))
+			Expect(emitted[0].State).To(Equal(StageEventStarted))
+
+			Expect(emitted[1].Stage).To(Equal("Simple stage A"))
+			Expect(emitted[1].Parent).To(Equal("Complex stage 1"))
+			Expect(emitted[1].State).To(Equal(StageEventStarted))
+
+			Expect(emitted[2].Stage).To(Equal("Simple stage A"))
+			Expect(emitted[2].State).To(Equal(StageEventFinished))
+			Expect(emitted[2].DurationSeconds).To(Equal(float64(60)))
+
+			Expect(emitted[3].Stage).To(Equal("Complex stage 1"))
+			Expect(emitted[3].State).To(Equal(StageEventFinished))
+			Expect(emitted[3].DurationSeconds).To(Equal(float64(60)))
+		})
+
+		It("emits a skipped event with the skip message", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				return NewSkipStageError(bosherr.Error("fake-skip-error"), "fake-skip-message")
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			emitted := events()
+			Expect(emitted[1].State).To(Equal(StageEventSkipped))
+			Expect(emitted[1].SkipMessage).To(Equal("fake-skip-message"))
+		})
+
+		It("emits a failed event with the error and the failed CPI method", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				return bosherr.WrapError(fakeCPIError{method: "create_vm"}, "Creating vm")
+			})
+			Expect(err).To(HaveOccurred())
+
+			emitted := events()
+			Expect(emitted[1].State).To(Equal(StageEventFailed))
+			Expect(emitted[1].CPIMethod).To(Equal("create_vm"))
+			Expect(emitted[1].Error).To(Equal("Creating vm: fake-cpi-error"))
+		})
+	})
 })
+
+type fakeCPIError struct {
+	method string
+}
+
+func (e fakeCPIError) Error() string  { return "fake-cpi-error" }
+func (e fakeCPIError) Method() string { return e.method }